@@ -4,11 +4,13 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 
 	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
 	v1 "github.com/gatewayd-io/gatewayd/api/v1"
 	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/logging"
 	"github.com/gatewayd-io/gatewayd/network"
 	"github.com/gatewayd-io/gatewayd/plugin"
 	"github.com/gatewayd-io/gatewayd/pool"
@@ -25,10 +27,30 @@ type Options struct {
 	GRPCAddress string
 	HTTPAddress string
 	Servers     map[string]*network.Server
+	Proxies     map[string]*network.Proxy
+	HookTracer  *plugin.HookTracer
+	Usage       *network.UsageTracker
+	Maintenance *network.Maintenance
+	// QueryStats and LogTail are the top queries and the tail of the logs shown by
+	// the dashboard, which is served if they are set.
+	QueryStats *network.QueryStats
+	LogTail    *logging.Tail
+	// ErrorStats are the error responses of the databases reported at /errors.
+	ErrorStats *network.ErrorStats
+	// Auth authorizes the requests, and TLSConfig serves the APIs over TLS, if set.
+	Auth      *Authenticator
+	TLSConfig *tls.Config
+	// Reload reloads the configuration from its files.
+	Reload func(ctx context.Context) error
+	// StateFile persists the changes made with the admin API, if set.
+	StateFile string
+	// Querier runs the queries of the query service, which is served if it is set.
+	Querier *network.Querier
 }
 
 type API struct {
 	v1.GatewayDAdminAPIServiceServer
+	v1.UnimplementedGatewayDQueryServiceServer
 
 	Options *Options
 
@@ -165,6 +187,7 @@ func (a *API) GetProxies(context.Context, *emptypb.Empty) (*structpb.Struct, err
 			"available": available,
 			"busy":      busy,
 			"total":     len(available) + len(busy),
+			"queries":   proxy.Queries(),
 		}
 	}
 	proxiesConfig, err := structpb.NewStruct(proxies)
@@ -178,11 +201,20 @@ func (a *API) GetProxies(context.Context, *emptypb.Empty) (*structpb.Struct, err
 func (a *API) GetServers(context.Context, *emptypb.Empty) (*structpb.Struct, error) {
 	servers := make(map[string]interface{}, 0)
 	for name, server := range a.Servers {
+		listeners := make([]interface{}, 0, len(server.Listeners))
+		for _, listener := range server.Listeners {
+			listeners = append(listeners, map[string]interface{}{
+				"network": listener.Network,
+				"address": listener.Address,
+			})
+		}
 		servers[name] = map[string]interface{}{
+			"listeners":    listeners,
 			"network":      server.Network,
 			"address":      server.Address,
 			"status":       uint(server.Status),
 			"tickInterval": server.TickInterval.Nanoseconds(),
+			"goroutines":   server.Goroutines(),
 		}
 	}
 	serversConfig, err := structpb.NewStruct(servers)
@@ -213,6 +213,7 @@ func TestGetProxies(t *testing.T) {
 		assert.Equal(t, 1.0, defaultProxy["total"])
 		assert.NotEmpty(t, defaultProxy["available"])
 		assert.Empty(t, defaultProxy["busy"])
+		assert.Equal(t, 0.0, defaultProxy["queries"])
 	} else {
 		t.Errorf("proxies.default is not found or not a map")
 	}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	v1 "github.com/gatewayd-io/gatewayd/api/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Permission is what a request to the admin API does. Each permission includes the
+// previous ones.
+type Permission uint
+
+const (
+	// PermissionRead reads the status and the configuration.
+	PermissionRead Permission = iota
+	// PermissionOperate changes the state of the running instance, e.g. kills a
+	// connection or enables the maintenance mode.
+	PermissionOperate
+	// PermissionReload reloads the configuration.
+	PermissionReload
+)
+
+// rolePermissions are the highest permissions of the roles.
+var rolePermissions = map[config.APIRole]Permission{
+	config.Viewer:   PermissionRead,
+	config.Operator: PermissionOperate,
+	config.Admin:    PermissionReload,
+}
+
+// Principal is an authenticated client of the admin API.
+type Principal struct {
+	Name string
+	Role config.APIRole
+}
+
+// Can returns true if the role of the principal allows the permission.
+func (p Principal) Can(permission Permission) bool {
+	highest, ok := rolePermissions[p.Role]
+	return ok && permission <= highest
+}
+
+// anonymous is the principal of all the requests if the authentication is disabled.
+var anonymous = Principal{Name: "anonymous", Role: config.Admin}
+
+// Authenticator authenticates the clients of the admin API by their bearer tokens or
+// their TLS client certificates, and authorizes their requests by their roles.
+type Authenticator struct {
+	enabled bool
+	// tokens are the principals by the SHA256 digests of their tokens, which are
+	// compared in constant time.
+	tokens map[[sha256.Size]byte]Principal
+	// clientCerts are the principals by the common names of their certificates.
+	clientCerts map[string]Principal
+}
+
+// NewAuthenticator creates an authenticator of the clients in the configuration.
+func NewAuthenticator(auth config.APIAuth) (*Authenticator, *gerr.GatewayDError) {
+	authenticator := &Authenticator{
+		enabled:     auth.Enabled,
+		tokens:      map[[sha256.Size]byte]Principal{},
+		clientCerts: map[string]Principal{},
+	}
+
+	for _, token := range auth.Tokens {
+		role := config.APIRole(token.Role)
+		if _, ok := rolePermissions[role]; !ok {
+			return nil, gerr.ErrValidationFailed.Wrap(
+				fmt.Errorf("unknown role of the %s API token: %s", token.Name, token.Role))
+		}
+		if token.Token == "" {
+			return nil, gerr.ErrValidationFailed.Wrap(
+				fmt.Errorf("the %s API token is empty", token.Name))
+		}
+		authenticator.tokens[sha256.Sum256([]byte(token.Token))] = Principal{
+			Name: token.Name, Role: role,
+		}
+	}
+
+	for _, clientCert := range auth.ClientCerts {
+		role := config.APIRole(clientCert.Role)
+		if _, ok := rolePermissions[role]; !ok {
+			return nil, gerr.ErrValidationFailed.Wrap(
+				fmt.Errorf("unknown role of the %s client certificate: %s",
+					clientCert.CommonName, clientCert.Role))
+		}
+		authenticator.clientCerts[clientCert.CommonName] = Principal{
+			Name: clientCert.CommonName, Role: role,
+		}
+	}
+
+	return authenticator, nil
+}
+
+// Authorize authenticates the client by the Authorization header or the verified TLS
+// client certificate, and checks that its role allows the permission.
+func (a *Authenticator) Authorize(
+	authorization string, state *tls.ConnectionState, permission Permission,
+) (Principal, *gerr.GatewayDError) {
+	if a == nil || !a.enabled {
+		return anonymous, nil
+	}
+
+	principal, authenticated := a.authenticate(authorization, state)
+	if !authenticated {
+		return principal, gerr.ErrUnauthenticated
+	}
+	if !principal.Can(permission) {
+		return principal, gerr.ErrPermissionDenied
+	}
+	return principal, nil
+}
+
+func (a *Authenticator) authenticate(
+	authorization string, state *tls.ConnectionState,
+) (Principal, bool) {
+	if token, ok := strings.CutPrefix(authorization, "Bearer "); ok {
+		digest := sha256.Sum256([]byte(token))
+		var found Principal
+		authenticated := false
+		// All the tokens are compared, so that the time doesn't tell which one matched.
+		for known, principal := range a.tokens {
+			if subtle.ConstantTimeCompare(digest[:], known[:]) == 1 {
+				found = principal
+				authenticated = true
+			}
+		}
+		return found, authenticated
+	}
+
+	// Only the certificates verified against the client CA are trusted.
+	if state != nil && len(state.VerifiedChains) > 0 {
+		principal, ok := a.clientCerts[state.VerifiedChains[0][0].Subject.CommonName]
+		return principal, ok
+	}
+
+	return Principal{}, false
+}
+
+// NewTLSConfig returns the TLS configuration of the APIs, which verifies the client
+// certificates if the client CA is set, or nil if the certificate isn't set.
+func NewTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the API certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if clientCAFile != "" {
+		contents, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the API client CA: %w", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(contents) {
+			return nil, errors.New("failed to parse the API client CA")
+		}
+		tlsConfig.ClientCAs = clientCAs
+		// The clients can authenticate with tokens instead of certificates.
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, nil
+}
+
+// httpPermission returns the permission of the HTTP request.
+func httpPermission(request *http.Request) Permission {
+	switch {
+	case request.Method == http.MethodGet || request.Method == http.MethodHead:
+		return PermissionRead
+	case request.URL.Path == "/config/reload":
+		return PermissionReload
+	default:
+		return PermissionOperate
+	}
+}
+
+// statusRecorder records the status code of the response for the audit log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// authMiddleware authorizes the requests to the HTTP API, except the health checks
+// and the static files of the dashboard, and audit logs the actions and the denied
+// requests.
+func authMiddleware(options *Options, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/healthz" || isDashboardAsset(request) {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		permission := httpPermission(request)
+		principal, err := options.Auth.Authorize(
+			request.Header.Get("Authorization"), request.TLS, permission)
+		if err != nil {
+			auditLog(options.Logger, principal, request.RemoteAddr).Warn().
+				Str("method", request.Method).Str("path", request.URL.Path).Err(err).Msg(
+				"Denied a request to the admin API")
+			writeError(writer, err)
+			return
+		}
+
+		if permission == PermissionRead {
+			next.ServeHTTP(writer, request)
+			return
+		}
+		recorder := &statusRecorder{ResponseWriter: writer, status: http.StatusOK}
+		next.ServeHTTP(recorder, request)
+		auditLog(options.Logger, principal, request.RemoteAddr).Info().
+			Str("method", request.Method).Str("path", request.URL.Path).
+			Str("query", request.URL.RawQuery).Int("status", recorder.status).Msg(
+			"Admin API action")
+	})
+}
+
+// auditLog returns a logger of the admin API audit events of the principal.
+func auditLog(logger zerolog.Logger, principal Principal, remoteAddr string) *zerolog.Logger {
+	auditLogger := logger.With().Bool("audit", true).Str("principal", principal.Name).
+		Str("role", string(principal.Role)).Str("remoteAddr", remoteAddr).Logger()
+	return &auditLogger
+}
+
+// grpcPermission returns the permission of the gRPC call, which only reads the status
+// and the configuration, except the queries.
+func grpcPermission(method string) Permission {
+	if strings.HasPrefix(method, "/"+v1.GatewayDQueryService_ServiceDesc.ServiceName+"/") {
+		return PermissionOperate
+	}
+	return PermissionRead
+}
+
+// grpcPeer returns the address and the TLS state of the client of the gRPC call.
+func grpcPeer(ctx context.Context) (string, *tls.ConnectionState) {
+	client, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", nil
+	}
+	if tlsInfo, ok := client.AuthInfo.(credentials.TLSInfo); ok {
+		return client.Addr.String(), &tlsInfo.State
+	}
+	return client.Addr.String(), nil
+}
+
+// authorizeGRPC authorizes the gRPC calls by their permissions, except the health
+// checks, and returns the principal of the call.
+func authorizeGRPC(ctx context.Context, options *Options, method string) (Principal, error) {
+	if strings.HasPrefix(method, "/"+grpc_health_v1.Health_ServiceDesc.ServiceName+"/") {
+		return anonymous, nil
+	}
+
+	var authorization string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+	}
+	remoteAddr, state := grpcPeer(ctx)
+
+	principal, err := options.Auth.Authorize(authorization, state, grpcPermission(method))
+	if err != nil {
+		auditLog(options.Logger, principal, remoteAddr).Warn().Str("method", method).Err(err).Msg(
+			"Denied a call to the admin API")
+		return principal, err
+	}
+	return principal, nil
+}
+
+// unaryAuthInterceptor authorizes the unary gRPC calls, and audit logs the ones that
+// don't only read, with their queries.
+func unaryAuthInterceptor(options *Options) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		principal, err := authorizeGRPC(ctx, options, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		if grpcPermission(info.FullMethod) == PermissionRead {
+			return handler(ctx, req)
+		}
+
+		response, err := handler(ctx, req)
+		remoteAddr, _ := grpcPeer(ctx)
+		event := auditLog(options.Logger, principal, remoteAddr).Info().Str(
+			"method", info.FullMethod).Str("code", status.Code(err).String())
+		if query, ok := req.(*v1.QueryRequest); ok {
+			event = event.Str("server", query.GetServer()).Str("sql", query.GetSql())
+		}
+		event.Msg("Admin API action")
+		return response, err
+	}
+}
+
+// streamAuthInterceptor authorizes the streaming gRPC calls, e.g. of the reflection.
+func streamAuthInterceptor(options *Options) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		if _, err := authorizeGRPC(stream.Context(), options, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+
+	authenticator, err := NewAuthenticator(config.APIAuth{
+		Enabled: true,
+		Tokens: []config.APIToken{
+			{Name: "dashboard", Token: "viewer-token", Role: string(config.Viewer)},
+			{Name: "oncall", Token: "operator-token", Role: string(config.Operator)},
+		},
+		ClientCerts: []config.APIClientCert{
+			{CommonName: "deploy.example.com", Role: string(config.Admin)},
+		},
+	})
+	require.Nil(t, err)
+	return authenticator
+}
+
+// clientCert returns the state of a TLS connection with a verified client certificate.
+func clientCert(commonName string) *tls.ConnectionState {
+	return &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{
+			{{Subject: pkix.Name{CommonName: commonName}}},
+		},
+	}
+}
+
+func TestAuthenticator(t *testing.T) {
+	authenticator := newTestAuthenticator(t)
+
+	principal, err := authenticator.Authorize("Bearer viewer-token", nil, PermissionRead)
+	assert.Nil(t, err)
+	assert.Equal(t, Principal{Name: "dashboard", Role: config.Viewer}, principal)
+
+	_, err = authenticator.Authorize("Bearer viewer-token", nil, PermissionOperate)
+	assert.Equal(t, gerr.ErrPermissionDenied, err)
+
+	_, err = authenticator.Authorize("Bearer operator-token", nil, PermissionOperate)
+	assert.Nil(t, err)
+	_, err = authenticator.Authorize("Bearer operator-token", nil, PermissionReload)
+	assert.Equal(t, gerr.ErrPermissionDenied, err)
+
+	principal, err = authenticator.Authorize("", clientCert("deploy.example.com"), PermissionReload)
+	assert.Nil(t, err)
+	assert.Equal(t, "deploy.example.com", principal.Name)
+
+	_, err = authenticator.Authorize("Bearer unknown", nil, PermissionRead)
+	assert.Equal(t, gerr.ErrUnauthenticated, err)
+	_, err = authenticator.Authorize("", clientCert("unknown.example.com"), PermissionRead)
+	assert.Equal(t, gerr.ErrUnauthenticated, err)
+	// The certificates that weren't verified are not trusted.
+	_, err = authenticator.Authorize("", &tls.ConnectionState{}, PermissionRead)
+	assert.Equal(t, gerr.ErrUnauthenticated, err)
+
+	// All the requests are allowed if the authentication is disabled.
+	disabled, err := NewAuthenticator(config.APIAuth{Enabled: false})
+	require.Nil(t, err)
+	_, err = disabled.Authorize("", nil, PermissionReload)
+	assert.Nil(t, err)
+
+	_, err = NewAuthenticator(config.APIAuth{
+		Enabled: true,
+		Tokens:  []config.APIToken{{Name: "ci", Token: "token", Role: "root"}},
+	})
+	assert.ErrorContains(t, err, "unknown role of the ci API token: root")
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	reloaded := false
+	options := &Options{
+		Logger: zerolog.New(&logs),
+		Auth:   newTestAuthenticator(t),
+		Reload: func(context.Context) error {
+			reloaded = true
+			return nil
+		},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config/reload", reloadHandler(options))
+	mux.HandleFunc("/healthz", func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/connections/", func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusNoContent)
+	})
+	handler := authMiddleware(options, mux)
+
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	// The health checks are not authenticated.
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/healthz", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodGet, "/connections/", "").Code)
+	assert.Equal(t, http.StatusForbidden,
+		request(http.MethodDelete, "/connections/1", "viewer-token").Code)
+	assert.Contains(t, logs.String(), `"principal":"dashboard"`)
+	assert.Contains(t, logs.String(), "Denied a request to the admin API")
+
+	logs.Reset()
+	assert.Equal(t, http.StatusNoContent,
+		request(http.MethodDelete, "/connections/1", "operator-token").Code)
+	assert.Contains(t, logs.String(), `"audit":true`)
+	assert.Contains(t, logs.String(), `"principal":"oncall"`)
+	assert.Contains(t, logs.String(), `"status":204`)
+
+	assert.Equal(t, http.StatusForbidden,
+		request(http.MethodPost, "/config/reload", "operator-token").Code)
+	assert.False(t, reloaded)
+
+	options.Auth, _ = NewAuthenticator(config.APIAuth{Enabled: false})
+	assert.Equal(t, http.StatusNoContent, request(http.MethodPost, "/config/reload", "").Code)
+	assert.True(t, reloaded)
+
+	options.Reload = func(context.Context) error { return errors.New("invalid config") }
+	assert.Equal(t, http.StatusUnprocessableEntity,
+		request(http.MethodPost, "/config/reload", "").Code)
+}
+
+func TestAuthorizeGRPC(t *testing.T) {
+	options := &Options{Logger: zerolog.Nop(), Auth: newTestAuthenticator(t)}
+	method := "/api.v1.GatewayDAdminAPIService/Version"
+
+	_, err := authorizeGRPC(context.Background(), options, method)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.NewIncomingContext(
+		context.Background(), metadata.Pairs("authorization", "Bearer viewer-token"))
+	principal, err := authorizeGRPC(ctx, options, method)
+	require.NoError(t, err)
+	assert.Equal(t, "dashboard", principal.Name)
+
+	// The queries need the operator role.
+	queryMethod := "/api.v1.GatewayDQueryService/Query"
+	_, err = authorizeGRPC(ctx, options, queryMethod)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	ctx = metadata.NewIncomingContext(
+		context.Background(), metadata.Pairs("authorization", "Bearer operator-token"))
+	_, err = authorizeGRPC(ctx, options, queryMethod)
+	require.NoError(t, err)
+
+	// The health checks are not authenticated.
+	_, err = authorizeGRPC(context.Background(), options, "/grpc.health.v1.Health/Check")
+	require.NoError(t, err)
+}
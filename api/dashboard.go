@@ -0,0 +1,126 @@
+package api
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	"github.com/gatewayd-io/gatewayd/plugin"
+)
+
+// DefaultDashboardQueries is the number of the top queries returned by default.
+const DefaultDashboardQueries = 20
+
+// dashboardUI is the single-page dashboard, which polls the admin API.
+//
+//go:embed dashboard
+var dashboardUI embed.FS
+
+// PluginHealth is whether a loaded plugin answers the health checks.
+type PluginHealth struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// isDashboardAsset returns true if the request is for the static files of the
+// dashboard, which are served without authentication, as they contain no data.
+func isDashboardAsset(request *http.Request) bool {
+	return strings.HasPrefix(request.URL.Path, "/dashboard/") &&
+		!strings.HasPrefix(request.URL.Path, "/dashboard/api/")
+}
+
+// registerDashboard serves the dashboard and the data only it shows, i.e. the top
+// queries, the health of the plugins and the tail of the logs.
+func registerDashboard(mux *http.ServeMux, api *API) error {
+	fsys, err := fs.Sub(dashboardUI, "dashboard")
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	mux.Handle("/dashboard/", http.StripPrefix("/dashboard/", http.FileServer(http.FS(fsys))))
+	mux.HandleFunc("/dashboard/api/queries", dashboardQueriesHandler(api.Options))
+	mux.HandleFunc("/dashboard/api/logs", dashboardLogsHandler(api.Options))
+	mux.HandleFunc("/dashboard/api/plugins", dashboardPluginsHandler(api))
+	return nil
+}
+
+// writeJSON responds with the value encoded as JSON.
+func writeJSON(writer http.ResponseWriter, options *Options, value interface{}, what string) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(writer).Encode(value); err != nil {
+		options.Logger.Err(err).Msg("failed to serve " + what)
+	}
+}
+
+// dashboardQueriesHandler returns the most sent queries, e.g. /dashboard/api/queries?limit=10.
+func dashboardQueriesHandler(options *Options) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if options.QueryStats == nil {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if request.Method != http.MethodGet {
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit := DefaultDashboardQueries
+		if value := request.URL.Query().Get("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				http.Error(writer, "invalid limit value", http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+		writeJSON(writer, options, options.QueryStats.Top(limit), "top queries")
+	}
+}
+
+// dashboardLogsHandler returns the last lines of the default logger.
+func dashboardLogsHandler(options *Options) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if options.LogTail == nil {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if request.Method != http.MethodGet {
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(writer, options, options.LogTail.Lines(), "logs")
+	}
+}
+
+// dashboardPluginsHandler pings the loaded plugins and returns their health.
+func dashboardPluginsHandler(api *API) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet {
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		plugins := make([]PluginHealth, 0)
+		if api.PluginRegistry != nil {
+			api.PluginRegistry.ForEach(func(id sdkPlugin.Identifier, plugIn *plugin.Plugin) {
+				health := PluginHealth{Name: id.Name, Version: id.Version, Healthy: true}
+				if err := plugIn.Ping(); err != nil {
+					health.Healthy = false
+					health.Error = err.Error()
+				}
+				plugins = append(plugins, health)
+			})
+		}
+		sort.Slice(plugins, func(i, j int) bool {
+			return plugins[i].Name < plugins[j].Name
+		})
+		writeJSON(writer, api.Options, plugins, "plugins")
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/logging"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboard(t *testing.T) {
+	queryStats := network.NewQueryStats(10)
+	queryStats.Record(network.PostgreSQLQuery("SELECT 1"))
+	queryStats.Record(network.PostgreSQLQuery("SELECT * FROM users"))
+	queryStats.Record(network.PostgreSQLQuery("SELECT 2"))
+	logTail := logging.NewTail(10)
+	logger := zerolog.New(logTail)
+	logger.Info().Msg("Started the HTTP API")
+
+	options := &Options{
+		Logger:     zerolog.Nop(),
+		QueryStats: queryStats,
+		LogTail:    logTail,
+		Auth:       newTestAuthenticator(t),
+	}
+	mux := http.NewServeMux()
+	require.NoError(t, registerDashboard(mux, &API{Options: options}))
+	handler := authMiddleware(options, mux)
+
+	request := func(path, token string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	// The page is served without authentication, as it contains no data.
+	recorder := request("/dashboard/", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "<title>GatewayD Dashboard</title>")
+	assert.Equal(t, http.StatusUnauthorized, request("/dashboard/api/queries", "").Code)
+
+	recorder = request("/dashboard/api/queries?limit=1", "viewer-token")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var queries []network.QueryStat
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&queries))
+	require.Len(t, queries, 1)
+	assert.Equal(t, "SELECT ?", queries[0].Query)
+	assert.Equal(t, uint64(2), queries[0].Count)
+	assert.Equal(t, http.StatusBadRequest,
+		request("/dashboard/api/queries?limit=-1", "viewer-token").Code)
+
+	recorder = request("/dashboard/api/logs", "viewer-token")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var logs []map[string]interface{}
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&logs))
+	require.Len(t, logs, 1)
+	assert.Equal(t, "Started the HTTP API", logs[0]["message"])
+
+	recorder = request("/dashboard/api/plugins", "viewer-token")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, "[]", recorder.Body.String())
+}
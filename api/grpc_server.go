@@ -5,6 +5,7 @@ import (
 
 	v1 "github.com/gatewayd-io/gatewayd/api/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
@@ -16,9 +17,19 @@ func StartGRPCAPI(api *API, healthchecker *HealthChecker) {
 		api.Options.Logger.Err(err).Msg("failed to start gRPC API")
 	}
 
-	grpcServer := grpc.NewServer()
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryAuthInterceptor(api.Options)),
+		grpc.ChainStreamInterceptor(streamAuthInterceptor(api.Options)),
+	}
+	if api.Options.TLSConfig != nil {
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(api.Options.TLSConfig)))
+	}
+	grpcServer := grpc.NewServer(serverOptions...)
 	reflection.Register(grpcServer)
 	v1.RegisterGatewayDAdminAPIServiceServer(grpcServer, api)
+	if api.Options.Querier != nil {
+		v1.RegisterGatewayDQueryServiceServer(grpcServer, api)
+	}
 	grpc_health_v1.RegisterHealthServer(grpcServer, healthchecker)
 	if err := grpcServer.Serve(listener); err != nil {
 		api.Options.Logger.Err(err).Msg("failed to start gRPC API")
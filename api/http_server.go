@@ -3,33 +3,337 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io/fs"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	v1 "github.com/gatewayd-io/gatewayd/api/v1"
 	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/network"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type Healthz struct {
 	Status string `json:"status"`
 }
 
+// Connection is a client connection of a proxy.
+type Connection struct {
+	network.ConnectionInfo
+	Proxy string `json:"proxy"`
+}
+
+// Backend is the backend of a proxy, and whether its IP addresses changed when it
+// was re-resolved.
+type Backend struct {
+	network.BackendStatus
+	Proxy   string `json:"proxy"`
+	Changed bool   `json:"changed"`
+}
+
+type HookTraceStatus struct {
+	Enabled    bool    `json:"enabled"`
+	SampleRate float64 `json:"sampleRate"`
+}
+
+// hookTraceHandler dumps the recorded hook chains on GET, and enables or disables
+// the hook trace on POST, e.g. /debug/hooks?enabled=true&sampleRate=0.1.
+func hookTraceHandler(options *Options) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if options.HookTracer == nil {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var response interface{}
+		switch request.Method {
+		case http.MethodGet:
+			response = options.HookTracer.Dump()
+		case http.MethodPost:
+			enabled, sampleRate := options.HookTracer.Status()
+			if value := request.URL.Query().Get("enabled"); value != "" {
+				parsed, err := strconv.ParseBool(value)
+				if err != nil {
+					http.Error(writer, "invalid enabled value", http.StatusBadRequest)
+					return
+				}
+				enabled = parsed
+			}
+			if value := request.URL.Query().Get("sampleRate"); value != "" {
+				parsed, err := strconv.ParseFloat(value, 64)
+				if err != nil || parsed < 0 || parsed > 1 {
+					http.Error(writer, "invalid sampleRate value", http.StatusBadRequest)
+					return
+				}
+				sampleRate = parsed
+			}
+			options.HookTracer.Configure(enabled, sampleRate)
+			options.Logger.Info().Bool("enabled", enabled).Float64("sampleRate", sampleRate).Msg(
+				"Configured the hook trace")
+			status := HookTraceStatus{Enabled: enabled, SampleRate: sampleRate}
+			options.persistState(func(state *State) { state.HookTrace = &status })
+			response = status
+		default:
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(writer).Encode(response); err != nil {
+			options.Logger.Err(err).Msg("failed to serve hook trace")
+		}
+	}
+}
+
+// usageHandler reports the traffic of the open connections, and of each user and
+// database since GatewayD started.
+func usageHandler(options *Options) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if options.Usage == nil {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if request.Method != http.MethodGet {
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(writer).Encode(options.Usage.Report()); err != nil {
+			options.Logger.Err(err).Msg("failed to serve usage")
+		}
+	}
+}
+
+// errorsHandler reports the error responses of the databases by database, user and
+// SQLSTATE code, filtered with ?database=, ?user= and ?code=, which matches the codes
+// that start with it, e.g. 40 for their class.
+func errorsHandler(options *Options) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if options.ErrorStats == nil {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if request.Method != http.MethodGet {
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := request.URL.Query()
+		writeJSON(writer, options, options.ErrorStats.Report(
+			query.Get("database"), query.Get("user"), query.Get("code")), "errors")
+	}
+}
+
+// connectionsHandler lists the client connections of the proxies on GET /connections,
+// sorted by memory with ?sort=memory, and kills a connection on DELETE /connections/{id}.
+func connectionsHandler(options *Options) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id := strings.Trim(strings.TrimPrefix(request.URL.Path, "/connections"), "/")
+
+		switch {
+		case request.Method == http.MethodGet && id == "":
+			connections := make([]Connection, 0)
+			for name, proxy := range options.Proxies {
+				for _, info := range proxy.Connections() {
+					connections = append(connections, Connection{ConnectionInfo: info, Proxy: name})
+				}
+			}
+			// The connections are listed oldest first, or the ones that hold the most
+			// memory first, e.g. to find the leaky ones.
+			sort.Slice(connections, func(i, j int) bool {
+				if request.URL.Query().Get("sort") == "memory" {
+					return connections[i].Memory > connections[j].Memory
+				}
+				return connections[i].Since.Before(connections[j].Since)
+			})
+
+			writer.Header().Set("Content-Type", "application/json")
+			writer.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(writer).Encode(connections); err != nil {
+				options.Logger.Err(err).Msg("failed to serve connections")
+			}
+		case request.Method == http.MethodDelete && id != "":
+			for _, proxy := range options.Proxies {
+				if proxy.Kill(id) {
+					writer.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+			writeError(writer, gerr.ErrKillConnectionFailed.Wrap(errors.New("connection not found")))
+		default:
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// maintenanceHandler returns the status of the maintenance mode on GET, and enables
+// or disables it on POST, e.g. /maintenance?enabled=true&message=...&queue=true.
+func maintenanceHandler(options *Options) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if options.Maintenance == nil {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		switch request.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			query := request.URL.Query()
+			enabled, err := strconv.ParseBool(query.Get("enabled"))
+			if err != nil {
+				http.Error(writer, "invalid enabled value", http.StatusBadRequest)
+				return
+			}
+			queue := false
+			if value := query.Get("queue"); value != "" {
+				if queue, err = strconv.ParseBool(value); err != nil {
+					http.Error(writer, "invalid queue value", http.StatusBadRequest)
+					return
+				}
+			}
+			var queueTimeout time.Duration
+			if value := query.Get("queueTimeout"); value != "" {
+				if queueTimeout, err = time.ParseDuration(value); err != nil || queueTimeout < 0 {
+					http.Error(writer, "invalid queueTimeout value", http.StatusBadRequest)
+					return
+				}
+			}
+
+			if enabled {
+				options.Maintenance.Enable(query.Get("message"), queue, queueTimeout)
+				options.Logger.Info().Str("message", query.Get("message")).Bool("queue", queue).Msg(
+					"Enabled the maintenance mode")
+			} else {
+				options.Maintenance.Disable()
+				options.Logger.Info().Msg("Disabled the maintenance mode")
+			}
+			options.persistState(func(state *State) {
+				state.Maintenance = nil
+				if status := options.Maintenance.Status(); status.Enabled {
+					status.Queued = 0
+					state.Maintenance = &status
+				}
+			})
+		default:
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(writer).Encode(options.Maintenance.Status()); err != nil {
+			options.Logger.Err(err).Msg("failed to serve maintenance status")
+		}
+	}
+}
+
+// backendsHandler lists the backends of the proxies with a hostname on GET /backends,
+// and re-resolves their hostnames on POST /backends/resolve.
+func backendsHandler(options *Options) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		resolve := strings.Trim(strings.TrimPrefix(request.URL.Path, "/backends"), "/")
+
+		switch {
+		case request.Method == http.MethodGet && resolve == "":
+		case request.Method == http.MethodPost && resolve == "resolve":
+		default:
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		backends := make([]Backend, 0)
+		for name, proxy := range options.Proxies {
+			if proxy.Backend == nil {
+				continue
+			}
+			changed := false
+			if request.Method == http.MethodPost {
+				var err *gerr.GatewayDError
+				if changed, err = proxy.ResolveBackend(); err != nil {
+					writeError(writer, err)
+					return
+				}
+			}
+			backends = append(backends, Backend{
+				BackendStatus: proxy.Backend.Status(), Proxy: name, Changed: changed,
+			})
+		}
+		sort.Slice(backends, func(i, j int) bool {
+			return backends[i].Proxy < backends[j].Proxy
+		})
+
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(writer).Encode(backends); err != nil {
+			options.Logger.Err(err).Msg("failed to serve backends")
+		}
+	}
+}
+
+// reloadHandler reloads the configuration on POST /config/reload.
+func reloadHandler(options *Options) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if options.Reload == nil {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if request.Method != http.MethodPost {
+			writer.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := options.Reload(request.Context()); err != nil {
+			writeError(writer, gerr.ErrReloadConfigFailed.Wrap(err))
+			return
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Error is the body of the responses of the failed requests, with the taxonomy of
+// the error.
+type Error struct {
+	Error       string `json:"error"`
+	Code        uint32 `json:"code"`
+	Name        string `json:"name"`
+	Category    string `json:"category"`
+	Remediation string `json:"remediation"`
+}
+
+// writeError responds with the error and the HTTP status code of its taxonomy.
+func writeError(writer http.ResponseWriter, err *gerr.GatewayDError) {
+	taxonomy := err.Taxonomy()
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(taxonomy.HTTPStatus)
+	//nolint:errcheck,errchkjson
+	json.NewEncoder(writer).Encode(Error{
+		Error:       err.Error(),
+		Code:        uint32(err.Code),
+		Name:        taxonomy.Name,
+		Category:    string(taxonomy.Category),
+		Remediation: taxonomy.Remediation,
+	})
+}
+
 // StartHTTPAPI starts the HTTP API.
-func StartHTTPAPI(options *Options) {
+func StartHTTPAPI(api *API) {
+	options := api.Options
 	ctx := context.Background()
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	// Register gRPC server endpoint
-	// TODO: Make this configurable with TLS and Auth.
+	// Serve the gRPC API over HTTP in-process, so that the requests are authorized
+	// once, by the HTTP API.
 	rmux := runtime.NewServeMux()
-	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
-	err := v1.RegisterGatewayDAdminAPIServiceHandlerFromEndpoint(
-		ctx, rmux, options.GRPCAddress, opts)
-	if err != nil {
+	if err := v1.RegisterGatewayDAdminAPIServiceHandlerServer(ctx, rmux, api); err != nil {
 		options.Logger.Err(err).Msg("failed to start HTTP API")
 	}
 
@@ -60,6 +364,22 @@ func StartHTTPAPI(options *Options) {
 		}
 	})
 
+	mux.HandleFunc("/debug/hooks", hookTraceHandler(options))
+	mux.HandleFunc("/usage", usageHandler(options))
+	mux.HandleFunc("/errors", errorsHandler(options))
+	mux.HandleFunc("/connections", connectionsHandler(options))
+	mux.HandleFunc("/connections/", connectionsHandler(options))
+	mux.HandleFunc("/maintenance", maintenanceHandler(options))
+	mux.HandleFunc("/backends", backendsHandler(options))
+	mux.HandleFunc("/backends/", backendsHandler(options))
+	mux.HandleFunc("/config/reload", reloadHandler(options))
+
+	if options.QueryStats != nil && options.LogTail != nil {
+		if err := registerDashboard(mux, api); err != nil {
+			options.Logger.Err(err).Msg("failed to serve the dashboard")
+		}
+	}
+
 	if IsSwaggerEmbedded() {
 		mux.HandleFunc("/swagger.json", func(writer http.ResponseWriter, r *http.Request) {
 			writer.WriteHeader(http.StatusOK)
@@ -79,7 +399,18 @@ func StartHTTPAPI(options *Options) {
 	}
 
 	// Start HTTP server (and proxy calls to gRPC server endpoint)
-	if err := http.ListenAndServe(options.HTTPAddress, mux); err != nil { //nolint:gosec
+	server := &http.Server{ //nolint:gosec
+		Addr:      options.HTTPAddress,
+		Handler:   authMiddleware(options, mux),
+		TLSConfig: options.TLSConfig,
+	}
+	var err error
+	if options.TLSConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil {
 		options.Logger.Err(err).Msg("failed to start HTTP API")
 	}
 }
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookTraceHandler(t *testing.T) {
+	tracer := plugin.NewHookTracer(10)
+	tracer.Record(plugin.HookChain{Hook: "HOOK_NAME_ON_TICK"})
+	handler := hookTraceHandler(&Options{Logger: zerolog.Nop(), HookTracer: tracer})
+
+	// Enable the hook trace.
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/debug/hooks?enabled=true&sampleRate=0.5", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	enabled, sampleRate := tracer.Status()
+	assert.True(t, enabled)
+	assert.Equal(t, 0.5, sampleRate)
+
+	// Invalid values are rejected.
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/debug/hooks?sampleRate=2", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Dump the hook chains.
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/debug/hooks", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var chains []plugin.HookChain
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&chains))
+	assert.Len(t, chains, 1)
+	assert.Equal(t, "HOOK_NAME_ON_TICK", chains[0].Hook)
+}
+
+func TestUsageHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	usageHandler(&Options{Logger: zerolog.Nop()})(
+		recorder, httptest.NewRequest(http.MethodGet, "/usage", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	handler := usageHandler(&Options{
+		Logger: zerolog.Nop(),
+		Usage:  network.NewUsageTracker(nil, zerolog.Nop()),
+	})
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/usage", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/usage", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var report network.UsageReport
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&report))
+	assert.Empty(t, report.Connections)
+	assert.Equal(t, network.Usage{}, report.Total)
+}
+
+func TestErrorsHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	errorsHandler(&Options{Logger: zerolog.Nop()})(
+		recorder, httptest.NewRequest(http.MethodGet, "/errors", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	stats := network.NewErrorStats(10)
+	stats.Record("shop", "app", "40P01")
+	stats.Record("shop", "app", "40001")
+	stats.Record("shop", "app", "40001")
+	stats.Record("shop", "report", "28P01")
+	handler := errorsHandler(&Options{Logger: zerolog.Nop(), ErrorStats: stats})
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodDelete, "/errors", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/errors?user=app&code=40", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var report []network.ErrorStat
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&report))
+	require.Len(t, report, 2)
+	assert.Equal(t, "40001", report[0].Code)
+	assert.Equal(t, uint64(2), report[0].Count)
+	assert.Equal(t, "40P01", report[1].Code)
+}
+
+func TestConnectionsHandler(t *testing.T) {
+	handler := connectionsHandler(&Options{Logger: zerolog.Nop()})
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/connections", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var connections []Connection
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&connections))
+	assert.Empty(t, connections)
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodDelete, "/connections/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	var apiErr Error
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&apiErr))
+	assert.Equal(t, "KILL_CONNECTION_FAILED", apiErr.Name)
+	assert.NotEmpty(t, apiErr.Remediation)
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodDelete, "/connections", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
+
+func TestMaintenanceHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	maintenanceHandler(&Options{Logger: zerolog.Nop()})(
+		recorder, httptest.NewRequest(http.MethodGet, "/maintenance", nil))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	maintenance := network.NewMaintenance()
+	handler := maintenanceHandler(&Options{Logger: zerolog.Nop(), Maintenance: maintenance})
+
+	// Enable the maintenance mode.
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(
+		http.MethodPost, "/maintenance?enabled=true&message=upgrading&queue=true&queueTimeout=1m", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var status network.MaintenanceStatus
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&status))
+	assert.Equal(t, network.MaintenanceStatus{
+		Enabled: true, Message: "upgrading", Queue: true, QueueTimeout: time.Minute,
+	}, status)
+	assert.Equal(t, status, maintenance.Status())
+
+	// Invalid values are rejected.
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/maintenance?enabled=maybe", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// Disable the maintenance mode.
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/maintenance?enabled=false", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.False(t, maintenance.Status().Enabled)
+}
+
+func TestBackendsHandler(t *testing.T) {
+	ctx := context.Background()
+	proxy := network.NewProxy(
+		ctx, pool.NewPool(ctx, 1), nil, false, false, config.DefaultHealthCheckPeriod,
+		&config.Client{Network: "tcp", Address: "localhost:5432"}, zerolog.Nop(),
+		config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+	proxy.WatchBackend()
+	require.NotNil(t, proxy.Backend)
+
+	handler := backendsHandler(&Options{
+		Logger:  zerolog.Nop(),
+		Proxies: map[string]*network.Proxy{"default": proxy},
+	})
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/backends", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var backends []Backend
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&backends))
+	require.Len(t, backends, 1)
+	assert.Equal(t, "default", backends[0].Proxy)
+	assert.Equal(t, "localhost:5432", backends[0].Address)
+	assert.NotEmpty(t, backends[0].Addresses)
+
+	// Re-resolving the same addresses isn't a change.
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/backends/resolve", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&backends))
+	require.Len(t, backends, 1)
+	assert.False(t, backends[0].Changed)
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/backends", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
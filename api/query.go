@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	v1 "github.com/gatewayd-io/gatewayd/api/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// queryArgs returns the parameters of the query in the text format, which are null if
+// they are null, and JSON if they are lists or objects.
+func queryArgs(params []*structpb.Value) ([][]byte, error) {
+	args := make([][]byte, 0, len(params))
+	for _, param := range params {
+		switch kind := param.GetKind().(type) {
+		case *structpb.Value_NullValue, nil:
+			args = append(args, nil)
+		case *structpb.Value_StringValue:
+			args = append(args, []byte(kind.StringValue))
+		case *structpb.Value_NumberValue:
+			args = append(args, []byte(strconv.FormatFloat(kind.NumberValue, 'f', -1, 64)))
+		case *structpb.Value_BoolValue:
+			args = append(args, []byte(strconv.FormatBool(kind.BoolValue)))
+		default:
+			arg, err := json.Marshal(param.AsInterface())
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+			args = append(args, arg)
+		}
+	}
+	return args, nil
+}
+
+// Query runs the read-only query of an internal tool through the listener of a server,
+// and returns its rows.
+func (a *API) Query(ctx context.Context, request *v1.QueryRequest) (*v1.QueryResponse, error) {
+	querier := a.Options.Querier
+	if querier == nil {
+		return nil, status.Error(codes.Unimplemented, "the query service is disabled")
+	}
+
+	name := config.If[string](request.GetServer() != "", request.GetServer(), config.Default)
+	server, exists := a.Servers[name]
+	if !exists {
+		return nil, status.Error(codes.NotFound, "server not found")
+	}
+	if server.Network != "tcp" {
+		return nil, status.Error(codes.FailedPrecondition, "the server doesn't listen on TCP")
+	}
+	args, err := queryArgs(request.GetParams())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid params: %v", err)
+	}
+
+	result, err := querier.Query(
+		ctx, server.Address, request.GetSql(), args, int(request.GetMaxRows()))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			// The errors of the database are the caller's, e.g. a syntax error or a write.
+			return nil, status.Errorf(codes.InvalidArgument, "failed to run the query: %v", err)
+		}
+		a.Options.Logger.Error().Err(err).Str("server", name).Msg("Failed to run the query")
+		return nil, status.Errorf(codes.Unavailable, "failed to run the query: %v", err)
+	}
+
+	// The rows are converted to JSON first, as the values are decoded to JSON values.
+	jsonData, err := json.Marshal(result.Rows)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to marshal the rows: %v", err)
+	}
+	var rows [][]interface{}
+	if err := json.Unmarshal(jsonData, &rows); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to marshal the rows: %v", err)
+	}
+	response := &v1.QueryResponse{
+		Columns:   result.Columns,
+		Rows:      make([]*structpb.ListValue, 0, len(rows)),
+		Truncated: result.Truncated,
+	}
+	for _, row := range rows {
+		values, err := structpb.NewList(row)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to marshal the rows: %v", err)
+		}
+		response.Rows = append(response.Rows, values)
+	}
+	return response, nil
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/gatewayd-io/gatewayd/api/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// TestQueryArgs tests that the parameters are converted to the text format.
+func TestQueryArgs(t *testing.T) {
+	list, err := structpb.NewList([]interface{}{1, "a"})
+	require.NoError(t, err)
+	args, err := queryArgs([]*structpb.Value{
+		structpb.NewNullValue(),
+		structpb.NewStringValue("Jane"),
+		structpb.NewNumberValue(30),
+		structpb.NewNumberValue(1.5),
+		structpb.NewBoolValue(true),
+		structpb.NewListValue(list),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{
+		nil, []byte("Jane"), []byte("30"), []byte("1.5"), []byte("true"), []byte(`[1,"a"]`),
+	}, args)
+}
+
+// TestQuery tests that the queries are rejected if the service is disabled, or if
+// their server doesn't exist.
+func TestQuery(t *testing.T) {
+	api := API{Options: &Options{Logger: zerolog.Nop()}}
+	_, err := api.Query(context.Background(), &v1.QueryRequest{Sql: "SELECT 1"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	api.Options.Querier = network.NewQuerier(config.APIQuery{Enabled: true})
+	require.NotNil(t, api.Options.Querier)
+	assert.Equal(t, config.DefaultAPIQueryMaxRows, api.Options.Querier.MaxRows)
+	_, err = api.Query(context.Background(), &v1.QueryRequest{Server: "missing", Sql: "SELECT 1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/network"
+)
+
+// State is what was changed at runtime with the admin API, which is persisted to the
+// state file and applied again when GatewayD restarts. The settings that weren't
+// changed are nil, so that they are taken from the config.
+type State struct {
+	Maintenance *network.MaintenanceStatus `json:"maintenance,omitempty"`
+	HookTrace   *HookTraceStatus           `json:"hookTrace,omitempty"`
+	UpdatedAt   time.Time                  `json:"updatedAt"`
+}
+
+// stateMu serializes the updates of the state file.
+var stateMu sync.Mutex
+
+// LoadState loads the state from the file. The state is empty if the file doesn't
+// exist.
+func LoadState(fileName string) (*State, error) {
+	state := &State{}
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return state, nil
+}
+
+// UpdateState applies the update to the state in the file. It is written to a
+// temporary file that is renamed, so that a crash never leaves it half-written.
+func UpdateState(fileName string, update func(state *State)) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state, err := LoadState(fileName)
+	if err != nil {
+		return err
+	}
+	update(state)
+	state.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	temporary := fileName + ".tmp"
+	//nolint:gomnd
+	if err := os.WriteFile(temporary, data, 0o600); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return os.Rename(temporary, fileName) //nolint:wrapcheck
+}
+
+// ResetState removes the state file, so that only the config applies when GatewayD
+// restarts.
+func ResetState(fileName string) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	if err := os.Remove(fileName); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err //nolint:wrapcheck
+	}
+	return nil
+}
+
+// persistState applies the update to the state file of the options, if any.
+func (o *Options) persistState(update func(state *State)) {
+	if o.StateFile == "" {
+		return
+	}
+	if err := UpdateState(o.StateFile, update); err != nil {
+		o.Logger.Error().Err(err).Str("fileName", o.StateFile).Msg(
+			"Failed to persist the state, it won't be applied when GatewayD restarts")
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestState tests that the changes made with the admin API are persisted to the state
+// file, until it is reset.
+func TestState(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "state.json")
+	state, err := LoadState(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, &State{}, state)
+
+	options := &Options{
+		Logger:      zerolog.Nop(),
+		Maintenance: network.NewMaintenance(),
+		HookTracer:  plugin.NewHookTracer(10),
+		StateFile:   fileName,
+	}
+	maintenanceHandler(options)(httptest.NewRecorder(), httptest.NewRequest(
+		http.MethodPost, "/maintenance?enabled=true&message=upgrading&queueTimeout=1m", nil))
+	hookTraceHandler(options)(httptest.NewRecorder(), httptest.NewRequest(
+		http.MethodPost, "/debug/hooks?enabled=true&sampleRate=0.5", nil))
+
+	state, err = LoadState(fileName)
+	require.NoError(t, err)
+	assert.Equal(t, &network.MaintenanceStatus{
+		Enabled: true, Message: "upgrading", QueueTimeout: time.Minute,
+	}, state.Maintenance)
+	assert.Equal(t, &HookTraceStatus{Enabled: true, SampleRate: 0.5}, state.HookTrace)
+	assert.False(t, state.UpdatedAt.IsZero())
+
+	// Disabling the maintenance mode keeps the hook trace.
+	maintenanceHandler(options)(httptest.NewRecorder(), httptest.NewRequest(
+		http.MethodPost, "/maintenance?enabled=false", nil))
+	state, err = LoadState(fileName)
+	require.NoError(t, err)
+	assert.Nil(t, state.Maintenance)
+	assert.NotNil(t, state.HookTrace)
+
+	require.NoError(t, ResetState(fileName))
+	require.NoError(t, ResetState(fileName))
+	assert.NoFileExists(t, fileName)
+}
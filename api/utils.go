@@ -6,7 +6,7 @@ import (
 
 func liveness(servers map[string]*network.Server) bool {
 	for _, v := range servers {
-		if !v.IsRunning() {
+		if !v.IsRunning() || v.IsDraining() {
 			return false
 		}
 	}
@@ -346,3 +346,97 @@ var GatewayDAdminAPIService_ServiceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/v1/api.proto",
 }
+
+const (
+	GatewayDQueryService_Query_FullMethodName = "/api.v1.GatewayDQueryService/Query"
+)
+
+// GatewayDQueryServiceClient is the client API for GatewayDQueryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GatewayDQueryServiceClient interface {
+	// Query runs a parameterized query in a read-only transaction through the listener
+	// of a server, and returns its rows.
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error)
+}
+
+type gatewayDQueryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGatewayDQueryServiceClient(cc grpc.ClientConnInterface) GatewayDQueryServiceClient {
+	return &gatewayDQueryServiceClient{cc}
+}
+
+func (c *gatewayDQueryServiceClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error) {
+	out := new(QueryResponse)
+	err := c.cc.Invoke(ctx, GatewayDQueryService_Query_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GatewayDQueryServiceServer is the server API for GatewayDQueryService service.
+// All implementations must embed UnimplementedGatewayDQueryServiceServer
+// for forward compatibility
+type GatewayDQueryServiceServer interface {
+	// Query runs a parameterized query in a read-only transaction through the listener
+	// of a server, and returns its rows.
+	Query(context.Context, *QueryRequest) (*QueryResponse, error)
+	mustEmbedUnimplementedGatewayDQueryServiceServer()
+}
+
+// UnimplementedGatewayDQueryServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedGatewayDQueryServiceServer struct {
+}
+
+func (UnimplementedGatewayDQueryServiceServer) Query(context.Context, *QueryRequest) (*QueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedGatewayDQueryServiceServer) mustEmbedUnimplementedGatewayDQueryServiceServer() {}
+
+// UnsafeGatewayDQueryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GatewayDQueryServiceServer will
+// result in compilation errors.
+type UnsafeGatewayDQueryServiceServer interface {
+	mustEmbedUnimplementedGatewayDQueryServiceServer()
+}
+
+func RegisterGatewayDQueryServiceServer(s grpc.ServiceRegistrar, srv GatewayDQueryServiceServer) {
+	s.RegisterService(&GatewayDQueryService_ServiceDesc, srv)
+}
+
+func _GatewayDQueryService_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GatewayDQueryServiceServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GatewayDQueryService_Query_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GatewayDQueryServiceServer).Query(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// GatewayDQueryService_ServiceDesc is the grpc.ServiceDesc for GatewayDQueryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GatewayDQueryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "api.v1.GatewayDQueryService",
+	HandlerType: (*GatewayDQueryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Query",
+			Handler:    _GatewayDQueryService_Query_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/v1/api.proto",
+}
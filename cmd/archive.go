@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// archiveEntry is a file, directory or symlink in an archive.
+type archiveEntry struct {
+	Name     string
+	Mode     os.FileMode
+	Linkname string
+	// Open returns the contents of the file, which are only valid until the next entry.
+	Open func() (io.ReadCloser, error)
+}
+
+// archiveReader iterates over the entries of an archive.
+type archiveReader interface {
+	// Next returns the next entry of the archive, or io.EOF after the last one.
+	Next() (*archiveEntry, error)
+	Close() error
+}
+
+// archiveFormats opens the archives by their extensions.
+var archiveFormats = map[string]func(filename string) (archiveReader, error){
+	".zip":    openZip,
+	".tar.gz": openTar(func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }),
+	".tgz":    openTar(func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }),
+	".tar.xz": openTar(func(r io.Reader) (io.ReadCloser, error) {
+		reader, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(reader), nil
+	}),
+	".tar.zst": openTar(func(r io.Reader) (io.ReadCloser, error) {
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}),
+}
+
+// archiveFormat returns the extension of the archive, or an empty string if its format
+// isn't supported.
+func archiveFormat(filename string) string {
+	for ext := range archiveFormats {
+		if strings.HasSuffix(filename, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// extractOptions limits what the archives can extract.
+type extractOptions struct {
+	// AllowSymlinks extracts the symlinks that point inside the destination. The
+	// symlinks are rejected otherwise.
+	AllowSymlinks bool
+	MaxFileSize   int64
+	MaxTotalSize  int64
+}
+
+// defaultExtractOptions rejects the symlinks and limits the sizes of the archives.
+var defaultExtractOptions = extractOptions{
+	MaxFileSize:  MaxFileSize,
+	MaxTotalSize: MaxExtractedSize,
+}
+
+// extractArchive extracts the archive to the destination and returns the paths of the
+// extracted files. The entries that would be written outside of the destination,
+// i.e. ZipSlip and TarSlip, the unsupported entries and the archives that are larger
+// than the limits are rejected.
+func extractArchive(filename, dest string, options extractOptions) ([]string, error) {
+	ext := archiveFormat(filename)
+	if ext == "" {
+		return nil, gerr.ErrExtractFailed.Wrap(
+			fmt.Errorf("unsupported archive format: %s", filename))
+	}
+
+	archive, err := archiveFormats[ext](filename)
+	if err != nil {
+		return nil, gerr.ErrExtractFailed.Wrap(err)
+	}
+	defer archive.Close()
+
+	// Create the output directory if it doesn't exist.
+	if err := os.MkdirAll(dest, FolderPermissions); err != nil {
+		return nil, gerr.ErrExtractFailed.Wrap(err)
+	}
+	// The paths of the extracted files are returned relative to the destination as
+	// given, e.g. for the local paths of the plugins config.
+	root := dest
+	if dest, err = filepath.Abs(dest); err == nil {
+		dest, err = filepath.EvalSymlinks(dest)
+	}
+	if err != nil {
+		return nil, gerr.ErrExtractFailed.Wrap(err)
+	}
+
+	filenames := []string{}
+	var totalSize int64
+	for {
+		entry, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return filenames, gerr.ErrExtractFailed.Wrap(err)
+		}
+
+		target, err := containedPath(dest, entry.Name)
+		if err != nil {
+			return filenames, gerr.ErrExtractFailed.Wrap(err)
+		}
+
+		switch {
+		case entry.Mode.IsDir():
+			if err := os.MkdirAll(target, FolderPermissions); err != nil {
+				return filenames, gerr.ErrExtractFailed.Wrap(err)
+			}
+		case entry.Mode&os.ModeSymlink != 0:
+			if !options.AllowSymlinks {
+				return filenames, gerr.ErrExtractFailed.Wrap(
+					fmt.Errorf("symlinks are not allowed: %s", entry.Name))
+			}
+			parent, err := resolvedDir(dest, target)
+			if err != nil {
+				return filenames, gerr.ErrExtractFailed.Wrap(err)
+			}
+			// The target of the symlink must be inside the destination too.
+			linkname := entry.Linkname
+			if !filepath.IsAbs(linkname) {
+				linkname = filepath.Join(parent, linkname)
+			}
+			if !isWithin(dest, linkname) {
+				return filenames, gerr.ErrExtractFailed.Wrap(
+					fmt.Errorf("illegal symlink target: %s -> %s", entry.Name, entry.Linkname))
+			}
+			if err := os.Symlink(entry.Linkname, target); err != nil {
+				return filenames, gerr.ErrExtractFailed.Wrap(err)
+			}
+			filenames = append(filenames, filepath.Join(root, entry.Name))
+		case entry.Mode.IsRegular():
+			written, err := extractFile(entry, dest, target, options.MaxFileSize)
+			totalSize += written
+			if err != nil {
+				return filenames, err
+			}
+			if totalSize > options.MaxTotalSize {
+				os.Remove(target)
+				return filenames, gerr.ErrExtractFailed.Wrap(
+					fmt.Errorf("the archive is larger than %d bytes", options.MaxTotalSize))
+			}
+			filenames = append(filenames, filepath.Join(root, entry.Name))
+		default:
+			return filenames, gerr.ErrExtractFailed.Wrap(
+				fmt.Errorf("unknown file type: %s", entry.Name))
+		}
+	}
+
+	return filenames, nil
+}
+
+// containedPath returns the path of the entry in the destination, or an error if it
+// would be outside of it.
+func containedPath(dest, name string) (string, error) {
+	target := filepath.Join(dest, name) //nolint:gosec
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") || !isWithin(dest, target) {
+		return "", fmt.Errorf("illegal file path: %s", name)
+	}
+	return target, nil
+}
+
+// isWithin returns true if the path is the destination or is inside of it.
+func isWithin(dest, path string) bool {
+	relative, err := filepath.Rel(dest, path)
+	return err == nil && relative != ".." &&
+		!strings.HasPrefix(relative, ".."+string(os.PathSeparator))
+}
+
+// resolvedDir creates the parent directory of the target and returns it with its
+// symlinks resolved, or an error if they lead outside of the destination, e.g. with a
+// symlink extracted earlier.
+func resolvedDir(dest, target string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(target), FolderPermissions); err != nil {
+		return "", err
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(target))
+	if err != nil {
+		return "", err
+	}
+	if !isWithin(dest, parent) {
+		return "", fmt.Errorf("illegal file path: %s", target)
+	}
+	return parent, nil
+}
+
+// extractFile writes the contents of the entry to the target and returns the number of
+// bytes written. The files larger than the max size are removed.
+func extractFile(entry *archiveEntry, dest, target string, maxSize int64) (int64, error) {
+	if _, err := resolvedDir(dest, target); err != nil {
+		return 0, gerr.ErrExtractFailed.Wrap(err)
+	}
+
+	contents, err := entry.Open()
+	if err != nil {
+		return 0, gerr.ErrExtractFailed.Wrap(err)
+	}
+	defer contents.Close()
+
+	// Set the file permissions.
+	permissions := FilePermissions
+	if entry.Mode&ExecFileMask != 0 {
+		permissions = ExecFilePermissions
+	}
+	// Remove the existing file, so that a symlink in its place isn't followed.
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return 0, gerr.ErrExtractFailed.Wrap(err)
+	}
+	outFile, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, permissions)
+	if err != nil {
+		return 0, gerr.ErrExtractFailed.Wrap(err)
+	}
+	defer outFile.Close()
+
+	written, err := io.Copy(outFile, io.LimitReader(contents, maxSize+1))
+	if err == nil && written > maxSize {
+		err = fmt.Errorf("the file is larger than %d bytes: %s", maxSize, entry.Name)
+	}
+	if err != nil {
+		os.Remove(target)
+		return written, gerr.ErrExtractFailed.Wrap(err)
+	}
+	// The umask might have removed some of the permissions.
+	if err := os.Chmod(target, permissions); err != nil {
+		return written, gerr.ErrExtractFailed.Wrap(err)
+	}
+
+	return written, nil
+}
+
+// zipArchive reads the entries of a zip archive.
+type zipArchive struct {
+	*zip.ReadCloser
+	index int
+}
+
+func openZip(filename string) (archiveReader, error) {
+	reader, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &zipArchive{ReadCloser: reader}, nil
+}
+
+func (z *zipArchive) Next() (*archiveEntry, error) {
+	if z.index >= len(z.File) {
+		return nil, io.EOF
+	}
+	file := z.File[z.index]
+	z.index++
+
+	entry := &archiveEntry{Name: file.Name, Mode: file.Mode(), Open: file.Open}
+	if entry.Mode&os.ModeSymlink != 0 {
+		// The target of a symlink is the contents of its file.
+		contents, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer contents.Close()
+		linkname, err := io.ReadAll(io.LimitReader(contents, MaxLinknameSize))
+		if err != nil {
+			return nil, err
+		}
+		entry.Linkname = string(linkname)
+	}
+	return entry, nil
+}
+
+// tarArchive reads the entries of a compressed tar archive.
+type tarArchive struct {
+	file         *os.File
+	uncompressed io.ReadCloser
+	reader       *tar.Reader
+}
+
+func openTar(decompress func(io.Reader) (io.ReadCloser, error)) func(string) (archiveReader, error) {
+	return func(filename string) (archiveReader, error) {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		uncompressed, err := decompress(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &tarArchive{
+			file:         file,
+			uncompressed: uncompressed,
+			reader:       tar.NewReader(uncompressed),
+		}, nil
+	}
+}
+
+func (t *tarArchive) Next() (*archiveEntry, error) {
+	header, err := t.reader.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &archiveEntry{
+		Name:     header.Name,
+		Mode:     header.FileInfo().Mode(),
+		Linkname: header.Linkname,
+		Open:     func() (io.ReadCloser, error) { return io.NopCloser(t.reader), nil },
+	}
+	if header.Typeflag == tar.TypeLink {
+		// The hard links are rejected like the other unsupported entries.
+		entry.Mode = os.ModeIrregular
+	}
+	return entry, nil
+}
+
+func (t *tarArchive) Close() error {
+	t.uncompressed.Close()
+	return t.file.Close()
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+// testEntry is an entry of the archives created by the tests.
+type testEntry struct {
+	name     string
+	contents string
+	linkname string
+	typeflag byte
+	mode     int64
+}
+
+// createTar creates a tar archive of the entries, compressed by the extension.
+func createTar(t *testing.T, ext string, entries []testEntry) string {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	var compressed io.WriteCloser
+	var err error
+	switch ext {
+	case ".tar.gz":
+		compressed = gzip.NewWriter(&buffer)
+	case ".tar.xz":
+		compressed, err = xz.NewWriter(&buffer)
+	case ".tar.zst":
+		compressed, err = zstd.NewWriter(&buffer)
+	}
+	require.NoError(t, err)
+
+	writer := tar.NewWriter(compressed)
+	for _, entry := range entries {
+		typeflag := entry.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		mode := entry.mode
+		if mode == 0 {
+			mode = int64(FilePermissions)
+		}
+		require.NoError(t, writer.WriteHeader(&tar.Header{
+			Name:     entry.name,
+			Linkname: entry.linkname,
+			Typeflag: typeflag,
+			Mode:     mode,
+			Size:     int64(len(entry.contents)),
+		}))
+		_, err := writer.Write([]byte(entry.contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, compressed.Close())
+
+	filename := filepath.Join(t.TempDir(), "plugin"+ext)
+	require.NoError(t, os.WriteFile(filename, buffer.Bytes(), FilePermissions))
+	return filename
+}
+
+// createZip creates a zip archive of the entries.
+func createZip(t *testing.T, entries []testEntry) string {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name}
+		header.SetMode(FilePermissions)
+		contents := entry.contents
+		if entry.linkname != "" {
+			header.SetMode(os.ModeSymlink | FilePermissions)
+			contents = entry.linkname
+		}
+		file, err := writer.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = file.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	filename := filepath.Join(t.TempDir(), "plugin.zip")
+	require.NoError(t, os.WriteFile(filename, buffer.Bytes(), FilePermissions))
+	return filename
+}
+
+// Test_extractArchive tests that the supported archive formats are extracted.
+func Test_extractArchive(t *testing.T) {
+	entries := []testEntry{
+		{name: "plugin", contents: "binary", mode: int64(ExecFilePermissions)},
+		{name: "docs/", typeflag: tar.TypeDir, mode: int64(FolderPermissions)},
+		{name: "docs/README.md", contents: "readme"},
+	}
+
+	for _, ext := range []string{".tar.gz", ".tar.xz", ".tar.zst"} {
+		t.Run(ext, func(t *testing.T) {
+			dest := t.TempDir()
+			filenames, err := extractArchive(createTar(t, ext, entries), dest, defaultExtractOptions)
+			require.NoError(t, err)
+			assert.Len(t, filenames, 2)
+
+			info, err := os.Stat(filepath.Join(dest, "plugin"))
+			require.NoError(t, err)
+			assert.Equal(t, ExecFilePermissions, info.Mode().Perm())
+			readme, err := os.ReadFile(filepath.Join(dest, "docs", "README.md"))
+			require.NoError(t, err)
+			assert.Equal(t, "readme", string(readme))
+		})
+	}
+
+	t.Run(".zip", func(t *testing.T) {
+		dest := t.TempDir()
+		filenames, err := extractArchive(createZip(t, entries[:1]), dest, defaultExtractOptions)
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dest, "plugin")}, filenames)
+
+		// The paths are relative to the destination as given.
+		cwd, err := os.Getwd()
+		require.NoError(t, err)
+		require.NoError(t, os.Chdir(dest))
+		defer func() { require.NoError(t, os.Chdir(cwd)) }()
+		filenames, err = extractArchive(createZip(t, entries[:1]), "plugins", defaultExtractOptions)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"plugins/plugin"}, filenames)
+	})
+
+	_, err := extractArchive("plugin.rar", t.TempDir(), defaultExtractOptions)
+	assert.ErrorContains(t, err, "unsupported archive format")
+}
+
+// Test_extractArchive_Malicious tests that the malicious archives are rejected.
+func Test_extractArchive_Malicious(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []testEntry
+		options extractOptions
+		err     string
+	}{
+		{
+			name:    "TarSlip",
+			entries: []testEntry{{name: "../../evil", contents: "evil"}},
+			options: defaultExtractOptions,
+			err:     "illegal file path",
+		},
+		{
+			name:    "absolute path",
+			entries: []testEntry{{name: "/tmp/evil", contents: "evil"}},
+			options: defaultExtractOptions,
+			err:     "illegal file path",
+		},
+		{
+			name:    "symlink",
+			entries: []testEntry{{name: "link", linkname: "plugin", typeflag: tar.TypeSymlink}},
+			options: defaultExtractOptions,
+			err:     "symlinks are not allowed",
+		},
+		{
+			name:    "symlink outside",
+			entries: []testEntry{{name: "link", linkname: "/etc", typeflag: tar.TypeSymlink}},
+			options: extractOptions{AllowSymlinks: true, MaxFileSize: 10, MaxTotalSize: 10},
+			err:     "illegal symlink target",
+		},
+		{
+			name: "symlink chain",
+			entries: []testEntry{
+				{name: "dir", linkname: ".", typeflag: tar.TypeSymlink},
+				{name: "dir/link", linkname: "..", typeflag: tar.TypeSymlink},
+				{name: "dir/link/evil", contents: "evil"},
+			},
+			options: extractOptions{AllowSymlinks: true, MaxFileSize: 10, MaxTotalSize: 10},
+			err:     "illegal symlink target",
+		},
+		{
+			name:    "hard link",
+			entries: []testEntry{{name: "link", linkname: "/etc/passwd", typeflag: tar.TypeLink}},
+			options: defaultExtractOptions,
+			err:     "unknown file type",
+		},
+		{
+			name:    "file too large",
+			entries: []testEntry{{name: "plugin", contents: "0123456789a"}},
+			options: extractOptions{MaxFileSize: 10, MaxTotalSize: 100},
+			err:     "the file is larger than 10 bytes",
+		},
+		{
+			name: "archive too large",
+			entries: []testEntry{
+				{name: "first", contents: "0123456789"},
+				{name: "second", contents: "0123456789"},
+			},
+			options: extractOptions{MaxFileSize: 10, MaxTotalSize: 15},
+			err:     "the archive is larger than 15 bytes",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parent := t.TempDir()
+			dest := filepath.Join(parent, "plugins")
+			_, err := extractArchive(createTar(t, ".tar.gz", test.entries), dest, test.options)
+			assert.ErrorContains(t, err, test.err)
+			assert.NoFileExists(t, filepath.Join(parent, "evil"))
+		})
+	}
+
+	t.Run("ZipSlip", func(t *testing.T) {
+		_, err := extractArchive(
+			createZip(t, []testEntry{{name: "../evil", contents: "evil"}}),
+			t.TempDir(), defaultExtractOptions)
+		assert.ErrorContains(t, err, "illegal file path")
+	})
+
+	t.Run("zip symlink", func(t *testing.T) {
+		_, err := extractArchive(
+			createZip(t, []testEntry{{name: "link", linkname: "../../etc"}}),
+			t.TempDir(), extractOptions{AllowSymlinks: true, MaxFileSize: 10, MaxTotalSize: 10})
+		assert.ErrorContains(t, err, "illegal symlink target")
+	})
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// backendsCmd represents the backends command.
+var backendsCmd = &cobra.Command{
+	Use:   "backends",
+	Short: "Manage the database backends of a running GatewayD",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Help(); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backendsCmd)
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/spf13/cobra"
+)
+
+// backendsResolveCmd represents the backends resolve command.
+var backendsResolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Re-resolve the hostnames of the backends and move the pools to their new addresses",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := resolveBackends(cmd, apiURL); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	backendsCmd.AddCommand(backendsResolveCmd)
+
+	backendsResolveCmd.Flags().StringVarP(
+		&apiURL,
+		"api-url", "u",
+		"http://"+config.DefaultHTTPAPIAddress,
+		"URL of the HTTP API of the running GatewayD") // Already exists in debug_hooks.go
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_backendsResolveCmd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/backends/resolve", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`[{"proxy":"default","network":"tcp",` +
+			`"address":"db.example.com:5432","addresses":["10.0.0.2:5432"],"changed":true}]`))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	output, err := executeCommandC(rootCmd, "backends", "resolve", "-u", server.URL)
+	require.NoError(t, err, "backends resolve command should not have returned an error")
+	assert.Equal(t,
+		"PROXY    ADDRESS              RESOLVED TO    CHANGED\n"+
+			"default  db.example.com:5432  10.0.0.2:5432  true\n",
+		output)
+}
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/Masterminds/semver/v3"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+)
+
+// DefaultPluginCacheDir returns the default directory of the plugin cache, i.e.
+// ~/.gatewayd/cache.
+func DefaultPluginCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".gatewayd", "cache")
+	}
+	return filepath.Join(home, ".gatewayd", "cache")
+}
+
+// pluginCacheEntry is a release of a plugin in the cache, for the OS and architecture.
+type pluginCacheEntry struct {
+	Version  string `json:"version"`
+	Archive  string `json:"archive"`
+	Checksum string `json:"checksum"`
+	// URL is the URL the archive was downloaded from.
+	URL string `json:"url,omitempty"`
+	// Config is the default plugins configuration file of the repository of the plugin.
+	Config string `json:"config"`
+}
+
+// pluginCache holds the downloaded plugin archives, keyed by their SHA256 checksums,
+// and the releases that refer to them, so that the plugins can be installed again
+// without downloading them, or without access to GitHub at all.
+//
+// The archives are stored in blobs/sha256/<checksum>, the releases in
+// releases/<account>/<repository>/<version>/<os>-<arch>.json, and the info of the
+// plugins shown before installing them in info/<account>/<repository>/<version>.json.
+type pluginCache struct {
+	Dir string
+}
+
+func (c *pluginCache) blobsDir() string {
+	return filepath.Join(c.Dir, "blobs", "sha256")
+}
+
+func (c *pluginCache) blobPath(checksum string) string {
+	return filepath.Join(c.blobsDir(), checksum)
+}
+
+func (c *pluginCache) releasePath(account, repository, version string) string {
+	return filepath.Join(
+		c.Dir, "releases", account, repository, version, runtime.GOOS+"-"+runtime.GOARCH+".json")
+}
+
+func (c *pluginCache) infoPath(account, repository, version string) string {
+	return filepath.Join(c.Dir, "info", account, repository, version+".json")
+}
+
+// LookupInfo returns the cached info of the release of the plugin, as requested, i.e.
+// the latest version is the latest one when the info was fetched.
+func (c *pluginCache) LookupInfo(account, repository, version string) (*pluginInfo, bool) {
+	contents, err := os.ReadFile(c.infoPath(account, repository, version))
+	if err != nil {
+		return nil, false
+	}
+	var info pluginInfo
+	if err := json.Unmarshal(contents, &info); err != nil {
+		return nil, false
+	}
+	return &info, true
+}
+
+// StoreInfo records the info of the release of the plugin, as requested.
+func (c *pluginCache) StoreInfo(account, repository, version string, info *pluginInfo) error {
+	contents, err := json.Marshal(info)
+	if err != nil {
+		return gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	infoPath := c.infoPath(account, repository, version)
+	if err := os.MkdirAll(filepath.Dir(infoPath), FolderPermissions); err != nil {
+		return gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	if err := os.WriteFile(infoPath, contents, FilePermissions); err != nil {
+		return gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	return nil
+}
+
+// Lookup returns the cached release of the plugin. The latest version is the highest
+// cached version.
+func (c *pluginCache) Lookup(account, repository, version string) (*pluginCacheEntry, bool) {
+	if version == LatestVersion || version == "" {
+		versions, err := os.ReadDir(filepath.Join(c.Dir, "releases", account, repository))
+		if err != nil {
+			return nil, false
+		}
+		var latest *semver.Version
+		for _, dir := range versions {
+			parsed, err := semver.NewVersion(dir.Name())
+			if err != nil {
+				continue
+			}
+			if _, err := os.Stat(c.releasePath(account, repository, dir.Name())); err != nil {
+				continue
+			}
+			if latest == nil || parsed.GreaterThan(latest) {
+				latest = parsed
+				version = dir.Name()
+			}
+		}
+		if latest == nil {
+			return nil, false
+		}
+	}
+
+	contents, err := os.ReadFile(c.releasePath(account, repository, version))
+	if err != nil {
+		return nil, false
+	}
+	var entry pluginCacheEntry
+	if err := json.Unmarshal(contents, &entry); err != nil {
+		return nil, false
+	}
+	if _, err := os.Stat(c.blobPath(entry.Checksum)); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Store copies the archive of the pulled plugin to the cache, and records the release
+// that refers to it.
+func (c *pluginCache) Store(account, repository string, plugin pulledPlugin) error {
+	archive, err := os.Open(plugin.Archive)
+	if err != nil {
+		return gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	defer archive.Close()
+
+	blobs := c.blobsDir()
+	if err := os.MkdirAll(blobs, FolderPermissions); err != nil {
+		return gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	// The archive is copied to a temporary file first, since its checksum is only known
+	// once it is copied, so that a failed copy doesn't leave a corrupt blob.
+	blob, err := os.CreateTemp(blobs, "*"+PartialDownloadExt)
+	if err != nil {
+		return gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	defer os.Remove(blob.Name())
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(blob, hash), archive)
+	if closeErr := blob.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return gerr.ErrFileReadFailed.Wrap(err)
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if err := os.Rename(blob.Name(), c.blobPath(checksum)); err != nil {
+		return gerr.ErrFileOpenFailed.Wrap(err)
+	}
+
+	entry, err := json.Marshal(pluginCacheEntry{
+		Version:  plugin.Version,
+		Archive:  filepath.Base(plugin.Archive),
+		Checksum: checksum,
+		URL:      plugin.URL,
+		Config:   plugin.Config,
+	})
+	if err != nil {
+		return gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	releasePath := c.releasePath(account, repository, plugin.Version)
+	if err := os.MkdirAll(filepath.Dir(releasePath), FolderPermissions); err != nil {
+		return gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	if err := os.WriteFile(releasePath, entry, FilePermissions); err != nil {
+		return gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	return nil
+}
+
+// Restore copies the archive of the cached release to the directory, verifying its
+// checksum, and returns its path.
+func (c *pluginCache) Restore(entry *pluginCacheEntry, dir string) (string, error) {
+	blob, err := os.Open(c.blobPath(entry.Checksum))
+	if err != nil {
+		return "", gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	defer blob.Close()
+
+	archivePath := filepath.Join(dir, filepath.Base(entry.Archive))
+	archive, err := os.Create(archivePath)
+	if err != nil {
+		return "", gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	defer archive.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(archive, hash), blob); err != nil {
+		os.Remove(archivePath)
+		return "", gerr.ErrFileReadFailed.Wrap(err)
+	}
+	if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != entry.Checksum {
+		os.Remove(archivePath)
+		return "", gerr.ErrChecksumMismatch.Wrap(
+			fmt.Errorf("the cached archive %s is corrupt", entry.Checksum))
+	}
+	return archivePath, nil
+}
+
+// Prune removes the archives that no release refers to, and the releases whose archives
+// are missing, and returns the number of removed files. If all is set, the whole cache
+// is removed.
+func (c *pluginCache) Prune(all bool) (int, error) {
+	if all {
+		var removed int
+		_ = filepath.WalkDir(c.Dir, func(path string, dir os.DirEntry, err error) error {
+			if err == nil && !dir.IsDir() {
+				removed++
+			}
+			return nil
+		})
+		if err := os.RemoveAll(c.Dir); err != nil {
+			return 0, gerr.ErrFileOpenFailed.Wrap(err)
+		}
+		return removed, nil
+	}
+
+	var removed int
+	referenced := map[string]bool{}
+	releases := filepath.Join(c.Dir, "releases")
+	err := filepath.WalkDir(releases, func(path string, dir os.DirEntry, err error) error {
+		if err != nil || dir.IsDir() {
+			return err
+		}
+		var entry pluginCacheEntry
+		if contents, err := os.ReadFile(path); err == nil {
+			_ = json.Unmarshal(contents, &entry)
+		}
+		if _, err := os.Stat(c.blobPath(entry.Checksum)); entry.Checksum == "" || err != nil {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+			return nil
+		}
+		referenced[entry.Checksum] = true
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return removed, gerr.ErrFileOpenFailed.Wrap(err)
+	}
+
+	blobs, err := os.ReadDir(c.blobsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return removed, gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	for _, blob := range blobs {
+		if referenced[blob.Name()] {
+			continue
+		}
+		if err := os.Remove(c.blobPath(blob.Name())); err != nil {
+			return removed, gerr.ErrFileOpenFailed.Wrap(err)
+		}
+		removed++
+	}
+	return removed, nil
+}
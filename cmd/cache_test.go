@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_pluginCache tests that the plugin archives are cached by their checksums, and
+// that the latest version is the highest cached version.
+func Test_pluginCache(t *testing.T) {
+	cache := &pluginCache{Dir: t.TempDir()}
+	_, cached := cache.Lookup("gatewayd-io", "plugin", LatestVersion)
+	assert.False(t, cached)
+
+	dir := t.TempDir()
+	for _, version := range []string{"v0.2.0", "v0.10.0", "v0.9.0"} {
+		archive := filepath.Join(dir, "plugin-"+version+".tar.gz")
+		require.NoError(t, os.WriteFile(archive, []byte(version), FilePermissions))
+		require.NoError(t, cache.Store("gatewayd-io", "plugin", pulledPlugin{
+			Archive: archive, Version: version, Config: "config",
+		}))
+	}
+
+	entry, cached := cache.Lookup("gatewayd-io", "plugin", LatestVersion)
+	require.True(t, cached)
+	assert.Equal(t, "v0.10.0", entry.Version)
+	assert.Equal(t, "plugin-v0.10.0.tar.gz", entry.Archive)
+	assert.Equal(t, "config", entry.Config)
+	assert.FileExists(t, cache.blobPath(entry.Checksum))
+
+	restored, err := cache.Restore(entry, t.TempDir())
+	require.NoError(t, err)
+	contents, err := os.ReadFile(restored)
+	require.NoError(t, err)
+	assert.Equal(t, "v0.10.0", string(contents))
+
+	// The corrupt archives are not restored.
+	require.NoError(t, os.WriteFile(cache.blobPath(entry.Checksum), []byte("corrupt"), FilePermissions))
+	_, err = cache.Restore(entry, t.TempDir())
+	assert.ErrorContains(t, err, "corrupt")
+
+	_, cached = cache.Lookup("gatewayd-io", "plugin", "v0.3.0")
+	assert.False(t, cached)
+}
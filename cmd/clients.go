@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// clientsCmd represents the clients command.
+var clientsCmd = &cobra.Command{
+	Use:   "clients",
+	Short: "Show how the clients connect to GatewayD",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Help(); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(clientsCmd)
+}
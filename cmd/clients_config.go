@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	clientsConfigFormat   string
+	clientsConfigHost     string
+	clientsConfigUser     string
+	clientsConfigDatabase string
+)
+
+// clientsConfigCmd represents the clients config command.
+var clientsConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Print the connection strings of the listeners of the servers for the clients",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printClientsConfig(
+			cmd, globalConfigFile, clientsConfigFormat, clientsConfigHost,
+			clientsConfigUser, clientsConfigDatabase,
+		); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	clientsCmd.AddCommand(clientsConfigCmd)
+
+	clientsConfigCmd.Flags().StringVarP(
+		&globalConfigFile, // Already exists in run.go
+		"config", "c", config.GetDefaultConfigFilePath(config.GlobalConfigFilename),
+		"Global config file")
+	clientsConfigCmd.Flags().StringVar(
+		&clientsConfigFormat, "format", "dsn", "Format of the connection strings: psql, jdbc or dsn")
+	clientsConfigCmd.Flags().StringVar(
+		&clientsConfigHost, "host", "",
+		"Host the clients connect to, instead of the hosts of the listeners")
+	clientsConfigCmd.Flags().StringVar(
+		&clientsConfigUser, "user", "postgres", "User of the connection strings")
+	clientsConfigCmd.Flags().StringVar(
+		&clientsConfigDatabase, "database", "postgres", "Database of the connection strings")
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeClientsConfig writes a global config with a TLS server listening on the wildcard
+// address and on a unix socket.
+func writeClientsConfig(t *testing.T) string {
+	t.Helper()
+
+	globalConfigFile := filepath.Join(t.TempDir(), "gatewayd.yaml")
+	require.NoError(t, os.WriteFile(globalConfigFile, []byte(`servers:
+  default:
+    network: tcp
+    address: 0.0.0.0:15432
+    enableTLS: true
+    listeners:
+      - network: unix
+        address: /var/run/gatewayd/.s.PGSQL.15432
+`), 0o600))
+	return globalConfigFile
+}
+
+func Test_clientsConfigCmd(t *testing.T) {
+	globalConfigFile := writeClientsConfig(t)
+
+	output, err := executeCommandC(
+		rootCmd, "clients", "config", "-c", globalConfigFile, "--format", "psql")
+	require.NoError(t, err, "clients config command should not have returned an error")
+	assert.Equal(t, `# default: tcp 0.0.0.0:15432 (TLS)
+psql "host=localhost port=15432 dbname=postgres user=postgres sslmode=require"
+# default: unix /var/run/gatewayd/.s.PGSQL.15432 (TLS)
+psql "host=/var/run/gatewayd port=15432 dbname=postgres user=postgres sslmode=require"
+`, output)
+
+	output, err = executeCommandC(
+		rootCmd, "clients", "config", "-c", globalConfigFile, "--format", "jdbc",
+		"--host", "gatewayd.internal", "--user", "app", "--database", "orders")
+	require.NoError(t, err, "clients config command should not have returned an error")
+	assert.Contains(t, output,
+		"jdbc:postgresql://gatewayd.internal:15432/orders?user=app&sslmode=require\n")
+	assert.Contains(t, output, "# the JDBC driver can't connect to unix sockets\n")
+
+	output, err = executeCommandC(
+		rootCmd, "clients", "config", "-c", globalConfigFile, "--format", "dsn",
+		"--host", "", "--user", "postgres", "--database", "postgres")
+	require.NoError(t, err, "clients config command should not have returned an error")
+	assert.Contains(t, output, "postgres://postgres@localhost:15432/postgres?sslmode=require\n")
+	assert.Contains(t, output,
+		"postgres://postgres@/postgres?host=%2Fvar%2Frun%2Fgatewayd&port=15432&sslmode=require\n")
+}
@@ -20,6 +20,7 @@ var configInitCmd = &cobra.Command{
 				Dsn:              DSN,
 				TracesSampleRate: config.DefaultTraceSampleRate,
 				AttachStacktrace: config.DefaultAttachStacktrace,
+				BeforeSend:       tagErrors,
 			})
 			if err != nil {
 				cmd.Println("Sentry initialization failed: ", err)
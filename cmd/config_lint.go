@@ -9,6 +9,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	lintPlugins  bool
+	fetchSchemas bool
+)
+
 // configLintCmd represents the config lint command.
 var configLintCmd = &cobra.Command{
 	Use:   "lint",
@@ -21,6 +26,7 @@ var configLintCmd = &cobra.Command{
 				Dsn:              DSN,
 				TracesSampleRate: config.DefaultTraceSampleRate,
 				AttachStacktrace: config.DefaultAttachStacktrace,
+				BeforeSend:       tagErrors,
 			})
 			if err != nil {
 				cmd.Println("Sentry initialization failed: ", err)
@@ -38,6 +44,18 @@ var configLintCmd = &cobra.Command{
 		}
 
 		cmd.Println("global config is valid")
+
+		if !lintPlugins {
+			return
+		}
+		if err := lintConfig(Plugins, pluginConfigFile); err != nil {
+			log.Fatal(err)
+		}
+		if err := lintPluginSettings(cmd, pluginConfigFile, fetchSchemas, devMode); err != nil {
+			log.Fatal(err)
+		}
+
+		cmd.Println("plugins config is valid")
 	},
 }
 
@@ -48,6 +66,18 @@ func init() {
 		&globalConfigFile, // Already exists in run.go
 		"config", "c", config.GetDefaultConfigFilePath(config.GlobalConfigFilename),
 		"Global config file")
+	configLintCmd.Flags().BoolVar(
+		&lintPlugins, "plugins", false,
+		"Also lint the plugins config, and the settings of each plugin against its config schema")
+	configLintCmd.Flags().StringVarP(
+		&pluginConfigFile, // Already exists in run.go
+		"plugin-config", "p", config.GetDefaultConfigFilePath(config.PluginsConfigFilename),
+		"Plugin config file")
+	configLintCmd.Flags().BoolVar(
+		&fetchSchemas, "fetch-schemas", false,
+		"Start the plugins to fetch the config schemas they publish")
+	configLintCmd.Flags().BoolVar(
+		&devMode, "dev", false, "Enable development mode for plugin development") // Already exists in run.go
 	configLintCmd.Flags().BoolVar(
 		&enableSentry, "sentry", true, "Enable Sentry") // Already exists in run.go
 }
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// connsCmd represents the conns command.
+var connsCmd = &cobra.Command{
+	Use:   "conns",
+	Short: "Manage the client connections of a running GatewayD",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Help(); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(connsCmd)
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/spf13/cobra"
+)
+
+// connsKillCmd represents the conns kill command.
+var connsKillCmd = &cobra.Command{
+	Use:   "kill <id>",
+	Short: "Terminate a client connection at the gateway",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := killConnection(cmd, apiURL, args[0]); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	connsCmd.AddCommand(connsKillCmd)
+
+	connsKillCmd.Flags().StringVarP(
+		&apiURL,
+		"api-url", "u",
+		"http://"+config.DefaultHTTPAPIAddress,
+		"URL of the HTTP API of the running GatewayD") // Already exists in debug_hooks.go
+}
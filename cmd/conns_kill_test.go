@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_connsKillCmd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/connections/abc123", r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	output, err := executeCommandC(rootCmd, "conns", "kill", "abc123", "-u", server.URL)
+	require.NoError(t, err, "conns kill command should not have returned an error")
+	assert.Equal(t, "Killed connection abc123\n", output)
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/spf13/cobra"
+)
+
+// connsListCmd represents the conns list command.
+var connsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the client connections with their user, database, state, age and traffic",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := listConnections(cmd, apiURL); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	connsCmd.AddCommand(connsListCmd)
+
+	connsListCmd.Flags().StringVarP(
+		&apiURL,
+		"api-url", "u",
+		"http://"+config.DefaultHTTPAPIAddress,
+		"URL of the HTTP API of the running GatewayD") // Already exists in debug_hooks.go
+}
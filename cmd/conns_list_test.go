@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_connsListCmd(t *testing.T) {
+	since := time.Now().Add(-90 * time.Second).UTC().Format(time.RFC3339Nano)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/connections", r.URL.Path)
+		_, _ = w.Write([]byte(`[{"id":"abc123","proxy":"default","remote":"127.0.0.1:5000",` +
+			`"user":"postgres","database":"app","state":"in-transaction","since":"` + since + `",` +
+			`"bytesIn":120,"bytesOut":4096}]`))
+	}))
+	defer server.Close()
+
+	output, err := executeCommandC(rootCmd, "conns", "list", "-u", server.URL)
+	require.NoError(t, err, "conns list command should not have returned an error")
+	assert.Regexp(t,
+		`^ID\s+PROXY\s+CLIENT\s+USER\s+DATABASE\s+STATE\s+AGE\s+BYTES IN\s+BYTES OUT\n`+
+			`abc123\s+default\s+127\.0\.0\.1:5000\s+postgres\s+app\s+in-transaction\s+1m3\ds\s+120\s+4096\n$`,
+		output, "conns list command should have printed the connections")
+}
+
+func Test_connsListCmdEmpty(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	output, err := executeCommandC(rootCmd, "conns", "list", "-u", server.URL)
+	require.NoError(t, err, "conns list command should not have returned an error")
+	assert.Equal(t, "No client connections\n", output)
+}
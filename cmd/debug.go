@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// debugCmd represents the debug command.
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Debug a running GatewayD",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Help(); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(debugCmd)
+}
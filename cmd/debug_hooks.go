@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/spf13/cobra"
+)
+
+var apiURL string
+
+// debugHooksCmd represents the debug hooks command.
+var debugHooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Debug the plugin hooks of a running GatewayD",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Help(); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+// debugHooksDumpCmd represents the debug hooks dump command.
+var debugHooksDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Dump the hook chains recorded by the hook trace, with the inputs and outputs of each plugin",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := dumpHookTrace(cmd, apiURL); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	debugCmd.AddCommand(debugHooksCmd)
+	debugHooksCmd.AddCommand(debugHooksDumpCmd)
+
+	debugHooksDumpCmd.Flags().StringVarP(
+		&apiURL,
+		"api-url", "u",
+		"http://"+config.DefaultHTTPAPIAddress,
+		"URL of the HTTP API of the running GatewayD")
+}
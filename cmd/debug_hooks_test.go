@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_debugHooksDumpCmd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/debug/hooks", r.URL.Path)
+		_, _ = w.Write([]byte(`[{"time":"2023-01-01T00:00:00Z","hook":"HOOK_NAME_ON_TICK",` +
+			`"steps":[{"plugin":"gatewayd-plugin-cache","priority":1000,"input":{},"verified":true,"duration":1000}]}]`))
+	}))
+	defer server.Close()
+
+	output, err := executeCommandC(rootCmd, "debug", "hooks", "dump", "-u", server.URL)
+	require.NoError(t, err, "debug hooks dump command should not have returned an error")
+	assert.Equal(t, `[
+  {
+    "time": "2023-01-01T00:00:00Z",
+    "hook": "HOOK_NAME_ON_TICK",
+    "steps": [
+      {
+        "plugin": "gatewayd-plugin-cache",
+        "priority": 1000,
+        "input": {},
+        "verified": true,
+        "duration": 1000
+      }
+    ]
+  }
+]
+`, output, "debug hooks dump command should have printed the hook chains")
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	execFilePaths     []string
+	execPool          string
+	execUser          string
+	execPassword      string
+	execDatabase      string
+	execNoTransaction bool
+)
+
+// execCmd represents the exec command.
+var execCmd = &cobra.Command{
+	Use:   "exec",
+	Short: "Run SQL files through a running GatewayD, e.g. to bootstrap the schemas of a test environment",
+	Run: func(cmd *cobra.Command, args []string) {
+		password := execPassword
+		if password == "" {
+			password = os.Getenv("PGPASSWORD")
+		}
+		if err := execFiles(
+			cmd, globalConfigFile, execPool, execFilePaths,
+			execUser, password, execDatabase, !execNoTransaction,
+		); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	execCmd.Flags().StringVarP(
+		&globalConfigFile, // Already exists in run.go
+		"config", "c", config.GetDefaultConfigFilePath(config.GlobalConfigFilename),
+		"Global config file")
+	execCmd.Flags().StringSliceVar(
+		&execFilePaths, "file", nil, "SQL file to run, which can be repeated")
+	execCmd.Flags().StringVar(
+		&execPool, "pool", config.Default, "Pool whose server the statements run through")
+	execCmd.Flags().StringVar(&execUser, "user", "postgres", "User of the connection")
+	execCmd.Flags().StringVar(
+		&execPassword, "password", "", "Password of the user, or the PGPASSWORD environment variable")
+	execCmd.Flags().StringVar(&execDatabase, "database", "postgres", "Database of the connection")
+	execCmd.Flags().BoolVar(
+		&execNoTransaction, "no-transaction", false,
+		"Run the statements of the files without a transaction, e.g. for CREATE INDEX CONCURRENTLY")
+}
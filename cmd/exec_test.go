@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_splitStatements(t *testing.T) {
+	statements := splitStatements(`-- The schema of the users.
+CREATE TABLE users (id serial PRIMARY KEY, name text DEFAULT 'a;b', "x;y" int);
+/* A /* nested */ comment; */
+INSERT INTO users (name) VALUES ('it''s; fine'), ($1);
+CREATE FUNCTION hello() RETURNS text AS $body$
+  SELECT 'hello; world';
+$body$ LANGUAGE sql;
+DO $$ BEGIN PERFORM 1; END $$;
+-- Only a comment;
+SELECT 1`)
+	assert.Equal(t, []string{
+		"-- The schema of the users.\nCREATE TABLE users (id serial PRIMARY KEY, name text DEFAULT 'a;b', \"x;y\" int)",
+		"/* A /* nested */ comment; */\nINSERT INTO users (name) VALUES ('it''s; fine'), ($1)",
+		"CREATE FUNCTION hello() RETURNS text AS $body$\n  SELECT 'hello; world';\n$body$ LANGUAGE sql",
+		"DO $$ BEGIN PERFORM 1; END $$",
+		"-- Only a comment;\nSELECT 1",
+	}, statements)
+
+	assert.Empty(t, splitStatements("-- Nothing to run\n;\n"))
+	assert.Equal(t, "CREATE TABLE users (", statementSummary("-- Users\nCREATE TABLE users (\n  id int\n)"))
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// generateCmd represents the generate command.
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate files for integrating GatewayD with other tools",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Help(); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/getsentry/sentry-go"
+	"github.com/spf13/cobra"
+)
+
+var dashboardsOutputDir string
+
+// generateDashboardsCmd represents the generate dashboards command.
+var generateDashboardsCmd = &cobra.Command{
+	Use:   "dashboards",
+	Short: "Generate a Grafana dashboard and Prometheus alert rules for GatewayD",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Enable Sentry.
+		if enableSentry {
+			// Initialize Sentry.
+			err := sentry.Init(sentry.ClientOptions{
+				Dsn:              DSN,
+				TracesSampleRate: config.DefaultTraceSampleRate,
+				AttachStacktrace: config.DefaultAttachStacktrace,
+				BeforeSend:       tagErrors,
+			})
+			if err != nil {
+				cmd.Println("Sentry initialization failed: ", err)
+				return
+			}
+
+			// Flush buffered events before the program terminates.
+			defer sentry.Flush(config.DefaultFlushTimeout)
+			// Recover from panics and report the error to Sentry.
+			defer sentry.Recover()
+		}
+
+		generateDashboards(cmd, globalConfigFile, pluginConfigFile, dashboardsOutputDir, force)
+	},
+}
+
+func init() {
+	generateCmd.AddCommand(generateDashboardsCmd)
+
+	generateDashboardsCmd.Flags().StringVarP(
+		&globalConfigFile, // Already exists in run.go
+		"config", "c", config.GetDefaultConfigFilePath(config.GlobalConfigFilename),
+		"Global config file")
+	generateDashboardsCmd.Flags().StringVarP(
+		&pluginConfigFile, // Already exists in run.go
+		"plugin-config", "p", config.GetDefaultConfigFilePath(config.PluginsConfigFilename),
+		"Plugin config file")
+	generateDashboardsCmd.Flags().StringVarP(
+		&dashboardsOutputDir, "output-dir", "o", ".",
+		"Output directory for the dashboard and alert rules")
+	generateDashboardsCmd.Flags().BoolVarP(
+		&force, "force", "f", false, "Force overwrite of existing files")
+	generateDashboardsCmd.Flags().BoolVar(
+		&enableSentry, "sentry", true, "Enable Sentry") // Already exists in run.go
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_generateDashboardsCmd(t *testing.T) {
+	outputDir := t.TempDir()
+	dashboardFile := filepath.Join(outputDir, config.DashboardFilename)
+	alertRulesFile := filepath.Join(outputDir, config.AlertRulesFilename)
+
+	output, err := executeCommandC(
+		rootCmd, "generate", "dashboards",
+		"-c", "../gatewayd.yaml", "-p", "../gatewayd_plugins.yaml", "-o", outputDir)
+	require.NoError(t, err, "generateDashboardsCmd should not return an error")
+	assert.Equal(t,
+		fmt.Sprintf(
+			"File '%s' was generated successfully.\nFile '%s' was generated successfully.\n",
+			dashboardFile, alertRulesFile),
+		output,
+		"generateDashboardsCmd should print the correct output")
+	assert.FileExists(t, dashboardFile, "generateDashboardsCmd should create a dashboard")
+	assert.FileExists(t, alertRulesFile, "generateDashboardsCmd should create alert rules")
+
+	// The pool exhaustion alert matches the size of the default pool.
+	alertRules, err := os.ReadFile(alertRulesFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(alertRules), "sum(gatewayd_proxied_connections) >= 10")
+}
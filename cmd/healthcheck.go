@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/spf13/cobra"
+)
+
+// healthCheckCmd represents the healthcheck command.
+var healthCheckCmd = &cobra.Command{
+	Use:   "healthcheck",
+	Short: "Check that a running GatewayD is ready, e.g. for a Docker HEALTHCHECK",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := healthCheck(cmd, apiURL); err != nil {
+			// The exit code is 1, which marks the container as unhealthy.
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(healthCheckCmd)
+
+	healthCheckCmd.Flags().StringVarP(
+		&apiURL,
+		"api-url", "u",
+		"http://"+config.DefaultHTTPAPIAddress,
+		"URL of the HTTP API of the running GatewayD") // Already exists in debug_hooks.go
+}
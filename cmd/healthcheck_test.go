@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_healthCheckCmd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/healthz", r.URL.Path)
+		_, _ = w.Write([]byte(`{"status":"SERVING"}`))
+	}))
+	defer server.Close()
+
+	output, err := executeCommandC(rootCmd, "healthcheck", "-u", server.URL)
+	require.NoError(t, err, "healthcheck command should not have returned an error")
+	assert.Equal(t, "SERVING\n", output)
+}
+
+func Test_healthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"status":"NOT_SERVING"}`))
+	}))
+	defer server.Close()
+
+	err := healthCheck(healthCheckCmd, server.URL)
+	require.Error(t, err, "healthcheck should have failed")
+	assert.Contains(t, err.Error(), "NOT_SERVING")
+}
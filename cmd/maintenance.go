@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// maintenanceCmd represents the maintenance command.
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Turn the maintenance mode of a running GatewayD on or off",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Help(); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(maintenanceCmd)
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/spf13/cobra"
+)
+
+// maintenanceOffCmd represents the maintenance off command.
+var maintenanceOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Accept the new connections again, including the queued ones",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := setMaintenance(cmd, apiURL, false, "", false, 0); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	maintenanceCmd.AddCommand(maintenanceOffCmd)
+
+	maintenanceOffCmd.Flags().StringVarP(
+		&apiURL,
+		"api-url", "u",
+		"http://"+config.DefaultHTTPAPIAddress,
+		"URL of the HTTP API of the running GatewayD") // Already exists in debug_hooks.go
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_maintenanceOffCmd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "false", r.URL.Query().Get("enabled"))
+		_, _ = w.Write([]byte(`{"enabled":false,"queued":2}`))
+	}))
+	defer server.Close()
+
+	output, err := executeCommandC(rootCmd, "maintenance", "off", "-u", server.URL)
+	require.NoError(t, err, "maintenance off command should not have returned an error")
+	assert.Equal(t, "Maintenance mode is off, 2 queued connections are let through\n", output)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"log"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/spf13/cobra"
+)
+
+var (
+	maintenanceMessage      string
+	maintenanceQueue        bool
+	maintenanceQueueTimeout time.Duration
+)
+
+// maintenanceOnCmd represents the maintenance on command.
+var maintenanceOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Reject or queue the new connections, while keeping the existing ones",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := setMaintenance(
+			cmd, apiURL, true, maintenanceMessage, maintenanceQueue, maintenanceQueueTimeout,
+		); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	maintenanceCmd.AddCommand(maintenanceOnCmd)
+
+	maintenanceOnCmd.Flags().StringVarP(
+		&maintenanceMessage,
+		"message", "m",
+		network.DefaultMaintenanceMessage,
+		"Message of the error the new connections are rejected with")
+	maintenanceOnCmd.Flags().BoolVarP(
+		&maintenanceQueue,
+		"queue", "q",
+		false,
+		"Queue the new connections until the maintenance mode is off, instead of rejecting them")
+	maintenanceOnCmd.Flags().DurationVar(
+		&maintenanceQueueTimeout,
+		"queue-timeout",
+		0,
+		"Reject the queued connections after this duration, 0 means never")
+	maintenanceOnCmd.Flags().StringVarP(
+		&apiURL,
+		"api-url", "u",
+		"http://"+config.DefaultHTTPAPIAddress,
+		"URL of the HTTP API of the running GatewayD") // Already exists in debug_hooks.go
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_maintenanceOnCmd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/maintenance", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("enabled"))
+		assert.Equal(t, "upgrading", r.URL.Query().Get("message"))
+		assert.Equal(t, "false", r.URL.Query().Get("queue"))
+		_, _ = w.Write([]byte(`{"enabled":true,"message":"upgrading","queue":false}`))
+	}))
+	defer server.Close()
+
+	output, err := executeCommandC(
+		rootCmd, "maintenance", "on", "--message", "upgrading", "-u", server.URL)
+	require.NoError(t, err, "maintenance on command should not have returned an error")
+	assert.Equal(t, "Maintenance mode is on, new connections are rejected: upgrading\n", output)
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// pluginCacheCmd represents the plugin cache command.
+var pluginCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local cache of the downloaded plugin archives",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Help(); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginCacheCmd)
+}
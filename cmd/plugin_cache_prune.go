@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var pruneAll bool
+
+// pluginCachePruneCmd represents the plugin cache prune command.
+var pluginCachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove the plugin archives that no release refers to from the cache",
+	Run: func(cmd *cobra.Command, args []string) {
+		removed, err := (&pluginCache{Dir: pluginCacheDir}).Prune(pruneAll)
+		if err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+		cmd.Printf("Removed %d files from the plugin cache\n", removed)
+	},
+}
+
+func init() {
+	pluginCacheCmd.AddCommand(pluginCachePruneCmd)
+
+	pluginCachePruneCmd.Flags().StringVar(
+		&pluginCacheDir, "cache-dir", DefaultPluginCacheDir(),
+		"Directory of the plugin cache") // Already exists in plugin_install.go
+	pluginCachePruneCmd.Flags().BoolVar(
+		&pruneAll, "all", false, "Remove all the plugin archives and releases from the cache")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_pluginCachePruneCmd(t *testing.T) {
+	cache := &pluginCache{Dir: t.TempDir()}
+	archive := filepath.Join(t.TempDir(), "plugin.tar.gz")
+	require.NoError(t, os.WriteFile(archive, []byte("plugin"), FilePermissions))
+	require.NoError(t, cache.Store(
+		"gatewayd-io", "plugin", pulledPlugin{Archive: archive, Version: "v0.1.0", Config: "plugins: []"}))
+	// The blob of an older release that was overwritten is no longer referenced.
+	require.NoError(t, os.WriteFile(cache.blobPath("unreferenced"), []byte("old"), FilePermissions))
+
+	output, err := executeCommandC(rootCmd, "plugin", "cache", "prune", "--cache-dir", cache.Dir)
+	require.NoError(t, err, "plugin cache prune command should not have returned an error")
+	assert.Equal(t, "Removed 1 files from the plugin cache\n", output)
+	_, cached := cache.Lookup("gatewayd-io", "plugin", "v0.1.0")
+	assert.True(t, cached)
+
+	output, err = executeCommandC(
+		rootCmd, "plugin", "cache", "prune", "--cache-dir", cache.Dir, "--all")
+	require.NoError(t, err, "plugin cache prune command should not have returned an error")
+	assert.Equal(t, "Removed 2 files from the plugin cache\n", output)
+	assert.NoDirExists(t, cache.Dir)
+
+	pruneAll = false
+	pluginCacheDir = DefaultPluginCacheDir()
+}
@@ -0,0 +1,361 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/getsentry/sentry-go"
+	"github.com/google/go-github/v53/github"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// PluginMetadataAssetSuffix is the suffix of the release asset that holds the
+	// metadata of the plugin, as returned by its GetPluginConfig method, in JSON.
+	PluginMetadataAssetSuffix string        = "metadata.json"
+	MaxReleaseMetadataSize    int64         = MiB
+	DefaultPluginInfoMaxAge   time.Duration = 24 * time.Hour
+)
+
+var pluginInfoMaxAge time.Duration
+
+// pluginInfo is the info of a release of a plugin, to vet it before installing it.
+type pluginInfo struct {
+	Name          string    `json:"name"`
+	Description   string    `json:"description,omitempty"`
+	Authors       []string  `json:"authors,omitempty"`
+	License       string    `json:"license,omitempty"`
+	Hooks         []string  `json:"hooks,omitempty"`
+	Capabilities  []string  `json:"capabilities,omitempty"`
+	Version       string    `json:"version"`
+	LatestVersion string    `json:"latestVersion"`
+	PublishedAt   time.Time `json:"publishedAt"`
+	// Archive is the plugin archive for the OS and architecture, and Checksum its SHA256
+	// checksum from the checksums file of the release.
+	Archive   string    `json:"archive,omitempty"`
+	Checksum  string    `json:"checksum,omitempty"`
+	Changelog string    `json:"changelog,omitempty"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// pluginMetadata is the part of the metadata of a plugin shown in its info.
+type pluginMetadata struct {
+	Description  string   `json:"description"`
+	Authors      []string `json:"authors"`
+	License      string   `json:"license"`
+	Capabilities []string `json:"capabilities"`
+	// Hooks are either the numbers of the hooks, as in the metadata of the plugin, or
+	// their names.
+	Hooks []interface{} `json:"hooks"`
+}
+
+// pluginInfoCmd represents the plugin info command.
+var pluginInfoCmd = &cobra.Command{
+	Use:     "info",
+	Short:   "Show the info of a plugin release, to vet the plugin before installing it",
+	Example: "  gatewayd plugin info github.com/gatewayd-io/gatewayd-plugin-cache@latest",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Enable Sentry.
+		if enableSentry {
+			// Initialize Sentry.
+			err := sentry.Init(sentry.ClientOptions{
+				Dsn:              DSN,
+				TracesSampleRate: config.DefaultTraceSampleRate,
+				AttachStacktrace: config.DefaultAttachStacktrace,
+				BeforeSend:       tagErrors,
+			})
+			if err != nil {
+				cmd.Println("Sentry initialization failed: ", err)
+				return
+			}
+
+			// Flush buffered events before the program terminates.
+			defer sentry.Flush(config.DefaultFlushTimeout)
+			// Recover from panics and report the error to Sentry.
+			defer sentry.Recover()
+		}
+
+		// The plugins can be hosted on GitHub or on a GitHub Enterprise server.
+		if gitHubURL == "" {
+			gitHubURL = os.Getenv(GitHubServerURLEnv)
+		}
+		if gitHubURL == "" {
+			gitHubURL = GitHubServerURL
+		}
+		urlPrefix, apiHost, err := parseGitHubServer(gitHubURL)
+		if err != nil {
+			cmd.Println("Invalid GitHub URL: ", err)
+			return
+		}
+
+		if len(args) < 1 {
+			cmd.Println(
+				"Invalid URL. Use the following format: " + urlPrefix + "account/repository[@version]")
+			return
+		}
+		account, repository, version, err := parsePluginURL(urlPrefix, args[0])
+		if err != nil {
+			cmd.Println(err)
+			return
+		}
+
+		// The info is fetched again once it is older than the max age, unless in the
+		// offline mode. If it can't be fetched, the cached info is shown instead.
+		cache := &pluginCache{Dir: pluginCacheDir}
+		info, cached := cache.LookupInfo(account, repository, version)
+		if !cached && offline {
+			cmd.Println("The plugin info could not be found in the cache")
+			return
+		}
+		if !offline && (!cached || time.Since(info.FetchedAt) > pluginInfoMaxAge) {
+			fetched, err := fetchPluginInfo(account, repository, version, apiHost)
+			switch {
+			case err == nil:
+				info = fetched
+				if err := cache.StoreInfo(account, repository, version, info); err != nil {
+					cmd.Println("There was an error caching the plugin info: ", err)
+				}
+			case cached:
+				cmd.Println("Using the cached plugin info, since it could not be fetched: ", err)
+			default:
+				cmd.Println(err)
+				return
+			}
+		}
+
+		printPluginInfo(cmd, info)
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginInfoCmd)
+
+	pluginInfoCmd.Flags().BoolVar(
+		&offline, "offline", false,
+		"Only show the plugin info from the cache, without GitHub") // Already exists in plugin_install.go
+	pluginInfoCmd.Flags().StringVar(
+		&pluginCacheDir, "cache-dir", DefaultPluginCacheDir(),
+		"Directory of the plugin cache") // Already exists in plugin_install.go
+	pluginInfoCmd.Flags().DurationVar(
+		&pluginInfoMaxAge, "max-age", DefaultPluginInfoMaxAge,
+		"Age of the cached plugin info after which it is fetched again")
+	pluginInfoCmd.Flags().StringVar(
+		&caBundle, "ca-bundle", "",
+		"CA bundle to trust, in addition to the system CAs") // Already exists in plugin_install.go
+	pluginInfoCmd.Flags().StringVar(
+		&gitHubToken, "github-token", "",
+		"GitHub token to authenticate the requests with (defaults to $"+GitHubTokenEnv+")",
+	) // Already exists in plugin_install.go
+	pluginInfoCmd.Flags().StringVar(
+		&gitHubURL, "github-url", "",
+		"URL of the GitHub Enterprise server hosting the plugins (defaults to $"+GitHubServerURLEnv+" or "+
+			GitHubServerURL+")") // Already exists in plugin_install.go
+	pluginInfoCmd.Flags().BoolVar(
+		&enableSentry, "sentry", true, "Enable Sentry") // Already exists in run.go
+}
+
+// parsePluginURL returns the account, the repository and the version of the plugin URL,
+// e.g. github.com/account/repository@version. The version defaults to the latest one.
+//
+//nolint:goerr113,stylecheck
+func parsePluginURL(urlPrefix, pluginURL string) (string, string, string, error) {
+	pluginURL = strings.TrimPrefix(pluginURL, "http://")
+	pluginURL = strings.TrimPrefix(pluginURL, "https://")
+	if !strings.Contains(pluginURL, "@") {
+		pluginURL += "@" + LatestVersion
+	}
+
+	validGitHubURL := regexp.MustCompile("^" + regexp.QuoteMeta(urlPrefix) + GitHubRepositoryRegex)
+	if !validGitHubURL.MatchString(pluginURL) {
+		return "", "", "", errors.New(
+			"Invalid URL. Use the following format: " + urlPrefix + "account/repository[@version]")
+	}
+	repository, version, _ := strings.Cut(strings.TrimPrefix(pluginURL, urlPrefix), "@")
+	account, repository, _ := strings.Cut(repository, "/")
+	return account, repository, version, nil
+}
+
+// fetchPluginInfo fetches the info of the release of the plugin from GitHub, with the
+// clients created from the flags of the command.
+//
+//nolint:goerr113,stylecheck
+func fetchPluginInfo(account, repository, version, apiHost string) (*pluginInfo, error) {
+	if gitHubToken == "" {
+		gitHubToken = os.Getenv(GitHubTokenEnv)
+	}
+	httpClient, err := newHTTPClient(caBundle, gitHubToken, apiHost)
+	if err != nil {
+		return nil, fmt.Errorf("There was an error creating the HTTP client: %w", err)
+	}
+	client, err := newGitHubClient(gitHubURL, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("There was an error creating the GitHub client: %w", err)
+	}
+	return fetchReleaseInfo(client, httpClient, account, repository, version)
+}
+
+// fetchReleaseInfo fetches the info of the release of the plugin from the release, its
+// checksums file and its metadata asset, if any. The description, authors and license
+// default to the ones of the repository, for the plugins that don't publish their
+// metadata. The returned errors are meant for the user.
+//
+//nolint:goerr113,stylecheck
+func fetchReleaseInfo(
+	client *github.Client, httpClient *http.Client, account, repository, version string,
+) (*pluginInfo, error) {
+	ctx := context.Background()
+	latest, _, err := client.Repositories.GetLatestRelease(ctx, account, repository)
+	if err != nil {
+		return nil, fmt.Errorf("The plugin could not be found: %w", err)
+	}
+	release := latest
+	if version != LatestVersion && version != "" {
+		release, _, err = client.Repositories.GetReleaseByTag(ctx, account, repository, version)
+		if err != nil {
+			return nil, fmt.Errorf("The plugin version could not be found: %w", err)
+		}
+	}
+	repo, _, err := client.Repositories.Get(ctx, account, repository)
+	if err != nil {
+		return nil, fmt.Errorf("The plugin repository could not be found: %w", err)
+	}
+
+	info := &pluginInfo{
+		Name:          repository,
+		Description:   repo.GetDescription(),
+		Authors:       []string{repo.GetOwner().GetLogin()},
+		License:       repo.GetLicense().GetSPDXID(),
+		Version:       release.GetTagName(),
+		LatestVersion: latest.GetTagName(),
+		PublishedAt:   release.GetPublishedAt().Time,
+		Changelog:     strings.TrimSpace(release.GetBody()),
+		FetchedAt:     time.Now(),
+	}
+
+	if archive, _, _ := findPluginArchive(release); archive != "" {
+		info.Archive = archive
+		_, _, checksumsID := findAsset(release, func(name string) bool {
+			return strings.HasSuffix(name, "checksums.txt")
+		})
+		if checksumsID != 0 {
+			checksums, err := readReleaseAsset(client, httpClient, account, repository, checksumsID)
+			if err != nil {
+				return nil, fmt.Errorf("The checksum file could not be downloaded: %w", err)
+			}
+			info.Checksum = parseChecksums(string(checksums))[archive]
+		}
+	}
+
+	_, _, metadataID := findAsset(release, func(name string) bool {
+		return strings.HasSuffix(name, PluginMetadataAssetSuffix)
+	})
+	if metadataID == 0 {
+		return info, nil
+	}
+	contents, err := readReleaseAsset(client, httpClient, account, repository, metadataID)
+	if err != nil {
+		return nil, fmt.Errorf("The plugin metadata could not be downloaded: %w", err)
+	}
+	var metadata pluginMetadata
+	if err := json.Unmarshal(contents, &metadata); err != nil {
+		return nil, fmt.Errorf("The plugin metadata is invalid: %w", err)
+	}
+	if metadata.Description != "" {
+		info.Description = metadata.Description
+	}
+	if len(metadata.Authors) > 0 {
+		info.Authors = metadata.Authors
+	}
+	if metadata.License != "" {
+		info.License = metadata.License
+	}
+	info.Capabilities = metadata.Capabilities
+	for _, hook := range metadata.Hooks {
+		switch hook := hook.(type) {
+		case float64:
+			info.Hooks = append(info.Hooks, plugin.HookNameString(v1.HookName(hook)))
+		case string:
+			if hookName, ok := plugin.ParseHookName(hook); ok {
+				info.Hooks = append(info.Hooks, plugin.HookNameString(hookName))
+			} else {
+				info.Hooks = append(info.Hooks, hook)
+			}
+		}
+	}
+	return info, nil
+}
+
+// readReleaseAsset reads the small release asset, e.g. the checksums file, in memory.
+func readReleaseAsset(
+	client *github.Client, httpClient *http.Client, account, repository string, assetID int64,
+) ([]byte, error) {
+	readCloser, _, err := client.Repositories.DownloadReleaseAsset(
+		context.Background(), account, repository, assetID, httpClient)
+	if err != nil {
+		return nil, gerr.ErrDownloadFailed.Wrap(err)
+	}
+	defer readCloser.Close()
+
+	contents, err := io.ReadAll(io.LimitReader(readCloser, MaxReleaseMetadataSize+1))
+	if err != nil {
+		return nil, gerr.ErrDownloadFailed.Wrap(err)
+	}
+	if int64(len(contents)) > MaxReleaseMetadataSize {
+		return nil, gerr.ErrDownloadFailed.Wrap(
+			fmt.Errorf("the asset is larger than %d bytes", MaxReleaseMetadataSize))
+	}
+	return contents, nil
+}
+
+// printPluginInfo prints the info of the plugin release.
+func printPluginInfo(cmd *cobra.Command, info *pluginInfo) {
+	cmd.Printf("Name: %s\n", info.Name)
+	cmd.Printf("Description: %s\n", info.Description)
+	cmd.Printf("Authors: %s\n", strings.Join(info.Authors, ", "))
+	cmd.Printf("License: %s\n", info.License)
+	cmd.Printf("Version: %s\n", info.Version)
+	cmd.Printf("Latest version: %s\n", info.LatestVersion)
+	if !info.PublishedAt.IsZero() {
+		cmd.Printf("Published at: %s\n", info.PublishedAt.Format(time.RFC3339))
+	}
+
+	cmd.Println("Hooks:")
+	if len(info.Hooks) == 0 {
+		cmd.Println("  None published in the release metadata")
+	}
+	for _, hook := range info.Hooks {
+		cmd.Printf("  %s\n", hook)
+	}
+	cmd.Println("Capabilities:")
+	if len(info.Capabilities) == 0 {
+		cmd.Println("  None declared, so the plugin has all of them unless they are required")
+	}
+	for _, capability := range info.Capabilities {
+		cmd.Printf("  %s\n", capability)
+	}
+
+	if info.Archive == "" {
+		cmd.Println("Archive: No archive for this OS and architecture")
+	} else {
+		cmd.Printf("Archive: %s\n", info.Archive)
+		cmd.Printf("Checksum: %s\n", config.If[string](
+			info.Checksum != "", info.Checksum, "Not published in the checksums file"))
+	}
+
+	cmd.Println("Changelog:")
+	for _, line := range strings.Split(info.Changelog, "\n") {
+		cmd.Printf("  %s\n", strings.TrimRight(line, "\r"))
+	}
+}
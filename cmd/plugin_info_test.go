@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPluginReleaseServer returns a GitHub Enterprise server with the v0.1.0 and the
+// latest v0.2.0 releases of a plugin, the latter with its checksums and metadata.
+func newPluginReleaseServer(t *testing.T) (*httptest.Server, *int) {
+	t.Helper()
+
+	archive := "plugin-" + runtime.GOOS + "-" + runtime.GOARCH + "-v0.2.0" + ExtOthers
+	if runtime.GOOS == "windows" {
+		archive = "plugin-" + runtime.GOOS + "-" + runtime.GOARCH + "-v0.2.0" + ExtWindows
+	}
+	responses := map[string]interface{}{
+		"/api/v3/repos/gatewayd-io/plugin": map[string]interface{}{
+			"description": "A plugin from the repository",
+			"owner":       map[string]interface{}{"login": "gatewayd-io"},
+			"license":     map[string]interface{}{"spdx_id": "Apache-2.0"},
+		},
+		"/api/v3/repos/gatewayd-io/plugin/releases/latest": map[string]interface{}{
+			"tag_name":     "v0.2.0",
+			"body":         "## Changes\r\n- Cache the results\r\n",
+			"published_at": "2024-05-01T10:00:00Z",
+			"assets": []map[string]interface{}{
+				{"id": 1, "name": archive},
+				{"id": 2, "name": "checksums.txt"},
+				{"id": 3, "name": "plugin-v0.2.0-metadata.json"},
+			},
+		},
+		"/api/v3/repos/gatewayd-io/plugin/releases/tags/v0.1.0": map[string]interface{}{
+			"tag_name": "v0.1.0",
+			"body":     "Initial release",
+		},
+		"/api/v3/repos/gatewayd-io/plugin/releases/assets/2": "abc123  " + archive + "\n",
+		"/api/v3/repos/gatewayd-io/plugin/releases/assets/3": map[string]interface{}{
+			"description":  "A plugin that caches the results",
+			"authors":      []string{"Alice", "Bob"},
+			"hooks":        []interface{}{16, 1000, "onTrafficToClient"},
+			"capabilities": []string{"read_traffic", "terminate_connections"},
+		},
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if contents, ok := response.(string); ok {
+			_, _ = w.Write([]byte(contents))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func Test_parsePluginURL(t *testing.T) {
+	account, repository, version, err := parsePluginURL(
+		GitHubURLPrefix, "https://github.com/gatewayd-io/gatewayd-plugin-cache@v0.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, "gatewayd-io", account)
+	assert.Equal(t, "gatewayd-plugin-cache", repository)
+	assert.Equal(t, "v0.2.0", version)
+
+	_, _, version, err = parsePluginURL(GitHubURLPrefix, "github.com/gatewayd-io/gatewayd-plugin-cache")
+	require.NoError(t, err)
+	assert.Equal(t, LatestVersion, version)
+
+	_, _, _, err = parsePluginURL(GitHubURLPrefix, "gitlab.com/gatewayd-io/gatewayd-plugin-cache")
+	assert.Error(t, err)
+	_, _, _, err = parsePluginURL(GitHubURLPrefix, "github.com/gatewayd-plugin-cache@latest")
+	assert.Error(t, err)
+}
+
+// Test_fetchReleaseInfo tests that the info of the release is taken from its metadata,
+// falling back to the repository for the releases without it.
+func Test_fetchReleaseInfo(t *testing.T) {
+	server, _ := newPluginReleaseServer(t)
+	client, err := newGitHubClient(server.URL, server.Client())
+	require.NoError(t, err)
+
+	info, err := fetchReleaseInfo(client, server.Client(), "gatewayd-io", "plugin", LatestVersion)
+	require.NoError(t, err)
+	assert.Equal(t, "plugin", info.Name)
+	assert.Equal(t, "A plugin that caches the results", info.Description)
+	assert.Equal(t, []string{"Alice", "Bob"}, info.Authors)
+	assert.Equal(t, "Apache-2.0", info.License)
+	assert.Equal(t, []string{
+		"HOOK_NAME_ON_TRAFFIC_FROM_CLIENT", "onScheduled", "HOOK_NAME_ON_TRAFFIC_TO_CLIENT",
+	}, info.Hooks)
+	assert.Equal(t, []string{"read_traffic", "terminate_connections"}, info.Capabilities)
+	assert.Equal(t, "v0.2.0", info.Version)
+	assert.Equal(t, "v0.2.0", info.LatestVersion)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), info.PublishedAt.UTC())
+	assert.Contains(t, info.Archive, runtime.GOOS+"-"+runtime.GOARCH)
+	assert.Equal(t, "abc123", info.Checksum)
+	assert.Equal(t, "## Changes\r\n- Cache the results", info.Changelog)
+
+	info, err = fetchReleaseInfo(client, server.Client(), "gatewayd-io", "plugin", "v0.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "A plugin from the repository", info.Description)
+	assert.Equal(t, []string{"gatewayd-io"}, info.Authors)
+	assert.Empty(t, info.Hooks)
+	assert.Equal(t, "v0.1.0", info.Version)
+	assert.Equal(t, "v0.2.0", info.LatestVersion)
+	assert.Empty(t, info.Archive)
+
+	_, err = fetchReleaseInfo(client, server.Client(), "gatewayd-io", "plugin", "v0.3.0")
+	assert.ErrorContains(t, err, "The plugin version could not be found")
+}
+
+// Test_pluginInfoCmd tests that the info of the plugin is fetched once and then shown
+// from the cache, until it is older than the max age.
+func Test_pluginInfoCmd(t *testing.T) {
+	server, requests := newPluginReleaseServer(t)
+	cacheDir := t.TempDir()
+	defer func() {
+		offline = false
+		gitHubURL = ""
+		pluginCacheDir = DefaultPluginCacheDir()
+		pluginInfoMaxAge = DefaultPluginInfoMaxAge
+	}()
+
+	output, err := executeCommandC(
+		rootCmd, "plugin", "info", "github.com/gatewayd-io/plugin", "--sentry=false",
+		"--cache-dir", cacheDir, "--offline")
+	require.NoError(t, err, "plugin info command should not have returned an error")
+	assert.Equal(t, "The plugin info could not be found in the cache\n", output)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	for range 2 {
+		output, err = executeCommandC(
+			rootCmd, "plugin", "info", host+"/gatewayd-io/plugin", "--sentry=false",
+			"--cache-dir", cacheDir, "--offline=false", "--github-url", server.URL)
+		require.NoError(t, err, "plugin info command should not have returned an error")
+		assert.Contains(t, output, "Name: plugin\n")
+		assert.Contains(t, output, "Authors: Alice, Bob\n")
+		assert.Contains(t, output, "Hooks:\n  HOOK_NAME_ON_TRAFFIC_FROM_CLIENT\n  onScheduled\n")
+		assert.Contains(t, output, "Capabilities:\n  read_traffic\n  terminate_connections\n")
+		assert.Contains(t, output, "Checksum: abc123\n")
+		assert.Contains(t, output, "Changelog:\n  ## Changes\n  - Cache the results\n")
+	}
+	// The latest release, the repository, the checksums and the metadata are only
+	// fetched once.
+	assert.Equal(t, 4, *requests)
+
+	output, err = executeCommandC(
+		rootCmd, "plugin", "info", host+"/gatewayd-io/plugin", "--sentry=false",
+		"--cache-dir", cacheDir, "--github-url", server.URL, "--max-age", "0s")
+	require.NoError(t, err, "plugin info command should not have returned an error")
+	assert.Contains(t, output, "Latest version: v0.2.0\n")
+	assert.Equal(t, 8, *requests)
+}
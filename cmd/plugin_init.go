@@ -18,6 +18,7 @@ var pluginInitCmd = &cobra.Command{
 				Dsn:              DSN,
 				TracesSampleRate: config.DefaultTraceSampleRate,
 				AttachStacktrace: config.DefaultAttachStacktrace,
+				BeforeSend:       tagErrors,
 			})
 			if err != nil {
 				cmd.Println("Sentry initialization failed: ", err)
@@ -2,19 +2,22 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"regexp"
-	"runtime"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/codingsince1985/checksum"
 	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/network"
 	"github.com/getsentry/sentry-go"
 	"github.com/google/go-github/v53/github"
+	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 	yamlv3 "gopkg.in/yaml.v3"
 )
@@ -25,7 +28,10 @@ const (
 	FolderPermissions           os.FileMode = 0o755
 	DefaultPluginConfigFilename string      = "./gatewayd_plugin.yaml"
 	GitHubURLPrefix             string      = "github.com/"
-	GitHubURLRegex              string      = `^github.com\/[a-zA-Z0-9\-]+\/[a-zA-Z0-9\-]+@(?:latest|v(=|>=|<=|=>|=<|>|<|!=|~|~>|\^)?(?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:-(?P<prerelease>(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?)$` //nolint:lll
+	GitHubRepositoryRegex       string      = `[a-zA-Z0-9\-]+\/[a-zA-Z0-9\-]+@(?:latest|v(=|>=|<=|=>|=<|>|<|!=|~|~>|\^)?(?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:-(?P<prerelease>(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?)$` //nolint:lll
+	GitHubURLRegex              string      = `^github.com\/` + GitHubRepositoryRegex
+	GitHubServerURL             string      = "https://github.com"
+	GitHubServerURLEnv          string      = "GITHUB_SERVER_URL"
 	ExtWindows                  string      = ".zip"
 	ExtOthers                   string      = ".tar.gz"
 )
@@ -37,6 +43,13 @@ var (
 	update          bool
 	backupConfig    bool
 	noPrompt        bool
+	caBundle        string
+	gitHubToken     string
+	gitHubURL       string
+	allowSymlinks   bool
+	downloadRetries int
+	offline         bool
+	pluginCacheDir  string
 )
 
 // pluginInstallCmd represents the plugin install command.
@@ -55,6 +68,7 @@ var pluginInstallCmd = &cobra.Command{
 				Dsn:              DSN,
 				TracesSampleRate: config.DefaultTraceSampleRate,
 				AttachStacktrace: config.DefaultAttachStacktrace,
+				BeforeSend:       tagErrors,
 			})
 			if err != nil {
 				cmd.Println("Sentry initialization failed: ", err)
@@ -74,20 +88,28 @@ var pluginInstallCmd = &cobra.Command{
 			return
 		}
 
-		var releaseID int64
-		var downloadURL string
 		var pluginFilename string
 		var pluginName string
-		var err error
-		var checksumsFilename string
-		var client *github.Client
 		var account string
 
 		// Strip scheme from the plugin URL.
 		args[0] = strings.TrimPrefix(args[0], "http://")
 		args[0] = strings.TrimPrefix(args[0], "https://")
 
-		if !strings.HasPrefix(args[0], GitHubURLPrefix) {
+		// The plugins can be hosted on GitHub or on a GitHub Enterprise server.
+		if gitHubURL == "" {
+			gitHubURL = os.Getenv(GitHubServerURLEnv)
+		}
+		if gitHubURL == "" {
+			gitHubURL = GitHubServerURL
+		}
+		urlPrefix, apiHost, err := parseGitHubServer(gitHubURL)
+		if err != nil {
+			cmd.Println("Invalid GitHub URL: ", err)
+			return
+		}
+
+		if !strings.HasPrefix(args[0], urlPrefix) {
 			// Pull the plugin from a local archive.
 			pluginFilename = filepath.Clean(args[0])
 			if _, err := os.Stat(pluginFilename); os.IsNotExist(err) {
@@ -97,10 +119,10 @@ var pluginInstallCmd = &cobra.Command{
 		}
 
 		// Validate the URL.
-		validGitHubURL := regexp.MustCompile(GitHubURLRegex)
+		validGitHubURL := regexp.MustCompile("^" + regexp.QuoteMeta(urlPrefix) + GitHubRepositoryRegex)
 		if !validGitHubURL.MatchString(args[0]) {
 			cmd.Println(
-				"Invalid URL. Use the following format: github.com/account/repository@version")
+				"Invalid URL. Use the following format: " + urlPrefix + "account/repository@version")
 			return
 		}
 
@@ -116,131 +138,72 @@ var pluginInstallCmd = &cobra.Command{
 		}
 
 		// Get the plugin account and repository.
-		accountRepo := strings.Split(strings.TrimPrefix(splittedURL[0], GitHubURLPrefix), "/")
+		accountRepo := strings.Split(strings.TrimPrefix(splittedURL[0], urlPrefix), "/")
 		if len(accountRepo) != NumParts {
 			cmd.Println(
-				"Invalid URL. Use the following format: github.com/account/repository@version")
+				"Invalid URL. Use the following format: " + urlPrefix + "account/repository@version")
 			return
 		}
 		account = accountRepo[0]
 		pluginName = accountRepo[1]
 		if account == "" || pluginName == "" {
 			cmd.Println(
-				"Invalid URL. Use the following format: github.com/account/repository@version")
-			return
-		}
-
-		// Get the release artifact from GitHub.
-		client = github.NewClient(nil)
-		var release *github.RepositoryRelease
-
-		if pluginVersion == LatestVersion || pluginVersion == "" {
-			// Get the latest release.
-			release, _, err = client.Repositories.GetLatestRelease(
-				context.Background(), account, pluginName)
-		} else if strings.HasPrefix(pluginVersion, "v") {
-			// Get an specific release.
-			release, _, err = client.Repositories.GetReleaseByTag(
-				context.Background(), account, pluginName, pluginVersion)
-		}
-
-		if err != nil {
-			cmd.Println("The plugin could not be found: ", err.Error())
+				"Invalid URL. Use the following format: " + urlPrefix + "account/repository@version")
 			return
 		}
 
-		if release == nil {
-			cmd.Println("The plugin could not be found in the release assets")
-			return
-		}
-
-		// Get the archive extension.
-		archiveExt := ExtOthers
-		if runtime.GOOS == "windows" {
-			archiveExt = ExtWindows
-		}
-
-		// Find and download the plugin binary from the release assets.
-		pluginFilename, downloadURL, releaseID = findAsset(release, func(name string) bool {
-			return strings.Contains(name, runtime.GOOS) &&
-				strings.Contains(name, runtime.GOARCH) &&
-				strings.Contains(name, archiveExt)
-		})
-
-		var filePath string
-		if downloadURL != "" && releaseID != 0 {
-			cmd.Println("Downloading", downloadURL)
-			filePath, err = downloadFile(client, account, pluginName, releaseID, pluginFilename)
-			toBeDeleted = append(toBeDeleted, filePath)
+		// Install the plugin from the cache if it is there, so that it isn't downloaded
+		// again, or only from the cache in the offline mode. The latest version is only
+		// taken from the cache in the offline mode, since a newer one might be released.
+		cache := &pluginCache{Dir: pluginCacheDir}
+		var contents string
+		// The version and the source of the installed plugin are recorded in the SBOM.
+		var installedVersion, sourceURL string
+		entry, cached := cache.Lookup(account, pluginName, pluginVersion)
+		switch {
+		case cached && (offline || (pluginVersion != LatestVersion && pluginVersion != "")):
+			cwd, err := os.Getwd()
 			if err != nil {
-				cmd.Println("Download failed: ", err)
-				if cleanup {
-					deleteFiles(toBeDeleted)
-				}
+				cmd.Println("There was an error getting the current directory: ", err)
 				return
 			}
-			cmd.Println("Download completed successfully")
-		} else {
-			cmd.Println("The plugin file could not be found in the release assets")
+			pluginFilename, err = cache.Restore(entry, cwd)
+			if err != nil {
+				cmd.Println("There was an error restoring the plugin from the cache: ", err)
+				return
+			}
+			toBeDeleted = append(toBeDeleted, pluginFilename)
+			contents = entry.Config
+			installedVersion, sourceURL = entry.Version, entry.URL
+			cmd.Println("Using the cached plugin archive of", entry.Version)
+		case offline:
+			cmd.Println("The plugin could not be found in the cache")
 			return
-		}
-
-		// Find and download the checksums.txt from the release assets.
-		checksumsFilename, downloadURL, releaseID = findAsset(release, func(name string) bool {
-			return strings.Contains(name, "checksums.txt")
-		})
-		if checksumsFilename != "" && downloadURL != "" && releaseID != 0 {
-			cmd.Println("Downloading", downloadURL)
-			filePath, err = downloadFile(client, account, pluginName, releaseID, checksumsFilename)
-			toBeDeleted = append(toBeDeleted, filePath)
+		default:
+			plugin, err := pullPlugin(cmd, account, pluginName, pluginVersion, apiHost)
+			toBeDeleted = append(toBeDeleted, plugin.Files...)
 			if err != nil {
-				cmd.Println("Download failed: ", err)
+				cmd.Println(err)
 				if cleanup {
 					deleteFiles(toBeDeleted)
 				}
 				return
 			}
-			cmd.Println("Download completed successfully")
-		} else {
-			cmd.Println("The checksum file could not be found in the release assets")
-			return
-		}
+			pluginFilename = plugin.Archive
+			contents = plugin.Config
+			installedVersion, sourceURL = plugin.Version, plugin.URL
 
-		// Read the checksums text file.
-		checksums, err := os.ReadFile(checksumsFilename)
-		if err != nil {
-			cmd.Println("There was an error reading the checksums file: ", err)
-			return
-		}
-
-		// Get the checksum for the plugin binary.
-		sum, err := checksum.SHA256sum(pluginFilename)
-		if err != nil {
-			cmd.Println("There was an error calculating the checksum: ", err)
-			return
-		}
-
-		// Verify the checksums.
-		checksumLines := strings.Split(string(checksums), "\n")
-		for _, line := range checksumLines {
-			if strings.Contains(line, pluginFilename) {
-				checksum := strings.Split(line, " ")[0]
-				if checksum != sum {
-					cmd.Println("Checksum verification failed")
-					return
-				}
-
-				cmd.Println("Checksum verification passed")
-				break
+			if err := cache.Store(account, pluginName, plugin); err != nil {
+				cmd.Println("There was an error caching the plugin archive: ", err)
 			}
 		}
 
 		if pullOnly {
 			cmd.Println("Plugin binary downloaded to", pluginFilename)
-			// Only the checksums file will be deleted if the --pull-only flag is set.
-			if err := os.Remove(checksumsFilename); err != nil {
-				cmd.Println("There was an error deleting the file: ", err)
-			}
+			// Only the other assets will be deleted if the --pull-only flag is set.
+			deleteFiles(slices.DeleteFunc(toBeDeleted, func(filename string) bool {
+				return filename == pluginFilename
+			}))
 			return
 		}
 
@@ -319,13 +282,9 @@ var pluginInstallCmd = &cobra.Command{
 		}
 
 		// Extract the archive.
-		var filenames []string
-		if runtime.GOOS == "windows" {
-			filenames, err = extractZip(pluginFilename, pluginOutputDir)
-		} else {
-			filenames, err = extractTarGz(pluginFilename, pluginOutputDir)
-		}
-
+		extractOptions := defaultExtractOptions
+		extractOptions.AllowSymlinks = allowSymlinks
+		filenames, err := extractArchive(pluginFilename, pluginOutputDir, extractOptions)
 		if err != nil {
 			cmd.Println("There was an error extracting the plugin archive: ", err)
 			if cleanup {
@@ -362,25 +321,7 @@ var pluginInstallCmd = &cobra.Command{
 			}
 		}
 
-		var contents string
-		if strings.HasPrefix(args[0], GitHubURLPrefix) {
-			// Get the list of files in the repository.
-			var repoContents *github.RepositoryContent
-			repoContents, _, _, err = client.Repositories.GetContents(
-				context.Background(), account, pluginName, DefaultPluginConfigFilename, nil)
-			if err != nil {
-				cmd.Println(
-					"There was an error getting the default plugins configuration file: ", err)
-				return
-			}
-			// Get the contents of the file.
-			contents, err = repoContents.GetContent()
-			if err != nil {
-				cmd.Println(
-					"There was an error getting the default plugins configuration file: ", err)
-				return
-			}
-		} else {
+		if !strings.HasPrefix(args[0], urlPrefix) {
 			// Get the contents of the file.
 			contentsBytes, err := os.ReadFile(
 				filepath.Join(pluginOutputDir, DefaultPluginConfigFilename))
@@ -445,6 +386,25 @@ var pluginInstallCmd = &cobra.Command{
 			return
 		}
 
+		// Record where the plugin came from in the SBOM of the plugins.
+		sbom := sbomEntry{
+			Name:            pluginName,
+			Version:         installedVersion,
+			Checksum:        pluginFileSum,
+			LocalPath:       localPath,
+			SourceURL:       sourceURL,
+			InstalledAt:     time.Now(),
+			GatewayDVersion: config.Version,
+		}
+		if strings.HasPrefix(args[0], urlPrefix) {
+			sbom.PackageURL = packageURL(urlPrefix, account, pluginName, installedVersion)
+		} else if sbom.SourceURL, err = filepath.Abs(pluginFilename); err != nil {
+			sbom.SourceURL = pluginFilename
+		}
+		if err := recordSBOMEntry(sbomFilePath(pluginConfigFile), sbom); err != nil {
+			cmd.Println("There was an error recording the plugin in the SBOM: ", err)
+		}
+
 		// Delete the downloaded and extracted files, except the plugin binary,
 		// if the --cleanup flag is set.
 		if cleanup {
@@ -478,4 +438,154 @@ func init() {
 		&backupConfig, "backup", false, "Backup the plugins configuration file before installing the plugin")
 	pluginInstallCmd.Flags().BoolVar(
 		&enableSentry, "sentry", true, "Enable Sentry") // Already exists in run.go
+	pluginInstallCmd.Flags().BoolVar(
+		&offline, "offline", false, "Only install the plugins from the cache, without GitHub")
+	pluginInstallCmd.Flags().StringVar(
+		&pluginCacheDir, "cache-dir", DefaultPluginCacheDir(), "Directory of the plugin cache")
+	pluginInstallCmd.Flags().IntVar(
+		&downloadRetries, "download-retries", config.DefaultRetries,
+		"Number of times to retry the download of each release asset")
+	pluginInstallCmd.Flags().BoolVar(
+		&allowSymlinks, "allow-symlinks", false,
+		"Extract the symlinks of the plugin archive that point inside the output directory")
+	pluginInstallCmd.Flags().StringVar(
+		&caBundle, "ca-bundle", "", "CA bundle to trust, in addition to the system CAs, e.g. behind a TLS-intercepting proxy")
+	pluginInstallCmd.Flags().StringVar(
+		&gitHubToken, "github-token", "",
+		"GitHub token to authenticate the requests with, e.g. to private repositories or to avoid rate limiting "+
+			"(defaults to $"+GitHubTokenEnv+")")
+	pluginInstallCmd.Flags().StringVar(
+		&gitHubURL, "github-url", "",
+		"URL of the GitHub Enterprise server hosting the plugins (defaults to $"+GitHubServerURLEnv+" or "+
+			GitHubServerURL+")")
+}
+
+// pulledPlugin is a plugin downloaded from GitHub.
+type pulledPlugin struct {
+	Archive string
+	// Files are the paths of all the downloaded assets, including the archive.
+	Files   []string
+	Version string
+	// URL is the URL the archive was downloaded from.
+	URL string
+	// Config is the default plugins configuration file of the repository of the plugin.
+	Config string
+}
+
+// pullPlugin downloads the plugin archive of the release of the plugin from GitHub,
+// with its checksums, SBOM and signature, verifies them, and gets the default plugins
+// configuration file of the repository. The returned errors are meant for the user.
+//
+//nolint:goerr113,stylecheck
+func pullPlugin(
+	cmd *cobra.Command, account, pluginName, pluginVersion, apiHost string,
+) (pulledPlugin, error) {
+	var plugin pulledPlugin
+
+	// Get the release artifact from GitHub.
+	if gitHubToken == "" {
+		gitHubToken = os.Getenv(GitHubTokenEnv)
+	}
+	httpClient, err := newHTTPClient(caBundle, gitHubToken, apiHost)
+	if err != nil {
+		return plugin, fmt.Errorf("There was an error creating the HTTP client: %w", err)
+	}
+	client, err := newGitHubClient(gitHubURL, httpClient)
+	if err != nil {
+		return plugin, fmt.Errorf("There was an error creating the GitHub client: %w", err)
+	}
+
+	var release *github.RepositoryRelease
+	if pluginVersion == LatestVersion || pluginVersion == "" {
+		// Get the latest release.
+		release, _, err = client.Repositories.GetLatestRelease(
+			context.Background(), account, pluginName)
+	} else if strings.HasPrefix(pluginVersion, "v") {
+		// Get an specific release.
+		release, _, err = client.Repositories.GetReleaseByTag(
+			context.Background(), account, pluginName, pluginVersion)
+	}
+	if err != nil {
+		return plugin, fmt.Errorf("The plugin could not be found: %w", err)
+	}
+	if release == nil {
+		return plugin, errors.New("The plugin could not be found in the release assets")
+	}
+
+	// Find the plugin binary from the release assets.
+	pluginFilename, downloadURL, releaseID := findPluginArchive(release)
+	if downloadURL == "" || releaseID == 0 {
+		return plugin, errors.New("The plugin file could not be found in the release assets")
+	}
+	assets := []releaseAsset{{Name: pluginFilename, URL: downloadURL, ID: releaseID}}
+
+	// Find the checksums.txt from the release assets.
+	checksumsFilename, downloadURL, releaseID := findAsset(release, func(name string) bool {
+		return strings.HasSuffix(name, "checksums.txt")
+	})
+	if checksumsFilename == "" || downloadURL == "" || releaseID == 0 {
+		return plugin, errors.New("The checksum file could not be found in the release assets")
+	}
+	assets = append(assets, releaseAsset{Name: checksumsFilename, URL: downloadURL, ID: releaseID})
+
+	// Find the SBOM of the plugin archive and the signature of the checksums, if any.
+	sbomFilename, downloadURL, releaseID := findAsset(release, func(name string) bool {
+		return strings.HasPrefix(name, pluginFilename) && isSBOM(name)
+	})
+	if sbomFilename != "" && downloadURL != "" && releaseID != 0 {
+		assets = append(assets, releaseAsset{Name: sbomFilename, URL: downloadURL, ID: releaseID})
+	}
+	signatureFilename, downloadURL, releaseID := findAsset(release, func(name string) bool {
+		return strings.HasPrefix(name, checksumsFilename) && isSignature(name)
+	})
+	if signatureFilename != "" && downloadURL != "" && releaseID != 0 {
+		assets = append(assets, releaseAsset{
+			Name: signatureFilename, URL: downloadURL, ID: releaseID,
+		})
+	}
+
+	// Download the assets concurrently.
+	for _, asset := range assets {
+		cmd.Println("Downloading", asset.URL)
+	}
+	filePaths, err := downloadAssets(
+		client, httpClient, account, pluginName, assets,
+		network.NewRetry(
+			downloadRetries, config.DefaultBackoff, config.DefaultBackoffMultiplier, false,
+			zerolog.Nop()),
+		cmd.OutOrStdout())
+	if err != nil {
+		return plugin, fmt.Errorf("Download failed: %w", err)
+	}
+	plugin.Files = filePaths
+	cmd.Println("Download completed successfully")
+
+	// Verify the plugin archive and its SBOM against the checksums, as a unit.
+	verified := []string{filePaths[0]}
+	if sbomFilename != "" {
+		verified = append(verified, filePaths[2])
+	}
+	if err := verifyChecksums(filePaths[1], verified); err != nil {
+		return plugin, fmt.Errorf("Checksum verification failed: %w", err)
+	}
+	cmd.Println("Checksum verification passed")
+
+	// Get the default plugins configuration file of the repository.
+	repoContents, _, _, err := client.Repositories.GetContents(
+		context.Background(), account, pluginName, DefaultPluginConfigFilename, nil)
+	if err != nil {
+		return plugin, fmt.Errorf(
+			"There was an error getting the default plugins configuration file: %w", err)
+	}
+	// Get the contents of the file.
+	plugin.Config, err = repoContents.GetContent()
+	if err != nil {
+		return plugin, fmt.Errorf(
+			"There was an error getting the default plugins configuration file: %w", err)
+	}
+
+	plugin.Archive = filePaths[0]
+	plugin.URL = assets[0].URL
+	plugin.Version = release.GetTagName()
+	return plugin, nil
 }
@@ -1,10 +1,21 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/codingsince1985/checksum"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -51,3 +62,232 @@ func Test_pluginInstallCmd(t *testing.T) {
 	require.NoError(t, os.Remove(pluginTestConfigFile))
 	require.NoError(t, os.Remove(fmt.Sprintf("%s.bak", pluginTestConfigFile)))
 }
+
+// Test_downloadURL tests that an interrupted download is resumed with a range.
+func Test_downloadURL(t *testing.T) {
+	content := bytes.Repeat([]byte("gatewayd"), 1024)
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "plugin.tar.gz", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+	require.NoError(t, os.WriteFile(filePath+PartialDownloadExt, content[:1000], FilePermissions))
+
+	var output bytes.Buffer
+	require.NoError(t, downloadURL(server.Client(), server.URL, filePath, &output))
+	assert.Equal(t, []string{"bytes=1000-"}, ranges)
+	assert.NoFileExists(t, filePath+PartialDownloadExt)
+	downloaded, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, content, downloaded)
+	assert.Contains(t, output.String(), "plugin.tar.gz [")
+	assert.Contains(t, output.String(), "100%")
+}
+
+// Test_downloadURL_TooLarge tests that the files larger than MaxFileSize aren't written.
+func Test_downloadURL_TooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.FormatInt(MaxFileSize+1, 10))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	filePath := filepath.Join(t.TempDir(), "plugin.tar.gz")
+	err := downloadURL(server.Client(), server.URL, filePath, nil)
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "larger than"))
+	assert.NoFileExists(t, filePath+PartialDownloadExt)
+}
+
+// Test_gitHubTokenTransport tests that only the GitHub API requests are authenticated.
+func Test_gitHubTokenTransport(t *testing.T) {
+	var authorization []string
+	transport := &gitHubTokenTransport{
+		token: "token",
+		host:  GitHubAPIHost,
+		base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			authorization = append(authorization, req.Header.Get("Authorization"))
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+	}
+
+	for _, url := range []string{
+		"https://api.github.com/repos/gatewayd-io/gatewayd/releases/latest",
+		"https://objects.githubusercontent.com/plugin.tar.gz",
+	} {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, []string{"Bearer token", ""}, authorization)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Test_parseGitHubServer tests the URL prefixes and API hosts of the GitHub servers.
+func Test_parseGitHubServer(t *testing.T) {
+	prefix, apiHost, err := parseGitHubServer(GitHubServerURL)
+	require.NoError(t, err)
+	assert.Equal(t, GitHubURLPrefix, prefix)
+	assert.Equal(t, GitHubAPIHost, apiHost)
+
+	prefix, apiHost, err = parseGitHubServer("https://ghe.example.com/")
+	require.NoError(t, err)
+	assert.Equal(t, "ghe.example.com/", prefix)
+	assert.Equal(t, "ghe.example.com", apiHost)
+
+	client, err := newGitHubClient("https://ghe.example.com/", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://ghe.example.com/api/v3/", client.BaseURL.String())
+
+	_, _, err = parseGitHubServer("ghe.example.com")
+	assert.Error(t, err)
+}
+
+// Test_downloadAssets tests that the release assets are downloaded concurrently, with
+// retries, and that they are all removed if any of them fails.
+func Test_downloadAssets(t *testing.T) {
+	releaseAssets := []releaseAsset{
+		{Name: "plugin.tar.gz", ID: 1},
+		{Name: "checksums.txt", ID: 2},
+		{Name: "plugin.tar.gz.sbom.json", ID: 3},
+	}
+	contents := map[string]string{
+		"plugin.tar.gz":           "plugin",
+		"checksums.txt":           "checksums",
+		"plugin.tar.gz.sbom.json": "sbom",
+	}
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The API redirects the assets to their downloads.
+		if id, ok := strings.CutPrefix(r.URL.Path, "/api/v3/repos/gatewayd-io/plugin/releases/assets/"); ok {
+			index, _ := strconv.Atoi(id)
+			name := "missing.sig"
+			if index <= len(releaseAssets) {
+				name = releaseAssets[index-1].Name
+			}
+			http.Redirect(w, r, "/download/"+name, http.StatusFound)
+			return
+		}
+
+		name := strings.TrimPrefix(r.URL.Path, "/download/")
+		mu.Lock()
+		attempts[name]++
+		attempt := attempts[name]
+		mu.Unlock()
+		// The SBOM fails once, and the missing asset always does.
+		if body, ok := contents[name]; ok && (name != "plugin.tar.gz.sbom.json" || attempt > 1) {
+			_, _ = w.Write([]byte(body))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+
+	client, err := newGitHubClient(server.URL, server.Client())
+	require.NoError(t, err)
+	retry := network.NewRetry(1, 0, 1, false, zerolog.Nop())
+
+	filePaths, err := downloadAssets(
+		client, server.Client(), "gatewayd-io", "plugin", releaseAssets, retry, nil)
+	require.NoError(t, err)
+	require.Len(t, filePaths, 3)
+	for index, filePath := range filePaths {
+		downloaded, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, contents[releaseAssets[index].Name], string(downloaded))
+	}
+	assert.Equal(t, 2, attempts["plugin.tar.gz.sbom.json"])
+
+	_, err = downloadAssets(
+		client, server.Client(), "gatewayd-io", "plugin",
+		append(releaseAssets, releaseAsset{Name: "missing.sig", ID: 4}), retry, nil)
+	require.Error(t, err)
+	for _, asset := range releaseAssets {
+		assert.NoFileExists(t, asset.Name)
+	}
+}
+
+// Test_verifyChecksums tests that the assets are verified against the checksums as a unit.
+func Test_verifyChecksums(t *testing.T) {
+	dir := t.TempDir()
+	plugin := filepath.Join(dir, "plugin.tar.gz")
+	sbom := filepath.Join(dir, "plugin.tar.gz.sbom.json")
+	require.NoError(t, os.WriteFile(plugin, []byte("plugin"), FilePermissions))
+	require.NoError(t, os.WriteFile(sbom, []byte("sbom"), FilePermissions))
+
+	checksums := filepath.Join(dir, "checksums.txt")
+	require.NoError(t, os.WriteFile(checksums, []byte(
+		"b4f5b2f8d2c3e2b5e6a1f3b1a8d6c1d4e9f0a2b3c4d5e6f7a8b9c0d1e2f3a4b5  plugin.tar.gz.sbom.json\n"+
+			"3ba8c8fa3e8f3e0d6d9c4ac8e54c4c4e2b0e1ac0e63c5d8a1f7a1e5c5b3b2b44  plugin.tar.gz\n",
+	), FilePermissions))
+	assert.ErrorContains(t, verifyChecksums(checksums, []string{plugin}), "checksum mismatch")
+
+	pluginSum, err := checksum.SHA256sum(plugin)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(checksums, []byte(pluginSum+"  plugin.tar.gz\n"), FilePermissions))
+	assert.NoError(t, verifyChecksums(checksums, []string{plugin}))
+	assert.ErrorContains(t, verifyChecksums(checksums, []string{plugin, sbom}), "no checksum for")
+}
+
+// Test_pluginInstallCmd_Offline tests that the plugins are installed from the cache in
+// the offline mode.
+func Test_pluginInstallCmd_Offline(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+	defer func() {
+		offline = false
+		pluginCacheDir = DefaultPluginCacheDir()
+	}()
+
+	_, err = executeCommandC(rootCmd, "plugin", "init", "-p", pluginTestConfigFile)
+	require.NoError(t, err, "plugin init should not return an error")
+
+	cacheDir := t.TempDir()
+	output, err := executeCommandC(
+		rootCmd, "plugin", "install", "github.com/gatewayd-io/gatewayd-plugin-test@latest",
+		"-p", pluginTestConfigFile, "--offline", "--cache-dir", cacheDir)
+	require.NoError(t, err, "plugin install should not return an error")
+	assert.Contains(t, output, "The plugin could not be found in the cache")
+
+	archive := createTar(t, ".tar.gz", []testEntry{
+		{name: "gatewayd-plugin-test", contents: "binary", mode: int64(ExecFilePermissions)},
+	})
+	cache := &pluginCache{Dir: cacheDir}
+	require.NoError(t, cache.Store("gatewayd-io", "gatewayd-plugin-test", pulledPlugin{
+		Archive: archive,
+		Version: "v0.1.0",
+		URL:     "https://github.com/gatewayd-io/gatewayd-plugin-test/releases/download/v0.1.0/plugin.tar.gz",
+		Config:  "plugins:\n  - name: gatewayd-plugin-test\n    enabled: true\n",
+	}))
+
+	output, err = executeCommandC(
+		rootCmd, "plugin", "install", "github.com/gatewayd-io/gatewayd-plugin-test@latest",
+		"-p", pluginTestConfigFile, "--offline", "--cache-dir", cacheDir, "--update")
+	require.NoError(t, err, "plugin install should not return an error")
+	assert.Contains(t, output, "Using the cached plugin archive of v0.1.0")
+	assert.Contains(t, output, "Plugin installed successfully")
+	assert.FileExists(t, "plugins/gatewayd-plugin-test")
+	assert.NoFileExists(t, "plugin.tar.gz")
+
+	output, err = executeCommandC(rootCmd, "plugin", "list", "-p", pluginTestConfigFile)
+	require.NoError(t, err, "plugin list should not return an error")
+	assert.Contains(t, output, "Name: gatewayd-plugin-test")
+}
@@ -21,6 +21,7 @@ var pluginLintCmd = &cobra.Command{
 				Dsn:              DSN,
 				TracesSampleRate: config.DefaultTraceSampleRate,
 				AttachStacktrace: config.DefaultAttachStacktrace,
+				BeforeSend:       tagErrors,
 			})
 			if err != nil {
 				cmd.Println("Sentry initialization failed: ", err)
@@ -36,6 +37,9 @@ var pluginLintCmd = &cobra.Command{
 		if err := lintConfig(Plugins, pluginConfigFile); err != nil {
 			log.Fatal(err)
 		}
+		if err := lintPluginSettings(cmd, pluginConfigFile, fetchSchemas, devMode); err != nil {
+			log.Fatal(err)
+		}
 
 		cmd.Println("plugins config is valid")
 	},
@@ -48,6 +52,11 @@ func init() {
 		&pluginConfigFile, // Already exists in run.go
 		"plugin-config", "p", config.GetDefaultConfigFilePath(config.PluginsConfigFilename),
 		"Plugin config file")
+	pluginLintCmd.Flags().BoolVar(
+		&fetchSchemas, "fetch-schemas", false,
+		"Start the plugins to fetch the config schemas they publish") // Already exists in config_lint.go
+	pluginLintCmd.Flags().BoolVar(
+		&devMode, "dev", false, "Enable development mode for plugin development") // Already exists in run.go
 	pluginLintCmd.Flags().BoolVar(
 		&enableSentry, "sentry", true, "Enable Sentry") // Already exists in run.go
 }
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/gatewayd-io/gatewayd/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -16,3 +20,74 @@ func Test_pluginLintCmd(t *testing.T) {
 		output,
 		"plugin lint command should have returned the correct output")
 }
+
+// Test_lintConfigReferences tests that the violations of the references between the
+// config groups fail the linting.
+func Test_lintConfigReferences(t *testing.T) {
+	pluginConfig, err := os.ReadFile("../gatewayd_plugins.yaml")
+	require.NoError(t, err)
+	plugins := string(pluginConfig)
+	// The plugin is configured twice.
+	plugin := plugins[strings.Index(plugins, "  - name: gatewayd-plugin-cache"):]
+	configFile := filepath.Join(t.TempDir(), "gatewayd_plugins.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(plugins+plugin), 0o600))
+
+	err = lintConfig(Plugins, configFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `plugin "gatewayd-plugin-cache" is configured more than once`)
+}
+
+// Test_lintPluginSettings tests that the settings of the plugins are linted against
+// the config schema bundled with them, or the one set in their config.
+func Test_lintPluginSettings(t *testing.T) {
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "test-plugin")
+	require.NoError(t, os.WriteFile(localPath+".schema.json", []byte(`{
+		"type": "object",
+		"properties": {
+			"REDIS_URL": {"type": "string", "pattern": "^redis://"},
+			"EXPIRY": {"type": "string", "pattern": "^[0-9]+[smh]$"}
+		},
+		"additionalProperties": false
+	}`), 0o600))
+	writeConfig := func(env ...string) string {
+		pluginConfig := "plugins:\n  - name: test-plugin\n    enabled: True\n" +
+			"    localPath: " + localPath + "\n    checksum: abc\n    env:\n" +
+			"      - MAGIC_COOKIE_KEY=GATEWAYD_PLUGIN\n      - MAGIC_COOKIE_VALUE=cookie\n"
+		for _, value := range env {
+			pluginConfig += "      - " + value + "\n"
+		}
+		configFile := filepath.Join(t.TempDir(), "gatewayd_plugins.yaml")
+		require.NoError(t, os.WriteFile(configFile, []byte(pluginConfig), 0o600))
+		return configFile
+	}
+
+	assert.NoError(t, lintPluginSettings(
+		rootCmd, writeConfig("REDIS_URL=redis://localhost:6379/0", "EXPIRY=1h"), false, false))
+
+	err := lintPluginSettings(
+		rootCmd, writeConfig("REDIS_ULR=redis://localhost:6379/0", "EXPIRY=1 hour"), false, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `plugin "test-plugin"`)
+	assert.Contains(t, err.Error(), "REDIS_ULR")
+	assert.Contains(t, err.Error(), "/EXPIRY")
+
+	// The schema set in the config is used instead of the bundled one.
+	schemaFile := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(schemaFile, []byte(`{"required": ["API_KEY"]}`), 0o600))
+	configFile := writeConfig("EXPIRY=1h")
+	pluginConfig, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(configFile, append(pluginConfig,
+		[]byte("    configSchema: "+schemaFile+"\n")...), 0o600))
+	err = lintPluginSettings(rootCmd, configFile, false, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API_KEY")
+}
+
+func Test_pluginSettings(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{"EXPIRY": "1h", "URL": "a=b"}, pluginSettings(
+		config.Plugin{Env: []string{
+			"MAGIC_COOKIE_KEY=GATEWAYD_PLUGIN", "MAGIC_COOKIE_VALUE=cookie", "EXPIRY=1h", "URL=a=b",
+		}}))
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"log"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/getsentry/sentry-go"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sbomFormat string
+	verifySums bool
+)
+
+// pluginSBOMCmd represents the plugin sbom command.
+var pluginSBOMCmd = &cobra.Command{
+	Use:     "sbom",
+	Short:   "Export the SBOM of the installed plugins",
+	Example: "  gatewayd plugin sbom --format spdx > gatewayd_plugins.spdx.json",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Enable Sentry.
+		if enableSentry {
+			// Initialize Sentry.
+			err := sentry.Init(sentry.ClientOptions{
+				Dsn:              DSN,
+				TracesSampleRate: config.DefaultTraceSampleRate,
+				AttachStacktrace: config.DefaultAttachStacktrace,
+				BeforeSend:       tagErrors,
+			})
+			if err != nil {
+				cmd.Println("Sentry initialization failed: ", err)
+				return
+			}
+
+			// Flush buffered events before the program terminates.
+			defer sentry.Flush(config.DefaultFlushTimeout)
+			// Recover from panics and report the error to Sentry.
+			defer sentry.Recover()
+		}
+
+		entries, err := readSBOM(sbomFilePath(pluginConfigFile))
+		if err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+
+		if verifySums {
+			if mismatched := verifySBOM(entries); len(mismatched) > 0 {
+				log.New(cmd.OutOrStdout(), "", 0).Fatalf(
+					"The checksums of the following plugins do not match the SBOM: %v", mismatched)
+			}
+			cmd.Println("The checksums of the plugins match the SBOM")
+			return
+		}
+
+		document, err := exportSBOM(entries, sbomFormat, time.Now())
+		if err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+		cmd.Println(string(document))
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginSBOMCmd)
+
+	pluginSBOMCmd.Flags().StringVarP(
+		&pluginConfigFile, // Already exists in run.go
+		"plugin-config", "p", config.GetDefaultConfigFilePath(config.PluginsConfigFilename),
+		"Plugin config file")
+	pluginSBOMCmd.Flags().StringVarP(
+		&sbomFormat, "format", "f", SBOMFormatCycloneDX, "SBOM format: cyclonedx or spdx")
+	pluginSBOMCmd.Flags().BoolVar(
+		&verifySums, "verify", false,
+		"Verify the installed plugin binaries against the checksums in the SBOM, instead of exporting it")
+	pluginSBOMCmd.Flags().BoolVar(
+		&enableSentry, "sentry", true, "Enable Sentry") // Already exists in run.go
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_pluginSBOMCmd tests that the installed plugins are recorded in the SBOM, and that
+// it is exported in the CycloneDX and SPDX formats.
+func Test_pluginSBOMCmd(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	defer func() { require.NoError(t, os.Chdir(cwd)) }()
+	defer func() {
+		offline = false
+		pluginCacheDir = DefaultPluginCacheDir()
+		sbomFormat = SBOMFormatCycloneDX
+		verifySums = false
+	}()
+
+	cacheDir := t.TempDir()
+	archive := createTar(t, ".tar.gz", []testEntry{
+		{name: "gatewayd-plugin-test", contents: "binary", mode: int64(ExecFilePermissions)},
+	})
+	require.NoError(t, (&pluginCache{Dir: cacheDir}).Store("gatewayd-io", "gatewayd-plugin-test", pulledPlugin{
+		Archive: archive,
+		Version: "v0.1.0",
+		URL:     "https://github.com/gatewayd-io/gatewayd-plugin-test/releases/download/v0.1.0/plugin.tar.gz",
+		Config:  "plugins:\n  - name: gatewayd-plugin-test\n    enabled: true\n",
+	}))
+	_, err = executeCommandC(rootCmd, "plugin", "init", "-p", pluginTestConfigFile)
+	require.NoError(t, err, "plugin init should not return an error")
+	_, err = executeCommandC(
+		rootCmd, "plugin", "install", "github.com/gatewayd-io/gatewayd-plugin-test@v0.1.0",
+		"-p", pluginTestConfigFile, "--offline", "--cache-dir", cacheDir, "--update")
+	require.NoError(t, err, "plugin install should not return an error")
+
+	entries, err := readSBOM(sbomFilePath(pluginTestConfigFile))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "gatewayd-plugin-test", entries[0].Name)
+	assert.Equal(t, "v0.1.0", entries[0].Version)
+	assert.Equal(t, "plugins/gatewayd-plugin-test", entries[0].LocalPath)
+	assert.Equal(t, "pkg:github/gatewayd-io/gatewayd-plugin-test@v0.1.0", entries[0].PackageURL)
+	assert.Contains(t, entries[0].SourceURL, "releases/download/v0.1.0")
+
+	output, err := executeCommandC(
+		rootCmd, "plugin", "sbom", "-p", pluginTestConfigFile, "--format", SBOMFormatCycloneDX)
+	require.NoError(t, err, "plugin sbom should not return an error")
+	var cycloneDX struct {
+		BOMFormat  string `json:"bomFormat"`
+		Components []struct {
+			Name   string `json:"name"`
+			PURL   string `json:"purl"`
+			Hashes []struct {
+				Content string `json:"content"`
+			} `json:"hashes"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &cycloneDX))
+	assert.Equal(t, "CycloneDX", cycloneDX.BOMFormat)
+	require.Len(t, cycloneDX.Components, 1)
+	assert.Equal(t, entries[0].PackageURL, cycloneDX.Components[0].PURL)
+	assert.Equal(t, entries[0].Checksum, cycloneDX.Components[0].Hashes[0].Content)
+
+	output, err = executeCommandC(
+		rootCmd, "plugin", "sbom", "-p", pluginTestConfigFile, "--format", SBOMFormatSPDX)
+	require.NoError(t, err, "plugin sbom should not return an error")
+	var spdx struct {
+		SPDXVersion string `json:"spdxVersion"`
+		Packages    []struct {
+			SPDXID           string `json:"SPDXID"`
+			DownloadLocation string `json:"downloadLocation"`
+		} `json:"packages"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &spdx))
+	assert.Equal(t, "SPDX-2.3", spdx.SPDXVersion)
+	require.Len(t, spdx.Packages, 1)
+	assert.Equal(t, "SPDXRef-Package-gatewayd-plugin-test", spdx.Packages[0].SPDXID)
+	assert.Equal(t, entries[0].SourceURL, spdx.Packages[0].DownloadLocation)
+
+	output, err = executeCommandC(rootCmd, "plugin", "sbom", "-p", pluginTestConfigFile, "--verify")
+	require.NoError(t, err, "plugin sbom should not return an error")
+	assert.Equal(t, "The checksums of the plugins match the SBOM\n", output)
+
+	// The modified plugin binaries don't match the SBOM.
+	require.NoError(t, os.WriteFile(entries[0].LocalPath, []byte("modified"), ExecFilePermissions))
+	assert.Equal(t, []string{"gatewayd-plugin-test"}, verifySBOM(entries))
+}
+
+func Test_exportSBOM_UnsupportedFormat(t *testing.T) {
+	_, err := exportSBOM([]sbomEntry{}, "swid", time.Now())
+	assert.ErrorContains(t, err, "unsupported SBOM format: swid")
+}
+
+func Test_packageURL(t *testing.T) {
+	assert.Equal(t,
+		"pkg:github/gatewayd-io/gatewayd-plugin-cache@v0.2.4",
+		packageURL(GitHubURLPrefix, "gatewayd-io", "gatewayd-plugin-cache", "v0.2.4"))
+	assert.Equal(t,
+		"pkg:github/acme/plugin@v1.0.0?repository_url=github.acme.com",
+		packageURL("github.acme.com/", "Acme", "plugin", "v1.0.0"))
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/getsentry/sentry-go"
+	"github.com/spf13/cobra"
+)
+
+var (
+	simulateHook    string
+	simulatePayload string
+)
+
+// pluginSimulateCmd represents the plugin simulate command.
+var pluginSimulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Run a hook chain of the configured plugins against a payload, without GatewayD running",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Enable Sentry.
+		if enableSentry {
+			// Initialize Sentry.
+			err := sentry.Init(sentry.ClientOptions{
+				Dsn:              DSN,
+				TracesSampleRate: config.DefaultTraceSampleRate,
+				AttachStacktrace: config.DefaultAttachStacktrace,
+				BeforeSend:       tagErrors,
+			})
+			if err != nil {
+				cmd.Println("Sentry initialization failed: ", err)
+				return
+			}
+
+			// Flush buffered events before the program terminates.
+			defer sentry.Flush(config.DefaultFlushTimeout)
+			// Recover from panics and report the error to Sentry.
+			defer sentry.Recover()
+		}
+
+		if err := simulateHooks(
+			cmd, pluginConfigFile, simulateHook, simulatePayload, devMode,
+		); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginSimulateCmd)
+
+	pluginSimulateCmd.Flags().StringVarP(
+		&pluginConfigFile, // Already exists in run.go
+		"plugin-config", "p", config.GetDefaultConfigFilePath(config.PluginsConfigFilename),
+		"Plugin config file")
+	pluginSimulateCmd.Flags().StringVarP(
+		&simulateHook,
+		"hook", "k", "onTraffic",
+		"Name of the hook to run, e.g. onTrafficFromClient or HOOK_NAME_ON_TRAFFIC_FROM_CLIENT")
+	pluginSimulateCmd.Flags().StringVarP(
+		&simulatePayload,
+		"payload", "d", "",
+		"JSON file with the arguments of the hook")
+	pluginSimulateCmd.Flags().BoolVar(
+		&devMode, "dev", false, "Enable development mode for plugin development") // Already exists in run.go
+	pluginSimulateCmd.Flags().BoolVar(
+		&enableSentry, "sentry", true, "Enable Sentry") // Already exists in run.go
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_pluginSimulateCmd(t *testing.T) {
+	_, err := executeCommandC(rootCmd, "plugin", "init", "-p", pluginTestConfigFile)
+	require.NoError(t, err, "plugin init command should not have returned an error")
+	defer os.Remove(pluginTestConfigFile)
+
+	payloadFile := filepath.Join(t.TempDir(), "payload.json")
+	require.NoError(t, os.WriteFile(payloadFile, []byte(`{"query": "SELECT 1;"}`), 0o600))
+
+	output, err := executeCommandC(
+		rootCmd, "plugin", "simulate", "-p", pluginTestConfigFile,
+		"--hook", "onTrafficFromClient", "--payload", payloadFile, "--sentry=false")
+	require.NoError(t, err, "plugin simulate command should not have returned an error")
+	assert.Equal(t, `Hook: HOOK_NAME_ON_TRAFFIC_FROM_CLIENT
+No plugins registered the hook
+Result:
+    {
+      "query": "SELECT 1;"
+    }
+`, output, "plugin simulate command should have returned the result of the hook chain")
+}
+
+func Test_printHookChain(t *testing.T) {
+	cmd := &cobra.Command{}
+	output := new(bytes.Buffer)
+	cmd.SetOut(output)
+
+	chain := plugin.HookChain{
+		Hook: "HOOK_NAME_ON_TRAFFIC_FROM_CLIENT",
+		Steps: []plugin.HookStep{
+			{
+				Plugin:   "gatewayd-plugin-cache",
+				Priority: 1000,
+				Input:    map[string]interface{}{"query": "SELECT 1;"},
+				Output:   map[string]interface{}{"query": "SELECT 1;", "terminate": true},
+				Duration: 2 * time.Millisecond,
+			},
+			{
+				Plugin:   "gatewayd-plugin-audit",
+				Priority: 1001,
+				Mode:     "async",
+				Input:    map[string]interface{}{"query": "SELECT 1;"},
+			},
+		},
+	}
+	require.NoError(t, printHookChain(
+		cmd, chain.Hook, chain, map[string]interface{}{"query": "SELECT 1;", "terminate": true}))
+	assert.Equal(t, `Hook: HOOK_NAME_ON_TRAFFIC_FROM_CLIENT
+Plugin: gatewayd-plugin-cache (priority 1000)
+  Input:
+    {
+      "query": "SELECT 1;"
+    }
+  Output:
+    {
+      "query": "SELECT 1;",
+      "terminate": true
+    }
+  Verified: false
+  Latency: 2ms
+Plugin: gatewayd-plugin-audit (priority 1001)
+  Mode: async
+  Input:
+    {
+      "query": "SELECT 1;"
+    }
+Result:
+    {
+      "query": "SELECT 1;",
+      "terminate": true
+    }
+`, output.String())
+}
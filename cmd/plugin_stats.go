@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/getsentry/sentry-go"
+	"github.com/spf13/cobra"
+)
+
+var metricsURL string
+
+// pluginStatsCmd represents the plugin stats command.
+var pluginStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the stats of the plugin hooks of a running GatewayD",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Enable Sentry.
+		if enableSentry {
+			// Initialize Sentry.
+			err := sentry.Init(sentry.ClientOptions{
+				Dsn:              DSN,
+				TracesSampleRate: config.DefaultTraceSampleRate,
+				AttachStacktrace: config.DefaultAttachStacktrace,
+				BeforeSend:       tagErrors,
+			})
+			if err != nil {
+				cmd.Println("Sentry initialization failed: ", err)
+				return
+			}
+
+			// Flush buffered events before the program terminates.
+			defer sentry.Flush(config.DefaultFlushTimeout)
+			// Recover from panics and report the error to Sentry.
+			defer sentry.Recover()
+		}
+
+		if err := pluginStats(cmd, metricsURL); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	pluginCmd.AddCommand(pluginStatsCmd)
+
+	pluginStatsCmd.Flags().StringVarP(
+		&metricsURL,
+		"metrics-url", "m",
+		"http://"+config.DefaultMetricsAddress+config.DefaultMetricsPath,
+		"URL of the metrics endpoint of the running GatewayD")
+	pluginStatsCmd.Flags().BoolVar(
+		&enableSentry, "sentry", true, "Enable Sentry") // Already exists in run.go
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_pluginStatsCmd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`# TYPE gatewayd_plugin_hook_invocations_total counter
+gatewayd_plugin_hook_invocations_total{hook="HOOK_NAME_ON_TRAFFIC_FROM_CLIENT",plugin="gatewayd-plugin-cache"} 4
+# TYPE gatewayd_plugin_hook_errors_total counter
+gatewayd_plugin_hook_errors_total{hook="HOOK_NAME_ON_TRAFFIC_FROM_CLIENT",plugin="gatewayd-plugin-cache"} 1
+# TYPE gatewayd_plugin_hook_terminations_total counter
+gatewayd_plugin_hook_terminations_total{hook="HOOK_NAME_ON_TRAFFIC_FROM_CLIENT",plugin="gatewayd-plugin-cache"} 2
+# TYPE gatewayd_plugin_hook_latency_seconds histogram
+gatewayd_plugin_hook_latency_seconds_bucket{hook="HOOK_NAME_ON_TRAFFIC_FROM_CLIENT",plugin="gatewayd-plugin-cache",le="+Inf"} 4
+gatewayd_plugin_hook_latency_seconds_sum{hook="HOOK_NAME_ON_TRAFFIC_FROM_CLIENT",plugin="gatewayd-plugin-cache"} 0.008
+gatewayd_plugin_hook_latency_seconds_count{hook="HOOK_NAME_ON_TRAFFIC_FROM_CLIENT",plugin="gatewayd-plugin-cache"} 4
+`))
+	}))
+	defer server.Close()
+
+	output, err := executeCommandC(rootCmd, "plugin", "stats", "-m", server.URL)
+	require.NoError(t, err, "plugin stats command should not have returned an error")
+	assert.Equal(t, `Plugin: gatewayd-plugin-cache
+  Hook: HOOK_NAME_ON_TRAFFIC_FROM_CLIENT
+    Invocations: 4
+    Errors: 1 (25.00%)
+    Modifications: 0 (0.00%)
+    Terminations: 2 (50.00%)
+    Average latency: 2ms
+`, output, "plugin stats command should have returned the stats")
+}
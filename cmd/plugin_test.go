@@ -18,10 +18,15 @@ Usage:
   gatewayd plugin [command]
 
 Available Commands:
+  cache       Manage the local cache of the downloaded plugin archives
+  info        Show the info of a plugin release, to vet the plugin before installing it
   init        Create or overwrite the GatewayD plugins config
   install     Install a plugin from a local archive or a GitHub repository
   lint        Lint the GatewayD plugins config
   list        List the GatewayD plugins
+  sbom        Export the SBOM of the installed plugins
+  simulate    Run a hook chain of the configured plugins against a payload, without GatewayD running
+  stats       Show the stats of the plugin hooks of a running GatewayD
 
 Flags:
   -h, --help   help for plugin
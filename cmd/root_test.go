@@ -18,11 +18,21 @@ Usage:
   gatewayd [command]
 
 Available Commands:
+  backends    Manage the database backends of a running GatewayD
+  clients     Show how the clients connect to GatewayD
   completion  Generate the autocompletion script for the specified shell
   config      Manage GatewayD global configuration
+  conns       Manage the client connections of a running GatewayD
+  debug       Debug a running GatewayD
+  exec        Run SQL files through a running GatewayD, e.g. to bootstrap the schemas of a test environment
+  generate    Generate files for integrating GatewayD with other tools
+  healthcheck Check that a running GatewayD is ready, e.g. for a Docker HEALTHCHECK
   help        Help about any command
+  maintenance Turn the maintenance mode of a running GatewayD on or off
   plugin      Manage plugins and their configuration
   run         Run a GatewayD instance
+  state       Show or reset the state changed at runtime, which is reapplied on restart
+  top         Monitor a running GatewayD in the terminal
   version     Show version information
 
 Flags:
@@ -2,17 +2,68 @@ package cmd
 
 import (
 	"context"
+	"net"
 	"os"
-	"sync"
 	"testing"
 	"time"
 
 	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/gatewayd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/zenizh/go-capturer"
 )
 
+// runGatewayD runs a GatewayD instance with the config files, like the run command,
+// stops it once its servers are running and returns its output.
+func runGatewayD(t *testing.T, globalConfigFile, pluginConfigFile string) string {
+	t.Helper()
+
+	return capturer.CaptureOutput(func() {
+		ctx := context.Background()
+		conf := config.NewConfig(ctx, globalConfigFile, pluginConfigFile)
+		conf.InitConfig(ctx)
+
+		gateway := gatewayd.New(ctx, conf, gatewayd.Options{})
+		require.NoError(t, gateway.Start(ctx), "GatewayD should have started")
+		for _, server := range gateway.Servers {
+			require.Eventually(t, server.IsRunning, 5*time.Second, 10*time.Millisecond)
+		}
+
+		gateway.Stop(ctx)
+		require.NoError(t, gateway.Wait(), "GatewayD should have stopped without an error")
+	})
+}
+
+// runCommand runs the run command with the arguments, cancels it once GatewayD is
+// listening on the address and returns its output.
+func runCommand(t *testing.T, address string, args ...string) string {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runCmd.SetContext(ctx)
+	// Cobra keeps the context of the command for its next runs.
+	defer runCmd.SetContext(context.Background())
+
+	go func() {
+		assert.Eventually(t, func() bool {
+			conn, err := net.Dial("tcp", address)
+			if err != nil {
+				return false
+			}
+			conn.Close()
+			return true
+		}, 5*time.Second, 10*time.Millisecond, "GatewayD should have been listening")
+		cancel()
+	}()
+
+	return capturer.CaptureOutput(func() {
+		_, err := executeCommandC(rootCmd, append([]string{"run"}, args...)...)
+		require.NoError(t, err, "run command should not have returned an error")
+	})
+}
+
 func Test_runCmd(t *testing.T) {
 	// Create a test plugins config file.
 	_, err := executeCommandC(rootCmd, "plugin", "init", "--force", "-p", pluginTestConfigFile)
@@ -25,43 +76,13 @@ func Test_runCmd(t *testing.T) {
 	// Check that the config file was created.
 	assert.FileExists(t, globalTestConfigFile, "configInitCmd should create a config file")
 
-	var waitGroup sync.WaitGroup
-
-	waitGroup.Add(1)
-	go func(waitGroup *sync.WaitGroup) {
-		// Test run command.
-		output := capturer.CaptureOutput(func() {
-			_, err := executeCommandC(rootCmd, "run", "-c", globalTestConfigFile, "-p", pluginTestConfigFile)
-			require.NoError(t, err, "run command should not have returned an error")
-		})
-		// Print the output for debugging purposes.
-		runCmd.Print(output)
-		// Check if GatewayD started and stopped correctly.
-		assert.Contains(t, output, "GatewayD is running")
-		assert.Contains(t, output, "Stopped all servers\n")
-
-		waitGroup.Done()
-	}(&waitGroup)
-
-	waitGroup.Add(1)
-	go func(waitGroup *sync.WaitGroup) {
-		time.Sleep(100 * time.Millisecond)
-
-		StopGracefully(
-			context.Background(),
-			nil,
-			nil,
-			metricsServer,
-			nil,
-			loggers[config.Default],
-			servers,
-			stopChan,
-		)
-
-		waitGroup.Done()
-	}(&waitGroup)
-
-	waitGroup.Wait()
+	output := runCommand(
+		t, "localhost:15432", "-c", globalTestConfigFile, "-p", pluginTestConfigFile)
+	// Print the output for debugging purposes.
+	runCmd.Print(output)
+	// Check if GatewayD started and stopped correctly.
+	assert.Contains(t, output, "GatewayD is running")
+	assert.Contains(t, output, "Stopped all servers\n")
 
 	// Clean up.
 	require.NoError(t, os.Remove(pluginTestConfigFile))
@@ -75,49 +96,14 @@ func Test_runCmdWithTLS(t *testing.T) {
 	require.NoError(t, err, "plugin init command should not have returned an error")
 	assert.FileExists(t, pluginTestConfigFile, "plugin init command should have created a config file")
 
-	stopChan = make(chan struct{})
-
-	var waitGroup sync.WaitGroup
-	// TODO: Test client certificate authentication.
-
-	waitGroup.Add(1)
-	go func(waitGroup *sync.WaitGroup) {
-		// Test run command.
-		output := capturer.CaptureOutput(func() {
-			_, err := executeCommandC(rootCmd, "run", "-c", globalTLSTestConfigFile, "-p", pluginTestConfigFile)
-			require.NoError(t, err, "run command should not have returned an error")
-		})
-
-		// Print the output for debugging purposes.
-		runCmd.Print(output)
-
-		// Check if GatewayD started and stopped correctly.
-		assert.Contains(t, output, "GatewayD is running")
-		assert.Contains(t, output, "TLS is enabled")
-		assert.Contains(t, output, "Stopped all servers\n")
-
-		waitGroup.Done()
-	}(&waitGroup)
-
-	waitGroup.Add(1)
-	go func(waitGroup *sync.WaitGroup) {
-		time.Sleep(100 * time.Millisecond)
-
-		StopGracefully(
-			context.Background(),
-			nil,
-			nil,
-			metricsServer,
-			nil,
-			loggers[config.Default],
-			servers,
-			stopChan,
-		)
-
-		waitGroup.Done()
-	}(&waitGroup)
-
-	waitGroup.Wait()
+	output := runCommand(
+		t, "localhost:15432", "-c", globalTLSTestConfigFile, "-p", pluginTestConfigFile)
+	// Print the output for debugging purposes.
+	runCmd.Print(output)
+	// Check if GatewayD started and stopped correctly.
+	assert.Contains(t, output, "GatewayD is running")
+	assert.Contains(t, output, "TLS is enabled")
+	assert.Contains(t, output, "Stopped all servers\n")
 
 	// Clean up.
 	require.NoError(t, os.Remove(pluginTestConfigFile))
@@ -131,60 +117,23 @@ func Test_runCmdWithMultiTenancy(t *testing.T) {
 	require.NoError(t, err, "plugin init command should not have returned an error")
 	assert.FileExists(t, pluginTestConfigFile, "plugin init command should have created a config file")
 
-	stopChan = make(chan struct{})
-
-	var waitGroup sync.WaitGroup
-
-	waitGroup.Add(1)
-	go func(waitGroup *sync.WaitGroup) {
-		// Test run command.
-		output := capturer.CaptureOutput(func() {
-			_, err := executeCommandC(
-				rootCmd, "run", "-c", "testdata/gatewayd.yaml", "-p", pluginTestConfigFile)
-			require.NoError(t, err, "run command should not have returned an error")
-		})
-		// Print the output for debugging purposes.
-		runCmd.Print(output)
-		// Check if GatewayD started and stopped correctly.
-		assert.Contains(t, output, "GatewayD is running")
-		assert.Contains(t, output, "There are clients available in the pool count=10 name=default")
-		assert.Contains(t, output, "There are clients available in the pool count=10 name=test")
-		assert.Contains(t, output, "GatewayD is listening address=0.0.0.0:15432")
-		assert.Contains(t, output, "GatewayD is listening address=0.0.0.0:15433")
-		assert.Contains(t, output, "Stopped all servers\n")
-
-		waitGroup.Done()
-	}(&waitGroup)
-
-	waitGroup.Add(1)
-	go func(waitGroup *sync.WaitGroup) {
-		time.Sleep(500 * time.Millisecond)
-
-		StopGracefully(
-			context.Background(),
-			nil,
-			nil,
-			metricsServer,
-			nil,
-			loggers[config.Default],
-			servers,
-			stopChan,
-		)
-
-		waitGroup.Done()
-	}(&waitGroup)
-
-	waitGroup.Wait()
+	output := runCommand(
+		t, "localhost:15433", "-c", "testdata/gatewayd.yaml", "-p", pluginTestConfigFile)
+	// Print the output for debugging purposes.
+	runCmd.Print(output)
+	// Check if GatewayD started and stopped correctly.
+	assert.Contains(t, output, "GatewayD is running")
+	assert.Contains(t, output, "There are clients available in the pool count=10 name=default")
+	assert.Contains(t, output, "There are clients available in the pool count=10 name=test")
+	assert.Contains(t, output, "GatewayD is listening address=0.0.0.0:15432")
+	assert.Contains(t, output, "GatewayD is listening address=0.0.0.0:15433")
+	assert.Contains(t, output, "Stopped all servers\n")
 
 	// Clean up.
 	require.NoError(t, os.Remove(pluginTestConfigFile))
 }
 
 func Test_runCmdWithCachePlugin(t *testing.T) {
-	// TODO: Remove this once these global variables are removed from cmd/run.go.
-	// https://github.com/gatewayd-io/gatewayd/issues/324
-	stopChan = make(chan struct{})
-
 	// Create a test plugins config file.
 	_, err := executeCommandC(rootCmd, "plugin", "init", "--force", "-p", pluginTestConfigFile)
 	require.NoError(t, err, "plugin init command should not have returned an error")
@@ -214,46 +163,38 @@ func Test_runCmdWithCachePlugin(t *testing.T) {
 	require.NoError(t, err, "plugin list should not return an error")
 	assert.Contains(t, output, "Name: gatewayd-plugin-cache")
 
-	var waitGroup sync.WaitGroup
-
-	waitGroup.Add(1)
-	go func(waitGroup *sync.WaitGroup) {
-		// Test run command.
-		output := capturer.CaptureOutput(func() {
-			_, err := executeCommandC(rootCmd, "run", "-c", globalTestConfigFile, "-p", pluginTestConfigFile)
-			require.NoError(t, err, "run command should not have returned an error")
-		})
-		// Print the output for debugging purposes.
-		runCmd.Print(output)
-		// Check if GatewayD started and stopped correctly.
-		assert.Contains(t, output, "GatewayD is running")
-		assert.Contains(t, output, "Stopped all servers\n")
-
-		waitGroup.Done()
-	}(&waitGroup)
+	output = runCommand(
+		t, "localhost:15432", "-c", globalTestConfigFile, "-p", pluginTestConfigFile)
+	// Print the output for debugging purposes.
+	runCmd.Print(output)
+	// Check if GatewayD started and stopped correctly.
+	assert.Contains(t, output, "GatewayD is running")
+	assert.Contains(t, output, "Stopped all servers\n")
 
-	waitGroup.Add(1)
-	go func(waitGroup *sync.WaitGroup) {
-		time.Sleep(time.Second)
+	// Clean up.
+	require.NoError(t, os.RemoveAll("plugins/"))
+	require.NoError(t, os.Remove(pluginTestConfigFile))
+	require.NoError(t, os.Remove(globalTestConfigFile))
+}
 
-		StopGracefully(
-			context.Background(),
-			nil,
-			nil,
-			metricsServer,
-			nil,
-			loggers[config.Default],
-			servers,
-			stopChan,
-		)
+// Test_runGatewayD tests that GatewayD runs as a library, without the run command.
+func Test_runGatewayD(t *testing.T) {
+	// Create a test plugins config file.
+	_, err := executeCommandC(rootCmd, "plugin", "init", "--force", "-p", pluginTestConfigFile)
+	require.NoError(t, err, "plugin init command should not have returned an error")
 
-		waitGroup.Done()
-	}(&waitGroup)
+	// Create a test config file.
+	_, err = executeCommandC(rootCmd, "config", "init", "--force", "-c", globalTestConfigFile)
+	require.NoError(t, err, "configInitCmd should not return an error")
 
-	waitGroup.Wait()
+	output := runGatewayD(t, globalTestConfigFile, pluginTestConfigFile)
+	// Print the output for debugging purposes.
+	runCmd.Print(output)
+	// Check if GatewayD started and stopped correctly.
+	assert.Contains(t, output, "GatewayD is running")
+	assert.Contains(t, output, "Stopped all servers\n")
 
 	// Clean up.
-	require.NoError(t, os.RemoveAll("plugins/"))
 	require.NoError(t, os.Remove(pluginTestConfigFile))
 	require.NoError(t, os.Remove(globalTestConfigFile))
 }
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codingsince1985/checksum"
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/google/uuid"
+)
+
+const (
+	SBOMFormatCycloneDX string = "cyclonedx"
+	SBOMFormatSPDX      string = "spdx"
+	SBOMFileExt         string = ".sbom.json"
+)
+
+// sbomEntry records where an installed plugin came from, for the SBOM of the plugins.
+type sbomEntry struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	Checksum  string `json:"checksum"`
+	LocalPath string `json:"localPath"`
+	// SourceURL is the URL of the release asset of the plugin, or the path of the local
+	// archive it was installed from.
+	SourceURL string `json:"sourceUrl"`
+	// PackageURL identifies the plugins installed from GitHub, e.g. for vulnerability
+	// scanners, see https://github.com/package-url/purl-spec.
+	PackageURL      string    `json:"purl,omitempty"`
+	InstalledAt     time.Time `json:"installedAt"`
+	GatewayDVersion string    `json:"gatewaydVersion"`
+}
+
+// sbomFilePath returns the path of the SBOM entries of the plugins in the plugins
+// configuration file, e.g. gatewayd_plugins.sbom.json next to gatewayd_plugins.yaml.
+func sbomFilePath(pluginConfigFile string) string {
+	return strings.TrimSuffix(pluginConfigFile, filepath.Ext(pluginConfigFile)) + SBOMFileExt
+}
+
+// packageURL returns the package URL of a plugin released on GitHub, with the server
+// as a qualifier for GitHub Enterprise.
+func packageURL(urlPrefix, account, repository, version string) string {
+	purl := fmt.Sprintf("pkg:github/%s/%s", strings.ToLower(account), strings.ToLower(repository))
+	if version != "" {
+		purl += "@" + url.PathEscape(version)
+	}
+	if urlPrefix != GitHubURLPrefix {
+		purl += "?repository_url=" + url.QueryEscape(strings.TrimSuffix(urlPrefix, "/"))
+	}
+	return purl
+}
+
+// readSBOM returns the SBOM entries of the installed plugins, if any.
+func readSBOM(sbomFile string) ([]sbomEntry, error) {
+	contents, err := os.ReadFile(sbomFile)
+	if os.IsNotExist(err) {
+		return []sbomEntry{}, nil
+	}
+	if err != nil {
+		return nil, gerr.ErrFileReadFailed.Wrap(err)
+	}
+	var entries []sbomEntry
+	if err := json.Unmarshal(contents, &entries); err != nil {
+		return nil, gerr.ErrFileReadFailed.Wrap(err)
+	}
+	return entries, nil
+}
+
+// recordSBOMEntry adds the entry of the installed plugin to the SBOM, replacing the
+// entry of the previously installed version.
+func recordSBOMEntry(sbomFile string, entry sbomEntry) error {
+	entries, err := readSBOM(sbomFile)
+	if err != nil {
+		return err
+	}
+	entries = slices.DeleteFunc(entries, func(e sbomEntry) bool {
+		return e.Name == entry.Name
+	})
+	entries = append(entries, entry)
+
+	contents, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	if err := os.WriteFile(sbomFile, contents, FilePermissions); err != nil {
+		return gerr.ErrFileOpenFailed.Wrap(err)
+	}
+	return nil
+}
+
+// verifySBOM returns the names of the plugins whose binaries no longer match the
+// checksums recorded when they were installed.
+func verifySBOM(entries []sbomEntry) []string {
+	mismatched := []string{}
+	for _, entry := range entries {
+		sum, err := checksum.SHA256sum(entry.LocalPath)
+		if err != nil || sum != entry.Checksum {
+			mismatched = append(mismatched, entry.Name)
+		}
+	}
+	return mismatched
+}
+
+// exportSBOM renders the SBOM entries in the CycloneDX 1.5 or the SPDX 2.3 JSON format.
+func exportSBOM(entries []sbomEntry, format string, now time.Time) ([]byte, error) {
+	var document any
+	switch format {
+	case SBOMFormatCycloneDX:
+		document = cycloneDXDocument(entries, now)
+	case SBOMFormatSPDX:
+		document = spdxDocument(entries, now)
+	default:
+		return nil, gerr.ErrValidationFailed.Wrap(
+			fmt.Errorf("unsupported SBOM format: %s", format))
+	}
+	contents, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, gerr.ErrValidationFailed.Wrap(err)
+	}
+	return contents, nil
+}
+
+func cycloneDXDocument(entries []sbomEntry, now time.Time) map[string]any {
+	gatewayd := map[string]any{
+		"type":    "application",
+		"name":    config.Name,
+		"version": config.Version,
+	}
+	components := []map[string]any{}
+	for _, entry := range entries {
+		component := map[string]any{
+			"type":     "application",
+			"bom-ref":  entry.Name,
+			"name":     entry.Name,
+			"version":  entry.Version,
+			"hashes":   []map[string]string{{"alg": "SHA-256", "content": entry.Checksum}},
+			"purl":     entry.PackageURL,
+			"scope":    "required",
+			"evidence": map[string]any{"occurrences": []map[string]string{{"location": entry.LocalPath}}},
+			"externalReferences": []map[string]string{
+				{"type": "distribution", "url": entry.SourceURL},
+			},
+			"properties": []map[string]string{
+				{"name": "gatewayd:installedAt", "value": entry.InstalledAt.UTC().Format(time.RFC3339)},
+				{"name": "gatewayd:gatewaydVersion", "value": entry.GatewayDVersion},
+			},
+		}
+		if entry.PackageURL == "" {
+			delete(component, "purl")
+		}
+		components = append(components, component)
+	}
+
+	return map[string]any{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + uuid.NewString(),
+		"version":      1,
+		"metadata": map[string]any{
+			"timestamp": now.UTC().Format(time.RFC3339),
+			"tools":     map[string]any{"components": []map[string]any{gatewayd}},
+			"component": gatewayd,
+		},
+		"components": components,
+	}
+}
+
+// spdxIDInvalidChars are the characters that are not allowed in the SPDX identifiers.
+var spdxIDInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9.\-]`)
+
+func spdxDocument(entries []sbomEntry, now time.Time) map[string]any {
+	packages := []map[string]any{}
+	relationships := []map[string]string{}
+	for _, entry := range entries {
+		spdxID := "SPDXRef-Package-" + spdxIDInvalidChars.ReplaceAllString(entry.Name, "-")
+		downloadLocation := entry.SourceURL
+		if !strings.Contains(downloadLocation, "://") {
+			// The local archives have no download location.
+			downloadLocation = "NOASSERTION"
+		}
+		pkg := map[string]any{
+			"name":             entry.Name,
+			"SPDXID":           spdxID,
+			"versionInfo":      entry.Version,
+			"downloadLocation": downloadLocation,
+			"filesAnalyzed":    false,
+			"checksums": []map[string]string{
+				{"algorithm": "SHA256", "checksumValue": entry.Checksum},
+			},
+			"comment": fmt.Sprintf("Installed to %s from %s at %s by %s %s",
+				entry.LocalPath, entry.SourceURL, entry.InstalledAt.UTC().Format(time.RFC3339),
+				config.Name, entry.GatewayDVersion),
+		}
+		if entry.Version == "" {
+			delete(pkg, "versionInfo")
+		}
+		if entry.PackageURL != "" {
+			pkg["externalRefs"] = []map[string]string{
+				{
+					"referenceCategory": "PACKAGE-MANAGER",
+					"referenceType":     "purl",
+					"referenceLocator":  entry.PackageURL,
+				},
+			}
+		}
+		packages = append(packages, pkg)
+		relationships = append(relationships, map[string]string{
+			"spdxElementId":      "SPDXRef-DOCUMENT",
+			"relationshipType":   "DESCRIBES",
+			"relatedSpdxElement": spdxID,
+		})
+	}
+
+	return map[string]any{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              "gatewayd-plugins",
+		"documentNamespace": "https://gatewayd.io/spdx/gatewayd-plugins-" + uuid.NewString(),
+		"creationInfo": map[string]any{
+			"created":  now.UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: " + config.Name + "-" + config.Version},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+}
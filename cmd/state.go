@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+// stateCmd represents the state command.
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Show or reset the state changed at runtime, which is reapplied on restart",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Help(); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(stateCmd)
+}
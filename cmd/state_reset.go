@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/spf13/cobra"
+)
+
+// stateResetCmd represents the state reset command.
+var stateResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Remove the persisted state, so that only the config applies on restart",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := resetState(cmd, globalConfigFile); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	stateCmd.AddCommand(stateResetCmd)
+
+	stateResetCmd.Flags().StringVarP(
+		&globalConfigFile, // Already exists in run.go
+		"config", "c", config.GetDefaultConfigFilePath(config.GlobalConfigFilename),
+		"Global config file")
+}
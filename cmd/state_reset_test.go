@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/api"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_stateResetCmd(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, api.UpdateState(stateFile, func(state *api.State) {
+		state.Maintenance = &network.MaintenanceStatus{Enabled: true}
+	}))
+
+	output, err := executeCommandC(
+		rootCmd, "state", "reset", "-c", writeStateConfig(t, stateFile))
+	require.NoError(t, err, "state reset command should not have returned an error")
+	assert.Equal(t,
+		"The state was reset, only the config applies when GatewayD restarts\n", output)
+	assert.NoFileExists(t, stateFile)
+}
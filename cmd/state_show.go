@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/spf13/cobra"
+)
+
+// stateShowCmd represents the state show command.
+var stateShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the state persisted to the state file of the config",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := showState(cmd, globalConfigFile); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	stateCmd.AddCommand(stateShowCmd)
+
+	stateShowCmd.Flags().StringVarP(
+		&globalConfigFile, // Already exists in run.go
+		"config", "c", config.GetDefaultConfigFilePath(config.GlobalConfigFilename),
+		"Global config file")
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeStateConfig writes a global config whose state is persisted to the file.
+func writeStateConfig(t *testing.T, stateFile string) string {
+	t.Helper()
+
+	globalConfigFile := filepath.Join(t.TempDir(), "gatewayd.yaml")
+	require.NoError(t, os.WriteFile(
+		globalConfigFile, []byte(fmt.Sprintf("state:\n  fileName: %s\n", stateFile)), 0o600))
+	return globalConfigFile
+}
+
+func Test_stateShowCmd(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	globalConfigFile := writeStateConfig(t, stateFile)
+
+	output, err := executeCommandC(rootCmd, "state", "show", "-c", globalConfigFile)
+	require.NoError(t, err, "state show command should not have returned an error")
+	assert.Equal(t, "Nothing was changed at runtime\n", output)
+
+	require.NoError(t, api.UpdateState(stateFile, func(state *api.State) {
+		state.HookTrace = &api.HookTraceStatus{Enabled: true, SampleRate: 0.5}
+	}))
+	output, err = executeCommandC(rootCmd, "state", "show", "-c", globalConfigFile)
+	require.NoError(t, err, "state show command should not have returned an error")
+	assert.Contains(t, output, `"sampleRate": 0.5`)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"log"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/spf13/cobra"
+)
+
+const DefaultTopInterval = 2 * time.Second
+
+var topInterval time.Duration
+
+// topCmd represents the top command.
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Monitor a running GatewayD in the terminal",
+	Long: "Monitor the proxies, pools, sessions and plugin hooks of a running GatewayD " +
+		"in the terminal, and kill the sessions interactively",
+	Run: func(cmd *cobra.Command, args []string) {
+		program := tea.NewProgram(
+			newTopModel(apiURL, metricsURL, topInterval),
+			tea.WithAltScreen(),
+			tea.WithInput(cmd.InOrStdin()),
+			tea.WithOutput(cmd.OutOrStdout()),
+		)
+		if _, err := program.Run(); err != nil {
+			log.New(cmd.OutOrStdout(), "", 0).Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(topCmd)
+
+	topCmd.Flags().StringVarP(
+		&apiURL,
+		"api-url", "u",
+		"http://"+config.DefaultHTTPAPIAddress,
+		"URL of the HTTP API of the running GatewayD") // Already exists in debug_hooks.go
+	topCmd.Flags().StringVarP(
+		&metricsURL,
+		"metrics-url", "m",
+		"http://"+config.DefaultMetricsAddress+config.DefaultMetricsPath,
+		"URL of the metrics endpoint of the running GatewayD") // Already exists in plugin_stats.go
+	topCmd.Flags().DurationVarP(
+		&topInterval, "interval", "i", DefaultTopInterval, "Interval between the refreshes")
+}
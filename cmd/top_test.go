@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_topModel(t *testing.T) {
+	var queries atomic.Int32
+	var killed atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/GatewayDPluginService/GetProxies":
+			_, _ = w.Write([]byte(`{"default":{"available":["a","b"],"busy":["c"],"total":3,` +
+				`"queries":` + map[bool]string{true: "100", false: "40"}[queries.Add(1) > 1] + `}}`))
+		case "/v1/GatewayDPluginService/GetPools":
+			_, _ = w.Write([]byte(`{"default":{"cap":4,"size":2}}`))
+		case "/connections":
+			_, _ = w.Write([]byte(`[{"id":"abc123","proxy":"default","remote":"127.0.0.1:5000",` +
+				`"user":"postgres","database":"app","state":"idle","since":"` +
+				time.Now().UTC().Format(time.RFC3339Nano) + `"}]`))
+		case "/connections/abc123":
+			assert.Equal(t, http.MethodDelete, r.Method)
+			killed.Store(true)
+			w.WriteHeader(http.StatusNoContent)
+		case "/metrics":
+			_, _ = w.Write([]byte(`# TYPE gatewayd_plugin_hook_invocations_total counter
+gatewayd_plugin_hook_invocations_total{hook="onTrafficFromClient",plugin="cache"} 8
+# TYPE gatewayd_plugin_hook_latency_seconds histogram
+gatewayd_plugin_hook_latency_seconds_bucket{hook="onTrafficFromClient",plugin="cache",le="+Inf"} 8
+gatewayd_plugin_hook_latency_seconds_sum{hook="onTrafficFromClient",plugin="cache"} 0.016
+gatewayd_plugin_hook_latency_seconds_count{hook="onTrafficFromClient",plugin="cache"} 8
+`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var model tea.Model = newTopModel(server.URL, server.URL+"/metrics", time.Second)
+	assert.Contains(t, model.View(), "Loading...")
+
+	first, ok := model.(topModel).refresh().(topSnapshotMsg)
+	require.True(t, ok)
+	require.NoError(t, first.err)
+	model, _ = model.Update(first)
+	second, ok := model.(topModel).refresh().(topSnapshotMsg)
+	require.True(t, ok)
+	require.NoError(t, second.err)
+	// The queries per second are the rate since the previous snapshot.
+	second.snapshot.time = first.snapshot.time.Add(2 * time.Second)
+	model, _ = model.Update(second)
+
+	view := model.View()
+	assert.Regexp(t, `default\s+30\.0\s+100\s+1\s+1\s+2\s+4\s+25%`, view)
+	assert.Regexp(t, `abc123\s+default\s+127\.0\.0\.1:5000\s+postgres\s+app\s+idle`, view)
+	assert.Regexp(t, `cache\s+onTrafficFromClient\s+8\s+0\s+2ms`, view)
+
+	// Select the session and kill it after the confirmation.
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	assert.Contains(t, model.View(), "> abc123")
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	assert.Contains(t, model.View(), "Kill session abc123? (y/n)")
+	model, kill := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	require.NotNil(t, kill)
+	model, _ = model.Update(kill())
+	assert.Equal(t, true, killed.Load())
+	assert.Contains(t, model.View(), "Killed session abc123")
+
+	_, quit := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	require.NotNil(t, quit)
+	assert.Equal(t, tea.Quit(), quit())
+}
+
+func Test_topModelError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	model := newTopModel(server.URL, server.URL+"/metrics", time.Second)
+	updated, _ := model.Update(model.refresh())
+	assert.Contains(t, updated.View(),
+		"Error: failed to fetch the status, OriginalError: unexpected status code of "+
+			"/v1/GatewayDPluginService/GetProxies: 401")
+}
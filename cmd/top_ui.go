@@ -0,0 +1,355 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gatewayd-io/gatewayd/api"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+)
+
+// topProxy is the status of a proxy, as returned by GetProxies.
+type topProxy struct {
+	Available []interface{} `json:"available"`
+	Busy      []interface{} `json:"busy"`
+	Queries   float64       `json:"queries"`
+}
+
+// topPool is the status of a pool, as returned by GetPools.
+type topPool struct {
+	Cap  float64 `json:"cap"`
+	Size float64 `json:"size"`
+}
+
+// topSnapshot is the status of a running GatewayD at a point in time.
+type topSnapshot struct {
+	time        time.Time
+	proxies     map[string]topProxy
+	pools       map[string]topPool
+	connections []api.Connection
+	// hooks are the stats of the plugin hooks, unless the metrics failed to be
+	// fetched, e.g. as they are disabled.
+	hooks    map[string]map[string]*pluginHookStats
+	hooksErr error
+}
+
+// fetchTopSnapshot fetches the status of a running GatewayD from its admin API and
+// its metrics.
+func fetchTopSnapshot(apiURL, metricsURL string) (*topSnapshot, error) {
+	snapshot := &topSnapshot{time: time.Now()}
+	if err := getAPI(apiURL, "/v1/GatewayDPluginService/GetProxies", &snapshot.proxies); err != nil {
+		return nil, gerr.ErrFetchStatusFailed.Wrap(err)
+	}
+	if err := getAPI(apiURL, "/v1/GatewayDPluginService/GetPools", &snapshot.pools); err != nil {
+		return nil, gerr.ErrFetchStatusFailed.Wrap(err)
+	}
+	if err := getAPI(apiURL, "/connections", &snapshot.connections); err != nil {
+		return nil, gerr.ErrFetchConnectionsFailed.Wrap(err)
+	}
+	sort.Slice(snapshot.connections, func(i, j int) bool {
+		return snapshot.connections[i].Since.Before(snapshot.connections[j].Since)
+	})
+	snapshot.hooks, snapshot.hooksErr = fetchPluginHookStats(metricsURL)
+	return snapshot, nil
+}
+
+type (
+	topTickMsg     struct{}
+	topSnapshotMsg struct {
+		snapshot *topSnapshot
+		err      error
+	}
+	topKilledMsg struct {
+		id  string
+		err error
+	}
+)
+
+// topModel is the terminal UI of the top command, which refreshes the status of a
+// running GatewayD periodically, and kills the selected session on demand.
+type topModel struct {
+	apiURL     string
+	metricsURL string
+	interval   time.Duration
+
+	current  *topSnapshot
+	previous *topSnapshot
+	err      error
+	// status is the result of the last kill.
+	status string
+
+	// selected is the ID of the selected session, and killing is set while the kill
+	// waits for a confirmation.
+	selected string
+	killing  bool
+	height   int
+}
+
+func newTopModel(apiURL, metricsURL string, interval time.Duration) topModel {
+	return topModel{apiURL: apiURL, metricsURL: metricsURL, interval: interval}
+}
+
+func (m topModel) Init() tea.Cmd {
+	return m.refresh
+}
+
+func (m topModel) refresh() tea.Msg {
+	snapshot, err := fetchTopSnapshot(m.apiURL, m.metricsURL)
+	return topSnapshotMsg{snapshot: snapshot, err: err}
+}
+
+func (m topModel) tick() tea.Cmd {
+	return tea.Tick(m.interval, func(time.Time) tea.Msg { return topTickMsg{} })
+}
+
+func (m topModel) kill(id string) tea.Cmd {
+	return func() tea.Msg {
+		return topKilledMsg{id: id, err: deleteConnection(m.apiURL, id)}
+	}
+}
+
+func (m topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case topTickMsg:
+		return m, m.refresh
+	case topSnapshotMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.previous, m.current = m.current, msg.snapshot
+			if m.sessionIndex() < 0 {
+				m.selected = ""
+				m.killing = false
+			}
+		}
+		return m, m.tick()
+	case topKilledMsg:
+		if msg.err != nil {
+			m.status = msg.err.Error()
+		} else {
+			m.status = "Killed session " + msg.id
+		}
+		return m, m.refresh
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m topModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.killing {
+		m.killing = false
+		if msg.String() == "y" && m.selected != "" {
+			return m, m.kill(m.selected)
+		}
+		m.status = ""
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "q", "ctrl+c", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		m.moveSelection(-1)
+	case "down", "j":
+		m.moveSelection(1)
+	case "x", "delete":
+		if m.selected != "" {
+			m.killing = true
+		}
+	case "r":
+		return m, m.refresh
+	}
+	return m, nil
+}
+
+// sessionIndex returns the index of the selected session, or -1.
+func (m topModel) sessionIndex() int {
+	if m.current == nil {
+		return -1
+	}
+	for i, conn := range m.current.connections {
+		if conn.ID == m.selected {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *topModel) moveSelection(delta int) {
+	if m.current == nil || len(m.current.connections) == 0 {
+		return
+	}
+	index := m.sessionIndex() + delta
+	if m.sessionIndex() < 0 {
+		index = 0
+	}
+	index = max(0, min(index, len(m.current.connections)-1))
+	m.selected = m.current.connections[index].ID
+}
+
+// queriesPerSecond returns the rate of the queries of the proxy since the previous
+// snapshot.
+func (m topModel) queriesPerSecond(name string) float64 {
+	if m.previous == nil {
+		return 0
+	}
+	previous, ok := m.previous.proxies[name]
+	elapsed := m.current.time.Sub(m.previous.time).Seconds()
+	if !ok || elapsed <= 0 || m.current.proxies[name].Queries < previous.Queries {
+		return 0
+	}
+	return (m.current.proxies[name].Queries - previous.Queries) / elapsed
+}
+
+//nolint:gomnd
+func (m topModel) View() string {
+	var view strings.Builder
+	fmt.Fprintf(&view, "GatewayD top - %s", m.apiURL)
+	if m.current != nil {
+		fmt.Fprintf(&view, " - updated at %s", m.current.time.Format(time.TimeOnly))
+	}
+	view.WriteString("\n")
+	if m.err != nil {
+		fmt.Fprintf(&view, "Error: %s\n", m.err)
+	}
+	if m.current == nil {
+		view.WriteString("\nLoading...\n")
+		return view.String()
+	}
+
+	clients := map[string]int{}
+	for _, conn := range m.current.connections {
+		clients[conn.Proxy]++
+	}
+	proxies := make([]string, 0, len(m.current.proxies))
+	for name := range m.current.proxies {
+		proxies = append(proxies, name)
+	}
+	sort.Strings(proxies)
+
+	view.WriteString("\n")
+	table := tabwriter.NewWriter(&view, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PROXY\tQPS\tQUERIES\tSESSIONS\tBUSY\tIDLE\tPOOL CAP\tPOOL USAGE")
+	for _, name := range proxies {
+		proxy := m.current.proxies[name]
+		usage := "-"
+		if capacity := m.current.pools[name].Cap; capacity > 0 {
+			usage = fmt.Sprintf("%.0f%%", float64(len(proxy.Busy))/capacity*100)
+		}
+		fmt.Fprintf(table, "%s\t%.1f\t%.0f\t%d\t%d\t%d\t%.0f\t%s\n",
+			name, m.queriesPerSecond(name), proxy.Queries, clients[name],
+			len(proxy.Busy), len(proxy.Available), m.current.pools[name].Cap, usage)
+	}
+	table.Flush()
+
+	view.WriteString("\n")
+	m.viewSessions(&view)
+
+	view.WriteString("\n")
+	m.viewHooks(&view)
+
+	view.WriteString("\n")
+	switch {
+	case m.killing:
+		fmt.Fprintf(&view, "Kill session %s? (y/n)\n", m.selected)
+	case m.status != "":
+		fmt.Fprintln(&view, m.status)
+	}
+	view.WriteString("up/down: select  x: kill session  r: refresh  q: quit\n")
+	return view.String()
+}
+
+// viewSessions renders the sessions, scrolled to the selected one if they don't fit
+// in the terminal.
+//
+//nolint:gomnd
+func (m topModel) viewSessions(view *strings.Builder) {
+	connections := m.current.connections
+	fmt.Fprintf(view, "SESSIONS (%d)\n", len(connections))
+	if len(connections) == 0 {
+		return
+	}
+
+	visible := len(connections)
+	if m.height > 0 {
+		// The rest of the view takes about half of the terminal.
+		visible = max(m.height/2-2, 3)
+	}
+	first := 0
+	if index := m.sessionIndex(); index >= visible {
+		first = index - visible + 1
+	}
+	last := min(first+visible, len(connections))
+
+	table := tabwriter.NewWriter(view, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "  ID\tPROXY\tCLIENT\tUSER\tDATABASE\tSTATE\tAGE\tBYTES IN\tBYTES OUT")
+	for _, conn := range connections[first:last] {
+		marker := " "
+		if conn.ID == m.selected {
+			marker = ">"
+		}
+		fmt.Fprintf(table, "%s %s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\n",
+			marker, conn.ID, conn.Proxy, conn.Remote, conn.User, conn.Database, conn.State,
+			m.current.time.Sub(conn.Since).Truncate(time.Second), conn.BytesIn, conn.BytesOut)
+	}
+	table.Flush()
+	if last < len(connections) {
+		fmt.Fprintf(view, "  ... %d more\n", len(connections)-last)
+	}
+}
+
+// viewHooks renders the invocations and the latency of the plugin hooks, where the
+// latency is the average since the previous snapshot, or since GatewayD started.
+func (m topModel) viewHooks(view *strings.Builder) {
+	view.WriteString("HOOKS\n")
+	if m.current.hooksErr != nil {
+		fmt.Fprintf(view, "  %s\n", m.current.hooksErr)
+		return
+	}
+	if len(m.current.hooks) == 0 {
+		view.WriteString("  No plugin hooks were invoked\n")
+		return
+	}
+
+	plugins := make([]string, 0, len(m.current.hooks))
+	for plugin := range m.current.hooks {
+		plugins = append(plugins, plugin)
+	}
+	sort.Strings(plugins)
+
+	table := tabwriter.NewWriter(view, 0, 0, 2, ' ', 0) //nolint:gomnd
+	fmt.Fprintln(table, "PLUGIN\tHOOK\tINVOCATIONS\tERRORS\tLATENCY")
+	for _, plugin := range plugins {
+		hooks := make([]string, 0, len(m.current.hooks[plugin]))
+		for hook := range m.current.hooks[plugin] {
+			hooks = append(hooks, hook)
+		}
+		sort.Strings(hooks)
+
+		for _, hook := range hooks {
+			stats := m.current.hooks[plugin][hook]
+			latencySum, latencyCount := stats.latencySum, stats.latencyCount
+			if m.previous != nil && m.previous.hooks[plugin][hook] != nil {
+				previous := m.previous.hooks[plugin][hook]
+				if latencyCount > previous.latencyCount {
+					latencySum -= previous.latencySum
+					latencyCount -= previous.latencyCount
+				}
+			}
+			latency := "-"
+			if latencyCount > 0 {
+				latency = time.Duration(
+					latencySum / float64(latencyCount) * float64(time.Second)).String()
+			}
+			fmt.Fprintf(table, "%s\t%s\t%.0f\t%.0f\t%s\n",
+				plugin, hook, stats.invocations, stats.errors, latency)
+		}
+	}
+	table.Flush()
+}
@@ -1,28 +1,45 @@
 package cmd
 
 import (
-	"archive/tar"
-	"archive/zip"
-	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 
+	"github.com/codingsince1985/checksum"
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	"github.com/gatewayd-io/gatewayd/api"
 	"github.com/gatewayd-io/gatewayd/config"
 	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/getsentry/sentry-go"
 	"github.com/google/go-github/v53/github"
 	jsonSchemaGenerator "github.com/invopop/jsonschema"
 	"github.com/knadh/koanf"
 	koanfJson "github.com/knadh/koanf/parsers/json"
 	"github.com/knadh/koanf/parsers/yaml"
+	promClient "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/rs/zerolog"
 	jsonSchemaV5 "github.com/santhosh-tekuri/jsonschema/v5"
 	"github.com/spf13/cobra"
 )
@@ -36,6 +53,15 @@ const (
 	ExecFilePermissions os.FileMode = 0o755
 	ExecFileMask        os.FileMode = 0o111
 	MaxFileSize         int64       = 1024 * 1024 * 100 // 10MB
+	MaxExtractedSize    int64       = 1024 * 1024 * 500
+	MaxLinknameSize     int64       = 4096
+	MiB                             = 1024 * 1024
+	PartialDownloadExt  string      = ".part"
+	ProgressBarWidth    int64       = 40
+	ProgressInterval                = 100 * time.Millisecond
+	GitHubAPIHost       string      = "api.github.com"
+	GitHubTokenEnv      string      = "GITHUB_TOKEN"
+	APITokenEnv         string      = "GATEWAYD_API_TOKEN"
 )
 
 var (
@@ -163,9 +189,189 @@ func lintConfig(fileType configFileType, configFile string) error {
 		return gerr.ErrLintingFailed.Wrap(err)
 	}
 
+	// Validate the references between the config groups, reporting all the violations.
+	var violations []error
+	switch fileType {
+	case Global:
+		violations = config.ValidateReferences(conf.Global, config.PluginConfig{})
+	case Plugins:
+		violations = config.ValidateReferences(config.GlobalConfig{}, conf.Plugin)
+	}
+	if len(violations) > 0 {
+		return gerr.ErrLintingFailed.Wrap(errors.Join(violations...))
+	}
+
 	return nil
 }
 
+// pluginSettings returns the settings of the plugin, i.e. its env, without the magic
+// cookie GatewayD identifies the plugin with.
+func pluginSettings(pluginConfig config.Plugin) map[string]interface{} {
+	settings := map[string]interface{}{}
+	for _, env := range pluginConfig.Env {
+		key, value, _ := strings.Cut(env, "=")
+		if key == "MAGIC_COOKIE_KEY" || key == "MAGIC_COOKIE_VALUE" {
+			continue
+		}
+		settings[key] = value
+	}
+	return settings
+}
+
+// pluginConfigSchema returns the JSON schema of the settings of the plugin and where it
+// comes from: the file set in its config, the one bundled next to its executable, or
+// the one the plugin published, if it was fetched. It returns nil if there is none.
+func pluginConfigSchema(
+	pluginConfig config.Plugin, published map[string][]byte,
+) ([]byte, string, error) {
+	if pluginConfig.ConfigSchema != "" {
+		schema, err := os.ReadFile(pluginConfig.ConfigSchema)
+		return schema, pluginConfig.ConfigSchema, err //nolint:wrapcheck
+	}
+	bundled := pluginConfig.LocalPath + config.PluginConfigSchemaSuffix
+	if schema, err := os.ReadFile(bundled); err == nil {
+		return schema, bundled, nil
+	}
+	if schema, ok := published[pluginConfig.Name]; ok {
+		return schema, pluginConfig.Name + config.PluginConfigSchemaSuffix, nil
+	}
+	return nil, "", nil
+}
+
+// fetchPluginConfigSchemas starts the plugins to fetch the JSON schemas of their
+// settings they publish in the "configSchema" field of their metadata, by plugin name.
+func fetchPluginConfigSchemas(
+	ctx context.Context, cmd *cobra.Command, conf *config.Config, devMode bool,
+) map[string][]byte {
+	pluginRegistry := newPluginRegistry(ctx, cmd, conf, devMode)
+	defer pluginRegistry.Shutdown()
+	pluginRegistry.LoadPlugins(ctx, conf.Plugin.Plugins, conf.Plugin.StartTimeout)
+
+	schemas := map[string][]byte{}
+	pluginRegistry.ForEach(func(pluginID sdkPlugin.Identifier, pluginInstance *plugin.Plugin) {
+		if schema := pluginRegistry.ConfigSchema(pluginInstance.Priority); schema != nil {
+			schemas[pluginID.Name] = schema
+		}
+	})
+	return schemas
+}
+
+// lintPluginSettings validates the settings of each enabled plugin against the JSON
+// schema of its config, reporting the violations of all the plugins. The plugins
+// without a schema are skipped, and the schemas the plugins publish are only fetched
+// if asked, as the plugins are started for that.
+func lintPluginSettings(
+	cmd *cobra.Command, pluginConfigFile string, fetchSchemas, devMode bool,
+) error {
+	ctx := context.Background()
+	conf := config.NewConfig(ctx, "", pluginConfigFile)
+	conf.LoadDefaults(ctx)
+	conf.LoadPluginConfigFile(ctx)
+	conf.UnmarshalPluginConfig(ctx)
+
+	var published map[string][]byte
+	if fetchSchemas {
+		published = fetchPluginConfigSchemas(ctx, cmd, conf, devMode)
+	}
+
+	var violations []error
+	for _, pluginConfig := range conf.Plugin.Plugins {
+		if !pluginConfig.Enabled {
+			continue
+		}
+		schemaBytes, source, err := pluginConfigSchema(pluginConfig, published)
+		if err != nil {
+			violations = append(violations, fmt.Errorf(
+				"plugin %q: failed to read its config schema: %w", pluginConfig.Name, err))
+			continue
+		}
+		if schemaBytes == nil {
+			continue
+		}
+
+		schema, err := jsonSchemaV5.CompileString(source, string(schemaBytes))
+		if err != nil {
+			violations = append(violations, fmt.Errorf(
+				"plugin %q: invalid config schema: %w", pluginConfig.Name, err))
+			continue
+		}
+		if err := schema.Validate(pluginSettings(pluginConfig)); err != nil {
+			// List all the violations of the settings, not just the first one.
+			violations = append(violations, fmt.Errorf("plugin %q: %#v", pluginConfig.Name, err))
+		}
+	}
+	if len(violations) > 0 {
+		return gerr.ErrLintingFailed.Wrap(errors.Join(violations...))
+	}
+
+	return nil
+}
+
+// generateDashboards generates a Grafana dashboard and Prometheus alert rules
+// whose thresholds match the given global and plugin config files.
+func generateDashboards(
+	cmd *cobra.Command,
+	globalConfigFile, pluginConfigFile, outputDir string,
+	forceRewriteFile bool,
+) {
+	logger := log.New(cmd.OutOrStdout(), "", 0)
+
+	// Load the config files.
+	conf := config.NewConfig(context.TODO(), globalConfigFile, pluginConfigFile)
+	conf.LoadDefaults(context.TODO())
+	conf.LoadGlobalConfigFile(context.TODO())
+	conf.UnmarshalGlobalConfig(context.TODO())
+	conf.LoadPluginConfigFile(context.TODO())
+	conf.UnmarshalPluginConfig(context.TODO())
+
+	// Elastic proxies create connections on demand, so their pools never run out.
+	options := metrics.DashboardOptions{
+		HookLatencyThreshold: conf.Plugin.Timeout / 2, //nolint:gomnd
+	}
+	for name, proxy := range conf.Global.Proxies {
+		if pool, ok := conf.Global.Pools[name]; ok && !proxy.Elastic {
+			options.PoolSize += pool.Size
+		}
+	}
+
+	dashboard, err := metrics.GrafanaDashboard(options)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	alertRules, err := metrics.PrometheusAlertRules(options)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{filepath.Join(outputDir, config.DashboardFilename), dashboard},
+		{filepath.Join(outputDir, config.AlertRulesFilename), alertRules},
+	}
+
+	// Check if the files already exist and if we should overwrite them.
+	for _, file := range files {
+		if _, err := os.Stat(file.name); err == nil && !forceRewriteFile {
+			logger.Fatalf(
+				"File '%s' already exists. Use --force to overwrite or choose a different directory.",
+				file.name)
+		}
+	}
+
+	if err := os.MkdirAll(outputDir, ExecFilePermissions); err != nil {
+		logger.Fatal(err)
+	}
+
+	for _, file := range files {
+		if err := os.WriteFile(file.name, file.data, FilePermissions); err != nil {
+			logger.Fatal(err)
+		}
+		cmd.Printf("File '%s' was generated successfully.\n", file.name)
+	}
+}
+
 func listPlugins(cmd *cobra.Command, pluginConfigFile string, onlyEnabled bool) {
 	// Load the plugin config file.
 	conf := config.NewConfig(context.TODO(), "", pluginConfigFile)
@@ -197,170 +403,569 @@ func listPlugins(cmd *cobra.Command, pluginConfigFile string, onlyEnabled bool)
 	}
 }
 
-func extractZip(filename, dest string) ([]string, error) {
-	// Open and extract the zip file.
-	zipRc, err := zip.OpenReader(filename)
+// pluginHookStats are the stats of a plugin hook, taken from the metrics.
+type pluginHookStats struct {
+	invocations   float64
+	errors        float64
+	modifications float64
+	terminations  float64
+	latencySum    float64
+	latencyCount  uint64
+}
+
+// fetchPluginHookStats fetches the metrics of a running GatewayD and returns the stats
+// of the hooks by plugin and hook.
+func fetchPluginHookStats(metricsURL string) (map[string]map[string]*pluginHookStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultMetricsServerTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metricsURL, nil)
+	if err != nil {
+		return nil, gerr.ErrFetchMetricsFailed.Wrap(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, gerr.ErrExtractFailed.Wrap(err)
+		return nil, gerr.ErrFetchMetricsFailed.Wrap(err)
 	}
-	defer zipRc.Close()
+	defer resp.Body.Close()
 
-	// Create the output directory if it doesn't exist.
-	if err := os.MkdirAll(dest, FolderPermissions); err != nil {
-		return nil, gerr.ErrExtractFailed.Wrap(err)
+	if resp.StatusCode != http.StatusOK {
+		return nil, gerr.ErrFetchMetricsFailed.Wrap(
+			fmt.Errorf("unexpected status code: %d", resp.StatusCode))
 	}
 
-	// Extract the files.
-	filenames := []string{}
-	for _, file := range zipRc.File {
-		switch fileInfo := file.FileInfo(); {
-		case fileInfo.IsDir():
-			// Sanitize the path.
-			filename := filepath.Clean(file.Name)
-			if !path.IsAbs(filename) {
-				destPath := path.Join(dest, filename)
-				// Create the directory.
+	textParser := expfmt.TextParser{}
+	families, err := textParser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, gerr.ErrFetchMetricsFailed.Wrap(err)
+	}
 
-				if err := os.MkdirAll(destPath, FolderPermissions); err != nil {
-					return nil, gerr.ErrExtractFailed.Wrap(err)
-				}
+	// Collect the stats by plugin and hook.
+	stats := map[string]map[string]*pluginHookStats{}
+	collect := func(name string, update func(*pluginHookStats, *promClient.Metric)) {
+		family, ok := families[metrics.Namespace+"_"+name]
+		if !ok {
+			return
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
 			}
-		case fileInfo.Mode().IsRegular():
-			// Sanitize the path.
-			outFilename := filepath.Join(filepath.Clean(dest), filepath.Clean(file.Name))
-
-			// Check for ZipSlip.
-			if strings.HasPrefix(outFilename, string(os.PathSeparator)) {
-				return nil, gerr.ErrExtractFailed.Wrap(
-					fmt.Errorf("illegal file path: %s", outFilename))
+			if _, ok := stats[labels["plugin"]]; !ok {
+				stats[labels["plugin"]] = map[string]*pluginHookStats{}
 			}
-
-			// Create the file.
-			outFile, err := os.Create(outFilename)
-			if err != nil {
-				return nil, gerr.ErrExtractFailed.Wrap(err)
+			if _, ok := stats[labels["plugin"]][labels["hook"]]; !ok {
+				stats[labels["plugin"]][labels["hook"]] = &pluginHookStats{}
 			}
-			defer outFile.Close()
+			update(stats[labels["plugin"]][labels["hook"]], metric)
+		}
+	}
+	collect("plugin_hook_invocations_total", func(s *pluginHookStats, m *promClient.Metric) {
+		s.invocations = m.GetCounter().GetValue()
+	})
+	collect("plugin_hook_errors_total", func(s *pluginHookStats, m *promClient.Metric) {
+		s.errors = m.GetCounter().GetValue()
+	})
+	collect("plugin_hook_modifications_total", func(s *pluginHookStats, m *promClient.Metric) {
+		s.modifications = m.GetCounter().GetValue()
+	})
+	collect("plugin_hook_terminations_total", func(s *pluginHookStats, m *promClient.Metric) {
+		s.terminations = m.GetCounter().GetValue()
+	})
+	collect("plugin_hook_latency_seconds", func(s *pluginHookStats, m *promClient.Metric) {
+		s.latencySum = m.GetHistogram().GetSampleSum()
+		s.latencyCount = m.GetHistogram().GetSampleCount()
+	})
 
-			// Open the file in the zip archive.
-			fileRc, err := file.Open()
-			if err != nil {
-				os.Remove(outFilename)
-				return nil, gerr.ErrExtractFailed.Wrap(err)
-			}
+	return stats, nil
+}
 
-			// Copy the file contents.
-			if _, err := io.Copy(outFile, io.LimitReader(fileRc, MaxFileSize)); err != nil {
-				os.Remove(outFilename)
-				return nil, gerr.ErrExtractFailed.Wrap(err)
-			}
+// pluginStats fetches the metrics of a running GatewayD and prints the stats of each
+// plugin hook: how often it was invoked, failed, modified or terminated the traffic,
+// and its average latency.
+func pluginStats(cmd *cobra.Command, metricsURL string) error {
+	stats, err := fetchPluginHookStats(metricsURL)
+	if err != nil {
+		return err
+	}
 
-			fileMode := file.FileInfo().Mode()
-			// Set the file permissions.
-			if fileMode.IsRegular() && fileMode&ExecFileMask != 0 {
-				if err := os.Chmod(outFilename, ExecFilePermissions); err != nil {
-					return nil, gerr.ErrExtractFailed.Wrap(err)
-				}
-			} else {
-				if err := os.Chmod(outFilename, FilePermissions); err != nil {
-					return nil, gerr.ErrExtractFailed.Wrap(err)
-				}
-			}
+	if len(stats) == 0 {
+		cmd.Println("No plugin hooks were invoked")
+		return nil
+	}
+
+	rate := func(count, total float64) string {
+		if total == 0 {
+			return fmt.Sprintf("%.0f", count)
+		}
+		return fmt.Sprintf("%.0f (%.2f%%)", count, count/total*100) //nolint:gomnd
+	}
+
+	plugins := make([]string, 0, len(stats))
+	for plugin := range stats {
+		plugins = append(plugins, plugin)
+	}
+	sort.Strings(plugins)
+
+	for _, plugin := range plugins {
+		cmd.Printf("Plugin: %s\n", plugin)
+
+		hooks := make([]string, 0, len(stats[plugin]))
+		for hook := range stats[plugin] {
+			hooks = append(hooks, hook)
+		}
+		sort.Strings(hooks)
 
-			filenames = append(filenames, outFile.Name())
-		default:
-			return nil, gerr.ErrExtractFailed.Wrap(
-				fmt.Errorf("unknown file type: %s", file.Name))
+		for _, hook := range hooks {
+			hookStats := stats[plugin][hook]
+			cmd.Printf("  Hook: %s\n", hook)
+			cmd.Printf("    Invocations: %.0f\n", hookStats.invocations)
+			cmd.Printf("    Errors: %s\n", rate(hookStats.errors, hookStats.invocations))
+			cmd.Printf("    Modifications: %s\n", rate(hookStats.modifications, hookStats.invocations))
+			cmd.Printf("    Terminations: %s\n", rate(hookStats.terminations, hookStats.invocations))
+			if hookStats.latencyCount > 0 {
+				cmd.Printf("    Average latency: %s\n", time.Duration(
+					hookStats.latencySum/float64(hookStats.latencyCount)*float64(time.Second)))
+			}
 		}
 	}
 
-	return filenames, nil
+	return nil
+}
+
+// doAPIRequest sends the request to the admin API of a running GatewayD, with the API
+// token in $GATEWAYD_API_TOKEN, if any.
+func doAPIRequest(req *http.Request) (*http.Response, error) {
+	if token := os.Getenv(APITokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return http.DefaultClient.Do(req) //nolint:wrapcheck
 }
 
-func extractTarGz(filename, dest string) ([]string, error) {
-	// Open and extract the tar.gz file.
-	gzipStream, err := os.Open(filename)
+// dumpHookTrace fetches the hook chains recorded by a running GatewayD and prints them.
+func dumpHookTrace(cmd *cobra.Command, apiURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultMetricsServerTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodGet, strings.TrimSuffix(apiURL, "/")+"/debug/hooks", nil)
 	if err != nil {
-		return nil, gerr.ErrExtractFailed.Wrap(err)
+		return gerr.ErrFetchHookTraceFailed.Wrap(err)
+	}
+	resp, err := doAPIRequest(req)
+	if err != nil {
+		return gerr.ErrFetchHookTraceFailed.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return gerr.ErrFetchHookTraceFailed.Wrap(
+			fmt.Errorf("unexpected status code: %d", resp.StatusCode))
+	}
+
+	var chains []plugin.HookChain
+	if err := json.NewDecoder(resp.Body).Decode(&chains); err != nil {
+		return gerr.ErrFetchHookTraceFailed.Wrap(err)
+	}
+
+	if len(chains) == 0 {
+		cmd.Println("No hook chains were recorded, make sure the hook trace is enabled")
+		return nil
 	}
-	defer gzipStream.Close()
 
-	uncompressedStream, err := gzip.NewReader(gzipStream)
+	output, err := json.MarshalIndent(chains, "", "  ")
 	if err != nil {
-		return nil, gerr.ErrExtractFailed.Wrap(err)
+		return gerr.ErrFetchHookTraceFailed.Wrap(err)
+	}
+	cmd.Println(string(output))
+
+	return nil
+}
+
+// newPluginRegistry creates a plugin registry with the policies of the plugin config,
+// which logs the warnings to the standard error of the command.
+func newPluginRegistry(
+	ctx context.Context, cmd *cobra.Command, conf *config.Config, devMode bool,
+) *plugin.Registry {
+	logger := zerolog.New(
+		zerolog.ConsoleWriter{Out: cmd.ErrOrStderr(), NoColor: true},
+	).Level(zerolog.WarnLevel).With().Timestamp().Logger()
+	pluginRegistry := plugin.NewRegistry(
+		ctx,
+		config.If[config.CompatibilityPolicy](
+			config.Exists[string, config.CompatibilityPolicy](
+				config.CompatibilityPolicies, conf.Plugin.CompatibilityPolicy),
+			config.CompatibilityPolicies[conf.Plugin.CompatibilityPolicy],
+			config.DefaultCompatibilityPolicy),
+		config.If[config.VerificationPolicy](
+			config.Exists[string, config.VerificationPolicy](
+				config.VerificationPolicies, conf.Plugin.VerificationPolicy),
+			config.VerificationPolicies[conf.Plugin.VerificationPolicy],
+			config.DefaultVerificationPolicy),
+		config.If[config.AcceptancePolicy](
+			config.Exists[string, config.AcceptancePolicy](
+				config.AcceptancePolicies, conf.Plugin.AcceptancePolicy),
+			config.AcceptancePolicies[conf.Plugin.AcceptancePolicy],
+			config.DefaultAcceptancePolicy),
+		config.If[config.TerminationPolicy](
+			config.Exists[string, config.TerminationPolicy](
+				config.TerminationPolicies, conf.Plugin.TerminationPolicy),
+			config.TerminationPolicies[conf.Plugin.TerminationPolicy],
+			config.DefaultTerminationPolicy),
+		logger,
+		devMode,
+	)
+	pluginRegistry.Timeout = config.If[time.Duration](
+		conf.Plugin.Timeout > 0, conf.Plugin.Timeout, config.DefaultPluginTimeout)
+	pluginRegistry.RequireCapabilities = conf.Plugin.RequireCapabilities
+	pluginRegistry.AllowOverride = conf.Plugin.AllowOverride
+	return pluginRegistry
+}
+
+// simulateHooks loads the configured plugins, runs the hook chain against the payload
+// and prints the input, output, verification result and latency of each plugin.
+func simulateHooks(
+	cmd *cobra.Command, pluginConfigFile, hook, payloadFile string, devMode bool,
+) error {
+	hookName, ok := plugin.ParseHookName(hook)
+	if !ok {
+		return gerr.ErrSimulateHooksFailed.Wrap(fmt.Errorf("unknown hook: %s", hook))
+	}
+
+	args := map[string]interface{}{}
+	if payloadFile != "" {
+		payload, err := os.ReadFile(payloadFile)
+		if err != nil {
+			return gerr.ErrSimulateHooksFailed.Wrap(err)
+		}
+		if err := json.Unmarshal(payload, &args); err != nil {
+			return gerr.ErrSimulateHooksFailed.Wrap(err)
+		}
 	}
 
-	// Create the output directory if it doesn't exist.
-	if err := os.MkdirAll(dest, FolderPermissions); err != nil {
-		return nil, gerr.ErrExtractFailed.Wrap(err)
+	// Load the plugin config file.
+	ctx := context.Background()
+	conf := config.NewConfig(ctx, "", pluginConfigFile)
+	conf.LoadDefaults(ctx)
+	conf.LoadPluginConfigFile(ctx)
+	conf.UnmarshalPluginConfig(ctx)
+
+	pluginRegistry := newPluginRegistry(ctx, cmd, conf, devMode)
+	// Record the chain of the simulated hook.
+	pluginRegistry.Tracer = plugin.NewHookTracer(1)
+	pluginRegistry.Tracer.Configure(true, 1)
+	defer pluginRegistry.Shutdown()
+
+	pluginRegistry.LoadPlugins(ctx, conf.Plugin.Plugins, conf.Plugin.StartTimeout)
+
+	// The arguments are passed through as is if no plugins registered the hook.
+	result := args
+	if len(pluginRegistry.Hooks()[hookName]) > 0 {
+		runCtx, cancel := context.WithTimeout(ctx, pluginRegistry.Timeout)
+		defer cancel()
+		var err *gerr.GatewayDError
+		if result, err = pluginRegistry.Run(runCtx, args, hookName); err != nil {
+			return gerr.ErrSimulateHooksFailed.Wrap(err)
+		}
 	}
 
-	tarReader := tar.NewReader(uncompressedStream)
-	filenames := []string{}
+	var chain plugin.HookChain
+	if chains := pluginRegistry.Tracer.Dump(); len(chains) > 0 {
+		chain = chains[len(chains)-1]
+	}
+	return printHookChain(cmd, hookName.String(), chain, result)
+}
 
-	for {
-		header, err := tarReader.Next()
+// printHookChain prints the steps of the hook chain and its result.
+func printHookChain(
+	cmd *cobra.Command, hook string, chain plugin.HookChain, result map[string]interface{},
+) error {
+	cmd.Printf("Hook: %s\n", hook)
+	if len(chain.Steps) == 0 {
+		cmd.Println("No plugins registered the hook")
+	}
 
-		if errors.Is(err, io.EOF) {
-			break
+	indent := func(value map[string]interface{}) (string, error) {
+		output, err := json.MarshalIndent(value, "    ", "  ")
+		if err != nil {
+			return "", gerr.ErrSimulateHooksFailed.Wrap(err)
 		}
+		return string(output), nil
+	}
 
+	for _, step := range chain.Steps {
+		cmd.Printf("Plugin: %s (priority %d)\n", step.Plugin, step.Priority)
+		if step.Mode != "" {
+			cmd.Printf("  Mode: %s\n", step.Mode)
+		}
+		input, err := indent(step.Input)
 		if err != nil {
-			return nil, gerr.ErrExtractFailed.Wrap(err)
-		}
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			// Sanitize the path
-			cleanPath := filepath.Clean(header.Name)
-			// Ensure it is not an absolute path
-			if !path.IsAbs(cleanPath) {
-				destPath := path.Join(dest, cleanPath)
-				if err := os.MkdirAll(destPath, FolderPermissions); err != nil {
-					return nil, gerr.ErrExtractFailed.Wrap(err)
-				}
-			}
-		case tar.TypeReg:
-			// Sanitize the path
-			outFilename := path.Join(filepath.Clean(dest), filepath.Clean(header.Name))
+			return err
+		}
+		cmd.Printf("  Input:\n    %s\n", input)
+		// Batched and async hooks run in the background, so they have no output.
+		if step.Mode != "" {
+			continue
+		}
+		output, err := indent(step.Output)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("  Output:\n    %s\n", output)
+		cmd.Printf("  Verified: %t\n", step.Verified)
+		if step.Error != "" {
+			cmd.Printf("  Error: %s\n", step.Error)
+		}
+		cmd.Printf("  Latency: %s\n", step.Duration)
+	}
 
-			// Check for TarSlip.
-			if strings.HasPrefix(outFilename, string(os.PathSeparator)) {
-				return nil, gerr.ErrExtractFailed.Wrap(err)
-			}
+	output, err := indent(result)
+	if err != nil {
+		return err
+	}
+	cmd.Printf("Result:\n    %s\n", output)
 
-			// Create the file.
-			outFile, err := os.Create(outFilename)
-			if err != nil {
-				return nil, gerr.ErrExtractFailed.Wrap(err)
-			}
-			defer outFile.Close()
+	return nil
+}
 
-			if _, err := io.Copy(outFile, io.LimitReader(tarReader, MaxFileSize)); err != nil {
-				os.Remove(outFilename)
-				return nil, gerr.ErrExtractFailed.Wrap(err)
-			}
+// listConnections fetches the client connections of a running GatewayD and prints them.
+func listConnections(cmd *cobra.Command, apiURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultMetricsServerTimeout)
+	defer cancel()
 
-			fileMode := header.FileInfo().Mode()
-			// Set the file permissions
-			if fileMode.IsRegular() && fileMode&ExecFileMask != 0 {
-				if err := os.Chmod(outFilename, ExecFilePermissions); err != nil {
-					return nil, gerr.ErrExtractFailed.Wrap(err)
-				}
-			} else {
-				if err := os.Chmod(outFilename, FilePermissions); err != nil {
-					return nil, gerr.ErrExtractFailed.Wrap(err)
-				}
-			}
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodGet, strings.TrimSuffix(apiURL, "/")+"/connections", nil)
+	if err != nil {
+		return gerr.ErrFetchConnectionsFailed.Wrap(err)
+	}
+	resp, err := doAPIRequest(req)
+	if err != nil {
+		return gerr.ErrFetchConnectionsFailed.Wrap(err)
+	}
+	defer resp.Body.Close()
 
-			filenames = append(filenames, outFile.Name())
-		default:
-			return nil, gerr.ErrExtractFailed.Wrap(
-				fmt.Errorf("unknown file type: %s", header.Name))
-		}
+	if resp.StatusCode != http.StatusOK {
+		return gerr.ErrFetchConnectionsFailed.Wrap(
+			fmt.Errorf("unexpected status code: %d", resp.StatusCode))
+	}
+
+	var connections []api.Connection
+	if err := json.NewDecoder(resp.Body).Decode(&connections); err != nil {
+		return gerr.ErrFetchConnectionsFailed.Wrap(err)
+	}
+
+	if len(connections) == 0 {
+		cmd.Println("No client connections")
+		return nil
+	}
+
+	//nolint:gomnd
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tPROXY\tCLIENT\tUSER\tDATABASE\tSTATE\tAGE\tBYTES IN\tBYTES OUT")
+	for _, conn := range connections {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\n",
+			conn.ID, conn.Proxy, conn.Remote, conn.User, conn.Database, conn.State,
+			time.Since(conn.Since).Truncate(time.Second), conn.BytesIn, conn.BytesOut)
+	}
+	if err := writer.Flush(); err != nil {
+		return gerr.ErrFetchConnectionsFailed.Wrap(err)
+	}
+
+	return nil
+}
+
+// killConnection terminates a client connection of a running GatewayD.
+func killConnection(cmd *cobra.Command, apiURL, id string) error {
+	if err := deleteConnection(apiURL, id); err != nil {
+		return err
+	}
+	cmd.Printf("Killed connection %s\n", id)
+	return nil
+}
+
+// deleteConnection asks a running GatewayD to terminate the client connection.
+func deleteConnection(apiURL, id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultMetricsServerTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodDelete,
+		strings.TrimSuffix(apiURL, "/")+"/connections/"+url.PathEscape(id), nil)
+	if err != nil {
+		return gerr.ErrKillConnectionFailed.Wrap(err)
+	}
+	resp, err := doAPIRequest(req)
+	if err != nil {
+		return gerr.ErrKillConnectionFailed.Wrap(err)
 	}
+	defer resp.Body.Close()
 
-	return filenames, nil
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return gerr.ErrKillConnectionFailed.Wrap(fmt.Errorf("connection %s not found", id))
+	default:
+		return gerr.ErrKillConnectionFailed.Wrap(
+			fmt.Errorf("unexpected status code: %d", resp.StatusCode))
+	}
+}
+
+// getAPI fetches the path of the admin API of a running GatewayD and decodes its JSON
+// response into the value.
+func getAPI(apiURL, path string, value interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultMetricsServerTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodGet, strings.TrimSuffix(apiURL, "/")+path, nil)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	resp, err := doAPIRequest(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code of %s: %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(value) //nolint:wrapcheck
+}
+
+// resolveBackends re-resolves the hostnames of the backends of a running GatewayD and
+// prints their addresses.
+func resolveBackends(cmd *cobra.Command, apiURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultMetricsServerTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, strings.TrimSuffix(apiURL, "/")+"/backends/resolve", nil)
+	if err != nil {
+		return gerr.ErrResolveBackendsFailed.Wrap(err)
+	}
+	resp, err := doAPIRequest(req)
+	if err != nil {
+		return gerr.ErrResolveBackendsFailed.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return gerr.ErrResolveBackendsFailed.Wrap(responseError(resp))
+	}
+
+	var backends []api.Backend
+	if err := json.NewDecoder(resp.Body).Decode(&backends); err != nil {
+		return gerr.ErrResolveBackendsFailed.Wrap(err)
+	}
+
+	if len(backends) == 0 {
+		cmd.Println("No backends with a hostname")
+		return nil
+	}
+
+	//nolint:gomnd
+	writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PROXY\tADDRESS\tRESOLVED TO\tCHANGED")
+	for _, backend := range backends {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%t\n",
+			backend.Proxy, backend.Address, strings.Join(backend.Addresses, ","), backend.Changed)
+	}
+	if err := writer.Flush(); err != nil {
+		return gerr.ErrResolveBackendsFailed.Wrap(err)
+	}
+
+	return nil
+}
+
+// responseError returns the error of a failed request to the admin API, with the
+// name and the remediation of the error if the response has them.
+func responseError(resp *http.Response) error {
+	var apiErr api.Error
+	if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Name == "" {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode) //nolint:goerr113
+	}
+	return fmt.Errorf("%s [%s]: %s", apiErr.Error, apiErr.Name, apiErr.Remediation) //nolint:goerr113
+}
+
+// setMaintenance enables or disables the maintenance mode of a running GatewayD.
+func setMaintenance(
+	cmd *cobra.Command, apiURL string, enabled bool,
+	message string, queue bool, queueTimeout time.Duration,
+) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultMetricsServerTimeout)
+	defer cancel()
+
+	query := url.Values{}
+	query.Set("enabled", strconv.FormatBool(enabled))
+	if enabled {
+		query.Set("message", message)
+		query.Set("queue", strconv.FormatBool(queue))
+		query.Set("queueTimeout", queueTimeout.String())
+	}
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost,
+		strings.TrimSuffix(apiURL, "/")+"/maintenance?"+query.Encode(), nil)
+	if err != nil {
+		return gerr.ErrMaintenanceFailed.Wrap(err)
+	}
+	resp, err := doAPIRequest(req)
+	if err != nil {
+		return gerr.ErrMaintenanceFailed.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return gerr.ErrMaintenanceFailed.Wrap(
+			fmt.Errorf("unexpected status code: %d", resp.StatusCode))
+	}
+
+	var status network.MaintenanceStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return gerr.ErrMaintenanceFailed.Wrap(err)
+	}
+
+	switch {
+	case !status.Enabled:
+		cmd.Printf("Maintenance mode is off, %d queued connections are let through\n", status.Queued)
+	case status.Queue:
+		cmd.Printf("Maintenance mode is on, new connections are queued: %s\n", status.Message)
+	default:
+		cmd.Printf("Maintenance mode is on, new connections are rejected: %s\n", status.Message)
+	}
+
+	return nil
+}
+
+// healthCheck checks whether a running GatewayD is ready to serve connections.
+func healthCheck(cmd *cobra.Command, apiURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultMetricsServerTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodGet, strings.TrimSuffix(apiURL, "/")+"/healthz", nil)
+	if err != nil {
+		return gerr.ErrHealthCheckFailed.Wrap(err)
+	}
+	resp, err := doAPIRequest(req)
+	if err != nil {
+		return gerr.ErrHealthCheckFailed.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	var health api.Healthz
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return gerr.ErrHealthCheckFailed.Wrap(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return gerr.ErrHealthCheckFailed.Wrap(
+			fmt.Errorf("status: %s, status code: %d", health.Status, resp.StatusCode))
+	}
+
+	cmd.Println(health.Status)
+	return nil
 }
 
 func findAsset(release *github.RepositoryRelease, match func(string) bool) (string, string, int64) {
@@ -377,60 +982,407 @@ func findAsset(release *github.RepositoryRelease, match func(string) bool) (stri
 	return "", "", 0
 }
 
+// findPluginArchive finds the plugin archive for the OS and architecture in the release
+// assets, preferring the usual archive format of the OS over the other supported ones.
+func findPluginArchive(release *github.RepositoryRelease) (string, string, int64) {
+	archiveExt := ExtOthers
+	if runtime.GOOS == "windows" {
+		archiveExt = ExtWindows
+	}
+
+	name, downloadURL, releaseID := findAsset(release, func(name string) bool {
+		return strings.Contains(name, runtime.GOOS) &&
+			strings.Contains(name, runtime.GOARCH) &&
+			strings.Contains(name, archiveExt)
+	})
+	if downloadURL == "" {
+		name, downloadURL, releaseID = findAsset(release, func(name string) bool {
+			return strings.Contains(name, runtime.GOOS) &&
+				strings.Contains(name, runtime.GOARCH) &&
+				archiveFormat(name) != ""
+		})
+	}
+	return name, downloadURL, releaseID
+}
+
+// downloadFile downloads the release asset to the current directory, resuming the
+// partial download left by a previous attempt, if any, and reporting the progress
+// to the output.
 func downloadFile(
-	client *github.Client, account, pluginName string, releaseID int64, filename string,
+	client *github.Client, httpClient *http.Client, account, pluginName string, releaseID int64,
+	filename string, output io.Writer,
 ) (string, error) {
-	// Download the plugin.
+	// Without a client to follow the redirect, the URL of the asset is returned,
+	// so that it can be downloaded with a range.
 	readCloser, redirectURL, err := client.Repositories.DownloadReleaseAsset(
-		context.Background(), account, pluginName, releaseID, http.DefaultClient)
+		context.Background(), account, pluginName, releaseID, nil)
 	if err != nil {
 		return "", gerr.ErrDownloadFailed.Wrap(err)
 	}
-	defer readCloser.Close()
+
+	// Create the output file in the current directory and write the downloaded content.
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", gerr.ErrDownloadFailed.Wrap(err)
+	}
+	filePath := path.Join([]string{cwd, filename}...)
 
 	if redirectURL != "" {
-		// Download the plugin from the redirect URL.
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+		if err := downloadURL(httpClient, redirectURL, filePath, output); err != nil {
+			return "", err
+		}
+		return filePath, nil
+	}
 
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, redirectURL, nil)
-		if err != nil {
-			return "", gerr.ErrDownloadFailed.Wrap(err)
+	if readCloser == nil {
+		return "", gerr.ErrDownloadFailed.Wrap(
+			fmt.Errorf("unable to download file: %s", filename))
+	}
+	defer readCloser.Close()
+
+	// The assets served without a redirect can't be resumed.
+	if err := writeDownload(readCloser, filePath, 0, -1, false, output); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// releaseAsset is an asset of a plugin release.
+type releaseAsset struct {
+	Name string
+	URL  string
+	ID   int64
+}
+
+// downloadAssets downloads the release assets concurrently, retrying each of them with
+// the retry config, and returns their paths. The progress of the first asset, i.e.
+// the plugin archive, is reported to the output. If any of them fails, the files and
+// partial downloads of all of them are removed, so that the release is downloaded as
+// a unit.
+func downloadAssets(
+	client *github.Client, httpClient *http.Client, account, pluginName string,
+	assets []releaseAsset, retry *network.Retry, output io.Writer,
+) ([]string, error) {
+	filePaths := make([]string, len(assets))
+	errs := make([]error, len(assets))
+
+	var wg sync.WaitGroup
+	for index, asset := range assets {
+		progress := output
+		if index > 0 {
+			progress = nil
 		}
 
-		resp, err := http.DefaultClient.Do(req)
+		wg.Add(1)
+		go func(index int, asset releaseAsset, progress io.Writer) {
+			defer wg.Done()
+			_, errs[index] = retry.Retry(func() (any, error) {
+				filePath, err := downloadFile(
+					client, httpClient, account, pluginName, asset.ID, asset.Name, progress)
+				filePaths[index] = filePath
+				return filePath, err
+			})
+		}(index, asset, progress)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		cwd, _ := os.Getwd()
+		for _, asset := range assets {
+			filePath := path.Join(cwd, asset.Name)
+			_ = os.Remove(filePath)
+			_ = os.Remove(filePath + PartialDownloadExt)
+		}
+		return nil, err
+	}
+	return filePaths, nil
+}
+
+// isSBOM returns true if the release asset is a software bill of materials.
+func isSBOM(name string) bool {
+	for _, ext := range []string{".sbom", ".sbom.json", ".spdx", ".spdx.json", ".cdx.json"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSignature returns true if the release asset is a signature.
+func isSignature(name string) bool {
+	for _, ext := range []string{".sig", ".asc", ".pem"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyChecksums verifies the files against the checksums file, as a unit: every file
+// must be listed in it, with a matching SHA256 checksum.
+func verifyChecksums(checksumsFile string, filePaths []string) error {
+	contents, err := os.ReadFile(checksumsFile)
+	if err != nil {
+		return gerr.ErrFileReadFailed.Wrap(err)
+	}
+
+	checksums := parseChecksums(string(contents))
+	for _, filePath := range filePaths {
+		name := path.Base(filePath)
+		expected, ok := checksums[name]
+		if !ok {
+			return gerr.ErrChecksumMismatch.Wrap(
+				fmt.Errorf("no checksum for %s", name))
+		}
+		sum, err := checksum.SHA256sum(filePath)
 		if err != nil {
-			return "", gerr.ErrDownloadFailed.Wrap(err)
+			return gerr.ErrChecksumMismatch.Wrap(err)
 		}
-		defer resp.Body.Close()
+		if sum != expected {
+			return gerr.ErrChecksumMismatch.Wrap(
+				fmt.Errorf("checksum mismatch for %s", name))
+		}
+	}
+	return nil
+}
 
-		readCloser = resp.Body
+// parseChecksums returns the checksums of the files in the checksums file, by name. The
+// files are listed in the format of sha256sum, i.e. "<checksum>  <name>".
+func parseChecksums(contents string) map[string]string {
+	checksums := map[string]string{}
+	for _, line := range strings.Split(contents, "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 { //nolint:gomnd
+			checksums[strings.TrimPrefix(fields[1], "*")] = fields[0]
+		}
 	}
+	return checksums
+}
 
-	if readCloser == nil {
-		return "", gerr.ErrDownloadFailed.Wrap(
-			fmt.Errorf("unable to download file: %s", filename))
+// downloadURL downloads the file at the URL to the file path. The file is downloaded
+// to a partial file first, which is resumed with an HTTP range by the next attempt if
+// the download fails.
+func downloadURL(httpClient *http.Client, assetURL, filePath string, output io.Writer) error {
+	var offset int64
+	if info, err := os.Stat(filePath + PartialDownloadExt); err == nil {
+		offset = info.Size()
 	}
 
-	// Create the output file in the current directory and write the downloaded content.
-	cwd, err := os.Getwd()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, assetURL, nil)
 	if err != nil {
-		return "", gerr.ErrDownloadFailed.Wrap(err)
+		return gerr.ErrDownloadFailed.Wrap(err)
 	}
-	filePath := path.Join([]string{cwd, filename}...)
-	output, err := os.Create(filePath)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return "", gerr.ErrDownloadFailed.Wrap(err)
+		return gerr.ErrDownloadFailed.Wrap(err)
 	}
-	defer output.Close()
+	defer resp.Body.Close()
 
-	// Write the bytes to the file.
-	_, err = io.Copy(output, readCloser)
+	switch {
+	case resp.StatusCode == http.StatusPartialContent &&
+		strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)):
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The previous attempt downloaded the whole file, which the checksum verifies.
+		if err := os.Rename(filePath+PartialDownloadExt, filePath); err != nil {
+			return gerr.ErrDownloadFailed.Wrap(err)
+		}
+		return nil
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the range, so the download starts over.
+		offset = 0
+	default:
+		return gerr.ErrDownloadFailed.Wrap(
+			fmt.Errorf("unexpected response status: %s", resp.Status))
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	return writeDownload(resp.Body, filePath, offset, total, offset > 0, output)
+}
+
+// writeDownload writes the body to the partial file of the file path, after the offset
+// if resuming, and renames it to the file path once complete. The downloads larger
+// than MaxFileSize are rejected, before writing them if their size is known.
+func writeDownload(
+	body io.Reader, filePath string, offset, total int64, resume bool, output io.Writer,
+) error {
+	if total > MaxFileSize {
+		return gerr.ErrDownloadFailed.Wrap(
+			fmt.Errorf("the file is larger than %d bytes", MaxFileSize))
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	partialPath := filePath + PartialDownloadExt
+	file, err := os.OpenFile(partialPath, flags, FilePermissions)
 	if err != nil {
-		return "", gerr.ErrDownloadFailed.Wrap(err)
+		return gerr.ErrDownloadFailed.Wrap(err)
 	}
 
-	return filePath, nil
+	progress := &progressBar{
+		output: output, name: path.Base(filePath), written: offset, total: total,
+	}
+	written, err := io.Copy(
+		file, io.TeeReader(io.LimitReader(body, MaxFileSize-offset+1), progress))
+	progress.Finish()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// The partial file is kept, so that the next attempt resumes it.
+		return gerr.ErrDownloadFailed.Wrap(err)
+	}
+	if offset+written > MaxFileSize {
+		_ = os.Remove(partialPath)
+		return gerr.ErrDownloadFailed.Wrap(
+			fmt.Errorf("the file is larger than %d bytes", MaxFileSize))
+	}
+
+	if err := os.Rename(partialPath, filePath); err != nil {
+		return gerr.ErrDownloadFailed.Wrap(err)
+	}
+	return nil
+}
+
+// progressBar reports the progress of a download to the output.
+type progressBar struct {
+	output  io.Writer
+	name    string
+	written int64
+	total   int64
+	printed time.Time
+}
+
+// Write counts the downloaded bytes, and prints the progress at most every
+// ProgressInterval.
+func (p *progressBar) Write(data []byte) (int, error) {
+	p.written += int64(len(data))
+	if time.Since(p.printed) >= ProgressInterval {
+		p.print()
+	}
+	return len(data), nil
+}
+
+// Finish prints the final progress of the download.
+func (p *progressBar) Finish() {
+	p.print()
+	if p.output != nil {
+		fmt.Fprintln(p.output)
+	}
+}
+
+func (p *progressBar) print() {
+	p.printed = time.Now()
+	if p.output == nil {
+		return
+	}
+
+	if p.total <= 0 {
+		fmt.Fprintf(p.output, "\r%s %.1f MiB", p.name, float64(p.written)/MiB)
+		return
+	}
+	filled := min(p.written*ProgressBarWidth/p.total, ProgressBarWidth)
+	fmt.Fprintf(p.output, "\r%s [%s%s] %3d%% %.1f/%.1f MiB",
+		p.name,
+		strings.Repeat("=", int(filled)),
+		strings.Repeat(" ", int(ProgressBarWidth-filled)),
+		p.written*100/p.total, //nolint:gomnd
+		float64(p.written)/MiB,
+		float64(p.total)/MiB)
+}
+
+// newHTTPClient returns the client used to talk to GitHub, which honors the proxy
+// environment variables, trusts the CA bundle, if any, in addition to the system CAs
+// and authenticates the requests to the GitHub API host with the token, if any, e.g.
+// for the private repositories or to avoid the rate limits of the anonymous requests.
+func newHTTPClient(caBundle, token, apiHost string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if caBundle != "" {
+		certificates, err := os.ReadFile(caBundle)
+		if err != nil {
+			return nil, gerr.ErrFileReadFailed.Wrap(err)
+		}
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(certificates) {
+			return nil, gerr.ErrFileReadFailed.Wrap(
+				fmt.Errorf("no certificates found in %s", caBundle))
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    rootCAs,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	if token == "" {
+		return &http.Client{Transport: transport}, nil
+	}
+	return &http.Client{
+		Transport: &gitHubTokenTransport{token: token, host: apiHost, base: transport},
+	}, nil
+}
+
+// parseGitHubServer returns the prefix of the URLs of the plugins hosted on the GitHub
+// server, e.g. github.com/ or the host of a GitHub Enterprise server, and the host of
+// its API.
+func parseGitHubServer(serverURL string) (string, string, error) {
+	server, err := url.Parse(serverURL)
+	if err != nil {
+		return "", "", err
+	}
+	if server.Host == "" {
+		return "", "", fmt.Errorf("no host in %s", serverURL)
+	}
+
+	prefix := server.Host + strings.TrimSuffix(server.Path, "/") + "/"
+	if prefix == GitHubURLPrefix {
+		return prefix, GitHubAPIHost, nil
+	}
+	// The API of GitHub Enterprise is served under /api/v3 on the same host.
+	return prefix, server.Host, nil
+}
+
+// newGitHubClient returns the client of the API of the GitHub server.
+func newGitHubClient(serverURL string, httpClient *http.Client) (*github.Client, error) {
+	if strings.TrimSuffix(serverURL, "/") == GitHubServerURL {
+		return github.NewClient(httpClient), nil
+	}
+	client, err := github.NewEnterpriseClient(serverURL, serverURL, httpClient)
+	if err != nil {
+		return nil, gerr.ErrDownloadFailed.Wrap(err)
+	}
+	return client, nil
+}
+
+// gitHubTokenTransport authenticates the requests to the GitHub API with the token.
+// The other requests, e.g. the downloads of the assets after the redirects, are sent
+// as is, so that the token isn't leaked to other hosts.
+type gitHubTokenTransport struct {
+	token string
+	host  string
+	base  http.RoundTripper
+}
+
+func (t *gitHubTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == t.host {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return t.base.RoundTrip(req)
 }
 
 // deleteFiles deletes the files in the toBeDeleted list.
@@ -442,3 +1394,403 @@ func deleteFiles(toBeDeleted []string) {
 		}
 	}
 }
+
+// stateFile returns the state file of the global config.
+func stateFile(globalConfigFile string) (string, error) {
+	conf := config.NewConfig(context.TODO(), globalConfigFile, "")
+	conf.LoadDefaults(context.TODO())
+	conf.LoadGlobalConfigFile(context.TODO())
+	conf.UnmarshalGlobalConfig(context.TODO())
+
+	if conf.Global.State.FileName == "" {
+		return "", gerr.ErrStateFailed.Wrap(
+			errors.New("the state isn't persisted, as state.fileName isn't set in the config")) //nolint:goerr113
+	}
+	return conf.Global.State.FileName, nil
+}
+
+// showState prints the state persisted to the state file of the global config.
+func showState(cmd *cobra.Command, globalConfigFile string) error {
+	fileName, err := stateFile(globalConfigFile)
+	if err != nil {
+		return err
+	}
+
+	state, err := api.LoadState(fileName)
+	if err != nil {
+		return gerr.ErrStateFailed.Wrap(err)
+	}
+	if state.Maintenance == nil && state.HookTrace == nil {
+		cmd.Println("Nothing was changed at runtime")
+		return nil
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return gerr.ErrStateFailed.Wrap(err)
+	}
+	cmd.Println(string(data))
+	return nil
+}
+
+// resetState removes the state file of the global config.
+func resetState(cmd *cobra.Command, globalConfigFile string) error {
+	fileName, err := stateFile(globalConfigFile)
+	if err != nil {
+		return err
+	}
+
+	if err := api.ResetState(fileName); err != nil {
+		return gerr.ErrStateFailed.Wrap(err)
+	}
+	cmd.Println("The state was reset, only the config applies when GatewayD restarts")
+	return nil
+}
+
+// clientListener is a network and address a server listens on for the clients.
+type clientListener struct {
+	server  string
+	network string
+	address string
+	tls     bool
+}
+
+// unixSocketPrefix is the prefix of the names of the unix sockets of PostgreSQL, which
+// the clients expect, followed by the port.
+const unixSocketPrefix = ".s.PGSQL."
+
+// psqlValue quotes the value of a psql connection string if it has to be.
+func psqlValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// clientHost returns the host the clients connect to the listener at, which is the host
+// of the listener, or localhost if it listens on all the addresses, unless it is set.
+func clientHost(host, listenerHost string) string {
+	if host != "" {
+		return host
+	}
+	if ip := net.ParseIP(listenerHost); listenerHost == "" || (ip != nil && ip.IsUnspecified()) {
+		return "localhost"
+	}
+	return listenerHost
+}
+
+// connectionString returns the connection string of the format for the listener, or an
+// error if the format doesn't support its network. The wildcard addresses are replaced
+// with localhost, unless the host is set.
+//
+//nolint:funlen
+func connectionString(format string, listener clientListener, host, user, database string) (string, error) {
+	sslMode := config.If[string](listener.tls, "require", "disable")
+
+	var port string
+	switch listener.network {
+	case "tcp":
+		listenerHost, listenerPort, err := net.SplitHostPort(listener.address)
+		if err != nil {
+			return "", err //nolint:wrapcheck
+		}
+		port = listenerPort
+		host = clientHost(host, listenerHost)
+	case "unix":
+		// The clients connect to the socket of the port in the directory.
+		directory, name := filepath.Split(listener.address)
+		if !strings.HasPrefix(name, unixSocketPrefix) {
+			return "", fmt.Errorf( //nolint:goerr113
+				"the clients can't connect to %s, as it isn't named %s<port>",
+				listener.address, unixSocketPrefix)
+		}
+		port = strings.TrimPrefix(name, unixSocketPrefix)
+		host = filepath.Clean(directory)
+	default:
+		return "", fmt.Errorf( //nolint:goerr113
+			"the clients can't connect to the %s network", listener.network)
+	}
+
+	switch format {
+	case "psql":
+		return fmt.Sprintf(`psql "host=%s port=%s dbname=%s user=%s sslmode=%s"`,
+			psqlValue(host), port, psqlValue(database), psqlValue(user), sslMode), nil
+	case "jdbc":
+		if listener.network == "unix" {
+			return "", errors.New("the JDBC driver can't connect to unix sockets") //nolint:goerr113
+		}
+		return fmt.Sprintf("jdbc:postgresql://%s/%s?user=%s&sslmode=%s",
+			net.JoinHostPort(host, port), url.PathEscape(database), url.QueryEscape(user),
+			sslMode), nil
+	case "dsn":
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.User(user),
+			Path:     "/" + database,
+			RawQuery: "sslmode=" + sslMode,
+		}
+		if listener.network == "unix" {
+			dsn.RawQuery = url.Values{
+				"host": {host}, "port": {port}, "sslmode": {sslMode},
+			}.Encode()
+		} else {
+			dsn.Host = net.JoinHostPort(host, port)
+		}
+		return dsn.String(), nil
+	default:
+		return "", fmt.Errorf("unknown format %s", format) //nolint:goerr113
+	}
+}
+
+// printClientsConfig prints the connection strings of the format for the listeners of
+// the servers of the global config, with the servers of their port ranges.
+func printClientsConfig(
+	cmd *cobra.Command, globalConfigFile, format, host, user, database string,
+) error {
+	if format != "psql" && format != "jdbc" && format != "dsn" {
+		return gerr.ErrValidationFailed.Wrap(
+			fmt.Errorf("unknown format %s, it must be psql, jdbc or dsn", format)) //nolint:goerr113
+	}
+
+	conf := config.NewConfig(context.TODO(), globalConfigFile, "")
+	conf.LoadDefaults(context.TODO())
+	conf.LoadGlobalConfigFile(context.TODO())
+	conf.UnmarshalGlobalConfig(context.TODO())
+	if _, err := conf.Global.ExpandPortRanges(); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(conf.Global.Servers))
+	for name := range conf.Global.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		server := conf.Global.Servers[name]
+		if server == nil {
+			continue
+		}
+		tls := server.EnableTLS || server.ACME.Enabled || server.SPIFFE.Enabled
+		listeners := []clientListener{{name, server.Network, server.Address, tls}}
+		for _, listener := range server.Listeners {
+			listeners = append(listeners, clientListener{name, listener.Network, listener.Address, tls})
+		}
+
+		for _, listener := range listeners {
+			description := fmt.Sprintf("# %s: %s %s", listener.server, listener.network, listener.address)
+			if listener.tls {
+				description += config.If[string](
+					server.SPIFFE.Enabled, " (TLS with a client SVID)", " (TLS)")
+			}
+			cmd.Println(description)
+			connection, err := connectionString(format, listener, host, user, database)
+			if err != nil {
+				cmd.Println("# " + err.Error())
+				continue
+			}
+			cmd.Println(connection)
+		}
+	}
+	return nil
+}
+
+// splitStatements splits the SQL into its statements, except within the string literals,
+// the quoted identifiers, the dollar-quoted strings and the comments. The statements
+// with only comments are skipped.
+//
+//nolint:funlen,cyclop
+func splitStatements(sql string) []string {
+	var statements []string
+	start, code := 0, false
+	for i := 0; i < len(sql); i++ {
+		switch char := sql[i]; {
+		case char == '\'' || char == '"':
+			end := strings.IndexByte(sql[i+1:], char)
+			if end < 0 {
+				i = len(sql)
+			} else {
+				// The doubled quotes are two quoted strings in a row.
+				i += end + 1
+			}
+			code = true
+		case char == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			i = config.If[int](end < 0, len(sql), i+end)
+		case char == '/' && strings.HasPrefix(sql[i:], "/*"):
+			// The block comments are nested.
+			depth := 0
+			for ; i < len(sql); i++ {
+				if strings.HasPrefix(sql[i:], "/*") {
+					depth++
+					i++
+				} else if strings.HasPrefix(sql[i:], "*/") {
+					depth--
+					i++
+					if depth == 0 {
+						break
+					}
+				}
+			}
+		case char == '$' && (i == 0 || !isIdentifierChar(sql[i-1])):
+			// A dollar quote is $tag$, whose tag doesn't start with a digit, unlike $1.
+			end := strings.IndexByte(sql[i+1:], '$')
+			tag := ""
+			if end >= 0 {
+				tag = sql[i : i+end+2]
+			}
+			if tag == "" || !isDollarQuoteTag(tag[1:len(tag)-1]) {
+				code = true
+				continue
+			}
+			closing := strings.Index(sql[i+len(tag):], tag)
+			i = config.If[int](closing < 0, len(sql), i+len(tag)+closing+len(tag)-1)
+			code = true
+		case char == ';':
+			if code {
+				statements = append(statements, strings.TrimSpace(sql[start:i]))
+			}
+			start, code = i+1, false
+		case char != ' ' && char != '\t' && char != '\n' && char != '\r':
+			code = true
+		}
+	}
+	if code {
+		statements = append(statements, strings.TrimSpace(sql[start:]))
+	}
+	return statements
+}
+
+// isIdentifierChar returns true if the character can be in an unquoted identifier.
+func isIdentifierChar(char byte) bool {
+	return char == '_' || char == '$' || (char >= 'a' && char <= 'z') ||
+		(char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9') || char >= 0x80
+}
+
+// isDollarQuoteTag returns true if the tag of a dollar quote is valid, which is empty or
+// an identifier without dollars.
+func isDollarQuoteTag(tag string) bool {
+	for i := 0; i < len(tag); i++ {
+		if tag[i] == '$' || !isIdentifierChar(tag[i]) || (i == 0 && tag[i] >= '0' && tag[i] <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// statementSummary returns the first line of the statement but the comments, shortened
+// for the progress.
+func statementSummary(statement string) string {
+	summary := statement
+	for _, line := range strings.Split(statement, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			summary = line
+			break
+		}
+	}
+	if len(summary) > config.DefaultExecSummaryLength {
+		summary = summary[:config.DefaultExecSummaryLength] + "..."
+	}
+	return summary
+}
+
+// execFiles runs the statements of the SQL files through the listener of the server of
+// the pool, like a client, each file in a transaction unless it is disabled, and prints
+// their progress. It stops at the first statement that fails, whose file is rolled back.
+//
+//nolint:funlen
+func execFiles(
+	cmd *cobra.Command, globalConfigFile, poolName string, files []string,
+	user, password, database string, transaction bool,
+) error {
+	if len(files) == 0 {
+		return gerr.ErrExecFailed.Wrap(errors.New("no SQL files to run, set them with --file"))
+	}
+
+	conf := config.NewConfig(context.TODO(), globalConfigFile, "")
+	conf.LoadDefaults(context.TODO())
+	conf.LoadGlobalConfigFile(context.TODO())
+	conf.UnmarshalGlobalConfig(context.TODO())
+	if _, err := conf.Global.ExpandPortRanges(); err != nil {
+		return err
+	}
+
+	// The pools are served by the servers of the same name.
+	server, exists := conf.Global.Servers[poolName]
+	if !exists || server == nil {
+		return gerr.ErrExecFailed.Wrap(fmt.Errorf("the pool %s has no server", poolName))
+	}
+	if server.Network != "tcp" {
+		return gerr.ErrExecFailed.Wrap(fmt.Errorf("the server of the pool %s doesn't listen on TCP", poolName))
+	}
+	listenerHost, port, err := net.SplitHostPort(server.Address)
+	if err != nil {
+		return gerr.ErrExecFailed.Wrap(err)
+	}
+	address := net.JoinHostPort(clientHost("", listenerHost), port)
+
+	ctx := context.Background()
+	conn, err := network.ConnectPostgres(ctx, address, user, password, database,
+		config.DefaultExecApplicationName, config.DefaultExecConnectTimeout)
+	if err != nil {
+		return gerr.ErrExecFailed.Wrap(err)
+	}
+	defer conn.Close(ctx)
+
+	for _, file := range files {
+		contents, err := os.ReadFile(file)
+		if err != nil {
+			return gerr.ErrExecFailed.Wrap(err)
+		}
+		statements := splitStatements(string(contents))
+		cmd.Printf("Running %d statements of %s through %s\n", len(statements), file, address)
+
+		start := time.Now()
+		if transaction {
+			if _, err := conn.Exec(ctx, "BEGIN").ReadAll(); err != nil {
+				return gerr.ErrExecFailed.Wrap(err)
+			}
+		}
+		for index, statement := range statements {
+			cmd.Printf("[%d/%d] %s\n", index+1, len(statements), statementSummary(statement))
+			if _, err := conn.Exec(ctx, statement).ReadAll(); err != nil {
+				if transaction {
+					conn.Exec(ctx, "ROLLBACK").ReadAll() //nolint:errcheck
+					cmd.Printf("Rolled back %s\n", file)
+				}
+				return gerr.ErrExecFailed.Wrap(
+					fmt.Errorf("statement %d of %s failed: %w", index+1, file, err))
+			}
+		}
+		if transaction {
+			if _, err := conn.Exec(ctx, "COMMIT").ReadAll(); err != nil {
+				return gerr.ErrExecFailed.Wrap(err)
+			}
+		}
+		cmd.Printf("Ran %s in %s\n", file, time.Since(start).Round(time.Millisecond))
+	}
+	return nil
+}
+
+// tagErrors tags the Sentry events of the GatewayD errors with their codes, names
+// and categories, so that the alerting rules can be keyed on them.
+func tagErrors(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+	var err *gerr.GatewayDError
+	if hint == nil || !errors.As(hint.OriginalException, &err) {
+		return event
+	}
+
+	if event.Tags == nil {
+		event.Tags = make(map[string]string)
+	}
+	for key, value := range err.Fields() {
+		if key != "remediation" {
+			event.Tags["error."+key] = value
+		}
+	}
+	if event.Extra == nil {
+		event.Extra = make(map[string]interface{})
+	}
+	event.Extra["remediation"] = err.Taxonomy().Remediation
+	return event
+}
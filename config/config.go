@@ -2,9 +2,13 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"maps"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
@@ -27,6 +31,7 @@ type IConfig interface {
 	LoadPluginEnvVars(ctx context.Context)
 	LoadGlobalEnvVars(ctx context.Context)
 	LoadGlobalConfigFile(ctx context.Context)
+	ApplyProfile(ctx context.Context)
 	LoadPluginConfigFile(ctx context.Context)
 	MergeGlobalConfig(ctx context.Context, updatedGlobalConfig map[string]interface{})
 }
@@ -41,6 +46,10 @@ type Config struct {
 	GlobalKoanf *koanf.Koanf
 	PluginKoanf *koanf.Koanf
 
+	// Profile is the profile of the global configuration file that is applied on top
+	// of it, e.g. dev, staging or prod. No profile is applied if it's empty.
+	Profile string
+
 	Global GlobalConfig
 	Plugin PluginConfig
 }
@@ -74,149 +83,112 @@ func (c *Config) InitConfig(ctx context.Context) {
 	c.UnmarshalPluginConfig(newCtx)
 
 	c.LoadGlobalConfigFile(newCtx)
+	c.ApplyProfile(newCtx)
 	c.ValidateGlobalConfig(newCtx)
 	c.LoadGlobalEnvVars(newCtx)
 	c.UnmarshalGlobalConfig(newCtx)
+	c.ValidateReferences(newCtx)
+}
+
+// Reinitialized returns a new configuration initialized from the same files and
+// profile, e.g. to reload them after they changed.
+func (c *Config) Reinitialized(ctx context.Context) *Config {
+	conf := NewConfig(ctx, c.globalConfigFile, c.pluginConfigFile)
+	conf.Profile = c.Profile
+	conf.InitConfig(ctx)
+	return conf
 }
 
 // LoadDefaults loads the default configuration before loading the config files.
 func (c *Config) LoadDefaults(ctx context.Context) {
 	_, span := otel.Tracer(TracerName).Start(ctx, "Load defaults")
 
-	defaultLogger := Logger{
-		Output:            []string{DefaultLogOutput},
-		Level:             DefaultLogLevel,
-		NoColor:           DefaultNoColor,
-		TimeFormat:        DefaultTimeFormat,
-		ConsoleTimeFormat: DefaultConsoleTimeFormat,
-		FileName:          DefaultLogFileName,
-		MaxSize:           DefaultMaxSize,
-		MaxBackups:        DefaultMaxBackups,
-		MaxAge:            DefaultMaxAge,
-		Compress:          DefaultCompress,
-		LocalTime:         DefaultLocalTime,
-		RSyslogNetwork:    DefaultRSyslogNetwork,
-		RSyslogAddress:    DefaultRSyslogAddress,
-		SyslogPriority:    DefaultSyslogPriority,
-	}
-
-	defaultMetric := Metrics{
-		Enabled:           true,
-		Address:           DefaultMetricsAddress,
-		Path:              DefaultMetricsPath,
-		ReadHeaderTimeout: DefaultReadHeaderTimeout,
-	}
-
-	defaultClient := Client{
-		Network:            DefaultNetwork,
-		Address:            DefaultAddress,
-		TCPKeepAlive:       DefaultTCPKeepAlive,
-		TCPKeepAlivePeriod: DefaultTCPKeepAlivePeriod,
-		ReceiveChunkSize:   DefaultChunkSize,
-		ReceiveDeadline:    DefaultReceiveDeadline,
-		ReceiveTimeout:     DefaultReceiveTimeout,
-		SendDeadline:       DefaultSendDeadline,
-		DialTimeout:        DefaultDialTimeout,
-		Retries:            DefaultRetries,
-		Backoff:            DefaultBackoff,
-		BackoffMultiplier:  DefaultBackoffMultiplier,
-		DisableBackoffCaps: DefaultDisableBackoffCaps,
-	}
-
-	defaultPool := Pool{
-		Size: DefaultPoolSize,
-	}
-
-	defaultProxy := Proxy{
-		Elastic:             false,
-		ReuseElasticClients: false,
-		HealthCheckPeriod:   DefaultHealthCheckPeriod,
-	}
-
-	defaultServer := Server{
-		Network:          DefaultListenNetwork,
-		Address:          DefaultListenAddress,
-		EnableTicker:     false,
-		TickInterval:     DefaultTickInterval,
-		EnableTLS:        false,
-		CertFile:         "",
-		KeyFile:          "",
-		HandshakeTimeout: DefaultHandshakeTimeout,
-	}
-
-	c.globalDefaults = GlobalConfig{
-		Loggers: map[string]*Logger{Default: &defaultLogger},
-		Metrics: map[string]*Metrics{Default: &defaultMetric},
-		Clients: map[string]*Client{Default: &defaultClient},
-		Pools:   map[string]*Pool{Default: &defaultPool},
-		Proxies: map[string]*Proxy{Default: &defaultProxy},
-		Servers: map[string]*Server{Default: &defaultServer},
-		API: API{
-			Enabled:     true,
-			HTTPAddress: DefaultHTTPAPIAddress,
-			GRPCNetwork: DefaultGRPCAPINetwork,
-			GRPCAddress: DefaultGRPCAPIAddress,
-		},
-	}
+	c.globalDefaults = DefaultGlobalConfig()
 
 	//nolint:nestif
-	if contents, err := os.ReadFile(c.globalConfigFile); err == nil {
-		gconf, err := yaml.Parser().Unmarshal(contents)
-		if err != nil {
-			span.RecordError(err)
-			span.End()
-			log.Fatal(fmt.Errorf("failed to unmarshal global configuration: %w", err))
-		}
-
-		for configObject, configMap := range gconf {
-			if configGroup, ok := configMap.(map[string]interface{}); ok {
-				for configGroupKey := range configGroup {
-					if configGroupKey == Default {
-						continue
-					}
-
-					switch configObject {
-					case "loggers":
-						c.globalDefaults.Loggers[configGroupKey] = &defaultLogger
-					case "metrics":
-						c.globalDefaults.Metrics[configGroupKey] = &defaultMetric
-					case "clients":
-						c.globalDefaults.Clients[configGroupKey] = &defaultClient
-					case "pools":
-						c.globalDefaults.Pools[configGroupKey] = &defaultPool
-					case "proxies":
-						c.globalDefaults.Proxies[configGroupKey] = &defaultProxy
-					case "servers":
-						c.globalDefaults.Servers[configGroupKey] = &defaultServer
-					case "api":
-						// TODO: Add support for multiple API config groups.
-					default:
-						err := fmt.Errorf("unknown config object: %s", configObject)
-						span.RecordError(err)
-						span.End()
-						log.Fatal(err)
+	if configFiles, err := globalConfigFiles(c.globalConfigFile); err == nil {
+		// The default config groups are added for the config groups of the profiles too.
+		var addGroupDefaults func(gconf map[string]interface{})
+		addGroupDefaults = func(gconf map[string]interface{}) {
+			for configObject, configMap := range gconf {
+				if configGroup, ok := configMap.(map[string]interface{}); ok {
+					for configGroupKey := range configGroup {
+						if configGroupKey == Default && configObject != "profiles" {
+							continue
+						}
+
+						switch configObject {
+						case "loggers":
+							c.globalDefaults.Loggers[configGroupKey] = c.globalDefaults.Loggers[Default]
+						case "metrics":
+							c.globalDefaults.Metrics[configGroupKey] = c.globalDefaults.Metrics[Default]
+						case "clients":
+							c.globalDefaults.Clients[configGroupKey] = c.globalDefaults.Clients[Default]
+						case "pools":
+							c.globalDefaults.Pools[configGroupKey] = c.globalDefaults.Pools[Default]
+						case "proxies":
+							c.globalDefaults.Proxies[configGroupKey] = c.globalDefaults.Proxies[Default]
+						case "servers":
+							c.globalDefaults.Servers[configGroupKey] = c.globalDefaults.Servers[Default]
+						case "api":
+							// TODO: Add support for multiple API config groups.
+						case "events":
+							// Events are configured globally.
+						case "usage":
+							// Usage accounting is configured globally.
+						case "sharedLimits":
+							// The shared limits are configured globally.
+						case "performance":
+							// The Go runtime is tuned globally.
+						case "runReport":
+							// The run report is written globally.
+						case "state":
+							// The runtime state is persisted globally.
+						case "signals":
+							// The signals are handled globally.
+						case "flightRecorder":
+							// The flight recorder is global.
+						case "sampling":
+							// The hooks and the logs are sampled globally.
+						case "dataAPI":
+							// The data API is global, and runs through one of the servers.
+						case "profiles":
+							if profile, ok := configGroup[configGroupKey].(map[string]interface{}); ok {
+								addGroupDefaults(profile)
+							}
+						default:
+							err := fmt.Errorf("unknown config object: %s", configObject)
+							span.RecordError(err)
+							span.End()
+							log.Fatal(err)
+						}
 					}
 				}
 			}
 		}
-	} else if !os.IsNotExist(err) {
+		// The included files can add config groups too.
+		for _, configFile := range configFiles {
+			contents, err := os.ReadFile(configFile)
+			if err != nil {
+				span.RecordError(err)
+				span.End()
+				log.Fatal(fmt.Errorf("failed to read global configuration file: %w", err))
+			}
+			gconf, err := yaml.Parser().Unmarshal(contents)
+			if err != nil {
+				span.RecordError(err)
+				span.End()
+				log.Fatal(fmt.Errorf("failed to unmarshal global configuration: %w", err))
+			}
+			addGroupDefaults(gconf)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
 		span.RecordError(err)
 		span.End()
 		log.Fatal(fmt.Errorf("failed to read global configuration file: %w", err))
 	}
 
-	c.pluginDefaults = PluginConfig{
-		VerificationPolicy:  string(PassDown),
-		CompatibilityPolicy: string(Strict),
-		AcceptancePolicy:    string(Accept),
-		TerminationPolicy:   string(Stop),
-		EnableMetricsMerger: true,
-		MetricsMergerPeriod: DefaultMetricsMergerPeriod,
-		HealthCheckPeriod:   DefaultPluginHealthCheckPeriod,
-		ReloadOnCrash:       true,
-		Timeout:             DefaultPluginTimeout,
-		StartTimeout:        DefaultPluginStartTimeout,
-	}
+	c.pluginDefaults = DefaultPluginConfig()
 
 	if c.GlobalKoanf != nil {
 		if err := c.GlobalKoanf.Load(structs.Provider(c.globalDefaults, "json"), nil); err != nil {
@@ -271,19 +243,183 @@ func loadEnvVars() *env.Env {
 	})
 }
 
-// LoadGlobalConfig loads the plugin configuration file.
+// LoadGlobalConfig loads the global configuration file, and merges the files it
+// includes into it.
 func (c *Config) LoadGlobalConfigFile(ctx context.Context) {
 	_, span := otel.Tracer(TracerName).Start(ctx, "Load global config file")
 
-	if err := c.GlobalKoanf.Load(file.Provider(c.globalConfigFile), yaml.Parser()); err != nil {
+	configFiles, err := globalConfigFiles(c.globalConfigFile)
+	if err != nil {
 		span.RecordError(err)
 		span.End()
 		log.Fatal(fmt.Errorf("failed to load global configuration: %w", err))
 	}
+	span.SetAttributes(attribute.StringSlice("configFiles", configFiles))
+
+	for _, configFile := range configFiles {
+		if err := c.GlobalKoanf.Load(file.Provider(configFile), yaml.Parser()); err != nil {
+			span.RecordError(err)
+			span.End()
+			log.Fatal(fmt.Errorf("failed to load global configuration: %w", err))
+		}
+	}
+
+	span.End()
+}
+
+// globalConfigFiles returns the global configuration file and the files it includes, in
+// the order they are merged. The included paths are relative to the file that includes
+// them, and can be files, globs or directories, whose YAML files are included in
+// lexical order, e.g. a conf.d directory with a file per tenant.
+func globalConfigFiles(configFile string) ([]string, error) {
+	configFiles := []string{}
+	seen := map[string]bool{}
+
+	var include func(configFile string) error
+	include = func(configFile string) error {
+		path, err := filepath.Abs(configFile)
+		if err != nil {
+			return err
+		}
+		// The files included more than once, e.g. by each other, are merged once.
+		if seen[path] {
+			return nil
+		}
+		seen[path] = true
+		configFiles = append(configFiles, configFile)
+
+		contents, err := os.ReadFile(configFile)
+		if err != nil {
+			return err
+		}
+		gconf, err := yaml.Parser().Unmarshal(contents)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal %s: %w", configFile, err)
+		}
+		if gconf[IncludeKey] == nil {
+			return nil
+		}
+		patterns, ok := gconf[IncludeKey].([]interface{})
+		if !ok {
+			return fmt.Errorf("%s of %s must be a list of paths", IncludeKey, configFile)
+		}
+
+		for _, pattern := range patterns {
+			pattern, ok := pattern.(string)
+			if !ok {
+				return fmt.Errorf("%s of %s must be a list of paths", IncludeKey, configFile)
+			}
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(configFile), pattern)
+			}
+
+			matches, err := includedFiles(pattern)
+			if err != nil {
+				return err
+			}
+			for _, match := range matches {
+				if err := include(match); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := include(configFile); err != nil {
+		return nil, err
+	}
+	return configFiles, nil
+}
+
+// includedFiles returns the files matching the glob, the YAML files of the directory or
+// the file itself, in lexical order.
+func includedFiles(pattern string) ([]string, error) {
+	if strings.ContainsAny(pattern, "*?[") {
+		return filepath.Glob(pattern)
+	}
+
+	info, err := os.Stat(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{pattern}, nil
+	}
+
+	entries, err := os.ReadDir(pattern)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, filepath.Join(pattern, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// ApplyProfile overrides the global configuration with the profile, after the profiles
+// it extends, from the profiles section of the global configuration file. The
+// environment variables still override the profile.
+func (c *Config) ApplyProfile(ctx context.Context) {
+	_, span := otel.Tracer(TracerName).Start(ctx, "Apply profile")
+
+	if c.Profile == "" {
+		span.End()
+		return
+	}
+	span.SetAttributes(attribute.String("profile", c.Profile))
+
+	overrides, err := profileOverrides(c.GlobalKoanf.Get("profiles"), c.Profile)
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		log.Fatal(err)
+	}
+
+	for _, override := range overrides {
+		if err := c.GlobalKoanf.Load(confmap.Provider(override, "."), nil); err != nil {
+			span.RecordError(err)
+			span.End()
+			log.Fatal(fmt.Errorf("failed to apply the %s profile: %w", c.Profile, err))
+		}
+	}
 
 	span.End()
 }
 
+// profileOverrides returns the overrides of the profile and of the profiles it extends,
+// starting with the one that extends no other profile.
+func profileOverrides(profiles interface{}, name string) ([]map[string]interface{}, error) {
+	definedProfiles, _ := profiles.(map[string]interface{})
+	overrides := []map[string]interface{}{}
+	seen := map[string]bool{}
+	for name != "" {
+		if seen[name] {
+			return nil, gerr.ErrValidationFailed.Wrap(
+				fmt.Errorf("the %s profile extends itself", name))
+		}
+		seen[name] = true
+
+		profile, defined := definedProfiles[name]
+		if !defined {
+			return nil, gerr.ErrValidationFailed.Wrap(
+				fmt.Errorf("the %s profile is not defined", name))
+		}
+		// An empty profile has no overrides.
+		override, _ := profile.(map[string]interface{})
+		override = maps.Clone(override)
+		name, _ = override[ProfileExtendsKey].(string)
+		delete(override, ProfileExtendsKey)
+		overrides = slices.Insert(overrides, 0, override)
+	}
+
+	return overrides, nil
+}
+
 // LoadPluginConfig loads the plugin configuration file.
 func (c *Config) LoadPluginConfigFile(ctx context.Context) {
 	_, span := otel.Tracer(TracerName).Start(ctx, "Load plugin config file")
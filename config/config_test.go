@@ -2,10 +2,13 @@ package config
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/knadh/koanf"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var parentDir = "../"
@@ -72,3 +75,100 @@ func TestMergeGlobalConfig(t *testing.T) {
 	// The log level should now be debug.
 	assert.Equal(t, "debug", config.Global.Loggers[Default].Level)
 }
+
+// TestApplyProfile tests that the profile overrides the global configuration, after
+// the profile it extends.
+func TestApplyProfile(t *testing.T) {
+	globalConfigFile := filepath.Join(t.TempDir(), GlobalConfigFilename)
+	require.NoError(t, os.WriteFile(globalConfigFile, []byte(`
+loggers:
+  default:
+    level: info
+pools:
+  default:
+    size: 10
+profiles:
+  prod:
+    pools:
+      default:
+        size: 50
+  staging:
+    extends: prod
+    loggers:
+      default:
+        level: debug
+`), 0o600))
+
+	ctx := context.Background()
+	config := NewConfig(ctx, globalConfigFile, parentDir+PluginsConfigFilename)
+	config.Profile = "staging"
+	config.InitConfig(ctx)
+	assert.Equal(t, "debug", config.Global.Loggers[Default].Level)
+	assert.Equal(t, 50, config.Global.Pools[Default].Size)
+	// The other keys of the overridden config groups are kept.
+	assert.Equal(t, DefaultLogOutput, config.Global.Loggers[Default].Output[0])
+
+	config = NewConfig(ctx, globalConfigFile, parentDir+PluginsConfigFilename)
+	config.InitConfig(ctx)
+	assert.Equal(t, "info", config.Global.Loggers[Default].Level)
+	assert.Equal(t, 10, config.Global.Pools[Default].Size)
+}
+
+// TestProfileOverrides tests that the undefined and circular profiles are rejected.
+func TestProfileOverrides(t *testing.T) {
+	profiles := map[string]interface{}{
+		"dev":   nil,
+		"first": map[string]interface{}{ProfileExtendsKey: "second"},
+		"second": map[string]interface{}{
+			ProfileExtendsKey: "first",
+		},
+	}
+
+	overrides, err := profileOverrides(profiles, "dev")
+	require.NoError(t, err)
+	require.Len(t, overrides, 1)
+	assert.Empty(t, overrides[0])
+
+	_, err = profileOverrides(profiles, "prod")
+	assert.ErrorContains(t, err, "the prod profile is not defined")
+
+	_, err = profileOverrides(profiles, "first")
+	assert.ErrorContains(t, err, "the first profile extends itself")
+}
+
+// TestGlobalConfigFiles tests that the included files are merged in order.
+func TestGlobalConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "conf.d"), 0o700))
+	files := map[string]string{
+		GlobalConfigFilename:      "include: [conf.d, extra.yaml]\npools:\n  default:\n    size: 10\n",
+		"conf.d/20-tenant-b.yaml": "pools:\n  default:\n    size: 30\n",
+		"conf.d/10-tenant-a.yaml": "pools:\n  default:\n    size: 20\n",
+		"conf.d/README.md":        "Not included",
+		// The files that include each other are merged once.
+		"extra.yaml": "include: [" + GlobalConfigFilename + "]\nloggers:\n  default:\n    level: debug\n",
+	}
+	for name, contents := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600))
+	}
+
+	globalConfigFile := filepath.Join(dir, GlobalConfigFilename)
+	configFiles, err := globalConfigFiles(globalConfigFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		globalConfigFile,
+		filepath.Join(dir, "conf.d", "10-tenant-a.yaml"),
+		filepath.Join(dir, "conf.d", "20-tenant-b.yaml"),
+		filepath.Join(dir, "extra.yaml"),
+	}, configFiles)
+
+	ctx := context.Background()
+	config := NewConfig(ctx, globalConfigFile, parentDir+PluginsConfigFilename)
+	config.InitConfig(ctx)
+	assert.Equal(t, 30, config.Global.Pools[Default].Size)
+	assert.Equal(t, "debug", config.Global.Loggers[Default].Level)
+
+	require.NoError(t, os.WriteFile(globalConfigFile, []byte("include: [missing.yaml]\n"), 0o600))
+	_, err = globalConfigFiles(globalConfigFile)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -5,12 +5,30 @@ import (
 )
 
 type (
-	Status              uint
-	VerificationPolicy  string
-	CompatibilityPolicy string
-	AcceptancePolicy    string
-	TerminationPolicy   string
-	LogOutput           uint
+	Status                uint
+	VerificationPolicy    string
+	CompatibilityPolicy   string
+	AcceptancePolicy      string
+	TerminationPolicy     string
+	OversizeBehavior      string
+	EngineMode            string
+	CompressionAlgorithm  string
+	IAMAuthProvider       string
+	FirewallAction        string
+	ResetPolicy           string
+	ErrorKind             string
+	APIRole               string
+	ClusterRole           string
+	TopologySource        string
+	AffinityKey           string
+	ShardKeySource        string
+	FailureMode           string
+	IdleTransactionAction string
+	SignalAction          string
+	HookBudgetAction      string
+	PreflightPolicy       string
+	BuiltinMode           string
+	LogOutput             uint
 )
 
 // Status is the status of the server.
@@ -48,6 +66,158 @@ const (
 	Stop     TerminationPolicy = "stop"     // Stop the execution of the functions
 )
 
+// OversizeBehavior is what the proxy does with the messages
+// larger than the maximum message size.
+const (
+	Close    OversizeBehavior = "close"    // Close the connection
+	Truncate OversizeBehavior = "truncate" // Truncate the message to the maximum size
+	Stream   OversizeBehavior = "stream"   // Stream the response to the client in chunks
+)
+
+// ResetPolicy is what happens to the settings a session changed with the SET
+// statements when it moves to a new server connection.
+const (
+	Replay  ResetPolicy = "replay"  // Replay the SET statements on the new connection
+	Discard ResetPolicy = "discard" // Start with the defaults, as after DISCARD ALL
+)
+
+// ErrorKind is an error of GatewayD that is sent to the clients
+// as an error of the database protocol.
+const (
+	ErrorPoolExhausted    ErrorKind = "poolExhausted"
+	ErrorQueueFull        ErrorKind = "queueFull"
+	ErrorQueueTimeout     ErrorKind = "queueTimeout"
+	ErrorShuttingDown     ErrorKind = "shuttingDown"
+	ErrorMaintenance      ErrorKind = "maintenance"
+	ErrorBackendDown      ErrorKind = "backendDown"
+	ErrorQueryDenied      ErrorKind = "queryDenied"
+	ErrorConcurrencyLimit ErrorKind = "concurrencyLimit"
+	ErrorMessageTooLarge  ErrorKind = "messageTooLarge"
+	ErrorCrossShard       ErrorKind = "crossShard"
+	ErrorShardUnavailable ErrorKind = "shardUnavailable"
+	ErrorConnectionQuota  ErrorKind = "connectionQuota"
+
+	ErrorIdleTransactionTerminated ErrorKind = "idleTransactionTerminated"
+	ErrorIdleTransactionRolledBack ErrorKind = "idleTransactionRolledBack"
+	ErrorConnectionMemory          ErrorKind = "connectionMemory"
+	ErrorGoroutineLimit            ErrorKind = "goroutineLimit"
+	ErrorResultTooLarge            ErrorKind = "resultTooLarge"
+	ErrorResponseTruncated         ErrorKind = "responseTruncated"
+)
+
+// IdleTransactionAction is what the proxy does with the sessions that are idle in a
+// transaction for longer than their timeout.
+const (
+	Terminate IdleTransactionAction = "terminate" // Close the connection of the client
+	Rollback  IdleTransactionAction = "rollback"  // Roll back the transaction, and fail the next query
+)
+
+// ShardKeySource is where the sharding router finds the shard keys of the statements.
+const (
+	ShardKeyColumn  ShardKeySource = "column"  // The values of a column in the query
+	ShardKeyComment ShardKeySource = "comment" // A hint in a comment of the query
+	ShardKeyHook    ShardKeySource = "hook"    // The shardKey field of the result of the hooks
+)
+
+// FailureMode is how the limits are enforced while their shared state is unavailable.
+const (
+	FailOpen   FailureMode = "open"   // Enforce the limits on the traffic of the instance only
+	FailClosed FailureMode = "closed" // Reject the connections and hold the traffic
+)
+
+// SignalAction is what GatewayD does when it receives a signal.
+const (
+	SignalStop       SignalAction = "stop"       // Drain the servers and stop
+	SignalReload     SignalAction = "reload"     // Reload the config from its files
+	SignalReopenLogs SignalAction = "reopenLogs" // Rotate the log files, e.g. after logrotate
+	SignalDumpState  SignalAction = "dumpState"  // Dump the goroutines and connections to a file
+	SignalIgnore     SignalAction = "ignore"     // Only notify the OnSignal hooks
+)
+
+// HookBudgetAction is what the plugin registry does with the slowest plugin of a hook
+// chain that exceeds its latency budget too many times in a row.
+const (
+	BudgetAsync   HookBudgetAction = "async"   // Run the hook of the plugin in the background
+	BudgetDisable HookBudgetAction = "disable" // Stop running the hook of the plugin
+	BudgetLog     HookBudgetAction = "log"     // Only log and publish the violations
+)
+
+// PreflightPolicy is what GatewayD does with the plugins that fail their preflight
+// checks at startup.
+const (
+	PreflightAbort   PreflightPolicy = "abort"   // Abort the startup
+	PreflightDisable PreflightPolicy = "disable" // Stop and remove the plugin
+)
+
+// BuiltinMode is when a built-in plugin is enabled.
+const (
+	BuiltinAuto     BuiltinMode = "auto"     // Unless a plugin that replaces it is loaded
+	BuiltinEnabled  BuiltinMode = "enabled"  // Always
+	BuiltinDisabled BuiltinMode = "disabled" // Never
+)
+
+// EngineMode is how the server serves the client connections.
+const (
+	Goroutine EngineMode = "goroutine" // A goroutine per connection and direction
+	EventLoop EngineMode = "eventloop" // A bounded set of event loop workers (Linux only)
+)
+
+// CompressionAlgorithm is an algorithm the traffic between
+// the proxy and the clients can be compressed with.
+const (
+	Zlib CompressionAlgorithm = "zlib"
+	Gzip CompressionAlgorithm = "gzip"
+)
+
+// The compressors of the gRPC calls of the plugin hooks.
+const (
+	ZstdPluginCompressor = "zstd"
+	GzipPluginCompressor = "gzip"
+)
+
+// IAMAuthProvider is a cloud provider whose IAM credentials the
+// clients authenticate to the database with.
+const (
+	AWSIAMAuth IAMAuthProvider = "aws"
+	GCPIAMAuth IAMAuthProvider = "gcp"
+)
+
+// ClusterRole is the member of a PostgreSQL cluster the clients connect to.
+const (
+	Primary ClusterRole = "primary" // The primary, for the writes
+	Standby ClusterRole = "standby" // A standby in recovery, for the reads
+)
+
+// TopologySource is where the roles of the members of a PostgreSQL cluster are learned.
+const (
+	PostgresSource TopologySource = "postgres" // Check which member isn't in recovery
+	PatroniSource  TopologySource = "patroni"  // Poll the REST API of Patroni
+	EtcdSource     TopologySource = "etcd"     // Watch the keys of Patroni in etcd (v3)
+	ConsulSource   TopologySource = "consul"   // Watch the keys of Patroni in Consul
+)
+
+// AffinityKey is a part of the sessions hashed to the standbys of a cluster.
+const (
+	AffinityUser     AffinityKey = "user"     // The user of the startup message
+	AffinityDatabase AffinityKey = "database" // The database of the startup message
+	AffinityClient   AffinityKey = "client"   // The IP address of the client
+)
+
+// APIRole is what the clients of the admin API are allowed to do. Each role is allowed
+// to do what the previous ones are.
+const (
+	Viewer   APIRole = "viewer"   // Read the status and the configuration
+	Operator APIRole = "operator" // Kill connections, and toggle the maintenance mode and hook trace
+	Admin    APIRole = "admin"    // Reload the configuration
+)
+
+// FirewallAction is what the proxy does with the queries
+// matched by a firewall rule.
+const (
+	Allow FirewallAction = "allow" // Send the query to the database
+	Deny  FirewallAction = "deny"  // Reject the query with an error
+)
+
 // LogOutput is the output type for the logger.
 const (
 	Console LogOutput = iota
@@ -65,6 +235,10 @@ const (
 	TracerName            = "gatewayd"
 	GlobalConfigFilename  = "gatewayd.yaml"
 	PluginsConfigFilename = "gatewayd_plugins.yaml"
+	DashboardFilename     = "gatewayd_dashboard.json"
+	AlertRulesFilename    = "gatewayd_alerts.yaml"
+	ProfileExtendsKey     = "extends"
+	IncludeKey            = "include"
 
 	// Logger constants.
 	DefaultLogOutput         = "console"
@@ -84,15 +258,34 @@ const (
 	DefaultSyslogPriority    = "info"
 
 	// Plugin constants.
-	DefaultMinPort                 = 50000
-	DefaultMaxPort                 = 60000
-	PluginPriorityStart            = 1000
-	LoggerName                     = "plugin"
-	DefaultPluginAddress           = "http://plugins/metrics"
-	DefaultMetricsMergerPeriod     = 5 * time.Second
-	DefaultPluginHealthCheckPeriod = 5 * time.Second
-	DefaultPluginTimeout           = 30 * time.Second
-	DefaultPluginStartTimeout      = 1 * time.Minute
+	DefaultMinPort                    = 50000
+	DefaultMaxPort                    = 60000
+	PluginPriorityStart               = 1000
+	LoggerName                        = "plugin"
+	DefaultPluginAddress              = "http://plugins/metrics"
+	DefaultMetricsMergerPeriod        = 5 * time.Second
+	DefaultPluginHealthCheckPeriod    = 5 * time.Second
+	DefaultPluginTimeout              = 30 * time.Second
+	DefaultPluginStartTimeout         = 1 * time.Minute
+	DefaultPluginShutdownTimeout      = 10 * time.Second
+	DefaultAsyncHookWorkers           = 4
+	DefaultAsyncHookQueueSize         = 1024
+	DefaultHookTraceSampleRate        = 0.01
+	DefaultHookTraceBufferSize        = 100
+	DefaultHookBudgetViolations       = 3
+	DefaultHookBudgetAction           = BudgetAsync
+	DefaultPluginCompressionThreshold = 4096
+	DefaultHookCacheMaxEntries        = 10000
+	DefaultHookCacheMaxTTL            = 5 * time.Minute
+	DefaultPreflightPolicy            = PreflightAbort
+	DefaultPreflightTimeout           = 30 * time.Second
+	DefaultBuiltinCacheTTL            = 30 * time.Second
+	DefaultBuiltinCacheMaxEntries     = 1000
+	DefaultBuiltinQueryLoggerLevel    = "debug"
+	DefaultBuiltinMaxQueryLength      = 1024
+	// PluginConfigSchemaSuffix is appended to the path of the executable of a plugin to
+	// find the JSON schema of its settings bundled with it.
+	PluginConfigSchemaSuffix = ".schema.json"
 
 	// Client constants.
 	DefaultNetwork            = "tcp"
@@ -104,10 +297,34 @@ const (
 	DefaultTCPKeepAlive       = false
 	DefaultReceiveTimeout     = 0
 	DefaultDialTimeout        = 60 * time.Second
+	DefaultFallbackDelay      = 300 * time.Millisecond // RFC 6555
 	DefaultRetries            = 3
 	DefaultBackoff            = 1 * time.Second
 	DefaultBackoffMultiplier  = 2.0
 	DefaultDisableBackoffCaps = false
+	DefaultMaxBackoff         = 1 * time.Minute
+	DefaultBackoffJitter      = 0.2
+
+	// SSH tunnel constants.
+	DefaultSSHTunnelKeepAliveInterval = 30 * time.Second
+	DefaultSSHKnownHostsFile          = "~/.ssh/known_hosts"
+
+	// IAM authentication constants.
+	DefaultIAMAuthRefreshBefore = 5 * time.Minute
+
+	// Primary discovery constants.
+	DefaultPrimaryDiscoveryInterval  = 5 * time.Second
+	DefaultPrimaryDiscoveryDatabase  = "postgres"
+	DefaultPrimaryDiscoveryNamespace = "/service"
+	DefaultAffinityLoadFactor        = 1.25
+
+	// Backend health check constants.
+	DefaultHealthCheckQuery            = "SELECT 1"
+	DefaultHealthCheckInterval         = 10 * time.Second
+	DefaultHealthCheckTimeout          = 5 * time.Second
+	DefaultHealthCheckLatencyThreshold = 1 * time.Second
+	DefaultHealthCheckFailureThreshold = 3
+	DefaultHealthCheckDatabase         = "postgres"
 
 	// Pool constants.
 	EmptyPoolCapacity        = 0
@@ -115,6 +332,62 @@ const (
 	MinimumPoolSize          = 2
 	DefaultHealthCheckPeriod = 60 * time.Second // This must match PostgreSQL authentication timeout.
 
+	// Proxy constants.
+	DefaultInjectCorrelationIDs = false
+	DefaultQueueSize            = 0
+	DefaultQueueTimeout         = 10 * time.Second
+	DefaultReceiveBufferSize    = 8192
+	DefaultSendBufferSize       = 8192
+	DefaultMaxMessageSize       = 1 << 27 // 134217728 bytes
+	DefaultOversizeBehavior     = Stream
+	DefaultFastPath             = false
+	DefaultReconnectSessions    = false
+	DefaultResetPolicy          = Replay
+
+	// Sharding constants.
+	DefaultShardKeySource = ShardKeyColumn
+	DefaultShardKeyHint   = "shard_key"
+
+	// Adaptive limiter constants.
+	DefaultInitialLimit        = 20
+	DefaultMinLimit            = 1
+	DefaultMaxLimit            = 200
+	DefaultLatencyThreshold    = 100 * time.Millisecond
+	DefaultBackoffRatio        = 0.9
+	DefaultLimiterQueueSize    = 0
+	DefaultLimiterQueueTimeout = 1 * time.Second
+	// DefaultPriorityClass is the class of the queries no priority class matches.
+	DefaultPriorityClass = "default"
+
+	// Bandwidth constants.
+	DefaultIngressRate = 0 // unlimited
+	DefaultEgressRate  = 0 // unlimited
+	DefaultBurst       = 0 // one second worth of traffic
+
+	// Compression constants.
+	DefaultCompressionLevel = -1 // The default level of compress/flate
+
+	// Idle transaction constants.
+	DefaultIdleTransactionTimeout       = 0 // disabled
+	DefaultIdleTransactionAction        = Terminate
+	DefaultIdleTransactionCheckInterval = 1 * time.Second
+
+	// Statement timeout constants.
+	DefaultStatementTimeout = 0 // disabled
+	// DefaultStatementTimeoutRule is the rule of the queries no statement timeout rule matches.
+	DefaultStatementTimeoutRule = "default"
+
+	// DefaultResultLimitRule is the rule of the queries no result limit rule matches.
+	DefaultResultLimitRule = "default"
+
+	// Statement cache constants.
+	DefaultMaxCachedStatements = 500
+
+	// Read/write split constants.
+	DefaultReadYourWritesWindow = 5 * time.Second
+	DefaultReplicationInterval  = time.Second
+	DefaultReplicationDatabase  = "postgres"
+
 	// Server constants.
 	DefaultListenNetwork        = "tcp"
 	DefaultListenAddress        = "0.0.0.0:15432"
@@ -125,6 +398,52 @@ const (
 	DefaultTCPNoDelay           = true
 	DefaultEngineStopTimeout    = 5 * time.Second
 	DefaultHandshakeTimeout     = 5 * time.Second
+	DefaultEngineMode           = Goroutine
+	DefaultEventLoopWorkers     = 0 // number of CPUs
+	DefaultDrainTimeout         = 5 * time.Second
+	DefaultFaultLatency         = 100 * time.Millisecond
+	DefaultSniffingTimeout      = 500 * time.Millisecond
+	DefaultPortRangeTenant      = "{server}-{port}"
+
+	// Signal constants.
+	DefaultSIGHUPAction  = SignalReload
+	DefaultSIGUSR1Action = SignalReopenLogs
+	DefaultSIGUSR2Action = SignalDumpState
+
+	// Flight recorder constants.
+	DefaultFlightRecorderSize = 1000
+
+	// Data API constants.
+	DefaultDataAPIAddress         = "localhost:18081"
+	DefaultDataAPIMaxRows         = 1000
+	DefaultDataAPITimeout         = 10 * time.Second
+	DefaultDataAPIDatabase        = "postgres"
+	DefaultDataAPIApplicationName = "gatewayd-data-api"
+	DefaultDataAPIIdleConns       = 4
+
+	// Performance constants.
+	DefaultAutoMaxProcs     = true
+	DefaultMemoryLimitRatio = 0.9
+
+	// Canary constants.
+	DefaultCanaryInterval        = 30 * time.Second
+	DefaultCanaryTimeout         = 5 * time.Second
+	DefaultCanaryQuery           = "SELECT 1"
+	DefaultCanaryDatabase        = "postgres"
+	DefaultCanaryApplicationName = "gatewayd-canary"
+
+	// Exec constants.
+	DefaultExecApplicationName = "gatewayd-exec"
+	DefaultExecConnectTimeout  = 10 * time.Second
+	DefaultExecSummaryLength   = 72
+
+	// ACME constants.
+	DefaultACMECacheDir     = "acme"
+	DefaultACMEDirectoryURL = "https://acme-v02.api.letsencrypt.org/directory"
+	DefaultACMEHTTPAddress  = "0.0.0.0:80"
+
+	// SPIFFE constants.
+	DefaultSPIFFEEndpointSocket = "unix:///tmp/spire-agent/public/api.sock"
 
 	// Utility constants.
 	DefaultSeed        = 1000
@@ -135,6 +454,31 @@ const (
 	DefaultMetricsPath          = "/metrics"
 	DefaultReadHeaderTimeout    = 10 * time.Second
 	DefaultMetricsServerTimeout = 10 * time.Second
+	DefaultOTLPProtocol         = "grpc"
+	DefaultOTLPEndpoint         = "localhost:4317"
+	DefaultOTLPInterval         = 30 * time.Second
+
+	// The templates of the labels of the connection metrics, and the limit of the
+	// values of each label.
+	UsageMetricLabels                   = "usage"
+	ConnectionsMetricLabels             = "connections"
+	ErrorsMetricLabels                  = "errors"
+	DefaultMetricLabelsCardinalityLimit = 100
+
+	// Event constants.
+	DefaultEventBufferSize = 100
+	DefaultEventTimeout    = 5 * time.Second
+	DefaultNATSURL         = "nats://localhost:4222"
+	DefaultNATSSubject     = "gatewayd.events"
+
+	// Usage constants.
+	DefaultUsageFlushInterval = time.Minute
+
+	// Shared limits constants.
+	DefaultSharedLimitsAddress      = "localhost:6379"
+	DefaultSharedLimitsKeyPrefix    = "gatewayd:"
+	DefaultSharedLimitsSyncInterval = 100 * time.Millisecond
+	DefaultSharedLimitsFailureMode  = FailOpen
 
 	// Sentry constants.
 	DefaultTraceSampleRate  = 0.2
@@ -142,9 +486,18 @@ const (
 	DefaultFlushTimeout     = 2 * time.Second
 
 	// API constants.
-	DefaultHTTPAPIAddress = "localhost:18080"
-	DefaultGRPCAPINetwork = "tcp"
-	DefaultGRPCAPIAddress = "localhost:19090"
+	DefaultHTTPAPIAddress      = "localhost:18080"
+	DefaultGRPCAPINetwork      = "tcp"
+	DefaultGRPCAPIAddress      = "localhost:19090"
+	DefaultDashboardTopQueries = 1000
+	DefaultDashboardLogLines   = 200
+	DefaultAPIErrorsLimit      = 1000
+
+	// Query service constants.
+	DefaultAPIQueryDatabase        = "postgres"
+	DefaultAPIQueryMaxRows         = 1000
+	DefaultAPIQueryTimeout         = 30 * time.Second
+	DefaultAPIQueryApplicationName = "gatewayd-query"
 
 	// Policies.
 	DefaultCompatibilityPolicy = Strict
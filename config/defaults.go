@@ -0,0 +1,341 @@
+package config
+
+// DefaultGlobalConfig returns the default global configuration, with the default config
+// group of each config object. Each call returns a new configuration, which can be
+// modified by the caller.
+func DefaultGlobalConfig() GlobalConfig {
+	defaultLogger := Logger{
+		Output:            []string{DefaultLogOutput},
+		Level:             DefaultLogLevel,
+		NoColor:           DefaultNoColor,
+		TimeFormat:        DefaultTimeFormat,
+		ConsoleTimeFormat: DefaultConsoleTimeFormat,
+		FileName:          DefaultLogFileName,
+		MaxSize:           DefaultMaxSize,
+		MaxBackups:        DefaultMaxBackups,
+		MaxAge:            DefaultMaxAge,
+		Compress:          DefaultCompress,
+		LocalTime:         DefaultLocalTime,
+		RSyslogNetwork:    DefaultRSyslogNetwork,
+		RSyslogAddress:    DefaultRSyslogAddress,
+		SyslogPriority:    DefaultSyslogPriority,
+	}
+
+	defaultMetric := Metrics{
+		Enabled:           true,
+		Address:           DefaultMetricsAddress,
+		Path:              DefaultMetricsPath,
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+		OTLPProtocol:      DefaultOTLPProtocol,
+		OTLPEndpoint:      DefaultOTLPEndpoint,
+		OTLPInterval:      DefaultOTLPInterval,
+		Labels: MetricLabels{
+			Templates: map[string][]string{
+				UsageMetricLabels:       {"user", "database"},
+				ConnectionsMetricLabels: {"server", "database"},
+				ErrorsMetricLabels:      {"user", "database"},
+			},
+			CardinalityLimit: DefaultMetricLabelsCardinalityLimit,
+		},
+	}
+
+	defaultClient := Client{
+		Network:            DefaultNetwork,
+		Address:            DefaultAddress,
+		TCPKeepAlive:       DefaultTCPKeepAlive,
+		TCPKeepAlivePeriod: DefaultTCPKeepAlivePeriod,
+		ReceiveChunkSize:   DefaultChunkSize,
+		ReceiveDeadline:    DefaultReceiveDeadline,
+		ReceiveTimeout:     DefaultReceiveTimeout,
+		SendDeadline:       DefaultSendDeadline,
+		DialTimeout:        DefaultDialTimeout,
+		Retries:            DefaultRetries,
+		Backoff:            DefaultBackoff,
+		BackoffMultiplier:  DefaultBackoffMultiplier,
+		DisableBackoffCaps: DefaultDisableBackoffCaps,
+		MaxBackoff:         DefaultMaxBackoff,
+		BackoffJitter:      DefaultBackoffJitter,
+		SSHTunnel: SSHTunnel{
+			Enabled:           false,
+			KnownHostsFile:    DefaultSSHKnownHostsFile,
+			KeepAliveInterval: DefaultSSHTunnelKeepAliveInterval,
+		},
+		IAMAuth: IAMAuth{
+			RefreshBefore: DefaultIAMAuthRefreshBefore,
+		},
+		PrimaryDiscovery: PrimaryDiscovery{
+			Enabled:    false,
+			Source:     PostgresSource,
+			Members:    []string{},
+			Endpoints:  []string{},
+			Namespace:  DefaultPrimaryDiscoveryNamespace,
+			Role:       Primary,
+			Interval:   DefaultPrimaryDiscoveryInterval,
+			Database:   DefaultPrimaryDiscoveryDatabase,
+			RetryReads: true,
+			Affinity: SessionAffinity{
+				Enabled:    false,
+				Keys:       []string{string(AffinityUser), string(AffinityDatabase)},
+				LoadFactor: DefaultAffinityLoadFactor,
+			},
+		},
+		HealthCheck: BackendHealthCheck{
+			Enabled:          false,
+			Query:            DefaultHealthCheckQuery,
+			Interval:         DefaultHealthCheckInterval,
+			Timeout:          DefaultHealthCheckTimeout,
+			LatencyThreshold: DefaultHealthCheckLatencyThreshold,
+			FailureThreshold: DefaultHealthCheckFailureThreshold,
+			Database:         DefaultHealthCheckDatabase,
+		},
+	}
+
+	defaultPool := Pool{
+		Size: DefaultPoolSize,
+	}
+
+	defaultProxy := Proxy{
+		Elastic:              false,
+		ReuseElasticClients:  false,
+		HealthCheckPeriod:    DefaultHealthCheckPeriod,
+		InjectCorrelationIDs: DefaultInjectCorrelationIDs,
+		QueueSize:            DefaultQueueSize,
+		QueueTimeout:         DefaultQueueTimeout,
+		ReceiveBufferSize:    DefaultReceiveBufferSize,
+		SendBufferSize:       DefaultSendBufferSize,
+		MaxMessageSize:       DefaultMaxMessageSize,
+		OversizeBehavior:     string(DefaultOversizeBehavior),
+		FastPath:             DefaultFastPath,
+		ReconnectSessions:    DefaultReconnectSessions,
+		ResetPolicy:          string(DefaultResetPolicy),
+		StartupParameters:    []StartupParameter{},
+		ErrorMessages:        map[string]ErrorMessage{},
+		AdaptiveLimit: AdaptiveLimit{
+			Enabled:          false,
+			InitialLimit:     DefaultInitialLimit,
+			MinLimit:         DefaultMinLimit,
+			MaxLimit:         DefaultMaxLimit,
+			LatencyThreshold: DefaultLatencyThreshold,
+			BackoffRatio:     DefaultBackoffRatio,
+			QueueSize:        DefaultLimiterQueueSize,
+			QueueTimeout:     DefaultLimiterQueueTimeout,
+			PriorityClasses:  []PriorityClass{},
+		},
+		Bandwidth: Bandwidth{
+			IngressRate: DefaultIngressRate,
+			EgressRate:  DefaultEgressRate,
+			Burst:       DefaultBurst,
+			PerUser:     false,
+			When:        "",
+		},
+		Compression: Compression{
+			Enabled:    false,
+			Algorithms: []string{string(Zlib), string(Gzip)},
+			Level:      DefaultCompressionLevel,
+		},
+		Firewall: Firewall{
+			Rules: []FirewallRule{},
+		},
+		Sharding: Sharding{
+			Enabled: false,
+			Key:     string(DefaultShardKeySource),
+			Hint:    DefaultShardKeyHint,
+			Shards:  []Shard{},
+		},
+		StatementTimeout: StatementTimeout{
+			Timeout: DefaultStatementTimeout,
+			Rules:   []StatementTimeoutRule{},
+		},
+		ResultLimit: ResultLimit{
+			Rules: []ResultLimitRule{},
+		},
+		IdleTransaction: IdleTransaction{
+			Timeout:   DefaultIdleTransactionTimeout,
+			Action:    string(DefaultIdleTransactionAction),
+			Databases: []IdleTransactionOverride{},
+		},
+		StatementCache: StatementCache{
+			Enabled:       false,
+			MaxStatements: DefaultMaxCachedStatements,
+		},
+		ReadWriteSplit: ReadWriteSplit{
+			Enabled:             false,
+			Window:              DefaultReadYourWritesWindow,
+			ReplicationInterval: DefaultReplicationInterval,
+			Database:            DefaultReplicationDatabase,
+		},
+	}
+
+	defaultServer := Server{
+		Network:          DefaultListenNetwork,
+		Address:          DefaultListenAddress,
+		EnableTicker:     false,
+		TickInterval:     DefaultTickInterval,
+		EnableTLS:        false,
+		CertFile:         "",
+		KeyFile:          "",
+		HandshakeTimeout: DefaultHandshakeTimeout,
+		EngineMode:       string(DefaultEngineMode),
+		EventLoopWorkers: DefaultEventLoopWorkers,
+		WatchCertFiles:   false,
+		ACME: ACME{
+			Enabled:      false,
+			Domains:      []string{},
+			CacheDir:     DefaultACMECacheDir,
+			DirectoryURL: DefaultACMEDirectoryURL,
+			HTTPAddress:  DefaultACMEHTTPAddress,
+		},
+		SPIFFE: ServerSPIFFE{
+			AuthorizedIDs: []string{},
+		},
+		DrainTimeout: DefaultDrainTimeout,
+		Canary: Canary{
+			Enabled:  false,
+			Interval: DefaultCanaryInterval,
+			Timeout:  DefaultCanaryTimeout,
+			Query:    DefaultCanaryQuery,
+			Database: DefaultCanaryDatabase,
+		},
+	}
+
+	return GlobalConfig{
+		Loggers: map[string]*Logger{Default: &defaultLogger},
+		Metrics: map[string]*Metrics{Default: &defaultMetric},
+		Clients: map[string]*Client{Default: &defaultClient},
+		Pools:   map[string]*Pool{Default: &defaultPool},
+		Proxies: map[string]*Proxy{Default: &defaultProxy},
+		Servers: map[string]*Server{Default: &defaultServer},
+		API: API{
+			Enabled:     true,
+			HTTPAddress: DefaultHTTPAPIAddress,
+			GRPCNetwork: DefaultGRPCAPINetwork,
+			GRPCAddress: DefaultGRPCAPIAddress,
+			Auth: APIAuth{
+				Enabled:     false,
+				Tokens:      []APIToken{},
+				ClientCerts: []APIClientCert{},
+			},
+			Dashboard: APIDashboard{
+				Enabled:    false,
+				TopQueries: DefaultDashboardTopQueries,
+				LogLines:   DefaultDashboardLogLines,
+			},
+			Query: APIQuery{
+				Enabled:  false,
+				Database: DefaultAPIQueryDatabase,
+				MaxRows:  DefaultAPIQueryMaxRows,
+				Timeout:  DefaultAPIQueryTimeout,
+			},
+			Errors: APIErrors{
+				Enabled: true,
+				Limit:   DefaultAPIErrorsLimit,
+			},
+		},
+		Events: Events{
+			Enabled:    false,
+			BufferSize: DefaultEventBufferSize,
+			Timeout:    DefaultEventTimeout,
+			Types:      []string{},
+			Webhook: WebhookEventSink{
+				Headers: map[string]string{},
+			},
+			NATS: NATSEventSink{
+				URL:     DefaultNATSURL,
+				Subject: DefaultNATSSubject,
+			},
+		},
+		Usage: Usage{
+			Enabled:       false,
+			FlushInterval: DefaultUsageFlushInterval,
+		},
+		SharedLimits: SharedLimits{
+			Enabled:      false,
+			Address:      DefaultSharedLimitsAddress,
+			KeyPrefix:    DefaultSharedLimitsKeyPrefix,
+			SyncInterval: DefaultSharedLimitsSyncInterval,
+			FailureMode:  string(DefaultSharedLimitsFailureMode),
+		},
+		Performance: Performance{
+			AutoMaxProcs:     DefaultAutoMaxProcs,
+			MemoryLimitRatio: DefaultMemoryLimitRatio,
+		},
+		Signals: Signals{
+			SIGHUP:  string(DefaultSIGHUPAction),
+			SIGUSR1: string(DefaultSIGUSR1Action),
+			SIGUSR2: string(DefaultSIGUSR2Action),
+		},
+		FlightRecorder: FlightRecorder{
+			Size: DefaultFlightRecorderSize,
+		},
+		Sampling: Sampling{
+			Hooks: map[string]float64{},
+			Logs:  map[string]float64{},
+		},
+		DataAPI: DataAPI{
+			Enabled:  false,
+			Address:  DefaultDataAPIAddress,
+			Server:   Default,
+			Database: DefaultDataAPIDatabase,
+			Tables:   []string{},
+			Tokens:   []string{},
+			MaxRows:  DefaultDataAPIMaxRows,
+			Timeout:  DefaultDataAPITimeout,
+		},
+	}
+}
+
+// DefaultPluginConfig returns the default plugin configuration, without any plugins.
+func DefaultPluginConfig() PluginConfig {
+	return PluginConfig{
+		VerificationPolicy:  string(PassDown),
+		CompatibilityPolicy: string(Strict),
+		AcceptancePolicy:    string(Accept),
+		TerminationPolicy:   string(Stop),
+		EnableMetricsMerger: true,
+		MetricsMergerPeriod: DefaultMetricsMergerPeriod,
+		HealthCheckPeriod:   DefaultPluginHealthCheckPeriod,
+		ReloadOnCrash:       true,
+		Timeout:             DefaultPluginTimeout,
+		StartTimeout:        DefaultPluginStartTimeout,
+		ShutdownTimeout:     DefaultPluginShutdownTimeout,
+		AsyncHookWorkers:    DefaultAsyncHookWorkers,
+		AsyncHookQueueSize:  DefaultAsyncHookQueueSize,
+		HookTrace: HookTrace{
+			Enabled:    false,
+			SampleRate: DefaultHookTraceSampleRate,
+			BufferSize: DefaultHookTraceBufferSize,
+		},
+		Compression: PluginCompression{
+			Enabled:    false,
+			Algorithms: []string{ZstdPluginCompressor, GzipPluginCompressor},
+			Threshold:  DefaultPluginCompressionThreshold,
+		},
+		PreflightPolicy:  string(DefaultPreflightPolicy),
+		PreflightTimeout: DefaultPreflightTimeout,
+		HookCache: HookCache{
+			Enabled:    true,
+			MaxEntries: DefaultHookCacheMaxEntries,
+			MaxTTL:     DefaultHookCacheMaxTTL,
+		},
+		Builtins: BuiltinPlugins{
+			// The cache serves stale results until their TTL, so it is opt-in.
+			Cache: BuiltinCache{
+				Mode:       string(BuiltinDisabled),
+				ReplacedBy: []string{"gatewayd-plugin-cache"},
+				TTL:        DefaultBuiltinCacheTTL,
+				MaxEntries: DefaultBuiltinCacheMaxEntries,
+			},
+			Metrics: BuiltinMetrics{
+				Mode:       string(BuiltinAuto),
+				ReplacedBy: []string{"gatewayd-plugin-metrics"},
+				Labels:     map[string]string{},
+			},
+			QueryLogger: BuiltinQueryLogger{
+				Mode:           string(BuiltinAuto),
+				ReplacedBy:     []string{"gatewayd-plugin-query-logger"},
+				Level:          DefaultBuiltinQueryLoggerLevel,
+				MaxQueryLength: DefaultBuiltinMaxQueryLength,
+			},
+		},
+	}
+}
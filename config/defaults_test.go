@@ -0,0 +1,58 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// assertGolden asserts that the value marshals to the JSON of the golden file, which is
+// written instead with the -update flag.
+func assertGolden(t *testing.T, name string, value interface{}) {
+	t.Helper()
+
+	data, err := json.MarshalIndent(value, "", "  ")
+	require.NoError(t, err)
+	data = append(data, '\n')
+
+	golden := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(golden, data, 0o600))
+	}
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(data))
+}
+
+// TestDefaultGlobalConfig tests that the default global config matches its golden file,
+// and that each call returns a new config.
+func TestDefaultGlobalConfig(t *testing.T) {
+	assertGolden(t, "default_global_config.golden.json", DefaultGlobalConfig())
+
+	defaults := DefaultGlobalConfig()
+	defaults.Servers[Default].Address = "localhost:1"
+	defaults.Proxies[Default].Compression.Algorithms[0] = "lz4"
+	assert.Equal(t, DefaultListenAddress, DefaultGlobalConfig().Servers[Default].Address)
+	assert.Equal(t, string(Zlib), DefaultGlobalConfig().Proxies[Default].Compression.Algorithms[0])
+}
+
+// TestDefaultPluginConfig tests that the default plugin config matches its golden file.
+func TestDefaultPluginConfig(t *testing.T) {
+	assertGolden(t, "default_plugin_config.golden.json", DefaultPluginConfig())
+}
+
+// TestLoadDefaults tests that the defaults loaded by the config are the default configs.
+func TestLoadDefaults(t *testing.T) {
+	config := NewConfig(context.Background(), "", "")
+	config.LoadDefaults(context.Background())
+	assert.Equal(t, DefaultGlobalConfig(), config.globalDefaults)
+	assert.Equal(t, DefaultPluginConfig(), config.pluginDefaults)
+}
@@ -27,6 +27,15 @@ var (
 		"continue": Continue,
 		"stop":     Stop,
 	}
+	PreflightPolicies = map[string]PreflightPolicy{
+		"abort":   PreflightAbort,
+		"disable": PreflightDisable,
+	}
+	BuiltinModes = map[string]BuiltinMode{
+		"auto":     BuiltinAuto,
+		"enabled":  BuiltinEnabled,
+		"disabled": BuiltinDisabled,
+	}
 	logOutputs = map[string]LogOutput{
 		"console": Console,
 		"stdout":  Stdout,
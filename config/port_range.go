@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+)
+
+const maxPort = 65535
+
+// tenantName returns the name of the tenant of the port from the template.
+func (pr PortRange) tenantName(server string, port int) string {
+	template := If[string](pr.Tenant != "", pr.Tenant, DefaultPortRangeTenant)
+	return strings.NewReplacer(
+		"{server}", server,
+		"{port}", strconv.Itoa(port),
+		"{index}", strconv.Itoa(port-pr.Start),
+	).Replace(template)
+}
+
+// ExpandPortRanges replaces the servers with a port range with a server per port,
+// named after its tenant. The server, pool and proxy of a tenant are copies of the
+// ones of the expanded server, and so is the client, with the placeholders of its
+// address replaced. The clients, pools and proxies that are configured with the name
+// of a tenant are used instead of the copies. It returns the names of the servers
+// of the tenants.
+func (gc *GlobalConfig) ExpandPortRanges() (map[string]string, *gerr.GatewayDError) {
+	names := make([]string, 0, len(gc.Servers))
+	for name, server := range gc.Servers {
+		if server != nil && server.PortRange != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	tenants := make(map[string]string)
+	for _, name := range names {
+		server := gc.Servers[name]
+		portRange := *server.PortRange
+		if portRange.Start < 1 || portRange.End > maxPort || portRange.Start > portRange.End {
+			return nil, gerr.ErrValidationFailed.Wrap(fmt.Errorf(
+				"\"servers.%s.portRange\" must be between 1 and %d", name, maxPort))
+		}
+		if server.Network != "tcp" && server.Network != "udp" {
+			return nil, gerr.ErrValidationFailed.Wrap(fmt.Errorf(
+				"\"servers.%s.portRange\" requires the tcp or udp network", name))
+		}
+		host, _, err := net.SplitHostPort(server.Address)
+		if err != nil {
+			return nil, gerr.ErrValidationFailed.Wrap(err)
+		}
+
+		client := gc.Clients[name]
+		if client == nil {
+			client = gc.Clients[Default]
+		}
+
+		for port := portRange.Start; port <= portRange.End; port++ {
+			tenant := portRange.tenantName(name, port)
+			if _, exists := gc.Servers[tenant]; exists {
+				return nil, gerr.ErrValidationFailed.Wrap(fmt.Errorf(
+					"\"servers.%s.portRange\" maps port %d to the existing server %q",
+					name, port, tenant))
+			}
+			tenants[tenant] = name
+
+			tenantServer := *server
+			tenantServer.Address = net.JoinHostPort(host, strconv.Itoa(port))
+			tenantServer.Listeners = nil
+			tenantServer.PortRange = nil
+			gc.Servers[tenant] = &tenantServer
+
+			if _, exists := gc.Clients[tenant]; !exists && client != nil {
+				tenantClient := *client
+				tenantClient.Address = strings.NewReplacer(
+					"{tenant}", tenant,
+					"{port}", strconv.Itoa(port),
+					"{index}", strconv.Itoa(port-portRange.Start),
+				).Replace(client.Address)
+				gc.Clients[tenant] = &tenantClient
+			}
+			if _, exists := gc.Pools[tenant]; !exists && gc.Pools[name] != nil {
+				tenantPool := *gc.Pools[name]
+				gc.Pools[tenant] = &tenantPool
+			}
+			if _, exists := gc.Proxies[tenant]; !exists && gc.Proxies[name] != nil {
+				tenantProxy := *gc.Proxies[name]
+				gc.Proxies[tenant] = &tenantProxy
+			}
+			if _, exists := gc.Loggers[tenant]; !exists && gc.Loggers[name] != nil {
+				gc.Loggers[tenant] = gc.Loggers[name]
+			}
+		}
+
+		// The expanded server is only a template, so its pool and proxy aren't created.
+		// Its client is kept, since it might be the default one.
+		delete(gc.Servers, name)
+		delete(gc.Pools, name)
+		delete(gc.Proxies, name)
+	}
+
+	return tenants, nil
+}
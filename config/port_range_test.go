@@ -0,0 +1,75 @@
+package config
+
+import (
+	"testing"
+
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPortRangeConfig(portRange *PortRange) *GlobalConfig {
+	return &GlobalConfig{
+		Loggers: map[string]*Logger{Default: {Level: DefaultLogLevel}},
+		Clients: map[string]*Client{
+			Default: {Network: "tcp", Address: "{tenant}.db.internal:5432"},
+		},
+		Pools:   map[string]*Pool{Default: {Size: 2}},
+		Proxies: map[string]*Proxy{Default: {QueueSize: 1}},
+		Servers: map[string]*Server{
+			Default: {Network: "tcp", Address: "0.0.0.0:15432", PortRange: portRange},
+		},
+	}
+}
+
+// TestExpandPortRanges tests that a server with a port range is replaced with a server
+// per port, with the client, pool and proxy of its tenant.
+func TestExpandPortRanges(t *testing.T) {
+	globalConfig := newPortRangeConfig(&PortRange{Start: 16000, End: 16002, Tenant: "tenant{index}"})
+	// The configured client of a tenant overrides the copy.
+	globalConfig.Clients["tenant2"] = &Client{Network: "tcp", Address: "other:5432"}
+
+	tenants, err := globalConfig.ExpandPortRanges()
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"tenant0": Default, "tenant1": Default, "tenant2": Default,
+	}, tenants)
+
+	assert.NotContains(t, globalConfig.Servers, Default)
+	assert.NotContains(t, globalConfig.Pools, Default)
+	assert.NotContains(t, globalConfig.Proxies, Default)
+	assert.Contains(t, globalConfig.Clients, Default)
+
+	assert.Equal(t, "0.0.0.0:16001", globalConfig.Servers["tenant1"].Address)
+	assert.Nil(t, globalConfig.Servers["tenant1"].PortRange)
+	assert.Equal(t, "tenant1.db.internal:5432", globalConfig.Clients["tenant1"].Address)
+	assert.Equal(t, "other:5432", globalConfig.Clients["tenant2"].Address)
+	assert.Equal(t, 2, globalConfig.Pools["tenant1"].Size)
+	assert.Equal(t, 1, globalConfig.Proxies["tenant1"].QueueSize)
+	assert.Equal(t, globalConfig.Loggers[Default], globalConfig.Loggers["tenant1"])
+
+	// The servers without a port range are left as they are.
+	tenants, err = globalConfig.ExpandPortRanges()
+	require.Nil(t, err)
+	assert.Empty(t, tenants)
+	assert.Len(t, globalConfig.Servers, 3)
+}
+
+// TestExpandPortRangesErrors tests the invalid port ranges.
+func TestExpandPortRangesErrors(t *testing.T) {
+	for _, portRange := range []*PortRange{
+		{Start: 0, End: 10},
+		{Start: 16001, End: 16000},
+		{Start: 65535, End: 65536},
+		// Every port is mapped to the same tenant.
+		{Start: 16000, End: 16001, Tenant: "tenant"},
+	} {
+		_, err := newPortRangeConfig(portRange).ExpandPortRanges()
+		assert.ErrorIs(t, err, gerr.ErrValidationFailed)
+	}
+
+	globalConfig := newPortRangeConfig(&PortRange{Start: 16000, End: 16001})
+	globalConfig.Servers[Default].Network = "unix"
+	_, err := globalConfig.ExpandPortRanges()
+	assert.ErrorIs(t, err, gerr.ErrValidationFailed)
+}
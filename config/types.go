@@ -12,36 +12,208 @@ type Plugin struct {
 	Args      []string `json:"args"`
 	Env       []string `json:"env" jsonschema:"required"`
 	Checksum  string   `json:"checksum" jsonschema:"required"`
+	// Priorities sets the priority of the hooks of the plugin by hook name, instead of
+	// the order of the plugin in the list.
+	Priorities map[string]uint `json:"priorities,omitempty"`
+	// ConfigSchema is the JSON schema file the settings of the plugin, i.e. its env, are
+	// linted against, instead of the one bundled next to its executable.
+	ConfigSchema string `json:"configSchema,omitempty"`
+}
+
+// PluginCompression compresses the hook arguments sent to the plugins over gRPC, and
+// their results, once the arguments reach the threshold in bytes. Each plugin uses the
+// first of the algorithms it declares in the "compression" field of its metadata.
+type PluginCompression struct {
+	Enabled    bool     `json:"enabled"`
+	Algorithms []string `json:"algorithms" jsonschema:"enum=zstd,enum=gzip"`
+	Threshold  int      `json:"threshold" jsonschema:"minimum=0"`
+}
+
+// HookCache serves the repeated hook calls from the results the plugins marked as
+// cacheable, until their TTL, which is capped at the max TTL.
+type HookCache struct {
+	Enabled    bool          `json:"enabled"`
+	MaxEntries int           `json:"maxEntries" jsonschema:"minimum=1"`
+	MaxTTL     time.Duration `json:"maxTTL" jsonschema:"oneof_type=string;integer"` //nolint:tagliatelle
+}
+
+type HookTrace struct {
+	Enabled    bool    `json:"enabled"`
+	SampleRate float64 `json:"sampleRate"`
+	BufferSize int     `json:"bufferSize"`
+}
+
+// HookBudget is the latency budget of the hook chain of a hook. When the chain exceeds
+// it the number of violations in a row, the action applies to its slowest plugin.
+type HookBudget struct {
+	Budget     time.Duration `json:"budget" jsonschema:"oneof_type=string;integer"`
+	Violations int           `json:"violations"`
+	Action     string        `json:"action" jsonschema:"enum=async,enum=disable,enum=log"`
 }
 
 type PluginConfig struct {
-	VerificationPolicy  string        `json:"verificationPolicy" jsonschema:"enum=passdown,enum=ignore,enum=abort,enum=remove"`
-	CompatibilityPolicy string        `json:"compatibilityPolicy" jsonschema:"enum=strict,enum=loose"`
-	AcceptancePolicy    string        `json:"acceptancePolicy" jsonschema:"enum=accept,enum=reject"`
-	TerminationPolicy   string        `json:"terminationPolicy" jsonschema:"enum=continue,enum=stop"`
-	EnableMetricsMerger bool          `json:"enableMetricsMerger"`
-	MetricsMergerPeriod time.Duration `json:"metricsMergerPeriod" jsonschema:"oneof_type=string;integer"`
-	HealthCheckPeriod   time.Duration `json:"healthCheckPeriod" jsonschema:"oneof_type=string;integer"`
-	ReloadOnCrash       bool          `json:"reloadOnCrash"`
-	Timeout             time.Duration `json:"timeout" jsonschema:"oneof_type=string;integer"`
-	StartTimeout        time.Duration `json:"startTimeout" jsonschema:"oneof_type=string;integer"`
-	Plugins             []Plugin      `json:"plugins"`
+	VerificationPolicy  string            `json:"verificationPolicy" jsonschema:"enum=passdown,enum=ignore,enum=abort,enum=remove"`
+	CompatibilityPolicy string            `json:"compatibilityPolicy" jsonschema:"enum=strict,enum=loose"`
+	AcceptancePolicy    string            `json:"acceptancePolicy" jsonschema:"enum=accept,enum=reject"`
+	TerminationPolicy   string            `json:"terminationPolicy" jsonschema:"enum=continue,enum=stop"`
+	EnableMetricsMerger bool              `json:"enableMetricsMerger"`
+	MetricsMergerPeriod time.Duration     `json:"metricsMergerPeriod" jsonschema:"oneof_type=string;integer"`
+	HealthCheckPeriod   time.Duration     `json:"healthCheckPeriod" jsonschema:"oneof_type=string;integer"`
+	ReloadOnCrash       bool              `json:"reloadOnCrash"`
+	Timeout             time.Duration     `json:"timeout" jsonschema:"oneof_type=string;integer"`
+	StartTimeout        time.Duration     `json:"startTimeout" jsonschema:"oneof_type=string;integer"`
+	ShutdownTimeout     time.Duration     `json:"shutdownTimeout" jsonschema:"oneof_type=string;integer"`
+	AsyncHookWorkers    int               `json:"asyncHookWorkers"`
+	AsyncHookQueueSize  int               `json:"asyncHookQueueSize"`
+	RequireCapabilities bool              `json:"requireCapabilities"`
+	HookTrace           HookTrace         `json:"hookTrace"`
+	Compression         PluginCompression `json:"compression"`
+	HookCache           HookCache         `json:"hookCache"`
+	// PreflightPolicy is what to do with the plugins whose onValidate hooks report
+	// errors in their config or dependencies, before GatewayD listens.
+	PreflightPolicy  string        `json:"preflightPolicy" jsonschema:"enum=abort,enum=disable"`
+	PreflightTimeout time.Duration `json:"preflightTimeout" jsonschema:"oneof_type=string;integer"`
+	// HookBudgets are the latency budgets of the hook chains, by hook name.
+	HookBudgets   map[string]HookBudget `json:"hookBudgets"`
+	Builtins      BuiltinPlugins        `json:"builtins"`
+	Plugins       []Plugin              `json:"plugins"`
+	AllowOverride bool                  `json:"allowOverride"`
+}
+
+// BuiltinPlugins are the minimal equivalents of common plugins compiled into GatewayD,
+// so that it is useful without any plugins. Each of them is enabled, disabled, or in
+// the auto mode, only enabled if none of the plugins it is replaced by are loaded.
+type BuiltinPlugins struct {
+	Cache       BuiltinCache       `json:"cache"`
+	Metrics     BuiltinMetrics     `json:"metrics"`
+	QueryLogger BuiltinQueryLogger `json:"queryLogger"`
+}
+
+// BuiltinCache serves the results of the read-only queries from memory, by user and
+// database, until their TTL. Any other query evicts the results of its database.
+type BuiltinCache struct {
+	Mode       string        `json:"mode" jsonschema:"enum=auto,enum=enabled,enum=disabled"`
+	ReplacedBy []string      `json:"replacedBy"`
+	TTL        time.Duration `json:"ttl" jsonschema:"oneof_type=string;integer"`
+	MaxEntries int           `json:"maxEntries" jsonschema:"minimum=1"`
+}
+
+// BuiltinMetrics records the samples of the metrics by the user, database and
+// application of their connections, with the static labels.
+type BuiltinMetrics struct {
+	Mode       string            `json:"mode" jsonschema:"enum=auto,enum=enabled,enum=disabled"`
+	ReplacedBy []string          `json:"replacedBy"`
+	Labels     map[string]string `json:"labels"`
+}
+
+// BuiltinQueryLogger logs the queries of the clients, with their user and database.
+type BuiltinQueryLogger struct {
+	Mode           string   `json:"mode" jsonschema:"enum=auto,enum=enabled,enum=disabled"`
+	ReplacedBy     []string `json:"replacedBy"`
+	Level          string   `json:"level" jsonschema:"enum=trace,enum=debug,enum=info,enum=warn"`
+	MaxQueryLength int      `json:"maxQueryLength" jsonschema:"minimum=1"`
+}
+
+type SSHTunnel struct {
+	Enabled           bool          `json:"enabled"`
+	Address           string        `json:"address"`
+	User              string        `json:"user"`
+	KeyFile           string        `json:"keyFile"`
+	KnownHostsFile    string        `json:"knownHostsFile"`
+	KeepAliveInterval time.Duration `json:"keepAliveInterval" jsonschema:"oneof_type=string;integer"`
+}
+
+type UpstreamProxy struct {
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type ClientSPIFFE struct {
+	Enabled    bool   `json:"enabled"`
+	SocketPath string `json:"socketPath"`
+	ServerID   string `json:"serverID"` //nolint:tagliatelle
+}
+
+type IAMAuth struct {
+	Provider        IAMAuthProvider `json:"provider" jsonschema:"enum=,enum=aws,enum=gcp"`
+	Region          string          `json:"region"`
+	CredentialsFile string          `json:"credentialsFile"`
+	RefreshBefore   time.Duration   `json:"refreshBefore" jsonschema:"oneof_type=string;integer"`
+}
+
+// PrimaryDiscovery finds the primary of a PostgreSQL cluster among its members, e.g.
+// of a Patroni or repmgr setup, by checking which one isn't in recovery, or learns it
+// from Patroni's REST API or the keys Patroni maintains in etcd or Consul.
+type PrimaryDiscovery struct {
+	Enabled   bool           `json:"enabled"`
+	Source    TopologySource `json:"source" jsonschema:"enum=postgres,enum=patroni,enum=etcd,enum=consul"`
+	Members   []string       `json:"members"`
+	Endpoints []string       `json:"endpoints"`
+	Namespace string         `json:"namespace"`
+	Scope     string         `json:"scope"`
+	Token     string         `json:"token"`
+	Role      ClusterRole    `json:"role" jsonschema:"enum=primary,enum=standby"`
+	Interval  time.Duration  `json:"interval" jsonschema:"oneof_type=string;integer"`
+	User      string         `json:"user"`
+	Password  string         `json:"password"`
+	Database  string         `json:"database"`
+	// RetryReads retries the read-only queries once on another standby when theirs
+	// dies.
+	RetryReads bool            `json:"retryReads"`
+	Affinity   SessionAffinity `json:"affinity"`
+}
+
+// SessionAffinity hashes the sessions of the standby role to the standbys by the keys,
+// with a consistent hash whose standbys serve at most the load factor times the
+// average number of sessions.
+type SessionAffinity struct {
+	Enabled    bool     `json:"enabled"`
+	Keys       []string `json:"keys" jsonschema:"enum=user,enum=database,enum=client"`
+	LoadFactor float64  `json:"loadFactor"`
+}
+
+// BackendHealthCheck runs a query on the backend on the interval, over the PostgreSQL
+// protocol, and marks it degraded after consecutive checks fail or are slower than the
+// latency threshold.
+type BackendHealthCheck struct {
+	Enabled          bool          `json:"enabled"`
+	Query            string        `json:"query"`
+	Interval         time.Duration `json:"interval" jsonschema:"oneof_type=string;integer"`
+	Timeout          time.Duration `json:"timeout" jsonschema:"oneof_type=string;integer"`
+	LatencyThreshold time.Duration `json:"latencyThreshold" jsonschema:"oneof_type=string;integer"`
+	FailureThreshold int           `json:"failureThreshold"`
+	CircuitBreaker   bool          `json:"circuitBreaker"`
+	User             string        `json:"user"`
+	Password         string        `json:"password"`
+	Database         string        `json:"database"`
 }
 
 type Client struct {
-	Network            string        `json:"network" jsonschema:"enum=tcp,enum=udp,enum=unix"`
-	Address            string        `json:"address"`
-	TCPKeepAlive       bool          `json:"tcpKeepAlive"`
-	TCPKeepAlivePeriod time.Duration `json:"tcpKeepAlivePeriod" jsonschema:"oneof_type=string;integer"`
-	ReceiveChunkSize   int           `json:"receiveChunkSize"`
-	ReceiveDeadline    time.Duration `json:"receiveDeadline" jsonschema:"oneof_type=string;integer"`
-	ReceiveTimeout     time.Duration `json:"receiveTimeout" jsonschema:"oneof_type=string;integer"`
-	SendDeadline       time.Duration `json:"sendDeadline" jsonschema:"oneof_type=string;integer"`
-	DialTimeout        time.Duration `json:"dialTimeout" jsonschema:"oneof_type=string;integer"`
-	Retries            int           `json:"retries"`
-	Backoff            time.Duration `json:"backoff" jsonschema:"oneof_type=string;integer"`
-	BackoffMultiplier  float64       `json:"backoffMultiplier"`
-	DisableBackoffCaps bool          `json:"disableBackoffCaps"`
+	Network            string             `json:"network" jsonschema:"enum=tcp,enum=udp,enum=unix"`
+	Address            string             `json:"address"`
+	TCPKeepAlive       bool               `json:"tcpKeepAlive"`
+	TCPKeepAlivePeriod time.Duration      `json:"tcpKeepAlivePeriod" jsonschema:"oneof_type=string;integer"`
+	ReceiveChunkSize   int                `json:"receiveChunkSize"`
+	ReceiveDeadline    time.Duration      `json:"receiveDeadline" jsonschema:"oneof_type=string;integer"`
+	ReceiveTimeout     time.Duration      `json:"receiveTimeout" jsonschema:"oneof_type=string;integer"`
+	SendDeadline       time.Duration      `json:"sendDeadline" jsonschema:"oneof_type=string;integer"`
+	DialTimeout        time.Duration      `json:"dialTimeout" jsonschema:"oneof_type=string;integer"`
+	ResolveInterval    time.Duration      `json:"resolveInterval,omitempty" jsonschema:"oneof_type=string;integer"`
+	DualStack          bool               `json:"dualStack,omitempty"`
+	FallbackDelay      time.Duration      `json:"fallbackDelay,omitempty" jsonschema:"oneof_type=string;integer"`
+	Retries            int                `json:"retries"`
+	Backoff            time.Duration      `json:"backoff" jsonschema:"oneof_type=string;integer"`
+	BackoffMultiplier  float64            `json:"backoffMultiplier"`
+	DisableBackoffCaps bool               `json:"disableBackoffCaps"`
+	MaxBackoff         time.Duration      `json:"maxBackoff" jsonschema:"oneof_type=string;integer"`
+	BackoffJitter      float64            `json:"backoffJitter" jsonschema:"minimum=0,maximum=1"`
+	SSHTunnel          SSHTunnel          `json:"sshTunnel"`
+	UpstreamProxy      UpstreamProxy      `json:"upstreamProxy"`
+	IAMAuth            IAMAuth            `json:"iamAuth"`
+	SPIFFE             ClientSPIFFE       `json:"spiffe"`
+	PrimaryDiscovery   PrimaryDiscovery   `json:"primaryDiscovery"`
+	HealthCheck        BackendHealthCheck `json:"healthCheck"`
 }
 
 type Logger struct {
@@ -71,16 +243,254 @@ type Metrics struct {
 	Timeout           time.Duration `json:"timeout" jsonschema:"oneof_type=string;integer"`
 	CertFile          string        `json:"certFile"`
 	KeyFile           string        `json:"keyFile"`
+	OTLPEnabled       bool          `json:"otlpEnabled"`
+	OTLPProtocol      string        `json:"otlpProtocol" jsonschema:"enum=grpc,enum=http"`
+	OTLPEndpoint      string        `json:"otlpEndpoint"`
+	OTLPInsecure      bool          `json:"otlpInsecure"`
+	OTLPInterval      time.Duration `json:"otlpInterval" jsonschema:"oneof_type=string;integer"`
+	Labels            MetricLabels  `json:"labels"`
+}
+
+// MetricLabels select the labels of the connection metrics of each template, out of
+// server, proxy, user, database and application, and limit the number of values of
+// each label, past which the values are collapsed into "other".
+type MetricLabels struct {
+	Templates        map[string][]string `json:"templates"`
+	CardinalityLimit int                 `json:"cardinalityLimit"`
 }
 
 type Pool struct {
 	Size int `json:"size"`
 }
 
+// PriorityClass is a class of the queries queued by the adaptive limiter, which the
+// queries its policy matches belong to.
+type PriorityClass struct {
+	Name string `json:"name"`
+	When string `json:"when"`
+}
+
+type AdaptiveLimit struct {
+	Enabled          bool          `json:"enabled"`
+	InitialLimit     int           `json:"initialLimit"`
+	MinLimit         int           `json:"minLimit"`
+	MaxLimit         int           `json:"maxLimit"`
+	LatencyThreshold time.Duration `json:"latencyThreshold" jsonschema:"oneof_type=string;integer"`
+	BackoffRatio     float64       `json:"backoffRatio" jsonschema:"exclusiveMinimum=0,exclusiveMaximum=1"`
+	QueueSize        int           `json:"queueSize"`
+	QueueTimeout     time.Duration `json:"queueTimeout" jsonschema:"oneof_type=string;integer"`
+	When             string        `json:"when"`
+	// PriorityClasses are in order of priority, and the queries none of them matches
+	// are in the default class, which has the lowest priority.
+	PriorityClasses []PriorityClass `json:"priorityClasses"`
+	ShedLowestClass bool            `json:"shedLowestClass"`
+}
+
+type Bandwidth struct {
+	IngressRate int    `json:"ingressRate"`
+	EgressRate  int    `json:"egressRate"`
+	Burst       int    `json:"burst"`
+	PerUser     bool   `json:"perUser"`
+	When        string `json:"when"`
+}
+
+type FirewallRule struct {
+	Name    string `json:"name"`
+	When    string `json:"when"`
+	Action  string `json:"action" jsonschema:"enum=allow,enum=deny"`
+	Message string `json:"message"`
+}
+
+type Firewall struct {
+	Rules []FirewallRule `json:"rules"`
+}
+
+// StatementTimeoutRule is the statement timeout of the queries its policy matches.
+type StatementTimeoutRule struct {
+	Name    string        `json:"name"`
+	When    string        `json:"when"`
+	Timeout time.Duration `json:"timeout" jsonschema:"oneof_type=string;integer"`
+}
+
+// StatementTimeout is the timeout the proxy cancels the queries after, which is the
+// timeout of the first rule that matches a query, or the default timeout. A timeout
+// of zero disables it.
+type StatementTimeout struct {
+	Timeout time.Duration          `json:"timeout" jsonschema:"oneof_type=string;integer"`
+	Rules   []StatementTimeoutRule `json:"rules"`
+}
+
+// ResultLimitRule is the result limit of the queries its policy matches.
+type ResultLimitRule struct {
+	Name     string `json:"name"`
+	When     string `json:"when"`
+	MaxRows  int    `json:"maxRows" jsonschema:"minimum=0"`
+	MaxBytes int    `json:"maxBytes" jsonschema:"minimum=0"`
+}
+
+// ResultLimit is the maximum number of rows and bytes of each result set of the
+// queries, which is the limit of the first rule that matches a query, or the default
+// limit. A limit of zero disables it.
+type ResultLimit struct {
+	MaxRows  int               `json:"maxRows" jsonschema:"minimum=0"`
+	MaxBytes int               `json:"maxBytes" jsonschema:"minimum=0"`
+	Rules    []ResultLimitRule `json:"rules"`
+}
+
+// IdleTransactionOverride is the idle transaction timeout of the sessions of a database.
+type IdleTransactionOverride struct {
+	Database string        `json:"database"`
+	Timeout  time.Duration `json:"timeout" jsonschema:"oneof_type=string;integer"`
+}
+
+type IdleTransaction struct {
+	Timeout   time.Duration             `json:"timeout" jsonschema:"oneof_type=string;integer"`
+	Action    string                    `json:"action" jsonschema:"enum=terminate,enum=rollback"`
+	Databases []IdleTransactionOverride `json:"databases"`
+}
+
+// StatementCache caches the named prepared statements on the server connections, and
+// prepares the identical statements of a session once, up to max statements per server
+// connection.
+type StatementCache struct {
+	Enabled       bool `json:"enabled"`
+	MaxStatements int  `json:"maxStatements" jsonschema:"minimum=0"`
+}
+
+// FaultInjection injects faults in the traffic of the proxy at the rates, between 0 and
+// 1, to test how the applications handle them. It only applies if GatewayD runs with
+// the --fault-injection flag, so that a config never injects faults by accident.
+type FaultInjection struct {
+	Enabled     bool          `json:"enabled"`
+	Latency     time.Duration `json:"latency" jsonschema:"oneof_type=string;integer"`
+	LatencyRate float64       `json:"latencyRate" jsonschema:"minimum=0,maximum=1"`
+	DropRate    float64       `json:"dropRate" jsonschema:"minimum=0,maximum=1"`
+	CorruptRate float64       `json:"corruptRate" jsonschema:"minimum=0,maximum=1"`
+	KillRate    float64       `json:"killRate" jsonschema:"minimum=0,maximum=1"`
+}
+
+// Shard is a config group whose pool serves the statements of the shard keys its
+// policy matches, or of a share of the hashes of the other keys if it has none.
+type Shard struct {
+	Name string `json:"name"`
+	When string `json:"when"`
+}
+
+// ReadWriteSplit sends the read-only queries of the sessions outside of transactions to
+// the pool of the reads config group, except in the read-your-writes window after a
+// write of the session, which lasts until the window ends or the standbys replayed the
+// write, as polled from pg_stat_replication of the primary on the replication interval.
+type ReadWriteSplit struct {
+	Enabled             bool          `json:"enabled"`
+	Reads               string        `json:"reads"`
+	Window              time.Duration `json:"window" jsonschema:"oneof_type=string;integer"`
+	ReplicationInterval time.Duration `json:"replicationInterval" jsonschema:"oneof_type=string;integer"`
+	User                string        `json:"user"`
+	Password            string        `json:"password"`
+	Database            string        `json:"database"`
+}
+
+type Sharding struct {
+	Enabled bool    `json:"enabled"`
+	Key     string  `json:"key" jsonschema:"enum=column,enum=comment,enum=hook"`
+	Column  string  `json:"column"`
+	Hint    string  `json:"hint"`
+	Shards  []Shard `json:"shards"`
+}
+
+type Compression struct {
+	Enabled    bool     `json:"enabled"`
+	Algorithms []string `json:"algorithms" jsonschema:"enum=zlib,enum=gzip"`
+	Level      int      `json:"level"`
+}
+
+// ErrorMessage is the code and message of an error sent to the clients. The empty
+// fields keep their defaults.
+type ErrorMessage struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// StartupParameter is a parameter set in the startup messages sent to the database.
+type StartupParameter struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Override bool   `json:"override"`
+}
+
 type Proxy struct {
-	Elastic             bool          `json:"elastic"`
-	ReuseElasticClients bool          `json:"reuseElasticClients"`
-	HealthCheckPeriod   time.Duration `json:"healthCheckPeriod" jsonschema:"oneof_type=string;integer"`
+	Elastic              bool                    `json:"elastic"`
+	ReuseElasticClients  bool                    `json:"reuseElasticClients"`
+	HealthCheckPeriod    time.Duration           `json:"healthCheckPeriod" jsonschema:"oneof_type=string;integer"`
+	InjectCorrelationIDs bool                    `json:"injectCorrelationIDs"` //nolint:tagliatelle
+	QueueSize            int                     `json:"queueSize"`
+	QueueTimeout         time.Duration           `json:"queueTimeout" jsonschema:"oneof_type=string;integer"`
+	AdaptiveLimit        AdaptiveLimit           `json:"adaptiveLimit"`
+	Bandwidth            Bandwidth               `json:"bandwidth"`
+	ReceiveBufferSize    int                     `json:"receiveBufferSize"`
+	SendBufferSize       int                     `json:"sendBufferSize"`
+	MaxMessageSize       int                     `json:"maxMessageSize"`
+	OversizeBehavior     string                  `json:"oversizeBehavior" jsonschema:"enum=close,enum=truncate,enum=stream"`
+	FastPath             bool                    `json:"fastPath"`
+	Compression          Compression             `json:"compression"`
+	Firewall             Firewall                `json:"firewall"`
+	StartupParameters    []StartupParameter      `json:"startupParameters"`
+	ReconnectSessions    bool                    `json:"reconnectSessions"`
+	ResetPolicy          string                  `json:"resetPolicy" jsonschema:"enum=replay,enum=discard"`
+	ErrorMessages        map[string]ErrorMessage `json:"errorMessages"`
+	Sharding             Sharding                `json:"sharding"`
+	MaxConnections       int                     `json:"maxConnections"`
+	StatementTimeout     StatementTimeout        `json:"statementTimeout"`
+	ResultLimit          ResultLimit             `json:"resultLimit"`
+	IdleTransaction      IdleTransaction         `json:"idleTransaction"`
+	MaxConnectionMemory  int                     `json:"maxConnectionMemory"`
+	FaultInjection       FaultInjection          `json:"faultInjection"`
+	StatementCache       StatementCache          `json:"statementCache"`
+	ReadWriteSplit       ReadWriteSplit          `json:"readWriteSplit"`
+}
+
+type ACME struct {
+	Enabled      bool     `json:"enabled"`
+	Domains      []string `json:"domains"`
+	Email        string   `json:"email"`
+	CacheDir     string   `json:"cacheDir"`
+	DirectoryURL string   `json:"directoryURL"` //nolint:tagliatelle
+	HTTPAddress  string   `json:"httpAddress"`
+}
+
+type ServerSPIFFE struct {
+	Enabled       bool     `json:"enabled"`
+	SocketPath    string   `json:"socketPath"`
+	AuthorizedIDs []string `json:"authorizedIDs"` //nolint:tagliatelle
+}
+
+// Listener is an additional network and address a server listens on.
+type Listener struct {
+	Network string `json:"network" jsonschema:"enum=tcp,enum=udp,enum=unix"`
+	Address string `json:"address"`
+}
+
+// Canary opens a client connection through the listener of a server on the interval
+// and runs the query on it, to measure the latency of the full path through GatewayD.
+type Canary struct {
+	Enabled  bool          `json:"enabled"`
+	Address  string        `json:"address"`
+	Interval time.Duration `json:"interval" jsonschema:"oneof_type=string;integer"`
+	Timeout  time.Duration `json:"timeout" jsonschema:"oneof_type=string;integer"`
+	Query    string        `json:"query"`
+	User     string        `json:"user"`
+	Password string        `json:"password"`
+	Database string        `json:"database"`
+}
+
+// PortRange expands a server into a server per port, each with the client, pool
+// and proxy of a tenant. The tenant name is a template of the {server}, {port}
+// and {index} placeholders, and the address of the client of the server can
+// also have them and the {tenant} placeholder.
+type PortRange struct {
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Tenant string `json:"tenant,omitempty"`
 }
 
 type Server struct {
@@ -91,7 +501,50 @@ type Server struct {
 	EnableTLS        bool          `json:"enableTLS"` //nolint:tagliatelle
 	CertFile         string        `json:"certFile"`
 	KeyFile          string        `json:"keyFile"`
+	WatchCertFiles   bool          `json:"watchCertFiles"`
+	ACME             ACME          `json:"acme"`
+	SPIFFE           ServerSPIFFE  `json:"spiffe"`
 	HandshakeTimeout time.Duration `json:"handshakeTimeout" jsonschema:"oneof_type=string;integer"`
+	EngineMode       string        `json:"engineMode" jsonschema:"enum=goroutine,enum=eventloop"`
+	EventLoopWorkers int           `json:"eventLoopWorkers"`
+	DrainTimeout     time.Duration `json:"drainTimeout" jsonschema:"oneof_type=string;integer"`
+	Listeners        []Listener    `json:"listeners,omitempty"`
+	PortRange        *PortRange    `json:"portRange,omitempty"`
+	DualStack        bool          `json:"dualStack,omitempty"`
+	Canary           Canary        `json:"canary"`
+	MaxGoroutines    int           `json:"maxGoroutines"`
+	Sniffing         Sniffing      `json:"sniffing"`
+}
+
+// Sniffing detects the protocol of the connections of a server from their first bytes,
+// so that one listener serves mixed clients. The PostgreSQL connections are proxied, and
+// the MySQL and HTTP ones are forwarded to the address of their protocol, or closed if
+// it is empty.
+type Sniffing struct {
+	Enabled bool          `json:"enabled"`
+	Timeout time.Duration `json:"timeout" jsonschema:"oneof_type=string;integer"`
+	MySQL   string        `json:"mysql"`
+	HTTP    string        `json:"http"`
+}
+
+// APIToken is the bearer token of a client of the admin API.
+type APIToken struct {
+	Name  string `json:"name"`
+	Token string `json:"token"`
+	Role  string `json:"role" jsonschema:"enum=viewer,enum=operator,enum=admin"`
+}
+
+// APIClientCert is a client of the admin API authenticated by its TLS certificate,
+// which is identified by its common name.
+type APIClientCert struct {
+	CommonName string `json:"commonName"`
+	Role       string `json:"role" jsonschema:"enum=viewer,enum=operator,enum=admin"`
+}
+
+type APIAuth struct {
+	Enabled     bool            `json:"enabled"`
+	Tokens      []APIToken      `json:"tokens"`
+	ClientCerts []APIClientCert `json:"clientCerts"`
 }
 
 type API struct {
@@ -99,14 +552,178 @@ type API struct {
 	HTTPAddress string `json:"httpAddress"`
 	GRPCAddress string `json:"grpcAddress"`
 	GRPCNetwork string `json:"grpcNetwork" jsonschema:"enum=tcp,enum=udp,enum=unix"`
+	// The APIs are served over TLS if the certificate is set, and verify the client
+	// certificates signed by the client CA, if any.
+	CertFile     string       `json:"certFile"`
+	KeyFile      string       `json:"keyFile"`
+	ClientCAFile string       `json:"clientCAFile"` //nolint:tagliatelle
+	Auth         APIAuth      `json:"auth"`
+	Dashboard    APIDashboard `json:"dashboard"`
+	Query        APIQuery     `json:"query"`
+	Errors       APIErrors    `json:"errors"`
+}
+
+// APIDashboard is the web dashboard served by the HTTP API at /dashboard/.
+type APIDashboard struct {
+	Enabled bool `json:"enabled"`
+	// TopQueries is the number of the distinct queries counted for the top queries,
+	// which requires decoding the traffic of the proxies.
+	TopQueries int `json:"topQueries"`
+	// LogLines is the number of the last lines of the default logger kept for the tail.
+	LogLines int `json:"logLines"`
+}
+
+// APIErrors reports the error responses of the databases by database, user and
+// SQLSTATE code at /errors.
+type APIErrors struct {
+	Enabled bool `json:"enabled"`
+	// Limit is the number of the distinct databases, users and codes counted.
+	Limit int `json:"limit" jsonschema:"minimum=1"`
+}
+
+// APIQuery is the gRPC query service of the admin API, which runs the read-only queries
+// of the operators and admins through the listeners of the servers as the user.
+type APIQuery struct {
+	Enabled  bool          `json:"enabled"`
+	User     string        `json:"user"`
+	Password string        `json:"password"`
+	Database string        `json:"database"`
+	MaxRows  int           `json:"maxRows" jsonschema:"minimum=0"`
+	Timeout  time.Duration `json:"timeout" jsonschema:"oneof_type=string;integer"`
+}
+
+type WebhookEventSink struct {
+	Enabled bool              `json:"enabled"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+}
+
+type SlackEventSink struct {
+	Enabled    bool   `json:"enabled"`
+	WebhookURL string `json:"webhookURL"` //nolint:tagliatelle
+}
+
+type NATSEventSink struct {
+	Enabled bool   `json:"enabled"`
+	URL     string `json:"url"`
+	Subject string `json:"subject"`
+}
+
+type Events struct {
+	Enabled    bool             `json:"enabled"`
+	BufferSize int              `json:"bufferSize"`
+	Timeout    time.Duration    `json:"timeout" jsonschema:"oneof_type=string;integer"`
+	Types      []string         `json:"types" jsonschema:"enum=server.started,enum=pool.exhausted,enum=plugin.crashed,enum=failover.happened,enum=config.reloaded,enum=backend.degraded,enum=plugin.demoted"`
+	Webhook    WebhookEventSink `json:"webhook"`
+	Slack      SlackEventSink   `json:"slack"`
+	NATS       NATSEventSink    `json:"nats"`
+}
+
+// SharedLimits keeps the state of the bandwidth limits and the connection quotas of the
+// proxies in a Redis shared by the GatewayD instances, so that they are enforced across
+// the instances. The usage is counted locally and synced on the interval.
+type SharedLimits struct {
+	Enabled      bool          `json:"enabled"`
+	Address      string        `json:"address"`
+	Username     string        `json:"username"`
+	Password     string        `json:"password"`
+	DB           int           `json:"db"`
+	KeyPrefix    string        `json:"keyPrefix"`
+	SyncInterval time.Duration `json:"syncInterval" jsonschema:"oneof_type=string;integer"`
+	FailureMode  string        `json:"failureMode" jsonschema:"enum=open,enum=closed"`
+}
+
+// Performance tunes the Go runtime at startup, e.g. for the CPU and memory limits of
+// the container GatewayD runs in.
+type Performance struct {
+	AutoMaxProcs     bool    `json:"autoMaxProcs"`
+	MaxProcs         int     `json:"maxProcs"`
+	GCPercent        int     `json:"gcPercent"`
+	MemoryLimit      int64   `json:"memoryLimit"`
+	MemoryLimitRatio float64 `json:"memoryLimitRatio" jsonschema:"minimum=0,maximum=1"`
+	Ballast          int64   `json:"ballast"`
+}
+
+// RunReport is the report of the listeners, pools, plugins and features GatewayD runs
+// with, which is logged once it has started, and written to the file if it is set.
+type RunReport struct {
+	FileName string `json:"fileName"`
+}
+
+// State persists the changes made at runtime with the admin API, e.g. the maintenance
+// mode and the hook trace, to the file, so that they are applied again on restart.
+// Nothing is persisted if it is empty.
+type State struct {
+	FileName string `json:"fileName"`
+}
+
+// Signals maps the signals that don't stop GatewayD by default to their actions, and the
+// directory the dumpState action writes the dumps to.
+type Signals struct {
+	SIGHUP  string `json:"sighup" jsonschema:"enum=stop,enum=reload,enum=reopenLogs,enum=dumpState,enum=ignore"`
+	SIGUSR1 string `json:"sigusr1" jsonschema:"enum=stop,enum=reload,enum=reopenLogs,enum=dumpState,enum=ignore"`
+	SIGUSR2 string `json:"sigusr2" jsonschema:"enum=stop,enum=reload,enum=reopenLogs,enum=dumpState,enum=ignore"`
+	DumpDir string `json:"dumpDir"`
+}
+
+// FlightRecorder keeps the last events, hook outcomes and errors in memory, which are
+// dumped with the goroutines to a file in the dump directory on a panic or SIGQUIT.
+type FlightRecorder struct {
+	Size    int    `json:"size" jsonschema:"minimum=0"`
+	DumpDir string `json:"dumpDir"`
+}
+
+// Sampling keeps a fraction of the runs of each hook and of the debug messages of each
+// log category, between 0 and 1, so that the observability can be tuned without
+// disabling the features. The hooks and the categories that aren't listed are kept.
+type Sampling struct {
+	Hooks map[string]float64 `json:"hooks"`
+	Logs  map[string]float64 `json:"logs"`
+}
+
+// DataAPI serves the tables of the allowlist over HTTP as JSON, e.g. GET /users?age=gt.30,
+// for the clients without a SQL driver. The requests are translated to parameterized
+// queries, which run through the listener of the server, i.e. its hooks, proxy and pool.
+type DataAPI struct {
+	Enabled  bool          `json:"enabled"`
+	Address  string        `json:"address"`
+	Server   string        `json:"server"`
+	User     string        `json:"user"`
+	Password string        `json:"password"`
+	Database string        `json:"database"`
+	Tables   []string      `json:"tables"`
+	Tokens   []string      `json:"tokens"`
+	MaxRows  int           `json:"maxRows" jsonschema:"minimum=0"`
+	Timeout  time.Duration `json:"timeout" jsonschema:"oneof_type=string;integer"`
+}
+
+type Usage struct {
+	Enabled       bool          `json:"enabled"`
+	LogFile       string        `json:"logFile"`
+	FlushInterval time.Duration `json:"flushInterval" jsonschema:"oneof_type=string;integer"`
 }
 
 type GlobalConfig struct {
-	API     API                 `json:"api"`
-	Loggers map[string]*Logger  `json:"loggers"`
-	Clients map[string]*Client  `json:"clients"`
-	Pools   map[string]*Pool    `json:"pools"`
-	Proxies map[string]*Proxy   `json:"proxies"`
-	Servers map[string]*Server  `json:"servers"`
-	Metrics map[string]*Metrics `json:"metrics"`
+	API            API                 `json:"api"`
+	Events         Events              `json:"events"`
+	Usage          Usage               `json:"usage"`
+	SharedLimits   SharedLimits        `json:"sharedLimits"`
+	Performance    Performance         `json:"performance"`
+	RunReport      RunReport           `json:"runReport"`
+	State          State               `json:"state"`
+	Signals        Signals             `json:"signals"`
+	FlightRecorder FlightRecorder      `json:"flightRecorder"`
+	Sampling       Sampling            `json:"sampling"`
+	DataAPI        DataAPI             `json:"dataAPI"` //nolint:tagliatelle
+	Loggers        map[string]*Logger  `json:"loggers"`
+	Clients        map[string]*Client  `json:"clients"`
+	Pools          map[string]*Pool    `json:"pools"`
+	Proxies        map[string]*Proxy   `json:"proxies"`
+	Servers        map[string]*Server  `json:"servers"`
+	Metrics        map[string]*Metrics `json:"metrics"`
+	// Profiles override the configuration above by profile name, e.g. dev, staging
+	// or prod, and can extend another profile.
+	Profiles map[string]map[string]interface{} `json:"profiles,omitempty"`
+	// Include merges the other files, globs or directories into the configuration.
+	Include []string `json:"include,omitempty"`
 }
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"go.opentelemetry.io/otel"
+)
+
+// endpoint is an address a config object listens on, with the range of its ports.
+type endpoint struct {
+	owner   string
+	network string
+	host    string
+	start   int
+	end     int
+}
+
+// overlaps returns true if both endpoints can't listen at the same time.
+func (e endpoint) overlaps(other endpoint) bool {
+	if e.network != other.network {
+		return false
+	}
+	if e.network == "unix" {
+		return e.host == other.host
+	}
+	if e.start > other.end || other.start > e.end {
+		return false
+	}
+	return e.host == other.host || e.host == "" || other.host == ""
+}
+
+// newEndpoint returns the endpoint of the address on the network, or false if it isn't
+// a fixed address, e.g. a random port. The wildcard hosts are empty, and the loopback
+// hosts are localhost.
+func newEndpoint(owner, network, address string) (endpoint, bool) {
+	if network == "unix" {
+		return endpoint{owner: owner, network: network, host: address}, address != ""
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return endpoint{}, false
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil || portNumber == 0 {
+		return endpoint{}, false
+	}
+	switch host {
+	case "0.0.0.0", "::":
+		host = ""
+	case "127.0.0.1", "::1":
+		host = "localhost"
+	}
+	return endpoint{
+		owner:   owner,
+		network: strings.TrimRight(If[string](network != "", network, "tcp"), "46"),
+		host:    host,
+		start:   portNumber,
+		end:     portNumber,
+	}, true
+}
+
+// sortedKeys returns the keys of the config groups in order, so that the violations
+// are reported in the same order.
+func sortedKeys[T any](groups map[string]*T) []string {
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidateReferences returns all the violations of the references between the config
+// groups: the proxies need the pool and a client of their name, the servers the proxy
+// and the logger of their name, the shards and the data API existing config groups,
+// and the listeners their own ports. The plugin names must be unique. The violations
+// aren't wrapped, as the wrapped errors are shared.
+func ValidateReferences(global GlobalConfig, plugins PluginConfig) []error {
+	var errors []error
+	violation := func(format string, args ...interface{}) {
+		errors = append(errors, fmt.Errorf(format, args...))
+	}
+
+	for _, name := range sortedKeys(global.Proxies) {
+		if _, exists := global.Pools[name]; !exists {
+			violation("\"proxies.%s\" has no pool, \"pools.%s\" is missing", name, name)
+		}
+		_, exists := global.Clients[name]
+		_, hasDefault := global.Clients[Default]
+		if !exists && !hasDefault {
+			violation("\"proxies.%s\" has no client, \"clients.%s\" is missing", name, name)
+		}
+		if proxy := global.Proxies[name]; proxy != nil && proxy.Sharding.Enabled {
+			for _, shard := range proxy.Sharding.Shards {
+				if _, exists := global.Pools[shard.Name]; !exists {
+					violation("shard %q of \"proxies.%s\" has no pool, \"pools.%s\" is missing",
+						shard.Name, name, shard.Name)
+				}
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(global.Servers) {
+		if _, exists := global.Proxies[name]; !exists {
+			violation("\"servers.%s\" has no proxy, \"proxies.%s\" is missing", name, name)
+		}
+		if _, exists := global.Loggers[name]; !exists {
+			violation("\"servers.%s\" has no logger, \"loggers.%s\" is missing", name, name)
+		}
+	}
+
+	if global.DataAPI.Enabled {
+		if _, exists := global.Servers[global.DataAPI.Server]; !exists {
+			violation("\"dataAPI.server\" references the missing server %q", global.DataAPI.Server)
+		}
+	}
+
+	endpoints := listenerEndpoints(global)
+	for i, first := range endpoints {
+		for _, second := range endpoints[i+1:] {
+			if first.overlaps(second) {
+				violation("%s and %s listen on the same port", first.owner, second.owner)
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, plugin := range plugins.Plugins {
+		if seen[plugin.Name] {
+			violation("plugin %q is configured more than once", plugin.Name)
+		}
+		seen[plugin.Name] = true
+	}
+
+	return errors
+}
+
+// listenerEndpoints returns the endpoints of the servers, the APIs, the metrics server,
+// and the data API, in order.
+func listenerEndpoints(global GlobalConfig) []endpoint {
+	var endpoints []endpoint
+	add := func(owner, network, address string) {
+		if listener, ok := newEndpoint(owner, network, address); ok {
+			endpoints = append(endpoints, listener)
+		}
+	}
+
+	for _, name := range sortedKeys(global.Servers) {
+		server := global.Servers[name]
+		if server == nil {
+			continue
+		}
+		owner := fmt.Sprintf("\"servers.%s\"", name)
+		if server.PortRange != nil {
+			host, _, err := net.SplitHostPort(server.Address)
+			if listener, ok := newEndpoint(owner, server.Network, net.JoinHostPort(
+				host, strconv.Itoa(server.PortRange.Start))); ok && err == nil {
+				listener.end = server.PortRange.End
+				endpoints = append(endpoints, listener)
+			}
+		} else {
+			add(owner, server.Network, server.Address)
+		}
+		for _, listener := range server.Listeners {
+			add(owner, listener.Network, listener.Address)
+		}
+		if server.ACME.Enabled {
+			add(fmt.Sprintf("\"servers.%s.acme\"", name), "tcp", server.ACME.HTTPAddress)
+		}
+	}
+
+	if global.API.Enabled {
+		add("\"api.httpAddress\"", "tcp", global.API.HTTPAddress)
+		add("\"api.grpcAddress\"", global.API.GRPCNetwork, global.API.GRPCAddress)
+	}
+	// Only the default metrics server is started.
+	if metrics := global.Metrics[Default]; metrics != nil && metrics.Enabled {
+		add("\"metrics.default\"", "tcp", metrics.Address)
+	}
+	if global.DataAPI.Enabled {
+		add("\"dataAPI\"", "tcp", global.DataAPI.Address)
+	}
+
+	return endpoints
+}
+
+// ValidateReferences validates the references between the config groups, and logs all
+// the violations before failing.
+func (c *Config) ValidateReferences(ctx context.Context) {
+	_, span := otel.Tracer(TracerName).Start(ctx, "Validate config references")
+
+	errors := ValidateReferences(c.Global, c.Plugin)
+	if len(errors) > 0 {
+		for _, err := range errors {
+			span.RecordError(err)
+			log.Println(gerr.ErrValidationFailed.Wrap(err))
+		}
+		span.End()
+		log.Fatal("failed to validate the references of the configuration")
+	}
+	span.End()
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateReferences tests that all the violations of the references between the
+// config groups are reported together.
+func TestValidateReferences(t *testing.T) {
+	global := DefaultGlobalConfig()
+	plugins := DefaultPluginConfig()
+	assert.Empty(t, ValidateReferences(global, plugins))
+
+	global.Servers["orders"] = &Server{Network: "tcp", Address: "127.0.0.1:15432"}
+	global.Servers["tenants"] = &Server{
+		Network: "tcp", Address: "0.0.0.0:0", PortRange: &PortRange{Start: 16000, End: 16010},
+	}
+	global.Servers[Default].Listeners = []Listener{{Network: "tcp", Address: "localhost:16005"}}
+	global.Proxies["orders"] = &Proxy{
+		Sharding: Sharding{Enabled: true, Shards: []Shard{{Name: "eu"}}},
+	}
+	global.Proxies["tenants"] = &Proxy{}
+	global.Pools["tenants"] = &Pool{}
+	global.Loggers["tenants"] = &Logger{}
+	global.API.GRPCAddress = "localhost:18080"
+	global.DataAPI = DataAPI{Enabled: true, Server: "missing", Address: "localhost:0"}
+	plugins.Plugins = []Plugin{{Name: "cache"}, {Name: "auth"}, {Name: "cache"}}
+
+	var messages []string
+	for _, err := range ValidateReferences(global, plugins) {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		`"proxies.orders" has no pool, "pools.orders" is missing`,
+		`shard "eu" of "proxies.orders" has no pool, "pools.eu" is missing`,
+		`"servers.orders" has no logger, "loggers.orders" is missing`,
+		`"dataAPI.server" references the missing server "missing"`,
+		`"servers.default" and "servers.orders" listen on the same port`,
+		`"servers.default" and "servers.tenants" listen on the same port`,
+		`"api.httpAddress" and "api.grpcAddress" listen on the same port`,
+		`plugin "cache" is configured more than once`,
+	}, messages)
+}
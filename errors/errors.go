@@ -42,6 +42,58 @@ const (
 	ErrCodeLintingFailed
 	ErrCodeExtractFailed
 	ErrCodeDownloadFailed
+	ErrCodeExportMetricsFailed
+	ErrCodeSendEventFailed
+	ErrCodePoolQueueFull
+	ErrCodePoolQueueTimeout
+	ErrCodeConcurrencyLimitExceeded
+	ErrCodeMessageTooLarge
+	ErrCodeEventLoopFailed
+	ErrCodeFetchMetricsFailed
+	ErrCodeFetchHookTraceFailed
+	ErrCodeCompressionFailed
+	ErrCodeSSHTunnelFailed
+	ErrCodeUpstreamProxyFailed
+	ErrCodeIAMAuthFailed
+	ErrCodeSPIFFEFailed
+	ErrCodeFetchConnectionsFailed
+	ErrCodeKillConnectionFailed
+	ErrCodeMaintenanceFailed
+	ErrCodeHealthCheckFailed
+	ErrCodeInvalidPolicy
+	ErrCodePolicyEvalFailed
+	ErrCodeQueryDenied
+	ErrCodeCreateClientFailed
+	ErrCodeInitializePoolFailed
+	ErrCodeStartServerFailed
+	ErrCodeAlreadyStarted
+	ErrCodeHookExists
+	ErrCodeSimulateHooksFailed
+	ErrCodeResolveBackendsFailed
+	ErrCodeRecoverSessionFailed
+	ErrCodeHookPanicked
+	ErrCodeConnectionPanicked
+	ErrCodeChecksumMismatch
+	ErrCodeUnauthenticated
+	ErrCodePermissionDenied
+	ErrCodeReloadConfigFailed
+	ErrCodeFetchStatusFailed
+	ErrCodePrimaryNotFound
+	ErrCodeInvalidSharding
+	ErrCodeCrossShard
+	ErrCodeShardUnavailable
+	ErrCodeConnectionQuotaExceeded
+	ErrCodeSharedLimitsFailed
+	ErrCodeInvalidPriorityClass
+	ErrCodeCancelRequestFailed
+	ErrCodeBackendDegraded
+	ErrCodeConnectionMemoryExceeded
+	ErrCodeGoroutineLimitExceeded
+	ErrCodeStateFailed
+	ErrCodeExecFailed
+	ErrCodePreflightFailed
+	ErrCodeInvalidReadWriteSplit
+	ErrCodeReadsUnavailable
 )
 
 var (
@@ -59,6 +111,40 @@ var (
 		ErrCodeResolveFailed, "failed to resolve address", nil)
 	ErrPoolExhausted = NewGatewayDError(
 		ErrCodePoolExhausted, "pool is exhausted", nil)
+	ErrPoolQueueFull = NewGatewayDError(
+		ErrCodePoolQueueFull, "pool is exhausted and the connection queue is full", nil)
+	ErrPoolQueueTimeout = NewGatewayDError(
+		ErrCodePoolQueueTimeout, "timed out waiting for an available connection", nil)
+	ErrConcurrencyLimitExceeded = NewGatewayDError(
+		ErrCodeConcurrencyLimitExceeded, "concurrency limit exceeded", nil)
+	ErrMessageTooLarge = NewGatewayDError(
+		ErrCodeMessageTooLarge, "message is larger than the maximum message size", nil)
+	ErrEventLoopFailed = NewGatewayDError(
+		ErrCodeEventLoopFailed, "failed to serve the connection with the event loop", nil)
+	ErrCompressionFailed = NewGatewayDError(
+		ErrCodeCompressionFailed, "failed to compress the connection", nil)
+	ErrSSHTunnelFailed = NewGatewayDError(
+		ErrCodeSSHTunnelFailed, "failed to connect through the SSH tunnel", nil)
+	ErrUpstreamProxyFailed = NewGatewayDError(
+		ErrCodeUpstreamProxyFailed, "failed to connect through the upstream proxy", nil)
+	ErrIAMAuthFailed = NewGatewayDError(
+		ErrCodeIAMAuthFailed, "failed to get the IAM credentials for the database", nil)
+	ErrSPIFFEFailed = NewGatewayDError(
+		ErrCodeSPIFFEFailed, "failed to get the SPIFFE identity of the workload", nil)
+	ErrInvalidPolicy = NewGatewayDError(
+		ErrCodeInvalidPolicy, "invalid policy expression", nil)
+	ErrPolicyEvalFailed = NewGatewayDError(
+		ErrCodePolicyEvalFailed, "failed to evaluate the policy expression", nil)
+	ErrQueryDenied = NewGatewayDError(
+		ErrCodeQueryDenied, "query denied by a firewall rule", nil)
+	ErrCreateClientFailed = NewGatewayDError(
+		ErrCodeCreateClientFailed, "failed to create a client, please check the configuration", nil)
+	ErrInitializePoolFailed = NewGatewayDError(
+		ErrCodeInitializePoolFailed, "failed to add all the clients to the pool", nil)
+	ErrStartServerFailed = NewGatewayDError(
+		ErrCodeStartServerFailed, "failed to start the server", nil)
+	ErrAlreadyStarted = NewGatewayDError(
+		ErrCodeAlreadyStarted, "GatewayD is already started", nil)
 
 	ErrPluginNotFound = NewGatewayDError(
 		ErrCodePluginNotFound, "plugin not found", nil)
@@ -74,6 +160,8 @@ var (
 		ErrCodePluginMetricsMergeFailed, "failed to merge plugin metrics", nil)
 	ErrFailedToPingPlugin = NewGatewayDError(
 		ErrCodePluginPingFailed, "failed to ping plugin", nil)
+	ErrHookExists = NewGatewayDError(
+		ErrCodeHookExists, "a hook is already registered with the same priority", nil)
 
 	ErrClientReceiveFailed = NewGatewayDError(
 		ErrCodeClientReceiveFailed, "couldn't receive data from the server", nil)
@@ -136,6 +224,76 @@ var (
 		ErrCodeExtractFailed, "failed to extract the archive", nil)
 	ErrDownloadFailed = NewGatewayDError(
 		ErrCodeDownloadFailed, "failed to download the file", nil)
+
+	ErrFailedToExportMetrics = NewGatewayDError(
+		ErrCodeExportMetricsFailed, "failed to export metrics", nil)
+	ErrFetchMetricsFailed = NewGatewayDError(
+		ErrCodeFetchMetricsFailed, "failed to fetch metrics", nil)
+	ErrFetchHookTraceFailed = NewGatewayDError(
+		ErrCodeFetchHookTraceFailed, "failed to fetch the hook trace", nil)
+	ErrFetchConnectionsFailed = NewGatewayDError(
+		ErrCodeFetchConnectionsFailed, "failed to fetch the connections", nil)
+	ErrKillConnectionFailed = NewGatewayDError(
+		ErrCodeKillConnectionFailed, "failed to kill the connection", nil)
+	ErrMaintenanceFailed = NewGatewayDError(
+		ErrCodeMaintenanceFailed, "failed to change the maintenance mode", nil)
+	ErrHealthCheckFailed = NewGatewayDError(
+		ErrCodeHealthCheckFailed, "GatewayD is not healthy", nil)
+	ErrSimulateHooksFailed = NewGatewayDError(
+		ErrCodeSimulateHooksFailed, "failed to simulate the hook chain", nil)
+	ErrResolveBackendsFailed = NewGatewayDError(
+		ErrCodeResolveBackendsFailed, "failed to re-resolve the backends", nil)
+	ErrRecoverSessionFailed = NewGatewayDError(
+		ErrCodeRecoverSessionFailed, "failed to recover the session on a new server connection", nil)
+	ErrHookPanicked = NewGatewayDError(
+		ErrCodeHookPanicked, "hook panicked", nil)
+	ErrConnectionPanicked = NewGatewayDError(
+		ErrCodeConnectionPanicked, "the connection panicked and was closed", nil)
+	ErrChecksumMismatch = NewGatewayDError(
+		ErrCodeChecksumMismatch, "the checksums do not match", nil)
+	ErrUnauthenticated = NewGatewayDError(
+		ErrCodeUnauthenticated, "the client is not authenticated", nil)
+	ErrPermissionDenied = NewGatewayDError(
+		ErrCodePermissionDenied, "the role of the client is not allowed to do it", nil)
+	ErrReloadConfigFailed = NewGatewayDError(
+		ErrCodeReloadConfigFailed, "failed to reload the configuration", nil)
+	ErrFetchStatusFailed = NewGatewayDError(
+		ErrCodeFetchStatusFailed, "failed to fetch the status", nil)
+	ErrPrimaryNotFound = NewGatewayDError(
+		ErrCodePrimaryNotFound, "the primary of the cluster was not found", nil)
+	ErrInvalidSharding = NewGatewayDError(
+		ErrCodeInvalidSharding, "the sharding configuration is invalid", nil)
+	ErrCrossShard = NewGatewayDError(
+		ErrCodeCrossShard, "the statement spans more than one shard", nil)
+	ErrShardUnavailable = NewGatewayDError(
+		ErrCodeShardUnavailable, "no server connection to the shard is available", nil)
+	ErrConnectionQuotaExceeded = NewGatewayDError(
+		ErrCodeConnectionQuotaExceeded, "the connection quota of the proxy is exceeded", nil)
+	ErrSharedLimitsFailed = NewGatewayDError(
+		ErrCodeSharedLimitsFailed, "failed to sync the shared state of the limits", nil)
+	ErrInvalidPriorityClass = NewGatewayDError(
+		ErrCodeInvalidPriorityClass, "invalid priority class", nil)
+	ErrCancelRequestFailed = NewGatewayDError(
+		ErrCodeCancelRequestFailed, "failed to send the cancel request to the server", nil)
+	ErrBackendDegraded = NewGatewayDError(
+		ErrCodeBackendDegraded, "backend is degraded", nil)
+	ErrConnectionMemoryExceeded = NewGatewayDError(
+		ErrCodeConnectionMemoryExceeded, "the connection exceeded its memory limit", nil)
+	ErrGoroutineLimitExceeded = NewGatewayDError(
+		ErrCodeGoroutineLimitExceeded, "the goroutine limit of the server is exceeded", nil)
+	ErrStateFailed = NewGatewayDError(
+		ErrCodeStateFailed, "failed to show or reset the state", nil)
+	ErrExecFailed = NewGatewayDError(
+		ErrCodeExecFailed, "failed to run the SQL files", nil)
+	ErrPreflightFailed = NewGatewayDError(
+		ErrCodePreflightFailed, "the preflight checks of the plugins failed", nil)
+	ErrInvalidReadWriteSplit = NewGatewayDError(
+		ErrCodeInvalidReadWriteSplit, "the read/write split configuration is invalid", nil)
+	ErrReadsUnavailable = NewGatewayDError(
+		ErrCodeReadsUnavailable, "no server connection to the reads config group is available", nil)
+
+	ErrSendEventFailed = NewGatewayDError(
+		ErrCodeSendEventFailed, "failed to send event", nil)
 )
 
 const (
@@ -1,6 +1,10 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+)
 
 type ErrCode uint32
 
@@ -37,3 +41,12 @@ func (e *GatewayDError) Wrap(err error) *GatewayDError {
 func (e *GatewayDError) Unwrap() error {
 	return e.OriginalError
 }
+
+// MarshalZerologObject logs the error with the code, name and category of its taxonomy.
+func (e *GatewayDError) MarshalZerologObject(event *zerolog.Event) {
+	taxonomy := e.Taxonomy()
+	event.Str("message", e.Error()).
+		Uint32("code", uint32(e.Code)).
+		Str("name", taxonomy.Name).
+		Str("category", string(taxonomy.Category))
+}
@@ -0,0 +1,184 @@
+package errors
+
+import (
+	"net/http"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain is the domain of the errors in the details of the gRPC statuses.
+const Domain = "gatewayd.io"
+
+// Category groups the errors by the part of GatewayD they come from.
+type Category string
+
+const (
+	CategoryInternal Category = "internal"
+	CategoryNetwork  Category = "network"
+	CategoryPool     Category = "pool"
+	CategoryProxy    Category = "proxy"
+	CategoryServer   Category = "server"
+	CategoryPlugin   Category = "plugin"
+	CategoryConfig   Category = "config"
+	CategoryPolicy   Category = "policy"
+	CategoryFile     Category = "file"
+	CategoryMetrics  Category = "metrics"
+	CategoryAPI      Category = "api"
+)
+
+// Taxonomy describes an error code for the machines: its stable name, e.g. for the
+// alerting rules, its category, the HTTP and gRPC status codes it maps to and the
+// suggested remediation. The numeric codes are stable too, since the new ones are
+// only ever added at the end.
+type Taxonomy struct {
+	Name        string
+	Category    Category
+	HTTPStatus  int
+	GRPCCode    codes.Code
+	Remediation string
+}
+
+var unknownTaxonomy = Taxonomy{
+	"UNKNOWN", CategoryInternal, http.StatusInternalServerError, codes.Unknown,
+	"Report the error with the logs to the GatewayD maintainers.",
+}
+
+//nolint:lll
+var taxonomies = map[ErrCode]Taxonomy{
+	ErrCodeUnknown:                   unknownTaxonomy,
+	ErrCodeNilContext:                {"NIL_CONTEXT", CategoryInternal, http.StatusInternalServerError, codes.Internal, "Report the error with the logs to the GatewayD maintainers."},
+	ErrCodeClientNotFound:            {"CLIENT_NOT_FOUND", CategoryProxy, http.StatusNotFound, codes.NotFound, "The client connection was already closed; no action is needed."},
+	ErrCodeClientNotConnected:        {"CLIENT_NOT_CONNECTED", CategoryNetwork, http.StatusServiceUnavailable, codes.Unavailable, "Check that the database is up and reachable from GatewayD."},
+	ErrCodeClientConnectionFailed:    {"CLIENT_CONNECTION_FAILED", CategoryNetwork, http.StatusBadGateway, codes.Unavailable, "Check the address of the client and that the database is up and reachable."},
+	ErrCodeNetworkNotSupported:       {"NETWORK_NOT_SUPPORTED", CategoryConfig, http.StatusBadRequest, codes.InvalidArgument, "Use tcp, udp or unix as the network."},
+	ErrCodeResolveFailed:             {"RESOLVE_FAILED", CategoryNetwork, http.StatusBadGateway, codes.Unavailable, "Check the address and the DNS resolution of its hostname."},
+	ErrCodePoolExhausted:             {"POOL_EXHAUSTED", CategoryPool, http.StatusServiceUnavailable, codes.ResourceExhausted, "Increase the size of the pool, or enable the queue or the elastic pool."},
+	ErrCodePluginNotFound:            {"PLUGIN_NOT_FOUND", CategoryPlugin, http.StatusNotFound, codes.NotFound, "Check the name of the plugin and that it is in the plugins config."},
+	ErrCodePluginNotReady:            {"PLUGIN_NOT_READY", CategoryPlugin, http.StatusServiceUnavailable, codes.Unavailable, "Check the logs of the plugin and retry once it is started."},
+	ErrCodeStartPluginFailed:         {"START_PLUGIN_FAILED", CategoryPlugin, http.StatusInternalServerError, codes.Internal, "Check the path, checksum and logs of the plugin."},
+	ErrCodeGetRPCClientFailed:        {"GET_RPC_CLIENT_FAILED", CategoryPlugin, http.StatusInternalServerError, codes.Internal, "Check that the plugin is compatible with this version of GatewayD."},
+	ErrCodeDispensePluginFailed:      {"DISPENSE_PLUGIN_FAILED", CategoryPlugin, http.StatusInternalServerError, codes.Internal, "Check that the plugin is compatible with this version of GatewayD."},
+	ErrCodePluginMetricsMergeFailed:  {"PLUGIN_METRICS_MERGE_FAILED", CategoryMetrics, http.StatusInternalServerError, codes.Internal, "Check that the metrics of the plugins don't conflict with each other."},
+	ErrCodePluginPingFailed:          {"PLUGIN_PING_FAILED", CategoryPlugin, http.StatusServiceUnavailable, codes.Unavailable, "Check the logs of the plugin, which might have crashed."},
+	ErrCodeClientReceiveFailed:       {"CLIENT_RECEIVE_FAILED", CategoryNetwork, http.StatusBadGateway, codes.Unavailable, "Check the database, which closed the connection or didn't answer in time."},
+	ErrCodeClientSendFailed:          {"CLIENT_SEND_FAILED", CategoryNetwork, http.StatusBadGateway, codes.Unavailable, "Check the database, which closed the connection or didn't accept the data in time."},
+	ErrCodeServerReceiveFailed:       {"SERVER_RECEIVE_FAILED", CategoryNetwork, http.StatusBadRequest, codes.Aborted, "The client closed the connection; no action is needed unless it persists."},
+	ErrCodeServerSendFailed:          {"SERVER_SEND_FAILED", CategoryNetwork, http.StatusBadRequest, codes.Aborted, "The client closed the connection; no action is needed unless it persists."},
+	ErrCodeServerListenFailed:        {"SERVER_LISTEN_FAILED", CategoryServer, http.StatusInternalServerError, codes.Internal, "Check that the address of the server is free and can be listened on."},
+	ErrCodeSplitHostPortFailed:       {"SPLIT_HOST_PORT_FAILED", CategoryConfig, http.StatusBadRequest, codes.InvalidArgument, "Use the host:port format for the address."},
+	ErrCodeAcceptFailed:              {"ACCEPT_FAILED", CategoryServer, http.StatusInternalServerError, codes.Internal, "Check the limits of open files and connections of the system."},
+	ErrCodeGetTLSConfigFailed:        {"GET_TLS_CONFIG_FAILED", CategoryConfig, http.StatusInternalServerError, codes.FailedPrecondition, "Check the certificate and key files of the server."},
+	ErrCodeTLSDisabled:               {"TLS_DISABLED", CategoryServer, http.StatusBadRequest, codes.FailedPrecondition, "Enable TLS on the server, or connect without TLS."},
+	ErrCodeUpgradeToTLSFailed:        {"UPGRADE_TO_TLS_FAILED", CategoryNetwork, http.StatusBadRequest, codes.Unavailable, "Check that the client trusts the certificate of the server."},
+	ErrCodeReadFailed:                {"READ_FAILED", CategoryNetwork, http.StatusBadRequest, codes.Aborted, "The client closed the connection; no action is needed unless it persists."},
+	ErrCodePutFailed:                 {"PUT_FAILED", CategoryPool, http.StatusInternalServerError, codes.Internal, "Check the size of the pool, which might be full."},
+	ErrCodeNilPointer:                {"NIL_POINTER", CategoryInternal, http.StatusInternalServerError, codes.Internal, "Report the error with the logs to the GatewayD maintainers."},
+	ErrCodeCastFailed:                {"CAST_FAILED", CategoryInternal, http.StatusInternalServerError, codes.Internal, "Report the error with the logs to the GatewayD maintainers."},
+	ErrCodeHookVerificationFailed:    {"HOOK_VERIFICATION_FAILED", CategoryPlugin, http.StatusInternalServerError, codes.Internal, "Check the plugin, whose hook result was rejected by the verification policy."},
+	ErrCodeHookReturnedError:         {"HOOK_RETURNED_ERROR", CategoryPlugin, http.StatusInternalServerError, codes.Internal, "Check the logs of the plugin whose hook failed."},
+	ErrCodeHookTerminatedConnection:  {"HOOK_TERMINATED_CONNECTION", CategoryPlugin, http.StatusForbidden, codes.PermissionDenied, "A plugin terminated the connection on purpose; check its rules if it is unexpected."},
+	ErrCodeFileNotFound:              {"FILE_NOT_FOUND", CategoryFile, http.StatusNotFound, codes.NotFound, "Check the path of the file."},
+	ErrCodeFileOpenFailed:            {"FILE_OPEN_FAILED", CategoryFile, http.StatusInternalServerError, codes.Internal, "Check the permissions of the file."},
+	ErrCodeFileReadFailed:            {"FILE_READ_FAILED", CategoryFile, http.StatusInternalServerError, codes.Internal, "Check the permissions and contents of the file."},
+	ErrCodeDuplicateMetricsCollector: {"DUPLICATE_METRICS_COLLECTOR", CategoryMetrics, http.StatusInternalServerError, codes.AlreadyExists, "Rename the conflicting metrics of the plugins."},
+	ErrCodeInvalidMetricType:         {"INVALID_METRIC_TYPE", CategoryMetrics, http.StatusBadRequest, codes.InvalidArgument, "Use a counter, gauge, histogram or summary as the type of the metric."},
+	ErrCodeValidationFailed:          {"VALIDATION_FAILED", CategoryConfig, http.StatusBadRequest, codes.InvalidArgument, "Fix the configuration errors listed in the logs."},
+	ErrCodeLintingFailed:             {"LINTING_FAILED", CategoryConfig, http.StatusBadRequest, codes.InvalidArgument, "Run gatewayd config lint and fix the errors it lists."},
+	ErrCodeExtractFailed:             {"EXTRACT_FAILED", CategoryFile, http.StatusInternalServerError, codes.Internal, "Check the archive of the plugin, which might be corrupted."},
+	ErrCodeDownloadFailed:            {"DOWNLOAD_FAILED", CategoryNetwork, http.StatusBadGateway, codes.Unavailable, "Check the URL of the file and the network connection, then retry."},
+	ErrCodeExportMetricsFailed:       {"EXPORT_METRICS_FAILED", CategoryMetrics, http.StatusBadGateway, codes.Unavailable, "Check that the metrics collector is up and reachable."},
+	ErrCodeSendEventFailed:           {"SEND_EVENT_FAILED", CategoryNetwork, http.StatusBadGateway, codes.Unavailable, "Check that the event sink is up and reachable."},
+	ErrCodePoolQueueFull:             {"POOL_QUEUE_FULL", CategoryPool, http.StatusServiceUnavailable, codes.ResourceExhausted, "Increase the size of the pool or of the queue."},
+	ErrCodePoolQueueTimeout:          {"POOL_QUEUE_TIMEOUT", CategoryPool, http.StatusServiceUnavailable, codes.DeadlineExceeded, "Increase the size of the pool or the queue timeout."},
+	ErrCodeConcurrencyLimitExceeded:  {"CONCURRENCY_LIMIT_EXCEEDED", CategoryProxy, http.StatusTooManyRequests, codes.ResourceExhausted, "Retry later, or raise the maximum limit of the adaptive limiter."},
+	ErrCodeMessageTooLarge:           {"MESSAGE_TOO_LARGE", CategoryProxy, http.StatusRequestEntityTooLarge, codes.ResourceExhausted, "Raise the maximum message size, or stream the large responses."},
+	ErrCodeEventLoopFailed:           {"EVENT_LOOP_FAILED", CategoryServer, http.StatusInternalServerError, codes.Internal, "Use the goroutine engine mode, and report the error to the GatewayD maintainers."},
+	ErrCodeFetchMetricsFailed:        {"FETCH_METRICS_FAILED", CategoryAPI, http.StatusBadGateway, codes.Unavailable, "Check that the metrics server is enabled and reachable."},
+	ErrCodeFetchHookTraceFailed:      {"FETCH_HOOK_TRACE_FAILED", CategoryAPI, http.StatusBadGateway, codes.Unavailable, "Check that the admin API is enabled and reachable."},
+	ErrCodeCompressionFailed:         {"COMPRESSION_FAILED", CategoryProxy, http.StatusInternalServerError, codes.Internal, "Disable the compression, or check the algorithms requested by the clients."},
+	ErrCodeSSHTunnelFailed:           {"SSH_TUNNEL_FAILED", CategoryNetwork, http.StatusBadGateway, codes.Unavailable, "Check the address, user and keys of the SSH bastion."},
+	ErrCodeUpstreamProxyFailed:       {"UPSTREAM_PROXY_FAILED", CategoryNetwork, http.StatusBadGateway, codes.Unavailable, "Check the URL and credentials of the upstream proxy."},
+	ErrCodeIAMAuthFailed:             {"IAM_AUTH_FAILED", CategoryNetwork, http.StatusUnauthorized, codes.Unauthenticated, "Check the IAM credentials and that the database allows IAM authentication."},
+	ErrCodeSPIFFEFailed:              {"SPIFFE_FAILED", CategoryNetwork, http.StatusUnauthorized, codes.Unauthenticated, "Check that the SPIFFE Workload API socket is reachable and the workload is registered."},
+	ErrCodeFetchConnectionsFailed:    {"FETCH_CONNECTIONS_FAILED", CategoryAPI, http.StatusBadGateway, codes.Unavailable, "Check that the admin API is enabled and reachable."},
+	ErrCodeKillConnectionFailed:      {"KILL_CONNECTION_FAILED", CategoryAPI, http.StatusNotFound, codes.NotFound, "Check the ID of the connection, which might be closed already."},
+	ErrCodeMaintenanceFailed:         {"MAINTENANCE_FAILED", CategoryAPI, http.StatusBadGateway, codes.Unavailable, "Check that the admin API is enabled and reachable."},
+	ErrCodeHealthCheckFailed:         {"HEALTH_CHECK_FAILED", CategoryServer, http.StatusServiceUnavailable, codes.Unavailable, "Check the status of the servers and the plugins in the logs."},
+	ErrCodeInvalidPolicy:             {"INVALID_POLICY", CategoryPolicy, http.StatusBadRequest, codes.InvalidArgument, "Fix the policy expression, which must be valid CEL returning a boolean."},
+	ErrCodePolicyEvalFailed:          {"POLICY_EVAL_FAILED", CategoryPolicy, http.StatusInternalServerError, codes.Internal, "Check the fields the policy expression uses, which might be missing."},
+	ErrCodeQueryDenied:               {"QUERY_DENIED", CategoryPolicy, http.StatusForbidden, codes.PermissionDenied, "The firewall denied the query on purpose; check its rules if it is unexpected."},
+	ErrCodeCreateClientFailed:        {"CREATE_CLIENT_FAILED", CategoryConfig, http.StatusBadGateway, codes.Unavailable, "Check the address of the clients and that the database is up and reachable."},
+	ErrCodeInitializePoolFailed:      {"INITIALIZE_POOL_FAILED", CategoryPool, http.StatusInternalServerError, codes.Internal, "Check the size of the pool and the limits of the database."},
+	ErrCodeStartServerFailed:         {"START_SERVER_FAILED", CategoryServer, http.StatusInternalServerError, codes.Internal, "Check that the addresses of the servers are free and can be listened on."},
+	ErrCodeAlreadyStarted:            {"ALREADY_STARTED", CategoryServer, http.StatusConflict, codes.FailedPrecondition, "Stop the GatewayD instance before starting it again."},
+	ErrCodeHookExists:                {"HOOK_EXISTS", CategoryPlugin, http.StatusConflict, codes.AlreadyExists, "Change the priority of one of the plugins."},
+	ErrCodeSimulateHooksFailed:       {"SIMULATE_HOOKS_FAILED", CategoryAPI, http.StatusBadRequest, codes.InvalidArgument, "Check the hook name and the arguments of the simulation."},
+	ErrCodeResolveBackendsFailed:     {"RESOLVE_BACKENDS_FAILED", CategoryNetwork, http.StatusBadGateway, codes.Unavailable, "Check the DNS resolution of the hostnames of the backends."},
+	ErrCodeRecoverSessionFailed:      {"RECOVER_SESSION_FAILED", CategoryProxy, http.StatusBadGateway, codes.Unavailable, "Check that the database is back up, and that it authenticates the sessions with trust, a cleartext password or IAM auth."},
+	ErrCodeHookPanicked:              {"HOOK_PANICKED", CategoryPlugin, http.StatusInternalServerError, codes.Internal, "Report the panic in the logs to the maintainers of the plugin."},
+	ErrCodeConnectionPanicked:        {"CONNECTION_PANICKED", CategoryInternal, http.StatusInternalServerError, codes.Internal, "Report the panic in the logs to the GatewayD maintainers."},
+	ErrCodeChecksumMismatch:          {"CHECKSUM_MISMATCH", CategoryFile, http.StatusUnprocessableEntity, codes.DataLoss, "Download the release again, and report it to the maintainers of the plugin if it persists."},
+	ErrCodeUnauthenticated:           {"UNAUTHENTICATED", CategoryAPI, http.StatusUnauthorized, codes.Unauthenticated, "Send a valid API token in the Authorization header, or a client certificate signed by the client CA."},
+	ErrCodePermissionDenied:          {"PERMISSION_DENIED", CategoryAPI, http.StatusForbidden, codes.PermissionDenied, "Use a token or client certificate whose role allows the action."},
+	ErrCodeReloadConfigFailed:        {"RELOAD_CONFIG_FAILED", CategoryConfig, http.StatusUnprocessableEntity, codes.FailedPrecondition, "Fix the configuration files, which are checked with gatewayd config lint."},
+	ErrCodeFetchStatusFailed:         {"FETCH_STATUS_FAILED", CategoryAPI, http.StatusBadGateway, codes.Unavailable, "Check that the admin API is enabled and reachable."},
+	ErrCodePrimaryNotFound:           {"PRIMARY_NOT_FOUND", CategoryNetwork, http.StatusServiceUnavailable, codes.Unavailable, "Check that the members of the cluster are reachable, and that the user of the primary discovery can connect to them."},
+	ErrCodeInvalidSharding:           {"INVALID_SHARDING", CategoryConfig, http.StatusBadRequest, codes.InvalidArgument, "Check that the shards are the names of config groups, and that their policies are valid CEL."},
+	ErrCodeCrossShard:                {"CROSS_SHARD", CategoryProxy, http.StatusBadRequest, codes.FailedPrecondition, "Send the statements of each shard separately, or install a plugin that handles the cross-shard statements."},
+	ErrCodeShardUnavailable:          {"SHARD_UNAVAILABLE", CategoryPool, http.StatusServiceUnavailable, codes.Unavailable, "Check the pool of the shard, and that its database accepts the authentication of the session without a challenge."},
+	ErrCodeConnectionQuotaExceeded:   {"CONNECTION_QUOTA_EXCEEDED", CategoryProxy, http.StatusTooManyRequests, codes.ResourceExhausted, "Retry later, or raise the maximum connections of the proxy."},
+	ErrCodeSharedLimitsFailed:        {"SHARED_LIMITS_FAILED", CategoryNetwork, http.StatusServiceUnavailable, codes.Unavailable, "Check the address and the credentials of the Redis of the shared limits."},
+	ErrCodeInvalidPriorityClass:      {"INVALID_PRIORITY_CLASS", CategoryConfig, http.StatusBadRequest, codes.InvalidArgument, "Give the priority classes unique names other than default, and valid CEL policies."},
+	ErrCodeCancelRequestFailed:       {"CANCEL_REQUEST_FAILED", CategoryNetwork, http.StatusBadGateway, codes.Unavailable, "Check that the server accepts new connections, and that it sent its backend key to the connection."},
+	ErrCodeBackendDegraded:           {"BACKEND_DEGRADED", CategoryNetwork, http.StatusServiceUnavailable, codes.Unavailable, "Check the database, whose health checks fail or are slower than the latency threshold."},
+	ErrCodeConnectionMemoryExceeded:  {"CONNECTION_MEMORY_EXCEEDED", CategoryProxy, http.StatusTooManyRequests, codes.ResourceExhausted, "Deallocate the prepared statements of the connection, or raise the maximum connection memory of the proxy."},
+	ErrCodeGoroutineLimitExceeded:    {"GOROUTINE_LIMIT_EXCEEDED", CategoryServer, http.StatusServiceUnavailable, codes.ResourceExhausted, "Retry later, or raise the maximum goroutines of the server."},
+	ErrCodeStateFailed:               {"STATE_FAILED", CategoryFile, http.StatusInternalServerError, codes.Internal, "Check that the state file of the config is valid JSON and can be written."},
+	ErrCodeExecFailed:                {"EXEC_FAILED", CategoryNetwork, http.StatusBadGateway, codes.Unavailable, "Check the failed statement of the SQL file, and that GatewayD is running and accepts the user."},
+	ErrCodePreflightFailed:           {"PREFLIGHT_FAILED", CategoryPlugin, http.StatusServiceUnavailable, codes.FailedPrecondition, "Fix the config or the dependencies of the plugins listed in the logs, or set the preflight policy to disable."},
+	ErrCodeInvalidReadWriteSplit:     {"INVALID_READ_WRITE_SPLIT", CategoryConfig, http.StatusBadRequest, codes.InvalidArgument, "Set the reads of the read/write split to another config group, e.g. of the standby role."},
+	ErrCodeReadsUnavailable:          {"READS_UNAVAILABLE", CategoryPool, http.StatusServiceUnavailable, codes.Unavailable, "Check the pool of the reads config group, and that its database accepts the authentication of the session without a challenge."},
+}
+
+// TaxonomyOf returns the taxonomy of the error code.
+func TaxonomyOf(code ErrCode) Taxonomy {
+	if taxonomy, ok := taxonomies[code]; ok {
+		return taxonomy
+	}
+	return unknownTaxonomy
+}
+
+// Taxonomy returns the taxonomy of the code of the error.
+func (e *GatewayDError) Taxonomy() Taxonomy {
+	return TaxonomyOf(e.Code)
+}
+
+// Fields returns the code, name, category and remediation of the error, e.g. for the
+// logs and the tags of the Sentry events.
+func (e *GatewayDError) Fields() map[string]string {
+	taxonomy := e.Taxonomy()
+	return map[string]string{
+		"code":        strconv.FormatUint(uint64(e.Code), 10),
+		"name":        taxonomy.Name,
+		"category":    string(taxonomy.Category),
+		"remediation": taxonomy.Remediation,
+	}
+}
+
+// GRPCStatus returns the gRPC status of the error, with its taxonomy in the details,
+// so that the errors returned by the gRPC handlers have the right status codes.
+func (e *GatewayDError) GRPCStatus() *status.Status {
+	taxonomy := e.Taxonomy()
+	grpcStatus := status.New(taxonomy.GRPCCode, e.Error())
+	if detailed, err := grpcStatus.WithDetails(&errdetails.ErrorInfo{
+		Reason:   taxonomy.Name,
+		Domain:   Domain,
+		Metadata: e.Fields(),
+	}); err == nil {
+		return detailed
+	}
+	return grpcStatus
+}
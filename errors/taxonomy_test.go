@@ -0,0 +1,47 @@
+package errors
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestTaxonomy tests that every error code has a taxonomy with a unique name.
+func TestTaxonomy(t *testing.T) {
+	names := make(map[string]ErrCode)
+	for code := ErrCodeUnknown; code <= ErrCodeReadsUnavailable; code++ {
+		taxonomy, ok := taxonomies[code]
+		assert.True(t, ok, "error code %d has no taxonomy", code)
+		assert.NotEmpty(t, taxonomy.Remediation, taxonomy.Name)
+		if other, ok := names[taxonomy.Name]; ok {
+			t.Errorf("error codes %d and %d have the same name %s", other, code, taxonomy.Name)
+		}
+		names[taxonomy.Name] = code
+	}
+
+	assert.Equal(t, unknownTaxonomy, TaxonomyOf(ErrCode(1<<31)))
+	assert.Equal(t, http.StatusServiceUnavailable, ErrPoolExhausted.Taxonomy().HTTPStatus)
+	assert.Equal(t, "pool", ErrPoolExhausted.Fields()["category"])
+}
+
+// TestGRPCStatus tests that the errors are converted to gRPC statuses with their
+// taxonomy in the details.
+func TestGRPCStatus(t *testing.T) {
+	err := NewGatewayDError(ErrCodeQueryDenied, "query denied", nil)
+	grpcStatus, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.PermissionDenied, grpcStatus.Code())
+	assert.Equal(t, "query denied", grpcStatus.Message())
+
+	details := grpcStatus.Details()
+	if assert.Len(t, details, 1) {
+		info, ok := details[0].(*errdetails.ErrorInfo)
+		assert.True(t, ok)
+		assert.Equal(t, "QUERY_DENIED", info.GetReason())
+		assert.Equal(t, Domain, info.GetDomain())
+	}
+}
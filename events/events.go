@@ -0,0 +1,188 @@
+package events
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/recorder"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type Type string
+
+const (
+	ServerStarted    Type = "server.started"
+	PoolExhausted    Type = "pool.exhausted"
+	PluginCrashed    Type = "plugin.crashed"
+	FailoverHappened Type = "failover.happened"
+	ConfigReloaded   Type = "config.reloaded"
+	BackendDegraded  Type = "backend.degraded"
+	PluginDemoted    Type = "plugin.demoted"
+	PluginDisabled   Type = "plugin.disabled"
+)
+
+// Event is a gateway-level lifecycle event that operators might want to be notified of.
+type Event struct {
+	Type    Type                   `json:"type"`
+	Time    time.Time              `json:"time"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Sink delivers events to an external system.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, event Event) error
+}
+
+type IBus interface {
+	Publish(eventType Type, message string, fields map[string]interface{})
+	Start()
+	Stop()
+}
+
+// Bus fans out the published events to the sinks in the background, so that
+// publishing never blocks the caller. If the buffer is full, the event is dropped.
+type Bus struct {
+	ctx     context.Context //nolint:containedctx
+	events  chan Event
+	types   map[Type]bool
+	wg      sync.WaitGroup
+	stopped bool
+	mu      sync.RWMutex
+
+	Logger  zerolog.Logger
+	Sinks   []Sink
+	Timeout time.Duration
+}
+
+var _ IBus = (*Bus)(nil)
+
+// NewBus creates a new event bus. If types is empty, all events are delivered.
+func NewBus(
+	ctx context.Context,
+	bufferSize int,
+	timeout time.Duration,
+	types []string,
+	logger zerolog.Logger,
+	sinks ...Sink,
+) *Bus {
+	busCtx, span := otel.Tracer(config.TracerName).Start(ctx, "NewBus")
+	defer span.End()
+
+	filter := map[Type]bool{}
+	for _, eventType := range types {
+		filter[Type(eventType)] = true
+	}
+
+	return &Bus{
+		ctx:     busCtx,
+		events:  make(chan Event, bufferSize),
+		types:   filter,
+		Logger:  logger,
+		Sinks:   sinks,
+		Timeout: timeout,
+	}
+}
+
+// Publish queues the event for delivery to the sinks.
+func (b *Bus) Publish(eventType Type, message string, fields map[string]interface{}) {
+	if len(b.types) > 0 && !b.types[eventType] {
+		return
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.stopped {
+		return
+	}
+
+	select {
+	case b.events <- Event{Type: eventType, Time: time.Now(), Message: message, Fields: fields}:
+	default:
+		b.Logger.Warn().Str("type", string(eventType)).Msg(
+			"Event buffer is full, dropping the event")
+	}
+}
+
+// Start starts delivering the published events to the sinks.
+func (b *Bus) Start() {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		for event := range b.events {
+			b.deliver(event)
+		}
+	}()
+
+	sinks := make([]string, 0, len(b.Sinks))
+	for _, sink := range b.Sinks {
+		sinks = append(sinks, sink.Name())
+	}
+	b.Logger.Info().Strs("sinks", sinks).Msg("Started the event bus")
+}
+
+// deliver sends the event to all the sinks.
+func (b *Bus) deliver(event Event) {
+	_, span := otel.Tracer(config.TracerName).Start(b.ctx, "Deliver event")
+	span.SetAttributes(attribute.String("type", string(event.Type)))
+	defer span.End()
+
+	for _, sink := range b.Sinks {
+		ctx, cancel := context.WithTimeout(b.ctx, b.Timeout)
+		if err := sink.Send(ctx, event); err != nil {
+			b.Logger.Error().Err(err).Fields(map[string]interface{}{
+				"sink": sink.Name(),
+				"type": event.Type,
+			}).Msg("Failed to send the event")
+			span.RecordError(err)
+		}
+		cancel()
+	}
+}
+
+// Stop stops the event bus after delivering the events that are already queued.
+func (b *Bus) Stop() {
+	b.mu.Lock()
+	if b.stopped {
+		b.mu.Unlock()
+		return
+	}
+	b.stopped = true
+	close(b.events)
+	b.mu.Unlock()
+
+	b.wg.Wait()
+}
+
+var (
+	defaultBus *Bus
+	defaultMu  sync.RWMutex
+)
+
+// SetDefault sets the bus used by the package-level Publish function.
+func SetDefault(bus *Bus) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultBus = bus
+}
+
+// Publish keeps the event in the flight recorder, and publishes it on the default
+// bus if the event bus is enabled.
+func Publish(eventType Type, message string, fields map[string]interface{}) {
+	recorded := map[string]interface{}{"type": eventType}
+	maps.Copy(recorded, fields)
+	recorder.Add(recorder.Event, message, recorded)
+
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	if defaultBus != nil {
+		defaultBus.Publish(eventType, message, fields)
+	}
+}
@@ -0,0 +1,170 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Send(_ context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestBus(t *testing.T) {
+	sink := &recordingSink{}
+	bus := NewBus(
+		context.Background(), 10, time.Second, []string{string(PoolExhausted)}, zerolog.Nop(), sink)
+	bus.Start()
+
+	bus.Publish(PoolExhausted, "exhausted", map[string]interface{}{"poolSize": 10})
+	// Filtered out, because only the pool exhausted events are delivered.
+	bus.Publish(ServerStarted, "started", nil)
+	bus.Stop()
+
+	// Publishing after stopping is a no-op.
+	bus.Publish(PoolExhausted, "exhausted", nil)
+
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, PoolExhausted, sink.events[0].Type)
+	assert.Equal(t, "exhausted", sink.events[0].Message)
+	assert.Equal(t, 10, sink.events[0].Fields["poolSize"])
+}
+
+func TestDefaultBus(t *testing.T) {
+	// Publishing without a default bus is a no-op.
+	Publish(ServerStarted, "started", nil)
+
+	sink := &recordingSink{}
+	bus := NewBus(context.Background(), 10, time.Second, nil, zerolog.Nop(), sink)
+	bus.Start()
+	SetDefault(bus)
+	defer SetDefault(nil)
+
+	Publish(ServerStarted, "started", nil)
+	bus.Stop()
+
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, ServerStarted, sink.events[0].Type)
+}
+
+func TestWebhookSink(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(responseWriter http.ResponseWriter, request *http.Request) {
+			assert.Equal(t, "Bearer token", request.Header.Get("Authorization"))
+
+			var event Event
+			assert.NoError(t, json.NewDecoder(request.Body).Decode(&event))
+			assert.Equal(t, PluginCrashed, event.Type)
+
+			if event.Message == "fail" {
+				responseWriter.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+	defer server.Close()
+
+	sink := &WebhookSink{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+	assert.NoError(t, sink.Send(context.Background(), Event{Type: PluginCrashed, Message: "ok"}))
+	assert.Error(t, sink.Send(context.Background(), Event{Type: PluginCrashed, Message: "fail"}))
+}
+
+func TestSlackSink(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(_ http.ResponseWriter, request *http.Request) {
+			var message map[string]string
+			assert.NoError(t, json.NewDecoder(request.Body).Decode(&message))
+			assert.Equal(t,
+				"*GatewayD pool.exhausted*: exhausted\n• poolSize: `10`", message["text"])
+		}))
+	defer server.Close()
+
+	sink := &SlackSink{WebhookURL: server.URL}
+	assert.NoError(t, sink.Send(context.Background(), Event{
+		Type:    PoolExhausted,
+		Message: "exhausted",
+		Fields:  map[string]interface{}{"poolSize": 10},
+	}))
+}
+
+// serveNATS accepts a single connection and answers like a NATS server would,
+// returning the lines sent by the client.
+func serveNATS(t *testing.T, listener net.Listener, reply string) <-chan []string {
+	t.Helper()
+
+	lines := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+
+		var received []string
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				break
+			}
+			line = strings.TrimSpace(line)
+			received = append(received, line)
+			if line == "PING" {
+				fmt.Fprint(conn, reply)
+				break
+			}
+		}
+		lines <- received
+	}()
+
+	return lines
+}
+
+func TestNATSSink(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := serveNATS(t, listener, "PONG\r\n")
+	sink := &NATSSink{URL: "nats://user:pass@" + listener.Addr().String(), Subject: "gatewayd.events"}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, sink.Send(ctx, Event{Type: ServerStarted, Message: "started"}))
+
+	lines := <-received
+	require.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[0], "CONNECT "))
+	assert.Contains(t, lines[0], `"user":"user"`)
+	assert.True(t, strings.HasPrefix(lines[1], "PUB gatewayd.events "))
+	assert.Contains(t, lines[2], `"type":"server.started"`)
+	assert.Equal(t, "PING", lines[3])
+
+	// Errors reported by the server are returned.
+	received = serveNATS(t, listener, "-ERR 'Authorization Violation'\r\n")
+	assert.Error(t, sink.Send(ctx, Event{Type: ServerStarted, Message: "started"}))
+	<-received
+}
@@ -0,0 +1,507 @@
+// Package gatewayd runs a GatewayD instance, so that it can be embedded in other Go
+// programs and tests. The run command is a thin wrapper around it:
+//
+//	conf := config.NewConfig(ctx, "gatewayd.yaml", "gatewayd_plugins.yaml")
+//	conf.InitConfig(ctx)
+//
+//	gateway := gatewayd.New(ctx, conf, gatewayd.Options{})
+//	if err := gateway.Start(ctx); err != nil {
+//		return err
+//	}
+//	defer gateway.Stop(ctx)
+package gatewayd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/api"
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/events"
+	"github.com/gatewayd-io/gatewayd/logging"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/gatewayd-io/gatewayd/recorder"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"github.com/go-co-op/gocron"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultUsageReportURL is the address the usage statistics are reported to.
+const DefaultUsageReportURL = "localhost:59091"
+
+// Options are the options of a GatewayD instance that are not in the configuration.
+type Options struct {
+	// DevMode enables the development mode for plugin development.
+	DevMode bool
+	// UsageReport enables reporting the usage statistics to UsageReportURL.
+	UsageReport    bool
+	UsageReportURL string
+	// FaultInjection allows the proxies to inject the faults of their config.
+	FaultInjection bool
+}
+
+// GatewayD is a GatewayD instance: the plugins, pools, proxies and servers created
+// from the configuration, and the services around them.
+type GatewayD struct {
+	Options Options
+	Config  *config.Config
+
+	Loggers        map[string]zerolog.Logger
+	Pools          map[string]*pool.Pool
+	Clients        map[string]*config.Client
+	Proxies        map[string]*network.Proxy
+	Servers        map[string]*network.Server
+	PluginRegistry *plugin.Registry
+	Maintenance    *network.Maintenance
+
+	ctx                  context.Context //nolint:containedctx
+	logger               zerolog.Logger
+	api                  *api.API
+	dataAPI              *network.DataAPI
+	metricsMerger        *metrics.Merger
+	metricsServer        atomic.Pointer[http.Server]
+	otlpExporter         *metrics.OTLPExporter
+	eventBus             *events.Bus
+	usageTracker         *network.UsageTracker
+	sharedLimits         *network.SharedState
+	queryStats           *network.QueryStats
+	errorStats           *network.ErrorStats
+	logTail              *logging.Tail
+	clusterMonitors      map[string]*network.ClusterMonitor
+	healthCheckScheduler *gocron.Scheduler
+
+	mu       sync.Mutex
+	started  bool
+	stopOnce sync.Once
+	stopped  chan struct{}
+	err      error
+}
+
+// New creates a GatewayD instance and its loggers from the configuration, which must
+// be initialized. Nothing else is created or started until Start is called.
+func New(ctx context.Context, conf *config.Config, options Options) *GatewayD {
+	if options.UsageReportURL == "" {
+		options.UsageReportURL = DefaultUsageReportURL
+	}
+
+	gatewayd := &GatewayD{
+		Options:              options,
+		Config:               conf,
+		Loggers:              make(map[string]zerolog.Logger),
+		Pools:                make(map[string]*pool.Pool),
+		Clients:              make(map[string]*config.Client),
+		Proxies:              make(map[string]*network.Proxy),
+		Servers:              make(map[string]*network.Server),
+		Maintenance:          network.NewMaintenance(),
+		clusterMonitors:      make(map[string]*network.ClusterMonitor),
+		ctx:                  ctx,
+		healthCheckScheduler: gocron.NewScheduler(time.UTC),
+		stopped:              make(chan struct{}),
+	}
+
+	// The dashboard shows the top queries and the tail of the default logger.
+	if dashboard := conf.Global.API.Dashboard; conf.Global.API.Enabled && dashboard.Enabled {
+		gatewayd.queryStats = network.NewQueryStats(dashboard.TopQueries)
+		gatewayd.logTail = logging.NewTail(dashboard.LogLines)
+	}
+	// The admin API reports the error responses of the databases.
+	if reported := conf.Global.API.Errors; conf.Global.API.Enabled && reported.Enabled {
+		gatewayd.errorStats = network.NewErrorStats(reported.Limit)
+	}
+
+	// Create and initialize loggers from the config.
+	for name, cfg := range conf.Global.Loggers {
+		var tail *logging.Tail
+		if name == config.Default {
+			tail = gatewayd.logTail
+		}
+		gatewayd.Loggers[name] = logging.NewLogger(ctx, logging.LoggerConfig{
+			Output: cfg.GetOutput(),
+			Level: config.If[zerolog.Level](
+				config.Exists[string, zerolog.Level](config.LogLevels, cfg.Level),
+				config.LogLevels[cfg.Level],
+				config.LogLevels[config.DefaultLogLevel],
+			),
+			TimeFormat: config.If[string](
+				config.Exists[string, string](config.TimeFormats, cfg.TimeFormat),
+				config.TimeFormats[cfg.TimeFormat],
+				config.TimeFormats[config.DefaultTimeFormat],
+			),
+			ConsoleTimeFormat: config.If[string](
+				config.Exists[string, string](
+					config.ConsoleTimeFormats, cfg.ConsoleTimeFormat),
+				config.ConsoleTimeFormats[cfg.ConsoleTimeFormat],
+				config.ConsoleTimeFormats[config.DefaultConsoleTimeFormat],
+			),
+			NoColor:        cfg.NoColor,
+			FileName:       cfg.FileName,
+			MaxSize:        cfg.MaxSize,
+			MaxBackups:     cfg.MaxBackups,
+			MaxAge:         cfg.MaxAge,
+			Compress:       cfg.Compress,
+			LocalTime:      cfg.LocalTime,
+			SyslogPriority: cfg.GetSyslogPriority(),
+			RSyslogNetwork: cfg.RSyslogNetwork,
+			RSyslogAddress: cfg.RSyslogAddress,
+			Tail:           tail,
+		})
+	}
+
+	// Set the default logger.
+	gatewayd.logger = gatewayd.Loggers[config.Default]
+
+	return gatewayd
+}
+
+// Logger returns the default logger.
+func (g *GatewayD) Logger() zerolog.Logger {
+	return g.logger
+}
+
+// Start loads the plugins, creates the pools, proxies and servers, and starts the
+// servers and the services around them in the background. The instance is stopped
+// if it fails to start.
+func (g *GatewayD) Start(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.started {
+		return gerr.ErrAlreadyStarted
+	}
+	g.started = true
+	g.ctx = ctx
+
+	if g.Options.DevMode {
+		g.logger.Warn().Msg(
+			"Running GatewayD in development mode (not recommended for production)")
+	}
+
+	g.tuneRuntime()
+	recorder.Configure(g.Config.Global.FlightRecorder.Size, g.Config.Global.FlightRecorder.DumpDir)
+	g.startEventBus()
+	g.startUsageTracker()
+	g.startSharedLimits()
+	g.startPluginRegistry()
+	if err := g.preflightPlugins(ctx); err != nil {
+		g.shutdown(nil, err)
+		return err
+	}
+	g.runOnConfigLoadedHooks(ctx, g.Config)
+	if err := g.expandPortRanges(); err != nil {
+		g.shutdown(nil, err)
+		return err
+	}
+	if err := g.configureMetricLabels(); err != nil {
+		g.shutdown(nil, err)
+		return err
+	}
+	if err := logging.ConfigureSampling(g.Config.Global.Sampling.Logs); err != nil {
+		g.logger.Error().Err(err).Msg("Failed to configure the log sampling")
+		g.shutdown(nil, err)
+		return err
+	}
+	g.startOTLPExporter()
+	go g.startMetricsServer(g.Config.Global.Metrics[config.Default])
+	g.runOnNewLoggerHooks()
+
+	if err := g.createPools(); err != nil {
+		g.shutdown(nil, err)
+		return err
+	}
+	if err := g.createProxies(); err != nil {
+		g.shutdown(nil, err)
+		return err
+	}
+	g.createServers()
+	g.restoreState()
+
+	g.startAPI()
+	if g.Options.UsageReport {
+		go g.reportUsage()
+	}
+
+	g.startServers()
+	g.startDataAPI()
+	go g.reportRun()
+
+	return nil
+}
+
+// expandPortRanges expands the servers with a port range into a server per tenant,
+// which logs with the logger of the expanded server, unless it has its own.
+func (g *GatewayD) expandPortRanges() error {
+	tenants, err := g.Config.Global.ExpandPortRanges()
+	if err != nil {
+		g.logger.Error().Err(err).Msg("Failed to expand the port ranges of the servers")
+		return err
+	}
+
+	for tenant, server := range tenants {
+		if _, exists := g.Loggers[tenant]; !exists {
+			g.Loggers[tenant] = g.Loggers[server]
+		}
+	}
+	if len(tenants) > 0 {
+		g.logger.Info().Int("tenants", len(tenants)).Msg("Expanded the port ranges of the servers")
+	}
+
+	return nil
+}
+
+// Stop stops the servers gracefully, after draining them, and the services around
+// them. It can be called more than once.
+func (g *GatewayD) Stop(ctx context.Context) {
+	g.StopWithSignal(ctx, nil)
+}
+
+// StopWithSignal stops GatewayD like Stop, and passes the signal that stopped it to
+// the OnSignal hooks of the plugins.
+func (g *GatewayD) StopWithSignal(ctx context.Context, sig os.Signal) {
+	g.mu.Lock()
+	g.ctx = ctx
+	g.mu.Unlock()
+
+	g.shutdown(sig, nil)
+}
+
+// Wait blocks until GatewayD is stopped, and returns the error that stopped it, if a
+// server failed.
+func (g *GatewayD) Wait() error {
+	<-g.stopped
+	return g.err
+}
+
+// Done returns a channel that is closed when GatewayD is stopped.
+func (g *GatewayD) Done() <-chan struct{} {
+	return g.stopped
+}
+
+// Reload applies the configuration, which must be initialized, after running the
+// OnConfigLoaded hooks on it. Only the hook tracing and the rules of the firewalls
+// are applied to the running instance, and the other settings take effect when it is
+// started again. The configuration is not applied if the firewall rules are invalid.
+func (g *GatewayD) Reload(ctx context.Context, conf *config.Config) error {
+	_, span := otel.Tracer(config.TracerName).Start(ctx, "Reload config")
+	defer span.End()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.PluginRegistry != nil {
+		g.runOnConfigLoadedHooks(ctx, conf)
+	}
+
+	// Check all the firewall rules before applying them, so that invalid rules don't
+	// leave the instance half reloaded.
+	firewallRules := make(map[string][]config.FirewallRule, len(g.Proxies))
+	for name, proxy := range g.Proxies {
+		var rules []config.FirewallRule
+		if proxyConfig := conf.Global.Proxies[name]; proxyConfig != nil {
+			rules = proxyConfig.Firewall.Rules
+		}
+		if proxy.Firewall == nil {
+			if len(rules) > 0 {
+				g.logger.Warn().Str("name", name).Msg(
+					"The firewall will be enabled when GatewayD is restarted")
+			}
+			continue
+		}
+		if _, err := network.NewFirewall(rules, g.logger); err != nil {
+			span.RecordError(err)
+			return err
+		}
+		firewallRules[name] = rules
+	}
+	for name, rules := range firewallRules {
+		if err := g.Proxies[name].Firewall.Update(rules); err != nil {
+			span.RecordError(err)
+			return err
+		}
+	}
+
+	if g.PluginRegistry != nil {
+		g.PluginRegistry.Tracer.Configure(
+			conf.Plugin.HookTrace.Enabled, conf.Plugin.HookTrace.SampleRate)
+		// The hook trace of the config replaces the one changed at runtime.
+		if fileName := conf.Global.State.FileName; fileName != "" {
+			if err := api.UpdateState(fileName, func(state *api.State) {
+				state.HookTrace = nil
+			}); err != nil {
+				g.logger.Error().Err(err).Str("fileName", fileName).Msg(
+					"Failed to remove the hook trace from the state")
+			}
+		}
+	}
+
+	g.Config = conf
+	if g.api != nil {
+		g.api.Config = conf
+	}
+
+	span.AddEvent("Reloaded config", trace.WithAttributes(
+		attribute.Int("proxies", len(g.Proxies)),
+	))
+	g.logger.Info().Msg("Reloaded the configuration")
+	events.Publish(events.ConfigReloaded, "Global config was reloaded", nil)
+
+	return nil
+}
+
+// shutdown stops GatewayD once, after a signal, a call to Stop or a failure.
+func (g *GatewayD) shutdown(sig os.Signal, failure error) {
+	g.stopOnce.Do(func() {
+		g.stop(sig)
+		g.err = failure
+		close(g.stopped)
+	})
+}
+
+func (g *GatewayD) stop(sig os.Signal) {
+	_, span := otel.Tracer(config.TracerName).Start(g.ctx, "Shutdown server")
+	signal := "unknown"
+	if sig != nil {
+		signal = sig.String()
+	}
+	logger := g.logger
+
+	logger.Info().Msg("Notifying the plugins that the server is shutting down")
+	if g.PluginRegistry != nil {
+		pluginTimeoutCtx, cancel := context.WithTimeout(
+			context.Background(), g.Config.Plugin.Timeout)
+		defer cancel()
+
+		//nolint:contextcheck
+		_, err := g.PluginRegistry.RunTyped(
+			pluginTimeoutCtx,
+			&sdk.SignalMessage{Signal: signal},
+			v1.HookName_HOOK_NAME_ON_SIGNAL,
+		)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to run OnSignal hooks")
+			span.RecordError(err)
+		}
+
+		// The plugins flush their caches or deregister from discovery before the
+		// connections are drained.
+		deadline := time.Now().Add(g.PluginRegistry.ShutdownTimeout)
+		shuttingDownCtx, cancelShuttingDown := context.WithDeadline(context.Background(), deadline)
+		defer cancelShuttingDown()
+		//nolint:contextcheck
+		_, err = g.PluginRegistry.Run(
+			shuttingDownCtx,
+			map[string]interface{}{
+				"signal":   signal,
+				"deadline": deadline.UTC().Format(time.RFC3339),
+			},
+			sdk.OnShuttingDown,
+		)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to run OnShuttingDown hooks")
+			span.RecordError(err)
+		}
+	}
+
+	logger.Info().Msg("GatewayD is shutting down")
+	span.AddEvent("GatewayD is shutting down", trace.WithAttributes(
+		attribute.String("signal", signal),
+	))
+
+	// The data API is stopped first, as its connections would be drained otherwise.
+	if g.dataAPI != nil {
+		//nolint:contextcheck
+		if err := g.dataAPI.Shutdown(context.Background()); err != nil {
+			logger.Error().Err(err).Msg("Failed to stop the data API")
+			span.RecordError(err)
+		} else {
+			logger.Info().Msg("Stopped the data API")
+			span.AddEvent("Stopped the data API")
+		}
+	}
+
+	// Let the open connections finish before they are closed, e.g. when the container
+	// is stopped. The servers are drained at the same time, to fit in the stop timeout.
+	var drained sync.WaitGroup
+	for name, server := range g.Servers {
+		drained.Add(1)
+		go func(name string, server *network.Server) {
+			defer drained.Done()
+			logger.Info().Str("name", name).Str("timeout", server.DrainTimeout.String()).Msg(
+				"Draining server")
+			if remaining := server.Drain(); remaining > 0 {
+				logger.Warn().Str("name", name).Int("connections", remaining).Msg(
+					"Closing the connections still open after the drain timeout")
+			}
+		}(name, server)
+	}
+	drained.Wait()
+	span.AddEvent("Drained servers")
+	if g.healthCheckScheduler != nil {
+		g.healthCheckScheduler.Stop()
+		g.healthCheckScheduler.Clear()
+		logger.Info().Msg("Stopped health check scheduler")
+		span.AddEvent("Stopped health check scheduler")
+	}
+	if g.metricsMerger != nil {
+		g.metricsMerger.Stop()
+		logger.Info().Msg("Stopped metrics merger")
+		span.AddEvent("Stopped metrics merger")
+	}
+	if g.otlpExporter != nil {
+		g.otlpExporter.Stop()
+		logger.Info().Msg("Stopped OTLP metrics exporter")
+		span.AddEvent("Stopped OTLP metrics exporter")
+	}
+	if metricsServer := g.metricsServer.Load(); metricsServer != nil {
+		//nolint:contextcheck
+		if err := metricsServer.Shutdown(context.Background()); err != nil {
+			logger.Error().Err(err).Msg("Failed to stop metrics server")
+			span.RecordError(err)
+		} else {
+			logger.Info().Msg("Stopped metrics server")
+			span.AddEvent("Stopped metrics server")
+		}
+	}
+	for name, server := range g.Servers {
+		logger.Info().Str("name", name).Msg("Stopping server")
+		server.Shutdown() //nolint:contextcheck
+		span.AddEvent("Stopped server")
+	}
+	logger.Info().Msg("Stopped all servers")
+	if g.PluginRegistry != nil {
+		g.PluginRegistry.Shutdown()
+		logger.Info().Msg("Stopped plugin registry")
+		span.AddEvent("Stopped plugin registry")
+	}
+	for _, monitor := range g.clusterMonitors {
+		monitor.Stop()
+	}
+	if g.usageTracker != nil {
+		g.usageTracker.Stop()
+		logger.Info().Msg("Stopped usage tracker")
+		span.AddEvent("Stopped usage tracker")
+	}
+	if g.sharedLimits != nil {
+		g.sharedLimits.Stop()
+		logger.Info().Msg("Stopped syncing the shared limits")
+		span.AddEvent("Stopped syncing the shared limits")
+	}
+	if g.eventBus != nil {
+		events.SetDefault(nil)
+		g.eventBus.Stop()
+		logger.Info().Msg("Stopped event bus")
+		span.AddEvent("Stopped event bus")
+	}
+	span.End()
+}
@@ -0,0 +1,132 @@
+package gatewayd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/api"
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestConfig loads the default global config, without the APIs and the metrics
+// server, with the clients connecting to the address and no plugins.
+func newTestConfig(t *testing.T, clientAddress string) *config.Config {
+	t.Helper()
+
+	pluginConfigFile := filepath.Join(t.TempDir(), config.PluginsConfigFilename)
+	require.NoError(t, os.WriteFile(pluginConfigFile, []byte("plugins: []\n"), 0o600))
+
+	t.Setenv("GATEWAYD_CLIENTS_DEFAULT_ADDRESS", clientAddress)
+	t.Setenv("GATEWAYD_CLIENTS_DEFAULT_RETRIES", "0")
+	t.Setenv("GATEWAYD_POOLS_DEFAULT_SIZE", "2")
+	t.Setenv("GATEWAYD_SERVERS_DEFAULT_ADDRESS", "127.0.0.1:15439")
+	t.Setenv("GATEWAYD_SERVERS_DEFAULT_DRAINTIMEOUT", "100ms")
+	t.Setenv("GATEWAYD_METRICS_DEFAULT_ENABLED", "false")
+	t.Setenv("GATEWAYD_API_ENABLED", "false")
+
+	ctx := context.Background()
+	conf := config.NewConfig(ctx, "../"+config.GlobalConfigFilename, pluginConfigFile)
+	conf.InitConfig(ctx)
+	return conf
+}
+
+// TestGatewayD tests that GatewayD starts, reloads its config and stops.
+func TestGatewayD(t *testing.T) {
+	// The clients only need to connect to the database.
+	database, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer database.Close()
+	go func() {
+		for {
+			conn, err := database.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	ctx := context.Background()
+	runReportFile := filepath.Join(t.TempDir(), "report.json")
+	conf := newTestConfig(t, database.Addr().String())
+	// The config is unmarshalled again after the OnConfigLoaded hooks.
+	require.NoError(t, conf.GlobalKoanf.Set("runReport.fileName", runReportFile))
+	// The maintenance mode enabled before the restart is restored.
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, api.UpdateState(stateFile, func(state *api.State) {
+		state.Maintenance = &network.MaintenanceStatus{Enabled: true, Message: "upgrading"}
+	}))
+	require.NoError(t, conf.GlobalKoanf.Set("state.fileName", stateFile))
+	gateway := New(ctx, conf, Options{})
+	require.NoError(t, gateway.Start(ctx))
+	assert.Equal(t, "upgrading", gateway.Maintenance.Status().Message)
+	assert.ErrorIs(t, gateway.Start(ctx), gerr.ErrAlreadyStarted)
+
+	assert.Equal(t, 2, gateway.Pools[config.Default].Size())
+	require.Contains(t, gateway.Servers, config.Default)
+	assert.Eventually(t, gateway.Servers[config.Default].IsRunning, time.Second, 10*time.Millisecond)
+
+	// The run report is written once the servers are listening.
+	var report RunReport
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(runReportFile)
+		return err == nil && json.Unmarshal(data, &report) == nil
+	}, time.Second, 10*time.Millisecond)
+	require.Len(t, report.Servers, 1)
+	assert.Equal(t, []ListenerReport{{Network: "tcp", Address: "127.0.0.1:15439"}},
+		report.Servers[0].Listeners)
+	assert.Equal(t, []PoolReport{{Name: config.Default, Size: 2, Capacity: 2}}, report.Pools)
+	// Only the built-in plugins are loaded.
+	assert.Equal(t, []PluginReport{
+		{Name: plugin.BuiltinMetricsName, Version: config.Version},
+		{Name: plugin.BuiltinQueryLoggerName, Version: config.Version},
+	}, report.Plugins)
+	assert.Contains(t, report.Features, "builtinPlugins")
+	assert.NotContains(t, report.Features, "api")
+
+	conf = newTestConfig(t, database.Addr().String())
+	conf.Plugin.HookTrace.Enabled = true
+	conf.Plugin.HookTrace.SampleRate = 0.5
+	require.NoError(t, gateway.Reload(ctx, conf))
+	enabled, sampleRate := gateway.PluginRegistry.Tracer.Status()
+	assert.True(t, enabled)
+	assert.Equal(t, 0.5, sampleRate)
+	assert.Same(t, conf, gateway.Config)
+
+	gateway.Stop(ctx)
+	gateway.Stop(ctx)
+	select {
+	case <-gateway.Done():
+	case <-time.After(time.Second):
+		t.Fatal("GatewayD didn't stop")
+	}
+	assert.NoError(t, gateway.Wait())
+}
+
+// TestGatewayDFailsToStart tests that GatewayD is stopped if it can't connect to the
+// database.
+func TestGatewayDFailsToStart(t *testing.T) {
+	// Get a free port that nothing listens on.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	ctx := context.Background()
+	gateway := New(ctx, newTestConfig(t, address), Options{})
+	err = gateway.Start(ctx)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, gerr.ErrCreateClientFailed))
+	assert.ErrorIs(t, gateway.Wait(), gerr.ErrCreateClientFailed)
+}
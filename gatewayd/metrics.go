@@ -0,0 +1,228 @@
+package gatewayd
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/NYTimes/gziphandler"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/getsentry/sentry-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+)
+
+// startOTLPExporter pushes the metrics to an OpenTelemetry collector if enabled.
+func (g *GatewayD) startOTLPExporter() {
+	metricsConfig := g.Config.Global.Metrics[config.Default]
+	if metricsConfig == nil || !metricsConfig.OTLPEnabled {
+		return
+	}
+
+	_, span := otel.Tracer(config.TracerName).Start(g.ctx, "Start OTLP metrics exporter")
+	defer span.End()
+
+	exporter, err := metrics.NewOTLPExporter(
+		g.ctx,
+		prometheus.DefaultGatherer,
+		metricsConfig.OTLPProtocol,
+		metricsConfig.OTLPEndpoint,
+		metricsConfig.OTLPInsecure,
+		config.If[time.Duration](
+			metricsConfig.OTLPInterval > 0,
+			metricsConfig.OTLPInterval,
+			config.DefaultOTLPInterval,
+		),
+		g.logger,
+	)
+	if err != nil {
+		g.logger.Error().Err(err).Msg("Failed to create the OTLP metrics exporter")
+		span.RecordError(err)
+		return
+	}
+	g.otlpExporter = exporter
+	g.otlpExporter.Start()
+}
+
+// configureMetricLabels configures the label templates of the connection metrics.
+func (g *GatewayD) configureMetricLabels() error {
+	metricsConfig := g.Config.Global.Metrics[config.Default]
+	if metricsConfig == nil {
+		return nil
+	}
+	if err := metrics.ConfigureLabels(metricsConfig.Labels); err != nil {
+		g.logger.Error().Err(err).Msg("Failed to configure the labels of the metrics")
+		return err
+	}
+	return nil
+}
+
+// startMetricsServer starts the metrics server if enabled, and blocks until it is
+// stopped.
+//
+// TODO: Start multiple metrics servers. For now, only one default is supported.
+// I should first find a use case for those multiple metrics servers.
+func (g *GatewayD) startMetricsServer(metricsConfig *config.Metrics) {
+	_, span := otel.Tracer(config.TracerName).Start(g.ctx, "Start metrics server")
+	defer span.End()
+
+	logger := g.logger
+	metricsMerger := g.metricsMerger
+
+	if metricsConfig == nil || !metricsConfig.Enabled {
+		logger.Info().Msg("Metrics server is disabled")
+		return
+	}
+
+	scheme := "http://"
+	if metricsConfig.KeyFile != "" && metricsConfig.CertFile != "" {
+		scheme = "https://"
+	}
+
+	fqdn, err := url.Parse(scheme + metricsConfig.Address)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse metrics address")
+		span.RecordError(err)
+		return
+	}
+
+	address, err := url.JoinPath(fqdn.String(), metricsConfig.Path)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse metrics path")
+		span.RecordError(err)
+		return
+	}
+
+	// Merge the metrics from the plugins with the ones from GatewayD.
+	mergedMetricsHandler := func(next http.Handler) http.Handler {
+		handler := func(responseWriter http.ResponseWriter, request *http.Request) {
+			if _, err := responseWriter.Write(metricsMerger.OutputMetrics); err != nil {
+				logger.Error().Err(err).Msg("Failed to write metrics")
+				span.RecordError(err)
+				sentry.CaptureException(err)
+			}
+			// The WriteHeader method intentionally does nothing, to prevent a bug
+			// in the merging metrics that causes the headers to be written twice,
+			// which results in an error: "http: superfluous response.WriteHeader call".
+			next.ServeHTTP(
+				&metrics.HeaderBypassResponseWriter{
+					ResponseWriter: responseWriter,
+				},
+				request)
+		}
+		return http.HandlerFunc(handler)
+	}
+
+	handler := func() http.Handler {
+		return promhttp.InstrumentMetricHandler(
+			prometheus.DefaultRegisterer,
+			promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
+				DisableCompression: true,
+				// The exemplars are only exposed in the OpenMetrics format.
+				EnableOpenMetrics: true,
+			}),
+		)
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(responseWriter http.ResponseWriter, request *http.Request) {
+		// Serve a static page with a link to the metrics endpoint.
+		if _, err := responseWriter.Write([]byte(fmt.Sprintf(
+			`<html><head><title>GatewayD Prometheus Metrics Server</title></head><body><a href="%s">Metrics</a></body></html>`,
+			address,
+		))); err != nil {
+			logger.Error().Err(err).Msg("Failed to write metrics")
+			span.RecordError(err)
+			sentry.CaptureException(err)
+		}
+	})
+
+	if g.Config.Plugin.EnableMetricsMerger && metricsMerger != nil {
+		handler = mergedMetricsHandler(handler)
+	}
+
+	readHeaderTimeout := config.If[time.Duration](
+		metricsConfig.ReadHeaderTimeout > 0,
+		metricsConfig.ReadHeaderTimeout,
+		config.DefaultReadHeaderTimeout,
+	)
+
+	// Check if the metrics server is already running before registering the handler.
+	if _, err = http.Get(address); err != nil { //nolint:gosec
+		// The timeout handler limits the nested handlers from running for too long.
+		mux.Handle(
+			metricsConfig.Path,
+			http.TimeoutHandler(
+				gziphandler.GzipHandler(handler),
+				readHeaderTimeout,
+				"The request timed out while fetching the metrics",
+			),
+		)
+	} else {
+		logger.Warn().Msg("Metrics server is already running, consider changing the port")
+		span.RecordError(err)
+	}
+
+	// Create a new metrics server.
+	timeout := config.If[time.Duration](
+		metricsConfig.Timeout > 0,
+		metricsConfig.Timeout,
+		config.DefaultMetricsServerTimeout,
+	)
+	metricsServer := &http.Server{
+		Addr:              metricsConfig.Address,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       timeout,
+		WriteTimeout:      timeout,
+		IdleTimeout:       timeout,
+	}
+
+	logger.Info().Fields(map[string]interface{}{
+		"address":           address,
+		"timeout":           timeout.String(),
+		"readHeaderTimeout": readHeaderTimeout.String(),
+	}).Msg("Metrics are exposed")
+
+	if metricsConfig.CertFile != "" && metricsConfig.KeyFile != "" {
+		// Set up TLS.
+		metricsServer.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS13,
+			CurvePreferences: []tls.CurveID{
+				tls.CurveP521,
+				tls.CurveP384,
+				tls.CurveP256,
+			},
+			PreferServerCipherSuites: true,
+			CipherSuites: []uint16{
+				tls.TLS_AES_128_GCM_SHA256,
+				tls.TLS_AES_256_GCM_SHA384,
+				tls.TLS_CHACHA20_POLY1305_SHA256,
+			},
+		}
+		metricsServer.TLSNextProto = make(
+			map[string]func(*http.Server, *tls.Conn, http.Handler), 0)
+		logger.Debug().Msg("Metrics server is running with TLS")
+		g.metricsServer.Store(metricsServer)
+
+		// Start the metrics server with TLS.
+		if err = metricsServer.ListenAndServeTLS(
+			metricsConfig.CertFile, metricsConfig.KeyFile); !errors.Is(err, http.ErrServerClosed) {
+			logger.Error().Err(err).Msg("Failed to start metrics server")
+			span.RecordError(err)
+		}
+	} else {
+		g.metricsServer.Store(metricsServer)
+
+		// Start the metrics server without TLS.
+		if err = metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			logger.Error().Err(err).Msg("Failed to start metrics server")
+			span.RecordError(err)
+		}
+	}
+}
@@ -0,0 +1,616 @@
+package gatewayd
+
+import (
+	"context"
+	"crypto/tls"
+	"runtime"
+	"strconv"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/api"
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	usage "github.com/gatewayd-io/gatewayd/usagereport/v1"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// createPools creates the pools and fills them with connected clients.
+func (g *GatewayD) createPools() error {
+	conf := g.Config
+	pools := g.Pools
+	clients := g.Clients
+
+	_, span := otel.Tracer(config.TracerName).Start(g.ctx, "Create pools and clients")
+	defer span.End()
+
+	for name, cfg := range conf.Global.Pools {
+		logger := g.Loggers[name]
+		// Check if the pool size is greater than zero.
+		currentPoolSize := config.If[int](
+			cfg.Size > 0,
+			// Check if the pool size is greater than the minimum pool size.
+			config.If[int](
+				cfg.Size > config.MinimumPoolSize,
+				cfg.Size,
+				config.MinimumPoolSize,
+			),
+			config.DefaultPoolSize,
+		)
+		pools[name] = pool.NewPool(g.ctx, currentPoolSize)
+
+		span.AddEvent("Create pool", trace.WithAttributes(
+			attribute.String("name", name),
+			attribute.Int("size", currentPoolSize),
+		))
+
+		// Get client config from the config file.
+		if clientConfig, ok := conf.Global.Clients[name]; !ok {
+			// This ensures that the default client config is used if the pool name is not
+			// found in the clients section.
+			clients[name] = conf.Global.Clients[config.Default]
+		} else {
+			// Merge the default client config with the one from the pool.
+			clients[name] = clientConfig
+		}
+
+		// Fill the missing and zero values with the default ones.
+		clients[name].TCPKeepAlivePeriod = config.If[time.Duration](
+			clients[name].TCPKeepAlivePeriod > 0,
+			clients[name].TCPKeepAlivePeriod,
+			config.DefaultTCPKeepAlivePeriod,
+		)
+		clients[name].ReceiveDeadline = config.If[time.Duration](
+			clients[name].ReceiveDeadline > 0,
+			clients[name].ReceiveDeadline,
+			config.DefaultReceiveDeadline,
+		)
+		clients[name].ReceiveTimeout = config.If[time.Duration](
+			clients[name].ReceiveTimeout > 0,
+			clients[name].ReceiveTimeout,
+			config.DefaultReceiveTimeout,
+		)
+		clients[name].SendDeadline = config.If[time.Duration](
+			clients[name].SendDeadline > 0,
+			clients[name].SendDeadline,
+			config.DefaultSendDeadline,
+		)
+		clients[name].ReceiveChunkSize = config.If[int](
+			clients[name].ReceiveChunkSize > 0,
+			clients[name].ReceiveChunkSize,
+			config.DefaultChunkSize,
+		)
+		clients[name].DialTimeout = config.If[time.Duration](
+			clients[name].DialTimeout > 0,
+			clients[name].DialTimeout,
+			config.DefaultDialTimeout,
+		)
+
+		// Connect the clients to the member of the cluster of their role, if the
+		// primary is discovered.
+		monitor := g.clusterMonitor(clients[name], logger)
+
+		// Add clients to the pool.
+		for i := 0; i < currentPoolSize; i++ {
+			clientConfig := clients[name]
+			if monitor != nil {
+				if target := monitor.Target(clientConfig.PrimaryDiscovery.Role); target != "" {
+					targeted := *clientConfig
+					targeted.Address = target
+					clientConfig = &targeted
+				}
+			}
+			client := network.NewClient(
+				g.ctx, clientConfig, logger,
+				network.NewClientRetry(clientConfig, logger),
+			)
+
+			if client == nil {
+				logger.Error().Msg("Failed to create client, please check the configuration")
+				return gerr.ErrCreateClientFailed
+			}
+
+			eventOptions := trace.WithAttributes(
+				attribute.String("name", name),
+				attribute.String("network", client.Network),
+				attribute.String("address", client.Address),
+				attribute.Int("receiveChunkSize", client.ReceiveChunkSize),
+				attribute.String("receiveDeadline", client.ReceiveDeadline.String()),
+				attribute.String("receiveTimeout", client.ReceiveTimeout.String()),
+				attribute.String("sendDeadline", client.SendDeadline.String()),
+				attribute.String("dialTimeout", client.DialTimeout.String()),
+				attribute.Bool("tcpKeepAlive", client.TCPKeepAlive),
+				attribute.String("tcpKeepAlivePeriod", client.TCPKeepAlivePeriod.String()),
+				attribute.String("localAddress", client.LocalAddr()),
+				attribute.String("remoteAddress", client.RemoteAddr()),
+				attribute.Int("retries", clientConfig.Retries),
+				attribute.String("backoff", client.Retry().Backoff.String()),
+				attribute.Float64("backoffMultiplier", clientConfig.BackoffMultiplier),
+				attribute.Bool("disableBackoffCaps", clientConfig.DisableBackoffCaps),
+			)
+			if client.ID != "" {
+				eventOptions = trace.WithAttributes(
+					attribute.String("id", client.ID),
+				)
+			}
+
+			span.AddEvent("Create client", eventOptions)
+
+			clientCfg := map[string]interface{}{
+				"id":                 client.ID,
+				"network":            client.Network,
+				"address":            client.Address,
+				"receiveChunkSize":   client.ReceiveChunkSize,
+				"receiveDeadline":    client.ReceiveDeadline.String(),
+				"receiveTimeout":     client.ReceiveTimeout.String(),
+				"sendDeadline":       client.SendDeadline.String(),
+				"dialTimeout":        client.DialTimeout.String(),
+				"tcpKeepAlive":       client.TCPKeepAlive,
+				"tcpKeepAlivePeriod": client.TCPKeepAlivePeriod.String(),
+				"localAddress":       client.LocalAddr(),
+				"remoteAddress":      client.RemoteAddr(),
+				"retries":            clientConfig.Retries,
+				"backoff":            client.Retry().Backoff.String(),
+				"backoffMultiplier":  clientConfig.BackoffMultiplier,
+				"disableBackoffCaps": clientConfig.DisableBackoffCaps,
+			}
+			if err := g.runHook(clientCfg, v1.HookName_HOOK_NAME_ON_NEW_CLIENT); err != nil {
+				logger.Error().Err(err).Msg("Failed to run OnNewClient hooks")
+				span.RecordError(err)
+			}
+
+			if err := pools[name].Put(client.ID, client); err != nil {
+				logger.Error().Err(err).Msg("Failed to add client to the pool")
+				span.RecordError(err)
+			}
+		}
+
+		// Verify that the pool is properly populated.
+		logger.Info().Fields(map[string]interface{}{
+			"name":  name,
+			"count": strconv.Itoa(pools[name].Size()),
+		}).Msg("There are clients available in the pool")
+
+		if pools[name].Size() != currentPoolSize {
+			logger.Error().Msg(
+				"The pool size is incorrect, either because " +
+					"the clients cannot connect due to no network connectivity " +
+					"or the server is not running. exiting...")
+			return gerr.ErrInitializePoolFailed
+		}
+
+		if err := g.runTypedHook(
+			&sdk.PoolMessage{Name: name, Size: currentPoolSize},
+			v1.HookName_HOOK_NAME_ON_NEW_POOL,
+		); err != nil {
+			logger.Error().Err(err).Msg("Failed to run OnNewPool hooks")
+			span.RecordError(err)
+		}
+	}
+
+	return nil
+}
+
+// clusterMonitor returns the monitor of the cluster of the client config, if its
+// primary is discovered, which is started on the first call. The config groups of the
+// same cluster share its monitor.
+func (g *GatewayD) clusterMonitor(
+	clientConfig *config.Client, logger zerolog.Logger,
+) *network.ClusterMonitor {
+	if clientConfig == nil || !clientConfig.PrimaryDiscovery.Enabled {
+		return nil
+	}
+
+	key := network.ClusterKey(clientConfig.PrimaryDiscovery)
+	if monitor, ok := g.clusterMonitors[key]; ok {
+		return monitor
+	}
+	discovery := clientConfig.PrimaryDiscovery
+	discovery.Interval = config.If[time.Duration](
+		discovery.Interval > 0, discovery.Interval, config.DefaultPrimaryDiscoveryInterval)
+	monitor := network.NewClusterMonitor(discovery, clientConfig.DialTimeout, logger)
+	monitor.Start(g.ctx)
+	g.clusterMonitors[key] = monitor
+	return monitor
+}
+
+// createProxies creates the proxies of the pools.
+func (g *GatewayD) createProxies() error {
+	conf := g.Config
+	proxies := g.Proxies
+
+	_, span := otel.Tracer(config.TracerName).Start(g.ctx, "Create proxies")
+	defer span.End()
+
+	for name, cfg := range conf.Global.Proxies {
+		logger := g.Loggers[name]
+		clientConfig := g.Clients[name]
+		// Fill the missing and zero value with the default one.
+		cfg.HealthCheckPeriod = config.If[time.Duration](
+			cfg.HealthCheckPeriod > 0,
+			cfg.HealthCheckPeriod,
+			config.DefaultHealthCheckPeriod,
+		)
+
+		proxies[name] = network.NewProxy(
+			g.ctx,
+			g.Pools[name],
+			g.PluginRegistry,
+			cfg.Elastic,
+			cfg.ReuseElasticClients,
+			cfg.HealthCheckPeriod,
+			clientConfig,
+			logger,
+			conf.Plugin.Timeout,
+		)
+		proxies[name].Name = name
+		proxies[name].Usage = g.usageTracker
+		if g.sharedLimits != nil {
+			proxies[name].Limits = g.sharedLimits.Limits(name)
+		}
+		proxies[name].QueryStats = g.queryStats
+		proxies[name].ErrorStats = g.errorStats
+		if err := proxies[name].Configure(cfg); err != nil {
+			logger.Error().Err(err).Str("name", name).Msg(
+				"Failed to configure the proxy")
+			return err
+		}
+		if proxies[name].Faults != nil {
+			if g.Options.FaultInjection {
+				logger.Warn().Str("name", name).Msg(
+					"Injecting faults in the traffic of the proxy (not for production)")
+			} else {
+				logger.Warn().Str("name", name).Msg(
+					"Fault injection is only enabled with the --fault-injection flag, ignoring it")
+				proxies[name].Faults = nil
+			}
+		}
+		if monitor := g.clusterMonitor(clientConfig, logger); monitor != nil {
+			proxies[name].Cluster = monitor
+			proxies[name].ClusterRole = clientConfig.PrimaryDiscovery.Role
+			proxies[name].RetryReads = clientConfig.PrimaryDiscovery.RetryReads
+			proxies[name].Affinity = network.NewSessionAffinity(clientConfig.PrimaryDiscovery.Affinity)
+			monitor.OnPrimaryChanged(proxies[name].PrimaryChanged)
+		}
+		proxies[name].WatchBackend()
+		proxies[name].WatchIdleTransactions()
+		proxies[name].WatchHealth()
+
+		span.AddEvent("Create proxy", trace.WithAttributes(
+			attribute.String("name", name),
+			attribute.Bool("elastic", cfg.Elastic),
+			attribute.Bool("reuseElasticClients", cfg.ReuseElasticClients),
+			attribute.String("healthCheckPeriod", cfg.HealthCheckPeriod.String()),
+			attribute.Bool("injectCorrelationIDs", cfg.InjectCorrelationIDs),
+			attribute.Int("queueSize", cfg.QueueSize),
+			attribute.String("queueTimeout", cfg.QueueTimeout.String()),
+			attribute.Int("receiveBufferSize", cfg.ReceiveBufferSize),
+			attribute.Int("sendBufferSize", cfg.SendBufferSize),
+			attribute.Int("maxMessageSize", cfg.MaxMessageSize),
+			attribute.String("oversizeBehavior", cfg.OversizeBehavior),
+			attribute.Bool("fastPath", cfg.FastPath),
+			attribute.Bool("adaptiveLimit", cfg.AdaptiveLimit.Enabled),
+			attribute.Int("ingressRate", cfg.Bandwidth.IngressRate),
+			attribute.Int("egressRate", cfg.Bandwidth.EgressRate),
+			attribute.Bool("perUserBandwidth", cfg.Bandwidth.PerUser),
+			attribute.Bool("compression", cfg.Compression.Enabled),
+			attribute.Int("firewallRules", len(cfg.Firewall.Rules)),
+			attribute.Int("maxConnections", cfg.MaxConnections),
+			attribute.String("statementTimeout", cfg.StatementTimeout.Timeout.String()),
+			attribute.Int("statementTimeoutRules", len(cfg.StatementTimeout.Rules)),
+			attribute.String("idleTransactionTimeout", cfg.IdleTransaction.Timeout.String()),
+			attribute.Int("maxConnectionMemory", cfg.MaxConnectionMemory),
+			attribute.Bool("statementCache", cfg.StatementCache.Enabled),
+			attribute.Bool("faultInjection", proxies[name].Faults != nil),
+			attribute.Bool("healthCheck", clientConfig != nil && clientConfig.HealthCheck.Enabled),
+		))
+
+		if data, ok := conf.GlobalKoanf.Get("proxies").(map[string]interface{}); ok {
+			if err := g.runHook(data, v1.HookName_HOOK_NAME_ON_NEW_PROXY); err != nil {
+				logger.Error().Err(err).Msg("Failed to run OnNewProxy hooks")
+				span.RecordError(err)
+			}
+		} else {
+			logger.Error().Msg("Failed to get proxy from config")
+		}
+	}
+
+	// The shards are served by the proxies of other config groups, so the routers are
+	// created once all the proxies exist.
+	for name, cfg := range conf.Global.Proxies {
+		if !cfg.Sharding.Enabled {
+			continue
+		}
+		logger := g.Loggers[name]
+		router, err := network.NewShardRouter(name, cfg.Sharding, proxies, logger)
+		if err != nil {
+			logger.Error().Err(err).Str("name", name).Msg(
+				"Failed to configure the sharding of the proxy")
+			return err
+		}
+		proxies[name].Sharding = router
+		span.AddEvent("Configure sharding", trace.WithAttributes(
+			attribute.String("name", name),
+			attribute.String("key", cfg.Sharding.Key),
+			attribute.Int("shards", len(router.Shards)),
+		))
+	}
+
+	// The reads are served by the proxies of other config groups too.
+	for name, cfg := range conf.Global.Proxies {
+		if !cfg.ReadWriteSplit.Enabled {
+			continue
+		}
+		logger := g.Loggers[name]
+		dialTimeout := config.DefaultDialTimeout
+		if proxies[name].ClientConfig != nil && proxies[name].ClientConfig.DialTimeout > 0 {
+			dialTimeout = proxies[name].ClientConfig.DialTimeout
+		}
+		splitter, err := network.NewReadWriteSplitter(name, cfg.ReadWriteSplit, proxies, dialTimeout)
+		if err != nil {
+			logger.Error().Err(err).Str("name", name).Msg(
+				"Failed to configure the read/write split of the proxy")
+			return err
+		}
+		proxies[name].ReadWriteSplit = splitter
+		proxies[name].WatchReplication()
+		span.AddEvent("Configure read/write split", trace.WithAttributes(
+			attribute.String("name", name),
+			attribute.String("reads", splitter.Reads),
+			attribute.String("window", splitter.Window.String()),
+		))
+	}
+
+	return nil
+}
+
+// createServers creates the servers of the proxies.
+func (g *GatewayD) createServers() {
+	conf := g.Config
+	servers := g.Servers
+	proxies := g.Proxies
+
+	_, span := otel.Tracer(config.TracerName).Start(g.ctx, "Create servers")
+	defer span.End()
+
+	for name, cfg := range conf.Global.Servers {
+		logger := g.Loggers[name]
+		servers[name] = network.NewServer(
+			g.ctx,
+			cfg.Network,
+			cfg.Address,
+			config.If[time.Duration](
+				cfg.TickInterval > 0,
+				cfg.TickInterval,
+				config.DefaultTickInterval,
+			),
+			network.Option{
+				// Can be used to send keepalive messages to the client.
+				EnableTicker: cfg.EnableTicker,
+			},
+			proxies[name],
+			logger,
+			g.PluginRegistry,
+			conf.Plugin.Timeout,
+			cfg.EnableTLS,
+			cfg.CertFile,
+			cfg.KeyFile,
+			cfg.HandshakeTimeout,
+		)
+		servers[name].Maintenance = g.Maintenance
+		servers[name].Configure(cfg)
+
+		span.AddEvent("Create server", trace.WithAttributes(
+			attribute.String("name", name),
+			attribute.String("network", cfg.Network),
+			attribute.String("address", cfg.Address),
+			attribute.String("tickInterval", cfg.TickInterval.String()),
+			attribute.String("pluginTimeout", conf.Plugin.Timeout.String()),
+			attribute.Bool("enableTLS", cfg.EnableTLS),
+			attribute.String("certFile", cfg.CertFile),
+			attribute.String("keyFile", cfg.KeyFile),
+			attribute.Bool("watchCertFiles", cfg.WatchCertFiles),
+			attribute.Bool("acme", cfg.ACME.Enabled),
+			attribute.Bool("spiffe", cfg.SPIFFE.Enabled),
+			attribute.String("handshakeTimeout", cfg.HandshakeTimeout.String()),
+			attribute.String("engineMode", cfg.EngineMode),
+			attribute.Int("eventLoopWorkers", cfg.EventLoopWorkers),
+			attribute.Bool("canary", cfg.Canary.Enabled),
+			attribute.Int("maxGoroutines", cfg.MaxGoroutines),
+		))
+
+		if data, ok := conf.GlobalKoanf.Get("servers").(map[string]interface{}); ok {
+			if err := g.runHook(data, v1.HookName_HOOK_NAME_ON_NEW_SERVER); err != nil {
+				logger.Error().Err(err).Msg("Failed to run OnNewServer hooks")
+				span.RecordError(err)
+			}
+		} else {
+			logger.Error().Msg("Failed to get the servers configuration")
+		}
+	}
+}
+
+// startAPI starts the HTTP and gRPC APIs.
+func (g *GatewayD) startAPI() {
+	conf := g.Config
+	if !conf.Global.API.Enabled {
+		return
+	}
+
+	authenticator, err := api.NewAuthenticator(conf.Global.API.Auth)
+	if err != nil {
+		g.logger.Error().Err(err).Msg("Failed to start the API")
+		return
+	}
+	tlsConfig, tlsErr := api.NewTLSConfig(
+		conf.Global.API.CertFile, conf.Global.API.KeyFile, conf.Global.API.ClientCAFile)
+	if tlsErr != nil {
+		g.logger.Error().Err(tlsErr).Msg("Failed to start the API")
+		return
+	}
+
+	apiOptions := api.Options{
+		Logger:      g.logger,
+		GRPCNetwork: conf.Global.API.GRPCNetwork,
+		GRPCAddress: conf.Global.API.GRPCAddress,
+		HTTPAddress: conf.Global.API.HTTPAddress,
+		Servers:     g.Servers,
+		Proxies:     g.Proxies,
+		HookTracer:  g.PluginRegistry.Tracer,
+		Usage:       g.usageTracker,
+		Maintenance: g.Maintenance,
+		QueryStats:  g.queryStats,
+		ErrorStats:  g.errorStats,
+		LogTail:     g.logTail,
+		Auth:        authenticator,
+		TLSConfig:   tlsConfig,
+		// The configuration is reloaded from the files and the profile it was loaded from.
+		Reload: func(ctx context.Context) error {
+			return g.Reload(ctx, conf.Reinitialized(ctx))
+		},
+		StateFile: conf.Global.State.FileName,
+		Querier:   network.NewQuerier(conf.Global.API.Query),
+	}
+	g.api = &api.API{
+		Options:        &apiOptions,
+		Config:         conf,
+		PluginRegistry: g.PluginRegistry,
+		Pools:          g.Pools,
+		Proxies:        g.Proxies,
+		Servers:        g.Servers,
+	}
+
+	go api.StartGRPCAPI(g.api, &api.HealthChecker{Servers: g.Servers})
+	g.logger.Info().Str("address", apiOptions.HTTPAddress).Msg("Started the HTTP API")
+
+	go api.StartHTTPAPI(g.api)
+	g.logger.Info().Fields(
+		map[string]interface{}{
+			"network": apiOptions.GRPCNetwork,
+			"address": apiOptions.GRPCAddress,
+		},
+	).Msg("Started the gRPC API")
+}
+
+// startDataAPI starts the data API, which runs the queries through its server.
+func (g *GatewayD) startDataAPI() {
+	cfg := g.Config.Global.DataAPI
+	if !cfg.Enabled {
+		return
+	}
+
+	server, exists := g.Servers[cfg.Server]
+	if !exists {
+		g.logger.Error().Str("server", cfg.Server).Msg(
+			"Failed to start the data API, as its server doesn't exist")
+		return
+	}
+	if server.Network != "tcp" {
+		g.logger.Error().Str("server", cfg.Server).Msg(
+			"Failed to start the data API, as its server doesn't listen on TCP")
+		return
+	}
+
+	dataAPI, err := network.NewDataAPI(cfg, server.Address, g.logger)
+	if err != nil {
+		g.logger.Error().Err(err).Msg("Failed to start the data API")
+		return
+	}
+	g.dataAPI = dataAPI
+	go dataAPI.Start()
+}
+
+// reportUsage reports the usage statistics.
+func (g *GatewayD) reportUsage() {
+	conn, err := grpc.Dial(g.Options.UsageReportURL,
+		grpc.WithTransportCredentials(
+			credentials.NewTLS(
+				&tls.Config{
+					MinVersion: tls.VersionTLS12,
+				},
+			),
+		),
+	)
+	if err != nil {
+		g.logger.Trace().Err(err).Msg(
+			"Failed to dial to the gRPC server for usage reporting")
+		return
+	}
+	defer conn.Close()
+
+	client := usage.NewUsageReportServiceClient(conn)
+	report := usage.UsageReportRequest{
+		Version:        config.Version,
+		RuntimeVersion: runtime.Version(),
+		Goos:           runtime.GOOS,
+		Goarch:         runtime.GOARCH,
+		Service:        "gatewayd",
+		DevMode:        g.Options.DevMode,
+		Plugins:        []*usage.Plugin{},
+	}
+	g.PluginRegistry.ForEach(
+		func(identifier sdkPlugin.Identifier, plugin *plugin.Plugin) {
+			report.Plugins = append(report.GetPlugins(), &usage.Plugin{
+				Name:     identifier.Name,
+				Version:  identifier.Version,
+				Checksum: identifier.Checksum,
+			})
+		},
+	)
+	_, err = client.Report(context.Background(), &report)
+	if err != nil {
+		g.logger.Trace().Err(err).Msg("Failed to report usage statistics")
+	}
+}
+
+// startServers starts the servers in the background. GatewayD is stopped if one of
+// them fails.
+func (g *GatewayD) startServers() {
+	_, span := otel.Tracer(config.TracerName).Start(g.ctx, "Start servers")
+	defer span.End()
+
+	for name, server := range g.Servers {
+		go func(span trace.Span, server *network.Server, logger zerolog.Logger) {
+			span.AddEvent("Start server")
+			if err := server.Run(); err != nil {
+				logger.Error().Err(err).Msg("Failed to start server")
+				span.RecordError(err)
+
+				g.shutdown(nil, gerr.ErrStartServerFailed.Wrap(err))
+			}
+		}(span, server, g.Loggers[name])
+	}
+}
+
+// runHook runs the notification hooks, whose result is ignored.
+func (g *GatewayD) runHook(
+	args map[string]interface{}, hookName v1.HookName,
+) *gerr.GatewayDError {
+	pluginTimeoutCtx, cancel := context.WithTimeout(
+		context.Background(), g.Config.Plugin.Timeout)
+	defer cancel()
+
+	_, err := g.PluginRegistry.Run(pluginTimeoutCtx, args, hookName)
+	return err
+}
+
+// runTypedHook runs the notification hooks with the typed message of the hook, whose
+// result is ignored.
+func (g *GatewayD) runTypedHook(message sdk.Message, hookName v1.HookName) *gerr.GatewayDError {
+	pluginTimeoutCtx, cancel := context.WithTimeout(
+		context.Background(), g.Config.Plugin.Timeout)
+	defer cancel()
+
+	_, err := g.PluginRegistry.RunTyped(pluginTimeoutCtx, message, hookName)
+	return err
+}
@@ -0,0 +1,92 @@
+package gatewayd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"go.uber.org/automaxprocs/maxprocs"
+)
+
+// cgroupRoot is where the cgroup filesystem is mounted.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// ballast is a heap allocation that is never used, which makes the GC run less often
+// with small heaps.
+var ballast []byte
+
+// tuneRuntime tunes the Go runtime with the performance config, and reports its
+// settings in the startup log. The GOMAXPROCS, GOGC and GOMEMLIMIT environment
+// variables take precedence over the config.
+func (g *GatewayD) tuneRuntime() {
+	performance := g.Config.Global.Performance
+
+	switch {
+	case os.Getenv("GOMAXPROCS") != "":
+	case performance.MaxProcs > 0:
+		runtime.GOMAXPROCS(performance.MaxProcs)
+	case performance.AutoMaxProcs:
+		if _, err := maxprocs.Set(maxprocs.Logger(func(format string, args ...interface{}) {
+			g.logger.Debug().Msgf(format, args...)
+		})); err != nil {
+			g.logger.Error().Err(err).Msg("Failed to set GOMAXPROCS to the CPU quota")
+		}
+	}
+
+	if os.Getenv("GOGC") == "" && performance.GCPercent != 0 {
+		debug.SetGCPercent(performance.GCPercent)
+	}
+	if os.Getenv("GOMEMLIMIT") == "" {
+		if limit := memoryLimit(performance, containerMemoryLimit(cgroupRoot)); limit > 0 {
+			debug.SetMemoryLimit(limit)
+		}
+	}
+	if performance.Ballast > 0 && ballast == nil {
+		ballast = make([]byte, performance.Ballast)
+	}
+
+	// The current GC percent can only be read by setting it.
+	gcPercent := debug.SetGCPercent(-1)
+	debug.SetGCPercent(gcPercent)
+	g.logger.Info().Int("gomaxprocs", runtime.GOMAXPROCS(0)).Int("gogc", gcPercent).Int64(
+		"gomemlimit", debug.SetMemoryLimit(-1)).Int64("ballast", performance.Ballast).Msg(
+		"Tuned the Go runtime")
+}
+
+// memoryLimit returns the memory limit of the Go runtime: the one of the config, or
+// the ratio of the memory limit of the container, if any. It is zero if there is none.
+func memoryLimit(performance config.Performance, containerLimit int64) int64 {
+	if performance.MemoryLimit > 0 {
+		return performance.MemoryLimit
+	}
+	if performance.MemoryLimitRatio > 0 && containerLimit > 0 {
+		return int64(float64(containerLimit) * performance.MemoryLimitRatio)
+	}
+	return 0
+}
+
+// containerMemoryLimit returns the memory limit of the cgroup of GatewayD, v2 or v1,
+// in bytes. It is zero if there is none, or if it can't be read.
+func containerMemoryLimit(root string) int64 {
+	for _, file := range []string{"memory.max", filepath.Join("memory", "memory.limit_in_bytes")} {
+		data, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil {
+			continue
+		}
+		value := strings.TrimSpace(string(data))
+		if value == "max" {
+			return 0
+		}
+		limit, err := strconv.ParseInt(value, 10, 64)
+		// The unlimited cgroups v1 have a limit close to the maximum int64.
+		if err != nil || limit <= 0 || limit > 1<<62 {
+			return 0
+		}
+		return limit
+	}
+	return 0
+}
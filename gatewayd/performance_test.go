@@ -0,0 +1,39 @@
+package gatewayd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestContainerMemoryLimit tests that the memory limit is read from the cgroups v2 and
+// v1, and that the unlimited cgroups have none.
+func TestContainerMemoryLimit(t *testing.T) {
+	root := t.TempDir()
+	assert.Zero(t, containerMemoryLimit(root))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "memory"), 0o700))
+	v1 := filepath.Join(root, "memory", "memory.limit_in_bytes")
+	require.NoError(t, os.WriteFile(v1, []byte("9223372036854771712\n"), 0o600))
+	assert.Zero(t, containerMemoryLimit(root))
+	require.NoError(t, os.WriteFile(v1, []byte("536870912\n"), 0o600))
+	assert.Equal(t, int64(536870912), containerMemoryLimit(root))
+
+	v2 := filepath.Join(root, "memory.max")
+	require.NoError(t, os.WriteFile(v2, []byte("max\n"), 0o600))
+	assert.Zero(t, containerMemoryLimit(root))
+	require.NoError(t, os.WriteFile(v2, []byte("1073741824\n"), 0o600))
+	assert.Equal(t, int64(1073741824), containerMemoryLimit(root))
+}
+
+func TestMemoryLimit(t *testing.T) {
+	assert.Equal(t, int64(900), memoryLimit(config.Performance{MemoryLimitRatio: 0.9}, 1000))
+	assert.Equal(t, int64(500),
+		memoryLimit(config.Performance{MemoryLimit: 500, MemoryLimitRatio: 0.9}, 1000))
+	assert.Zero(t, memoryLimit(config.Performance{MemoryLimitRatio: 0.9}, 0))
+	assert.Zero(t, memoryLimit(config.Performance{}, 1000))
+}
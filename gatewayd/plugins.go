@@ -0,0 +1,352 @@
+package gatewayd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/events"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// startEventBus starts the event bus to notify operators of gateway-level incidents.
+func (g *GatewayD) startEventBus() {
+	eventsConfig := g.Config.Global.Events
+	if !eventsConfig.Enabled {
+		return
+	}
+
+	var sinks []events.Sink
+	if eventsConfig.Webhook.Enabled {
+		sinks = append(sinks, &events.WebhookSink{
+			URL:     eventsConfig.Webhook.URL,
+			Headers: eventsConfig.Webhook.Headers,
+		})
+	}
+	if eventsConfig.Slack.Enabled {
+		sinks = append(sinks, &events.SlackSink{WebhookURL: eventsConfig.Slack.WebhookURL})
+	}
+	if eventsConfig.NATS.Enabled {
+		sinks = append(sinks, &events.NATSSink{
+			URL:     eventsConfig.NATS.URL,
+			Subject: eventsConfig.NATS.Subject,
+		})
+	}
+
+	g.eventBus = events.NewBus(
+		g.ctx,
+		config.If[int](
+			eventsConfig.BufferSize > 0,
+			eventsConfig.BufferSize,
+			config.DefaultEventBufferSize,
+		),
+		config.If[time.Duration](
+			eventsConfig.Timeout > 0,
+			eventsConfig.Timeout,
+			config.DefaultEventTimeout,
+		),
+		eventsConfig.Types,
+		g.logger,
+		sinks...,
+	)
+	g.eventBus.Start()
+	events.SetDefault(g.eventBus)
+}
+
+// startUsageTracker accounts the traffic of the connections, users and databases.
+func (g *GatewayD) startUsageTracker() {
+	usageConfig := g.Config.Global.Usage
+	if !usageConfig.Enabled {
+		return
+	}
+
+	var usageLog io.Writer
+	if usageConfig.LogFile != "" {
+		//nolint:gomnd
+		file, err := os.OpenFile(
+			usageConfig.LogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			g.logger.Error().Err(err).Str("logFile", usageConfig.LogFile).Msg(
+				"Failed to open the usage log, the usage won't be logged")
+		} else {
+			usageLog = file
+		}
+	}
+	g.usageTracker = network.NewUsageTracker(usageLog, g.logger)
+	g.usageTracker.Start(config.If[time.Duration](
+		usageConfig.FlushInterval > 0,
+		usageConfig.FlushInterval,
+		config.DefaultUsageFlushInterval,
+	))
+}
+
+// startSharedLimits syncs the state of the limits of the proxies with the Redis
+// shared by the GatewayD instances.
+func (g *GatewayD) startSharedLimits() {
+	limitsConfig := g.Config.Global.SharedLimits
+	if !limitsConfig.Enabled {
+		return
+	}
+
+	g.sharedLimits = network.NewSharedState(
+		network.NewRedisStore(limitsConfig), limitsConfig, g.logger)
+	g.sharedLimits.Start(g.ctx)
+}
+
+// startPluginRegistry creates the plugin registry, loads the plugins and registers
+// their hooks, and starts the metrics merger and the health check of the plugins.
+func (g *GatewayD) startPluginRegistry() {
+	conf := g.Config
+	logger := g.logger
+
+	// The plugins are loaded and hooks registered before the configuration is loaded.
+	g.PluginRegistry = plugin.NewRegistry(
+		g.ctx,
+		config.If[config.CompatibilityPolicy](
+			config.Exists[string, config.CompatibilityPolicy](
+				config.CompatibilityPolicies, conf.Plugin.CompatibilityPolicy),
+			config.CompatibilityPolicies[conf.Plugin.CompatibilityPolicy],
+			config.DefaultCompatibilityPolicy),
+		config.If[config.VerificationPolicy](
+			config.Exists[string, config.VerificationPolicy](
+				config.VerificationPolicies, conf.Plugin.VerificationPolicy),
+			config.VerificationPolicies[conf.Plugin.VerificationPolicy],
+			config.DefaultVerificationPolicy),
+		config.If[config.AcceptancePolicy](
+			config.Exists[string, config.AcceptancePolicy](
+				config.AcceptancePolicies, conf.Plugin.AcceptancePolicy),
+			config.AcceptancePolicies[conf.Plugin.AcceptancePolicy],
+			config.DefaultAcceptancePolicy),
+		config.If[config.TerminationPolicy](
+			config.Exists[string, config.TerminationPolicy](
+				config.TerminationPolicies, conf.Plugin.TerminationPolicy),
+			config.TerminationPolicies[conf.Plugin.TerminationPolicy],
+			config.DefaultTerminationPolicy),
+		logger,
+		g.Options.DevMode,
+	)
+	pluginRegistry := g.PluginRegistry
+	pluginRegistry.Timeout = config.If[time.Duration](
+		conf.Plugin.Timeout > 0, conf.Plugin.Timeout, config.DefaultPluginTimeout)
+	pluginRegistry.ShutdownTimeout = config.If[time.Duration](
+		conf.Plugin.ShutdownTimeout > 0, conf.Plugin.ShutdownTimeout, config.DefaultPluginShutdownTimeout)
+	pluginRegistry.AsyncHookWorkers = config.If[int](
+		conf.Plugin.AsyncHookWorkers > 0, conf.Plugin.AsyncHookWorkers, config.DefaultAsyncHookWorkers)
+	pluginRegistry.AsyncHookQueueSize = config.If[int](
+		conf.Plugin.AsyncHookQueueSize > 0,
+		conf.Plugin.AsyncHookQueueSize,
+		config.DefaultAsyncHookQueueSize)
+	pluginRegistry.RequireCapabilities = conf.Plugin.RequireCapabilities
+	pluginRegistry.AllowOverride = conf.Plugin.AllowOverride
+	pluginRegistry.Tracer = plugin.NewHookTracer(config.If[int](
+		conf.Plugin.HookTrace.BufferSize > 0,
+		conf.Plugin.HookTrace.BufferSize,
+		config.DefaultHookTraceBufferSize))
+	pluginRegistry.Tracer.Configure(conf.Plugin.HookTrace.Enabled, conf.Plugin.HookTrace.SampleRate)
+	pluginRegistry.SetHookBudgets(plugin.NewHookBudgets(conf.Plugin.HookBudgets, logger))
+	pluginRegistry.SetHookSampler(plugin.NewHookSampler(conf.Global.Sampling.Hooks, logger))
+	pluginRegistry.SetHookCache(plugin.NewHookCache(conf.Plugin.HookCache))
+	pluginRegistry.SetHookCompression(plugin.NewHookCompression(conf.Plugin.Compression, logger))
+	pluginRegistry.Scheduler = plugin.NewJobScheduler(pluginRegistry.Timeout, logger)
+	pluginRegistry.MetricEnricher = plugin.NewMetricEnricher(
+		pluginRegistry.AsyncHookQueueSize,
+		pluginRegistry.Timeout,
+		pluginRegistry.MetricHooks,
+		logger)
+	// Export the metrics recorded from the results of the onMetric hooks.
+	if err := prometheus.Register(pluginRegistry.MetricEnricher); err != nil {
+		logger.Error().Err(err).Msg("Failed to register the enriched metrics")
+	}
+
+	// Load plugins and register their hooks.
+	pluginRegistry.LoadPlugins(g.ctx, conf.Plugin.Plugins, conf.Plugin.StartTimeout)
+	// Load the built-in plugins that aren't disabled or replaced by the loaded plugins.
+	pluginRegistry.LoadBuiltins(conf.Plugin.Builtins)
+
+	// Run the scheduled jobs of the plugins.
+	pluginRegistry.Scheduler.Start()
+
+	// Start the metrics merger if enabled.
+	if conf.Plugin.EnableMetricsMerger {
+		g.metricsMerger = metrics.NewMerger(g.ctx, conf.Plugin.MetricsMergerPeriod, logger)
+		pluginRegistry.ForEach(func(_ sdkPlugin.Identifier, plugin *plugin.Plugin) {
+			if metricsEnabled, err := strconv.ParseBool(plugin.Config["metricsEnabled"]); err == nil && metricsEnabled {
+				g.metricsMerger.Add(plugin.ID.Name, plugin.Config["metricsUnixDomainSocket"])
+				logger.Debug().Str("plugin", plugin.ID.Name).Msg(
+					"Added plugin to metrics merger")
+			}
+		})
+		g.metricsMerger.Start()
+	}
+
+	g.startPluginHealthCheck()
+}
+
+// preflightPlugins runs the preflight checks of the plugins before GatewayD listens,
+// and either aborts the startup or removes the plugins that fail, depending on the
+// preflight policy.
+func (g *GatewayD) preflightPlugins(ctx context.Context) error {
+	conf := g.Config.Plugin
+	ctx, span := otel.Tracer(config.TracerName).Start(ctx, "Preflight plugins")
+	defer span.End()
+
+	failures := g.PluginRegistry.Preflight(ctx, config.If[time.Duration](
+		conf.PreflightTimeout > 0, conf.PreflightTimeout, config.DefaultPreflightTimeout))
+	if len(failures) == 0 {
+		return nil
+	}
+
+	policy := config.If[config.PreflightPolicy](
+		config.Exists[string, config.PreflightPolicy](
+			config.PreflightPolicies, conf.PreflightPolicy),
+		config.PreflightPolicies[conf.PreflightPolicy],
+		config.DefaultPreflightPolicy)
+	names := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		names = append(names, failure.Plugin.Name)
+		for _, err := range failure.Errors {
+			g.logger.Error().Str("name", failure.Plugin.Name).Str("error", err).Msg(
+				"Plugin failed the preflight checks")
+		}
+
+		if policy != config.PreflightDisable {
+			continue
+		}
+		if pluginInstance := g.PluginRegistry.Get(failure.Plugin); pluginInstance != nil {
+			pluginInstance.Stop()
+		}
+		if g.metricsMerger != nil {
+			g.metricsMerger.Remove(failure.Plugin.Name)
+		}
+		g.PluginRegistry.Remove(failure.Plugin)
+		events.Publish(events.PluginDisabled, "Plugin failed the preflight checks",
+			map[string]interface{}{
+				"name":   failure.Plugin.Name,
+				"errors": failure.Errors,
+			})
+		g.logger.Warn().Str("name", failure.Plugin.Name).Msg(
+			"Plugin is disabled, as it failed the preflight checks")
+	}
+	span.SetAttributes(attribute.StringSlice("failed", names))
+
+	if policy == config.PreflightDisable {
+		return nil
+	}
+	err := gerr.ErrPreflightFailed.Wrap(
+		fmt.Errorf("the plugins %s failed the preflight checks", strings.Join(names, ", ")))
+	span.RecordError(err)
+	return err
+}
+
+// startPluginHealthCheck pings the plugins to check if they are alive, and removes
+// them if they are not.
+//
+// TODO: Move this to the plugin registry.
+func (g *GatewayD) startPluginHealthCheck() {
+	conf := g.Config
+	logger := g.logger
+	pluginRegistry := g.PluginRegistry
+
+	ctx, span := otel.Tracer(config.TracerName).Start(g.ctx, "Plugin health check")
+	defer span.End()
+
+	startDelay := time.Now().Add(conf.Plugin.HealthCheckPeriod)
+	if _, err := g.healthCheckScheduler.Every(
+		conf.Plugin.HealthCheckPeriod).SingletonMode().StartAt(startDelay).Do(func() {
+		_, span := otel.Tracer(config.TracerName).Start(ctx, "Run plugin health check")
+		defer span.End()
+
+		var plugins []string
+		pluginRegistry.ForEach(func(pluginId sdkPlugin.Identifier, plugin *plugin.Plugin) {
+			if err := plugin.Ping(); err != nil {
+				span.RecordError(err)
+				logger.Error().Err(err).Msg("Failed to ping plugin")
+				if conf.Plugin.EnableMetricsMerger && g.metricsMerger != nil {
+					g.metricsMerger.Remove(pluginId.Name)
+				}
+				pluginRegistry.Remove(pluginId)
+				events.Publish(events.PluginCrashed, "Plugin failed the health check",
+					map[string]interface{}{
+						"name":          pluginId.Name,
+						"reloadOnCrash": conf.Plugin.ReloadOnCrash,
+					})
+
+				if !conf.Plugin.ReloadOnCrash {
+					return // Do not reload the plugins.
+				}
+
+				// Reload the plugins and register their hooks upon crash.
+				logger.Info().Str("name", pluginId.Name).Msg("Reloading crashed plugin")
+				pluginConfig := conf.Plugin.GetPlugins(pluginId.Name)
+				if pluginConfig != nil {
+					pluginRegistry.LoadPlugins(g.ctx, pluginConfig, conf.Plugin.StartTimeout)
+				}
+			} else {
+				logger.Trace().Str("name", pluginId.Name).Msg("Successfully pinged plugin")
+				plugins = append(plugins, pluginId.Name)
+			}
+		})
+		span.SetAttributes(attribute.StringSlice("plugins", plugins))
+	}); err != nil {
+		logger.Error().Err(err).Msg("Failed to start plugin health check scheduler")
+		span.RecordError(err)
+	}
+	if pluginRegistry.Size() > 0 {
+		logger.Info().Str(
+			"healthCheckPeriod", conf.Plugin.HealthCheckPeriod.String(),
+		).Msg("Starting plugin health check scheduler")
+		g.healthCheckScheduler.StartAsync()
+	}
+}
+
+// runOnConfigLoadedHooks passes the global config to the plugins that register to
+// the OnConfigLoaded hook, and merges the config they return with the one loaded from
+// the file. Only the global configuration is merged, which means that the plugins
+// cannot modify the plugin configurations.
+func (g *GatewayD) runOnConfigLoadedHooks(ctx context.Context, conf *config.Config) {
+	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), conf.Plugin.Timeout)
+	defer cancel()
+
+	updatedGlobalConfig, err := g.PluginRegistry.Run(
+		pluginTimeoutCtx, conf.GlobalKoanf.All(), v1.HookName_HOOK_NAME_ON_CONFIG_LOADED)
+	if err != nil {
+		g.logger.Error().Err(err).Msg("Failed to run OnConfigLoaded hooks")
+	}
+
+	if updatedGlobalConfig != nil {
+		// Merge the config with the one loaded from the file (in memory).
+		// The changes won't be persisted to disk.
+		conf.MergeGlobalConfig(ctx, updatedGlobalConfig)
+		events.Publish(events.ConfigReloaded, "Global config was updated by the plugins", nil)
+	}
+}
+
+// runOnNewLoggerHooks notifies the plugins of the loggers. This is a notification
+// hook, so the result is ignored.
+func (g *GatewayD) runOnNewLoggerHooks() {
+	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), g.Config.Plugin.Timeout)
+	defer cancel()
+
+	if data, ok := g.Config.GlobalKoanf.Get("loggers").(map[string]interface{}); ok {
+		_, err := g.PluginRegistry.Run(
+			pluginTimeoutCtx, data, v1.HookName_HOOK_NAME_ON_NEW_LOGGER)
+		if err != nil {
+			g.logger.Error().Err(err).Msg("Failed to run OnNewLogger hooks")
+		}
+	} else {
+		g.logger.Error().Msg("Failed to get loggers from config")
+	}
+}
@@ -0,0 +1,186 @@
+package gatewayd
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/plugin"
+)
+
+// RunReport is what a GatewayD instance runs with once it has started, which is
+// used by orchestration to verify that a deployment matches its intent.
+type RunReport struct {
+	Version   string         `json:"version"`
+	PID       int            `json:"pid"`
+	StartedAt time.Time      `json:"startedAt"`
+	Servers   []ServerReport `json:"servers"`
+	Pools     []PoolReport   `json:"pools"`
+	Plugins   []PluginReport `json:"plugins"`
+	Features  []string       `json:"features"`
+}
+
+// ServerReport is a server and the listeners it is bound to.
+type ServerReport struct {
+	Name       string           `json:"name"`
+	Listeners  []ListenerReport `json:"listeners"`
+	TLS        bool             `json:"tls"`
+	EngineMode string           `json:"engineMode"`
+	Canary     bool             `json:"canary"`
+}
+
+// ListenerReport is a network and address a server is bound to.
+type ListenerReport struct {
+	Network string `json:"network"`
+	Address string `json:"address"`
+}
+
+// PoolReport is the number of the connections of a pool, and its capacity.
+type PoolReport struct {
+	Name     string `json:"name"`
+	Size     int    `json:"size"`
+	Capacity int    `json:"capacity"`
+}
+
+// PluginReport is a loaded plugin.
+type PluginReport struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Checksum string `json:"checksum"`
+}
+
+// reportRun logs the run report once all the servers are listening, and writes it to
+// the file of the config, if any. Nothing is reported if GatewayD stops before.
+func (g *GatewayD) reportRun() {
+	for _, server := range g.Servers {
+		select {
+		case <-server.Listening():
+		case <-g.stopped:
+			return
+		}
+	}
+
+	report := g.runReport()
+	g.logger.Info().Interface("report", report).Msg("GatewayD started")
+
+	if fileName := g.Config.Global.RunReport.FileName; fileName != "" {
+		if err := writeRunReport(fileName, report); err != nil {
+			g.logger.Error().Err(err).Str("fileName", fileName).Msg(
+				"Failed to write the run report")
+		}
+	}
+}
+
+// runReport returns the report of the servers, pools, plugins and features GatewayD
+// runs with, sorted by name.
+func (g *GatewayD) runReport() RunReport {
+	report := RunReport{
+		Version:   config.Version,
+		PID:       os.Getpid(),
+		StartedAt: time.Now().UTC(),
+		Servers:   []ServerReport{},
+		Pools:     []PoolReport{},
+		Plugins:   []PluginReport{},
+		Features:  g.features(),
+	}
+
+	for name, server := range g.Servers {
+		serverReport := ServerReport{
+			Name:       name,
+			Listeners:  []ListenerReport{},
+			TLS:        server.EnableTLS,
+			EngineMode: string(server.EngineMode),
+			Canary:     server.Canary != nil,
+		}
+		for _, address := range server.ListenerAddresses() {
+			serverReport.Listeners = append(serverReport.Listeners, ListenerReport{
+				Network: address.Network(),
+				Address: address.String(),
+			})
+		}
+		report.Servers = append(report.Servers, serverReport)
+	}
+	sort.Slice(report.Servers, func(i, j int) bool {
+		return report.Servers[i].Name < report.Servers[j].Name
+	})
+
+	for name, pool := range g.Pools {
+		report.Pools = append(report.Pools, PoolReport{
+			Name:     name,
+			Size:     pool.Size(),
+			Capacity: pool.Cap(),
+		})
+	}
+	sort.Slice(report.Pools, func(i, j int) bool {
+		return report.Pools[i].Name < report.Pools[j].Name
+	})
+
+	if g.PluginRegistry != nil {
+		g.PluginRegistry.ForEach(func(identifier sdkPlugin.Identifier, _ *plugin.Plugin) {
+			report.Plugins = append(report.Plugins, PluginReport{
+				Name:     identifier.Name,
+				Version:  identifier.Version,
+				Checksum: identifier.Checksum,
+			})
+		})
+	}
+	sort.Slice(report.Plugins, func(i, j int) bool {
+		return report.Plugins[i].Name < report.Plugins[j].Name
+	})
+
+	return report
+}
+
+// features returns the names of the features enabled in the config and the options.
+func (g *GatewayD) features() []string {
+	global := g.Config.Global
+	metricsConfig, metricsExists := global.Metrics[config.Default]
+	enabled := map[string]bool{
+		"api":               global.API.Enabled,
+		"dataAPI":           global.DataAPI.Enabled,
+		"dashboard":         global.API.Enabled && global.API.Dashboard.Enabled,
+		"query":             global.API.Enabled && global.API.Query.Enabled,
+		"metrics":           metricsExists && metricsConfig.Enabled,
+		"otlp":              metricsExists && metricsConfig.OTLPEnabled,
+		"events":            global.Events.Enabled,
+		"usage":             global.Usage.Enabled,
+		"sharedLimits":      global.SharedLimits.Enabled,
+		"metricsMerger":     g.Config.Plugin.EnableMetricsMerger,
+		"hookCache":         g.Config.Plugin.HookCache.Enabled,
+		"hookTrace":         g.Config.Plugin.HookTrace.Enabled,
+		"pluginCompression": g.Config.Plugin.Compression.Enabled,
+		"builtinPlugins":    g.PluginRegistry != nil && len(g.PluginRegistry.Builtins()) > 0,
+		"sampling":          len(global.Sampling.Hooks) > 0 || len(global.Sampling.Logs) > 0,
+		"usageReport":       g.Options.UsageReport,
+		"devMode":           g.Options.DevMode,
+		"faultInjection":    g.Options.FaultInjection,
+	}
+
+	features := []string{}
+	for feature, isEnabled := range enabled {
+		if isEnabled {
+			features = append(features, feature)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// writeRunReport writes the report to the file as JSON. It is written to a temporary
+// file that is renamed, so that the file is never read half-written.
+func writeRunReport(fileName string, report RunReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	temporary := fileName + ".tmp"
+	//nolint:gomnd
+	if err := os.WriteFile(temporary, data, 0o600); err != nil {
+		return err //nolint:wrapcheck
+	}
+	return os.Rename(temporary, fileName) //nolint:wrapcheck
+}
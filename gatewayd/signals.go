@@ -0,0 +1,121 @@
+package gatewayd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"syscall"
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/logging"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/gatewayd-io/gatewayd/recorder"
+	"github.com/gatewayd-io/gatewayd/sdk"
+)
+
+// HandleSignal runs the action of the signal in the config, and returns true if the
+// signal stops GatewayD, which is then stopped with StopWithSignal. The OnSignal hooks
+// are notified of the signals that don't stop GatewayD here, and of the ones that do
+// when it stops.
+func (g *GatewayD) HandleSignal(ctx context.Context, sig os.Signal) bool {
+	// SIGQUIT dumps the flight recorder, e.g. to debug a stall, before stopping GatewayD.
+	if sig == syscall.SIGQUIT {
+		if fileName, err := recorder.Dump(sig.String()); err != nil {
+			g.logger.Error().Err(err).Msg("Failed to dump the flight recorder")
+		} else {
+			g.logger.Info().Str("fileName", fileName).Msg("Dumped the flight recorder")
+		}
+	}
+
+	action := signalAction(g.Config.Global.Signals, sig)
+	if action == config.SignalStop {
+		return true
+	}
+
+	logger := g.logger.With().Str("signal", sig.String()).Str("action", string(action)).Logger()
+	logger.Info().Msg("Received a signal")
+	if g.PluginRegistry != nil {
+		if err := g.runTypedHook(
+			&sdk.SignalMessage{Signal: sig.String()}, v1.HookName_HOOK_NAME_ON_SIGNAL,
+		); err != nil {
+			logger.Error().Err(err).Msg("Failed to run OnSignal hooks")
+		}
+	}
+
+	switch action {
+	case config.SignalReload:
+		if err := g.Reload(ctx, g.Config.Reinitialized(ctx)); err != nil {
+			logger.Error().Err(err).Msg("Failed to reload the configuration")
+		}
+	case config.SignalReopenLogs:
+		if err := logging.RotateFiles(); err != nil {
+			logger.Error().Err(err).Msg("Failed to rotate the log files")
+		} else {
+			logger.Info().Msg("Rotated the log files")
+		}
+	case config.SignalDumpState:
+		if fileName, err := g.dumpState(); err != nil {
+			logger.Error().Err(err).Msg("Failed to dump the state")
+		} else {
+			logger.Info().Str("fileName", fileName).Msg("Dumped the goroutines and connections")
+		}
+	case config.SignalIgnore:
+	default:
+		logger.Warn().Msg("Unknown signal action, ignoring the signal")
+	}
+	return false
+}
+
+// signalAction returns the action of the signal in the config, or its default one.
+// The signals whose action isn't configurable stop GatewayD.
+func signalAction(signals config.Signals, sig os.Signal) config.SignalAction {
+	action, fallback := "", config.SignalStop
+	switch sig {
+	case sighup:
+		action, fallback = signals.SIGHUP, config.DefaultSIGHUPAction
+	case sigusr1:
+		action, fallback = signals.SIGUSR1, config.DefaultSIGUSR1Action
+	case sigusr2:
+		action, fallback = signals.SIGUSR2, config.DefaultSIGUSR2Action
+	}
+	return config.If[config.SignalAction](action != "", config.SignalAction(action), fallback)
+}
+
+// dumpState writes the connections of the proxies and the stacks of the goroutines to
+// a new file in the dump directory, and returns its name.
+func (g *GatewayD) dumpState() (string, error) {
+	dumpDir := config.If[string](
+		g.Config.Global.Signals.DumpDir != "", g.Config.Global.Signals.DumpDir, os.TempDir())
+	fileName := filepath.Join(dumpDir, fmt.Sprintf(
+		"gatewayd-%d-%s.dump", os.Getpid(), time.Now().UTC().Format("20060102T150405.000Z")))
+
+	//nolint:gomnd
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	defer file.Close()
+
+	connections := make(map[string][]network.ConnectionInfo, len(g.Proxies))
+	for name, proxy := range g.Proxies {
+		connections[name] = proxy.Connections()
+	}
+	fmt.Fprintln(file, "Connections:")
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(connections); err != nil {
+		return "", err //nolint:wrapcheck
+	}
+
+	fmt.Fprintln(file, "\nGoroutines:")
+	//nolint:gomnd
+	if err := pprof.Lookup("goroutine").WriteTo(file, 2); err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return fileName, nil
+}
@@ -0,0 +1,70 @@
+//go:build !windows
+// +build !windows
+
+package gatewayd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/network"
+	"github.com/gatewayd-io/gatewayd/recorder"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalAction(t *testing.T) {
+	signals := config.Signals{SIGUSR1: string(config.SignalIgnore)}
+	assert.Equal(t, config.DefaultSIGHUPAction, signalAction(signals, syscall.SIGHUP))
+	assert.Equal(t, config.SignalIgnore, signalAction(signals, syscall.SIGUSR1))
+	assert.Equal(t, config.DefaultSIGUSR2Action, signalAction(signals, syscall.SIGUSR2))
+	assert.Equal(t, config.SignalStop, signalAction(signals, syscall.SIGTERM))
+}
+
+// TestHandleSignal tests that the dumpState action writes the connections and the
+// goroutines to a file, and that the stop signals are left to StopWithSignal.
+func TestHandleSignal(t *testing.T) {
+	dumpDir := t.TempDir()
+	gateway := &GatewayD{
+		Config: &config.Config{Global: config.GlobalConfig{
+			Signals: config.Signals{DumpDir: dumpDir},
+		}},
+		Proxies: map[string]*network.Proxy{},
+		logger:  zerolog.Nop(),
+	}
+
+	assert.True(t, gateway.HandleSignal(context.Background(), syscall.SIGTERM))
+	assert.False(t, gateway.HandleSignal(context.Background(), syscall.SIGUSR2))
+
+	dumps, err := filepath.Glob(filepath.Join(dumpDir, "gatewayd-*.dump"))
+	require.NoError(t, err)
+	require.Len(t, dumps, 1)
+	data, err := os.ReadFile(dumps[0])
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "Connections:\n{}\n"))
+	assert.Contains(t, string(data), "Goroutines:")
+	assert.Contains(t, string(data), "TestHandleSignal")
+}
+
+// TestHandleSIGQUIT tests that SIGQUIT dumps the flight recorder before stopping.
+func TestHandleSIGQUIT(t *testing.T) {
+	dumpDir := t.TempDir()
+	recorder.Configure(config.DefaultFlightRecorderSize, dumpDir)
+	defer recorder.Configure(config.DefaultFlightRecorderSize, "")
+	gateway := &GatewayD{Config: &config.Config{}, logger: zerolog.Nop()}
+
+	assert.True(t, gateway.HandleSignal(context.Background(), syscall.SIGQUIT))
+
+	dumps, err := filepath.Glob(filepath.Join(dumpDir, "gatewayd-*.flight"))
+	require.NoError(t, err)
+	require.Len(t, dumps, 1)
+	data, err := os.ReadFile(dumps[0])
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "Reason: quit\n"))
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package gatewayd
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	sighup  os.Signal = syscall.SIGHUP
+	sigusr1 os.Signal = syscall.SIGUSR1
+	sigusr2 os.Signal = syscall.SIGUSR2
+)
+
+// ActionSignals are the signals whose action is configured, which don't stop GatewayD
+// by default.
+var ActionSignals = []os.Signal{sighup, sigusr1, sigusr2}
@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package gatewayd
+
+import (
+	"os"
+	"syscall"
+)
+
+// SIGUSR1 and SIGUSR2 don't exist on Windows, so they never match.
+var (
+	sighup  os.Signal = syscall.SIGHUP
+	sigusr1 os.Signal
+	sigusr2 os.Signal
+)
+
+// ActionSignals are the signals whose action is configured, which don't stop GatewayD
+// by default.
+var ActionSignals = []os.Signal{sighup}
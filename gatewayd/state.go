@@ -0,0 +1,32 @@
+package gatewayd
+
+import (
+	"github.com/gatewayd-io/gatewayd/api"
+)
+
+// restoreState applies the changes made at runtime with the admin API before GatewayD
+// restarted, which were persisted to the state file, over the config.
+func (g *GatewayD) restoreState() {
+	fileName := g.Config.Global.State.FileName
+	if fileName == "" {
+		return
+	}
+
+	state, err := api.LoadState(fileName)
+	if err != nil {
+		g.logger.Error().Err(err).Str("fileName", fileName).Msg(
+			"Failed to load the state, the changes made at runtime aren't applied")
+		return
+	}
+
+	if maintenance := state.Maintenance; maintenance != nil && maintenance.Enabled {
+		g.Maintenance.Enable(maintenance.Message, maintenance.Queue, maintenance.QueueTimeout)
+		g.logger.Info().Str("message", maintenance.Message).Bool("queue", maintenance.Queue).Msg(
+			"Restored the maintenance mode from the state")
+	}
+	if hookTrace := state.HookTrace; hookTrace != nil && g.PluginRegistry != nil {
+		g.PluginRegistry.Tracer.Configure(hookTrace.Enabled, hookTrace.SampleRate)
+		g.logger.Info().Bool("enabled", hookTrace.Enabled).Float64(
+			"sampleRate", hookTrace.SampleRate).Msg("Restored the hook trace from the state")
+	}
+}
@@ -0,0 +1,318 @@
+// Package gatewaydtest runs GatewayD in front of a PostgreSQL container for end-to-end
+// tests, so that the plugins and the applications can be tested against a real gateway,
+// e.g. in CI. The container is run with the docker CLI, and the tests are skipped if it
+// isn't installed.
+package gatewaydtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/gatewayd"
+	"github.com/gatewayd-io/gatewayd/network"
+)
+
+const (
+	DefaultPostgresImage    = "postgres:16-alpine"
+	DefaultPostgresUser     = "postgres"
+	DefaultPostgresPassword = "postgres"
+	DefaultPostgresDatabase = "postgres"
+	DefaultStartupTimeout   = time.Minute
+
+	// postgresPort is the port PostgreSQL listens on in the container.
+	postgresPort = "5432/tcp"
+	// readinessInterval is the interval PostgreSQL and GatewayD are checked at until
+	// they are ready.
+	readinessInterval = 100 * time.Millisecond
+)
+
+var (
+	// ErrNotReady is returned if PostgreSQL or GatewayD isn't ready before the startup
+	// timeout.
+	ErrNotReady = errors.New("not ready before the startup timeout")
+	// ErrNoPort is returned if the port of PostgreSQL isn't published by docker.
+	ErrNoPort = errors.New("the port of PostgreSQL isn't published")
+)
+
+// Options configures the gateway and its database.
+type Options struct {
+	// PostgresImage is the image of the PostgreSQL container.
+	PostgresImage string
+	// PostgresAddress is the address of an existing database, which is used instead
+	// of running a container, e.g. the service container of the CI.
+	PostgresAddress string
+	// User, Password and Database are the credentials of the database.
+	User     string
+	Password string
+	Database string
+	// Config overrides the global config by key, e.g. "pools.default.size".
+	Config map[string]interface{}
+	// PluginConfigFile is the plugins config file. No plugins are loaded if it is empty.
+	PluginConfigFile string
+	// GatewayD are the options GatewayD is started with.
+	GatewayD gatewayd.Options
+	// StartupTimeout is how long PostgreSQL and GatewayD have to be ready.
+	StartupTimeout time.Duration
+}
+
+// Gateway is a running GatewayD and the database it proxies.
+type Gateway struct {
+	GatewayD *gatewayd.GatewayD
+	Config   *config.Config
+
+	// Address is the address the clients connect to GatewayD on.
+	Address string
+	// PostgresAddress is the address of the database, to connect to it directly.
+	PostgresAddress string
+	User            string
+	Password        string
+	Database        string
+
+	container string
+}
+
+// DSN returns the connection string of GatewayD.
+func (g *Gateway) DSN() string {
+	return dsn(g.Address, g.User, g.Password, g.Database)
+}
+
+// PostgresDSN returns the connection string of the database, bypassing GatewayD.
+func (g *Gateway) PostgresDSN() string {
+	return dsn(g.PostgresAddress, g.User, g.Password, g.Database)
+}
+
+// Start runs PostgreSQL and GatewayD in front of it, and returns the gateway once both
+// accept connections. They are torn down when the test and its subtests complete. The
+// test is skipped if docker isn't installed and no database address is given.
+func Start(tb testing.TB, options Options) *Gateway {
+	tb.Helper()
+
+	options.PostgresImage = config.If[string](
+		options.PostgresImage != "", options.PostgresImage, DefaultPostgresImage)
+	options.User = config.If[string](options.User != "", options.User, DefaultPostgresUser)
+	options.Password = config.If[string](
+		options.Password != "", options.Password, DefaultPostgresPassword)
+	options.Database = config.If[string](
+		options.Database != "", options.Database, DefaultPostgresDatabase)
+	options.StartupTimeout = config.If[time.Duration](
+		options.StartupTimeout > 0, options.StartupTimeout, DefaultStartupTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), options.StartupTimeout)
+	defer cancel()
+
+	gateway := &Gateway{
+		PostgresAddress: options.PostgresAddress,
+		User:            options.User,
+		Password:        options.Password,
+		Database:        options.Database,
+	}
+	if gateway.PostgresAddress == "" {
+		if _, err := exec.LookPath("docker"); err != nil {
+			tb.Skip("Skipping, as docker isn't installed to run PostgreSQL")
+		}
+		container, address, err := runPostgres(ctx, options)
+		if container != "" {
+			tb.Cleanup(func() { removeContainer(container) })
+		}
+		if err != nil {
+			tb.Fatalf("failed to run the PostgreSQL container: %v", err)
+		}
+		gateway.container = container
+		gateway.PostgresAddress = address
+		if err := waitForPostgres(ctx, gateway); err != nil {
+			tb.Fatalf("failed to connect to PostgreSQL: %v", err)
+		}
+	}
+
+	address, err := freeAddress()
+	if err != nil {
+		tb.Fatalf("failed to find a free address for GatewayD: %v", err)
+	}
+	gateway.Address = address
+
+	conf, err := newConfig(ctx, tb.TempDir(), gateway, options)
+	if err != nil {
+		tb.Fatalf("failed to create the config of GatewayD: %v", err)
+	}
+	gateway.Config = conf
+
+	gateway.GatewayD = gatewayd.New(context.Background(), conf, options.GatewayD)
+	if err := gateway.GatewayD.Start(context.Background()); err != nil {
+		tb.Fatalf("failed to start GatewayD: %v", err)
+	}
+	tb.Cleanup(func() {
+		gateway.GatewayD.Stop(context.Background())
+		<-gateway.GatewayD.Done()
+	})
+	if err := waitForGatewayD(ctx, gateway); err != nil {
+		tb.Fatalf("failed to connect to GatewayD: %v", err)
+	}
+
+	return gateway
+}
+
+// newConfig writes the config files of GatewayD to the directory, and loads them with
+// the overrides of the options.
+func newConfig(
+	ctx context.Context, dir string, gateway *Gateway, options Options,
+) (*config.Config, error) {
+	// The clients connect to the database, and the APIs and the metrics server are
+	// disabled, so that several gateways can run at once.
+	globalConfig := fmt.Sprintf(`clients:
+  default:
+    address: %q
+servers:
+  default:
+    address: %q
+metrics:
+  default:
+    enabled: false
+api:
+  enabled: false
+`, gateway.PostgresAddress, gateway.Address)
+	globalConfigFile := filepath.Join(dir, config.GlobalConfigFilename)
+	if err := os.WriteFile(globalConfigFile, []byte(globalConfig), 0o600); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	pluginConfigFile := options.PluginConfigFile
+	if pluginConfigFile == "" {
+		pluginConfigFile = filepath.Join(dir, config.PluginsConfigFilename)
+		if err := os.WriteFile(pluginConfigFile, []byte("plugins: []\n"), 0o600); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+	}
+
+	conf := config.NewConfig(ctx, globalConfigFile, pluginConfigFile)
+	conf.InitConfig(ctx)
+	for key, value := range options.Config {
+		if err := conf.GlobalKoanf.Set(key, value); err != nil {
+			return nil, fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+	conf.UnmarshalGlobalConfig(ctx)
+	return conf, nil
+}
+
+// runPostgres runs the PostgreSQL container, and returns its ID and the address its
+// port is published on. The ID is returned even if the address isn't, so that the
+// container is removed.
+func runPostgres(ctx context.Context, options Options) (string, string, error) {
+	output, err := exec.CommandContext(ctx, "docker", "run", "--detach", "--publish-all",
+		"--env", "POSTGRES_USER="+options.User,
+		"--env", "POSTGRES_PASSWORD="+options.Password,
+		"--env", "POSTGRES_DB="+options.Database,
+		options.PostgresImage,
+	).Output()
+	if err != nil {
+		return "", "", commandError(err)
+	}
+	container := strings.TrimSpace(string(output))
+
+	output, err = exec.CommandContext(ctx, "docker", "port", container, postgresPort).Output()
+	if err != nil {
+		return container, "", commandError(err)
+	}
+	port, err := publishedPort(string(output))
+	return container, net.JoinHostPort("127.0.0.1", port), err
+}
+
+// publishedPort returns the port of the output of docker port, which lists the host
+// addresses the port of the container is published on, one per line.
+func publishedPort(output string) (string, error) {
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if _, port, err := net.SplitHostPort(strings.TrimSpace(line)); err == nil && port != "" {
+			return port, nil
+		}
+	}
+	return "", ErrNoPort
+}
+
+// removeContainer removes the container and its volumes.
+func removeContainer(container string) {
+	//nolint:errcheck
+	exec.Command("docker", "rm", "--force", "--volumes", container).Run()
+}
+
+// commandError adds the standard error of the failed command to its error.
+func commandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
+
+// waitForPostgres waits until PostgreSQL accepts the connections, which it only does
+// over TCP once the database is initialized.
+func waitForPostgres(ctx context.Context, gateway *Gateway) error {
+	return waitFor(ctx, func() error {
+		conn, err := network.ConnectPostgres(
+			ctx, gateway.PostgresAddress, gateway.User, gateway.Password, gateway.Database,
+			"gatewaydtest", config.DefaultDialTimeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close(ctx) //nolint:wrapcheck
+	})
+}
+
+// waitForGatewayD waits until the server of GatewayD listens.
+func waitForGatewayD(ctx context.Context, gateway *Gateway) error {
+	return waitFor(ctx, func() error {
+		conn, err := net.DialTimeout("tcp", gateway.Address, config.DefaultDialTimeout)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		return conn.Close() //nolint:wrapcheck
+	})
+}
+
+// waitFor retries the check until it succeeds, or returns its last error once the
+// context is done.
+func waitFor(ctx context.Context, check func() error) error {
+	ticker := time.NewTicker(readinessInterval)
+	defer ticker.Stop()
+	for {
+		err := check()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrNotReady, err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// freeAddress returns a local address whose port is free.
+func freeAddress() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	defer listener.Close()
+	return listener.Addr().String(), nil
+}
+
+// dsn returns the connection string of the address and the credentials.
+func dsn(address, user, password, database string) string {
+	return (&url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, password),
+		Host:     address,
+		Path:     "/" + database,
+		RawQuery: "sslmode=disable",
+	}).String()
+}
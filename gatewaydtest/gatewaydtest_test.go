@@ -0,0 +1,65 @@
+package gatewaydtest
+
+import (
+	"net"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStart tests that GatewayD is started in front of an existing database, and that
+// it is stopped once the test completes.
+func TestStart(t *testing.T) {
+	// The clients only need to connect to the database.
+	database, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer database.Close()
+	go func() {
+		for {
+			conn, err := database.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	var gateway *Gateway
+	t.Run("start", func(t *testing.T) {
+		gateway = Start(t, Options{
+			PostgresAddress: database.Addr().String(),
+			Password:        "p@ss",
+			Config:          map[string]interface{}{"pools.default.size": 2},
+		})
+
+		// The readiness check may still hold a client of the pool.
+		assert.Equal(t, 2, gateway.GatewayD.Pools[config.Default].Cap())
+		assert.Equal(t, database.Addr().String(), gateway.Config.Global.Clients[config.Default].Address)
+		assert.Equal(t, "postgres://postgres:p%40ss@"+gateway.Address+"/postgres?sslmode=disable",
+			gateway.DSN())
+		assert.Equal(t, "postgres://postgres:p%40ss@"+database.Addr().String()+"/postgres?sslmode=disable",
+			gateway.PostgresDSN())
+
+		conn, err := net.Dial("tcp", gateway.Address)
+		require.NoError(t, err)
+		conn.Close()
+	})
+
+	select {
+	case <-gateway.GatewayD.Done():
+	default:
+		t.Fatal("GatewayD didn't stop")
+	}
+}
+
+// TestPublishedPort tests that the port is parsed from the output of docker port.
+func TestPublishedPort(t *testing.T) {
+	port, err := publishedPort("0.0.0.0:49153\n[::]:49153\n")
+	require.NoError(t, err)
+	assert.Equal(t, "49153", port)
+
+	_, err = publishedPort("")
+	assert.ErrorIs(t, err, ErrNoPort)
+}
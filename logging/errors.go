@@ -0,0 +1,18 @@
+package logging
+
+import (
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	// Log the GatewayD errors with their codes, names and categories, so that the
+	// logs can be filtered and alerted on by error code. The errors that wrap them
+	// are logged as is, to keep their messages.
+	zerolog.ErrorMarshalFunc = func(err error) interface{} {
+		if gErr, ok := err.(*gerr.GatewayDError); ok && gErr != nil { //nolint:errorlint
+			return gErr
+		}
+		return err
+	}
+}
@@ -0,0 +1,25 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestErrorMarshalFunc tests that the GatewayD errors are logged with their taxonomy.
+func TestErrorMarshalFunc(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := zerolog.New(&buffer)
+
+	logger.Error().Err(gerr.NewGatewayDError(gerr.ErrCodePoolExhausted, "pool is exhausted", nil)).Send()
+	assert.Contains(t, buffer.String(),
+		`"error":{"message":"pool is exhausted","code":7,"name":"POOL_EXHAUSTED","category":"pool"}`)
+
+	buffer.Reset()
+	logger.Error().Err(io.EOF).Send()
+	assert.Contains(t, buffer.String(), `"error":"EOF"`)
+}
@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"errors"
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+var (
+	filesMu sync.Mutex
+	// files are the log files of the loggers, which are rotated together.
+	files []*lumberjack.Logger
+)
+
+// addFile adds the log file to the ones rotated by RotateFiles.
+func addFile(file *lumberjack.Logger) *lumberjack.Logger {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+
+	files = append(files, file)
+	return file
+}
+
+// RotateFiles closes the log files of all the loggers, moves them aside and opens new
+// ones, e.g. when logrotate moved them.
+func RotateFiles() error {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+
+	var errs []error
+	for _, file := range files {
+		if err := file.Rotate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
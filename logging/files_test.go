@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// TestRotateFiles tests that the log files are moved aside and opened again.
+func TestRotateFiles(t *testing.T) {
+	// Only rotate the file of this test.
+	filesMu.Lock()
+	previous := files
+	files = []*lumberjack.Logger{}
+	filesMu.Unlock()
+	defer func() {
+		filesMu.Lock()
+		files = previous
+		filesMu.Unlock()
+	}()
+
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "gatewayd.log")
+	logger := NewLogger(context.Background(), LoggerConfig{
+		Output:     []config.LogOutput{config.File},
+		FileName:   fileName,
+		MaxBackups: config.DefaultMaxBackups,
+		Level:      zerolog.DebugLevel,
+		TimeFormat: zerolog.TimeFormatUnix,
+	})
+	logger.Info().Msg("Before the rotation")
+
+	require.NoError(t, RotateFiles())
+	logger.Info().Msg("After the rotation")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	data, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "After the rotation")
+	assert.NotContains(t, string(data), "Before the rotation")
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 
 	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/recorder"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/otel"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -34,6 +35,9 @@ type LoggerConfig struct {
 	MaxAge     int
 	Compress   bool
 	LocalTime  bool
+
+	// Tail keeps the last lines of the logs, e.g. for the dashboard, if set.
+	Tail *Tail
 }
 
 // NewLogger creates a new logger with the given configuration.
@@ -58,14 +62,14 @@ func NewLogger(ctx context.Context, cfg LoggerConfig) zerolog.Logger {
 			outputs = append(outputs, os.Stderr)
 		case config.File:
 			outputs = append(
-				outputs, &lumberjack.Logger{
+				outputs, addFile(&lumberjack.Logger{
 					Filename:   cfg.FileName,
 					MaxSize:    cfg.MaxSize,
 					MaxBackups: cfg.MaxBackups,
 					MaxAge:     cfg.MaxAge,
 					Compress:   cfg.Compress,
 					LocalTime:  cfg.LocalTime,
-				},
+				}),
 			)
 		case config.Syslog:
 			log.Fatal("Syslog is not supported on Windows")
@@ -79,6 +83,12 @@ func NewLogger(ctx context.Context, cfg LoggerConfig) zerolog.Logger {
 	zerolog.SetGlobalLevel(cfg.Level)
 	zerolog.TimeFieldFormat = cfg.TimeFormat
 
+	if cfg.Tail != nil {
+		outputs = append(outputs, cfg.Tail)
+	}
+	// The errors are always kept in the flight recorder.
+	outputs = append(outputs, recorder.LogWriter{})
+
 	multiWriter := zerolog.MultiLevelWriter(outputs...)
 	logger := zerolog.New(multiWriter)
 	logger = logger.With().Timestamp().Logger()
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// TrafficCategory are the debug messages of the data received and sent.
+	TrafficCategory = "traffic"
+	// QueriesCategory are the debug messages of the queries received.
+	QueriesCategory = "queries"
+)
+
+// Categories are the log categories whose debug messages can be sampled.
+var Categories = []string{TrafficCategory, QueriesCategory}
+
+// rateSampler keeps the fraction of the messages.
+type rateSampler float64
+
+func (r rateSampler) Sample(zerolog.Level) bool {
+	return rand.Float64() < float64(r) //nolint:gosec
+}
+
+// samplers sample the debug and trace messages of the categories, by category.
+var samplers sync.Map
+
+// ConfigureSampling sets the fraction of the debug and trace messages of each category
+// that are logged, between 0 and 1. The messages of the other categories and levels are
+// always logged.
+func ConfigureSampling(rates map[string]float64) *gerr.GatewayDError {
+	for category, rate := range rates {
+		if !slices.Contains(Categories, category) {
+			return gerr.ErrValidationFailed.Wrap(fmt.Errorf(
+				"unknown log category %q in the log sampling, it must be one of %v",
+				category, Categories))
+		}
+		if rate < 0 || rate > 1 {
+			return gerr.ErrValidationFailed.Wrap(fmt.Errorf(
+				"invalid log sampling rate %v of %q, it must be between 0 and 1", rate, category))
+		}
+	}
+
+	for _, category := range Categories {
+		rate, ok := rates[category]
+		if !ok {
+			samplers.Delete(category)
+			continue
+		}
+		sampler := rateSampler(rate)
+		samplers.Store(category, &zerolog.LevelSampler{TraceSampler: sampler, DebugSampler: sampler})
+	}
+	return nil
+}
+
+// Sampled returns the logger of the messages of the category, which samples its debug
+// and trace messages if the category is sampled.
+func Sampled(logger zerolog.Logger, category string) *zerolog.Logger {
+	if sampler, ok := samplers.Load(category); ok {
+		logger = logger.Sample(sampler.(zerolog.Sampler)) //nolint:forcetypeassert
+	}
+	return &logger
+}
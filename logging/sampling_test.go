@@ -0,0 +1,35 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSampled tests that only the debug messages of the sampled categories are sampled.
+func TestSampled(t *testing.T) {
+	t.Cleanup(func() { require.Nil(t, ConfigureSampling(nil)) })
+
+	assert.NotNil(t, ConfigureSampling(map[string]float64{"unknown": 0.5}))
+	assert.NotNil(t, ConfigureSampling(map[string]float64{TrafficCategory: 1.5}))
+	require.Nil(t, ConfigureSampling(map[string]float64{TrafficCategory: 0}))
+
+	var output bytes.Buffer
+	logger := zerolog.New(&output).Level(zerolog.DebugLevel)
+	Sampled(logger, TrafficCategory).Debug().Msg("traffic")
+	Sampled(logger, TrafficCategory).Error().Msg("traffic error")
+	Sampled(logger, QueriesCategory).Debug().Msg("query")
+	assert.NotContains(t, output.String(), `"traffic"`)
+	assert.Contains(t, output.String(), "traffic error")
+	assert.Contains(t, output.String(), `"query"`)
+
+	// All the messages are logged once the category isn't sampled anymore.
+	output.Reset()
+	require.Nil(t, ConfigureSampling(map[string]float64{}))
+	Sampled(logger, TrafficCategory).Debug().Msg("traffic")
+	assert.Equal(t, 1, strings.Count(output.String(), `"traffic"`))
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Tail keeps the last lines written by a logger, which are its JSON events.
+type Tail struct {
+	mu    sync.Mutex
+	lines [][]byte
+	next  int
+	full  bool
+}
+
+// NewTail creates a tail of up to size lines.
+func NewTail(size int) *Tail {
+	return &Tail{lines: make([][]byte, max(size, 1))}
+}
+
+// Write keeps the line, replacing the oldest one if the tail is full. The line is
+// copied, as zerolog reuses its buffers.
+func (t *Tail) Write(line []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lines[t.next] = append([]byte(nil), line...)
+	t.next++
+	if t.next == len(t.lines) {
+		t.next = 0
+		t.full = true
+	}
+	return len(line), nil
+}
+
+// Lines returns the kept lines, from the oldest to the newest.
+func (t *Tail) Lines() []json.RawMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := make([]json.RawMessage, 0, len(t.lines))
+	if t.full {
+		for _, line := range t.lines[t.next:] {
+			lines = append(lines, line)
+		}
+	}
+	for _, line := range t.lines[:t.next] {
+		lines = append(lines, line)
+	}
+	return lines
+}
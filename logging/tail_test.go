@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTail tests that the tail keeps the last lines of the logger.
+func TestTail(t *testing.T) {
+	tail := NewTail(2)
+	assert.Empty(t, tail.Lines())
+
+	logger := NewLogger(context.Background(), LoggerConfig{
+		Output:     []config.LogOutput{},
+		Level:      zerolog.InfoLevel,
+		TimeFormat: zerolog.TimeFormatUnix,
+		Tail:       tail,
+	})
+	logger.Info().Msg("first")
+	logger.Info().Msg("second")
+	logger.Info().Msg("third")
+
+	lines := tail.Lines()
+	require.Len(t, lines, 2)
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[0], &event))
+	assert.Equal(t, "second", event["message"])
+	require.NoError(t, json.Unmarshal(lines[1], &event))
+	assert.Equal(t, "third", event["message"])
+}
@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"slices"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -15,11 +17,96 @@ var (
 		Name:      "client_connections",
 		Help:      "Number of client connections",
 	})
+	ListenerConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "listener_connections",
+		Help:      "Number of client connections, by the address they were accepted on",
+	}, []string{"listener"})
+	ListenerConnectionsAccepted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "listener_connections_accepted_total",
+		Help:      "Number of client connections accepted, by the address they were accepted on",
+	}, []string{"listener"})
+	ClientConnectionsAccepted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "client_connections_accepted_total",
+		Help:      "Number of client connections accepted, by address family",
+	}, []string{"family"})
+	EventLoopConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "event_loop_connections",
+		Help:      "Number of client connections served by the event loop workers",
+	})
 	ServerConnections = promauto.NewGauge(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Name:      "server_connections",
 		Help:      "Number of server connections",
 	})
+	SSHTunnelConnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "ssh_tunnel_connects_total",
+		Help:      "Number of connections made to the SSH bastions, including reconnections",
+	})
+	IAMAuthTokenRefreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "iam_auth_token_refreshes_total",
+		Help:      "Number of IAM auth tokens fetched for the database, by provider",
+	}, []string{"provider"})
+	IAMAuthTokenFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "iam_auth_token_failures_total",
+		Help:      "Number of failures to fetch the IAM auth tokens, by provider",
+	}, []string{"provider"})
+	TLSCertificateReloads = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "tls_certificate_reloads_total",
+		Help:      "Number of times the TLS certificate files were reloaded",
+	})
+	TLSCertificateReloadFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "tls_certificate_reload_failures_total",
+		Help:      "Number of times the TLS certificate files failed to reload",
+	})
+	SPIFFESVIDUpdates = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "spiffe_svid_updates_total",
+		Help:      "Number of X.509 SVIDs received from the SPIFFE Workload API",
+	})
+	ProxyUsageBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_usage_bytes_total",
+		Help:      "Number of bytes proxied, by the labels of the usage template and direction",
+	}, append(slices.Clone(ConnectionLabels), "direction"))
+	ProxyUsageMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_usage_messages_total",
+		Help:      "Number of protocol messages proxied, by the labels of the usage template and direction",
+	}, append(slices.Clone(ConnectionLabels), "direction"))
+	ProxyClientConnections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_client_connections_total",
+		Help:      "Number of client connections started, by the labels of the connections template",
+	}, ConnectionLabels)
+	ProxyBackendErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_backend_errors_total",
+		Help:      "Number of error responses of the database, by the labels of the errors template and SQLSTATE code",
+	}, append(slices.Clone(ConnectionLabels), "code"))
+	MetricLabelsCollapsed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "metric_labels_collapsed_total",
+		Help:      "Number of label values collapsed into other past the cardinality limit, by label",
+	}, []string{"label"})
+	MaintenanceQueuedConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "maintenance_queued_connections",
+		Help:      "Number of new client connections waiting for the maintenance to end",
+	})
+	MaintenanceRejectedConnections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "maintenance_rejected_connections_total",
+		Help:      "Number of new client connections rejected during maintenance",
+	})
 	TLSConnections = promauto.NewGauge(prometheus.GaugeOpts{
 		Namespace: Namespace,
 		Name:      "tls_connections",
@@ -70,6 +157,129 @@ var (
 		Name:      "plugin_hooks_executed_total",
 		Help:      "Number of plugin hooks executed",
 	})
+	PluginHookDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_duration_seconds",
+		Help:      "Duration of running the plugin hooks registered for a hook",
+		Buckets:   prometheus.DefBuckets,
+	})
+	PluginHookBatchesSent = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_batches_sent_total",
+		Help:      "Number of batches of hook arguments sent to the plugins",
+	})
+	PluginHookBatchesDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_batches_dropped_total",
+		Help:      "Number of batches of hook arguments dropped, because the queue was full",
+	})
+	PluginAsyncHooksDispatched = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_async_hooks_dispatched_total",
+		Help:      "Number of async plugin hooks dispatched without waiting for the result",
+	})
+	PluginAsyncHooksDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_async_hooks_dropped_total",
+		Help:      "Number of async plugin hooks dropped, because the queue was full",
+	})
+	PluginMetricSamplesDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_metric_samples_dropped_total",
+		Help:      "Number of metric samples not sent to the onMetric hooks, because the queue was full",
+	})
+	PluginCapabilityViolations = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_capability_violations_total",
+		Help:      "Number of plugin hook results reverted, because the plugin lacked the capability",
+	})
+	PluginHookInvocations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_invocations_total",
+		Help:      "Number of times each plugin hook was invoked",
+	}, []string{"plugin", "hook"})
+	PluginHookLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_latency_seconds",
+		Help:      "Latency of each plugin hook",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"plugin", "hook"})
+	PluginHookErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_errors_total",
+		Help:      "Number of errors returned by each plugin hook",
+	}, []string{"plugin", "hook"})
+	PluginHookPanics = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_panics_total",
+		Help:      "Number of panics recovered while running each plugin hook",
+	}, []string{"plugin", "hook"})
+	PluginHookModifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_modifications_total",
+		Help:      "Number of times each plugin hook modified its arguments",
+	}, []string{"plugin", "hook"})
+	PluginHookTerminations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_terminations_total",
+		Help:      "Number of times each plugin hook terminated the request",
+	}, []string{"plugin", "hook"})
+	PluginPreflightFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_preflight_failures_total",
+		Help:      "Number of times each plugin failed its preflight checks at startup",
+	}, []string{"plugin"})
+	PluginHookCacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_cache_hits_total",
+		Help:      "Number of calls of each plugin hook served from the cached results",
+	}, []string{"plugin", "hook"})
+	PluginHookCacheMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_cache_misses_total",
+		Help:      "Number of calls of each cacheable plugin hook that weren't cached",
+	}, []string{"plugin", "hook"})
+	PluginHookCompressedCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_compressed_calls_total",
+		Help:      "Number of calls of each plugin hook whose arguments were compressed",
+	}, []string{"plugin", "compressor"})
+	PluginHookRunsSampledOut = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_runs_sampled_out_total",
+		Help:      "Number of runs of the hooks that skipped the plugins, as they weren't sampled",
+	}, []string{"hook"})
+	PluginHookBudgetViolations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_budget_violations_total",
+		Help:      "Number of times the hook chain of each hook exceeded its latency budget",
+	}, []string{"hook"})
+	PluginHookBudgetDemotions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "plugin_hook_budget_demotions_total",
+		Help:      "Number of plugin hooks demoted for exceeding the latency budget of their hook chain, by action",
+	}, []string{"plugin", "hook", "action"})
+	ScheduledJobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "scheduled_job_runs_total",
+		Help:      "Number of runs of each scheduled job",
+	}, []string{"job"})
+	ScheduledJobErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "scheduled_job_errors_total",
+		Help:      "Number of failed runs of each scheduled job",
+	}, []string{"job"})
+	ScheduledJobMissed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "scheduled_job_missed_total",
+		Help:      "Number of runs of each scheduled job missed, because the previous run was still running",
+	}, []string{"job"})
+	ScheduledJobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "scheduled_job_duration_seconds",
+		Help:      "Duration of the runs of each scheduled job",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"job"})
 	ProxyHealthChecks = promauto.NewCounter(prometheus.CounterOpts{
 		Namespace: Namespace,
 		Name:      "proxy_health_checks_total",
@@ -95,4 +305,300 @@ var (
 		Name:      "proxy_passthrough_terminations_total",
 		Help:      "Number of proxy passthrough terminations by plugins",
 	})
+	ProxyQueryLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "proxy_query_latency_seconds",
+		Help:      "Time between receiving a query from the client and receiving its response from the database",
+		Buckets:   prometheus.DefBuckets,
+	})
+	ProxyQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "proxy_queue_depth",
+		Help:      "Number of client connections waiting for an available connection in the pool",
+	})
+	ProxyQueueWaitDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "proxy_queue_wait_seconds",
+		Help:      "Time spent by client connections waiting for an available connection in the pool",
+		Buckets:   prometheus.DefBuckets,
+	})
+	ProxyQueueRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_queue_rejections_total",
+		Help:      "Number of client connections rejected because the queue was full or the wait timed out",
+	})
+	ProxyConcurrencyLimit = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "proxy_concurrency_limit",
+		Help:      "Current adaptive limit of requests in flight to the database",
+	})
+	ProxyLimiterQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "proxy_limiter_queue_depth",
+		Help:      "Number of queries waiting for a slot of the adaptive limiter, by priority class",
+	}, []string{"class"})
+	ProxyInFlightRequests = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "proxy_in_flight_requests",
+		Help:      "Number of requests in flight to the database",
+	})
+	ProxyBackendLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "proxy_backend_latency_seconds",
+		Help:      "Time between sending a request to the database and receiving the first response",
+		Buckets:   prometheus.DefBuckets,
+	})
+	ProxyShedRequests = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_shed_requests_total",
+		Help:      "Number of requests rejected because the concurrency limit was reached",
+	})
+	ProxyIngressThrottled = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_ingress_throttled_seconds_total",
+		Help:      "Time spent throttling the traffic from the clients to the database",
+	})
+	ProxyEgressThrottled = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_egress_throttled_seconds_total",
+		Help:      "Time spent throttling the traffic from the database to the clients",
+	})
+	ProxyStreamedResponses = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_streamed_responses_total",
+		Help:      "Number of responses too large to be buffered that were streamed to the client",
+	})
+	ProxyOversizeMessages = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_oversize_messages_total",
+		Help:      "Number of messages larger than the maximum message size",
+	})
+	ProxySplicedConnections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_spliced_connections_total",
+		Help:      "Number of client connections whose traffic took the kernel splicing fast path",
+	})
+	ProxyCompressedConnections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_compressed_connections_total",
+		Help:      "Number of client connections that negotiated compression",
+	})
+	ProxyUncompressedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_uncompressed_bytes_total",
+		Help:      "Number of bytes passed through the compressed client connections before compression",
+	}, []string{"direction"})
+	ProxyCompressedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_compressed_bytes_total",
+		Help:      "Number of bytes passed through the compressed client connections after compression",
+	}, []string{"direction"})
+	ProxyFirewallDenials = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_firewall_denials_total",
+		Help:      "Number of queries denied by the firewall, by rule",
+	}, []string{"rule"})
+	ProxyShardedQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_sharded_queries_total",
+		Help:      "Number of queries routed by the sharding router, by shard",
+	}, []string{"shard"})
+	ProxyCrossShardRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_cross_shard_rejections_total",
+		Help:      "Number of cross-shard statements rejected, as no hook handled them",
+	})
+	ProxyStatementTimeouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_statement_timeouts_total",
+		Help:      "Number of queries cancelled for exceeding their statement timeout, by rule",
+	}, []string{"rule"})
+	ProxyResultLimitsExceeded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_result_limits_exceeded_total",
+		Help:      "Number of result sets stopped for exceeding their maximum rows or bytes, by rule",
+	}, []string{"rule"})
+	ProxyIdleTransactions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_idle_transactions_total",
+		Help:      "Number of transactions ended for being idle for too long, by database and action",
+	}, []string{"database", "action"})
+	ProxyBackendReconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_backend_reconnects_total",
+		Help:      "Number of reconnections of the connections of the pools to the database, by reason and result",
+	}, []string{"reason", "result"})
+	ProxyBackendFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_backend_failures_total",
+		Help:      "Number of failures to send to, receive from or reconnect to the database",
+	})
+	ProxyPanics = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_panics_total",
+		Help:      "Number of panics recovered while serving the connections, which closed them",
+	})
+	ProxyInjectedFaults = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_injected_faults_total",
+		Help:      "Number of faults injected in the traffic of the proxies, by fault",
+	}, []string{"fault"})
+	ProxySessionRecoveries = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_session_recoveries_total",
+		Help:      "Number of sessions moved transparently to a new server connection after theirs died",
+	})
+	ProxyReadRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_read_retries_total",
+		Help:      "Number of read-only queries retried transparently on another standby after theirs died, by result",
+	}, []string{"result"})
+	ProxyAffinitySessions = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "proxy_affinity_sessions",
+		Help:      "Number of sessions hashed to each standby by the session affinity",
+	}, []string{"address"})
+	ProxySplitReads = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_split_reads_total",
+		Help:      "Number of read-only queries sent to the reads config group by the read/write split",
+	})
+	ProxyReadYourWrites = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_read_your_writes_total",
+		Help:      "Number of read-only queries kept on the backend in the read-your-writes window of a write",
+	})
+	ProxyReplicationLagBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "proxy_replication_lag_bytes",
+		Help:      "Number of bytes of WAL the slowest standby has yet to replay, as last polled by the read/write split",
+	})
+	ProxyTransactions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_transactions_total",
+		Help:      "Number of transactions of the sessions that ended, by outcome",
+	}, []string{"outcome"})
+	ProxyTransactionDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "proxy_transaction_duration_seconds",
+		Help:      "Time between the first request of a transaction and the response that ended it",
+		Buckets:   prometheus.DefBuckets,
+	})
+	ProxySessionDrops = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_session_drops_total",
+		Help:      "Number of sessions disconnected because their server connection died and couldn't be recovered",
+	})
+	ProxyCachedStatements = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "proxy_cached_statements",
+		Help:      "Number of prepared statements cached on the server connections",
+	})
+	ProxyStatementCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_statement_cache_hits_total",
+		Help:      "Number of Parse messages answered with a statement already prepared on the server connection",
+	})
+	ProxyStatementCacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_statement_cache_misses_total",
+		Help:      "Number of Parse messages whose statement was prepared on the server connection and cached",
+	})
+	BackendDials = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "backend_dials_total",
+		Help:      "Number of connections made to the databases, by address family",
+	}, []string{"family"})
+	BackendAddressChanges = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "backend_address_changes_total",
+		Help:      "Number of times the IP addresses of the hostnames of the databases changed",
+	})
+	BackendResolveFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "backend_resolve_failures_total",
+		Help:      "Number of failures to re-resolve the hostnames of the databases",
+	})
+	ClusterPrimaryChanges = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "cluster_primary_changes_total",
+		Help:      "Number of times the primary of the PostgreSQL clusters changed",
+	})
+	ClusterMemberCheckFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "cluster_member_check_failures_total",
+		Help:      "Number of failures to check whether the members of the PostgreSQL clusters are in recovery",
+	}, []string{"member"})
+	BackendHealthCheckLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "backend_health_check_latency_seconds",
+		Help:      "Time the health queries took on the databases, by address",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"address"})
+	BackendHealthCheckFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "backend_health_check_failures_total",
+		Help:      "Number of health checks of the databases that failed or exceeded the latency threshold, by address",
+	}, []string{"address"})
+	BackendDegraded = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "backend_degraded",
+		Help:      "Whether the databases are degraded (1) or healthy (0) according to their health checks, by address",
+	}, []string{"address"})
+	CanaryLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "canary_latency_seconds",
+		Help:      "Time the canary sessions took to connect and to run their query through the servers, by address and phase",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"address", "phase"})
+	CanaryFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "canary_failures_total",
+		Help:      "Number of canary sessions that failed to connect or to run their query through the servers, by address",
+	}, []string{"address"})
+	ProxyConnectionMemoryRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_connection_memory_rejections_total",
+		Help:      "Number of requests rejected because their connection would exceed its memory limit",
+	})
+	ServerGoroutines = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "server_goroutines",
+		Help:      "Number of goroutines the servers run for their connections, by address",
+	}, []string{"address"})
+	ServerSniffedConnections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "server_sniffed_connections_total",
+		Help:      "Number of connections whose protocol was detected by the servers, by protocol",
+	}, []string{"protocol"})
+	ServerGoroutineRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "server_goroutine_rejections_total",
+		Help:      "Number of client connections rejected because the servers ran too many goroutines",
+	})
+	ProxyCircuitBreakerRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_circuit_breaker_rejections_total",
+		Help:      "Number of client connections rejected because the database was degraded",
+	})
+	ProxyConnectionQuotaRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "proxy_connection_quota_rejections_total",
+		Help:      "Number of client connections rejected because the connection quota of the proxy was exceeded",
+	})
+	SharedLimitsSyncFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "shared_limits_sync_failures_total",
+		Help:      "Number of failures to sync the state of the limits with the Redis shared by the instances",
+	})
+	SharedLimitsAvailable = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "shared_limits_available",
+		Help:      "Whether the state of the limits shared by the instances is available (1) or the limits fall back (0)",
+	})
+	DataAPIRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "data_api_requests_total",
+		Help:      "Number of requests to the data API, by table and status code",
+	}, []string{"table", "code"})
 )
@@ -0,0 +1,328 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DashboardUID        = "gatewayd"
+	DashboardDatasource = "${DS_PROMETHEUS}"
+
+	dashboardSchemaVersion = 38
+	dashboardPanelWidth    = 12
+	dashboardPanelHeight   = 8
+	dashboardRateInterval  = "5m"
+)
+
+// DashboardOptions holds the values from the running config that the generated
+// dashboards and alert rules depend on.
+type DashboardOptions struct {
+	// PoolSize is the total number of connections in the non-elastic pools.
+	// Pool exhaustion alerts are not generated if it is zero.
+	PoolSize int
+	// HookLatencyThreshold is the hook latency above which an alert fires.
+	HookLatencyThreshold time.Duration
+}
+
+type dashboardDatasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type dashboardTarget struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+	RefID        string `json:"refId"`
+}
+
+type dashboardGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type dashboardPanel struct {
+	ID          int                    `json:"id"`
+	Type        string                 `json:"type"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Datasource  dashboardDatasource    `json:"datasource"`
+	GridPos     dashboardGridPos       `json:"gridPos"`
+	FieldConfig map[string]interface{} `json:"fieldConfig"`
+	Targets     []dashboardTarget      `json:"targets"`
+}
+
+type alertRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+type alertRuleGroup struct {
+	Name  string      `yaml:"name"`
+	Rules []alertRule `yaml:"rules"`
+}
+
+// metricName returns the fully-qualified name of a built-in metric.
+func metricName(name string) string {
+	return Namespace + "_" + name
+}
+
+// selector returns the metric name filtered by the instance dashboard variable.
+func selector(name string) string {
+	return metricName(name) + `{instance=~"$instance"}`
+}
+
+// rate returns the per-second rate of a counter filtered by the instance variable.
+func rate(name string) string {
+	return fmt.Sprintf("sum(rate(%s[%s]))", selector(name), dashboardRateInterval)
+}
+
+// quantile returns the given quantile of a histogram filtered by the instance variable.
+func quantile(q float64, name string) string {
+	return fmt.Sprintf(
+		"histogram_quantile(%g, sum by (le) (rate(%s[%s])))",
+		q, metricName(name)+`_bucket{instance=~"$instance"}`, dashboardRateInterval)
+}
+
+// GrafanaDashboard returns a Grafana dashboard in JSON, ready to be imported, that
+// charts the built-in metrics. The Prometheus datasource is chosen on import.
+func GrafanaDashboard(options DashboardOptions) ([]byte, error) {
+	type panelSpec struct {
+		title       string
+		description string
+		unit        string
+		targets     []dashboardTarget
+	}
+
+	proxiedConnections := []dashboardTarget{
+		{Expr: "sum(" + selector("proxied_connections") + ")", LegendFormat: "proxied"},
+	}
+	if options.PoolSize > 0 {
+		proxiedConnections = append(proxiedConnections, dashboardTarget{
+			Expr: fmt.Sprintf("vector(%d)", options.PoolSize), LegendFormat: "pool size",
+		})
+	}
+
+	specs := []panelSpec{
+		{
+			title:       "Client connections",
+			description: "Number of clients connected to GatewayD",
+			unit:        "short",
+			targets: []dashboardTarget{
+				{Expr: "sum(" + selector("client_connections") + ")", LegendFormat: "clients"},
+				{Expr: "sum(" + selector("tls_connections") + ")", LegendFormat: "TLS"},
+			},
+		},
+		{
+			title:       "Pool usage",
+			description: "Number of database connections in use compared to the pool size",
+			unit:        "short",
+			targets:     proxiedConnections,
+		},
+		{
+			title:       "Server connections",
+			description: "Number of connections opened by GatewayD to the database",
+			unit:        "short",
+			targets: []dashboardTarget{
+				{Expr: "sum(" + selector("server_connections") + ")", LegendFormat: "server"},
+			},
+		},
+		{
+			title:       "Backend failures",
+			description: "Rate of failures to send to, receive from or reconnect to the database",
+			unit:        "ops",
+			targets: []dashboardTarget{
+				{Expr: rate("proxy_backend_failures_total"), LegendFormat: "failures"},
+			},
+		},
+		{
+			title:       "Passthroughs",
+			description: "Rate of messages passed through between clients and the database",
+			unit:        "ops",
+			targets: []dashboardTarget{
+				{Expr: rate("proxy_passthroughs_to_server_total"), LegendFormat: "to server"},
+				{Expr: rate("proxy_passthroughs_to_client_total"), LegendFormat: "to client"},
+				{Expr: rate("proxy_passthrough_terminations_total"), LegendFormat: "terminated"},
+			},
+		},
+		{
+			title:       "Traffic",
+			description: "Rate of bytes passed through GatewayD",
+			unit:        "Bps",
+			targets: []dashboardTarget{
+				{Expr: rate("bytes_received_from_client_sum"), LegendFormat: "from client"},
+				{Expr: rate("bytes_sent_to_server_sum"), LegendFormat: "to server"},
+				{Expr: rate("bytes_received_from_server_sum"), LegendFormat: "from server"},
+				{Expr: rate("bytes_sent_to_client_sum"), LegendFormat: "to client"},
+			},
+		},
+		{
+			title:       "Hook latency",
+			description: "Latency of running the plugin hooks",
+			unit:        "s",
+			targets: []dashboardTarget{
+				{Expr: quantile(0.5, "plugin_hook_duration_seconds"), LegendFormat: "p50"},  //nolint:gomnd
+				{Expr: quantile(0.99, "plugin_hook_duration_seconds"), LegendFormat: "p99"}, //nolint:gomnd
+			},
+		},
+		{
+			title:       "Hooks executed",
+			description: "Rate of plugin hook executions",
+			unit:        "ops",
+			targets: []dashboardTarget{
+				{Expr: rate("plugin_hooks_executed_total"), LegendFormat: "hooks"},
+			},
+		},
+	}
+
+	panels := make([]dashboardPanel, 0, len(specs))
+	for index, spec := range specs {
+		for refID := range spec.targets {
+			spec.targets[refID].RefID = string(rune('A' + refID))
+		}
+		panels = append(panels, dashboardPanel{
+			ID:          index + 1,
+			Type:        "timeseries",
+			Title:       spec.title,
+			Description: spec.description,
+			Datasource:  dashboardDatasource{Type: "prometheus", UID: DashboardDatasource},
+			GridPos: dashboardGridPos{
+				H: dashboardPanelHeight,
+				W: dashboardPanelWidth,
+				X: (index % 2) * dashboardPanelWidth,  //nolint:gomnd
+				Y: (index / 2) * dashboardPanelHeight, //nolint:gomnd
+			},
+			FieldConfig: map[string]interface{}{
+				"defaults":  map[string]interface{}{"unit": spec.unit},
+				"overrides": []interface{}{},
+			},
+			Targets: spec.targets,
+		})
+	}
+
+	dashboard := map[string]interface{}{
+		"__inputs": []map[string]string{
+			{
+				"name":       "DS_PROMETHEUS",
+				"label":      "Prometheus",
+				"type":       "datasource",
+				"pluginId":   "prometheus",
+				"pluginName": "Prometheus",
+			},
+		},
+		"uid":           DashboardUID,
+		"title":         "GatewayD",
+		"tags":          []string{"gatewayd"},
+		"timezone":      "browser",
+		"schemaVersion": dashboardSchemaVersion,
+		"refresh":       "30s",
+		"time":          map[string]string{"from": "now-1h", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{
+				{
+					"name":       "instance",
+					"label":      "Instance",
+					"type":       "query",
+					"datasource": dashboardDatasource{Type: "prometheus", UID: DashboardDatasource},
+					"query":      fmt.Sprintf("label_values(%s, instance)", metricName("client_connections")),
+					"refresh":    2, //nolint:gomnd
+					"includeAll": true,
+					"multi":      true,
+				},
+			},
+		},
+		"panels": panels,
+	}
+
+	return json.MarshalIndent(dashboard, "", "  ") //nolint:wrapcheck
+}
+
+// PrometheusAlertRules returns Prometheus alerting rules in YAML for pool exhaustion,
+// hook latency and backend failures, with thresholds taken from the running config.
+func PrometheusAlertRules(options DashboardOptions) ([]byte, error) {
+	rules := []alertRule{}
+
+	if options.PoolSize > 0 {
+		rules = append(rules,
+			alertRule{
+				Alert:  "GatewayDPoolExhausted",
+				Expr:   fmt.Sprintf("sum(%s) >= %d", metricName("proxied_connections"), options.PoolSize),
+				For:    "1m",
+				Labels: map[string]string{"severity": "critical"},
+				Annotations: map[string]string{
+					"summary": "GatewayD connection pool is exhausted",
+					"description": fmt.Sprintf(
+						"All %d pooled database connections are in use, new clients are rejected.",
+						options.PoolSize),
+				},
+			},
+			alertRule{
+				Alert: "GatewayDPoolNearlyExhausted",
+				Expr: fmt.Sprintf(
+					"sum(%s) >= %d", metricName("proxied_connections"), nearlyExhausted(options.PoolSize)),
+				For:    "5m",
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "GatewayD connection pool is nearly exhausted",
+					"description": "Over 90% of the pooled database connections are in use.",
+				},
+			},
+		)
+	}
+
+	if options.HookLatencyThreshold > 0 {
+		rules = append(rules, alertRule{
+			Alert: "GatewayDHighHookLatency",
+			Expr: fmt.Sprintf(
+				"histogram_quantile(0.99, sum by (le) (rate(%s_bucket[%s]))) > %g",
+				metricName("plugin_hook_duration_seconds"),
+				dashboardRateInterval,
+				options.HookLatencyThreshold.Seconds()),
+			For:    "5m",
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary": "GatewayD plugin hooks are slow",
+				"description": fmt.Sprintf(
+					"The p99 latency of the plugin hooks is above %s.", options.HookLatencyThreshold),
+			},
+		})
+	}
+
+	rules = append(rules, alertRule{
+		Alert: "GatewayDBackendFailures",
+		Expr: fmt.Sprintf(
+			"sum(increase(%s[%s])) > 0", metricName("proxy_backend_failures_total"), dashboardRateInterval),
+		For:    "1m",
+		Labels: map[string]string{"severity": "critical"},
+		Annotations: map[string]string{
+			"summary":     "GatewayD is failing to reach the database",
+			"description": "GatewayD failed to send to, receive from or reconnect to the database.",
+		},
+	})
+
+	var output bytes.Buffer
+	encoder := yaml.NewEncoder(&output)
+	encoder.SetIndent(2) //nolint:gomnd
+	if err := encoder.Encode(map[string][]alertRuleGroup{
+		"groups": {{Name: "gatewayd", Rules: rules}},
+	}); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return output.Bytes(), nil
+}
+
+// nearlyExhausted returns the number of connections at which the pool is
+// considered nearly exhausted, which is 90% of the pool size, rounded up.
+func nearlyExhausted(poolSize int) int {
+	return (poolSize*9 + 9) / 10 //nolint:gomnd
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// builtinMetricNames returns the names of the registered built-in metrics,
+// including the series that summaries and histograms expand to.
+func builtinMetricNames(t *testing.T) map[string]bool {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	names := map[string]bool{}
+	for _, family := range families {
+		names[family.GetName()] = true
+		for _, suffix := range []string{"_sum", "_count", "_bucket"} {
+			names[family.GetName()+suffix] = true
+		}
+	}
+	return names
+}
+
+// assertKnownMetrics checks that every GatewayD metric referenced in the expression
+// is actually exposed by GatewayD.
+func assertKnownMetrics(t *testing.T, names map[string]bool, expr string) {
+	t.Helper()
+
+	for _, name := range regexp.MustCompile(Namespace+`_[a-z_]+`).FindAllString(expr, -1) {
+		assert.True(t, names[name], "unknown metric %s in %s", name, expr)
+	}
+}
+
+func TestGrafanaDashboard(t *testing.T) {
+	output, err := GrafanaDashboard(DashboardOptions{PoolSize: 10})
+	require.NoError(t, err)
+
+	var dashboard struct {
+		UID    string           `json:"uid"`
+		Panels []dashboardPanel `json:"panels"`
+	}
+	require.NoError(t, json.Unmarshal(output, &dashboard))
+	assert.Equal(t, DashboardUID, dashboard.UID)
+	assert.NotEmpty(t, dashboard.Panels)
+
+	names := builtinMetricNames(t)
+	for _, panel := range dashboard.Panels {
+		assert.Equal(t, DashboardDatasource, panel.Datasource.UID)
+		for _, target := range panel.Targets {
+			assertKnownMetrics(t, names, target.Expr)
+		}
+	}
+	assert.Contains(t, string(output), "vector(10)")
+}
+
+func TestPrometheusAlertRules(t *testing.T) {
+	output, err := PrometheusAlertRules(DashboardOptions{
+		PoolSize:             20,
+		HookLatencyThreshold: 15 * time.Second,
+	})
+	require.NoError(t, err)
+
+	var rules map[string][]alertRuleGroup
+	require.NoError(t, yaml.Unmarshal(output, &rules))
+	require.Len(t, rules["groups"], 1)
+
+	names := builtinMetricNames(t)
+	alerts := map[string]string{}
+	for _, rule := range rules["groups"][0].Rules {
+		assertKnownMetrics(t, names, rule.Expr)
+		alerts[rule.Alert] = rule.Expr
+	}
+	assert.True(t, strings.HasSuffix(alerts["GatewayDPoolExhausted"], ">= 20"))
+	assert.True(t, strings.HasSuffix(alerts["GatewayDPoolNearlyExhausted"], ">= 18"))
+	assert.True(t, strings.HasSuffix(alerts["GatewayDHighHookLatency"], "> 15"))
+	assert.Contains(t, alerts, "GatewayDBackendFailures")
+
+	// Pool alerts are skipped if all pools are elastic.
+	output, err = PrometheusAlertRules(DashboardOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, string(output), "GatewayDPoolExhausted")
+	assert.Contains(t, string(output), "GatewayDBackendFailures")
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+)
+
+const (
+	LabelServer      = "server"
+	LabelProxy       = "proxy"
+	LabelUser        = "user"
+	LabelDatabase    = "database"
+	LabelApplication = "application"
+
+	// OtherLabelValue replaces the values of a label past its cardinality limit.
+	OtherLabelValue = "other"
+)
+
+// ConnectionLabels are the labels the templates select from, in the order of the label
+// values of the connection metrics.
+var ConnectionLabels = []string{LabelServer, LabelProxy, LabelUser, LabelDatabase, LabelApplication}
+
+// LabelTemplates select the labels of the connection metrics of each template. The
+// labels that aren't selected are empty, which Prometheus treats as missing, and the
+// values of each label past the cardinality limit are collapsed into "other".
+type LabelTemplates struct {
+	mu        sync.Mutex
+	templates map[string][]bool
+	limit     int
+	values    map[string]map[string]bool
+}
+
+// labelTemplates are the label templates of the connection metrics.
+var labelTemplates = newLabelTemplates(config.DefaultGlobalConfig().Metrics[config.Default].Labels)
+
+func newLabelTemplates(cfg config.MetricLabels) *LabelTemplates {
+	templates := &LabelTemplates{
+		templates: map[string][]bool{},
+		limit:     cfg.CardinalityLimit,
+		values:    map[string]map[string]bool{},
+	}
+	for name, labels := range cfg.Templates {
+		selected := make([]bool, len(ConnectionLabels))
+		for _, label := range labels {
+			if index := slices.Index(ConnectionLabels, label); index >= 0 {
+				selected[index] = true
+			}
+		}
+		templates.templates[name] = selected
+	}
+	return templates
+}
+
+// ConfigureLabels replaces the label templates of the connection metrics, after
+// checking that they only select the known labels.
+func ConfigureLabels(cfg config.MetricLabels) *gerr.GatewayDError {
+	for name, labels := range cfg.Templates {
+		for _, label := range labels {
+			if !slices.Contains(ConnectionLabels, label) {
+				return gerr.ErrValidationFailed.Wrap(fmt.Errorf(
+					"unknown label %q in the metric label template %q, it must be one of %v",
+					label, name, ConnectionLabels))
+			}
+		}
+	}
+	if cfg.CardinalityLimit < 0 {
+		return gerr.ErrValidationFailed.Wrap(fmt.Errorf(
+			"invalid metric labels cardinality limit %d, it must be positive", cfg.CardinalityLimit))
+	}
+
+	templates := newLabelTemplates(cfg)
+	labelTemplates.mu.Lock()
+	defer labelTemplates.mu.Unlock()
+	labelTemplates.templates = templates.templates
+	labelTemplates.limit = templates.limit
+	labelTemplates.values = templates.values
+	return nil
+}
+
+// LabelValues returns the values of the connection labels selected by the template,
+// followed by the extra values, e.g. the direction. The labels are given by name.
+func LabelValues(template string, labels map[string]string, extra ...string) []string {
+	return labelTemplates.LabelValues(template, labels, extra...)
+}
+
+// LabelValues returns the values of the connection labels selected by the template,
+// followed by the extra values. The values of a label are counted until its limit is
+// reached, and the new ones are "other" after that.
+func (t *LabelTemplates) LabelValues(
+	template string, labels map[string]string, extra ...string,
+) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	values := make([]string, len(ConnectionLabels), len(ConnectionLabels)+len(extra))
+	selected := t.templates[template]
+	for index, label := range ConnectionLabels {
+		if index >= len(selected) || !selected[index] {
+			continue
+		}
+		values[index] = t.guard(label, labels[label])
+	}
+	return append(values, extra...)
+}
+
+// guard returns the value of the label, or "other" if the label has reached its limit
+// of values and the value is new.
+func (t *LabelTemplates) guard(label, value string) string {
+	if t.limit == 0 || value == "" {
+		return value
+	}
+	seen, ok := t.values[label]
+	if !ok {
+		seen = map[string]bool{}
+		t.values[label] = seen
+	}
+	if seen[value] {
+		return value
+	}
+	if len(seen) >= t.limit {
+		MetricLabelsCollapsed.WithLabelValues(label).Inc()
+		return OtherLabelValue
+	}
+	seen[value] = true
+	return value
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLabelTemplates tests that the templates select the labels, and that the values
+// past the cardinality limit are collapsed into other.
+func TestLabelTemplates(t *testing.T) {
+	templates := newLabelTemplates(config.MetricLabels{
+		Templates:        map[string][]string{"usage": {"user", "server"}},
+		CardinalityLimit: 2,
+	})
+	labels := func(user string) map[string]string {
+		return map[string]string{"server": "default", "proxy": "default", "user": user, "database": "sales"}
+	}
+
+	assert.Equal(t, []string{"default", "", "alice", "", "", "in"},
+		templates.LabelValues("usage", labels("alice"), "in"))
+	assert.Equal(t, []string{"default", "", "bob", "", ""},
+		templates.LabelValues("usage", labels("bob")))
+	assert.Equal(t, []string{"default", "", "other", "", ""},
+		templates.LabelValues("usage", labels("carol")))
+	// The values seen before the limit was reached are kept.
+	assert.Equal(t, []string{"default", "", "alice", "", ""},
+		templates.LabelValues("usage", labels("alice")))
+	// The unknown templates select no labels.
+	assert.Equal(t, []string{"", "", "", "", ""}, templates.LabelValues("queries", labels("alice")))
+}
+
+// TestConfigureLabels tests that the templates with unknown labels are rejected.
+func TestConfigureLabels(t *testing.T) {
+	defaults := config.DefaultGlobalConfig().Metrics[config.Default].Labels
+	t.Cleanup(func() { require.Nil(t, ConfigureLabels(defaults)) })
+
+	assert.NotNil(t, ConfigureLabels(config.MetricLabels{
+		Templates: map[string][]string{"usage": {"tenant"}},
+	}))
+	assert.NotNil(t, ConfigureLabels(config.MetricLabels{CardinalityLimit: -1}))
+
+	require.Nil(t, ConfigureLabels(config.MetricLabels{
+		Templates: map[string][]string{"usage": {"application"}},
+	}))
+	assert.Equal(t, []string{"", "", "", "", "psql"},
+		LabelValues("usage", map[string]string{"user": "alice", "application": "psql"}))
+}
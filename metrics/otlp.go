@@ -0,0 +1,388 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-co-op/gocron"
+	"github.com/prometheus/client_golang/prometheus"
+	promClient "github.com/prometheus/client_model/go"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http"
+
+	otlpHTTPPath = "/v1/metrics"
+)
+
+type IOTLPExporter interface {
+	Export(ctx context.Context) *gerr.GatewayDError
+	Start()
+	Stop()
+}
+
+// OTLPExporter periodically pushes the metrics registered with Prometheus to an
+// OpenTelemetry collector over OTLP/gRPC or OTLP/HTTP. The metrics are read from
+// the same gatherer that backs the Prometheus endpoint, so both always agree.
+type OTLPExporter struct {
+	scheduler *gocron.Scheduler
+	ctx       context.Context //nolint:containedctx
+	startTime time.Time
+	conn      *grpc.ClientConn
+	client    colmetricspb.MetricsServiceClient
+
+	Logger   zerolog.Logger
+	Gatherer prometheus.Gatherer
+	Protocol string
+	Endpoint string
+	Insecure bool
+	Interval time.Duration
+}
+
+var _ IOTLPExporter = (*OTLPExporter)(nil)
+
+// NewOTLPExporter creates a new OTLP metrics exporter. For gRPC, the connection
+// is established lazily, so the collector doesn't need to be up at startup.
+func NewOTLPExporter(
+	ctx context.Context,
+	gatherer prometheus.Gatherer,
+	protocol, endpoint string,
+	insecureConn bool,
+	interval time.Duration,
+	logger zerolog.Logger,
+) (*OTLPExporter, *gerr.GatewayDError) {
+	exporterCtx, span := otel.Tracer(config.TracerName).Start(ctx, "NewOTLPExporter")
+	defer span.End()
+
+	exporter := &OTLPExporter{
+		scheduler: gocron.NewScheduler(time.UTC),
+		ctx:       exporterCtx,
+		startTime: time.Now(),
+		Logger:    logger,
+		Gatherer:  gatherer,
+		Protocol:  protocol,
+		Endpoint:  endpoint,
+		Insecure:  insecureConn,
+		Interval:  interval,
+	}
+
+	switch protocol {
+	case OTLPProtocolGRPC:
+		creds := credentials.NewClientTLSFromCert(nil, "")
+		if insecureConn {
+			creds = insecure.NewCredentials()
+		}
+		conn, err := grpc.DialContext(
+			exporterCtx, endpoint, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			span.RecordError(err)
+			return nil, gerr.ErrFailedToExportMetrics.Wrap(err)
+		}
+		exporter.conn = conn
+		exporter.client = colmetricspb.NewMetricsServiceClient(conn)
+	case OTLPProtocolHTTP:
+	default:
+		err := fmt.Errorf("unknown OTLP protocol: %s", protocol)
+		span.RecordError(err)
+		return nil, gerr.ErrFailedToExportMetrics.Wrap(err)
+	}
+
+	return exporter, nil
+}
+
+// Export gathers the metrics and pushes them to the collector.
+func (e *OTLPExporter) Export(ctx context.Context) *gerr.GatewayDError {
+	ctx, span := otel.Tracer(config.TracerName).Start(ctx, "Export metrics")
+	defer span.End()
+
+	families, err := e.Gatherer.Gather()
+	if err != nil {
+		span.RecordError(err)
+		return gerr.ErrFailedToExportMetrics.Wrap(err)
+	}
+
+	request := &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{
+			{
+				Resource: &resourcepb.Resource{
+					Attributes: []*commonpb.KeyValue{
+						stringKeyValue("service.name", config.TracerName),
+						stringKeyValue("service.version", config.Version),
+						stringKeyValue("library.language", "go"),
+					},
+				},
+				ScopeMetrics: []*metricspb.ScopeMetrics{
+					{
+						Scope: &commonpb.InstrumentationScope{
+							Name:    config.TracerName,
+							Version: config.Version,
+						},
+						Metrics: ToOTLPMetrics(families, e.startTime, time.Now()),
+					},
+				},
+			},
+		},
+	}
+
+	if e.Protocol == OTLPProtocolGRPC {
+		if _, err := e.client.Export(ctx, request); err != nil {
+			span.RecordError(err)
+			return gerr.ErrFailedToExportMetrics.Wrap(err)
+		}
+		return nil
+	}
+
+	return e.exportHTTP(ctx, request)
+}
+
+// exportHTTP pushes the metrics to the collector using protobuf over HTTP.
+func (e *OTLPExporter) exportHTTP(
+	ctx context.Context, request *colmetricspb.ExportMetricsServiceRequest,
+) *gerr.GatewayDError {
+	body, err := proto.Marshal(request)
+	if err != nil {
+		return gerr.ErrFailedToExportMetrics.Wrap(err)
+	}
+
+	scheme := "https://"
+	if e.Insecure {
+		scheme = "http://"
+	}
+
+	httpRequest, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, scheme+e.Endpoint+otlpHTTPPath, bytes.NewReader(body))
+	if err != nil {
+		return gerr.ErrFailedToExportMetrics.Wrap(err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/x-protobuf")
+
+	response, err := http.DefaultClient.Do(httpRequest)
+	if err != nil {
+		return gerr.ErrFailedToExportMetrics.Wrap(err)
+	}
+	defer response.Body.Close()
+
+	// Drain the body, so that the connection can be reused.
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return gerr.ErrFailedToExportMetrics.Wrap(
+			fmt.Errorf("collector responded with status %s", response.Status))
+	}
+
+	return nil
+}
+
+// Start starts pushing metrics to the collector periodically.
+func (e *OTLPExporter) Start() {
+	ctx, span := otel.Tracer(config.TracerName).Start(e.ctx, "OTLP metrics exporter")
+	span.SetAttributes(
+		attribute.String("protocol", e.Protocol),
+		attribute.String("endpoint", e.Endpoint),
+	)
+	defer span.End()
+
+	if _, err := e.scheduler.
+		Every(e.Interval).
+		SingletonMode().
+		StartAt(time.Now().Add(e.Interval)).
+		Do(func() {
+			e.Logger.Trace().Msg("Running the scheduler for exporting metrics over OTLP")
+			if err := e.Export(ctx); err != nil {
+				e.Logger.Error().Err(err.Unwrap()).Msg("Failed to export metrics over OTLP")
+			}
+		}); err != nil {
+		e.Logger.Error().Err(err).Msg("Failed to start OTLP metrics exporter scheduler")
+		span.RecordError(err)
+		sentry.CaptureException(err)
+		return
+	}
+
+	e.scheduler.StartAsync()
+	e.Logger.Info().Fields(
+		map[string]interface{}{
+			"protocol": e.Protocol,
+			"endpoint": e.Endpoint,
+			"interval": e.Interval.String(),
+		},
+	).Msg("Started the OTLP metrics exporter")
+}
+
+// Stop stops the exporter after pushing the metrics one last time.
+func (e *OTLPExporter) Stop() {
+	ctx, span := otel.Tracer(config.TracerName).Start(e.ctx, "Stop OTLP metrics exporter")
+	defer span.End()
+
+	e.scheduler.Clear()
+
+	if err := e.Export(ctx); err != nil {
+		e.Logger.Error().Err(err.Unwrap()).Msg("Failed to export metrics over OTLP")
+		span.RecordError(err)
+	}
+
+	if e.conn != nil {
+		if err := e.conn.Close(); err != nil {
+			e.Logger.Error().Err(err).Msg("Failed to close the OTLP connection")
+			span.RecordError(err)
+		}
+	}
+}
+
+// ToOTLPMetrics converts the Prometheus metric families to OTLP metrics. Counters
+// become cumulative monotonic sums, gauges and untyped metrics become gauges, and
+// histograms and summaries keep their shape. Labels become data point attributes.
+func ToOTLPMetrics(
+	families []*promClient.MetricFamily, startTime, now time.Time,
+) []*metricspb.Metric {
+	start := uint64(startTime.UnixNano())
+	timestamp := uint64(now.UnixNano())
+
+	metrics := make([]*metricspb.Metric, 0, len(families))
+	for _, family := range families {
+		metric := &metricspb.Metric{
+			Name:        family.GetName(),
+			Description: family.GetHelp(),
+		}
+
+		switch family.GetType() {
+		case promClient.MetricType_COUNTER:
+			sum := &metricspb.Sum{
+				AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+				IsMonotonic:            true,
+			}
+			for _, m := range family.GetMetric() {
+				sum.DataPoints = append(sum.DataPoints, &metricspb.NumberDataPoint{
+					Attributes:        labelsToAttributes(m.GetLabel()),
+					StartTimeUnixNano: start,
+					TimeUnixNano:      timestamp,
+					Value:             &metricspb.NumberDataPoint_AsDouble{AsDouble: m.GetCounter().GetValue()},
+				})
+			}
+			metric.Data = &metricspb.Metric_Sum{Sum: sum}
+		case promClient.MetricType_GAUGE, promClient.MetricType_UNTYPED:
+			gauge := &metricspb.Gauge{}
+			for _, m := range family.GetMetric() {
+				value := m.GetGauge().GetValue()
+				if family.GetType() == promClient.MetricType_UNTYPED {
+					value = m.GetUntyped().GetValue()
+				}
+				gauge.DataPoints = append(gauge.DataPoints, &metricspb.NumberDataPoint{
+					Attributes:   labelsToAttributes(m.GetLabel()),
+					TimeUnixNano: timestamp,
+					Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: value},
+				})
+			}
+			metric.Data = &metricspb.Metric_Gauge{Gauge: gauge}
+		case promClient.MetricType_HISTOGRAM, promClient.MetricType_GAUGE_HISTOGRAM:
+			histogram := &metricspb.Histogram{
+				AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			}
+			for _, m := range family.GetMetric() {
+				histogram.DataPoints = append(
+					histogram.DataPoints, toHistogramDataPoint(m, start, timestamp))
+			}
+			metric.Data = &metricspb.Metric_Histogram{Histogram: histogram}
+		case promClient.MetricType_SUMMARY:
+			summary := &metricspb.Summary{}
+			for _, m := range family.GetMetric() {
+				dataPoint := &metricspb.SummaryDataPoint{
+					Attributes:        labelsToAttributes(m.GetLabel()),
+					StartTimeUnixNano: start,
+					TimeUnixNano:      timestamp,
+					Count:             m.GetSummary().GetSampleCount(),
+					Sum:               m.GetSummary().GetSampleSum(),
+				}
+				for _, quantile := range m.GetSummary().GetQuantile() {
+					dataPoint.QuantileValues = append(
+						dataPoint.QuantileValues,
+						&metricspb.SummaryDataPoint_ValueAtQuantile{
+							Quantile: quantile.GetQuantile(),
+							Value:    quantile.GetValue(),
+						})
+				}
+				summary.DataPoints = append(summary.DataPoints, dataPoint)
+			}
+			metric.Data = &metricspb.Metric_Summary{Summary: summary}
+		default:
+			continue
+		}
+
+		metrics = append(metrics, metric)
+	}
+
+	return metrics
+}
+
+// toHistogramDataPoint converts a Prometheus histogram to an OTLP histogram data point.
+// Prometheus buckets are cumulative, while OTLP bucket counts are per bucket, with an
+// implicit +Inf bucket at the end.
+func toHistogramDataPoint(
+	metric *promClient.Metric, start, timestamp uint64,
+) *metricspb.HistogramDataPoint {
+	histogram := metric.GetHistogram()
+	buckets := histogram.GetBucket()
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].GetUpperBound() < buckets[j].GetUpperBound()
+	})
+
+	sum := histogram.GetSampleSum()
+	dataPoint := &metricspb.HistogramDataPoint{
+		Attributes:        labelsToAttributes(metric.GetLabel()),
+		StartTimeUnixNano: start,
+		TimeUnixNano:      timestamp,
+		Count:             histogram.GetSampleCount(),
+		Sum:               &sum,
+	}
+
+	var previous uint64
+	for _, bucket := range buckets {
+		if math.IsInf(bucket.GetUpperBound(), 1) {
+			continue
+		}
+		dataPoint.ExplicitBounds = append(dataPoint.ExplicitBounds, bucket.GetUpperBound())
+		dataPoint.BucketCounts = append(
+			dataPoint.BucketCounts, bucket.GetCumulativeCount()-previous)
+		previous = bucket.GetCumulativeCount()
+	}
+	dataPoint.BucketCounts = append(dataPoint.BucketCounts, histogram.GetSampleCount()-previous)
+
+	return dataPoint
+}
+
+// labelsToAttributes converts Prometheus labels to OTLP attributes.
+func labelsToAttributes(labels []*promClient.LabelPair) []*commonpb.KeyValue {
+	attributes := make([]*commonpb.KeyValue, 0, len(labels))
+	for _, label := range labels {
+		attributes = append(attributes, stringKeyValue(label.GetName(), label.GetValue()))
+	}
+	return attributes
+}
+
+// stringKeyValue returns an OTLP attribute with a string value.
+func stringKeyValue(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
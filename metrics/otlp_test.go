@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestRegistry(t *testing.T) *prometheus.Registry {
+	t.Helper()
+
+	registry := prometheus.NewRegistry()
+
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "test_total",
+		Help:      "Test counter",
+	}, []string{"name"})
+	counter.WithLabelValues("default").Add(3)
+
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "test_gauge",
+		Help:      "Test gauge",
+	})
+	gauge.Set(5)
+
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Name:      "test_histogram",
+		Help:      "Test histogram",
+		Buckets:   []float64{1, 10},
+	})
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+	histogram.Observe(50)
+
+	registry.MustRegister(counter, gauge, histogram)
+
+	return registry
+}
+
+func TestToOTLPMetrics(t *testing.T) {
+	families, err := newTestRegistry(t).Gather()
+	require.NoError(t, err)
+
+	metrics := ToOTLPMetrics(families, time.Now(), time.Now())
+	require.Len(t, metrics, 3)
+
+	byName := map[string]int{}
+	for i, metric := range metrics {
+		byName[metric.GetName()] = i
+	}
+
+	sum := metrics[byName["gatewayd_test_total"]].GetSum()
+	require.NotNil(t, sum)
+	assert.True(t, sum.GetIsMonotonic())
+	assert.Equal(t, 3.0, sum.GetDataPoints()[0].GetAsDouble())
+	assert.Equal(t, "name", sum.GetDataPoints()[0].GetAttributes()[0].GetKey())
+	assert.Equal(t, "default", sum.GetDataPoints()[0].GetAttributes()[0].GetValue().GetStringValue())
+
+	gauge := metrics[byName["gatewayd_test_gauge"]].GetGauge()
+	require.NotNil(t, gauge)
+	assert.Equal(t, 5.0, gauge.GetDataPoints()[0].GetAsDouble())
+
+	histogram := metrics[byName["gatewayd_test_histogram"]].GetHistogram()
+	require.NotNil(t, histogram)
+	dataPoint := histogram.GetDataPoints()[0]
+	assert.Equal(t, uint64(3), dataPoint.GetCount())
+	assert.Equal(t, 55.5, dataPoint.GetSum())
+	assert.Equal(t, []float64{1, 10}, dataPoint.GetExplicitBounds())
+	// One observation per bucket, including the implicit +Inf bucket.
+	assert.Equal(t, []uint64{1, 1, 1}, dataPoint.GetBucketCounts())
+}
+
+func TestOTLPExporterHTTP(t *testing.T) {
+	received := make(chan *colmetricspb.ExportMetricsServiceRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(
+		func(responseWriter http.ResponseWriter, request *http.Request) {
+			assert.Equal(t, "/v1/metrics", request.URL.Path)
+			assert.Equal(t, "application/x-protobuf", request.Header.Get("Content-Type"))
+
+			body, err := io.ReadAll(request.Body)
+			assert.NoError(t, err)
+			var export colmetricspb.ExportMetricsServiceRequest
+			assert.NoError(t, proto.Unmarshal(body, &export))
+			received <- &export
+		}))
+	defer collector.Close()
+
+	exporter, err := NewOTLPExporter(
+		context.Background(),
+		newTestRegistry(t),
+		OTLPProtocolHTTP,
+		strings.TrimPrefix(collector.URL, "http://"),
+		true,
+		time.Second,
+		zerolog.Nop(),
+	)
+	require.Nil(t, err)
+
+	require.Nil(t, exporter.Export(context.Background()))
+	export := <-received
+	assert.Len(t, export.GetResourceMetrics()[0].GetScopeMetrics()[0].GetMetrics(), 3)
+
+	// Unknown protocols are rejected.
+	_, err = NewOTLPExporter(
+		context.Background(), newTestRegistry(t), "udp", "localhost:4317", true, time.Second,
+		zerolog.Nop())
+	assert.NotNil(t, err)
+}
@@ -1,6 +1,10 @@
 package metrics
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 // HeaderBypassResponseWriter implements the http.ResponseWriter interface
 // and allows us to bypass the response header when writing to the response.
@@ -17,3 +21,14 @@ func (w *HeaderBypassResponseWriter) WriteHeader(int) {}
 func (w *HeaderBypassResponseWriter) Write(data []byte) (int, error) {
 	return w.ResponseWriter.Write(data) //nolint:wrapcheck
 }
+
+// ObserveWithExemplar observes the value and attaches the given labels as an
+// exemplar if the observer supports exemplars (e.g. histograms). Otherwise,
+// the value is observed without an exemplar.
+func ObserveWithExemplar(observer prometheus.Observer, value float64, labels prometheus.Labels) {
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && len(labels) > 0 {
+		exemplarObserver.ObserveWithExemplar(value, labels)
+		return
+	}
+	observer.Observe(value)
+}
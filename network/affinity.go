@@ -0,0 +1,200 @@
+package network
+
+import (
+	"hash/fnv"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/rs/zerolog"
+	"golang.org/x/exp/slices"
+)
+
+// ReconnectAffinity is the reason of the reconnections of the clients to the standby
+// their session is hashed to.
+const ReconnectAffinity = "affinity"
+
+// affinityPoints is the number of points of each standby on the hash ring, which
+// spread the keys evenly over the standbys.
+const affinityPoints = 100
+
+type affinityPoint struct {
+	hash    uint64
+	address string
+}
+
+// SessionAffinity hashes the sessions to the standbys of a cluster by their keys, with
+// a consistent hash with bounded loads: a key goes to the first standby after it on the
+// hash ring that serves less than the load factor times the average number of
+// sessions. The ring is rebuilt when the standbys change, so that only the keys of the
+// standbys that joined or left move.
+type SessionAffinity struct {
+	Keys       []config.AffinityKey
+	LoadFactor float64
+
+	mu      sync.Mutex
+	members []string
+	ring    []affinityPoint
+	// loads holds the number of sessions of each standby, and sessions holds the
+	// standby of each session.
+	loads    map[string]int
+	sessions map[*ConnWrapper]string
+}
+
+// NewSessionAffinity creates the session affinity of the config, or returns nil if it
+// is disabled.
+func NewSessionAffinity(cfg config.SessionAffinity) *SessionAffinity {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	affinity := &SessionAffinity{
+		LoadFactor: config.If[float64](
+			cfg.LoadFactor >= 1, cfg.LoadFactor, config.DefaultAffinityLoadFactor),
+		loads:    map[string]int{},
+		sessions: map[*ConnWrapper]string{},
+	}
+	for _, key := range cfg.Keys {
+		affinity.Keys = append(affinity.Keys, config.AffinityKey(key))
+	}
+	return affinity
+}
+
+// Key returns the key of the session of the startup parameters and the address of the
+// client.
+func (a *SessionAffinity) Key(parameters map[string]string, remote net.Addr) string {
+	parts := make([]string, 0, len(a.Keys))
+	for _, key := range a.Keys {
+		switch key {
+		case config.AffinityUser:
+			parts = append(parts, parameters["user"])
+		case config.AffinityDatabase:
+			// PostgreSQL connects to the database of the name of the user by default.
+			parts = append(parts, config.If[string](
+				parameters["database"] != "", parameters["database"], parameters["user"]))
+		case config.AffinityClient:
+			host := ""
+			if remote != nil {
+				host = remote.String()
+				if address, _, err := net.SplitHostPort(host); err == nil {
+					host = address
+				}
+			}
+			parts = append(parts, host)
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// Assign returns the standby of the key among the standbys, and counts the session of
+// the connection on it until it is released. It is empty if there are no standbys.
+func (a *SessionAffinity) Assign(conn *ConnWrapper, key string, standbys []string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.release(conn)
+	if len(standbys) == 0 {
+		return ""
+	}
+	members := slices.Clone(standbys)
+	sort.Strings(members)
+	if !slices.Equal(members, a.members) {
+		a.rebuild(members)
+	}
+
+	total := 0
+	for _, member := range a.members {
+		total += a.loads[member]
+	}
+	capacity := int(math.Ceil(a.LoadFactor * float64(total+1) / float64(len(a.members))))
+
+	hash := affinityHash(key)
+	start := sort.Search(len(a.ring), func(index int) bool { return a.ring[index].hash >= hash })
+	for index := range len(a.ring) {
+		point := a.ring[(start+index)%len(a.ring)]
+		if a.loads[point.address] < capacity {
+			a.loads[point.address]++
+			a.sessions[conn] = point.address
+			metrics.ProxyAffinitySessions.WithLabelValues(point.address).Inc()
+			return point.address
+		}
+	}
+	return ""
+}
+
+// Release stops counting the session of the connection on its standby.
+func (a *SessionAffinity) Release(conn *ConnWrapper) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.release(conn)
+}
+
+// release must be called with the lock held.
+func (a *SessionAffinity) release(conn *ConnWrapper) {
+	address, ok := a.sessions[conn]
+	if !ok {
+		return
+	}
+	delete(a.sessions, conn)
+	metrics.ProxyAffinitySessions.WithLabelValues(address).Dec()
+	if a.loads[address]--; a.loads[address] <= 0 {
+		delete(a.loads, address)
+	}
+}
+
+// rebuild must be called with the lock held.
+func (a *SessionAffinity) rebuild(members []string) {
+	a.members = members
+	a.ring = make([]affinityPoint, 0, len(members)*affinityPoints)
+	for _, member := range members {
+		for point := range affinityPoints {
+			a.ring = append(a.ring, affinityPoint{
+				hash:    affinityHash(member + "#" + strconv.Itoa(point)),
+				address: member,
+			})
+		}
+	}
+	sort.Slice(a.ring, func(i, j int) bool { return a.ring[i].hash < a.ring[j].hash })
+}
+
+// affinityHash returns the position of the value on the hash ring.
+func affinityHash(value string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(value))
+	// Mix the bits, as the hashes of the similar values are close to each other.
+	sum := hash.Sum64()
+	sum ^= sum >> 33
+	sum *= 0xff51afd7ed558ccd
+	sum ^= sum >> 33
+	return sum
+}
+
+// routeSession moves the server connection of the connection to the standby its
+// session is hashed to, before the startup message of the session is sent to it.
+func (pr *Proxy) routeSession(
+	conn *ConnWrapper, client *Client, parameters map[string]string, logger zerolog.Logger,
+) {
+	if pr.Affinity == nil || pr.Cluster == nil || pr.ClusterRole != config.Standby {
+		return
+	}
+	// The server connection can't be moved once it got a request, e.g. an SSL request.
+	if value, ok := pr.sessions.Load(conn); ok && value.(*session).bytesIn.Load() > 0 { //nolint:forcetypeassert
+		return
+	}
+
+	address := pr.Affinity.Assign(
+		conn, pr.Affinity.Key(parameters, conn.Conn().RemoteAddr()), pr.Cluster.Standbys())
+	if address == "" || address == client.Address {
+		return
+	}
+	client.Address = address
+	if err := pr.reconnectClient(client, ReconnectAffinity); err != nil {
+		logger.Error().Err(err).Str("address", address).Msg(
+			"Failed to connect to the standby of the session")
+	}
+}
@@ -0,0 +1,147 @@
+package network
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSessionAffinity tests that the sessions of a key go to the same standby until it
+// is full, and that only the keys of a new standby move to it.
+func TestSessionAffinity(t *testing.T) {
+	affinity := NewSessionAffinity(config.SessionAffinity{
+		Enabled: true, Keys: []string{"user", "database"}, LoadFactor: 1.25,
+	})
+	require.NotNil(t, affinity)
+	standbys := []string{"pg-1:5432", "pg-2:5432", "pg-3:5432"}
+
+	// The sessions of the same key go to the same standby, up to the bounded load.
+	first := &ConnWrapper{}
+	address := affinity.Assign(first, "app\x00db", standbys)
+	assert.Contains(t, standbys, address)
+	affinity.Release(first)
+	conns := make([]*ConnWrapper, 12)
+	loads := map[string]int{}
+	for index := range conns {
+		conns[index] = &ConnWrapper{}
+		loads[affinity.Assign(conns[index], "app\x00db", standbys)]++
+	}
+	assert.Equal(t, 5, loads[address])
+	assert.Len(t, loads, 3)
+	for _, conn := range conns {
+		affinity.Release(conn)
+	}
+	assert.Empty(t, affinity.loads)
+
+	// Only the keys that move to the new standby change their standby.
+	unbounded := NewSessionAffinity(config.SessionAffinity{
+		Enabled: true, Keys: []string{"user"}, LoadFactor: 1000,
+	})
+	before := map[string]string{}
+	for index := range 100 {
+		conn := &ConnWrapper{}
+		key := "user-" + strconv.Itoa(index)
+		before[key] = unbounded.Assign(conn, key, standbys)
+		unbounded.Release(conn)
+	}
+	moved := 0
+	for key, address := range before {
+		conn := &ConnWrapper{}
+		after := unbounded.Assign(conn, key, append(standbys, "pg-4:5432"))
+		unbounded.Release(conn)
+		if after != address {
+			assert.Equal(t, "pg-4:5432", after)
+			moved++
+		}
+	}
+	assert.Positive(t, moved)
+	assert.Less(t, moved, 50)
+
+	assert.Empty(t, affinity.Assign(first, "app\x00db", nil))
+	assert.Nil(t, NewSessionAffinity(config.SessionAffinity{}))
+}
+
+func TestSessionAffinityKey(t *testing.T) {
+	affinity := NewSessionAffinity(config.SessionAffinity{
+		Enabled: true, Keys: []string{"user", "database", "client"},
+	})
+	assert.InDelta(t, config.DefaultAffinityLoadFactor, affinity.LoadFactor, 0)
+	assert.Equal(t, "app\x00app\x0010.0.0.1", affinity.Key(
+		map[string]string{"user": "app"}, &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 50000}))
+	assert.Equal(t, "app\x00db\x00", affinity.Key(map[string]string{"user": "app", "database": "db"}, nil))
+}
+
+// TestProxyRouteSession tests that the server connection of a session is moved to the
+// standby of its key before its startup message is sent.
+func TestProxyRouteSession(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, 1),
+		plugin.NewRegistry(ctx, config.Loose, config.PassDown, config.Accept, config.Stop, logger, false),
+		false, false, config.DefaultHealthCheckPeriod, nil, logger, config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+
+	var standbys []string
+	for range 2 {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		go func() {
+			for {
+				if _, err := listener.Accept(); err != nil {
+					return
+				}
+			}
+		}()
+		standbys = append(standbys, listener.Addr().String())
+	}
+
+	cluster := &fakeCluster{}
+	cluster.set(map[string]bool{"primary:5432": false, standbys[0]: true, standbys[1]: true})
+	monitor := NewClusterMonitor(config.PrimaryDiscovery{
+		Members: []string{"primary:5432", standbys[0], standbys[1]},
+	}, config.DefaultDialTimeout, logger)
+	monitor.check = cluster.check
+	_, _, _, checkErr := monitor.Check(ctx)
+	require.Nil(t, checkErr)
+	proxy.Cluster = monitor
+	proxy.ClusterRole = config.Standby
+	proxy.Affinity = NewSessionAffinity(config.SessionAffinity{Enabled: true, Keys: []string{"user"}})
+
+	parameters := map[string]string{"user": "app"}
+	expected := NewSessionAffinity(config.SessionAffinity{Enabled: true, Keys: []string{"user"}}).
+		Assign(&ConnWrapper{}, "app", standbys)
+	other := standbys[0]
+	if other == expected {
+		other = standbys[1]
+	}
+
+	client := NewClient(ctx, &config.Client{
+		Network:          "tcp",
+		Address:          other,
+		ReceiveChunkSize: config.DefaultChunkSize,
+		DialTimeout:      config.DefaultDialTimeout,
+	}, logger, NewRetry(0, config.DefaultBackoff, config.DefaultBackoffMultiplier, false, logger))
+	require.NotNil(t, client)
+	defer client.Close()
+
+	incoming, outgoing := net.Pipe()
+	defer outgoing.Close()
+	conn := NewConnWrapper(incoming, nil, config.DefaultHandshakeTimeout)
+	defer conn.Close()
+	proxy.sessions.Store(conn, newSession())
+
+	proxy.routeSession(conn, client, parameters, logger)
+	assert.Equal(t, expected, client.Address)
+	assert.True(t, client.IsConnected())
+	assert.Equal(t, 1, proxy.Affinity.loads[expected])
+}
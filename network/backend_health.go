@@ -0,0 +1,208 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/events"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/getsentry/sentry-go"
+)
+
+// BackendHealthStatus is the last health check of the backend.
+type BackendHealthStatus struct {
+	Address   string        `json:"address"`
+	Degraded  bool          `json:"degraded"`
+	Failures  int           `json:"failures"`
+	Latency   time.Duration `json:"latency"`
+	Error     string        `json:"error,omitempty"`
+	CheckedAt time.Time     `json:"checkedAt"`
+}
+
+// BackendHealth checks the health of the backend with a query over the PostgreSQL
+// protocol, beyond the TCP connections of the pool, and marks it degraded once the
+// checks fail or are slower than the latency threshold the failure threshold times
+// in a row. A check that succeeds in time marks it healthy again.
+type BackendHealth struct {
+	Interval         time.Duration
+	Timeout          time.Duration
+	LatencyThreshold time.Duration
+	FailureThreshold int
+	// CircuitBreaker rejects the new client connections while the backend is
+	// degraded.
+	CircuitBreaker bool
+
+	// check runs the health query on the backend at the address, and returns how
+	// long the query took.
+	check func(ctx context.Context, address string) (time.Duration, error)
+
+	mu     sync.RWMutex
+	status BackendHealthStatus
+}
+
+// NewBackendHealth creates the health check of the config, which connects to the
+// backend as its user, or returns nil if it is disabled.
+func NewBackendHealth(cfg config.BackendHealthCheck) *BackendHealth {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	query := config.If[string](cfg.Query != "", cfg.Query, config.DefaultHealthCheckQuery)
+	database := config.If[string](cfg.Database != "", cfg.Database, config.DefaultHealthCheckDatabase)
+	timeout := config.If[time.Duration](cfg.Timeout > 0, cfg.Timeout, config.DefaultHealthCheckTimeout)
+	return &BackendHealth{
+		Interval: config.If[time.Duration](
+			cfg.Interval > 0, cfg.Interval, config.DefaultHealthCheckInterval),
+		Timeout:          timeout,
+		LatencyThreshold: cfg.LatencyThreshold,
+		FailureThreshold: config.If[int](
+			cfg.FailureThreshold > 0, cfg.FailureThreshold, config.DefaultHealthCheckFailureThreshold),
+		CircuitBreaker: cfg.CircuitBreaker,
+		check: func(ctx context.Context, address string) (time.Duration, error) {
+			conn, err := ConnectPostgres(
+				ctx, address, cfg.User, cfg.Password, database, config.Name, timeout)
+			if err != nil {
+				return 0, err
+			}
+			defer conn.Close(ctx)
+
+			// The latency is of the query alone, as the authentication might be slow
+			// on purpose, e.g. with SCRAM.
+			start := time.Now()
+			_, err = conn.Exec(ctx, query).ReadAll()
+			return time.Since(start), err //nolint:wrapcheck
+		},
+	}
+}
+
+// Check runs the health query on the backend at the address, and returns its status
+// and whether it became degraded or healthy again.
+func (h *BackendHealth) Check(ctx context.Context, address string) (BackendHealthStatus, bool) {
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	latency, err := h.check(ctx, address)
+	cancel()
+	if err == nil {
+		metrics.BackendHealthCheckLatency.WithLabelValues(address).Observe(latency.Seconds())
+		if h.LatencyThreshold > 0 && latency > h.LatencyThreshold {
+			err = fmt.Errorf("the health query took %s, longer than the latency threshold of %s",
+				latency, h.LatencyThreshold)
+		}
+	}
+
+	h.mu.Lock()
+	previous := h.status
+	h.status.Address = address
+	h.status.Latency = latency
+	h.status.CheckedAt = time.Now()
+	h.status.Error = ""
+	if err != nil {
+		h.status.Failures++
+		h.status.Error = err.Error()
+	} else {
+		h.status.Failures = 0
+	}
+	h.status.Degraded = h.status.Failures >= h.FailureThreshold
+	status := h.status
+	h.mu.Unlock()
+
+	if err != nil {
+		metrics.BackendHealthCheckFailures.WithLabelValues(address).Inc()
+	}
+	// The gauge follows the backend, e.g. after a failover.
+	if previous.Address != "" && previous.Address != address {
+		metrics.BackendDegraded.DeleteLabelValues(previous.Address)
+	}
+	metrics.BackendDegraded.WithLabelValues(address).Set(
+		config.If[float64](status.Degraded, 1, 0))
+	return status, status.Degraded != previous.Degraded
+}
+
+// Degraded returns true if the backend is degraded.
+func (h *BackendHealth) Degraded() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.status.Degraded
+}
+
+// Status returns the last health check of the backend.
+func (h *BackendHealth) Status() BackendHealthStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.status
+}
+
+// WatchHealth checks the health of the backend on the interval, if the health check of
+// the clients is enabled. The backends reached through an SSH tunnel or an upstream
+// proxy can't be checked, as the health check connects to them directly.
+func (pr *Proxy) WatchHealth() {
+	if pr.ClientConfig == nil {
+		return
+	}
+	health := NewBackendHealth(pr.ClientConfig.HealthCheck)
+	if health == nil {
+		return
+	}
+	if !strings.HasPrefix(pr.ClientConfig.Network, "tcp") || pr.ClientConfig.SSHTunnel.Enabled ||
+		pr.ClientConfig.UpstreamProxy.URL != "" {
+		pr.logger.Warn().Str("network", pr.ClientConfig.Network).Msg(
+			"The health check only supports the TCP backends that are reached directly")
+		return
+	}
+	pr.Health = health
+
+	if _, err := pr.scheduler.Every(health.Interval).SingletonMode().StartAt(
+		time.Now().Add(health.Interval)).Do(pr.CheckHealth); err != nil {
+		pr.logger.Error().Err(err).Msg("Failed to schedule the health check of the backend")
+		sentry.CaptureException(err)
+		return
+	}
+	pr.logger.Info().Str("interval", health.Interval.String()).Int(
+		"failureThreshold", health.FailureThreshold).Bool(
+		"circuitBreaker", health.CircuitBreaker).Msg("Checking the health of the backend")
+}
+
+// CheckHealth checks the health of the backend the new clients connect to. While the
+// primary of a cluster is degraded, the cluster is checked too, so that the clients
+// follow a failover right away instead of after the interval of the cluster.
+func (pr *Proxy) CheckHealth() {
+	if pr.Health == nil || pr.ClientConfig == nil {
+		return
+	}
+
+	address := pr.clientConfig().Address
+	status, changed := pr.Health.Check(pr.ctx, address)
+	logger := pr.logger.With().Str("address", address).Logger()
+	switch {
+	case changed && status.Degraded:
+		logger.Warn().Str("error", status.Error).Int("failures", status.Failures).Msg(
+			"The backend is degraded")
+		events.Publish(events.BackendDegraded, "The backend is degraded",
+			map[string]interface{}{
+				"address":        address,
+				"failures":       status.Failures,
+				"error":          status.Error,
+				"circuitBreaker": pr.Health.CircuitBreaker,
+			})
+	case changed:
+		logger.Info().Str("latency", status.Latency.String()).Msg("The backend is healthy again")
+	case status.Error != "":
+		logger.Debug().Str("error", status.Error).Int("failures", status.Failures).Msg(
+			"The health check of the backend failed")
+	}
+
+	if status.Degraded && pr.Cluster != nil && pr.ClusterRole != config.Standby {
+		if _, _, _, err := pr.Cluster.Check(pr.ctx); err != nil {
+			logger.Error().Err(err).Msg("Failed to find the primary of the cluster")
+		}
+	}
+}
+
+// circuitOpen returns true if the new client connections are rejected, as the backend
+// is degraded.
+func (pr *Proxy) circuitOpen() bool {
+	return pr.Health != nil && pr.Health.CircuitBreaker && pr.Health.Degraded()
+}
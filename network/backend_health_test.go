@@ -0,0 +1,74 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBackendHealth(t *testing.T) {
+	assert.Nil(t, NewBackendHealth(config.BackendHealthCheck{}))
+
+	health := NewBackendHealth(config.BackendHealthCheck{Enabled: true})
+	require.NotNil(t, health)
+	assert.Equal(t, config.DefaultHealthCheckInterval, health.Interval)
+	assert.Equal(t, config.DefaultHealthCheckTimeout, health.Timeout)
+	assert.Equal(t, config.DefaultHealthCheckFailureThreshold, health.FailureThreshold)
+	assert.Zero(t, health.LatencyThreshold)
+}
+
+// TestBackendHealthCheck tests that the backend is degraded after the failure threshold
+// of consecutive failed or slow checks, and healthy again after a check succeeds.
+func TestBackendHealthCheck(t *testing.T) {
+	health := NewBackendHealth(config.BackendHealthCheck{
+		Enabled:          true,
+		LatencyThreshold: 100 * time.Millisecond,
+		FailureThreshold: 2,
+		CircuitBreaker:   true,
+	})
+	require.NotNil(t, health)
+	var latency time.Duration
+	var checkErr error
+	health.check = func(context.Context, string) (time.Duration, error) {
+		return latency, checkErr
+	}
+	proxy := &Proxy{Health: health}
+	ctx := context.Background()
+
+	checkErr = errors.New("connection refused")
+	status, changed := health.Check(ctx, "localhost:5432")
+	assert.False(t, changed)
+	assert.False(t, status.Degraded)
+	assert.Equal(t, 1, status.Failures)
+	assert.False(t, proxy.circuitOpen())
+
+	// The slow checks are failures too.
+	checkErr, latency = nil, time.Second
+	status, changed = health.Check(ctx, "localhost:5432")
+	assert.True(t, changed)
+	assert.True(t, status.Degraded)
+	assert.Contains(t, status.Error, "latency threshold")
+	assert.True(t, proxy.circuitOpen())
+
+	latency = time.Millisecond
+	status, changed = health.Check(ctx, "localhost:5432")
+	assert.True(t, changed)
+	assert.False(t, status.Degraded)
+	assert.Zero(t, status.Failures)
+	assert.Equal(t, status, health.Status())
+	assert.False(t, proxy.circuitOpen())
+
+	// The connections aren't rejected without the circuit breaker.
+	health.CircuitBreaker = false
+	checkErr = errors.New("connection refused")
+	health.Check(ctx, "localhost:5432")
+	health.Check(ctx, "localhost:5432")
+	assert.True(t, health.Degraded())
+	assert.False(t, proxy.circuitOpen())
+	assert.False(t, (&Proxy{}).circuitOpen())
+}
@@ -0,0 +1,129 @@
+package network
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"golang.org/x/exp/slices"
+)
+
+// BackendStatus is the last resolution of the hostname of a backend.
+type BackendStatus struct {
+	Network    string    `json:"network"`
+	Address    string    `json:"address"`
+	Addresses  []string  `json:"addresses"`
+	ResolvedAt time.Time `json:"resolvedAt"`
+}
+
+// BackendResolver re-resolves the hostname of the address of a backend, so that the
+// connections to it follow its IP addresses when they change, e.g. on a failover.
+// Go's resolver doesn't expose the TTLs of the DNS records, so the hostname is
+// re-resolved on the configured interval, which should be at most the TTL.
+type BackendResolver struct {
+	Network  string
+	Address  string
+	Interval time.Duration
+
+	host       string
+	port       string
+	lookup     func(ctx context.Context, host string) ([]string, error)
+	mu         sync.RWMutex
+	addresses  []string
+	resolvedAt time.Time
+}
+
+// NewBackendResolver creates a resolver for the address of the backend. It returns nil
+// if the address doesn't have a hostname to re-resolve, e.g. an IP address or a unix
+// socket.
+func NewBackendResolver(network, address string, interval time.Duration) *BackendResolver {
+	switch network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+	default:
+		return nil
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host == "" || net.ParseIP(host) != nil {
+		return nil
+	}
+
+	return &BackendResolver{
+		Network:  network,
+		Address:  address,
+		Interval: interval,
+		host:     host,
+		port:     port,
+		lookup:   net.DefaultResolver.LookupHost,
+	}
+}
+
+// Resolve looks up the IP addresses of the hostname, and returns the previous and the
+// current ones, and whether they changed since the last lookup. The first lookup is
+// not a change. The addresses are kept if the lookup fails.
+func (r *BackendResolver) Resolve(ctx context.Context) ([]string, []string, bool, *gerr.GatewayDError) {
+	ips, err := r.lookup(ctx, r.host)
+	if err != nil {
+		return nil, nil, false, gerr.ErrResolveFailed.Wrap(err)
+	}
+
+	addresses := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addresses = append(addresses, net.JoinHostPort(ip, r.port))
+	}
+	sort.Strings(addresses)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := r.addresses
+	changed := previous != nil && !slices.Equal(previous, addresses)
+	r.addresses = addresses
+	r.resolvedAt = time.Now()
+
+	return previous, addresses, changed, nil
+}
+
+// Current returns the address the new connections are made to: the first IPv4
+// address, like Resolve, or the first address if there are none.
+func (r *BackendResolver) Current() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, address := range r.addresses {
+		host, _, err := net.SplitHostPort(address)
+		if err == nil && net.ParseIP(host).To4() != nil {
+			return address
+		}
+	}
+	if len(r.addresses) > 0 {
+		return r.addresses[0]
+	}
+	return ""
+}
+
+// IsCurrent returns true if the address is one of the addresses of the hostname, or if
+// the hostname isn't resolved yet. The hostname itself is always current, since it is
+// resolved on every dial.
+func (r *BackendResolver) IsCurrent(address string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.addresses == nil || address == r.Address || slices.Contains(r.addresses, address)
+}
+
+// Status returns the last resolution of the hostname.
+func (r *BackendResolver) Status() BackendStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return BackendStatus{
+		Network:    r.Network,
+		Address:    r.Address,
+		Addresses:  slices.Clone(r.addresses),
+		ResolvedAt: r.resolvedAt,
+	}
+}
@@ -0,0 +1,177 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/events"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// TestNewBackendResolver tests that only the hostnames are re-resolved.
+func TestNewBackendResolver(t *testing.T) {
+	assert.NotNil(t, NewBackendResolver("tcp", "db.example.com:5432", 0))
+	assert.Nil(t, NewBackendResolver("tcp", "127.0.0.1:5432", 0))
+	assert.Nil(t, NewBackendResolver("tcp", "[::1]:5432", 0))
+	assert.Nil(t, NewBackendResolver("unix", "/tmp/.s.PGSQL.5432", 0))
+	assert.Nil(t, NewBackendResolver("tcp", "db.example.com", 0))
+}
+
+// TestBackendResolver tests that the changes of the IP addresses of the hostname
+// are detected.
+func TestBackendResolver(t *testing.T) {
+	resolver := NewBackendResolver("tcp", "db.example.com:5432", 0)
+	ips := []string{"::1", "10.0.0.2"}
+	resolver.lookup = func(context.Context, string) ([]string, error) {
+		return ips, nil
+	}
+
+	// The first lookup is not a change.
+	assert.True(t, resolver.IsCurrent("10.0.0.1:5432"))
+	_, current, changed, err := resolver.Resolve(context.Background())
+	require.Nil(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, []string{"10.0.0.2:5432", "[::1]:5432"}, current)
+	assert.Equal(t, "10.0.0.2:5432", resolver.Current())
+	assert.False(t, resolver.IsCurrent("10.0.0.1:5432"))
+
+	ips = []string{"10.0.0.3"}
+	previous, current, changed, err := resolver.Resolve(context.Background())
+	require.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []string{"10.0.0.2:5432", "[::1]:5432"}, previous)
+	assert.Equal(t, []string{"10.0.0.3:5432"}, current)
+
+	// The addresses are kept if the lookup fails.
+	resolver.lookup = func(context.Context, string) ([]string, error) {
+		return nil, errors.New("no such host")
+	}
+	_, _, _, err = resolver.Resolve(context.Background())
+	assert.ErrorIs(t, err, gerr.ErrResolveFailed)
+	assert.Equal(t, []string{"10.0.0.3:5432"}, resolver.Status().Addresses)
+}
+
+// TestProxyResolveBackend tests that the available clients are reconnected to the new
+// address of the backend, and that the OnBackendChanged hooks are run.
+func TestProxyResolveBackend(t *testing.T) {
+	oldBackend, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer oldBackend.Close()
+	_, port, err := net.SplitHostPort(oldBackend.Addr().String())
+	require.NoError(t, err)
+	newBackend, err := net.Listen("tcp", net.JoinHostPort("127.0.0.2", port))
+	if err != nil {
+		t.Skipf("Failed to listen on 127.0.0.2: %v", err)
+	}
+	defer newBackend.Close()
+	for _, backend := range []net.Listener{oldBackend, newBackend} {
+		go func(backend net.Listener) {
+			for {
+				conn, err := backend.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+			}
+		}(backend)
+	}
+
+	logger := zerolog.Nop()
+	pluginRegistry := plugin.NewRegistry(
+		context.Background(), config.Loose, config.PassDown, config.Accept, config.Stop,
+		logger, false)
+	var mu sync.Mutex
+	var changes []map[string]interface{}
+	pluginRegistry.AddHook(sdk.OnBackendChanged, 1, func(
+		_ context.Context, params *v1.Struct, _ ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, params.AsMap())
+		return params, nil
+	})
+
+	clientConfig := &config.Client{
+		Network:     "tcp",
+		Address:     oldBackend.Addr().String(),
+		DialTimeout: config.DefaultDialTimeout,
+	}
+	client := NewClient(context.Background(), clientConfig, logger, nil)
+	require.NotNil(t, client)
+	connPool := pool.NewPool(context.Background(), 1)
+	require.Nil(t, connPool.Put(client.ID, client))
+
+	proxy := NewProxy(
+		context.Background(), connPool, pluginRegistry, false, false,
+		config.DefaultHealthCheckPeriod, clientConfig, logger, config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+
+	ips := []string{"127.0.0.1"}
+	proxy.Backend = NewBackendResolver("tcp", net.JoinHostPort("db.example.com", port), 0)
+	proxy.Backend.lookup = func(context.Context, string) ([]string, error) {
+		return ips, nil
+	}
+	changed, resolveErr := proxy.ResolveBackend()
+	require.Nil(t, resolveErr)
+	assert.False(t, changed)
+
+	sink := &eventSink{}
+	bus := events.NewBus(context.Background(), 1, time.Second, nil, logger, sink)
+	bus.Start()
+	events.SetDefault(bus)
+	defer events.SetDefault(nil)
+
+	ips = []string{"127.0.0.2"}
+	changed, resolveErr = proxy.ResolveBackend()
+	require.Nil(t, resolveErr)
+	assert.True(t, changed)
+
+	// The change of address is published as a failover.
+	bus.Stop()
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, events.FailoverHappened, sink.events[0].Type)
+	assert.Equal(t, []string{newBackend.Addr().String()}, sink.events[0].Fields["current"])
+
+	mu.Lock()
+	require.Len(t, changes, 1)
+	assert.Equal(t, []interface{}{oldBackend.Addr().String()}, changes[0]["previous"])
+	assert.Equal(t, []interface{}{newBackend.Addr().String()}, changes[0]["current"])
+	mu.Unlock()
+
+	require.Equal(t, 1, connPool.Size())
+	connPool.ForEach(func(_, value interface{}) bool {
+		client, ok := value.(*Client)
+		require.True(t, ok)
+		assert.Equal(t, newBackend.Addr().String(), client.Address)
+		assert.Equal(t, newBackend.Addr().String(), client.RemoteAddr())
+		assert.True(t, client.IsConnected())
+		return true
+	})
+}
+
+// eventSink records the events it receives.
+type eventSink struct {
+	events []events.Event
+}
+
+func (s *eventSink) Name() string {
+	return "test"
+}
+
+func (s *eventSink) Send(_ context.Context, event events.Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
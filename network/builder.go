@@ -0,0 +1,257 @@
+package network
+
+import (
+	"context"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ServerBuilder composes a server, its proxy and its pool of clients without a
+// config file, e.g. to embed GatewayD or in tests:
+//
+//	server, err := network.NewServerBuilder().
+//		WithListener("tcp", "localhost:15432").
+//		WithClientConfig(clientConfig).
+//		WithHooks(pluginRegistry).
+//		Build(ctx)
+//
+// The settings that aren't set are the ones of the default config.
+type ServerBuilder struct {
+	serverConfig   config.Server
+	proxyConfig    config.Proxy
+	clientConfig   config.Client
+	poolSize       int
+	pool           pool.IPool
+	pluginRegistry *plugin.Registry
+	pluginTimeout  time.Duration
+	logger         zerolog.Logger
+	maintenance    *Maintenance
+}
+
+// NewServerBuilder returns a builder with the settings of the default config.
+func NewServerBuilder() *ServerBuilder {
+	conf := config.NewConfig(context.Background(), "", "")
+	conf.LoadDefaults(context.Background())
+	conf.UnmarshalGlobalConfig(context.Background())
+	conf.UnmarshalPluginConfig(context.Background())
+
+	return &ServerBuilder{
+		serverConfig:  *conf.Global.Servers[config.Default],
+		proxyConfig:   *conf.Global.Proxies[config.Default],
+		clientConfig:  *conf.Global.Clients[config.Default],
+		poolSize:      conf.Global.Pools[config.Default].Size,
+		pluginTimeout: conf.Plugin.Timeout,
+		logger:        zerolog.Nop(),
+	}
+}
+
+// WithListener sets the network and address the server listens on.
+func (b *ServerBuilder) WithListener(network, address string) *ServerBuilder {
+	b.serverConfig.Network = network
+	b.serverConfig.Address = address
+	return b
+}
+
+// WithAdditionalListener adds a network and address the server also listens on.
+func (b *ServerBuilder) WithAdditionalListener(network, address string) *ServerBuilder {
+	b.serverConfig.Listeners = append(
+		b.serverConfig.Listeners, config.Listener{Network: network, Address: address})
+	return b
+}
+
+// WithTLS enables TLS with the cert and key files.
+func (b *ServerBuilder) WithTLS(certFile, keyFile string) *ServerBuilder {
+	b.serverConfig.EnableTLS = true
+	b.serverConfig.CertFile = certFile
+	b.serverConfig.KeyFile = keyFile
+	return b
+}
+
+// WithServerConfig replaces the settings of the server.
+func (b *ServerBuilder) WithServerConfig(serverConfig config.Server) *ServerBuilder {
+	b.serverConfig = serverConfig
+	return b
+}
+
+// WithProxyConfig replaces the settings of the proxy.
+func (b *ServerBuilder) WithProxyConfig(proxyConfig config.Proxy) *ServerBuilder {
+	b.proxyConfig = proxyConfig
+	return b
+}
+
+// WithClientConfig sets the settings of the clients connecting to the database.
+func (b *ServerBuilder) WithClientConfig(clientConfig config.Client) *ServerBuilder {
+	b.clientConfig = clientConfig
+	return b
+}
+
+// WithPoolSize sets the number of clients of the pool created by Build.
+func (b *ServerBuilder) WithPoolSize(size int) *ServerBuilder {
+	b.poolSize = size
+	return b
+}
+
+// WithPool makes the proxy use the pool of connected clients, instead of creating
+// one with Build. The client config is still used by elastic proxies and to recycle
+// the clients.
+func (b *ServerBuilder) WithPool(connPool pool.IPool) *ServerBuilder {
+	b.pool = connPool
+	return b
+}
+
+// WithHooks makes the server and the proxy run the hooks of the plugin registry.
+// No hooks are run by default.
+func (b *ServerBuilder) WithHooks(pluginRegistry *plugin.Registry) *ServerBuilder {
+	b.pluginRegistry = pluginRegistry
+	return b
+}
+
+// WithPluginTimeout sets the timeout of the hooks.
+func (b *ServerBuilder) WithPluginTimeout(timeout time.Duration) *ServerBuilder {
+	b.pluginTimeout = timeout
+	return b
+}
+
+// WithLogger sets the logger of the server, the proxy and the clients. Nothing is
+// logged by default.
+func (b *ServerBuilder) WithLogger(logger zerolog.Logger) *ServerBuilder {
+	b.logger = logger
+	return b
+}
+
+// WithTicker calls the OnTick hooks at the interval.
+func (b *ServerBuilder) WithTicker(interval time.Duration) *ServerBuilder {
+	b.serverConfig.EnableTicker = true
+	b.serverConfig.TickInterval = interval
+	return b
+}
+
+// WithMaintenance makes the server reject or queue the new connections while the
+// maintenance mode is enabled.
+func (b *ServerBuilder) WithMaintenance(maintenance *Maintenance) *ServerBuilder {
+	b.maintenance = maintenance
+	return b
+}
+
+// Build creates the pool of clients, unless one is set, and the proxy and server.
+// The server is started with Run.
+func (b *ServerBuilder) Build(ctx context.Context) (*Server, *gerr.GatewayDError) {
+	buildCtx, span := otel.Tracer(config.TracerName).Start(ctx, "Build server")
+	defer span.End()
+
+	pluginRegistry := b.pluginRegistry
+	if pluginRegistry == nil {
+		pluginRegistry = plugin.NewRegistry(
+			buildCtx,
+			config.DefaultCompatibilityPolicy,
+			config.DefaultVerificationPolicy,
+			config.DefaultAcceptancePolicy,
+			config.DefaultTerminationPolicy,
+			b.logger,
+			false,
+		)
+	}
+
+	// Check the policies of the proxy config before connecting the clients.
+	proxyConfig := b.proxyConfig
+	if err := (&Proxy{logger: b.logger}).Configure(&proxyConfig); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	clientConfig := b.clientConfig
+	connPool := b.pool
+	if connPool == nil {
+		size := config.If[int](b.poolSize > 0, b.poolSize, config.DefaultPoolSize)
+		connPool = pool.NewPool(buildCtx, size)
+		for i := 0; i < size; i++ {
+			client := NewClient(
+				buildCtx, &clientConfig, b.logger,
+				NewClientRetry(&clientConfig, b.logger),
+			)
+			if client == nil {
+				closeClients(connPool)
+				span.RecordError(gerr.ErrCreateClientFailed)
+				return nil, gerr.ErrCreateClientFailed
+			}
+			if err := connPool.Put(client.ID, client); err != nil {
+				client.Close()
+				closeClients(connPool)
+				span.RecordError(err)
+				return nil, gerr.ErrInitializePoolFailed.Wrap(err)
+			}
+		}
+	}
+
+	proxy := NewProxy(
+		buildCtx,
+		connPool,
+		pluginRegistry,
+		proxyConfig.Elastic,
+		proxyConfig.ReuseElasticClients,
+		config.If[time.Duration](
+			proxyConfig.HealthCheckPeriod > 0,
+			proxyConfig.HealthCheckPeriod,
+			config.DefaultHealthCheckPeriod,
+		),
+		&clientConfig,
+		b.logger,
+		b.pluginTimeout,
+	)
+	if err := proxy.Configure(&proxyConfig); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	proxy.WatchBackend()
+	proxy.WatchIdleTransactions()
+	proxy.WatchHealth()
+
+	serverConfig := b.serverConfig
+	server := NewServer(
+		buildCtx,
+		serverConfig.Network,
+		serverConfig.Address,
+		config.If[time.Duration](
+			serverConfig.TickInterval > 0,
+			serverConfig.TickInterval,
+			config.DefaultTickInterval,
+		),
+		Option{EnableTicker: serverConfig.EnableTicker},
+		proxy,
+		b.logger,
+		pluginRegistry,
+		b.pluginTimeout,
+		serverConfig.EnableTLS,
+		serverConfig.CertFile,
+		serverConfig.KeyFile,
+		serverConfig.HandshakeTimeout,
+	)
+	server.Configure(&serverConfig)
+	server.Maintenance = b.maintenance
+
+	span.SetAttributes(
+		attribute.String("network", server.Network),
+		attribute.String("address", server.Address),
+		attribute.Int("poolSize", connPool.Size()),
+	)
+
+	return server, nil
+}
+
+// closeClients closes the clients of the pool.
+func closeClients(connPool pool.IPool) {
+	connPool.ForEach(func(_, value interface{}) bool {
+		if client, ok := value.(*Client); ok {
+			client.Close()
+		}
+		return true
+	})
+	connPool.Clear()
+}
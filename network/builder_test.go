@@ -0,0 +1,92 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestServerBuilder tests that the server, proxy and pool are built with the given
+// settings and the defaults of the config.
+func TestServerBuilder(t *testing.T) {
+	// The clients only need to connect to the database.
+	database, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer database.Close()
+	go func() {
+		for {
+			conn, err := database.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	clientConfig := config.Client{
+		Network:     "tcp",
+		Address:     database.Addr().String(),
+		DialTimeout: time.Second,
+	}
+	proxyConfig := config.Proxy{
+		QueueSize: 5,
+		Firewall: config.Firewall{
+			Rules: []config.FirewallRule{{Name: "deny-all", When: "true"}},
+		},
+	}
+
+	maintenance := NewMaintenance()
+	server, err := NewServerBuilder().
+		WithListener("tcp", "127.0.0.1:15441").
+		WithClientConfig(clientConfig).
+		WithPoolSize(2).
+		WithProxyConfig(proxyConfig).
+		WithTicker(time.Second).
+		WithMaintenance(maintenance).
+		Build(context.Background())
+	require.Nil(t, err)
+
+	assert.Equal(t, "127.0.0.1:15441", server.Address)
+	assert.True(t, server.Options.EnableTicker)
+	assert.Equal(t, time.Second, server.TickInterval)
+	assert.Equal(t, config.DefaultEngineMode, server.EngineMode)
+	assert.Same(t, maintenance, server.Maintenance)
+
+	proxy, ok := server.proxy.(*Proxy)
+	require.True(t, ok)
+	defer proxy.Shutdown()
+	assert.Len(t, proxy.AvailableConnections(), 2)
+	assert.Equal(t, 5, proxy.QueueSize)
+	assert.Equal(t, config.DefaultQueueTimeout, proxy.QueueTimeout)
+	require.NotNil(t, proxy.Firewall)
+	assert.Len(t, proxy.Firewall.Rules, 1)
+}
+
+// TestServerBuilderErrors tests that the invalid settings and the clients that can't
+// connect fail the build.
+func TestServerBuilderErrors(t *testing.T) {
+	// Get a free port that nothing listens on.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	clientConfig := config.Client{Network: "tcp", Address: address, DialTimeout: time.Second}
+
+	_, buildErr := NewServerBuilder().
+		WithClientConfig(clientConfig).
+		WithProxyConfig(config.Proxy{Bandwidth: config.Bandwidth{IngressRate: 1, When: "("}}).
+		Build(context.Background())
+	assert.ErrorIs(t, buildErr, gerr.ErrInvalidPolicy)
+
+	_, buildErr = NewServerBuilder().
+		WithClientConfig(clientConfig).
+		Build(context.Background())
+	assert.ErrorIs(t, buildErr, gerr.ErrCreateClientFailed)
+}
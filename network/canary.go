@@ -0,0 +1,127 @@
+package network
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/rs/zerolog"
+)
+
+// Canary opens a client connection through the listener of the server on the interval
+// and runs a query on it, like the clients do, to measure the latency of the full path
+// through the gateway, i.e. its hooks, proxy and pool as well as the database.
+type Canary struct {
+	// Address is where the canary connects to. The first listener of the server is
+	// used if it is empty.
+	Address  string
+	Interval time.Duration
+	Timeout  time.Duration
+
+	logger zerolog.Logger
+	// probe connects to the address and runs the query, and returns how long each
+	// took.
+	probe func(ctx context.Context, address string) (time.Duration, time.Duration, error)
+}
+
+// NewCanary creates the canary of the config, or returns nil if it is disabled.
+func NewCanary(cfg config.Canary, logger zerolog.Logger) *Canary {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	query := config.If[string](cfg.Query != "", cfg.Query, config.DefaultCanaryQuery)
+	database := config.If[string](cfg.Database != "", cfg.Database, config.DefaultCanaryDatabase)
+	timeout := config.If[time.Duration](cfg.Timeout > 0, cfg.Timeout, config.DefaultCanaryTimeout)
+	return &Canary{
+		Address: cfg.Address,
+		Interval: config.If[time.Duration](
+			cfg.Interval > 0, cfg.Interval, config.DefaultCanaryInterval),
+		Timeout: timeout,
+		logger:  logger,
+		probe: func(ctx context.Context, address string) (time.Duration, time.Duration, error) {
+			start := time.Now()
+			conn, err := ConnectPostgres(ctx, address, cfg.User, cfg.Password, database,
+				config.DefaultCanaryApplicationName, timeout)
+			if err != nil {
+				return 0, 0, err
+			}
+			defer conn.Close(ctx)
+			connected := time.Since(start)
+
+			start = time.Now()
+			_, err = conn.Exec(ctx, query).ReadAll()
+			return connected, time.Since(start), err //nolint:wrapcheck
+		},
+	}
+}
+
+// Probe runs a canary session through the address, and records its latencies or its
+// failure.
+func (c *Canary) Probe(ctx context.Context, address string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	connect, query, err := c.probe(ctx, address)
+	if connect > 0 {
+		metrics.CanaryLatency.WithLabelValues(address, "connect").Observe(connect.Seconds())
+	}
+	if err != nil {
+		metrics.CanaryFailures.WithLabelValues(address).Inc()
+		return err
+	}
+	metrics.CanaryLatency.WithLabelValues(address, "query").Observe(query.Seconds())
+	c.logger.Trace().Str("address", address).Str("connect", connect.String()).Str(
+		"query", query.String()).Msg("The canary session succeeded")
+	return nil
+}
+
+// Start runs a canary session through the listener on the interval, until the server
+// is stopped.
+func (c *Canary) Start(ctx context.Context, listener net.Addr, stop <-chan struct{}) {
+	address := c.Address
+	if address == "" {
+		address = canaryAddress(listener)
+	}
+	c.logger.Info().Str("address", address).Str("interval", c.Interval.String()).Msg(
+		"Running the canary sessions")
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Probe(ctx, address); err != nil {
+				c.logger.Warn().Err(err).Str("address", address).Msg("The canary session failed")
+			}
+		}
+	}
+}
+
+// canaryAddress returns the address the canary connects to the listener at, which is
+// the loopback address if the listener listens on all the addresses.
+func canaryAddress(listener net.Addr) string {
+	host, port, err := net.SplitHostPort(listener.String())
+	if err != nil {
+		return listener.String()
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		host = config.If[string](ip.To4() != nil, "127.0.0.1", "::1")
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// serverAddress returns the address the clients inside GatewayD connect to the server
+// listening at the address at, like the canary.
+func serverAddress(address string) string {
+	if addr, err := net.ResolveTCPAddr("tcp", address); err == nil {
+		return canaryAddress(addr)
+	}
+	return address
+}
@@ -0,0 +1,62 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanaryAddress(t *testing.T) {
+	assert.Equal(t, "127.0.0.1:15432",
+		canaryAddress(&net.TCPAddr{IP: net.IPv4zero, Port: 15432}))
+	assert.Equal(t, "[::1]:15432",
+		canaryAddress(&net.TCPAddr{IP: net.IPv6unspecified, Port: 15432}))
+	assert.Equal(t, "10.0.0.1:15432",
+		canaryAddress(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 15432}))
+}
+
+// TestCanary tests that the canary probes the address on the interval until the server
+// stops.
+func TestCanary(t *testing.T) {
+	assert.Nil(t, NewCanary(config.Canary{}, zerolog.Nop()))
+
+	canary := NewCanary(config.Canary{Enabled: true, Interval: 10 * time.Millisecond}, zerolog.Nop())
+	require.NotNil(t, canary)
+	assert.Equal(t, config.DefaultCanaryTimeout, canary.Timeout)
+	probed := make(chan string, 10)
+	canary.probe = func(_ context.Context, address string) (time.Duration, time.Duration, error) {
+		probed <- address
+		return time.Millisecond, time.Millisecond, nil
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		canary.Start(context.Background(), &net.TCPAddr{IP: net.IPv4zero, Port: 15432}, stop)
+		close(done)
+	}()
+	select {
+	case address := <-probed:
+		assert.Equal(t, "127.0.0.1:15432", address)
+	case <-time.After(time.Second):
+		t.Fatal("the canary didn't probe the server")
+	}
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the canary didn't stop with the server")
+	}
+
+	canary.probe = func(context.Context, string) (time.Duration, time.Duration, error) {
+		return 0, 0, errors.New("connection refused")
+	}
+	assert.NotNil(t, canary.Probe(context.Background(), "127.0.0.1:15432"))
+}
@@ -0,0 +1,186 @@
+package network
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/rs/zerolog"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// acmeChallengeReadHeaderTimeout is how long the ACME challenge server waits for the
+// headers of a request.
+const acmeChallengeReadHeaderTimeout = 10 * time.Second
+
+// CertificateReloader serves the certificate of the cert and key files, and loads
+// them again when they change, so that rotated certificates are used by the new
+// connections without a restart. The directories of the files are watched, since the
+// files are often replaced, e.g. by swapping symlinks, rather than written in place.
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+	logger   zerolog.Logger
+	watcher  *fsnotify.Watcher
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// NewCertificateReloader loads the cert and key files and starts watching them.
+func NewCertificateReloader(
+	certFile, keyFile string, logger zerolog.Logger,
+) (*CertificateReloader, error) {
+	reloader := &CertificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+	}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	for _, directory := range []string{filepath.Dir(certFile), filepath.Dir(keyFile)} {
+		if err := watcher.Add(directory); err != nil {
+			watcher.Close()
+			return nil, err //nolint:wrapcheck
+		}
+	}
+	reloader.watcher = watcher
+	go reloader.watch()
+
+	return reloader, nil
+}
+
+// Reload loads the cert and key files. The current certificate is kept if they
+// can't be loaded, e.g. because only one of them is rotated yet.
+func (r *CertificateReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	r.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate returns the current certificate, for the TLS config.
+func (r *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Close stops watching the files.
+func (r *CertificateReloader) Close() {
+	if r.watcher != nil {
+		r.watcher.Close()
+	}
+}
+
+// watch reloads the files when anything changes in their directories.
+func (r *CertificateReloader) watch() {
+	for {
+		select {
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Chmod) {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				metrics.TLSCertificateReloadFailures.Inc()
+				r.logger.Warn().Err(err).Str("certFile", r.certFile).Msg(
+					"Failed to reload the TLS certificate, keeping the current one")
+				continue
+			}
+			metrics.TLSCertificateReloads.Inc()
+			r.logger.Info().Str("certFile", r.certFile).Msg("Reloaded the TLS certificate")
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			r.logger.Error().Err(err).Msg("Failed to watch the TLS certificate files")
+		}
+	}
+}
+
+// ACMECertificates gets the certificates of the domains from an ACME certificate
+// authority, caches them and renews them before they expire. The HTTP-01 challenges
+// are answered by a HTTP server on the configured address.
+type ACMECertificates struct {
+	manager *autocert.Manager
+	domains []string
+	server  *http.Server
+	logger  zerolog.Logger
+}
+
+// NewACMECertificates creates the ACME certificates of the domains, and starts
+// answering the challenges.
+func NewACMECertificates(acmeConfig config.ACME, logger zerolog.Logger) (*ACMECertificates, error) {
+	if len(acmeConfig.Domains) == 0 {
+		return nil, errors.New("at least one domain is required to get ACME certificates")
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(acmeConfig.Domains...),
+		Email:      acmeConfig.Email,
+	}
+	if acmeConfig.CacheDir != "" {
+		manager.Cache = autocert.DirCache(acmeConfig.CacheDir)
+	}
+	if acmeConfig.DirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: acmeConfig.DirectoryURL}
+	}
+
+	certificates := &ACMECertificates{
+		manager: manager,
+		domains: acmeConfig.Domains,
+		logger:  logger,
+	}
+	if acmeConfig.HTTPAddress != "" {
+		certificates.server = &http.Server{
+			Addr:              acmeConfig.HTTPAddress,
+			Handler:           manager.HTTPHandler(nil),
+			ReadHeaderTimeout: acmeChallengeReadHeaderTimeout,
+		}
+		go func() {
+			if err := certificates.server.ListenAndServe(); err != nil &&
+				!errors.Is(err, http.ErrServerClosed) {
+				logger.Error().Err(err).Str("address", acmeConfig.HTTPAddress).Msg(
+					"Failed to answer the ACME challenges")
+			}
+		}()
+	}
+
+	return certificates, nil
+}
+
+// GetCertificate returns the certificate of the domain the client asked for with
+// SNI, or of the first domain if it didn't, which is obtained on the first use.
+func (a *ACMECertificates) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if hello.ServerName == "" {
+		hello.ServerName = a.domains[0]
+	}
+	cert, err := a.manager.GetCertificate(hello)
+	if err != nil {
+		a.logger.Error().Err(err).Str("domain", hello.ServerName).Msg(
+			"Failed to get the ACME certificate")
+	}
+	return cert, err //nolint:wrapcheck
+}
+
+// Close stops answering the challenges.
+func (a *ACMECertificates) Close() {
+	if a.server != nil {
+		a.server.Close()
+	}
+}
@@ -0,0 +1,124 @@
+package network
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes a self-signed certificate with the serial number and
+// its key to the files.
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(
+		keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.WriteFile(
+		certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+}
+
+// serialOf returns the serial number of the certificate served by the reloader.
+func serialOf(t *testing.T, reloader *CertificateReloader) int64 {
+	t.Helper()
+
+	cert, err := reloader.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.SerialNumber.Int64()
+}
+
+// TestCertificateReloader tests that the rotated certificate is served once the
+// files change, and that the current one is kept if they are invalid.
+func TestCertificateReloader(t *testing.T) {
+	directory := t.TempDir()
+	certFile := filepath.Join(directory, "tls.crt")
+	keyFile := filepath.Join(directory, "tls.key")
+	writeCertificate(t, certFile, keyFile, 1)
+
+	reloader, err := NewCertificateReloader(certFile, keyFile, zerolog.Nop())
+	require.NoError(t, err)
+	defer reloader.Close()
+	assert.Equal(t, int64(1), serialOf(t, reloader))
+
+	reloads := testutil.ToFloat64(metrics.TLSCertificateReloads)
+	writeCertificate(t, certFile, keyFile, 2)
+	assert.Eventually(t, func() bool {
+		return serialOf(t, reloader) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Greater(t, testutil.ToFloat64(metrics.TLSCertificateReloads), reloads)
+
+	failures := testutil.ToFloat64(metrics.TLSCertificateReloadFailures)
+	require.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0o600))
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.TLSCertificateReloadFailures) > failures
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(2), serialOf(t, reloader))
+
+	// The TLS config of the rotated certificates enables TLS on the connections.
+	tlsConfig := CreateTLSConfigWithCertificates(reloader.GetCertificate)
+	assert.True(t, NewConnWrapper(nil, tlsConfig, time.Second).IsTLSEnabled())
+}
+
+// TestCertificateReloader_Invalid tests that the reloader isn't created without
+// valid files.
+func TestCertificateReloader_Invalid(t *testing.T) {
+	directory := t.TempDir()
+	_, err := NewCertificateReloader(
+		filepath.Join(directory, "tls.crt"), filepath.Join(directory, "tls.key"), zerolog.Nop())
+	assert.Error(t, err)
+}
+
+// TestACMECertificates tests that the ACME certificates require the domains, and
+// that the clients without SNI get the certificate of the first domain.
+func TestACMECertificates(t *testing.T) {
+	_, err := NewACMECertificates(config.ACME{Enabled: true}, zerolog.Nop())
+	assert.Error(t, err)
+
+	certificates, err := NewACMECertificates(config.ACME{
+		Enabled:      true,
+		Domains:      []string{"db.example.com"},
+		CacheDir:     t.TempDir(),
+		DirectoryURL: "http://127.0.0.1:0/directory",
+	}, zerolog.Nop())
+	require.NoError(t, err)
+	defer certificates.Close()
+
+	// The certificate can't be obtained without a certificate authority.
+	hello := &tls.ClientHelloInfo{}
+	_, err = certificates.GetCertificate(hello)
+	assert.Error(t, err)
+	assert.Equal(t, "db.example.com", hello.ServerName)
+
+	// The other domains are rejected by the host policy.
+	_, err = certificates.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"})
+	assert.Error(t, err)
+}
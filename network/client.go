@@ -3,7 +3,10 @@ package network
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -34,6 +37,9 @@ type Client struct {
 	connected atomic.Bool
 	mu        sync.Mutex
 	retry     IRetry
+	// backendKey is the process ID and the secret key the server sent when the
+	// connection started, for cancelling its queries.
+	backendKey atomic.Pointer[[2]uint32]
 
 	TCPKeepAlive       bool
 	TCPKeepAlivePeriod time.Duration
@@ -45,6 +51,20 @@ type Client struct {
 	ID                 string
 	Network            string // tcp/udp/unix
 	Address            string
+	// FallbackDelay is how long to wait for the IPv6 addresses of the hostname to
+	// connect before racing the IPv4 ones (RFC 6555 happy eyeballs).
+	FallbackDelay time.Duration
+	// Tunnel is the SSH tunnel the connection to the server goes through, if any.
+	Tunnel *SSHTunnel
+	// UpstreamProxy is the SOCKS5 or HTTP proxy the connection to the server goes
+	// through, if any.
+	UpstreamProxy *UpstreamProxy
+	// IAMAuth answers the password requests of the server with IAM auth tokens,
+	// if IAM authentication is enabled.
+	IAMAuth *IAMAuth
+	// TLSConfig is the TLS config of the connection to the server, which is upgraded
+	// to TLS right after it is made, if it is set.
+	TLSConfig *tls.Config
 }
 
 var _ IClient = (*Client)(nil)
@@ -65,40 +85,95 @@ func NewClient(
 	client.connected.Store(false)
 	client.logger = logger
 
-	// Try to resolve the address and log an error if it can't be resolved.
-	addr, err := Resolve(clientConfig.Network, clientConfig.Address, logger)
-	if err != nil {
-		logger.Error().Err(err).Msg("Failed to resolve address")
-		span.RecordError(err)
-	}
+	if clientConfig.SSHTunnel.Enabled || clientConfig.UpstreamProxy.URL != "" {
+		// The address is resolved by the bastion or the upstream proxy, since it
+		// might not be resolvable here.
+		client = Client{
+			ctx:         clientCtx,
+			mu:          sync.Mutex{},
+			retry:       retry,
+			Network:     clientConfig.Network,
+			Address:     clientConfig.Address,
+			DialTimeout: clientConfig.DialTimeout,
+		}
 
-	// Create a resolved client.
-	client = Client{
-		ctx:         clientCtx,
-		mu:          sync.Mutex{},
-		retry:       retry,
-		Network:     clientConfig.Network,
-		Address:     addr,
-		DialTimeout: clientConfig.DialTimeout,
-	}
+		if clientConfig.SSHTunnel.Enabled {
+			client.Tunnel = GetSSHTunnel(clientConfig.SSHTunnel, clientConfig.DialTimeout, logger)
+		} else {
+			upstreamProxy, err := NewUpstreamProxy(clientConfig.UpstreamProxy, clientConfig.DialTimeout)
+			if err != nil {
+				logger.Error().Err(err).Msg("Failed to configure the upstream proxy")
+				span.RecordError(err)
+				return nil
+			}
+			client.UpstreamProxy = upstreamProxy
+		}
+	} else if clientConfig.DualStack {
+		// The hostname is resolved on every dial, so that the connections are made to
+		// its IPv6 or IPv4 addresses, whichever connects first.
+		client = Client{
+			ctx:         clientCtx,
+			mu:          sync.Mutex{},
+			retry:       retry,
+			Network:     clientConfig.Network,
+			Address:     clientConfig.Address,
+			DialTimeout: clientConfig.DialTimeout,
+			FallbackDelay: config.If[time.Duration](
+				clientConfig.FallbackDelay > 0,
+				clientConfig.FallbackDelay,
+				config.DefaultFallbackDelay,
+			),
+		}
+	} else {
+		// Try to resolve the address and log an error if it can't be resolved.
+		addr, err := Resolve(clientConfig.Network, clientConfig.Address, logger)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to resolve address")
+			span.RecordError(err)
+		}
 
-	// Fall back to the original network and address if the address can't be resolved.
-	if client.Address == "" || client.Network == "" {
+		// Create a resolved client.
 		client = Client{
-			Network: clientConfig.Network,
-			Address: clientConfig.Address,
+			ctx:         clientCtx,
+			mu:          sync.Mutex{},
+			retry:       retry,
+			Network:     clientConfig.Network,
+			Address:     addr,
+			DialTimeout: clientConfig.DialTimeout,
+		}
+
+		// Fall back to the original network and address if the address can't be resolved.
+		if client.Address == "" || client.Network == "" {
+			client = Client{
+				Network: clientConfig.Network,
+				Address: clientConfig.Address,
+			}
 		}
 	}
 
+	// Authenticate to the server with IAM auth tokens, for the configured address,
+	// which the tokens are signed for.
+	if clientConfig.IAMAuth.Provider != "" {
+		iamAuth, err := GetIAMAuth(clientConfig.IAMAuth, clientConfig.Address, clientConfig.DialTimeout)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to configure the IAM authentication")
+			span.RecordError(err)
+			return nil
+		}
+		client.IAMAuth = iamAuth
+	}
+
+	// Connect to the server with mTLS, with the SVID of the workload.
+	if clientConfig.SPIFFE.Enabled {
+		client.TLSConfig = GetX509Source(clientConfig.SPIFFE.SocketPath, logger).ClientTLSConfig(
+			clientConfig.SPIFFE.ServerID)
+	}
+
 	var origErr error
 	// Create a new connection and retry a few times if needed.
 	//nolint:wrapcheck
 	if conn, err := client.retry.Retry(func() (any, error) {
-		if client.DialTimeout > 0 {
-			return net.DialTimeout(client.Network, client.Address, client.DialTimeout)
-		} else {
-			return net.Dial(client.Network, client.Address)
-		}
+		return client.dial()
 	}); err != nil {
 		origErr = err
 	} else {
@@ -215,12 +290,21 @@ func (c *Client) Send(data []byte) (int, *gerr.GatewayDError) {
 
 // Receive receives data from the server.
 func (c *Client) Receive() (int, []byte, *gerr.GatewayDError) {
+	received, data, _, err := c.ReceiveUpTo(0)
+	return received, data, err
+}
+
+// ReceiveUpTo receives data from the server, like Receive, but stops once limit
+// bytes are buffered, so that large responses can be streamed with bounded memory.
+// The returned bool is true if there is more data to receive. A limit of zero
+// reads the whole response.
+func (c *Client) ReceiveUpTo(limit int) (int, []byte, bool, *gerr.GatewayDError) {
 	_, span := otel.Tracer(config.TracerName).Start(c.ctx, "Receive")
 	defer span.End()
 
 	if !c.connected.Load() {
 		span.RecordError(gerr.ErrClientNotConnected)
-		return 0, nil, gerr.ErrClientNotConnected
+		return 0, nil, false, gerr.ErrClientNotConnected
 	}
 
 	var ctx context.Context
@@ -241,7 +325,7 @@ func (c *Client) Receive() (int, []byte, *gerr.GatewayDError) {
 		if err != nil {
 			c.logger.Error().Err(err).Msg("Couldn't receive data from the server")
 			span.RecordError(err)
-			return received, buffer.Bytes(), gerr.ErrClientReceiveFailed.Wrap(err)
+			return received, buffer.Bytes(), false, gerr.ErrClientReceiveFailed.Wrap(err)
 		}
 		received += read
 		buffer.Write(chunk[:read])
@@ -249,11 +333,47 @@ func (c *Client) Receive() (int, []byte, *gerr.GatewayDError) {
 		if read == 0 || read < c.ReceiveChunkSize {
 			break
 		}
+
+		if limit > 0 && received >= limit {
+			span.AddEvent("Received data from server up to the limit")
+			return received, buffer.Bytes(), true, nil
+		}
 	}
 
 	span.AddEvent("Received data from server")
 
-	return received, buffer.Bytes(), nil
+	if c.backendKey.Load() == nil {
+		if processID, secretKey, ok := PostgresBackendKeyData(buffer.Bytes()); ok {
+			c.backendKey.Store(&[2]uint32{processID, secretKey})
+		}
+	}
+
+	return received, buffer.Bytes(), false, nil
+}
+
+// Cancel asks the server to cancel the query in flight on the connection, with a
+// CancelRequest message over a new connection. The server doesn't answer it, so the
+// query might have finished already.
+func (c *Client) Cancel() *gerr.GatewayDError {
+	key := c.backendKey.Load()
+	if key == nil {
+		return gerr.ErrCancelRequestFailed.Wrap(
+			errors.New("the server didn't send the backend key of the connection"))
+	}
+
+	conn, err := c.dial()
+	if err != nil {
+		return gerr.ErrCancelRequestFailed.Wrap(err)
+	}
+	defer conn.Close()
+
+	if c.DialTimeout > 0 {
+		_ = conn.SetWriteDeadline(time.Now().Add(c.DialTimeout))
+	}
+	if _, err := conn.Write(PostgreSQLCancelRequest(key[0], key[1])); err != nil {
+		return gerr.ErrCancelRequestFailed.Wrap(err)
+	}
+	return nil
 }
 
 // Reconnect reconnects to the server.
@@ -271,6 +391,7 @@ func (c *Client) Reconnect() error {
 		metrics.ServerConnections.Dec()
 	}
 	c.connected.Store(false)
+	c.backendKey.Store(nil)
 
 	// Restore the address and network.
 	c.Address = address
@@ -280,11 +401,7 @@ func (c *Client) Reconnect() error {
 	// Create a new connection and retry a few times if needed.
 	//nolint:wrapcheck
 	if conn, err := c.retry.Retry(func() (any, error) {
-		if c.DialTimeout > 0 {
-			return net.DialTimeout(c.Network, c.Address, c.DialTimeout)
-		} else {
-			return net.Dial(c.Network, c.Address)
-		}
+		return c.dial()
 	}); err != nil {
 		origErr = err
 	} else {
@@ -314,6 +431,65 @@ func (c *Client) Reconnect() error {
 	return nil
 }
 
+// dial connects to the server, through the SSH tunnel or the upstream proxy if any.
+func (c *Client) dial() (net.Conn, error) {
+	var conn net.Conn
+	var err error
+	switch {
+	case c.Tunnel != nil:
+		conn, err = c.Tunnel.Dial(c.Network, c.Address)
+	case c.UpstreamProxy != nil:
+		conn, err = c.UpstreamProxy.Dial(c.Network, c.Address)
+	default:
+		dialer := net.Dialer{Timeout: c.DialTimeout, FallbackDelay: c.FallbackDelay}
+		conn, err = dialer.Dial(c.Network, c.Address)
+		if err == nil {
+			metrics.BackendDials.WithLabelValues(AddressFamily(conn.RemoteAddr())).Inc()
+		}
+	}
+	if err != nil || c.TLSConfig == nil {
+		return conn, err //nolint:wrapcheck
+	}
+
+	return c.upgradeToTLS(conn)
+}
+
+// upgradeToTLS asks the server to upgrade the connection to TLS with an SSLRequest,
+// and performs the TLS handshake, before the client sends its startup message:
+// https://www.postgresql.org/docs/current/protocol-flow.html#PROTOCOL-FLOW-SSL
+func (c *Client) upgradeToTLS(conn net.Conn) (net.Conn, error) {
+	ctx := context.Background()
+	if c.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.DialTimeout)
+		defer cancel()
+		_ = conn.SetDeadline(time.Now().Add(c.DialTimeout))
+	}
+
+	response := make([]byte, 1)
+	if _, err := conn.Write(PostgreSQLSSLRequest()); err != nil {
+		conn.Close()
+		return nil, err //nolint:wrapcheck
+	}
+	if _, err := io.ReadFull(conn, response); err != nil {
+		conn.Close()
+		return nil, err //nolint:wrapcheck
+	}
+	if response[0] != 'S' {
+		conn.Close()
+		return nil, errors.New("the server doesn't support TLS")
+	}
+
+	tlsConn := tls.Client(conn, c.TLSConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err //nolint:wrapcheck
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return tlsConn, nil
+}
+
 // Close closes the connection to the server.
 func (c *Client) Close() {
 	_, span := otel.Tracer(config.TracerName).Start(c.ctx, "Close")
@@ -350,6 +526,19 @@ func (c *Client) Close() {
 	span.AddEvent("Closed connection to server")
 }
 
+// kill closes the connection to the server as if the server closed it, e.g. to inject
+// a fault, so that the client only finds out when it uses the connection.
+func (c *Client) kill() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil {
+		if err := c.conn.Close(); err != nil {
+			c.logger.Debug().Err(err).Msg("Failed to kill the connection")
+		}
+	}
+}
+
 // IsConnected checks if the client is still connected to the server.
 func (c *Client) IsConnected() bool {
 	if c != nil && c.ctx.Err() != nil {
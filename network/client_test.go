@@ -2,6 +2,7 @@ package network
 
 import (
 	"context"
+	"net"
 	"testing"
 	"time"
 
@@ -244,3 +245,32 @@ func BenchmarkIsConnected(b *testing.B) {
 		client.IsConnected()
 	}
 }
+
+// TestReceiveUpTo tests that the client stops receiving once the limit is reached.
+func TestReceiveUpTo(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+
+	client := &Client{
+		conn:             local,
+		ctx:              context.Background(),
+		logger:           zerolog.Nop(),
+		ReceiveChunkSize: 4,
+	}
+	client.connected.Store(true)
+
+	go func() {
+		_, _ = remote.Write([]byte("0123456789abcd"))
+	}()
+
+	received, data, more, err := client.ReceiveUpTo(8)
+	assert.Nil(t, err)
+	assert.True(t, more)
+	assert.Equal(t, "01234567", string(data[:received]))
+
+	received, data, more, err = client.ReceiveUpTo(8)
+	assert.Nil(t, err)
+	assert.False(t, more)
+	assert.Equal(t, "89abcd", string(data[:received]))
+}
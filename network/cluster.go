@@ -0,0 +1,395 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/events"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog"
+	"golang.org/x/exp/slices"
+)
+
+// ClusterMember is the last check of a member of a PostgreSQL cluster.
+type ClusterMember struct {
+	Address   string    `json:"address"`
+	Primary   bool      `json:"primary"`
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// ClusterMonitor finds the primary and the standbys of a PostgreSQL cluster among its
+// members, by checking which ones are in recovery, or learns them from Patroni, so that
+// the clients of the proxies follow the primary after a failover, e.g. of a Patroni or
+// repmgr setup. The old primary becomes a standby once it rejoins the cluster in
+// recovery.
+type ClusterMonitor struct {
+	Members  []string
+	Interval time.Duration
+
+	logger zerolog.Logger
+	// check returns whether the member is in recovery.
+	check func(ctx context.Context, address string) (bool, error)
+	// topology returns the members of the cluster and their roles.
+	topology func(ctx context.Context) ([]ClusterMember, error)
+	// watch blocks until the topology might have changed, if the source can be
+	// watched instead of polled on the interval.
+	watch func(ctx context.Context) error
+
+	mu        sync.RWMutex
+	primary   string
+	standbys  []string
+	status    []ClusterMember
+	next      int
+	listeners []func(primary string)
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewClusterMonitor creates a monitor of the members of the cluster, which connects to
+// them as the user of the primary discovery config, or to the source of the config.
+func NewClusterMonitor(
+	discovery config.PrimaryDiscovery, dialTimeout time.Duration, logger zerolog.Logger,
+) *ClusterMonitor {
+	monitor := &ClusterMonitor{
+		Members:  discovery.Members,
+		Interval: discovery.Interval,
+		logger:   logger,
+		check: func(ctx context.Context, address string) (bool, error) {
+			return isInRecovery(ctx, address, discovery, dialTimeout)
+		},
+		stop: make(chan struct{}),
+	}
+	monitor.topology = monitor.checkMembers
+
+	switch discovery.Source {
+	case config.PatroniSource:
+		monitor.topology = (&patroniSource{
+			endpoints: discovery.Endpoints,
+			timeout:   dialTimeout,
+		}).Topology
+	case config.EtcdSource:
+		source := &etcdSource{
+			endpoints: discovery.Endpoints,
+			prefix:    patroniPrefix(discovery),
+			token:     discovery.Token,
+			timeout:   dialTimeout,
+		}
+		monitor.topology, monitor.watch = source.Topology, source.Watch
+	case config.ConsulSource:
+		source := &consulSource{
+			endpoints: discovery.Endpoints,
+			prefix:    strings.TrimPrefix(patroniPrefix(discovery), "/"),
+			token:     discovery.Token,
+			timeout:   dialTimeout,
+		}
+		monitor.topology, monitor.watch = source.Topology, source.Watch
+	case config.PostgresSource:
+	}
+	return monitor
+}
+
+// ClusterKey identifies the cluster of the primary discovery config, so that the config
+// groups of the same cluster, e.g. for the writes and the reads, share its monitor.
+func ClusterKey(discovery config.PrimaryDiscovery) string {
+	if discovery.Source == "" || discovery.Source == config.PostgresSource {
+		return sortedList(discovery.Members)
+	}
+	return fmt.Sprintf("%s:%s%s", discovery.Source, sortedList(discovery.Endpoints),
+		patroniPrefix(discovery))
+}
+
+func sortedList(values []string) string {
+	sorted := slices.Clone(values)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// isInRecovery connects to the member and checks whether it is in recovery, i.e. a
+// standby.
+func isInRecovery(
+	ctx context.Context, address string, discovery config.PrimaryDiscovery, dialTimeout time.Duration,
+) (bool, error) {
+	conn, err := ConnectPostgres(
+		ctx, address, discovery.User, discovery.Password, discovery.Database, config.Name, dialTimeout)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(ctx)
+
+	results, err := conn.Exec(ctx, "SELECT pg_is_in_recovery()").ReadAll()
+	if err != nil {
+		return false, err //nolint:wrapcheck
+	}
+	if len(results) != 1 || len(results[0].Rows) != 1 || len(results[0].Rows[0]) != 1 {
+		return false, errors.New("unexpected result of pg_is_in_recovery()")
+	}
+	return string(results[0].Rows[0][0]) == "t", nil
+}
+
+// ConnectPostgres connects to the PostgreSQL server at the address as the user, out of
+// band of the pools, e.g. to check it or to connect through a server like a client.
+func ConnectPostgres(
+	ctx context.Context, address, user, password, database, applicationName string,
+	dialTimeout time.Duration,
+) (*pgconn.PgConn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	portNumber, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port of %s: %w", address, err)
+	}
+
+	// The environment variables of libpq are ignored, except for the TLS settings.
+	connConfig, err := pgconn.ParseConfig("sslmode=prefer")
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	connConfig.Host = host
+	connConfig.Port = uint16(portNumber)
+	connConfig.User = user
+	connConfig.Password = password
+	connConfig.Database = database
+	connConfig.ConnectTimeout = dialTimeout
+	connConfig.Fallbacks = nil
+	connConfig.RuntimeParams = map[string]string{"application_name": applicationName}
+
+	return pgconn.ConnectConfig(ctx, connConfig) //nolint:wrapcheck
+}
+
+// OnPrimaryChanged registers a function that is called with the new primary after it
+// changed.
+func (m *ClusterMonitor) OnPrimaryChanged(listener func(primary string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, listener)
+}
+
+// checkMembers checks which of the members of the cluster are in recovery.
+func (m *ClusterMonitor) checkMembers(ctx context.Context) ([]ClusterMember, error) {
+	status := make([]ClusterMember, len(m.Members))
+	var wait sync.WaitGroup
+	for index, address := range m.Members {
+		wait.Add(1)
+		go func(index int, address string) {
+			defer wait.Done()
+			inRecovery, err := m.check(ctx, address)
+			status[index] = ClusterMember{
+				Address:   address,
+				Primary:   err == nil && !inRecovery,
+				Healthy:   err == nil,
+				CheckedAt: time.Now(),
+			}
+			if err != nil {
+				status[index].Error = err.Error()
+				metrics.ClusterMemberCheckFailures.WithLabelValues(address).Inc()
+			}
+		}(index, address)
+	}
+	wait.Wait()
+	return status, nil
+}
+
+// Check checks all the members of the cluster, and returns the previous and the current
+// primary, and whether it changed since the last check. The first primary found is not
+// a change. The primary is kept if it can't be checked, e.g. during a network partition,
+// unless another member was promoted.
+func (m *ClusterMonitor) Check(ctx context.Context) (string, string, bool, *gerr.GatewayDError) {
+	status, err := m.topology(ctx)
+	if err != nil {
+		m.mu.RLock()
+		primary := m.primary
+		m.mu.RUnlock()
+		return primary, primary, false, gerr.ErrPrimaryNotFound.Wrap(err)
+	}
+
+	var primaries, standbys []string
+	for _, member := range status {
+		switch {
+		case member.Primary:
+			primaries = append(primaries, member.Address)
+		case member.Healthy:
+			standbys = append(standbys, member.Address)
+		}
+	}
+
+	m.mu.Lock()
+	previous := m.primary
+	m.status = status
+	m.standbys = standbys
+	switch {
+	case len(primaries) == 1:
+		m.primary = primaries[0]
+	case len(primaries) > 1 && slices.Contains(primaries, previous):
+		// The old primary might not know yet that it was replaced, so the primary
+		// is kept until it steps down.
+		m.logger.Warn().Strs("primaries", primaries).Msg(
+			"More than one member of the cluster isn't in recovery")
+	case len(primaries) > 1:
+		m.primary = primaries[0]
+		m.logger.Warn().Strs("primaries", primaries).Msg(
+			"More than one member of the cluster isn't in recovery")
+	}
+	current := m.primary
+	listeners := slices.Clone(m.listeners)
+	m.mu.Unlock()
+
+	if current == "" {
+		addresses := make([]string, 0, len(status))
+		for _, member := range status {
+			addresses = append(addresses, member.Address)
+		}
+		return previous, current, false, gerr.ErrPrimaryNotFound.Wrap(
+			fmt.Errorf("none of the members is the primary: %s", strings.Join(addresses, ", ")))
+	}
+	if previous == "" || previous == current {
+		return previous, current, false, nil
+	}
+
+	metrics.ClusterPrimaryChanges.Inc()
+	m.logger.Info().Str("previous", previous).Str("current", current).Strs(
+		"standbys", standbys).Msg("The primary of the cluster changed")
+	events.Publish(events.FailoverHappened, "The primary of the cluster changed",
+		map[string]interface{}{
+			"previous": previous,
+			"current":  current,
+			"standbys": standbys,
+		})
+	for _, listener := range listeners {
+		listener(current)
+	}
+	return previous, current, true, nil
+}
+
+// Start checks the members on the interval, or whenever the watched source changes,
+// until the monitor is stopped.
+func (m *ClusterMonitor) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	check := func() {
+		if _, _, _, err := m.Check(ctx); err != nil {
+			m.logger.Error().Err(err).Msg("Failed to find the primary of the cluster")
+		}
+	}
+	check()
+	m.logger.Info().Strs("members", m.Members).Str("interval", m.Interval.String()).Bool(
+		"watch", m.watch != nil).Msg("Monitoring the primary of the cluster")
+
+	go func() {
+		select {
+		case <-m.stop:
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
+	go func() {
+		for m.wait(ctx) == nil {
+			check()
+		}
+	}()
+}
+
+// wait waits until the watched source changed, or for the interval. It returns an
+// error once the monitor is stopped.
+func (m *ClusterMonitor) wait(ctx context.Context) error {
+	if m.watch != nil {
+		// The watch ends after the interval, so that the status of the members is
+		// refreshed even if the source doesn't change.
+		watchCtx, cancel := context.WithTimeout(ctx, m.Interval)
+		err := m.watch(watchCtx)
+		cancel()
+		if err == nil || (watchCtx.Err() != nil && ctx.Err() == nil) {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err() //nolint:wrapcheck
+		}
+		// The source is unreachable, so it is checked again after the interval.
+		m.logger.Debug().Err(err).Msg("Failed to watch the topology of the cluster")
+	}
+
+	timer := time.NewTimer(m.Interval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	}
+}
+
+// Stop stops checking the members.
+func (m *ClusterMonitor) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+// Target returns the address the clients of the role connect to: the primary, or the
+// standbys in turn, or the primary if there are none. It is empty if no primary was
+// found yet.
+func (m *ClusterMonitor) Target(role config.ClusterRole) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if role == config.Standby && len(m.standbys) > 0 {
+		m.next = (m.next + 1) % len(m.standbys)
+		return m.standbys[m.next]
+	}
+	return m.primary
+}
+
+// Standbys returns the standbys found by the last check.
+func (m *ClusterMonitor) Standbys() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.standbys)
+}
+
+// Alternative returns the next standby other than the address, or an empty string if
+// there is none.
+func (m *ClusterMonitor) Alternative(address string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for range m.standbys {
+		m.next = (m.next + 1) % len(m.standbys)
+		if m.standbys[m.next] != address {
+			return m.standbys[m.next]
+		}
+	}
+	return ""
+}
+
+// IsCurrent returns true if the address is a member of the role, or if no primary was
+// found yet.
+func (m *ClusterMonitor) IsCurrent(role config.ClusterRole, address string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	switch {
+	case m.primary == "":
+		return true
+	case role == config.Standby && len(m.standbys) > 0:
+		return slices.Contains(m.standbys, address)
+	default:
+		return address == m.primary
+	}
+}
+
+// Status returns the last check of the members.
+func (m *ClusterMonitor) Status() []ClusterMember {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.status)
+}
@@ -0,0 +1,115 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCluster is the recovery state of the members of a cluster, where the members
+// that are missing are down.
+type fakeCluster struct {
+	mu         sync.Mutex
+	inRecovery map[string]bool
+}
+
+func (f *fakeCluster) set(inRecovery map[string]bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inRecovery = inRecovery
+}
+
+func (f *fakeCluster) check(_ context.Context, address string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	inRecovery, ok := f.inRecovery[address]
+	if !ok {
+		return false, errors.New("connection refused")
+	}
+	return inRecovery, nil
+}
+
+// TestClusterMonitor tests that the primary is followed after a failover, and that the
+// old primary becomes a standby once it rejoins the cluster.
+func TestClusterMonitor(t *testing.T) {
+	cluster := &fakeCluster{}
+	monitor := NewClusterMonitor(config.PrimaryDiscovery{
+		Members:  []string{"pg-1:5432", "pg-2:5432", "pg-3:5432"},
+		Interval: time.Second,
+	}, time.Second, zerolog.Nop())
+	monitor.check = cluster.check
+	var changes []string
+	monitor.OnPrimaryChanged(func(primary string) {
+		changes = append(changes, primary)
+	})
+
+	// No primary is found while the cluster is down, and all the addresses are current.
+	cluster.set(map[string]bool{})
+	_, _, _, err := monitor.Check(context.Background())
+	assert.Equal(t, gerr.ErrCodePrimaryNotFound, err.Code)
+	assert.Empty(t, monitor.Target(config.Primary))
+	assert.True(t, monitor.IsCurrent(config.Primary, "localhost:5432"))
+
+	cluster.set(map[string]bool{"pg-1:5432": false, "pg-2:5432": true, "pg-3:5432": true})
+	previous, current, changed, err := monitor.Check(context.Background())
+	assert.Nil(t, err)
+	assert.Empty(t, previous)
+	assert.Equal(t, "pg-1:5432", current)
+	assert.False(t, changed)
+	assert.Equal(t, "pg-1:5432", monitor.Target(config.Primary))
+	assert.True(t, monitor.IsCurrent(config.Primary, "pg-1:5432"))
+	assert.False(t, monitor.IsCurrent(config.Primary, "pg-2:5432"))
+	// The reads are spread over the standbys.
+	assert.ElementsMatch(t, []string{"pg-2:5432", "pg-3:5432"},
+		[]string{monitor.Target(config.Standby), monitor.Target(config.Standby)})
+	assert.False(t, monitor.IsCurrent(config.Standby, "pg-1:5432"))
+
+	// The primary fails over to pg-2.
+	cluster.set(map[string]bool{"pg-2:5432": false, "pg-3:5432": true})
+	previous, current, changed, err = monitor.Check(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "pg-1:5432", previous)
+	assert.Equal(t, "pg-2:5432", current)
+	assert.True(t, changed)
+	assert.Equal(t, []string{"pg-2:5432"}, changes)
+	assert.Equal(t, "pg-3:5432", monitor.Target(config.Standby))
+	require.Len(t, monitor.Status(), 3)
+	assert.False(t, monitor.Status()[0].Healthy)
+	assert.Equal(t, "connection refused", monitor.Status()[0].Error)
+
+	// The old primary rejoins the cluster as a standby.
+	cluster.set(map[string]bool{"pg-1:5432": true, "pg-2:5432": false, "pg-3:5432": true})
+	_, _, changed, err = monitor.Check(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	assert.True(t, monitor.IsCurrent(config.Standby, "pg-1:5432"))
+
+	// The primary is kept while the old one doesn't know yet that it was replaced.
+	cluster.set(map[string]bool{"pg-1:5432": false, "pg-2:5432": false})
+	_, current, changed, err = monitor.Check(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "pg-2:5432", current)
+	assert.False(t, changed)
+
+	// The new clients connect to the member of the cluster of their role.
+	proxy := &Proxy{
+		ClientConfig: &config.Client{Address: "localhost:5432"},
+		Cluster:      monitor,
+		ClusterRole:  config.Primary,
+	}
+	assert.Equal(t, "pg-2:5432", proxy.clientConfig().Address)
+	assert.Equal(t, "localhost:5432", proxy.ClientConfig.Address)
+}
+
+func TestClusterKey(t *testing.T) {
+	assert.Equal(t, ClusterKey(config.PrimaryDiscovery{Members: []string{"pg-2:5432", "pg-1:5432"}}),
+		ClusterKey(config.PrimaryDiscovery{Members: []string{"pg-1:5432", "pg-2:5432"}}))
+}
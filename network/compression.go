@@ -0,0 +1,164 @@
+package network
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+)
+
+// CompressionParameter is the startup parameter a client lists the compression
+// algorithms it supports in, separated by commas and in order of preference.
+// PostgreSQL has no standard compression yet, so this is a protocol extension,
+// which is why it is prefixed with "_pq_.".
+const CompressionParameter = "_pq_.compression"
+
+// NegotiateCompression returns the first of the algorithms requested by the client
+// that is supported, or an empty string if none is.
+func NegotiateCompression(
+	requested string, supported []config.CompressionAlgorithm,
+) config.CompressionAlgorithm {
+	for _, algorithm := range strings.Split(requested, ",") {
+		algorithm = strings.ToLower(strings.TrimSpace(algorithm))
+		for _, candidate := range supported {
+			if algorithm == string(candidate) {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+// flushWriter is a compressing writer that can flush the compressed data.
+type flushWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// countingReader counts the bytes read from the connection.
+type countingReader struct {
+	reader io.Reader
+	read   atomic.Int64
+}
+
+func (r *countingReader) Read(data []byte) (int, error) {
+	read, err := r.reader.Read(data)
+	r.read.Add(int64(read))
+	return read, err
+}
+
+// countingWriter counts the bytes written to the connection.
+type countingWriter struct {
+	writer  io.Writer
+	written atomic.Int64
+}
+
+func (w *countingWriter) Write(data []byte) (int, error) {
+	written, err := w.writer.Write(data)
+	w.written.Add(int64(written))
+	return written, err
+}
+
+// compressedConn compresses the traffic of a client connection. The data read from
+// the client is decompressed right away, since the client compresses everything
+// after its startup message. The data written to the client is only compressed once
+// the negotiated algorithm is announced, which the first response does.
+type compressedConn struct {
+	net.Conn
+
+	algorithm config.CompressionAlgorithm
+	reader    *countingReader
+	writer    *countingWriter
+
+	readMu       sync.Mutex
+	decompressor io.ReadCloser
+
+	writeMu    sync.Mutex
+	compressor flushWriter
+	compress   atomic.Bool
+}
+
+var _ net.Conn = (*compressedConn)(nil)
+
+// newCompressedConn wraps the connection to compress its traffic with the algorithm.
+func newCompressedConn(
+	conn net.Conn, algorithm config.CompressionAlgorithm, level int,
+) (*compressedConn, error) {
+	compressed := &compressedConn{
+		Conn:      conn,
+		algorithm: algorithm,
+		reader:    &countingReader{reader: conn},
+		writer:    &countingWriter{writer: conn},
+	}
+
+	var err error
+	switch algorithm {
+	case config.Zlib:
+		compressed.compressor, err = zlib.NewWriterLevel(compressed.writer, level)
+	case config.Gzip:
+		compressed.compressor, err = gzip.NewWriterLevel(compressed.writer, level)
+	default:
+		err = errors.New("unsupported compression algorithm: " + string(algorithm))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return compressed, nil
+}
+
+// Read reads and decompresses the data sent by the client. The decompressor is
+// created on the first read, since it reads the header of the stream.
+func (c *compressedConn) Read(data []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	if c.decompressor == nil {
+		var err error
+		switch c.algorithm {
+		case config.Zlib:
+			c.decompressor, err = zlib.NewReader(c.reader)
+		case config.Gzip:
+			c.decompressor, err = gzip.NewReader(c.reader)
+		}
+		if err != nil {
+			return 0, err //nolint:wrapcheck
+		}
+	}
+
+	compressedBefore := c.reader.read.Load()
+	read, err := c.decompressor.Read(data)
+	metrics.ProxyUncompressedBytes.WithLabelValues(string(Ingress)).Add(float64(read))
+	metrics.ProxyCompressedBytes.WithLabelValues(string(Ingress)).Add(
+		float64(c.reader.read.Load() - compressedBefore))
+	return read, err //nolint:wrapcheck
+}
+
+// Write compresses the data and sends it to the client, flushing it right away, so
+// the client gets the whole response. The data is sent as is until the compression
+// is announced.
+func (c *compressedConn) Write(data []byte) (int, error) {
+	if !c.compress.Load() {
+		return c.Conn.Write(data) //nolint:wrapcheck
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	compressedBefore := c.writer.written.Load()
+	written, err := c.compressor.Write(data)
+	if err == nil {
+		err = c.compressor.Flush()
+	}
+	metrics.ProxyUncompressedBytes.WithLabelValues(string(Egress)).Add(float64(written))
+	metrics.ProxyCompressedBytes.WithLabelValues(string(Egress)).Add(
+		float64(c.writer.written.Load() - compressedBefore))
+	return written, err //nolint:wrapcheck
+}
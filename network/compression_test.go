@@ -0,0 +1,109 @@
+package network
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNegotiateCompression tests that the first requested algorithm that is
+// supported is picked.
+func TestNegotiateCompression(t *testing.T) {
+	supported := []config.CompressionAlgorithm{config.Zlib, config.Gzip}
+	assert.Equal(t, config.Gzip, NegotiateCompression("zstd, GZIP,zlib", supported))
+	assert.Equal(t, config.Zlib, NegotiateCompression("zlib", supported))
+	assert.Equal(t, config.CompressionAlgorithm(""), NegotiateCompression("zstd,lz4", supported))
+	assert.Equal(t, config.CompressionAlgorithm(""), NegotiateCompression("zlib", nil))
+}
+
+// TestConnWrapper_Compression tests that the data from the client is decompressed
+// right away, and the data to the client is only compressed once it is announced.
+func TestConnWrapper_Compression(t *testing.T) {
+	tests := []struct {
+		algorithm config.CompressionAlgorithm
+		newWriter func(io.Writer) io.WriteCloser
+		newReader func(io.Reader) (io.Reader, error)
+	}{
+		{
+			algorithm: config.Zlib,
+			newWriter: func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+			newReader: func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+		},
+		{
+			algorithm: config.Gzip,
+			newWriter: func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+			newReader: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		},
+	}
+	for _, test := range tests {
+		t.Run(string(test.algorithm), func(t *testing.T) {
+			server, client := net.Pipe()
+			defer server.Close()
+			defer client.Close()
+
+			conn := NewConnWrapper(server, nil, time.Second)
+			assert.False(t, conn.IsCompressed())
+			require.Nil(t, conn.EnableCompression(test.algorithm, config.DefaultCompressionLevel))
+			assert.True(t, conn.IsCompressed())
+			assert.Equal(t, test.algorithm, conn.PendingCompression())
+
+			// The client compresses everything after its startup message.
+			query := []byte("Q\x00\x00\x00\x0dselect 1;\x00")
+			go func() {
+				writer := test.newWriter(client)
+				_, _ = writer.Write(query)
+				if flusher, ok := writer.(interface{ Flush() error }); ok {
+					_ = flusher.Flush()
+				}
+			}()
+			received := make([]byte, len(query))
+			_, err := io.ReadFull(conn.Conn(), received)
+			require.NoError(t, err)
+			assert.Equal(t, query, received)
+
+			// The announcement is sent as is.
+			announcement := PostgreSQLParameterStatus(CompressionParameter, string(test.algorithm))
+			go func() {
+				_, _ = conn.Conn().Write(announcement)
+			}()
+			received = make([]byte, len(announcement))
+			_, err = io.ReadFull(client, received)
+			require.NoError(t, err)
+			assert.Equal(t, announcement, received)
+
+			// The responses after the announcement are compressed.
+			conn.CompressWrites()
+			assert.Equal(t, config.CompressionAlgorithm(""), conn.PendingCompression())
+			response := PostgreSQLReadyForQuery()
+			go func() {
+				_, _ = conn.Write(response)
+			}()
+			reader, err := test.newReader(client)
+			require.NoError(t, err)
+			received = make([]byte, len(response))
+			_, err = io.ReadFull(reader, received)
+			require.NoError(t, err)
+			assert.Equal(t, response, received)
+		})
+	}
+}
+
+// TestConnWrapper_Compression_Unsupported tests that an unsupported algorithm or
+// level isn't enabled.
+func TestConnWrapper_Compression_Unsupported(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	conn := NewConnWrapper(server, nil, time.Second)
+	assert.NotNil(t, conn.EnableCompression("zstd", config.DefaultCompressionLevel))
+	assert.NotNil(t, conn.EnableCompression(config.Zlib, 42))
+	assert.False(t, conn.IsCompressed())
+}
@@ -5,8 +5,10 @@ import (
 	"context"
 	"crypto/tls"
 	"net"
+	"sync/atomic"
 	"time"
 
+	"github.com/gatewayd-io/gatewayd/config"
 	gerr "github.com/gatewayd-io/gatewayd/errors"
 )
 
@@ -20,6 +22,7 @@ type UpgraderFunc func(net.Conn)
 
 type IConnWrapper interface {
 	Conn() net.Conn
+	ID() string
 	UpgradeToTLS(upgrader UpgraderFunc) *gerr.GatewayDError
 	Close() error
 	Write(data []byte) (int, error)
@@ -27,26 +30,51 @@ type IConnWrapper interface {
 	RemoteAddr() net.Addr
 	LocalAddr() net.Addr
 	IsTLSEnabled() bool
+	EnableCompression(algorithm config.CompressionAlgorithm, level int) *gerr.GatewayDError
+	IsCompressed() bool
 }
 
 type ConnWrapper struct {
+	id               string
+	listener         string
 	netConn          net.Conn
 	tlsConn          *tls.Conn
 	tlsConfig        *tls.Config
 	isTLSEnabled     bool
 	handshakeTimeout time.Duration
+	compressedConn   atomic.Pointer[compressedConn]
 }
 
 var _ IConnWrapper = (*ConnWrapper)(nil)
 
 // Conn returns the underlying connection.
 func (cw *ConnWrapper) Conn() net.Conn {
+	if compressed := cw.compressedConn.Load(); compressed != nil {
+		return compressed
+	}
 	if cw.tlsConn != nil {
 		return net.Conn(cw.tlsConn)
 	}
 	return cw.netConn
 }
 
+// ID returns the unique ID of the connection, which is used to correlate
+// the logs, hooks and traces of the connection.
+func (cw *ConnWrapper) ID() string {
+	if cw == nil {
+		return ""
+	}
+	return cw.id
+}
+
+// Listener returns the address of the listener that accepted the connection.
+func (cw *ConnWrapper) Listener() string {
+	if cw == nil {
+		return ""
+	}
+	return cw.listener
+}
+
 // UpgradeToTLS upgrades the connection to TLS.
 func (cw *ConnWrapper) UpgradeToTLS(upgrader UpgraderFunc) *gerr.GatewayDError {
 	if cw.tlsConn != nil {
@@ -84,6 +112,9 @@ func (cw *ConnWrapper) Close() error {
 
 // Write writes data to the connection.
 func (cw *ConnWrapper) Write(data []byte) (int, error) {
+	if compressed := cw.compressedConn.Load(); compressed != nil {
+		return compressed.Write(data)
+	}
 	if cw.tlsConn != nil {
 		return cw.tlsConn.Write(data)
 	}
@@ -92,6 +123,9 @@ func (cw *ConnWrapper) Write(data []byte) (int, error) {
 
 // Read reads data from the connection.
 func (cw *ConnWrapper) Read(data []byte) (int, error) {
+	if compressed := cw.compressedConn.Load(); compressed != nil {
+		return compressed.Read(data)
+	}
 	if cw.tlsConn != nil {
 		return cw.tlsConn.Read(data)
 	}
@@ -119,15 +153,56 @@ func (cw *ConnWrapper) IsTLSEnabled() bool {
 	return cw.tlsConn != nil || cw.isTLSEnabled
 }
 
+// EnableCompression compresses the traffic of the connection with the algorithm, on top
+// of TLS if enabled. The data from the client is decompressed right away, but the data
+// to the client is only compressed once CompressWrites is called.
+func (cw *ConnWrapper) EnableCompression(
+	algorithm config.CompressionAlgorithm, level int,
+) *gerr.GatewayDError {
+	if cw.compressedConn.Load() != nil {
+		return nil
+	}
+
+	compressed, err := newCompressedConn(cw.Conn(), algorithm, level)
+	if err != nil {
+		return gerr.ErrCompressionFailed.Wrap(err)
+	}
+	cw.compressedConn.Store(compressed)
+	return nil
+}
+
+// PendingCompression returns the algorithm the data to the client is about to be
+// compressed with, or an empty string if it is already compressed or not at all.
+func (cw *ConnWrapper) PendingCompression() config.CompressionAlgorithm {
+	if compressed := cw.compressedConn.Load(); compressed != nil && !compressed.compress.Load() {
+		return compressed.algorithm
+	}
+	return ""
+}
+
+// CompressWrites starts compressing the data to the client.
+func (cw *ConnWrapper) CompressWrites() {
+	if compressed := cw.compressedConn.Load(); compressed != nil {
+		compressed.compress.Store(true)
+	}
+}
+
+// IsCompressed returns true if the traffic of the connection is compressed.
+func (cw *ConnWrapper) IsCompressed() bool {
+	return cw.compressedConn.Load() != nil
+}
+
 // NewConnWrapper creates a new connection wrapper. The connection
 // wrapper is used to upgrade the connection to TLS if need be.
 func NewConnWrapper(
 	conn net.Conn, tlsConfig *tls.Config, handshakeTimeout time.Duration,
 ) *ConnWrapper {
 	return &ConnWrapper{
-		netConn:          conn,
-		tlsConfig:        tlsConfig,
-		isTLSEnabled:     tlsConfig != nil && tlsConfig.Certificates != nil,
+		id:        NewCorrelationID(),
+		netConn:   conn,
+		tlsConfig: tlsConfig,
+		isTLSEnabled: tlsConfig != nil &&
+			(tlsConfig.Certificates != nil || tlsConfig.GetCertificate != nil),
 		handshakeTimeout: handshakeTimeout,
 	}
 }
@@ -147,3 +222,16 @@ func CreateTLSConfig(certFile, keyFile string) (*tls.Config, error) {
 		PreferServerCipherSuites: true,
 	}, nil
 }
+
+// CreateTLSConfigWithCertificates returns a TLS config that gets the certificate
+// for each connection, e.g. so that it can be rotated or provisioned with ACME.
+func CreateTLSConfigWithCertificates(
+	getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error),
+) *tls.Config {
+	return &tls.Config{
+		MinVersion:               tls.VersionTLS13,
+		GetCertificate:           getCertificate,
+		ClientAuth:               tls.VerifyClientCertIfGiven,
+		PreferServerCipherSuites: true,
+	}
+}
@@ -0,0 +1,231 @@
+package network
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SessionState is what a client connection is doing.
+type SessionState string
+
+const (
+	// Idle connections wait for the next query of the client.
+	Idle SessionState = "idle"
+	// Active connections wait for the response of the database.
+	Active SessionState = "active"
+	// InTransaction connections are idle in a transaction block.
+	InTransaction SessionState = "in-transaction"
+	// Spliced connections are spliced in the kernel, so their traffic isn't seen.
+	Spliced SessionState = "spliced"
+)
+
+// ConnectionInfo describes a client connection of a proxy.
+type ConnectionInfo struct {
+	ID       string       `json:"id"`
+	Remote   string       `json:"remote"`
+	User     string       `json:"user"`
+	Database string       `json:"database"`
+	State    SessionState `json:"state"`
+	Since    time.Time    `json:"since"`
+	BytesIn  uint64       `json:"bytesIn"`
+	BytesOut uint64       `json:"bytesOut"`
+	// Memory is the approximate memory the proxy holds for the connection, in bytes.
+	Memory   int64    `json:"memory"`
+	Settings []string `json:"settings,omitempty"`
+}
+
+// session holds the state and traffic of a client connection.
+type session struct {
+	since    time.Time
+	state    atomic.Value
+	bytesIn  atomic.Uint64
+	bytesOut atomic.Uint64
+	settings sessionSettings
+	// statements are the prepared statements of the session.
+	statements preparedStatements
+
+	// startup and password are the startup and password messages the session
+	// authenticated with, for authenticating a new server connection.
+	mu       sync.Mutex
+	startup  []byte
+	password []byte
+	// terminated is true once the client sent a Terminate message.
+	terminated atomic.Bool
+	// timeout cancels the statement in flight once it exceeds its statement timeout.
+	timeout *time.Timer
+
+	// idleSince is when the session became idle in a transaction, in Unix nanoseconds.
+	idleSince atomic.Int64
+	// status is the transaction status of the last ReadyForQuery message of the session.
+	status atomic.Uint32
+	// exchange is held while the gateway rolls back the idle transaction of the
+	// session, so that the next request of the client waits for it.
+	exchange   sync.Mutex
+	rolledBack bool
+
+	// buffered is the size of the requests and responses the proxy buffers for the
+	// session.
+	buffered atomic.Int64
+	// transaction is the transaction of the session, for the transaction hooks.
+	transaction transaction
+	// result counts the result sets of the session against their limits.
+	result resultLimit
+}
+
+func newSession() *session {
+	s := &session{since: time.Now()}
+	s.state.Store(Idle)
+	return s
+}
+
+// record updates the state, traffic and settings of the session with the data sent
+// in the direction. The state follows the transaction status of the last ReadyForQuery
+// message in the responses.
+func (s *session) record(direction Direction, data []byte) {
+	if direction == Ingress {
+		s.bytesIn.Add(uint64(len(data)))
+		if len(data) > 0 {
+			s.state.Store(Active)
+		}
+		for _, query := range PostgresQueries(data) {
+			s.settings.track(query)
+		}
+		s.statements.track(data)
+		s.transaction.request(data)
+		s.recordAuthentication(data)
+		if len(data) > 0 && data[0] == 'X' {
+			s.terminated.Store(true)
+		}
+		return
+	}
+
+	s.bytesOut.Add(uint64(len(data)))
+	status := PostgresTransactionStatus(data)
+	if status != 0 {
+		s.status.Store(uint32(status))
+	}
+	switch status {
+	case 'I':
+		s.state.Store(Idle)
+		s.stopTimeout()
+	case 'T', 'E':
+		s.idleSince.Store(time.Now().UnixNano())
+		s.state.Store(InTransaction)
+		s.stopTimeout()
+	}
+}
+
+// startRequest marks the session active for the next request of the client, once the
+// gateway rolled back its idle transaction if it is doing so. It returns true if the
+// gateway rolled back the transaction since the last request.
+func (s *session) startRequest() bool {
+	s.exchange.Lock()
+	defer s.exchange.Unlock()
+
+	s.state.Store(Active)
+	rolledBack := s.rolledBack
+	s.rolledBack = false
+	return rolledBack
+}
+
+// idleInTransaction returns how long the session is idle in a transaction, or zero
+// if it isn't.
+func (s *session) idleInTransaction() time.Duration {
+	if s.state.Load() != InTransaction {
+		return 0
+	}
+	return time.Since(time.Unix(0, s.idleSince.Load()))
+}
+
+// startTimeout calls cancel if the statement in flight doesn't end within the
+// timeout, which is once the ReadyForQuery message of its response is recorded.
+func (s *session) startTimeout(timeout time.Duration, cancel func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.timeout != nil {
+		s.timeout.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
+		s.mu.Lock()
+		// The timer of a statement that ended might fire while it is being stopped.
+		expired := s.timeout == timer
+		if expired {
+			s.timeout = nil
+		}
+		s.mu.Unlock()
+
+		if expired {
+			cancel()
+		}
+	})
+	s.timeout = timer
+}
+
+// stopTimeout stops the statement timeout of the statement in flight, if any.
+func (s *session) stopTimeout() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.timeout != nil {
+		s.timeout.Stop()
+		s.timeout = nil
+	}
+}
+
+// recordAuthentication keeps the startup message of the session and the password
+// message that follows it.
+func (s *session) recordAuthentication(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if PostgresStartupParameters(data) != nil {
+		s.startup = data
+	} else if s.startup != nil && s.password == nil && len(data) > 0 && data[0] == 'p' {
+		s.password = data
+	}
+}
+
+// authentication returns the startup and password messages of the session.
+func (s *session) authentication() ([]byte, []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.startup, s.password
+}
+
+// hold accounts the data the proxy buffers for the session, until the returned
+// function releases it.
+func (s *session) hold(size int) func() {
+	s.buffered.Add(int64(size))
+	return func() { s.buffered.Add(-int64(size)) }
+}
+
+// memory returns the approximate memory held for the session: its buffered data,
+// prepared statements, settings and authentication messages.
+func (s *session) memory() int64 {
+	s.mu.Lock()
+	retained := len(s.startup) + len(s.password)
+	s.mu.Unlock()
+	return s.buffered.Load() + int64(retained+s.settings.Size()+s.statements.Size())
+}
+
+func (s *session) info(conn *ConnWrapper, parameters map[string]string) ConnectionInfo {
+	info := ConnectionInfo{
+		ID:       conn.ID(),
+		User:     parameters["user"],
+		Database: parameters["database"],
+		State:    s.state.Load().(SessionState), //nolint:forcetypeassert
+		Since:    s.since,
+		BytesIn:  s.bytesIn.Load(),
+		BytesOut: s.bytesOut.Load(),
+		Memory:   s.memory(),
+		Settings: s.settings.Statements(),
+	}
+	if remote := conn.RemoteAddr(); remote != nil {
+		info.Remote = remote.String()
+	}
+	return info
+}
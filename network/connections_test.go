@@ -0,0 +1,73 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProxyConnections tests that the connections are listed with their state and
+// traffic, and that they can be killed.
+func TestProxyConnections(t *testing.T) {
+	logger := zerolog.Nop()
+	proxy := NewProxy(
+		context.Background(),
+		pool.NewPool(context.Background(), 1),
+		plugin.NewRegistry(
+			context.Background(),
+			config.Loose,
+			config.PassDown,
+			config.Accept,
+			config.Stop,
+			logger,
+			false,
+		),
+		false,
+		false,
+		config.DefaultHealthCheckPeriod,
+		nil,
+		logger,
+		config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	conn := NewConnWrapper(serverConn, nil, 0)
+	proxy.sessions.Store(conn, newSession())
+	proxy.parameters.Store(conn, map[string]string{"user": "postgres", "database": "app"})
+
+	connections := proxy.Connections()
+	require.Len(t, connections, 1)
+	assert.Equal(t, conn.ID(), connections[0].ID)
+	assert.Equal(t, "postgres", connections[0].User)
+	assert.Equal(t, "app", connections[0].Database)
+	assert.Equal(t, Idle, connections[0].State)
+
+	// The state follows the queries and the transaction status of the responses.
+	query := CreatePostgreSQLPacket('Q', []byte("BEGIN\x00"))
+	proxy.recordUsage(conn, Ingress, query)
+	assert.Equal(t, Active, proxy.Connections()[0].State)
+	response := append(
+		CreatePostgreSQLPacket('C', []byte("BEGIN\x00")),
+		CreatePostgreSQLPacket('Z', []byte{'T'})...)
+	proxy.recordUsage(conn, Egress, response)
+	connections = proxy.Connections()
+	assert.Equal(t, InTransaction, connections[0].State)
+	assert.Equal(t, uint64(len(query)), connections[0].BytesIn)
+	assert.Equal(t, uint64(len(response)), connections[0].BytesOut)
+	proxy.recordUsage(conn, Egress, CreatePostgreSQLPacket('Z', []byte{'I'}))
+	assert.Equal(t, Idle, proxy.Connections()[0].State)
+
+	// Killing the connection closes it.
+	assert.False(t, proxy.Kill("unknown"))
+	assert.True(t, proxy.Kill(conn.ID()))
+	_, err := clientConn.Read(make([]byte, 1))
+	assert.Error(t, err)
+}
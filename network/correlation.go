@@ -0,0 +1,106 @@
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Correlation holds the identifiers that tie together the logs, hook payloads,
+// metric exemplars and trace spans of a single client connection and each of
+// the queries sent over it.
+type Correlation struct {
+	ConnectionID string
+	QueryID      string
+}
+
+// NewCorrelationID returns a new random identifier for a connection or a query.
+func NewCorrelationID() string {
+	return uuid.NewString()
+}
+
+// Logger returns a child logger that adds the correlation IDs to every log line.
+func (c Correlation) Logger(logger zerolog.Logger) zerolog.Logger {
+	ctx := logger.With()
+	if c.ConnectionID != "" {
+		ctx = ctx.Str("connectionId", c.ConnectionID)
+	}
+	if c.QueryID != "" {
+		ctx = ctx.Str("queryId", c.QueryID)
+	}
+	return ctx.Logger()
+}
+
+// Attributes returns the correlation IDs as trace span attributes.
+func (c Correlation) Attributes() []attribute.KeyValue {
+	attributes := make([]attribute.KeyValue, 0, 2) //nolint:gomnd
+	if c.ConnectionID != "" {
+		attributes = append(attributes, attribute.String("connectionId", c.ConnectionID))
+	}
+	if c.QueryID != "" {
+		attributes = append(attributes, attribute.String("queryId", c.QueryID))
+	}
+	return attributes
+}
+
+// Labels returns the correlation IDs as metric exemplar labels.
+func (c Correlation) Labels() prometheus.Labels {
+	labels := prometheus.Labels{}
+	if c.ConnectionID != "" {
+		labels["connection_id"] = c.ConnectionID
+	}
+	if c.QueryID != "" {
+		labels["query_id"] = c.QueryID
+	}
+	return labels
+}
+
+// Fields returns the correlation IDs as hook payload fields.
+func (c Correlation) Fields() map[string]interface{} {
+	fields := map[string]interface{}{}
+	if c.ConnectionID != "" {
+		fields["connectionId"] = c.ConnectionID
+	}
+	if c.QueryID != "" {
+		fields["queryId"] = c.QueryID
+	}
+	return fields
+}
+
+// SQLComment returns the correlation IDs formatted as a SQL comment, following the
+// sqlcommenter key='value' convention, so that they show up in the database logs.
+func (c Correlation) SQLComment() string {
+	return fmt.Sprintf(
+		"/*gatewayd_connection_id='%s',gatewayd_query_id='%s'*/", c.ConnectionID, c.QueryID)
+}
+
+// InjectSQLComment prepends the given comment to the query of a PostgreSQL simple
+// query message. Any other message, or a buffer containing more than one message,
+// is returned as is.
+//
+//nolint:gomnd
+func InjectSQLComment(request []byte, comment string) []byte {
+	// Simple query message: 'Q' + int32 length + null-terminated query string.
+	if len(request) < 6 || request[0] != 'Q' {
+		return request
+	}
+
+	length := int(binary.BigEndian.Uint32(request[1:5]))
+	if length != len(request)-1 {
+		return request
+	}
+
+	query := request[5:]
+	injected := make([]byte, 0, len(request)+len(comment)+1)
+	injected = append(injected, 'Q', 0, 0, 0, 0)
+	injected = append(injected, comment...)
+	injected = append(injected, ' ')
+	injected = append(injected, query...)
+	binary.BigEndian.PutUint32(injected[1:5], uint32(len(injected)-1))
+
+	return injected
+}
@@ -0,0 +1,86 @@
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCorrelation tests the different representations of the correlation IDs.
+func TestCorrelation(t *testing.T) {
+	correlation := Correlation{ConnectionID: "conn", QueryID: "query"}
+
+	assert.Equal(t,
+		map[string]interface{}{"connectionId": "conn", "queryId": "query"},
+		correlation.Fields())
+	assert.Equal(t, "conn", correlation.Labels()["connection_id"])
+	assert.Equal(t, "query", correlation.Labels()["query_id"])
+	assert.Len(t, correlation.Attributes(), 2)
+	assert.Equal(t,
+		"/*gatewayd_connection_id='conn',gatewayd_query_id='query'*/",
+		correlation.SQLComment())
+
+	// Empty IDs are omitted.
+	assert.Empty(t, Correlation{}.Fields())
+	assert.Empty(t, Correlation{}.Labels())
+	assert.Empty(t, Correlation{}.Attributes())
+
+	assert.NotEqual(t, NewCorrelationID(), NewCorrelationID())
+}
+
+// TestInjectSQLComment tests that the comment is only injected into simple query messages.
+func TestInjectSQLComment(t *testing.T) {
+	query := CreatePostgreSQLPacket('Q', []byte("SELECT 1\x00"))
+	injected := InjectSQLComment(query, "/*c*/")
+
+	assert.Equal(t, byte('Q'), injected[0])
+	assert.Equal(t, uint32(len(injected)-1), binary.BigEndian.Uint32(injected[1:5]))
+	assert.Equal(t, "/*c*/ SELECT 1\x00", string(injected[5:]))
+
+	// Other messages are left untouched.
+	startup := CreatePgStartupPacket()
+	assert.Equal(t, startup, InjectSQLComment(startup, "/*c*/"))
+	terminate := CreatePgTerminatePacket()
+	assert.Equal(t, terminate, InjectSQLComment(terminate, "/*c*/"))
+
+	// Multiple messages in a single buffer are left untouched.
+	pipelined := append(query, query...) //nolint:gocritic
+	assert.Equal(t, pipelined, InjectSQLComment(pipelined, "/*c*/"))
+}
+
+// TestQueryLatencyExemplar tests that the query latency is observed with the
+// correlation IDs as its exemplar.
+func TestQueryLatencyExemplar(t *testing.T) {
+	ctx := context.Background()
+	pluginRegistry := plugin.NewRegistry(
+		ctx, config.Loose, config.PassDown, config.Accept, config.Stop, zerolog.Nop(), false)
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, 1), pluginRegistry, false, false,
+		config.DefaultHealthCheckPeriod, &config.Client{}, zerolog.Nop(), config.DefaultPluginTimeout)
+
+	correlation := Correlation{ConnectionID: NewCorrelationID(), QueryID: NewCorrelationID()}
+	proxy.observeQueryLatency(&ConnWrapper{}, 42*time.Millisecond, correlation)
+
+	var metric dto.Metric
+	require.NoError(t, metrics.ProxyQueryLatency.Write(&metric))
+	labels := map[string]string{}
+	for _, bucket := range metric.GetHistogram().GetBucket() {
+		if exemplar := bucket.GetExemplar(); exemplar != nil {
+			for _, label := range exemplar.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+		}
+	}
+	assert.Equal(t, correlation.ConnectionID, labels["connection_id"])
+	assert.Equal(t, correlation.QueryID, labels["query_id"])
+}
@@ -0,0 +1,421 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+)
+
+// dataAPIOperators are the operators of the filters of the data API, and their SQL.
+var dataAPIOperators = map[string]string{
+	"eq":    "=",
+	"neq":   "<>",
+	"gt":    ">",
+	"gte":   ">=",
+	"lt":    "<",
+	"lte":   "<=",
+	"like":  "LIKE",
+	"ilike": "ILIKE",
+}
+
+// dataAPIIsValues are the values of the "is" operator, which aren't parameters.
+var dataAPIIsValues = map[string]string{
+	"null":    "NULL",
+	"true":    "TRUE",
+	"false":   "FALSE",
+	"unknown": "UNKNOWN",
+}
+
+// DataQuery is a parameterized query of the data API, whose arguments are in the text
+// format, so that their types are inferred by the database.
+type DataQuery struct {
+	SQL  string
+	Args [][]byte
+}
+
+// DataAPI serves the tables of the allowlist over HTTP as JSON, for the clients without
+// a SQL driver. The requests are translated to parameterized queries, which run through
+// the listener of the server, so that its hooks, proxy and pool apply to them.
+type DataAPI struct {
+	Address string
+	Tables  []string
+	MaxRows int
+	Timeout time.Duration
+
+	// tokens are the SHA256 digests of the bearer tokens of the clients, which are
+	// compared in constant time.
+	tokens [][sha256.Size]byte
+	// server is the address of the listener of the server the queries run through.
+	server     string
+	logger     zerolog.Logger
+	httpServer *http.Server
+	// idle are the open connections to the server, which are reused by the requests.
+	idle    chan *pgconn.PgConn
+	connect func(ctx context.Context, address string) (*pgconn.PgConn, error)
+}
+
+// NewDataAPI creates the data API of the config, which runs the queries through the
+// address of the listener of the server, or returns nil if it is disabled.
+func NewDataAPI(
+	cfg config.DataAPI, server string, logger zerolog.Logger,
+) (*DataAPI, *gerr.GatewayDError) {
+	if !cfg.Enabled {
+		return nil, nil //nolint:nilnil
+	}
+	if len(cfg.Tables) == 0 {
+		return nil, gerr.ErrValidationFailed.Wrap(
+			errors.New("the data API serves no tables, add them to its tables"))
+	}
+	// The queries run with the credentials of the data API, so its clients must be
+	// authenticated.
+	if len(cfg.Tokens) == 0 || slices.Contains(cfg.Tokens, "") {
+		return nil, gerr.ErrValidationFailed.Wrap(
+			errors.New("the data API has no tokens or an empty one, add them to its tokens"))
+	}
+
+	database := config.If[string](cfg.Database != "", cfg.Database, config.DefaultDataAPIDatabase)
+	timeout := config.If[time.Duration](cfg.Timeout > 0, cfg.Timeout, config.DefaultDataAPITimeout)
+	dataAPI := &DataAPI{
+		Address: config.If[string](cfg.Address != "", cfg.Address, config.DefaultDataAPIAddress),
+		Tables:  cfg.Tables,
+		MaxRows: config.If[int](cfg.MaxRows > 0, cfg.MaxRows, config.DefaultDataAPIMaxRows),
+		Timeout: timeout,
+		tokens:  make([][sha256.Size]byte, 0, len(cfg.Tokens)),
+		server:  serverAddress(server),
+		logger:  logger,
+		idle:    make(chan *pgconn.PgConn, config.DefaultDataAPIIdleConns),
+		connect: func(ctx context.Context, address string) (*pgconn.PgConn, error) {
+			return ConnectPostgres(ctx, address, cfg.User, cfg.Password, database,
+				config.DefaultDataAPIApplicationName, timeout)
+		},
+	}
+	for _, token := range cfg.Tokens {
+		dataAPI.tokens = append(dataAPI.tokens, sha256.Sum256([]byte(token)))
+	}
+	dataAPI.httpServer = &http.Server{
+		Addr:              dataAPI.Address,
+		Handler:           dataAPI,
+		ReadHeaderTimeout: timeout,
+	}
+	return dataAPI, nil
+}
+
+// quoteIdentifier quotes the identifier, which is either a name or a schema and a name.
+func quoteIdentifier(identifier string) string {
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+// BuildDataQuery translates the parameters of a request of the table to a query, e.g.
+// ?select=id,name&age=gt.30&order=name.desc&limit=10. The filters are sorted by column,
+// so that the same requests run the same queries, and the rows are limited to maxRows.
+//
+//nolint:funlen
+func BuildDataQuery(table string, params url.Values, maxRows int) (DataQuery, error) {
+	query := DataQuery{}
+	arg := func(value string) string {
+		query.Args = append(query.Args, []byte(value))
+		return "$" + strconv.Itoa(len(query.Args))
+	}
+
+	columns := "*"
+	if value := params.Get("select"); value != "" && value != "*" {
+		selected := strings.Split(value, ",")
+		for i, column := range selected {
+			selected[i] = quoteIdentifier(strings.TrimSpace(column))
+		}
+		columns = strings.Join(selected, ", ")
+	}
+
+	var filters []string
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch name {
+		case "select", "order", "limit", "offset":
+			continue
+		}
+		for _, value := range params[name] {
+			operator, operand, found := strings.Cut(value, ".")
+			if !found {
+				return DataQuery{}, fmt.Errorf("invalid filter %s=%s, it must be operator.value", name, value)
+			}
+			column := quoteIdentifier(name)
+			switch operator {
+			case "is":
+				literal, ok := dataAPIIsValues[strings.ToLower(operand)]
+				if !ok {
+					return DataQuery{}, fmt.Errorf("invalid filter %s=%s, is must be null, true or false", name, value)
+				}
+				filters = append(filters, column+" IS "+literal)
+			case "in":
+				if !strings.HasPrefix(operand, "(") || !strings.HasSuffix(operand, ")") {
+					return DataQuery{}, fmt.Errorf("invalid filter %s=%s, in must be a list, e.g. (1,2)", name, value)
+				}
+				var values []string
+				for _, item := range strings.Split(operand[1:len(operand)-1], ",") {
+					values = append(values, arg(item))
+				}
+				filters = append(filters, column+" IN ("+strings.Join(values, ", ")+")")
+			default:
+				sqlOperator, ok := dataAPIOperators[operator]
+				if !ok {
+					return DataQuery{}, fmt.Errorf("invalid filter %s=%s, unknown operator %s", name, value, operator)
+				}
+				if operator == "like" || operator == "ilike" {
+					// The wildcard is * in the URLs, as % is the escape character.
+					operand = strings.ReplaceAll(operand, "*", "%")
+				}
+				filters = append(filters, column+" "+sqlOperator+" "+arg(operand))
+			}
+		}
+	}
+
+	var order []string
+	if value := params.Get("order"); value != "" {
+		for _, term := range strings.Split(value, ",") {
+			parts := strings.Split(term, ".")
+			clause := quoteIdentifier(parts[0])
+			for _, modifier := range parts[1:] {
+				switch modifier {
+				case "asc":
+					clause += " ASC"
+				case "desc":
+					clause += " DESC"
+				case "nullsfirst":
+					clause += " NULLS FIRST"
+				case "nullslast":
+					clause += " NULLS LAST"
+				default:
+					return DataQuery{}, fmt.Errorf("invalid order %s, unknown modifier %s", term, modifier)
+				}
+			}
+			order = append(order, clause)
+		}
+	}
+
+	limit := maxRows
+	if value := params.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return DataQuery{}, fmt.Errorf("invalid limit %s", value)
+		}
+		limit = min(parsed, maxRows)
+	}
+	offset := 0
+	if value := params.Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return DataQuery{}, fmt.Errorf("invalid offset %s", value)
+		}
+		offset = parsed
+	}
+
+	var sql strings.Builder
+	sql.WriteString("SELECT " + columns + " FROM " + quoteIdentifier(table))
+	if len(filters) > 0 {
+		sql.WriteString(" WHERE " + strings.Join(filters, " AND "))
+	}
+	if len(order) > 0 {
+		sql.WriteString(" ORDER BY " + strings.Join(order, ", "))
+	}
+	sql.WriteString(" LIMIT " + strconv.Itoa(limit))
+	if offset > 0 {
+		sql.WriteString(" OFFSET " + strconv.Itoa(offset))
+	}
+	query.SQL = sql.String()
+	return query, nil
+}
+
+// decodeDataValue returns the JSON value of a column in the text format, which is a
+// string unless the column is a boolean, a number or JSON.
+func decodeDataValue(oid uint32, value []byte) interface{} {
+	if value == nil {
+		return nil
+	}
+	switch oid {
+	case pgtype.BoolOID:
+		return string(value) == "t"
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID, pgtype.Float4OID,
+		pgtype.Float8OID, pgtype.NumericOID:
+		// NaN and Infinity aren't JSON numbers.
+		if number, err := strconv.ParseFloat(string(value), 64); err == nil &&
+			!math.IsNaN(number) && !math.IsInf(number, 0) {
+			return json.Number(value)
+		}
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		// The value is copied, as the buffer of the rows is reused while they are read.
+		return json.RawMessage(bytes.Clone(value))
+	}
+	return string(value)
+}
+
+// acquire returns an idle connection to the server, or opens one.
+func (d *DataAPI) acquire(ctx context.Context) (*pgconn.PgConn, error) {
+	for {
+		select {
+		case conn := <-d.idle:
+			if !conn.IsClosed() {
+				return conn, nil
+			}
+		default:
+			return d.connect(ctx, d.server)
+		}
+	}
+}
+
+// release keeps the connection for the next requests, or closes it if it is broken or
+// there are enough idle connections.
+func (d *DataAPI) release(conn *pgconn.PgConn) {
+	if !conn.IsClosed() && !conn.IsBusy() {
+		select {
+		case d.idle <- conn:
+			return
+		default:
+		}
+	}
+	conn.Close(context.Background()) //nolint:errcheck
+}
+
+// Query runs the query through the server, and returns its rows by column name.
+func (d *DataAPI) Query(ctx context.Context, query DataQuery) ([]map[string]interface{}, error) {
+	conn, err := d.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer d.release(conn)
+
+	result := conn.ExecParams(ctx, query.SQL, query.Args, nil, nil, nil).Read()
+	if result.Err != nil {
+		return nil, result.Err //nolint:wrapcheck
+	}
+
+	rows := make([]map[string]interface{}, 0, len(result.Rows))
+	for _, values := range result.Rows {
+		row := make(map[string]interface{}, len(values))
+		for i, field := range result.FieldDescriptions {
+			row[field.Name] = decodeDataValue(field.DataTypeOID, values[i])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// authenticated returns true if the request has the bearer token of a client.
+func (d *DataAPI) authenticated(request *http.Request) bool {
+	token, ok := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	digest := sha256.Sum256([]byte(token))
+	authenticated := false
+	// All the tokens are compared, so that the time doesn't tell which one matched.
+	for _, known := range d.tokens {
+		if subtle.ConstantTimeCompare(digest[:], known[:]) == 1 {
+			authenticated = true
+		}
+	}
+	return authenticated
+}
+
+// ServeHTTP serves the rows of the table of the path on GET, e.g. GET /users?id=eq.1.
+func (d *DataAPI) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	table := strings.Trim(request.URL.Path, "/")
+	writeJSON := func(status int, response interface{}) {
+		metrics.DataAPIRequests.WithLabelValues(table, strconv.Itoa(status)).Inc()
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(status)
+		if err := json.NewEncoder(writer).Encode(response); err != nil {
+			d.logger.Err(err).Msg("Failed to serve the data API request")
+		}
+	}
+	writeError := func(status int, err error) {
+		writeJSON(status, map[string]string{"error": err.Error()})
+	}
+
+	if !d.authenticated(request) {
+		// The paths of the unauthenticated requests aren't counted, as they are arbitrary.
+		table = ""
+		writer.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(http.StatusUnauthorized, errors.New("unauthenticated"))
+		return
+	}
+	if !slices.Contains(d.Tables, table) {
+		// The paths of the tables that aren't served aren't counted, as they are arbitrary.
+		table = ""
+		writeError(http.StatusNotFound, errors.New("table not found"))
+		return
+	}
+	if request.Method != http.MethodGet {
+		writeError(http.StatusMethodNotAllowed, errors.New("only GET is allowed"))
+		return
+	}
+
+	query, err := BuildDataQuery(table, request.URL.Query(), d.MaxRows)
+	if err != nil {
+		writeError(http.StatusBadRequest, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(request.Context(), d.Timeout)
+	defer cancel()
+	rows, err := d.Query(ctx, query)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			// The errors of the database are the client's, e.g. an unknown column.
+			writeError(http.StatusBadRequest, err)
+			return
+		}
+		d.logger.Error().Err(err).Str("table", table).Msg("Failed to run the data API query")
+		writeError(http.StatusBadGateway, errors.New("failed to run the query"))
+		return
+	}
+	writeJSON(http.StatusOK, rows)
+}
+
+// Start serves the data API until it is shut down.
+func (d *DataAPI) Start() {
+	d.logger.Info().Str("address", d.Address).Str("server", d.server).Msg("Started the data API")
+	if err := d.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		d.logger.Error().Err(err).Msg("Failed to start the data API")
+	}
+}
+
+// Shutdown stops serving the data API, and closes its connections to the server.
+func (d *DataAPI) Shutdown(ctx context.Context) error {
+	err := d.httpServer.Shutdown(ctx)
+	for {
+		select {
+		case conn := <-d.idle:
+			conn.Close(ctx) //nolint:errcheck
+		default:
+			return err //nolint:wrapcheck
+		}
+	}
+}
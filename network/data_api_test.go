@@ -0,0 +1,100 @@
+package network
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildDataQuery tests that the requests are translated to parameterized queries.
+func TestBuildDataQuery(t *testing.T) {
+	params, err := url.ParseQuery(
+		"select=id,name&name=like.J*&age=gt.30&id=in.(1,2)&deleted=is.null&order=name.desc,id&limit=5000&offset=10")
+	require.NoError(t, err)
+	query, err := BuildDataQuery("public.users", params, 100)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`SELECT "id", "name" FROM "public"."users" WHERE "age" > $1 AND "deleted" IS NULL AND `+
+			`"id" IN ($2, $3) AND "name" LIKE $4 ORDER BY "name" DESC, "id" LIMIT 100 OFFSET 10`,
+		query.SQL)
+	assert.Equal(t, [][]byte{[]byte("30"), []byte("1"), []byte("2"), []byte("J%")}, query.Args)
+
+	// The identifiers are quoted, so they can't inject SQL.
+	query, err = BuildDataQuery("users", url.Values{`a"; DROP TABLE users; --`: {"eq.1"}}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM "users" WHERE "a""; DROP TABLE users; --" = $1 LIMIT 10`, query.SQL)
+
+	for _, invalid := range []string{"id=1", "id=foo.1", "id=is.maybe", "id=in.1,2", "order=id.up", "limit=-1"} {
+		params, err := url.ParseQuery(invalid)
+		require.NoError(t, err)
+		_, err = BuildDataQuery("users", params, 10)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// TestDecodeDataValue tests that the columns are decoded to their JSON values.
+func TestDecodeDataValue(t *testing.T) {
+	assert.Nil(t, decodeDataValue(pgtype.TextOID, nil))
+	assert.Equal(t, true, decodeDataValue(pgtype.BoolOID, []byte("t")))
+	assert.Equal(t, json.Number("42"), decodeDataValue(pgtype.Int4OID, []byte("42")))
+	assert.Equal(t, "NaN", decodeDataValue(pgtype.NumericOID, []byte("NaN")))
+	assert.Equal(t, json.RawMessage(`{"a":1}`), decodeDataValue(pgtype.JSONBOID, []byte(`{"a":1}`)))
+	assert.Equal(t, "2024-01-01", decodeDataValue(pgtype.DateOID, []byte("2024-01-01")))
+}
+
+// TestDataAPI tests that only the authenticated clients and the tables of the allowlist
+// are served, and that the invalid requests are rejected before they reach the database.
+func TestDataAPI(t *testing.T) {
+	request := func(method, path, authorization string) *http.Request {
+		request := httptest.NewRequest(method, path, nil)
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		return request
+	}
+
+	_, err := NewDataAPI(config.DataAPI{Enabled: true}, "127.0.0.1:15432", zerolog.Nop())
+	require.Error(t, err)
+
+	_, err = NewDataAPI(
+		config.DataAPI{Enabled: true, Tables: []string{"users"}}, "127.0.0.1:15432", zerolog.Nop())
+	require.Error(t, err)
+
+	dataAPI, err := NewDataAPI(
+		config.DataAPI{Enabled: true, Tables: []string{"users"}, Tokens: []string{"secret"}},
+		"0.0.0.0:15432", zerolog.Nop())
+	require.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:15432", dataAPI.server)
+	assert.Equal(t, config.DefaultDataAPIMaxRows, dataAPI.MaxRows)
+
+	for path, status := range map[string]int{
+		"/orders":        http.StatusNotFound,
+		"/users?id=1":    http.StatusBadRequest,
+		"/users?limit=x": http.StatusBadRequest,
+	} {
+		recorder := httptest.NewRecorder()
+		dataAPI.ServeHTTP(recorder, request(http.MethodGet, path, "Bearer secret"))
+		assert.Equal(t, status, recorder.Code, path)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	}
+
+	recorder := httptest.NewRecorder()
+	dataAPI.ServeHTTP(recorder, request(http.MethodPost, "/users", "Bearer secret"))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+
+	// The requests without a token of a client are rejected.
+	for _, authorization := range []string{"", "Bearer", "Bearer wrong", "Basic secret"} {
+		recorder := httptest.NewRecorder()
+		dataAPI.ServeHTTP(recorder, request(http.MethodGet, "/users?id=eq.1", authorization))
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code, authorization)
+		assert.Equal(t, "Bearer", recorder.Header().Get("WWW-Authenticate"))
+	}
+}
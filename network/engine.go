@@ -2,6 +2,7 @@ package network
 
 import (
 	"context"
+	"errors"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -19,14 +20,16 @@ type IEngine interface {
 // Engine is the network engine.
 // TODO: Move this to the Server struct.
 type Engine struct {
-	listener    net.Listener
+	listeners   []net.Listener
 	host        string
 	port        int
 	connections uint32
 	logger      zerolog.Logger
 	running     *atomic.Bool
 	stopServer  chan struct{}
-	mu          *sync.RWMutex
+	// listening is closed once the server listens on all its listeners.
+	listening chan struct{}
+	mu        *sync.RWMutex
 }
 
 var _ IEngine = (*Engine)(nil)
@@ -45,13 +48,17 @@ func (engine *Engine) Stop(ctx context.Context) error {
 
 	var err error
 	engine.running.Store(false)
-	if engine.listener != nil {
-		if err = engine.listener.Close(); err != nil {
-			engine.logger.Error().Err(err).Msg("Failed to close listener")
-		}
-	} else {
+	if len(engine.listeners) == 0 {
 		engine.logger.Error().Msg("Listener is not initialized")
 	}
+	for _, listener := range engine.listeners {
+		// The listeners are also closed when the server stops running.
+		if closeErr := listener.Close(); closeErr != nil && !errors.Is(closeErr, net.ErrClosed) {
+			engine.logger.Error().Err(closeErr).Str(
+				"listener", listener.Addr().String()).Msg("Failed to close listener")
+			err = closeErr
+		}
+	}
 
 	select {
 	case <-engine.stopServer:
@@ -71,6 +78,7 @@ func NewEngine(logger zerolog.Logger) Engine {
 		logger:      logger,
 		running:     &atomic.Bool{},
 		stopServer:  make(chan struct{}),
+		listening:   make(chan struct{}),
 		mu:          &sync.RWMutex{},
 	}
 }
@@ -0,0 +1,98 @@
+package network
+
+import (
+	"github.com/gatewayd-io/gatewayd/config"
+)
+
+// SQLStateConnectionFailure is the SQLSTATE of the errors that break the connection
+// to the database.
+const SQLStateConnectionFailure = "08006"
+
+// errorResponse is the severity, SQLSTATE code and message of an error of GatewayD.
+type errorResponse struct {
+	severity string
+	code     string
+	message  string
+}
+
+// defaultErrorResponses are sent to the clients for the errors of GatewayD, unless
+// their codes or messages are configured. The errors that close the connection are
+// FATAL, and the ones that only fail the query are ERROR, as in PostgreSQL.
+var defaultErrorResponses = map[config.ErrorKind]errorResponse{
+	config.ErrorPoolExhausted: {
+		"FATAL", SQLStateTooManyConnections, "sorry, too many clients already",
+	},
+	config.ErrorQueueFull: {
+		"FATAL", SQLStateTooManyConnections, "sorry, too many clients already",
+	},
+	config.ErrorQueueTimeout: {
+		"FATAL", SQLStateTooManyConnections, "timed out waiting for an available connection",
+	},
+	config.ErrorShuttingDown: {
+		"FATAL", SQLStateCannotConnectNow, "GatewayD is shutting down",
+	},
+	config.ErrorMaintenance: {
+		"FATAL", SQLStateCannotConnectNow, "GatewayD is under maintenance",
+	},
+	config.ErrorBackendDown: {
+		"FATAL", SQLStateConnectionFailure, "the connection to the database was lost",
+	},
+	config.ErrorQueryDenied: {
+		"ERROR", SQLStateInsufficientPrivilege, "permission denied",
+	},
+	config.ErrorConcurrencyLimit: {
+		"ERROR", SQLStateInsufficientResources, "too many queries in flight, please retry later",
+	},
+	config.ErrorMessageTooLarge: {
+		"FATAL", SQLStateProgramLimitExceeded, "message is larger than the maximum message size",
+	},
+	config.ErrorCrossShard: {
+		"ERROR", SQLStateFeatureNotSupported, "the statement spans more than one shard",
+	},
+	config.ErrorShardUnavailable: {
+		"ERROR", SQLStateConnectionFailure, "no connection to the shard is available",
+	},
+	config.ErrorConnectionQuota: {
+		"FATAL", SQLStateTooManyConnections, "too many connections to the proxy",
+	},
+	config.ErrorIdleTransactionTerminated: {
+		"FATAL", SQLStateIdleInTransactionTimeout,
+		"terminating connection due to idle-in-transaction timeout",
+	},
+	config.ErrorIdleTransactionRolledBack: {
+		"ERROR", SQLStateIdleInTransactionTimeout,
+		"the transaction was rolled back due to idle-in-transaction timeout",
+	},
+	config.ErrorConnectionMemory: {
+		"ERROR", SQLStateOutOfMemory, "the connection exceeded its memory limit",
+	},
+	config.ErrorGoroutineLimit: {
+		"FATAL", SQLStateTooManyConnections, "sorry, too many clients already",
+	},
+	config.ErrorResultTooLarge: {
+		"ERROR", SQLStateProgramLimitExceeded, "the result exceeds its maximum size",
+	},
+	config.ErrorResponseTruncated: {
+		"ERROR", SQLStateProgramLimitExceeded,
+		"the response was truncated to the maximum message size",
+	},
+}
+
+// ErrorResponse returns the PostgreSQL ErrorResponse message of the error, with the
+// configured code and message, if any. The message, e.g. of a firewall rule, takes
+// precedence over the configured one if it isn't empty.
+func ErrorResponse(
+	messages map[config.ErrorKind]config.ErrorMessage, kind config.ErrorKind, message string,
+) []byte {
+	response := defaultErrorResponses[kind]
+	if configured, ok := messages[kind]; ok {
+		response.code = config.If[string](configured.Code != "", configured.Code, response.code)
+		response.message = config.If[string](
+			configured.Message != "", configured.Message, response.message)
+	}
+	if message != "" {
+		response.message = message
+	}
+
+	return PostgreSQLErrorResponse(response.severity, response.code, response.message)
+}
@@ -0,0 +1,31 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestErrorResponse tests that the errors are sent with their default or configured
+// codes and messages.
+func TestErrorResponse(t *testing.T) {
+	assert.Equal(t,
+		PostgreSQLErrorResponse("FATAL", SQLStateTooManyConnections, "sorry, too many clients already"),
+		ErrorResponse(nil, config.ErrorPoolExhausted, ""))
+
+	messages := map[config.ErrorKind]config.ErrorMessage{
+		config.ErrorPoolExhausted: {Message: "try the replica"},
+		config.ErrorQueryDenied:   {Code: "P0001", Message: "not allowed"},
+	}
+	assert.Equal(t,
+		PostgreSQLErrorResponse("FATAL", SQLStateTooManyConnections, "try the replica"),
+		ErrorResponse(messages, config.ErrorPoolExhausted, ""))
+	assert.Equal(t,
+		PostgreSQLErrorResponse("ERROR", "P0001", "not allowed"),
+		ErrorResponse(messages, config.ErrorQueryDenied, ""))
+	// The message of the firewall rule takes precedence.
+	assert.Equal(t,
+		PostgreSQLErrorResponse("ERROR", "P0001", "no DDL on prod"),
+		ErrorResponse(messages, config.ErrorQueryDenied, "no DDL on prod"))
+}
@@ -0,0 +1,121 @@
+package network
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+)
+
+// ErrorStat is the number of the error responses with a SQLSTATE code the database
+// sent to the clients of a user and database.
+type ErrorStat struct {
+	Database  string    `json:"database"`
+	User      string    `json:"user"`
+	Code      string    `json:"code"`
+	Count     uint64    `json:"count"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+type errorKey struct {
+	database, user, code string
+}
+
+// ErrorStats counts the error responses of the databases of the proxies by database,
+// user and SQLSTATE code, up to a number of distinct ones, after which the least seen
+// one is evicted for a new one.
+type ErrorStats struct {
+	mu     sync.Mutex
+	limit  int
+	errors map[errorKey]*ErrorStat
+}
+
+// NewErrorStats creates a counter of up to limit distinct errors.
+func NewErrorStats(limit int) *ErrorStats {
+	return &ErrorStats{limit: limit, errors: map[errorKey]*ErrorStat{}}
+}
+
+// Record counts an error response with the code to the user and database.
+func (e *ErrorStats) Record(database, user, code string) {
+	now := time.Now()
+	key := errorKey{database: database, user: user, code: code}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stat, ok := e.errors[key]
+	if !ok {
+		if len(e.errors) >= e.limit {
+			e.evict()
+		}
+		stat = &ErrorStat{Database: database, User: user, Code: code, FirstSeen: now}
+		e.errors[key] = stat
+	}
+	stat.Count++
+	stat.LastSeen = now
+}
+
+// evict removes the least seen error, and the least recently seen one of those.
+func (e *ErrorStats) evict() {
+	var least *ErrorStat
+	for _, stat := range e.errors {
+		if least == nil || stat.Count < least.Count ||
+			(stat.Count == least.Count && stat.LastSeen.Before(least.LastSeen)) {
+			least = stat
+		}
+	}
+	if least != nil {
+		delete(e.errors, errorKey{database: least.Database, user: least.User, code: least.Code})
+	}
+}
+
+// Report returns the errors of the database and user, or of all of them if empty, with
+// the codes that start with the code, e.g. 40 for the class of the transaction
+// rollbacks, most seen first.
+func (e *ErrorStats) Report(database, user, code string) []ErrorStat {
+	e.mu.Lock()
+	report := make([]ErrorStat, 0, len(e.errors))
+	for _, stat := range e.errors {
+		if (database == "" || stat.Database == database) &&
+			(user == "" || stat.User == user) && strings.HasPrefix(stat.Code, code) {
+			report = append(report, *stat)
+		}
+	}
+	e.mu.Unlock()
+
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Count != report[j].Count {
+			return report[i].Count > report[j].Count
+		}
+		if report[i].Database != report[j].Database {
+			return report[i].Database < report[j].Database
+		}
+		if report[i].User != report[j].User {
+			return report[i].User < report[j].User
+		}
+		return report[i].Code < report[j].Code
+	})
+	return report
+}
+
+// recordErrors counts the error responses of the database in the response to the
+// connection, by the labels of the errors template and SQLSTATE code.
+func (pr *Proxy) recordErrors(conn *ConnWrapper, response []byte) {
+	codes := PostgresErrorCodes(response)
+	if len(codes) == 0 {
+		return
+	}
+
+	parameters := pr.startupParameters(conn)
+	labels := pr.metricLabels(parameters)
+	for _, code := range codes {
+		metrics.ProxyBackendErrors.WithLabelValues(
+			metrics.LabelValues(config.ErrorsMetricLabels, labels, code)...).Inc()
+		if pr.ErrorStats != nil {
+			pr.ErrorStats.Record(parameters["database"], parameters["user"], code)
+		}
+	}
+}
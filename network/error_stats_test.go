@@ -0,0 +1,63 @@
+package network
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestErrorStats tests that the errors are counted by database, user and code, and
+// that the least seen one is evicted past the limit.
+func TestErrorStats(t *testing.T) {
+	stats := NewErrorStats(2)
+	stats.Record("shop", "app", "40P01")
+	stats.Record("shop", "app", "40P01")
+	stats.Record("shop", "app", "23505")
+	stats.Record("crm", "app", "28P01")
+
+	report := stats.Report("", "", "")
+	require.Len(t, report, 2)
+	assert.Equal(t, ErrorStat{
+		Database: "shop", User: "app", Code: "40P01", Count: 2,
+		FirstSeen: report[0].FirstSeen, LastSeen: report[0].LastSeen,
+	}, report[0])
+	assert.Equal(t, "28P01", report[1].Code)
+	assert.False(t, report[0].LastSeen.Before(report[0].FirstSeen))
+
+	assert.Len(t, stats.Report("shop", "", "40"), 1)
+	assert.Empty(t, stats.Report("shop", "", "28"))
+	assert.Empty(t, stats.Report("", "report", ""))
+}
+
+// TestProxyRecordErrors tests that the error responses of the database to the clients
+// are counted by the database and user of their connections.
+func TestProxyRecordErrors(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, 1),
+		plugin.NewRegistry(ctx, config.Loose, config.PassDown, config.Accept, config.Stop, logger, false),
+		false, false, config.DefaultHealthCheckPeriod, nil, logger, config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+	proxy.ErrorStats = NewErrorStats(config.DefaultAPIErrorsLimit)
+
+	conn := &ConnWrapper{}
+	proxy.parameters.Store(conn, map[string]string{"user": "app", "database": "shop"})
+	response := PostgreSQLErrorResponse("ERROR", "40001", "could not serialize access")
+	proxy.recordUsage(conn, Egress, append(response, PostgreSQLReadyForQuery()...))
+	// The requests of the clients aren't counted.
+	proxy.recordUsage(conn, Ingress, response)
+
+	report := proxy.ErrorStats.Report("", "", "")
+	require.Len(t, report, 1)
+	assert.Equal(t, "shop", report[0].Database)
+	assert.Equal(t, "app", report[0].User)
+	assert.Equal(t, "40001", report[0].Code)
+	assert.Equal(t, uint64(1), report[0].Count)
+}
@@ -0,0 +1,241 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	eventLoopBatchSize   = 128
+	eventLoopWaitTimeout = 100 // milliseconds
+	eventLoopEvents      = syscall.EPOLLIN | syscall.EPOLLRDHUP | syscall.EPOLLONESHOT
+)
+
+// EventLoop serves the client connections with a bounded set of workers, each
+// watching its connections with epoll, instead of parking goroutines on every idle
+// connection. A worker passes the traffic of a connection once either the client
+// or the database has sent data, so it is blocked while the hooks run.
+type EventLoop struct {
+	server  *Server
+	workers []*eventLoopWorker
+	next    atomic.Uint32
+	stopped atomic.Bool
+	wg      sync.WaitGroup
+}
+
+type eventLoopWorker struct {
+	loop *EventLoop
+	epfd int
+	// conns maps the watched file descriptors to their connections.
+	conns sync.Map
+}
+
+type eventLoopConn struct {
+	conn     *ConnWrapper
+	stack    *Stack
+	clientFd int
+	serverFd int
+	closed   atomic.Bool
+}
+
+// NewEventLoop creates an event loop with the given number of workers.
+func NewEventLoop(server *Server, workers int) (*EventLoop, *gerr.GatewayDError) {
+	eventLoop := &EventLoop{server: server}
+	for i := 0; i < max(workers, 1); i++ {
+		epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+		if err != nil {
+			eventLoop.closeWorkers()
+			return nil, gerr.ErrEventLoopFailed.Wrap(err)
+		}
+		eventLoop.workers = append(eventLoop.workers, &eventLoopWorker{loop: eventLoop, epfd: epfd})
+	}
+
+	return eventLoop, nil
+}
+
+// Start starts the workers.
+func (el *EventLoop) Start() {
+	for _, worker := range el.workers {
+		el.wg.Add(1)
+		go worker.run()
+	}
+	el.server.logger.Info().Int("workers", len(el.workers)).Msg("Started the event loop")
+}
+
+// Stop stops the workers. The connections that are still open are left as is,
+// since the server is shutting down.
+func (el *EventLoop) Stop() {
+	if el.stopped.Swap(true) {
+		return
+	}
+	el.wg.Wait()
+	el.closeWorkers()
+}
+
+// closeWorkers closes the epoll instances of the workers.
+func (el *EventLoop) closeWorkers() {
+	for _, worker := range el.workers {
+		syscall.Close(worker.epfd)
+	}
+}
+
+// Register hands the connection over to one of the workers, which passes its traffic
+// from then on. TLS connections are not supported, because the TLS layer buffers the
+// decrypted data, which epoll can't see.
+func (el *EventLoop) Register(conn *ConnWrapper) *gerr.GatewayDError {
+	_, span := otel.Tracer("gatewayd").Start(el.server.ctx, "Register connection")
+	defer span.End()
+
+	if conn.IsTLSEnabled() {
+		return gerr.ErrEventLoopFailed.Wrap(errors.New("TLS connections are not supported"))
+	}
+
+	clientFd, err := fileDescriptor(conn.Conn())
+	if err != nil {
+		span.RecordError(err)
+		return gerr.ErrEventLoopFailed.Wrap(err)
+	}
+	serverFd, err := fileDescriptor(el.server.proxy.ServerConn(conn))
+	if err != nil {
+		span.RecordError(err)
+		return gerr.ErrEventLoopFailed.Wrap(err)
+	}
+
+	el.server.runOnTrafficHooks(conn)
+
+	entry := &eventLoopConn{
+		conn:     conn,
+		stack:    NewStack(),
+		clientFd: clientFd,
+		serverFd: serverFd,
+	}
+	worker := el.workers[el.next.Add(1)%uint32(len(el.workers))]
+	worker.conns.Store(clientFd, entry)
+	worker.conns.Store(serverFd, entry)
+	for _, fd := range []int{clientFd, serverFd} {
+		if err := syscall.EpollCtl(worker.epfd, syscall.EPOLL_CTL_ADD, fd, &syscall.EpollEvent{
+			Events: eventLoopEvents,
+			Fd:     int32(fd),
+		}); err != nil {
+			worker.unwatch(entry)
+			span.RecordError(err)
+			return gerr.ErrEventLoopFailed.Wrap(err)
+		}
+	}
+	metrics.EventLoopConnections.Inc()
+
+	return nil
+}
+
+// run waits for the connections to become readable and passes their traffic.
+func (w *eventLoopWorker) run() {
+	defer w.loop.wg.Done()
+
+	events := make([]syscall.EpollEvent, eventLoopBatchSize)
+	for !w.loop.stopped.Load() {
+		count, err := syscall.EpollWait(w.epfd, events, eventLoopWaitTimeout)
+		if err != nil {
+			if errors.Is(err, syscall.EINTR) {
+				continue
+			}
+			w.loop.server.logger.Error().Err(err).Msg("Event loop worker failed to wait for events")
+			return
+		}
+
+		for _, event := range events[:count] {
+			w.handle(int(event.Fd))
+		}
+	}
+}
+
+// handle passes the traffic in the direction of the readable file descriptor, and
+// watches it again, unless the connection is closed.
+func (w *eventLoopWorker) handle(fd int) {
+	value, ok := w.conns.Load(fd)
+	if !ok {
+		return
+	}
+	entry, ok := value.(*eventLoopConn)
+	if !ok {
+		return
+	}
+
+	err := recoverPanic(w.loop.server.logger, func() *gerr.GatewayDError {
+		if fd == entry.clientFd {
+			return w.loop.server.proxy.PassThroughToServer(entry.conn, entry.stack)
+		}
+		return w.loop.server.proxy.PassThroughToClient(entry.conn, entry.stack)
+	})
+	if err != nil {
+		w.loop.server.logger.Trace().Err(err).Msg("Failed to pass through traffic")
+		w.close(entry)
+		return
+	}
+
+	if err := syscall.EpollCtl(w.epfd, syscall.EPOLL_CTL_MOD, fd, &syscall.EpollEvent{
+		Events: eventLoopEvents,
+		Fd:     int32(fd),
+	}); err != nil {
+		w.loop.server.logger.Error().Err(err).Msg("Failed to watch the connection")
+		w.close(entry)
+	}
+}
+
+// close stops watching the connection and closes it.
+func (w *eventLoopWorker) close(entry *eventLoopConn) {
+	if entry.closed.Swap(true) {
+		return
+	}
+
+	// The file descriptors must be removed before the connections are closed,
+	// since they can be reused by new connections right after.
+	w.unwatch(entry)
+	metrics.EventLoopConnections.Dec()
+	entry.stack.Clear()
+
+	server := w.loop.server
+	server.engine.mu.Lock()
+	server.engine.connections--
+	server.engine.mu.Unlock()
+	server.OnClose(entry.conn, nil)
+}
+
+// unwatch stops watching the file descriptors of the connection.
+func (w *eventLoopWorker) unwatch(entry *eventLoopConn) {
+	for _, fd := range []int{entry.clientFd, entry.serverFd} {
+		_ = syscall.EpollCtl(w.epfd, syscall.EPOLL_CTL_DEL, fd, nil)
+		w.conns.Delete(fd)
+	}
+}
+
+// fileDescriptor returns the file descriptor of the connection.
+func fileDescriptor(conn net.Conn) (int, error) {
+	syscallConn, ok := conn.(syscall.Conn)
+	if !ok {
+		return 0, errors.New("connection has no file descriptor")
+	}
+
+	rawConn, err := syscallConn.SyscallConn()
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	var fd int
+	if err := rawConn.Control(func(descriptor uintptr) {
+		fd = int(descriptor)
+	}); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	return fd, nil
+}
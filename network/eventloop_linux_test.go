@@ -0,0 +1,136 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pipeProxy is a proxy that copies the traffic between a single client
+// connection and a database connection, without any hooks.
+type pipeProxy struct {
+	Proxy
+	database     net.Conn
+	disconnected atomic.Bool
+}
+
+func (p *pipeProxy) ServerConn(_ *ConnWrapper) net.Conn {
+	return p.database
+}
+
+func (p *pipeProxy) PassThroughToServer(conn *ConnWrapper, _ *Stack) *gerr.GatewayDError {
+	return pipe(p.database, conn.Conn())
+}
+
+func (p *pipeProxy) PassThroughToClient(conn *ConnWrapper, _ *Stack) *gerr.GatewayDError {
+	return pipe(conn.Conn(), p.database)
+}
+
+func (p *pipeProxy) Disconnect(_ *ConnWrapper) *gerr.GatewayDError {
+	p.disconnected.Store(true)
+	return nil
+}
+
+// pipe copies the data that is available on src to dst.
+func pipe(dst, src net.Conn) *gerr.GatewayDError {
+	buffer := make([]byte, config.DefaultChunkSize)
+	read, err := src.Read(buffer)
+	if err != nil {
+		return gerr.ErrReadFailed.Wrap(err)
+	}
+	if _, err := dst.Write(buffer[:read]); err != nil {
+		return gerr.ErrServerSendFailed.Wrap(err)
+	}
+	return nil
+}
+
+// listen returns both ends of a new TCP connection.
+func listen(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	dialed, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	accepted, err := listener.Accept()
+	require.NoError(t, err)
+
+	return dialed, accepted
+}
+
+// TestEventLoop tests that the event loop passes the traffic in both directions
+// and closes the connection once the client disconnects.
+func TestEventLoop(t *testing.T) {
+	logger := zerolog.Nop()
+
+	// An echo server in place of the database.
+	database, echo := listen(t)
+	defer database.Close()
+	go func() {
+		defer echo.Close()
+		_, _ = io.Copy(echo, echo)
+	}()
+
+	proxy := &pipeProxy{database: database}
+	server := NewServer(
+		context.Background(),
+		"tcp",
+		"127.0.0.1:0",
+		config.DefaultTickInterval,
+		Option{},
+		proxy,
+		logger,
+		plugin.NewRegistry(
+			context.Background(),
+			config.Loose,
+			config.PassDown,
+			config.Accept,
+			config.Stop,
+			logger,
+			false,
+		),
+		config.DefaultPluginTimeout,
+		false,
+		"",
+		"",
+		config.DefaultHandshakeTimeout,
+	)
+	server.Status = config.Running
+	server.engine.connections = 1
+
+	eventLoop, err := NewEventLoop(server, 2)
+	require.Nil(t, err)
+	eventLoop.Start()
+	defer eventLoop.Stop()
+
+	client, accepted := listen(t)
+	defer client.Close()
+	require.Nil(t, eventLoop.Register(NewConnWrapper(accepted, nil, config.DefaultHandshakeTimeout)))
+
+	_, origErr := client.Write([]byte("hello"))
+	require.NoError(t, origErr)
+	response := make([]byte, 5)
+	_, origErr = io.ReadFull(client, response)
+	require.NoError(t, origErr)
+	assert.Equal(t, "hello", string(response))
+
+	// The connection is closed once the client disconnects.
+	require.NoError(t, client.Close())
+	assert.Eventually(t, proxy.disconnected.Load, time.Second, time.Millisecond)
+	assert.Equal(t, 0, server.engine.CountConnections())
+}
@@ -0,0 +1,29 @@
+//go:build !linux
+// +build !linux
+
+package network
+
+import (
+	"errors"
+
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+)
+
+// EventLoop is only supported on Linux, where it uses epoll.
+type EventLoop struct{}
+
+// NewEventLoop returns an error, because the event loop is only supported on Linux.
+func NewEventLoop(_ *Server, _ int) (*EventLoop, *gerr.GatewayDError) {
+	return nil, gerr.ErrEventLoopFailed.Wrap(errors.New("the event loop is only supported on Linux"))
+}
+
+// Start does nothing.
+func (el *EventLoop) Start() {}
+
+// Stop does nothing.
+func (el *EventLoop) Stop() {}
+
+// Register returns an error, because the event loop is only supported on Linux.
+func (el *EventLoop) Register(_ *ConnWrapper) *gerr.GatewayDError {
+	return gerr.ErrEventLoopFailed.Wrap(errors.New("the event loop is only supported on Linux"))
+}
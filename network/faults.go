@@ -0,0 +1,108 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/rs/zerolog"
+)
+
+const (
+	faultLatency = "latency"
+	faultDrop    = "drop"
+	faultCorrupt = "corrupt"
+	faultKill    = "kill"
+)
+
+// FaultInjector injects faults in the traffic of a proxy, each at its rate, so that
+// the applications can be tested against a slow, lossy or failing database.
+type FaultInjector struct {
+	Latency     time.Duration
+	LatencyRate float64
+	DropRate    float64
+	CorruptRate float64
+	KillRate    float64
+
+	// random returns a number in [0, 1), which is replaced in the tests.
+	random func() float64
+}
+
+// NewFaultInjector returns the fault injector of the config, or nil if it is disabled.
+func NewFaultInjector(cfg config.FaultInjection) (*FaultInjector, *gerr.GatewayDError) {
+	if !cfg.Enabled {
+		return nil, nil //nolint:nilnil
+	}
+
+	for name, rate := range map[string]float64{
+		"latencyRate": cfg.LatencyRate,
+		"dropRate":    cfg.DropRate,
+		"corruptRate": cfg.CorruptRate,
+		"killRate":    cfg.KillRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return nil, gerr.ErrValidationFailed.Wrap(
+				fmt.Errorf("invalid fault injection %s %v, it must be between 0 and 1", name, rate))
+		}
+	}
+
+	return &FaultInjector{
+		Latency: config.If[time.Duration](
+			cfg.Latency > 0, cfg.Latency, config.DefaultFaultLatency),
+		LatencyRate: cfg.LatencyRate,
+		DropRate:    cfg.DropRate,
+		CorruptRate: cfg.CorruptRate,
+		KillRate:    cfg.KillRate,
+		random:      rand.Float64,
+	}, nil
+}
+
+// hit returns true if the fault of the rate is injected.
+func (f *FaultInjector) hit(rate float64) bool {
+	return rate > 0 && f.random() < rate
+}
+
+// injectFaults injects the faults in the message the proxy sends in the direction, and
+// returns it, or nothing if it is dropped. The server connection is only killed before
+// the requests, so that the proxy finds out when it sends them, like when the database
+// dies between the queries.
+func (pr *Proxy) injectFaults(
+	client *Client, direction Direction, message []byte, logger zerolog.Logger,
+) []byte {
+	faults := pr.Faults
+	if faults == nil || len(message) == 0 {
+		return message
+	}
+
+	injected := func(fault string) {
+		metrics.ProxyInjectedFaults.WithLabelValues(fault).Inc()
+		logger.Debug().Str("fault", fault).Str("direction", string(direction)).Msg(
+			"Injected a fault in the traffic")
+	}
+
+	if faults.hit(faults.LatencyRate) {
+		injected(faultLatency)
+		time.Sleep(faults.Latency)
+	}
+	if direction == Ingress && faults.hit(faults.KillRate) {
+		injected(faultKill)
+		client.kill()
+		return message
+	}
+	if faults.hit(faults.DropRate) {
+		injected(faultDrop)
+		return nil
+	}
+	if faults.hit(faults.CorruptRate) {
+		injected(faultCorrupt)
+		// The message may still be used, e.g. by the hooks, so a copy is corrupted.
+		corrupted := bytes.Clone(message)
+		corrupted[int(faults.random()*float64(len(corrupted)))] ^= 0xff
+		return corrupted
+	}
+	return message
+}
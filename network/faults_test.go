@@ -0,0 +1,60 @@
+package network
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFaultInjector(t *testing.T) {
+	disabled, err := NewFaultInjector(config.FaultInjection{DropRate: 1})
+	require.Nil(t, err)
+	assert.Nil(t, disabled)
+
+	faults, err := NewFaultInjector(config.FaultInjection{Enabled: true, LatencyRate: 0.5})
+	require.Nil(t, err)
+	require.NotNil(t, faults)
+	assert.Equal(t, config.DefaultFaultLatency, faults.Latency)
+	assert.InDelta(t, 0.5, faults.LatencyRate, 0)
+
+	_, err = NewFaultInjector(config.FaultInjection{Enabled: true, KillRate: 2})
+	assert.NotNil(t, err)
+}
+
+// TestInjectFaults tests that the faults are injected at their rates, and that the
+// server connection is only killed before the requests.
+func TestInjectFaults(t *testing.T) {
+	server, database := net.Pipe()
+	defer database.Close()
+	client := &Client{conn: server, logger: zerolog.Nop()}
+	logger := zerolog.Nop()
+	request := PostgreSQLQuery("SELECT 1")
+
+	proxy := &Proxy{}
+	assert.Equal(t, request, proxy.injectFaults(client, Ingress, request, logger))
+
+	proxy.Faults = &FaultInjector{
+		Latency:     10 * time.Millisecond,
+		LatencyRate: 1,
+		CorruptRate: 1,
+		random:      func() float64 { return 0 },
+	}
+	start := time.Now()
+	corrupted := proxy.injectFaults(client, Egress, request, logger)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+	assert.Equal(t, byte('Q'), request[0])
+	assert.Equal(t, request[0]^0xff, corrupted[0])
+	assert.Equal(t, request[1:], corrupted[1:])
+
+	proxy.Faults = &FaultInjector{DropRate: 1, KillRate: 1, random: func() float64 { return 0 }}
+	assert.Nil(t, proxy.injectFaults(client, Egress, request, logger))
+	assert.Equal(t, request, proxy.injectFaults(client, Ingress, request, logger))
+	_, err := server.Write(request)
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+}
@@ -0,0 +1,110 @@
+package network
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/policy"
+	"github.com/rs/zerolog"
+)
+
+// DefaultFirewallMessage is the message the denied queries are answered with, if
+// their rule has none.
+const DefaultFirewallMessage = "query denied by the gateway firewall"
+
+// FirewallRule allows or denies the queries its policy matches.
+type FirewallRule struct {
+	Name    string
+	Policy  *policy.Program
+	Action  config.FirewallAction
+	Message string
+}
+
+// Firewall allows or denies the queries with the first rule whose policy matches
+// them. The queries no rule matches are allowed.
+type Firewall struct {
+	Rules  []FirewallRule
+	mu     sync.RWMutex
+	logger zerolog.Logger
+}
+
+// NewFirewall compiles the policies of the rules.
+func NewFirewall(rules []config.FirewallRule, logger zerolog.Logger) (*Firewall, *gerr.GatewayDError) {
+	compiled, err := compileFirewallRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	return &Firewall{Rules: compiled, logger: logger}, nil
+}
+
+// Update replaces the rules, e.g. when the configuration is reloaded. The current
+// rules are kept if the new ones are invalid.
+func (f *Firewall) Update(rules []config.FirewallRule) *gerr.GatewayDError {
+	compiled, err := compileFirewallRules(rules)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Rules = compiled
+	return nil
+}
+
+// compileFirewallRules compiles the policies of the rules.
+func compileFirewallRules(rules []config.FirewallRule) ([]FirewallRule, *gerr.GatewayDError) {
+	compiled := make([]FirewallRule, 0, len(rules))
+	for _, rule := range rules {
+		program, err := policy.Compile(rule.When)
+		if err != nil {
+			return nil, err
+		}
+		action := config.FirewallAction(rule.Action)
+		switch action {
+		case config.Allow, config.Deny:
+		case "":
+			action = config.Deny
+		default:
+			return nil, gerr.ErrInvalidPolicy.Wrap(
+				fmt.Errorf("invalid action %q of the firewall rule %q", rule.Action, rule.Name))
+		}
+		message := rule.Message
+		if message == "" {
+			message = DefaultFirewallMessage
+		}
+		compiled = append(compiled, FirewallRule{
+			Name:    rule.Name,
+			Policy:  program,
+			Action:  action,
+			Message: message,
+		})
+	}
+	return compiled, nil
+}
+
+// Check returns the rule that denies the request, or nil if it is allowed.
+func (f *Firewall) Check(vars map[string]any) *FirewallRule {
+	f.mu.RLock()
+	rules := f.Rules
+	f.mu.RUnlock()
+
+	for i := range rules {
+		rule := &rules[i]
+		matches, err := rule.Policy.Matches(vars)
+		if err != nil {
+			f.logger.Debug().Err(err).Str("rule", rule.Name).Msg(
+				"Failed to evaluate the firewall rule, so it doesn't match")
+			continue
+		}
+		if !matches {
+			continue
+		}
+		if rule.Action == config.Allow {
+			return nil
+		}
+		return rule
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFirewall tests that the first matching rule decides whether the query is
+// allowed or denied.
+func TestFirewall(t *testing.T) {
+	firewall, err := NewFirewall([]config.FirewallRule{
+		{
+			Name:   "allow-admin",
+			When:   `request.user == "admin"`,
+			Action: string(config.Allow),
+		},
+		{
+			Name:    "no-ddl-on-prod",
+			When:    `request.db == "prod" && request.query.matches("(?i)^\\s*(drop|alter)\\s")`,
+			Action:  string(config.Deny),
+			Message: "DDL is not allowed on prod",
+		},
+		{
+			Name: "no-missing-field",
+			When: `request.missing == "value"`,
+		},
+	}, zerolog.Nop())
+	require.Nil(t, err)
+
+	vars := func(user, db, query string) map[string]any {
+		return map[string]any{
+			"request": map[string]any{"user": user, "db": db, "query": query},
+		}
+	}
+
+	rule := firewall.Check(vars("app", "prod", "DROP TABLE users"))
+	require.NotNil(t, rule)
+	assert.Equal(t, "no-ddl-on-prod", rule.Name)
+	assert.Equal(t, "DDL is not allowed on prod", rule.Message)
+
+	assert.Nil(t, firewall.Check(vars("admin", "prod", "DROP TABLE users")))
+	assert.Nil(t, firewall.Check(vars("app", "prod", "SELECT 1")))
+	assert.Nil(t, firewall.Check(vars("app", "dev", "DROP TABLE users")))
+}
+
+// TestNewFirewallInvalidRules tests that the invalid rules are rejected.
+func TestNewFirewallInvalidRules(t *testing.T) {
+	_, err := NewFirewall([]config.FirewallRule{{Name: "broken", When: `request.user ==`}}, zerolog.Nop())
+	assert.NotNil(t, err)
+
+	_, err = NewFirewall([]config.FirewallRule{
+		{Name: "unknown", When: "true", Action: "reject"},
+	}, zerolog.Nop())
+	assert.NotNil(t, err)
+
+	firewall, err := NewFirewall([]config.FirewallRule{{Name: "default", When: "true"}}, zerolog.Nop())
+	require.Nil(t, err)
+	assert.Equal(t, config.Deny, firewall.Rules[0].Action)
+	assert.Equal(t, DefaultFirewallMessage, firewall.Rules[0].Message)
+}
+
+// TestFirewallUpdate tests that the rules are replaced, and kept if the new ones
+// are invalid.
+func TestFirewallUpdate(t *testing.T) {
+	firewall, err := NewFirewall([]config.FirewallRule{{Name: "deny-all", When: "true"}}, zerolog.Nop())
+	require.Nil(t, err)
+	require.NotNil(t, firewall.Check(nil))
+
+	assert.NotNil(t, firewall.Update([]config.FirewallRule{{Name: "broken", When: "("}}))
+	assert.NotNil(t, firewall.Check(nil))
+
+	assert.Nil(t, firewall.Update(nil))
+	assert.Nil(t, firewall.Check(nil))
+}
@@ -0,0 +1,327 @@
+package network
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	// RDSAuthTokenLifetime is how long the RDS IAM auth tokens are valid.
+	RDSAuthTokenLifetime = 15 * time.Minute
+	// CloudSQLLoginScope is the OAuth2 scope of the Cloud SQL IAM logins.
+	CloudSQLLoginScope = "https://www.googleapis.com/auth/sqlservice.login"
+	// GCPMetadataHost is the host of the GCP metadata server, which can be overridden
+	// with the GCE_METADATA_HOST environment variable.
+	GCPMetadataHost = "metadata.google.internal"
+)
+
+// iamAuths holds the IAM authenticators by their config and database, so that the
+// clients of a pool share the cached tokens.
+var iamAuths sync.Map
+
+// IAMTokenSource gets a short-lived token that the user authenticates to the
+// database with, instead of a password, and the time it expires.
+type IAMTokenSource interface {
+	Token(ctx context.Context, user string) (string, time.Time, error)
+}
+
+// IAMAuth answers the password requests of the database with IAM auth tokens. The
+// tokens are cached for each user, and fetched again shortly before they expire.
+type IAMAuth struct {
+	provider      config.IAMAuthProvider
+	source        IAMTokenSource
+	refreshBefore time.Duration
+
+	mu     sync.Mutex
+	tokens map[string]iamToken
+}
+
+type iamToken struct {
+	value  string
+	expiry time.Time
+}
+
+type iamAuthKey struct {
+	config  config.IAMAuth
+	address string
+}
+
+// GetIAMAuth returns the IAM authenticator for the config and the address of the
+// database, which is created once and then shared by all the clients with the same
+// config and address.
+func GetIAMAuth(
+	iamConfig config.IAMAuth, address string, timeout time.Duration,
+) (*IAMAuth, *gerr.GatewayDError) {
+	key := iamAuthKey{config: iamConfig, address: address}
+	if iamAuth, ok := iamAuths.Load(key); ok {
+		auth, _ := iamAuth.(*IAMAuth)
+		return auth, nil
+	}
+
+	var source IAMTokenSource
+	switch iamConfig.Provider {
+	case config.AWSIAMAuth:
+		region := iamConfig.Region
+		if region == "" {
+			region = os.Getenv("AWS_REGION")
+		}
+		if region == "" {
+			return nil, gerr.ErrIAMAuthFailed.Wrap(errors.New("the AWS region is required"))
+		}
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return nil, gerr.ErrIAMAuthFailed.Wrap(err)
+		}
+		source = &RDSTokenSource{Region: region, Endpoint: address, Now: time.Now}
+	case config.GCPIAMAuth:
+		source = &CloudSQLTokenSource{CredentialsFile: iamConfig.CredentialsFile, Timeout: timeout}
+	default:
+		return nil, gerr.ErrIAMAuthFailed.Wrap(
+			fmt.Errorf("unsupported IAM auth provider: %q", iamConfig.Provider))
+	}
+
+	iamAuth, _ := iamAuths.LoadOrStore(key, NewIAMAuth(
+		iamConfig.Provider, source, iamConfig.RefreshBefore))
+	auth, _ := iamAuth.(*IAMAuth)
+	return auth, nil
+}
+
+// NewIAMAuth creates a new IAM authenticator that gets the tokens from the source.
+func NewIAMAuth(
+	provider config.IAMAuthProvider, source IAMTokenSource, refreshBefore time.Duration,
+) *IAMAuth {
+	return &IAMAuth{
+		provider:      provider,
+		source:        source,
+		refreshBefore: refreshBefore,
+		tokens:        map[string]iamToken{},
+	}
+}
+
+// Password returns the token the user authenticates to the database with, which is
+// fetched again if it expires within the refresh duration.
+func (a *IAMAuth) Password(ctx context.Context, user string) (string, *gerr.GatewayDError) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if token, ok := a.tokens[user]; ok && time.Until(token.expiry) > a.refreshBefore {
+		return token.value, nil
+	}
+
+	value, expiry, err := a.source.Token(ctx, user)
+	if err != nil {
+		metrics.IAMAuthTokenFailures.WithLabelValues(string(a.provider)).Inc()
+		return "", gerr.ErrIAMAuthFailed.Wrap(err)
+	}
+	metrics.IAMAuthTokenRefreshes.WithLabelValues(string(a.provider)).Inc()
+	a.tokens[user] = iamToken{value: value, expiry: expiry}
+
+	return value, nil
+}
+
+// RDSTokenSource generates the IAM auth tokens of RDS and Aurora, which are presigned
+// URLs of the connect action, with the AWS credentials in the environment variables.
+type RDSTokenSource struct {
+	Region   string
+	Endpoint string // host:port of the database
+	Now      func() time.Time
+}
+
+// Token generates an auth token for the user, which is valid for 15 minutes.
+func (s *RDSTokenSource) Token(_ context.Context, user string) (string, time.Time, error) {
+	accessKeyID := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretAccessKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKeyID == "" || secretAccessKey == "" {
+		return "", time.Time{}, errors.New(
+			"the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables are required")
+	}
+
+	now := s.Now().UTC()
+	date := now.Format("20060102")
+	scope := strings.Join([]string{date, s.Region, "rds-db", "aws4_request"}, "/")
+
+	query := map[string]string{
+		"Action":              "connect",
+		"DBUser":              user,
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    accessKeyID + "/" + scope,
+		"X-Amz-Date":          now.Format("20060102T150405Z"),
+		"X-Amz-Expires":       strconv.Itoa(int(RDSAuthTokenLifetime.Seconds())),
+		"X-Amz-SignedHeaders": "host",
+	}
+	if sessionToken := os.Getenv("AWS_SESSION_TOKEN"); sessionToken != "" {
+		query["X-Amz-Security-Token"] = sessionToken
+	}
+	canonicalQuery := canonicalQueryString(query)
+
+	// Sign the request with AWS Signature Version 4:
+	// https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html
+	emptyPayloadHash := sha256.Sum256(nil)
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		"/",
+		canonicalQuery,
+		"host:" + s.Endpoint + "\n",
+		"host",
+		hex.EncodeToString(emptyPayloadHash[:]),
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		query["X-Amz-Date"],
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + secretAccessKey)
+	for _, part := range []string{date, s.Region, "rds-db", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	token := s.Endpoint + "/?" + canonicalQuery + "&X-Amz-Signature=" + signature
+	return token, now.Add(RDSAuthTokenLifetime), nil
+}
+
+// canonicalQueryString returns the query sorted by the keys, with the keys and values
+// encoded as required by AWS Signature Version 4.
+func canonicalQueryString(query map[string]string) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	escape := func(value string) string {
+		return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+	}
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, escape(key)+"="+escape(query[key]))
+	}
+	return strings.Join(pairs, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// CloudSQLTokenSource gets the OAuth2 access tokens that Cloud SQL accepts as the
+// passwords of the IAM users, either with a service account key file or from the
+// metadata server of the instance the gateway runs on.
+type CloudSQLTokenSource struct {
+	CredentialsFile string
+	Timeout         time.Duration
+}
+
+// Token gets an access token for the Cloud SQL logins. The user is the IAM user or
+// service account the credentials belong to, so it isn't needed.
+func (s *CloudSQLTokenSource) Token(ctx context.Context, _ string) (string, time.Time, error) {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	if s.CredentialsFile != "" {
+		return s.tokenFromKeyFile(ctx)
+	}
+	return s.tokenFromMetadataServer(ctx)
+}
+
+// tokenFromKeyFile gets an access token with the JWT of the service account key file.
+func (s *CloudSQLTokenSource) tokenFromKeyFile(ctx context.Context) (string, time.Time, error) {
+	data, err := os.ReadFile(expandHome(s.CredentialsFile))
+	if err != nil {
+		return "", time.Time{}, err //nolint:wrapcheck
+	}
+
+	var key struct {
+		Type         string `json:"type"`
+		ClientEmail  string `json:"client_email"`
+		PrivateKey   string `json:"private_key"`
+		PrivateKeyID string `json:"private_key_id"`
+		TokenURI     string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return "", time.Time{}, err //nolint:wrapcheck
+	}
+	if key.Type != "service_account" {
+		return "", time.Time{}, fmt.Errorf("unsupported credentials type: %q", key.Type)
+	}
+
+	jwtConfig := &jwt.Config{
+		Email:        key.ClientEmail,
+		PrivateKey:   []byte(key.PrivateKey),
+		PrivateKeyID: key.PrivateKeyID,
+		Scopes:       []string{CloudSQLLoginScope},
+		TokenURL:     key.TokenURI,
+	}
+	token, err := jwtConfig.TokenSource(ctx).Token()
+	if err != nil {
+		return "", time.Time{}, err //nolint:wrapcheck
+	}
+	return token.AccessToken, token.Expiry, nil
+}
+
+// tokenFromMetadataServer gets an access token of the default service account of the
+// instance from the metadata server.
+func (s *CloudSQLTokenSource) tokenFromMetadataServer(ctx context.Context) (string, time.Time, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = GCPMetadataHost
+	}
+	tokenURL := url.URL{
+		Scheme:   "http",
+		Host:     host,
+		Path:     "/computeMetadata/v1/instance/service-accounts/default/token",
+		RawQuery: url.Values{"scopes": {CloudSQLLoginScope}}.Encode(),
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", time.Time{}, err //nolint:wrapcheck
+	}
+	request.Header.Set("Metadata-Flavor", "Google")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", time.Time{}, err //nolint:wrapcheck
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("metadata server returned %s", response.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return "", time.Time{}, err //nolint:wrapcheck
+	}
+	if token.AccessToken == "" {
+		return "", time.Time{}, errors.New("metadata server returned no access token")
+	}
+	return token.AccessToken, time.Now().Add(time.Duration(token.ExpiresIn) * time.Second), nil
+}
@@ -0,0 +1,196 @@
+package network
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenSource returns tokens that expire after the lifetime, and counts them.
+type fakeTokenSource struct {
+	lifetime time.Duration
+	fetched  int
+	err      error
+}
+
+func (s *fakeTokenSource) Token(_ context.Context, user string) (string, time.Time, error) {
+	if s.err != nil {
+		return "", time.Time{}, s.err
+	}
+	s.fetched++
+	return user + "-token", time.Now().Add(s.lifetime), nil
+}
+
+// TestIAMAuth_Password tests that the tokens are cached for each user, and fetched
+// again once they expire within the refresh duration.
+func TestIAMAuth_Password(t *testing.T) {
+	source := &fakeTokenSource{lifetime: time.Hour}
+	iamAuth := NewIAMAuth(config.AWSIAMAuth, source, time.Minute)
+
+	password, err := iamAuth.Password(context.Background(), "alice")
+	require.Nil(t, err)
+	assert.Equal(t, "alice-token", password)
+	_, err = iamAuth.Password(context.Background(), "alice")
+	require.Nil(t, err)
+	assert.Equal(t, 1, source.fetched)
+
+	_, err = iamAuth.Password(context.Background(), "bob")
+	require.Nil(t, err)
+	assert.Equal(t, 2, source.fetched)
+
+	// The tokens that expire within the refresh duration are fetched every time.
+	source.lifetime = time.Second
+	iamAuth = NewIAMAuth(config.AWSIAMAuth, source, time.Minute)
+	_, err = iamAuth.Password(context.Background(), "alice")
+	require.Nil(t, err)
+	_, err = iamAuth.Password(context.Background(), "alice")
+	require.Nil(t, err)
+	assert.Equal(t, 4, source.fetched)
+
+	source.err = errors.New("no credentials")
+	_, err = NewIAMAuth(config.AWSIAMAuth, source, time.Minute).Password(
+		context.Background(), "alice")
+	assert.NotNil(t, err)
+}
+
+// TestGetIAMAuth tests that the authenticators are shared, and that the invalid
+// configs are rejected.
+func TestGetIAMAuth(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+
+	awsConfig := config.IAMAuth{Provider: config.AWSIAMAuth, Region: "us-east-1"}
+	iamAuth, err := GetIAMAuth(awsConfig, "db.example.com:5432", time.Second)
+	require.Nil(t, err)
+	shared, err := GetIAMAuth(awsConfig, "db.example.com:5432", time.Second)
+	require.Nil(t, err)
+	assert.Same(t, iamAuth, shared)
+
+	_, err = GetIAMAuth(config.IAMAuth{Provider: config.AWSIAMAuth}, "db.example.com:5432", time.Second)
+	assert.NotNil(t, err)
+	_, err = GetIAMAuth(awsConfig, "db.example.com", time.Second)
+	assert.NotNil(t, err)
+	_, err = GetIAMAuth(config.IAMAuth{Provider: "azure"}, "db.example.com:5432", time.Second)
+	assert.NotNil(t, err)
+}
+
+// TestRDSTokenSource tests that the RDS auth tokens are presigned URLs of the
+// connect action, signed with the credentials in the environment variables.
+func TestRDSTokenSource(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	source := &RDSTokenSource{
+		Region:   "us-east-1",
+		Endpoint: "db.example.com:5432",
+		Now:      func() time.Time { return now },
+	}
+
+	token, expiry, err := source.Token(context.Background(), "gatewayd")
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(RDSAuthTokenLifetime), expiry)
+	assert.True(t, strings.HasPrefix(token, "db.example.com:5432/?Action=connect&DBUser=gatewayd&"+
+		"X-Amz-Algorithm=AWS4-HMAC-SHA256&"+
+		"X-Amz-Credential=AKIDEXAMPLE%2F20240102%2Fus-east-1%2Frds-db%2Faws4_request&"+
+		"X-Amz-Date=20240102T030405Z&X-Amz-Expires=900&X-Amz-SignedHeaders=host&"+
+		"X-Amz-Signature="), token)
+	assert.Regexp(t, regexp.MustCompile("X-Amz-Signature=[0-9a-f]{64}$"), token)
+
+	// The signature only depends on the request and the credentials.
+	again, _, err := source.Token(context.Background(), "gatewayd")
+	require.NoError(t, err)
+	assert.Equal(t, token, again)
+
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+	withSession, _, err := source.Token(context.Background(), "gatewayd")
+	require.NoError(t, err)
+	assert.Contains(t, withSession, "&X-Amz-Security-Token=session&")
+	assert.NotEqual(t, token[strings.Index(token, "X-Amz-Signature"):],
+		withSession[strings.Index(withSession, "X-Amz-Signature"):])
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	_, _, err = source.Token(context.Background(), "gatewayd")
+	assert.Error(t, err)
+}
+
+// TestCloudSQLTokenSource_MetadataServer tests that the access tokens are fetched
+// from the metadata server without a key file.
+func TestCloudSQLTokenSource_MetadataServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		assert.Equal(t, "/computeMetadata/v1/instance/service-accounts/default/token", r.URL.Path)
+		assert.Equal(t, CloudSQLLoginScope, r.URL.Query().Get("scopes"))
+		_, _ = w.Write([]byte(`{"access_token":"metadata-token","expires_in":3600}`))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	t.Setenv("GCE_METADATA_HOST", serverURL.Host)
+
+	source := &CloudSQLTokenSource{Timeout: time.Second}
+	token, expiry, err := source.Token(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "metadata-token", token)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiry, time.Minute)
+}
+
+// TestCloudSQLTokenSource_KeyFile tests that the access tokens are fetched with the
+// JWT of the service account key file.
+func TestCloudSQLTokenSource_KeyFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" ||
+			r.PostForm.Get("assertion") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"jwt-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyFile, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "gatewayd@project.iam.gserviceaccount.com",
+		"private_key": string(pem.EncodeToMemory(&pem.Block{
+			Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+		})),
+		"token_uri": server.URL,
+	})
+	require.NoError(t, err)
+	credentialsFile := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(credentialsFile, keyFile, 0o600))
+
+	source := &CloudSQLTokenSource{CredentialsFile: credentialsFile, Timeout: time.Second}
+	token, expiry, err := source.Token(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "jwt-token", token)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiry, time.Minute)
+
+	source.CredentialsFile = filepath.Join(t.TempDir(), "missing.json")
+	_, _, err = source.Token(context.Background(), "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"github.com/getsentry/sentry-go"
+)
+
+// IdleTransactions end the transactions of the sessions that are idle in them for
+// longer than the timeout of their database, so that they don't hold their locks.
+type IdleTransactions struct {
+	Timeout   time.Duration
+	Action    config.IdleTransactionAction
+	Databases map[string]time.Duration
+}
+
+// NewIdleTransactions returns the idle transaction timeouts of the config, or nil if
+// they are all disabled.
+func NewIdleTransactions(cfg config.IdleTransaction) (*IdleTransactions, *gerr.GatewayDError) {
+	action := config.If[config.IdleTransactionAction](
+		cfg.Action != "",
+		config.IdleTransactionAction(cfg.Action),
+		config.DefaultIdleTransactionAction,
+	)
+	if action != config.Terminate && action != config.Rollback {
+		return nil, gerr.ErrValidationFailed.Wrap(
+			fmt.Errorf("invalid idle transaction action %q", cfg.Action))
+	}
+
+	enabled := cfg.Timeout > 0
+	databases := make(map[string]time.Duration, len(cfg.Databases))
+	for _, override := range cfg.Databases {
+		databases[override.Database] = override.Timeout
+		enabled = enabled || override.Timeout > 0
+	}
+	if !enabled {
+		return nil, nil //nolint:nilnil
+	}
+	return &IdleTransactions{Timeout: cfg.Timeout, Action: action, Databases: databases}, nil
+}
+
+// TimeoutOf returns the idle transaction timeout of the database, which is zero if it
+// is disabled.
+func (t *IdleTransactions) TimeoutOf(database string) time.Duration {
+	if timeout, ok := t.Databases[database]; ok {
+		return timeout
+	}
+	return t.Timeout
+}
+
+// WatchIdleTransactions ends the idle transactions on the check interval, if the idle
+// transaction timeout is enabled.
+func (pr *Proxy) WatchIdleTransactions() {
+	if pr.IdleTransactions == nil {
+		return
+	}
+
+	if _, err := pr.scheduler.Every(config.DefaultIdleTransactionCheckInterval).SingletonMode().StartAt(
+		time.Now().Add(config.DefaultIdleTransactionCheckInterval)).Do(pr.EndIdleTransactions); err != nil {
+		pr.logger.Error().Err(err).Msg("Failed to schedule the idle transaction check")
+		sentry.CaptureException(err)
+		return
+	}
+	pr.logger.Info().Str("timeout", pr.IdleTransactions.Timeout.String()).Str(
+		"action", string(pr.IdleTransactions.Action)).Msg("Ending the idle transactions")
+}
+
+// EndIdleTransactions terminates the sessions that are idle in a transaction for longer
+// than the timeout of their database, or rolls back their transactions.
+func (pr *Proxy) EndIdleTransactions() {
+	pr.sessions.Range(func(key, value interface{}) bool {
+		conn, ok := key.(*ConnWrapper)
+		if !ok {
+			return true
+		}
+		database := pr.startupParameters(conn)["database"]
+		timeout := pr.IdleTransactions.TimeoutOf(database)
+		if timeout <= 0 {
+			return true
+		}
+		state := value.(*session) //nolint:forcetypeassert
+		if state.idleInTransaction() < timeout {
+			return true
+		}
+
+		pr.endIdleTransaction(conn, state, database, timeout)
+		return true
+	})
+}
+
+// endIdleTransaction ends the transaction of the session, unless the client sent a
+// request since it was found idle.
+func (pr *Proxy) endIdleTransaction(
+	conn *ConnWrapper, state *session, database string, timeout time.Duration,
+) {
+	state.exchange.Lock()
+	defer state.exchange.Unlock()
+	if state.idleInTransaction() < timeout {
+		return
+	}
+
+	correlation := Correlation{ConnectionID: conn.ID()}
+	logger := correlation.Logger(pr.logger).With().Str("database", database).Str(
+		"timeout", timeout.String()).Logger()
+
+	action := pr.IdleTransactions.Action
+	if action == config.Rollback {
+		if err := pr.rollbackTransaction(conn, correlation); err != nil {
+			logger.Error().Err(err).Msg(
+				"Failed to roll back the idle transaction, so the connection is terminated")
+			action = config.Terminate
+		} else {
+			state.state.Store(Idle)
+			state.rolledBack = true
+			pr.notifyTransactions(conn, state.transaction.abort(sdk.TransactionRollback), correlation)
+			logger.Warn().Msg("Rolled back the transaction that was idle for too long")
+		}
+	}
+	if action == config.Terminate {
+		response := ErrorResponse(pr.ErrorMessages, config.ErrorIdleTransactionTerminated, "")
+		//nolint:errcheck
+		pr.sendTrafficToClient(conn.Conn(), response, len(response), correlation)
+		if err := conn.Close(); err != nil {
+			logger.Debug().Err(err).Msg("Failed to close the connection of the idle transaction")
+		}
+		logger.Warn().Msg("Terminated the connection that was idle in a transaction for too long")
+	}
+	metrics.ProxyIdleTransactions.WithLabelValues(database, string(action)).Inc()
+}
+
+// startRequest marks the session of the connection active for the request of the
+// client, and returns true if the gateway rolled back its transaction before it.
+func (pr *Proxy) startRequest(conn *ConnWrapper) bool {
+	if value, ok := pr.sessions.Load(conn); ok {
+		return value.(*session).startRequest() //nolint:forcetypeassert
+	}
+	return false
+}
+
+// rollbackTransaction rolls back the transaction of the session on its server
+// connection, and discards the response.
+func (pr *Proxy) rollbackTransaction(conn *ConnWrapper, correlation Correlation) *gerr.GatewayDError {
+	client, ok := pr.busyConnections.Get(conn).(*Client)
+	if !ok || !client.IsConnected() {
+		return gerr.ErrClientNotConnected
+	}
+
+	if _, err := pr.sendTrafficToServer(client, PostgreSQLQuery("ROLLBACK"), correlation); err != nil {
+		return err
+	}
+	var response []byte
+	for PostgresTransactionStatus(response) == 0 {
+		_, received, _, err := pr.receiveTrafficFromServer(client, 0, correlation)
+		if err != nil {
+			return err
+		}
+		response = append(response, received...)
+	}
+	if message := PostgresErrorMessage(response); message != "" {
+		return gerr.ErrClientReceiveFailed.Wrap(errors.New(message))
+	}
+	return nil
+}
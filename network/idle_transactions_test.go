@@ -0,0 +1,134 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIdleTransactions(t *testing.T) {
+	disabled, err := NewIdleTransactions(config.IdleTransaction{})
+	require.Nil(t, err)
+	assert.Nil(t, disabled)
+
+	timeouts, err := NewIdleTransactions(config.IdleTransaction{
+		Databases: []config.IdleTransactionOverride{{Database: "analytics", Timeout: time.Minute}},
+	})
+	require.Nil(t, err)
+	require.NotNil(t, timeouts)
+	assert.Equal(t, config.Terminate, timeouts.Action)
+	assert.Equal(t, time.Minute, timeouts.TimeoutOf("analytics"))
+	assert.Zero(t, timeouts.TimeoutOf("app"))
+
+	_, err = NewIdleTransactions(config.IdleTransaction{Timeout: time.Second, Action: "kill"})
+	assert.NotNil(t, err)
+}
+
+// newIdleTransactionProxy returns a proxy with a session that has been idle in a
+// transaction for a minute, on a server connection to the database.
+func newIdleTransactionProxy(
+	t *testing.T, action config.IdleTransactionAction, database net.Listener,
+) (*Proxy, *ConnWrapper, net.Conn) {
+	t.Helper()
+	logger := zerolog.Nop()
+	ctx := context.Background()
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, 1),
+		plugin.NewRegistry(ctx, config.Loose, config.PassDown, config.Accept, config.Stop, logger, false),
+		false, false, config.DefaultHealthCheckPeriod, nil, logger, config.DefaultPluginTimeout)
+	t.Cleanup(proxy.Shutdown)
+	require.Nil(t, proxy.Configure(&config.Proxy{
+		IdleTransaction: config.IdleTransaction{
+			Timeout:   time.Second,
+			Action:    string(action),
+			Databases: []config.IdleTransactionOverride{{Database: "analytics", Timeout: 0}},
+		},
+	}))
+
+	client := NewClient(ctx, &config.Client{
+		Network:          "tcp",
+		Address:          database.Addr().String(),
+		ReceiveChunkSize: config.DefaultChunkSize,
+		DialTimeout:      config.DefaultDialTimeout,
+	}, logger, NewRetry(0, config.DefaultBackoff, config.DefaultBackoffMultiplier, false, logger))
+	require.NotNil(t, client)
+	t.Cleanup(client.Close)
+
+	incoming, outgoing := net.Pipe()
+	t.Cleanup(func() { outgoing.Close() })
+	conn := NewConnWrapper(incoming, nil, config.DefaultHandshakeTimeout)
+	t.Cleanup(func() { conn.Close() })
+	require.Nil(t, proxy.busyConnections.Put(conn, client))
+	state := newSession()
+	proxy.sessions.Store(conn, state)
+	proxy.parameters.Store(conn, map[string]string{"user": "app", "database": "app"})
+
+	proxy.recordUsage(conn, Ingress, PostgreSQLQuery("BEGIN"))
+	proxy.recordUsage(conn, Egress, CreatePostgreSQLPacket('Z', []byte{'T'}))
+	state.idleSince.Store(time.Now().Add(-time.Minute).UnixNano())
+	return proxy, conn, outgoing
+}
+
+// TestEndIdleTransactionsRollback tests that the idle transactions are rolled back,
+// and that the next request of their sessions fails.
+func TestEndIdleTransactionsRollback(t *testing.T) {
+	database, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer database.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		server, err := database.Accept()
+		if err != nil {
+			return
+		}
+		defer server.Close()
+		buffer := make([]byte, config.DefaultChunkSize)
+		read, err := server.Read(buffer)
+		if err != nil {
+			return
+		}
+		received <- buffer[:read]
+		_, _ = server.Write(append([]byte("C\x00\x00\x00\x0dROLLBACK\x00"), PostgreSQLReadyForQuery()...))
+	}()
+
+	proxy, conn, _ := newIdleTransactionProxy(t, config.Rollback, database)
+	proxy.EndIdleTransactions()
+	assert.Equal(t, PostgreSQLQuery("ROLLBACK"), <-received)
+	assert.True(t, proxy.startRequest(conn))
+	assert.False(t, proxy.startRequest(conn))
+
+	// The sessions of the databases whose timeout is disabled are left alone.
+	proxy.parameters.Store(conn, map[string]string{"user": "app", "database": "analytics"})
+	proxy.recordUsage(conn, Egress, CreatePostgreSQLPacket('Z', []byte{'T'}))
+	value, _ := proxy.sessions.Load(conn)
+	value.(*session).idleSince.Store(time.Now().Add(-time.Minute).UnixNano())
+	proxy.EndIdleTransactions()
+	assert.False(t, proxy.startRequest(conn))
+}
+
+// TestEndIdleTransactionsTerminate tests that the sessions idle in a transaction are
+// terminated with an error.
+func TestEndIdleTransactionsTerminate(t *testing.T) {
+	database, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer database.Close()
+
+	proxy, _, outgoing := newIdleTransactionProxy(t, config.Terminate, database)
+	response := make(chan []byte)
+	go func() {
+		buffer := make([]byte, config.DefaultChunkSize)
+		read, _ := outgoing.Read(buffer)
+		response <- buffer[:read]
+	}()
+	proxy.EndIdleTransactions()
+	assert.Equal(t, "terminating connection due to idle-in-transaction timeout",
+		PostgresErrorMessage(<-response))
+}
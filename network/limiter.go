@@ -0,0 +1,270 @@
+package network
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/policy"
+)
+
+type ILimiter interface {
+	Acquire(timeout time.Duration) *gerr.GatewayDError
+	AcquireClass(class int, timeout time.Duration) *gerr.GatewayDError
+	Release(latency time.Duration, dropped bool)
+	Limit() int
+	InFlight() int
+}
+
+// AdaptiveLimiter limits the number of requests in flight to the database using
+// AIMD (additive increase, multiplicative decrease). The limit grows by one for
+// every window of requests answered faster than the latency threshold, and is
+// cut by the backoff ratio whenever a request is slower than the threshold or
+// fails, so the gateway backs off as soon as the database saturates.
+type AdaptiveLimiter struct {
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	// waiters are the requests queued for a slot, by priority class and then in
+	// arrival order.
+	waiters []*waiter
+
+	MinLimit         int
+	MaxLimit         int
+	LatencyThreshold time.Duration
+	BackoffRatio     float64
+	// QueueSize is the maximum number of requests that wait for a slot when the
+	// limit is reached. Zero sheds the excess requests right away.
+	QueueSize int
+
+	// Classes are the names of the priority classes of the requests, from the highest
+	// to the lowest priority. The queued requests of a higher class get the free slots
+	// first, and take the place of the last queued request of a lower class when the
+	// queue is full. There is a single class if it is empty.
+	Classes []string
+	// ShedLowestClass sheds the excess requests of the lowest class right away,
+	// instead of queueing them.
+	ShedLowestClass bool
+}
+
+// waiter is a request queued for a slot.
+type waiter struct {
+	class int
+	ready chan struct{}
+	// shed is set when the request is removed from the queue without a slot, before
+	// ready is closed.
+	shed bool
+}
+
+var _ ILimiter = (*AdaptiveLimiter)(nil)
+
+// NewAdaptiveLimiter creates a new adaptive limiter. The initial limit is clamped
+// between the minimum and maximum limits.
+func NewAdaptiveLimiter(
+	initialLimit, minLimit, maxLimit int,
+	latencyThreshold time.Duration,
+	backoffRatio float64,
+	queueSize int,
+) *AdaptiveLimiter {
+	minLimit = max(minLimit, 1)
+	maxLimit = max(maxLimit, minLimit)
+
+	limiter := &AdaptiveLimiter{
+		limit:            float64(min(max(initialLimit, minLimit), maxLimit)),
+		MinLimit:         minLimit,
+		MaxLimit:         maxLimit,
+		LatencyThreshold: latencyThreshold,
+		BackoffRatio:     backoffRatio,
+		QueueSize:        queueSize,
+	}
+	metrics.ProxyConcurrencyLimit.Set(limiter.limit)
+
+	return limiter
+}
+
+// Acquire takes a slot for a request of the lowest priority class.
+func (l *AdaptiveLimiter) Acquire(timeout time.Duration) *gerr.GatewayDError {
+	return l.AcquireClass(l.lowestClass(), timeout)
+}
+
+// AcquireClass takes a slot for a request of the priority class, where zero is the
+// highest priority. If the limit is reached, the request waits in the queue for up to
+// the timeout, or is shed if the queue is full of requests of the same or a higher
+// class.
+func (l *AdaptiveLimiter) AcquireClass(class int, timeout time.Duration) *gerr.GatewayDError {
+	class = min(max(class, 0), l.lowestClass())
+
+	l.mu.Lock()
+	if l.inFlight < l.currentLimit() && len(l.waiters) == 0 {
+		l.inFlight++
+		metrics.ProxyInFlightRequests.Set(float64(l.inFlight))
+		l.mu.Unlock()
+		return nil
+	}
+
+	if l.ShedLowestClass && class == l.lowestClass() {
+		l.mu.Unlock()
+		return gerr.ErrConcurrencyLimitExceeded
+	}
+	if len(l.waiters) >= l.QueueSize {
+		// The last queued request of a lower class makes room for the request.
+		if len(l.waiters) == 0 || l.waiters[len(l.waiters)-1].class <= class {
+			l.mu.Unlock()
+			return gerr.ErrConcurrencyLimitExceeded
+		}
+		last := l.waiters[len(l.waiters)-1]
+		l.waiters = l.waiters[:len(l.waiters)-1]
+		l.dequeued(last)
+		last.shed = true
+		close(last.ready)
+	}
+
+	// The slot is handed over by Release, so inFlight is already counted
+	// for this request once the channel is closed.
+	queued := &waiter{class: class, ready: make(chan struct{})}
+	index := len(l.waiters)
+	for index > 0 && l.waiters[index-1].class > class {
+		index--
+	}
+	l.waiters = append(l.waiters[:index], append([]*waiter{queued}, l.waiters[index:]...)...)
+	metrics.ProxyLimiterQueueDepth.WithLabelValues(l.className(class)).Inc()
+	l.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-queued.ready:
+		if queued.shed {
+			return gerr.ErrConcurrencyLimitExceeded
+		}
+		return nil
+	case <-timer.C:
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for index, other := range l.waiters {
+			if other == queued {
+				l.waiters = append(l.waiters[:index], l.waiters[index+1:]...)
+				l.dequeued(queued)
+				return gerr.ErrConcurrencyLimitExceeded
+			}
+		}
+		// The slot was handed over, or the request was shed, just as the wait
+		// timed out.
+		if queued.shed {
+			return gerr.ErrConcurrencyLimitExceeded
+		}
+		return nil
+	}
+}
+
+// Release frees the slot taken by Acquire and adjusts the limit based on the
+// latency of the request. Dropped requests, e.g. failed ones, back off the limit.
+func (l *AdaptiveLimiter) Release(latency time.Duration, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if dropped || (l.LatencyThreshold > 0 && latency > l.LatencyThreshold) {
+		l.limit = math.Max(float64(l.MinLimit), l.limit*l.BackoffRatio)
+	} else {
+		l.limit = math.Min(float64(l.MaxLimit), l.limit+1/l.limit)
+	}
+	metrics.ProxyConcurrencyLimit.Set(l.limit)
+
+	// Hand over the free slots to the queued requests.
+	for len(l.waiters) > 0 && l.inFlight < l.currentLimit() {
+		l.inFlight++
+		l.dequeued(l.waiters[0])
+		close(l.waiters[0].ready)
+		l.waiters = l.waiters[1:]
+	}
+	metrics.ProxyInFlightRequests.Set(float64(l.inFlight))
+}
+
+// Limit returns the current concurrency limit.
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.currentLimit()
+}
+
+// InFlight returns the number of requests holding a slot.
+func (l *AdaptiveLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// Queued returns the number of the requests waiting for a slot.
+func (l *AdaptiveLimiter) Queued() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.waiters)
+}
+
+// lowestClass returns the index of the priority class with the lowest priority.
+func (l *AdaptiveLimiter) lowestClass() int {
+	return max(len(l.Classes)-1, 0)
+}
+
+// className returns the name of the priority class, for the metrics.
+func (l *AdaptiveLimiter) className(class int) string {
+	if class < len(l.Classes) {
+		return l.Classes[class]
+	}
+	return config.DefaultPriorityClass
+}
+
+// dequeued counts the request out of the queue depth of its class.
+// The caller must hold the lock.
+func (l *AdaptiveLimiter) dequeued(queued *waiter) {
+	metrics.ProxyLimiterQueueDepth.WithLabelValues(l.className(queued.class)).Dec()
+}
+
+// currentLimit returns the limit as a whole number of requests.
+// The caller must hold the lock.
+func (l *AdaptiveLimiter) currentLimit() int {
+	return max(int(l.limit), l.MinLimit)
+}
+
+// PriorityField is the field of the result of the OnTrafficFromClient hooks that holds
+// the name of the priority class of the request.
+const PriorityField = "priority"
+
+// PriorityClass is a class of the queries queued by the limiter, which the queries its
+// policy matches belong to. The queries are only put in the classes without a policy
+// by the hooks.
+type PriorityClass struct {
+	Name   string
+	Policy *policy.Program
+}
+
+// NewPriorityClasses compiles the policies of the priority classes. The names must be
+// unique, and the default class is implicit.
+func NewPriorityClasses(classes []config.PriorityClass) ([]*PriorityClass, *gerr.GatewayDError) {
+	compiled := make([]*PriorityClass, 0, len(classes))
+	seen := map[string]bool{config.DefaultPriorityClass: true}
+	for _, class := range classes {
+		if class.Name == "" || seen[class.Name] {
+			return nil, gerr.ErrInvalidPriorityClass.Wrap(
+				fmt.Errorf("the name %q is empty, reserved or used twice", class.Name))
+		}
+		seen[class.Name] = true
+
+		priorityClass := &PriorityClass{Name: class.Name}
+		if class.When != "" {
+			program, err := policy.Compile(class.When)
+			if err != nil {
+				return nil, gerr.ErrInvalidPriorityClass.Wrap(err)
+			}
+			priorityClass.Policy = program
+		}
+		compiled = append(compiled, priorityClass)
+	}
+	return compiled, nil
+}
@@ -0,0 +1,152 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdaptiveLimiter tests that the limit grows with fast requests and backs off
+// with slow or dropped requests.
+func TestAdaptiveLimiter(t *testing.T) {
+	limiter := NewAdaptiveLimiter(2, 1, 3, 100*time.Millisecond, 0.5, 0)
+	assert.Equal(t, 2, limiter.Limit())
+
+	assert.Nil(t, limiter.Acquire(time.Second))
+	assert.Nil(t, limiter.Acquire(time.Second))
+	assert.Equal(t, 2, limiter.InFlight())
+	// The limit is reached and there is no queue, so the request is shed.
+	assert.ErrorIs(t, limiter.Acquire(time.Second), gerr.ErrConcurrencyLimitExceeded)
+
+	// A window of fast requests grows the limit by about one.
+	limiter.Release(time.Millisecond, false)
+	limiter.Release(time.Millisecond, false)
+	assert.Equal(t, 0, limiter.InFlight())
+	assert.Equal(t, 2, limiter.Limit())
+	assert.Nil(t, limiter.Acquire(time.Second))
+	limiter.Release(time.Millisecond, false)
+	assert.Equal(t, 3, limiter.Limit())
+
+	// The limit never exceeds the maximum.
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, limiter.Acquire(time.Second))
+		limiter.Release(time.Millisecond, false)
+	}
+	assert.Equal(t, 3, limiter.Limit())
+
+	// A slow request cuts the limit.
+	assert.Nil(t, limiter.Acquire(time.Second))
+	limiter.Release(time.Second, false)
+	assert.Equal(t, 1, limiter.Limit())
+
+	// A dropped request cuts the limit, but never below the minimum.
+	assert.Nil(t, limiter.Acquire(time.Second))
+	limiter.Release(time.Millisecond, true)
+	assert.Equal(t, 1, limiter.Limit())
+}
+
+// TestAdaptiveLimiterQueue tests that the excess requests wait for a slot and are
+// rejected when the queue is full or the wait times out.
+func TestAdaptiveLimiterQueue(t *testing.T) {
+	limiter := NewAdaptiveLimiter(1, 1, 1, 100*time.Millisecond, 0.5, 1)
+	assert.Nil(t, limiter.Acquire(time.Second))
+
+	acquired := make(chan *gerr.GatewayDError)
+	go func() {
+		acquired <- limiter.Acquire(time.Second)
+	}()
+	assert.Eventually(t, func() bool {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		return len(limiter.waiters) == 1
+	}, time.Second, time.Millisecond)
+
+	// The queue is full.
+	assert.ErrorIs(t, limiter.Acquire(time.Second), gerr.ErrConcurrencyLimitExceeded)
+
+	// The slot is handed over to the queued request.
+	limiter.Release(time.Millisecond, false)
+	assert.Nil(t, <-acquired)
+	assert.Equal(t, 1, limiter.InFlight())
+
+	// Nothing is released, so the wait times out.
+	assert.ErrorIs(t, limiter.Acquire(10*time.Millisecond), gerr.ErrConcurrencyLimitExceeded)
+	assert.Equal(t, 1, limiter.InFlight())
+}
+
+// TestAdaptiveLimiterPriorityClasses tests that the queued requests of the higher
+// classes get the free slots first, and push out the lower classes when the queue is
+// full.
+func TestAdaptiveLimiterPriorityClasses(t *testing.T) {
+	limiter := NewAdaptiveLimiter(1, 1, 1, time.Second, 0.5, 2)
+	limiter.Classes = []string{"interactive", "batch", "default"}
+	assert.Nil(t, limiter.Acquire(time.Second))
+
+	acquire := func(class int) chan *gerr.GatewayDError {
+		acquired := make(chan *gerr.GatewayDError, 1)
+		queued := limiter.Queued()
+		go func() {
+			acquired <- limiter.AcquireClass(class, time.Second)
+		}()
+		assert.Eventually(t, func() bool { return limiter.Queued() == queued+1 }, time.Second, time.Millisecond)
+		return acquired
+	}
+	lowest := acquire(2)
+	batch := acquire(1)
+
+	// The queue is full, so the request of the lowest class makes room.
+	interactive := make(chan *gerr.GatewayDError, 1)
+	go func() {
+		interactive <- limiter.AcquireClass(0, time.Second)
+	}()
+	assert.ErrorIs(t, <-lowest, gerr.ErrConcurrencyLimitExceeded)
+	// The queue is full of the requests of higher classes.
+	assert.ErrorIs(t, limiter.AcquireClass(2, time.Second), gerr.ErrConcurrencyLimitExceeded)
+
+	// The request of the higher class gets the slot first.
+	assert.Eventually(t, func() bool { return limiter.Queued() == 2 }, time.Second, time.Millisecond)
+	limiter.Release(time.Millisecond, false)
+	assert.Nil(t, <-interactive)
+	limiter.Release(time.Millisecond, false)
+	assert.Nil(t, <-batch)
+
+	// The excess requests of the lowest class are shed right away.
+	limiter.ShedLowestClass = true
+	start := time.Now()
+	assert.ErrorIs(t, limiter.Acquire(time.Second), gerr.ErrConcurrencyLimitExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestPriorityClasses(t *testing.T) {
+	classes, err := NewPriorityClasses([]config.PriorityClass{
+		{Name: "interactive", When: `request.application == "web"`},
+		{Name: "batch"},
+	})
+	require.Nil(t, err)
+	proxy := &Proxy{PriorityClasses: classes}
+	conn := NewConnWrapper(nil, nil, 0)
+	proxy.parameters.Store(conn, map[string]string{"application_name": "web"})
+
+	request := PostgreSQLQuery("SELECT 1")
+	assert.Equal(t, 0, proxy.priorityClass(conn, request, nil))
+	assert.Equal(t, 1, proxy.priorityClass(conn, request, &v1.Struct{
+		Fields: map[string]*v1.Value{PriorityField: v1.NewStringValue("batch")},
+	}))
+	proxy.parameters.Store(conn, map[string]string{"application_name": "cron"})
+	assert.Equal(t, 2, proxy.priorityClass(conn, request, nil))
+
+	for _, invalid := range [][]config.PriorityClass{
+		{{Name: ""}},
+		{{Name: config.DefaultPriorityClass}},
+		{{Name: "batch"}, {Name: "batch"}},
+		{{Name: "broken", When: "request.user =="}},
+	} {
+		_, err := NewPriorityClasses(invalid)
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,113 @@
+package network
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/metrics"
+)
+
+// DefaultMaintenanceMessage is the message the new connections are rejected with
+// during maintenance, if none is given.
+const DefaultMaintenanceMessage = "the database is under maintenance, please try again later"
+
+// MaintenanceStatus is the status of the maintenance mode.
+type MaintenanceStatus struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+	// Queue holds the new connections until the maintenance ends, instead of
+	// rejecting them, for up to QueueTimeout if it isn't zero.
+	Queue        bool          `json:"queue"`
+	QueueTimeout time.Duration `json:"queueTimeout"`
+	Queued       int           `json:"queued"`
+}
+
+// Maintenance rejects or queues the new connections of the servers while it is
+// enabled. The existing connections are kept.
+type Maintenance struct {
+	mu      sync.Mutex
+	status  MaintenanceStatus
+	resumed chan struct{}
+}
+
+// NewMaintenance creates a new maintenance mode, which is disabled.
+func NewMaintenance() *Maintenance {
+	return &Maintenance{resumed: make(chan struct{})}
+}
+
+// Enable enables the maintenance mode, or updates it if it is already enabled.
+func (m *Maintenance) Enable(message string, queue bool, queueTimeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if message == "" {
+		message = DefaultMaintenanceMessage
+	}
+	if !m.status.Enabled {
+		m.resumed = make(chan struct{})
+	}
+	m.status.Enabled = true
+	m.status.Message = message
+	m.status.Queue = queue
+	m.status.QueueTimeout = queueTimeout
+}
+
+// Disable disables the maintenance mode, which lets the queued connections through.
+func (m *Maintenance) Disable() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.status.Enabled {
+		close(m.resumed)
+	}
+	m.status = MaintenanceStatus{Queued: m.status.Queued}
+}
+
+// Status returns the status of the maintenance mode.
+func (m *Maintenance) Status() MaintenanceStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.status
+}
+
+// Admit waits until a new connection is let through. It returns false and the
+// message to reject the connection with if the maintenance mode is enabled and
+// doesn't queue the connections, or if the connection timed out in the queue.
+func (m *Maintenance) Admit() (bool, string) {
+	m.mu.Lock()
+	status, resumed := m.status, m.resumed
+	if !status.Enabled {
+		m.mu.Unlock()
+		return true, ""
+	}
+	if !status.Queue {
+		m.mu.Unlock()
+		metrics.MaintenanceRejectedConnections.Inc()
+		return false, status.Message
+	}
+	m.status.Queued++
+	m.mu.Unlock()
+	metrics.MaintenanceQueuedConnections.Inc()
+
+	defer func() {
+		m.mu.Lock()
+		m.status.Queued--
+		m.mu.Unlock()
+		metrics.MaintenanceQueuedConnections.Dec()
+	}()
+
+	var timeout <-chan time.Time
+	if status.QueueTimeout > 0 {
+		timer := time.NewTimer(status.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-resumed:
+		return true, ""
+	case <-timeout:
+		metrics.MaintenanceRejectedConnections.Inc()
+		return false, status.Message
+	}
+}
@@ -0,0 +1,46 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMaintenance tests that the new connections are rejected or queued during
+// maintenance, and that the queued ones are let through once it ends.
+func TestMaintenance(t *testing.T) {
+	maintenance := NewMaintenance()
+	admitted, _ := maintenance.Admit()
+	assert.True(t, admitted)
+
+	maintenance.Enable("", false, 0)
+	admitted, message := maintenance.Admit()
+	assert.False(t, admitted)
+	assert.Equal(t, DefaultMaintenanceMessage, message)
+
+	maintenance.Enable("upgrading to PostgreSQL 16", true, 0)
+	results := make(chan bool)
+	go func() {
+		admitted, _ := maintenance.Admit()
+		results <- admitted
+	}()
+	assert.Eventually(t, func() bool {
+		return maintenance.Status().Queued == 1
+	}, time.Second, time.Millisecond)
+	status := maintenance.Status()
+	assert.True(t, status.Enabled)
+	assert.True(t, status.Queue)
+	assert.Equal(t, "upgrading to PostgreSQL 16", status.Message)
+
+	maintenance.Disable()
+	assert.True(t, <-results)
+	assert.Equal(t, MaintenanceStatus{}, maintenance.Status())
+
+	// The queued connections are rejected after the queue timeout.
+	maintenance.Enable("upgrading to PostgreSQL 16", true, 10*time.Millisecond)
+	admitted, message = maintenance.Admit()
+	assert.False(t, admitted)
+	assert.Equal(t, "upgrading to PostgreSQL 16", message)
+	maintenance.Disable()
+}
@@ -0,0 +1,31 @@
+package network
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog"
+)
+
+// recoverPanic runs the function and returns a panic in it as an error, so that a bug
+// in serving a connection only closes that connection instead of crashing GatewayD.
+func recoverPanic(logger zerolog.Logger, serve func() *gerr.GatewayDError) (err *gerr.GatewayDError) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			metrics.ProxyPanics.Inc()
+			sentry.CurrentHub().Recover(recovered)
+			logger.Error().Fields(
+				map[string]interface{}{
+					"panic": fmt.Sprint(recovered),
+					"stack": string(debug.Stack()),
+				},
+			).Msg("Recovered from a panic while serving the connection")
+			err = gerr.ErrConnectionPanicked.Wrap(fmt.Errorf("%v", recovered))
+		}
+	}()
+
+	return serve()
+}
@@ -0,0 +1,29 @@
+package network
+
+import (
+	"testing"
+
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRecoverPanic tests that a panic while serving a connection is returned as an error.
+func TestRecoverPanic(t *testing.T) {
+	logger := zerolog.Nop()
+	panics := testutil.ToFloat64(metrics.ProxyPanics)
+
+	assert.Nil(t, recoverPanic(logger, func() *gerr.GatewayDError { return nil }))
+	assert.Equal(t, gerr.ErrClientNotFound, recoverPanic(logger, func() *gerr.GatewayDError {
+		return gerr.ErrClientNotFound
+	}))
+
+	err := recoverPanic(logger, func() *gerr.GatewayDError {
+		panic("bug")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, gerr.ErrCodeConnectionPanicked, err.Code)
+	assert.Equal(t, panics+1, testutil.ToFloat64(metrics.ProxyPanics))
+}
@@ -0,0 +1,405 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	// SQLStateTooManyConnections is the SQLSTATE returned by PostgreSQL when
+	// it cannot accept more connections.
+	SQLStateTooManyConnections = "53300"
+	// SQLStateInsufficientResources is the SQLSTATE returned by PostgreSQL when
+	// it runs out of resources to process the query.
+	SQLStateInsufficientResources = "53000"
+	// SQLStateProgramLimitExceeded is the SQLSTATE returned by PostgreSQL when
+	// a message or value exceeds its limits.
+	SQLStateProgramLimitExceeded = "54000"
+	// SQLStateCannotConnectNow is the SQLSTATE returned by PostgreSQL when
+	// it doesn't accept connections, e.g. while it is starting up.
+	SQLStateCannotConnectNow = "57P03"
+	// SQLStateInsufficientPrivilege is the SQLSTATE returned by PostgreSQL when
+	// the user isn't allowed to run the query.
+	SQLStateInsufficientPrivilege = "42501"
+	// SQLStateFeatureNotSupported is the SQLSTATE returned by PostgreSQL when
+	// the query uses a feature it doesn't support.
+	SQLStateFeatureNotSupported = "0A000"
+	// SQLStateIdleInTransactionTimeout is the SQLSTATE returned by PostgreSQL when
+	// it terminates a session that is idle in a transaction for too long.
+	SQLStateIdleInTransactionTimeout = "25P03"
+	// SQLStateOutOfMemory is the SQLSTATE returned by PostgreSQL when it runs out of
+	// memory.
+	SQLStateOutOfMemory = "53200"
+)
+
+// PostgreSQLErrorResponse creates a PostgreSQL ErrorResponse message with the
+// given severity (e.g. FATAL), SQLSTATE code and message, that clients show
+// to the user as is.
+//
+//nolint:gomnd
+func PostgreSQLErrorResponse(severity, code, message string) []byte {
+	fields := []struct {
+		typ   byte
+		value string
+	}{
+		{'S', severity},
+		{'V', severity},
+		{'C', code},
+		{'M', message},
+	}
+
+	response := []byte{'E', 0, 0, 0, 0}
+	for _, field := range fields {
+		response = append(response, field.typ)
+		response = append(response, field.value...)
+		response = append(response, 0)
+	}
+	// The message ends with a zero byte.
+	response = append(response, 0)
+	binary.BigEndian.PutUint32(response[1:5], uint32(len(response)-1))
+
+	return response
+}
+
+// PostgresStartupParameters returns the parameters of a PostgreSQL StartupMessage,
+// e.g. user and database, or nil if the data is not a StartupMessage.
+//
+//nolint:gomnd
+func PostgresStartupParameters(data []byte) map[string]string {
+	if len(data) < 8 || int(binary.BigEndian.Uint32(data[0:4])) != len(data) {
+		return nil
+	}
+
+	// Protocol version 3.0.
+	if binary.BigEndian.Uint32(data[4:8]) != 196608 {
+		return nil
+	}
+
+	parameters := map[string]string{}
+	fields := bytes.Split(data[8:], []byte{0})
+	for index := 0; index+1 < len(fields); index += 2 {
+		if len(fields[index]) == 0 {
+			break
+		}
+		parameters[string(fields[index])] = string(fields[index+1])
+	}
+
+	return parameters
+}
+
+// RemovePostgresStartupParameter returns the StartupMessage without the given
+// parameter, or the data as is if it is not a StartupMessage or has no such parameter.
+//
+//nolint:gomnd
+func RemovePostgresStartupParameter(data []byte, name string) []byte {
+	if _, ok := PostgresStartupParameters(data)[name]; !ok {
+		return data
+	}
+
+	message := []byte{0, 0, 0, 0}
+	message = append(message, data[4:8]...)
+	fields := bytes.Split(data[8:], []byte{0})
+	for index := 0; index+1 < len(fields); index += 2 {
+		if len(fields[index]) == 0 {
+			break
+		}
+		if string(fields[index]) == name {
+			continue
+		}
+		message = append(message, fields[index]...)
+		message = append(message, 0)
+		message = append(message, fields[index+1]...)
+		message = append(message, 0)
+	}
+	// The message ends with a zero byte.
+	message = append(message, 0)
+	binary.BigEndian.PutUint32(message[0:4], uint32(len(message)))
+
+	return message
+}
+
+// SetPostgresStartupParameter returns the StartupMessage with the parameter set to the
+// value, replacing its current value or adding it after the others, or the data as is
+// if it is not a StartupMessage.
+//
+//nolint:gomnd
+func SetPostgresStartupParameter(data []byte, name, value string) []byte {
+	if PostgresStartupParameters(data) == nil {
+		return data
+	}
+
+	message := []byte{0, 0, 0, 0}
+	message = append(message, data[4:8]...)
+	found := false
+	fields := bytes.Split(data[8:], []byte{0})
+	for index := 0; index+1 < len(fields); index += 2 {
+		if len(fields[index]) == 0 {
+			break
+		}
+		message = append(message, fields[index]...)
+		message = append(message, 0)
+		if string(fields[index]) == name {
+			message = append(message, value...)
+			found = true
+		} else {
+			message = append(message, fields[index+1]...)
+		}
+		message = append(message, 0)
+	}
+	if !found {
+		message = append(message, name...)
+		message = append(message, 0)
+		message = append(message, value...)
+		message = append(message, 0)
+	}
+	// The message ends with a zero byte.
+	message = append(message, 0)
+	binary.BigEndian.PutUint32(message[0:4], uint32(len(message)))
+
+	return message
+}
+
+// PostgreSQLParameterStatus creates a PostgreSQL ParameterStatus message, which
+// tells the client the value of a parameter.
+//
+//nolint:gomnd
+func PostgreSQLParameterStatus(name, value string) []byte {
+	message := []byte{'S', 0, 0, 0, 0}
+	message = append(message, name...)
+	message = append(message, 0)
+	message = append(message, value...)
+	message = append(message, 0)
+	binary.BigEndian.PutUint32(message[1:5], uint32(len(message)-1))
+
+	return message
+}
+
+// PostgreSQLReadyForQuery creates a PostgreSQL ReadyForQuery message for an
+// idle session, which tells the client that it can send the next query.
+//
+//nolint:gomnd
+func PostgreSQLReadyForQuery() []byte {
+	return []byte{'Z', 0, 0, 0, 5, 'I'}
+}
+
+// PostgreSQLSSLRequest creates a PostgreSQL SSLRequest message, which asks the
+// server to upgrade the connection to TLS.
+//
+//nolint:gomnd
+func PostgreSQLSSLRequest() []byte {
+	return []byte{0, 0, 0, 8, 0x04, 0xd2, 0x16, 0x2f}
+}
+
+// IsPostgresCleartextPasswordRequest checks if the message is an
+// AuthenticationCleartextPassword message, which asks the client for its password.
+//
+//nolint:gomnd
+func IsPostgresCleartextPasswordRequest(data []byte) bool {
+	return len(data) == 9 && data[0] == 'R' &&
+		binary.BigEndian.Uint32(data[1:5]) == 8 && binary.BigEndian.Uint32(data[5:9]) == 3
+}
+
+// PostgreSQLPasswordMessage creates a PostgreSQL PasswordMessage, which answers the
+// password request of the server.
+//
+//nolint:gomnd
+func PostgreSQLPasswordMessage(password string) []byte {
+	message := []byte{'p', 0, 0, 0, 0}
+	message = append(message, password...)
+	message = append(message, 0)
+	binary.BigEndian.PutUint32(message[1:5], uint32(len(message)-1))
+
+	return message
+}
+
+// IsPostgresQuery checks if the message starts a query, either with the simple
+// query protocol (Query) or the extended query protocol (Parse, Bind or Execute).
+func IsPostgresQuery(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+
+	switch data[0] {
+	case 'Q', 'P', 'B', 'E':
+		return true
+	default:
+		return false
+	}
+}
+
+// PostgresQueries returns the SQL of the Query and Parse messages in the request.
+//
+//nolint:gomnd
+func PostgresQueries(request []byte) []string {
+	var queries []string
+	for offset := 0; offset+5 <= len(request); {
+		length := int(binary.BigEndian.Uint32(request[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(request) {
+			break
+		}
+		body := request[offset+5 : offset+1+length]
+		switch request[offset] {
+		case 'Q':
+			queries = append(queries, string(bytes.TrimRight(body, "\x00")))
+		case 'P':
+			// The name of the prepared statement comes before the query.
+			if name := bytes.IndexByte(body, 0); name >= 0 {
+				query := body[name+1:]
+				if end := bytes.IndexByte(query, 0); end >= 0 {
+					queries = append(queries, string(query[:end]))
+				}
+			}
+		}
+		offset += 1 + length
+	}
+	return queries
+}
+
+// PostgresTransactionStatus returns the transaction status of the last ReadyForQuery
+// message in the response, which is 'I' if the session is idle, 'T' if it is in a
+// transaction and 'E' if it is in a failed transaction, or zero if there is none.
+//
+//nolint:gomnd
+func PostgresTransactionStatus(response []byte) byte {
+	var status byte
+	for offset := 0; offset+5 <= len(response); {
+		length := int(binary.BigEndian.Uint32(response[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(response) {
+			break
+		}
+		if response[offset] == 'Z' && length == 5 {
+			status = response[offset+5]
+		}
+		offset += 1 + length
+	}
+	return status
+}
+
+// PostgreSQLQuery creates a PostgreSQL Query message, which runs the query with the
+// simple query protocol.
+//
+//nolint:gomnd
+func PostgreSQLQuery(query string) []byte {
+	message := []byte{'Q', 0, 0, 0, 0}
+	message = append(message, query...)
+	message = append(message, 0)
+	binary.BigEndian.PutUint32(message[1:5], uint32(len(message)-1))
+
+	return message
+}
+
+// PostgresMessagesLength returns the length of the complete messages at the start of
+// the data, without the message that is cut off at its end, if any.
+//
+//nolint:gomnd
+func PostgresMessagesLength(data []byte) int {
+	offset := 0
+	for offset+5 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(data) {
+			break
+		}
+		offset += 1 + length
+	}
+	return offset
+}
+
+// PostgresErrorMessage returns the message of the first ErrorResponse message in the
+// response, or an empty string if there is none.
+//
+//nolint:gomnd
+func PostgresErrorMessage(response []byte) string {
+	for offset := 0; offset+5 <= len(response); {
+		length := int(binary.BigEndian.Uint32(response[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(response) {
+			break
+		}
+		if response[offset] == 'E' {
+			// The fields are a type byte followed by a null-terminated value.
+			for _, field := range bytes.Split(response[offset+5:offset+1+length], []byte{0}) {
+				if len(field) > 0 && field[0] == 'M' {
+					return string(field[1:])
+				}
+			}
+		}
+		offset += 1 + length
+	}
+	return ""
+}
+
+// PostgresErrorCodes returns the SQLSTATE codes of the ErrorResponse messages in the
+// response, in order.
+//
+//nolint:gomnd
+func PostgresErrorCodes(response []byte) []string {
+	var codes []string
+	for offset := 0; offset+5 <= len(response); {
+		length := int(binary.BigEndian.Uint32(response[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(response) {
+			break
+		}
+		if response[offset] == 'E' {
+			for _, field := range bytes.Split(response[offset+5:offset+1+length], []byte{0}) {
+				if len(field) > 0 && field[0] == 'C' {
+					codes = append(codes, string(field[1:]))
+					break
+				}
+			}
+		}
+		offset += 1 + length
+	}
+	return codes
+}
+
+// PostgresAuthenticationRequest returns the type of the first Authentication message
+// in the response, which is zero for AuthenticationOk and 3 for a cleartext password
+// request, or -1 if there is none.
+//
+//nolint:gomnd
+func PostgresAuthenticationRequest(response []byte) int {
+	for offset := 0; offset+5 <= len(response); {
+		length := int(binary.BigEndian.Uint32(response[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(response) {
+			break
+		}
+		if response[offset] == 'R' && length >= 8 {
+			return int(binary.BigEndian.Uint32(response[offset+5 : offset+9]))
+		}
+		offset += 1 + length
+	}
+	return -1
+}
+
+// PostgresBackendKeyData returns the process ID and the secret key of the
+// BackendKeyData message in the response, which the server sends once the session
+// starts, and false if there is none.
+//
+//nolint:gomnd
+func PostgresBackendKeyData(response []byte) (uint32, uint32, bool) {
+	for offset := 0; offset+5 <= len(response); {
+		length := int(binary.BigEndian.Uint32(response[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(response) {
+			break
+		}
+		if response[offset] == 'K' && length == 12 {
+			return binary.BigEndian.Uint32(response[offset+5 : offset+9]),
+				binary.BigEndian.Uint32(response[offset+9 : offset+13]), true
+		}
+		offset += 1 + length
+	}
+	return 0, 0, false
+}
+
+// PostgreSQLCancelRequest creates a PostgreSQL CancelRequest message, which asks the
+// server to cancel the query in flight of the session with the process ID and the
+// secret key, over a new connection.
+//
+//nolint:gomnd
+func PostgreSQLCancelRequest(processID, secretKey uint32) []byte {
+	message := make([]byte, 16)
+	binary.BigEndian.PutUint32(message[0:4], 16)
+	binary.BigEndian.PutUint32(message[4:8], 80877102)
+	binary.BigEndian.PutUint32(message[8:12], processID)
+	binary.BigEndian.PutUint32(message[12:16], secretKey)
+	return message
+}
@@ -0,0 +1,176 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPostgreSQLErrorResponse tests that the ErrorResponse message is encoded correctly.
+func TestPostgreSQLErrorResponse(t *testing.T) {
+	response := PostgreSQLErrorResponse(
+		"FATAL", SQLStateTooManyConnections, "sorry, too many clients already")
+
+	assert.Equal(t, byte('E'), response[0])
+	assert.Equal(t, uint32(len(response)-1), binary.BigEndian.Uint32(response[1:5]))
+	assert.Equal(t, byte(0), response[len(response)-1])
+
+	fields := bytes.Split(response[5:len(response)-2], []byte{0})
+	assert.Equal(t, [][]byte{
+		[]byte("SFATAL"),
+		[]byte("VFATAL"),
+		[]byte("C53300"),
+		[]byte("Msorry, too many clients already"),
+	}, fields)
+}
+
+// TestIsPostgresQuery tests that only the messages that start a query are detected.
+func TestIsPostgresQuery(t *testing.T) {
+	assert.True(t, IsPostgresQuery([]byte("Q\x00\x00\x00\x0dSELECT 1;\x00")))
+	assert.True(t, IsPostgresQuery([]byte{'P', 0, 0, 0, 4}))
+	assert.False(t, IsPostgresQuery([]byte{'X', 0, 0, 0, 4}))
+	assert.False(t, IsPostgresQuery(nil))
+}
+
+// TestPostgresStartupParameters tests that the parameters are extracted from
+// the startup message.
+func TestPostgresStartupParameters(t *testing.T) {
+	startup := CreatePgStartupPacket()
+	parameters := PostgresStartupParameters(startup)
+	assert.Equal(t, "postgres", parameters["user"])
+	assert.Equal(t, "postgres", parameters["database"])
+
+	assert.Nil(t, PostgresStartupParameters([]byte{'Q', 0, 0, 0, 4}))
+}
+
+// TestRemovePostgresStartupParameter tests that the parameter is removed from the
+// startup message, and that the other messages are returned as is.
+func TestRemovePostgresStartupParameter(t *testing.T) {
+	startup := CreatePgStartupPacket()
+	withCompression := append([]byte{}, startup[:len(startup)-1]...)
+	withCompression = append(withCompression, CompressionParameter+"\x00zlib\x00\x00"...)
+	binary.BigEndian.PutUint32(withCompression[0:4], uint32(len(withCompression)))
+	assert.Equal(t, "zlib", PostgresStartupParameters(withCompression)[CompressionParameter])
+
+	assert.Equal(t, startup, RemovePostgresStartupParameter(withCompression, CompressionParameter))
+	assert.Equal(t, startup, RemovePostgresStartupParameter(startup, CompressionParameter))
+	query := []byte{'Q', 0, 0, 0, 4}
+	assert.Equal(t, query, RemovePostgresStartupParameter(query, CompressionParameter))
+}
+
+// TestSetPostgresStartupParameter tests that the parameter is replaced or added to the
+// startup message, and that the other messages are returned as is.
+func TestSetPostgresStartupParameter(t *testing.T) {
+	startup := CreatePgStartupPacket()
+	parameters := PostgresStartupParameters(startup)
+
+	renamed := SetPostgresStartupParameter(startup, "application_name", "psql")
+	assert.Equal(t, "psql", PostgresStartupParameters(renamed)["application_name"])
+	assert.Len(t, PostgresStartupParameters(renamed), len(parameters))
+
+	withPath := SetPostgresStartupParameter(startup, "search_path", "public")
+	assert.Equal(t, "public", PostgresStartupParameters(withPath)["search_path"])
+	assert.Equal(t, "postgres", PostgresStartupParameters(withPath)["user"])
+	assert.Equal(t, startup, RemovePostgresStartupParameter(withPath, "search_path"))
+
+	query := []byte{'Q', 0, 0, 0, 4}
+	assert.Equal(t, query, SetPostgresStartupParameter(query, "application_name", "psql"))
+}
+
+// TestPostgreSQLParameterStatus tests creating a ParameterStatus message.
+func TestPostgreSQLParameterStatus(t *testing.T) {
+	assert.Equal(t,
+		[]byte("S\x00\x00\x00\x1a_pq_.compression\x00zlib\x00"),
+		PostgreSQLParameterStatus(CompressionParameter, "zlib"))
+}
+
+// TestPostgresTransactionStatus tests that the status of the last ReadyForQuery
+// message is returned.
+func TestPostgresTransactionStatus(t *testing.T) {
+	commandComplete := []byte("C\x00\x00\x00\x0bCOMMIT\x00")
+	inTransaction := []byte{'Z', 0, 0, 0, 5, 'T'}
+
+	assert.Equal(t, byte('I'), PostgresTransactionStatus(
+		append(append([]byte{}, commandComplete...), PostgreSQLReadyForQuery()...)))
+	assert.Equal(t, byte('T'), PostgresTransactionStatus(
+		append(append([]byte{}, PostgreSQLReadyForQuery()...), inTransaction...)))
+	assert.Equal(t, byte(0), PostgresTransactionStatus(commandComplete))
+	// Incomplete messages are ignored.
+	assert.Equal(t, byte(0), PostgresTransactionStatus(inTransaction[:5]))
+	assert.Equal(t, byte(0), PostgresTransactionStatus(nil))
+}
+
+// TestPostgreSQLPasswordMessage tests that the password requests are detected, and
+// the PasswordMessage is encoded correctly.
+func TestPostgreSQLPasswordMessage(t *testing.T) {
+	assert.True(t, IsPostgresCleartextPasswordRequest([]byte{'R', 0, 0, 0, 8, 0, 0, 0, 3}))
+	// AuthenticationMD5Password and AuthenticationOk.
+	assert.False(t, IsPostgresCleartextPasswordRequest([]byte{'R', 0, 0, 0, 12, 0, 0, 0, 5, 1, 2, 3, 4}))
+	assert.False(t, IsPostgresCleartextPasswordRequest([]byte{'R', 0, 0, 0, 8, 0, 0, 0, 0}))
+
+	assert.Equal(t, []byte("p\x00\x00\x00\x0bsecret\x00"), PostgreSQLPasswordMessage("secret"))
+}
+
+// TestPostgresQueries tests that the queries are extracted from the simple and
+// extended query messages.
+func TestPostgresQueries(t *testing.T) {
+	parse := []byte{'P', 0, 0, 0, 0}
+	parse = append(parse, "stmt\x00SELECT $1\x00\x00\x00"...)
+	binary.BigEndian.PutUint32(parse[1:5], uint32(len(parse)-1))
+	request := append([]byte("Q\x00\x00\x00\x0eSELECT 1;\x00"), parse...)
+	request = append(request, 'S', 0, 0, 0, 4)
+
+	assert.Equal(t, []string{"SELECT 1;", "SELECT $1"}, PostgresQueries(request))
+	assert.Empty(t, PostgresQueries([]byte{'X', 0, 0, 0, 4}))
+	assert.Empty(t, PostgresQueries(nil))
+}
+
+// TestPostgresErrorMessage tests that the message of the error response is returned.
+func TestPostgresErrorMessage(t *testing.T) {
+	response := PostgreSQLErrorResponse("ERROR", SQLStateInsufficientPrivilege, "denied")
+	response = append(response, PostgreSQLReadyForQuery()...)
+	assert.Equal(t, "denied", PostgresErrorMessage(response))
+	assert.Empty(t, PostgresErrorMessage(PostgreSQLReadyForQuery()))
+	assert.Equal(t, []string{"SELECT 1"}, PostgresQueries(PostgreSQLQuery("SELECT 1")))
+}
+
+// TestPostgresMessagesLength tests that the message cut off at the end of the data
+// isn't counted.
+func TestPostgresMessagesLength(t *testing.T) {
+	commandComplete := []byte("C\x00\x00\x00\x0bCOMMIT\x00")
+	response := append(append([]byte{}, commandComplete...), PostgreSQLReadyForQuery()...)
+	assert.Equal(t, len(response), PostgresMessagesLength(response))
+	assert.Equal(t, len(commandComplete), PostgresMessagesLength(response[:len(response)-1]))
+	assert.Equal(t, len(commandComplete), PostgresMessagesLength(response[:len(commandComplete)+3]))
+	assert.Equal(t, 0, PostgresMessagesLength(commandComplete[:8]))
+}
+
+// TestPostgresErrorCodes tests that the SQLSTATE codes of the error responses are
+// returned.
+func TestPostgresErrorCodes(t *testing.T) {
+	response := PostgreSQLErrorResponse("ERROR", "40P01", "deadlock detected")
+	response = append(response, PostgreSQLErrorResponse("FATAL", "57P01", "terminating")...)
+	response = append(response, PostgreSQLReadyForQuery()...)
+	assert.Equal(t, []string{"40P01", "57P01"}, PostgresErrorCodes(response))
+	assert.Empty(t, PostgresErrorCodes(PostgreSQLReadyForQuery()))
+}
+
+// TestPostgresBackendKeyData tests that the backend key is read from the response
+// that starts the session.
+func TestPostgresBackendKeyData(t *testing.T) {
+	response := []byte{'R', 0, 0, 0, 8, 0, 0, 0, 0}
+	response = append(response, 'K', 0, 0, 0, 12, 0, 0, 0, 42, 0, 0, 1, 0)
+	response = append(response, PostgreSQLReadyForQuery()...)
+	processID, secretKey, ok := PostgresBackendKeyData(response)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(42), processID)
+	assert.Equal(t, uint32(256), secretKey)
+
+	_, _, ok = PostgresBackendKeyData(PostgreSQLReadyForQuery())
+	assert.False(t, ok)
+
+	assert.Equal(t, []byte{0, 0, 0, 16, 0x04, 0xd2, 0x16, 0x2e, 0, 0, 0, 42, 0, 0, 1, 0},
+		PostgreSQLCancelRequest(42, 256))
+}
@@ -6,14 +6,22 @@ import (
 	"errors"
 	"io"
 	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
 	"github.com/gatewayd-io/gatewayd/config"
 	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/events"
+	"github.com/gatewayd-io/gatewayd/logging"
 	"github.com/gatewayd-io/gatewayd/metrics"
 	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/policy"
 	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/gatewayd-io/gatewayd/sdk"
 	"github.com/getsentry/sentry-go"
 	"github.com/go-co-op/gocron"
 	"github.com/rs/zerolog"
@@ -25,11 +33,16 @@ type IProxy interface {
 	Disconnect(conn *ConnWrapper) *gerr.GatewayDError
 	PassThroughToServer(conn *ConnWrapper, stack *Stack) *gerr.GatewayDError
 	PassThroughToClient(conn *ConnWrapper, stack *Stack) *gerr.GatewayDError
+	ServerConn(conn *ConnWrapper) net.Conn
+	CanSplice(conn *ConnWrapper) bool
+	Splice(conn *ConnWrapper) *gerr.GatewayDError
 	IsHealthy(cl *Client) (*Client, *gerr.GatewayDError)
 	IsExhausted() bool
 	Shutdown()
 	AvailableConnections() []string
 	BusyConnections() []string
+	Connections() []ConnectionInfo
+	Kill(id string) bool
 }
 
 type Proxy struct {
@@ -41,12 +54,176 @@ type Proxy struct {
 	ctx                  context.Context //nolint:containedctx
 	pluginTimeout        time.Duration
 
+	// Name is the name of the config group of the proxy and of its server, for the
+	// labels of the connection metrics.
+	Name string
+
 	Elastic             bool
 	ReuseElasticClients bool
 	HealthCheckPeriod   time.Duration
 
+	// InjectCorrelationIDs prepends the connection and query IDs as a SQL comment
+	// to the queries sent to the server, so that the database logs can be
+	// correlated back to the gateway sessions.
+	InjectCorrelationIDs bool
+
+	// QueueSize is the maximum number of incoming connections that wait for a server
+	// connection to be released back to the pool when it is exhausted. Zero disables
+	// queueing, so incoming connections are rejected right away.
+	QueueSize    int
+	QueueTimeout time.Duration
+
+	// Limiter adaptively limits the number of queries in flight to the database,
+	// based on its latency. Excess queries wait for up to LimiterQueueTimeout and
+	// are then rejected. It is disabled if nil.
+	Limiter             ILimiter
+	LimiterQueueTimeout time.Duration
+	// LimitPolicy selects the queries the Limiter limits. All of them are limited if
+	// it is nil.
+	LimitPolicy *policy.Program
+	// PriorityClasses classify the queries queued by the Limiter, in order of
+	// priority. The queries none of them matches have the lowest priority.
+	PriorityClasses []*PriorityClass
+
+	// Throttler limits the bandwidth between the clients and the database.
+	// It is disabled if nil.
+	Throttler *Throttler
+
+	// ThrottlePolicy selects the connections the Throttler limits. All of them are
+	// limited if nil.
+	ThrottlePolicy *policy.Program
+
+	// Usage accounts the traffic of the connections, users and databases. It is
+	// disabled if nil.
+	Usage *UsageTracker
+
+	// QueryStats counts the queries of the clients for the top queries of the
+	// dashboard. It is disabled if nil.
+	QueryStats *QueryStats
+
+	// ErrorStats counts the error responses of the database by database, user and
+	// SQLSTATE code for the admin API. It is disabled if nil.
+	ErrorStats *ErrorStats
+
+	// Firewall allows or denies the queries before they are sent to the database.
+	// It is disabled if nil.
+	Firewall *Firewall
+	// StatementTimeouts cancel the queries that run longer than their timeout. They
+	// are disabled if nil.
+	StatementTimeouts *StatementTimeouts
+	// ResultLimits stop the result sets that exceed their maximum rows or bytes. They
+	// are disabled if nil.
+	ResultLimits *ResultLimits
+	// IdleTransactions end the transactions the sessions are idle in for too long.
+	// They are disabled if nil.
+	IdleTransactions *IdleTransactions
+	// Faults are injected in the traffic to test the applications. They are disabled
+	// if nil.
+	Faults *FaultInjector
+	// StatementCache caches the prepared statements of the sessions on their server
+	// connections. It is disabled if nil.
+	StatementCache *StatementCache
+
+	// ReceiveBufferSize and SendBufferSize are the sizes of the chunks read from
+	// and written to the clients.
+	ReceiveBufferSize int
+	SendBufferSize    int
+	// MaxMessageSize is the maximum size of a message buffered by the proxy, and
+	// OversizeBehavior is what happens to the larger ones. Only the responses can be
+	// streamed, in chunks of the maximum size, so the connection is closed instead
+	// for the requests. Zero disables the limit.
+	MaxMessageSize   int
+	OversizeBehavior config.OversizeBehavior
+
+	// FastPath splices the traffic between the clients and the database in the
+	// kernel on Linux, when nothing needs to see the traffic, e.g. traffic hooks.
+	FastPath bool
+
+	// CompressionAlgorithms are the algorithms the traffic between the proxy and the
+	// clients can be compressed with, in order of preference, if the clients request
+	// it in their startup message. Compression is disabled if there are none.
+	CompressionAlgorithms []config.CompressionAlgorithm
+	CompressionLevel      int
+
+	// StartupParameters are set in the startup messages of the clients before they
+	// are sent to the database, unless the clients set them and they don't override.
+	StartupParameters []config.StartupParameter
+
+	// ErrorMessages are the codes and messages of the errors sent to the clients,
+	// instead of the defaults.
+	ErrorMessages map[config.ErrorKind]config.ErrorMessage
+
+	// ReconnectSessions moves the idle sessions to a new server connection when theirs
+	// dies, instead of disconnecting the clients.
+	ReconnectSessions bool
+
+	// ResetPolicy is whether the settings the sessions changed with the SET statements
+	// are replayed or discarded when they move to a new server connection.
+	ResetPolicy config.ResetPolicy
+
 	// ClientConfig is used for elastic proxy and reconnection
 	ClientConfig *config.Client
+
+	// Backend re-resolves the hostname of the address of the clients. It is nil if
+	// the address has no hostname, or if it is resolved by an SSH bastion or an
+	// upstream proxy.
+	Backend *BackendResolver
+
+	// Cluster finds the primary of the PostgreSQL cluster of the backend, whose member
+	// of the ClusterRole the clients connect to instead of their address. It is
+	// disabled if nil.
+	Cluster     *ClusterMonitor
+	ClusterRole config.ClusterRole
+	// RetryReads retries the read-only queries once on another standby when theirs
+	// dies, if the ClusterRole is the standbys.
+	RetryReads bool
+	// Affinity hashes the sessions to the standbys, if the ClusterRole is the
+	// standbys. It is disabled if nil.
+	Affinity *SessionAffinity
+
+	// Health checks the backend with a query on the interval, and rejects the new
+	// client connections while it is degraded, if its circuit breaker is enabled. It
+	// is disabled if nil.
+	Health *BackendHealth
+
+	// Sharding routes the statements to the proxies of their shards by their shard key.
+	// It is disabled if nil.
+	Sharding *ShardRouter
+
+	// ReadWriteSplit sends the read-only queries to the proxy of the reads config
+	// group. It is disabled if nil.
+	ReadWriteSplit *ReadWriteSplitter
+
+	// MaxConnections is the maximum number of client connections to the proxy. Zero
+	// disables the quota.
+	MaxConnections int
+	// Limits shares the state of the bandwidth limits and the connection quota with
+	// the other GatewayD instances. The limits apply to the instance alone if nil.
+	Limits *SharedLimits
+	// MaxConnectionMemory is the approximate memory a client connection may hold in
+	// the proxy, in bytes. The requests beyond it are rejected. Zero disables the limit.
+	MaxConnectionMemory int
+	// connections is the number of client connections within the quota, if the
+	// limits aren't shared.
+	connections atomic.Int64
+
+	queued   atomic.Int32
+	released chan struct{}
+	// queries is the number of the requests that started a query, except on the
+	// fast path.
+	queries atomic.Uint64
+	// inFlight holds the time at which each connection took a limiter slot.
+	inFlight sync.Map
+	// parameters holds the startup parameters of each connection, e.g. the user
+	// it authenticated as, for throttling and labeling the metrics.
+	parameters sync.Map
+	// transactions holds the last transaction status of each connection, for
+	// flushing the batched hooks on transaction boundaries.
+	transactions sync.Map
+	// sessions holds the state and traffic of each connection, for listing them.
+	sessions sync.Map
+	// throttled holds whether the throttle policy matches each connection.
+	throttled sync.Map
 }
 
 var _ IProxy = (*Proxy)(nil)
@@ -75,6 +252,13 @@ func NewProxy(
 		ReuseElasticClients:  reuseElasticClients,
 		ClientConfig:         clientConfig,
 		HealthCheckPeriod:    healthCheckPeriod,
+		QueueTimeout:         config.DefaultQueueTimeout,
+		LimiterQueueTimeout:  config.DefaultLimiterQueueTimeout,
+		ReceiveBufferSize:    config.DefaultReceiveBufferSize,
+		SendBufferSize:       config.DefaultSendBufferSize,
+		OversizeBehavior:     config.DefaultOversizeBehavior,
+		// Buffered, so that releasing a connection never blocks, even with no waiters.
+		released: make(chan struct{}, max(connPool.Cap(), 1)),
 	}
 
 	startDelay := time.Now().Add(proxy.HealthCheckPeriod)
@@ -89,25 +273,25 @@ func NewProxy(
 					proxy.availableConnections.Remove(client.ID)
 					client.Close()
 					// Create a new client.
+					start := time.Now()
+					clientConfig := proxy.clientConfig()
 					client = NewClient(
-						proxyCtx, proxy.ClientConfig, proxy.logger,
-						NewRetry(
-							proxy.ClientConfig.Retries,
-							config.If[time.Duration](
-								proxy.ClientConfig.Backoff > 0,
-								proxy.ClientConfig.Backoff,
-								config.DefaultBackoff,
-							),
-							proxy.ClientConfig.BackoffMultiplier,
-							proxy.ClientConfig.DisableBackoffCaps,
-							proxy.logger,
-						),
+						proxyCtx, clientConfig, proxy.logger,
+						NewClientRetry(proxy.ClientConfig, proxy.logger),
 					)
+					var reconnectErr error
+					if client == nil || client.ID == "" {
+						reconnectErr = gerr.ErrClientConnectionFailed
+					}
+					proxy.backendReconnected(clientConfig.Network, clientConfig.Address,
+						ReconnectHealthCheck, time.Since(start), reconnectErr)
 					if client != nil && client.ID != "" {
 						if err := proxy.availableConnections.Put(client.ID, client); err != nil {
 							proxy.logger.Err(err).Msg("Failed to update the client connection")
 							// Close the client, because we don't want to have orphaned connections.
 							client.Close()
+						} else {
+							proxy.notifyReleased()
 						}
 					} else {
 						proxy.logger.Error().Msg("Failed to create a new client connection")
@@ -137,6 +321,194 @@ func NewProxy(
 	return &proxy
 }
 
+// Configure applies the settings of the proxy config, filling the zero values with
+// the default ones. It returns an error if the policy expressions are invalid.
+func (pr *Proxy) Configure(cfg *config.Proxy) *gerr.GatewayDError {
+	pr.InjectCorrelationIDs = cfg.InjectCorrelationIDs
+	pr.QueueSize = cfg.QueueSize
+	pr.QueueTimeout = config.If[time.Duration](
+		cfg.QueueTimeout > 0,
+		cfg.QueueTimeout,
+		config.DefaultQueueTimeout,
+	)
+	pr.ReceiveBufferSize = config.If[int](
+		cfg.ReceiveBufferSize > 0,
+		cfg.ReceiveBufferSize,
+		config.DefaultReceiveBufferSize,
+	)
+	pr.SendBufferSize = config.If[int](
+		cfg.SendBufferSize > 0,
+		cfg.SendBufferSize,
+		config.DefaultSendBufferSize,
+	)
+	pr.MaxMessageSize = cfg.MaxMessageSize
+	pr.OversizeBehavior = config.If[config.OversizeBehavior](
+		cfg.OversizeBehavior != "",
+		config.OversizeBehavior(cfg.OversizeBehavior),
+		config.DefaultOversizeBehavior,
+	)
+	pr.FastPath = cfg.FastPath
+
+	pr.CompressionAlgorithms = nil
+	if cfg.Compression.Enabled {
+		for _, algorithm := range cfg.Compression.Algorithms {
+			pr.CompressionAlgorithms = append(
+				pr.CompressionAlgorithms, config.CompressionAlgorithm(algorithm))
+		}
+		pr.CompressionLevel = cfg.Compression.Level
+	}
+
+	pr.ReconnectSessions = cfg.ReconnectSessions
+	pr.ErrorMessages = make(map[config.ErrorKind]config.ErrorMessage, len(cfg.ErrorMessages))
+	for kind, message := range cfg.ErrorMessages {
+		pr.ErrorMessages[config.ErrorKind(kind)] = message
+	}
+	pr.ResetPolicy = config.If[config.ResetPolicy](
+		cfg.ResetPolicy != "",
+		config.ResetPolicy(cfg.ResetPolicy),
+		config.DefaultResetPolicy,
+	)
+
+	pr.StartupParameters = nil
+	for _, parameter := range cfg.StartupParameters {
+		// The names of the parameters are case-insensitive.
+		parameter.Name = strings.ToLower(parameter.Name)
+		pr.StartupParameters = append(pr.StartupParameters, parameter)
+	}
+
+	pr.Limiter = nil
+	pr.LimitPolicy = nil
+	pr.PriorityClasses = nil
+	if cfg.AdaptiveLimit.Enabled {
+		limiter := NewAdaptiveLimiter(
+			config.If[int](
+				cfg.AdaptiveLimit.InitialLimit > 0,
+				cfg.AdaptiveLimit.InitialLimit,
+				config.DefaultInitialLimit,
+			),
+			config.If[int](
+				cfg.AdaptiveLimit.MinLimit > 0,
+				cfg.AdaptiveLimit.MinLimit,
+				config.DefaultMinLimit,
+			),
+			config.If[int](
+				cfg.AdaptiveLimit.MaxLimit > 0,
+				cfg.AdaptiveLimit.MaxLimit,
+				config.DefaultMaxLimit,
+			),
+			config.If[time.Duration](
+				cfg.AdaptiveLimit.LatencyThreshold > 0,
+				cfg.AdaptiveLimit.LatencyThreshold,
+				config.DefaultLatencyThreshold,
+			),
+			config.If[float64](
+				cfg.AdaptiveLimit.BackoffRatio > 0 && cfg.AdaptiveLimit.BackoffRatio < 1,
+				cfg.AdaptiveLimit.BackoffRatio,
+				config.DefaultBackoffRatio,
+			),
+			cfg.AdaptiveLimit.QueueSize,
+		)
+		classes, err := NewPriorityClasses(cfg.AdaptiveLimit.PriorityClasses)
+		if err != nil {
+			return err
+		}
+		pr.PriorityClasses = classes
+		for _, class := range classes {
+			limiter.Classes = append(limiter.Classes, class.Name)
+		}
+		limiter.Classes = append(limiter.Classes, config.DefaultPriorityClass)
+		limiter.ShedLowestClass = cfg.AdaptiveLimit.ShedLowestClass
+		pr.Limiter = limiter
+		pr.LimiterQueueTimeout = config.If[time.Duration](
+			cfg.AdaptiveLimit.QueueTimeout > 0,
+			cfg.AdaptiveLimit.QueueTimeout,
+			config.DefaultLimiterQueueTimeout,
+		)
+		if cfg.AdaptiveLimit.When != "" {
+			limitPolicy, err := policy.Compile(cfg.AdaptiveLimit.When)
+			if err != nil {
+				return err
+			}
+			pr.LimitPolicy = limitPolicy
+		}
+	}
+
+	pr.Throttler = nil
+	pr.ThrottlePolicy = nil
+	if cfg.Bandwidth.IngressRate > 0 || cfg.Bandwidth.EgressRate > 0 {
+		pr.Throttler = NewThrottler(
+			cfg.Bandwidth.IngressRate,
+			cfg.Bandwidth.EgressRate,
+			cfg.Bandwidth.Burst,
+			cfg.Bandwidth.PerUser,
+		)
+		pr.Throttler.Shared = pr.Limits
+		if cfg.Bandwidth.When != "" {
+			throttlePolicy, err := policy.Compile(cfg.Bandwidth.When)
+			if err != nil {
+				return err
+			}
+			pr.ThrottlePolicy = throttlePolicy
+		}
+	}
+
+	pr.MaxConnections = cfg.MaxConnections
+	pr.MaxConnectionMemory = cfg.MaxConnectionMemory
+
+	pr.Firewall = nil
+	if len(cfg.Firewall.Rules) > 0 {
+		firewall, err := NewFirewall(cfg.Firewall.Rules, pr.logger)
+		if err != nil {
+			return err
+		}
+		pr.Firewall = firewall
+	}
+
+	pr.StatementTimeouts = nil
+	if cfg.StatementTimeout.Timeout != 0 || len(cfg.StatementTimeout.Rules) > 0 {
+		timeouts, err := NewStatementTimeouts(cfg.StatementTimeout)
+		if err != nil {
+			return err
+		}
+		pr.StatementTimeouts = timeouts
+	}
+
+	pr.ResultLimits = nil
+	if cfg.ResultLimit.MaxRows != 0 || cfg.ResultLimit.MaxBytes != 0 || len(cfg.ResultLimit.Rules) > 0 {
+		limits, err := NewResultLimits(cfg.ResultLimit)
+		if err != nil {
+			return err
+		}
+		pr.ResultLimits = limits
+	}
+
+	idleTransactions, err := NewIdleTransactions(cfg.IdleTransaction)
+	if err != nil {
+		return err
+	}
+	pr.IdleTransactions = idleTransactions
+
+	faults, err := NewFaultInjector(cfg.FaultInjection)
+	if err != nil {
+		return err
+	}
+	pr.Faults = faults
+
+	// The sessions keep using the statements cached on their server connections, so a
+	// disabled cache only stops caching new statements.
+	if pr.StatementCache == nil {
+		pr.StatementCache = NewStatementCache(cfg.StatementCache)
+	} else {
+		pr.StatementCache.MaxStatements = config.If[int](
+			cfg.StatementCache.Enabled,
+			config.If[int](cfg.StatementCache.MaxStatements > 0,
+				cfg.StatementCache.MaxStatements, config.DefaultMaxCachedStatements),
+			0)
+	}
+
+	return nil
+}
+
 // Connect maps a server connection from the available connection pool to a incoming connection.
 // It returns an error if the pool is exhausted. If the pool is elastic, it creates a new client
 // and maps it to the incoming connection.
@@ -144,59 +516,75 @@ func (pr *Proxy) Connect(conn *ConnWrapper) *gerr.GatewayDError {
 	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "Connect")
 	defer span.End()
 
-	var clientID string
-	// Get the first available client from the pool.
-	pr.availableConnections.ForEach(func(key, _ interface{}) bool {
-		if cid, ok := key.(string); ok {
-			clientID = cid
-			return false // stop the loop.
-		}
-		return true
-	})
+	correlation := Correlation{ConnectionID: conn.ID()}
+	logger := correlation.Logger(pr.logger)
+	span.SetAttributes(correlation.Attributes()...)
 
-	var client *Client
-	if pr.IsExhausted() {
+	if pr.circuitOpen() {
+		span.RecordError(gerr.ErrBackendDegraded)
+		metrics.ProxyCircuitBreakerRejections.Inc()
+		logger.Debug().Msg("Rejected the connection, as the backend is degraded")
+		return gerr.ErrBackendDegraded
+	}
+
+	if !pr.acquireConnection() {
+		span.RecordError(gerr.ErrConnectionQuotaExceeded)
+		metrics.ProxyConnectionQuotaRejections.Inc()
+		logger.Debug().Int("maxConnections", pr.MaxConnections).Msg(
+			"Rejected the connection, as the connection quota is exceeded")
+		return gerr.ErrConnectionQuotaExceeded
+	}
+
+	// Get the first available client from the pool.
+	client := pr.popAvailableClient()
+	if client == nil {
 		// Pool is exhausted or is elastic.
-		if pr.Elastic {
+		switch {
+		case pr.Elastic:
 			// Create a new client.
-			client = NewClient(
-				pr.ctx, pr.ClientConfig, pr.logger,
-				NewRetry(
-					pr.ClientConfig.Retries,
-					config.If[time.Duration](
-						pr.ClientConfig.Backoff > 0,
-						pr.ClientConfig.Backoff,
-						config.DefaultBackoff,
-					),
-					pr.ClientConfig.BackoffMultiplier,
-					pr.ClientConfig.DisableBackoffCaps,
-					pr.logger,
-				),
-			)
+			client = pr.newClient()
 			span.AddEvent("Created a new client connection")
-			pr.logger.Debug().Str("id", client.ID[:7]).Msg("Reused the client connection")
-		} else {
+			logger.Debug().Str("id", client.ID[:7]).Msg("Reused the client connection")
+		case pr.QueueSize > 0:
+			// Wait for a client to be released back to the pool.
+			queuedClient, err := pr.waitForClient(logger)
+			if err != nil {
+				span.RecordError(err)
+				events.Publish(events.PoolExhausted, err.Message,
+					map[string]interface{}{
+						"connectionId": conn.ID(),
+						"poolSize":     pr.availableConnections.Cap(),
+						"queueSize":    pr.QueueSize,
+					})
+				pr.releaseConnection()
+				return err
+			}
+			client = queuedClient
+		default:
 			span.AddEvent(gerr.ErrPoolExhausted.Error())
+			events.Publish(events.PoolExhausted, "No more available connections in the pool",
+				map[string]interface{}{
+					"connectionId": conn.ID(),
+					"poolSize":     pr.availableConnections.Cap(),
+				})
+			pr.releaseConnection()
 			return gerr.ErrPoolExhausted
 		}
-	} else {
-		// Get the client from the pool with the given clientID.
-		if cl, ok := pr.availableConnections.Pop(clientID).(*Client); ok {
-			client = cl
-		}
 	}
 
 	client, err := pr.IsHealthy(client)
 	if err != nil {
-		pr.logger.Error().Err(err).Msg("Failed to connect to the client")
+		logger.Error().Err(err).Msg("Failed to connect to the client")
 		span.RecordError(err)
 	}
 
 	if err := pr.busyConnections.Put(conn, client); err != nil {
 		// This should never happen.
 		span.RecordError(err)
+		pr.releaseConnection()
 		return err
 	}
+	pr.sessions.Store(conn, newSession())
 
 	metrics.ProxiedConnections.Inc()
 
@@ -208,15 +596,15 @@ func (pr *Proxy) Connect(conn *ConnWrapper) *gerr.GatewayDError {
 	if client.ID != "" {
 		fields["client"] = client.ID[:7]
 	}
-	pr.logger.Debug().Fields(fields).Msg("Client has been assigned")
+	logger.Debug().Fields(fields).Msg("Client has been assigned")
 
-	pr.logger.Debug().Fields(
+	logger.Debug().Fields(
 		map[string]interface{}{
 			"function": "proxy.connect",
 			"count":    pr.availableConnections.Size(),
 		},
 	).Msg("Available client connections")
-	pr.logger.Debug().Fields(
+	logger.Debug().Fields(
 		map[string]interface{}{
 			"function": "proxy.connect",
 			"count":    pr.busyConnections.Size(),
@@ -226,34 +614,88 @@ func (pr *Proxy) Connect(conn *ConnWrapper) *gerr.GatewayDError {
 	return nil
 }
 
+// acquireConnection takes a connection of the quota, across the instances if the
+// limits are shared. It returns false if the quota is exceeded.
+func (pr *Proxy) acquireConnection() bool {
+	if pr.MaxConnections <= 0 {
+		return true
+	}
+	if pr.Limits != nil {
+		return pr.Limits.Acquire("connections", int64(pr.MaxConnections))
+	}
+	if pr.connections.Add(1) > int64(pr.MaxConnections) {
+		pr.connections.Add(-1)
+		return false
+	}
+	return true
+}
+
+// releaseConnection gives back the connection taken by acquireConnection.
+func (pr *Proxy) releaseConnection() {
+	if pr.MaxConnections <= 0 {
+		return
+	}
+	if pr.Limits != nil {
+		pr.Limits.Release("connections")
+		return
+	}
+	pr.connections.Add(-1)
+}
+
 // Disconnect removes the client from the busy connection pool and tries to recycle
 // the server connection.
 func (pr *Proxy) Disconnect(conn *ConnWrapper) *gerr.GatewayDError {
 	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "Disconnect")
 	defer span.End()
 
+	correlation := Correlation{ConnectionID: conn.ID()}
+	logger := correlation.Logger(pr.logger)
+	span.SetAttributes(correlation.Attributes()...)
+
+	if pr.Affinity != nil {
+		pr.Affinity.Release(conn)
+	}
+
 	client := pr.busyConnections.Pop(conn)
 	if client == nil {
 		// If this ever happens, it means that the client connection
 		// is pre-empted from the busy connections pool.
-		pr.logger.Debug().Msg("Client connection is pre-empted from the busy connections pool")
+		logger.Debug().Msg("Client connection is pre-empted from the busy connections pool")
 		span.RecordError(gerr.ErrClientNotFound)
 		return gerr.ErrClientNotFound
 	}
+	pr.releaseConnection()
+	// The state of the connection is released however the server connection ends up.
+	defer pr.releaseState(conn, correlation)
 
 	//nolint:nestif
 	if client, ok := client.(*Client); ok {
+		if pr.StatementCache != nil {
+			pr.StatementCache.Forget(client)
+		}
 		if (pr.Elastic && pr.ReuseElasticClients) || !pr.Elastic {
+			// Move the server connection to the new address of the backend, or to the
+			// member of the cluster of its role, if it changed.
+			if pr.Backend != nil && !pr.Backend.IsCurrent(client.Address) {
+				client.Address = pr.Backend.Current()
+			}
+			if pr.Cluster != nil && !pr.Cluster.IsCurrent(pr.ClusterRole, client.Address) {
+				if target := pr.Cluster.Target(pr.ClusterRole); target != "" {
+					client.Address = target
+				}
+			}
 			// Recycle the server connection by reconnecting.
-			if err := client.Reconnect(); err != nil {
-				pr.logger.Error().Err(err).Msg("Failed to reconnect to the client")
+			if err := pr.reconnectClient(client, ReconnectReleased); err != nil {
+				logger.Error().Err(err).Msg("Failed to reconnect to the client")
 				span.RecordError(err)
 			}
 
 			// If the client is not in the pool, put it back.
 			if err := pr.availableConnections.Put(client.ID, client); err != nil {
-				pr.logger.Error().Err(err).Msg("Failed to put the client back in the pool")
+				logger.Error().Err(err).Msg("Failed to put the client back in the pool")
 				span.RecordError(err)
+			} else {
+				pr.notifyReleased()
 			}
 		} else {
 			span.RecordError(gerr.ErrClientNotConnected)
@@ -262,20 +704,18 @@ func (pr *Proxy) Disconnect(conn *ConnWrapper) *gerr.GatewayDError {
 	} else {
 		// This should never happen, but if it does,
 		// then there are some serious issues with the pool.
-		pr.logger.Error().Msg("Failed to cast the client to the Client type")
+		logger.Error().Msg("Failed to cast the client to the Client type")
 		span.RecordError(gerr.ErrCastFailed)
 		return gerr.ErrCastFailed
 	}
 
-	metrics.ProxiedConnections.Dec()
-
-	pr.logger.Debug().Fields(
+	logger.Debug().Fields(
 		map[string]interface{}{
 			"function": "proxy.disconnect",
 			"count":    pr.availableConnections.Size(),
 		},
 	).Msg("Available client connections")
-	pr.logger.Debug().Fields(
+	logger.Debug().Fields(
 		map[string]interface{}{
 			"function": "proxy.disconnect",
 			"count":    pr.busyConnections.Size(),
@@ -285,11 +725,45 @@ func (pr *Proxy) Disconnect(conn *ConnWrapper) *gerr.GatewayDError {
 	return nil
 }
 
+// releaseState releases the state the proxy keeps for the connection, such as its
+// session, its slot of the query limiter and its usage.
+func (pr *Proxy) releaseState(conn *ConnWrapper, correlation Correlation) {
+	if pr.Sharding != nil {
+		pr.Sharding.release(conn)
+	}
+	if pr.ReadWriteSplit != nil {
+		pr.ReadWriteSplit.release(conn)
+	}
+
+	// The connection might be closed while a query is still in flight.
+	pr.releaseSlot(conn, false)
+	pr.transactions.Delete(conn)
+	if value, ok := pr.sessions.LoadAndDelete(conn); ok {
+		state := value.(*session) //nolint:forcetypeassert
+		state.stopTimeout()
+		// The database rolls back the transaction the client disconnected in.
+		pr.notifyTransactions(conn, state.transaction.abort(sdk.TransactionError), correlation)
+	}
+	pr.parameters.Delete(conn)
+	pr.throttled.Delete(conn)
+	if pr.Usage != nil {
+		pr.Usage.Close(conn)
+	}
+
+	metrics.ProxiedConnections.Dec()
+}
+
 // PassThroughToServer sends the data from the client to the server.
 func (pr *Proxy) PassThroughToServer(conn *ConnWrapper, stack *Stack) *gerr.GatewayDError {
 	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "PassThrough")
 	defer span.End()
 
+	// Every request gets a new query ID, which is carried over to the response
+	// via the stack, so that both directions of the traffic can be correlated.
+	correlation := Correlation{ConnectionID: conn.ID(), QueryID: NewCorrelationID()}
+	logger := correlation.Logger(pr.logger)
+	span.SetAttributes(correlation.Attributes()...)
+
 	var client *Client
 	// Check if the proxy has a egress client for the incoming connection.
 	if pr.busyConnections.Get(conn) == nil {
@@ -311,28 +785,34 @@ func (pr *Proxy) PassThroughToServer(conn *ConnWrapper, stack *Stack) *gerr.Gate
 	}
 
 	// Receive the request from the client.
-	request, origErr := pr.receiveTrafficFromClient(conn.Conn())
+	request, origErr := pr.receiveTrafficFromClient(conn.Conn(), correlation)
 	span.AddEvent("Received traffic from client")
 
+	// Log the queries of the request, if their debug messages are sampled.
+	if event := logging.Sampled(logger, logging.QueriesCategory).Debug(); event.Enabled() {
+		if queries := PostgresQueries(request); len(queries) > 0 {
+			event.Strs("queries", queries).Msg("Received queries from client")
+		} else {
+			event.Discard()
+		}
+	}
+
 	// Run the OnTrafficFromClient hooks.
 	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), pr.pluginTimeout)
 	defer cancel()
 
-	result, err := pr.pluginRegistry.Run(
+	result, err := pr.pluginRegistry.RunTyped(
 		pluginTimeoutCtx,
 		trafficData(
 			conn.Conn(),
 			client,
-			[]Field{
-				{
-					Name:  "request",
-					Value: request,
-				},
-			},
+			correlation,
+			request,
+			nil,
 			origErr),
 		v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
 	if err != nil {
-		pr.logger.Error().Err(err).Msg("Error running hook")
+		logger.Error().Err(err).Msg("Error running hook")
 		span.RecordError(err)
 	}
 	span.AddEvent("Ran the OnTrafficFromClient hooks")
@@ -343,6 +823,13 @@ func (pr *Proxy) PassThroughToServer(conn *ConnWrapper, stack *Stack) *gerr.Gate
 		return gerr.ErrClientNotConnected.Wrap(origErr)
 	}
 
+	if origErr != nil && errors.Is(origErr, gerr.ErrMessageTooLarge) {
+		// The request is too large to be buffered, so close the connection.
+		pr.rejectOversizeMessage(conn, correlation)
+		return origErr
+	}
+	defer pr.hold(conn, len(request))()
+
 	// Check if the client sent a SSL request and the server supports SSL.
 	//nolint:nestif
 	if conn.IsTLSEnabled() && IsPostgresSSLRequest(request) {
@@ -351,10 +838,10 @@ func (pr *Proxy) PassThroughToServer(conn *ConnWrapper, stack *Stack) *gerr.Gate
 			// Acknowledge the SSL request:
 			// https://www.postgresql.org/docs/current/protocol-flow.html#PROTOCOL-FLOW-SSL
 			if sent, err := conn.Write([]byte{'S'}); err != nil {
-				pr.logger.Error().Err(err).Msg("Failed to acknowledge the SSL request")
+				logger.Error().Err(err).Msg("Failed to acknowledge the SSL request")
 				span.RecordError(err)
 			} else {
-				pr.logger.Debug().Fields(
+				logger.Debug().Fields(
 					map[string]interface{}{
 						"function": "upgradeToTLS",
 						"local":    LocalAddr(conn.Conn()),
@@ -364,13 +851,13 @@ func (pr *Proxy) PassThroughToServer(conn *ConnWrapper, stack *Stack) *gerr.Gate
 				).Msg("Sent data to database")
 			}
 		}); err != nil {
-			pr.logger.Error().Err(err).Msg("Failed to perform the TLS handshake")
+			logger.Error().Err(err).Msg("Failed to perform the TLS handshake")
 			span.RecordError(err)
 		}
 
 		// Check if the TLS handshake was successful.
 		if conn.IsTLSEnabled() {
-			pr.logger.Debug().Fields(
+			logger.Debug().Fields(
 				map[string]interface{}{
 					"local":  LocalAddr(conn.Conn()),
 					"remote": RemoteAddr(conn.Conn()),
@@ -379,7 +866,7 @@ func (pr *Proxy) PassThroughToServer(conn *ConnWrapper, stack *Stack) *gerr.Gate
 			span.AddEvent("Performed the TLS handshake")
 			metrics.TLSConnections.Inc()
 		} else {
-			pr.logger.Error().Fields(
+			logger.Error().Fields(
 				map[string]interface{}{
 					"local":  LocalAddr(conn.Conn()),
 					"remote": RemoteAddr(conn.Conn()),
@@ -394,7 +881,7 @@ func (pr *Proxy) PassThroughToServer(conn *ConnWrapper, stack *Stack) *gerr.Gate
 	} else if !conn.IsTLSEnabled() && IsPostgresSSLRequest(request) {
 		// Client sent a SSL request, but the server does not support SSL.
 
-		pr.logger.Error().Fields(
+		logger.Error().Fields(
 			map[string]interface{}{
 				"local":  LocalAddr(conn.Conn()),
 				"remote": RemoteAddr(conn.Conn()),
@@ -406,7 +893,7 @@ func (pr *Proxy) PassThroughToServer(conn *ConnWrapper, stack *Stack) *gerr.Gate
 		// so we need to switch to a plaintext connection:
 		// https://www.postgresql.org/docs/current/protocol-flow.html#PROTOCOL-FLOW-SSL
 		if _, err := conn.Write([]byte{'N'}); err != nil {
-			pr.logger.Error().Err(err).Msg("Server does not support SSL, but SSL was required")
+			logger.Error().Err(err).Msg("Server does not support SSL, but SSL was required")
 			span.RecordError(err)
 		}
 
@@ -415,8 +902,23 @@ func (pr *Proxy) PassThroughToServer(conn *ConnWrapper, stack *Stack) *gerr.Gate
 		return nil
 	}
 
+	// Remember the user of the connection from its startup message.
+	if parameters := PostgresStartupParameters(request); parameters != nil {
+		pr.parameters.Store(conn, parameters)
+		labels := pr.metricLabels(parameters)
+		metrics.ProxyClientConnections.WithLabelValues(
+			metrics.LabelValues(config.ConnectionsMetricLabels, labels)...).Inc()
+		if pr.Usage != nil {
+			pr.Usage.Identify(conn, parameters["user"], parameters["database"], labels)
+		}
+		request = pr.negotiateCompression(conn, request, parameters, logger)
+		request = pr.injectStartupParameters(conn, request, parameters)
+		pr.routeSession(conn, client, parameters, logger)
+	}
+
 	// Push the client's request to the stack.
-	stack.Push(&Request{Data: request})
+	receivedAt := time.Now()
+	stack.Push(&Request{Data: request, QueryID: correlation.QueryID, Time: receivedAt})
 
 	// If the hook wants to terminate the connection, do it.
 	if pr.shouldTerminate(result) {
@@ -431,7 +933,7 @@ func (pr *Proxy) PassThroughToServer(conn *ConnWrapper, stack *Stack) *gerr.Gate
 			// Remove the request from the stack if the response is modified.
 			stack.PopLastRequest()
 
-			return pr.sendTrafficToClient(conn.Conn(), modResponse, modReceived)
+			return pr.sendTrafficToClient(conn.Conn(), modResponse, modReceived, correlation)
 		}
 		span.RecordError(gerr.ErrHookTerminatedConnection)
 		return gerr.ErrHookTerminatedConnection
@@ -442,31 +944,164 @@ func (pr *Proxy) PassThroughToServer(conn *ConnWrapper, stack *Stack) *gerr.Gate
 		span.AddEvent("Plugin(s) modified the request")
 	}
 
-	stack.UpdateLastRequest(&Request{Data: request})
+	stack.UpdateLastRequest(&Request{Data: request, QueryID: correlation.QueryID, Time: receivedAt})
 
-	// Send the request to the server.
-	_, err = pr.sendTrafficToServer(client, request)
-	span.AddEvent("Sent traffic to server")
+	// Fail the first request after the gateway rolled back the idle transaction of the
+	// session, since the client still sends it in the transaction.
+	if pr.startRequest(conn) {
+		span.AddEvent("Failed a request after rolling back its idle transaction")
+		stack.PopLastRequest()
+
+		response := ErrorResponse(pr.ErrorMessages, config.ErrorIdleTransactionRolledBack, "")
+		response = append(response, PostgreSQLReadyForQuery()...)
+		return pr.sendTrafficToClient(conn.Conn(), response, len(response), correlation)
+	}
+
+	// Reject the requests of the connections that hold too much memory, as PostgreSQL
+	// fails the queries when it runs out of memory.
+	if pr.exceedsConnectionMemory(conn, request) {
+		metrics.ProxyConnectionMemoryRejections.Inc()
+		logger.Warn().Int("maxConnectionMemory", pr.MaxConnectionMemory).Msg(
+			"Rejected the request, as the connection exceeded its memory limit")
+		span.RecordError(gerr.ErrConnectionMemoryExceeded)
+
+		stack.PopLastRequest()
+
+		response := ErrorResponse(pr.ErrorMessages, config.ErrorConnectionMemory, "")
+		response = append(response, PostgreSQLReadyForQuery()...)
+		return pr.sendTrafficToClient(conn.Conn(), response, len(response), correlation)
+	}
+
+	// Reject the queries denied by the firewall, as PostgreSQL rejects the queries the
+	// user has no privileges for.
+	if rule := pr.checkFirewall(conn, request); rule != nil {
+		metrics.ProxyFirewallDenials.WithLabelValues(rule.Name).Inc()
+		logger.Warn().Str("rule", rule.Name).Msg("The firewall denied the query")
+		span.RecordError(gerr.ErrQueryDenied)
+
+		stack.PopLastRequest()
+
+		response := ErrorResponse(pr.ErrorMessages, config.ErrorQueryDenied, rule.Message)
+		response = append(response, PostgreSQLReadyForQuery()...)
+		return pr.sendTrafficToClient(conn.Conn(), response, len(response), correlation)
+	}
+
+	// Route the statement to the shard of its shard key, or answer it if it spans the
+	// shards.
+	var shard *Shard
+	if pr.Sharding != nil {
+		var response []byte
+		if shard, response = pr.routeStatement(conn, request, result, correlation); response != nil {
+			span.AddEvent("Answered a statement that can't be routed to a shard")
+			stack.PopLastRequest()
+			return pr.sendTrafficToClient(conn.Conn(), response, len(response), correlation)
+		}
+	}
+
+	// Send the read-only queries to the reads, or to the server connection of the
+	// session if no server connection to the reads is available.
+	var readsClient *Client
+	if shard == nil && pr.ReadWriteSplit != nil && pr.splitRead(conn, request) {
+		if readsClient, err = pr.readsClient(conn, correlation); err != nil {
+			logger.Warn().Err(err).Str("reads", pr.ReadWriteSplit.Reads).Msg(
+				"Failed to connect to the reads, sending the query to the backend")
+		}
+	}
+
+	// Queue or shed the query if the database is saturated.
+	if pr.Limiter != nil && IsPostgresQuery(request) && pr.isLimited(conn, request) {
+		if err := pr.acquireSlot(conn, pr.priorityClass(conn, request, result)); err != nil {
+			metrics.ProxyShedRequests.Inc()
+			logger.Warn().Int("limit", pr.Limiter.Limit()).Msg(
+				"Concurrency limit exceeded, rejecting the query")
+			span.RecordError(err)
+
+			stack.PopLastRequest()
+
+			response := ErrorResponse(pr.ErrorMessages, config.ErrorConcurrencyLimit, "")
+			response = append(response, PostgreSQLReadyForQuery()...)
+			return pr.sendTrafficToClient(conn.Conn(), response, len(response), correlation)
+		}
+	}
+
+	// Tag the query with the correlation IDs, so that the database logs
+	// can be correlated back to this session. The hooks still see the
+	// request without the comment.
+	outgoing := request
+	if pr.InjectCorrelationIDs {
+		outgoing = InjectSQLComment(request, correlation.SQLComment())
+	}
+
+	pr.throttle(conn, Ingress, len(outgoing), logger)
+	pr.recordUsage(conn, Ingress, outgoing)
+	pr.startResultLimit(conn, request)
+	if IsPostgresQuery(request) {
+		pr.queries.Add(1)
+	}
+	if pr.QueryStats != nil {
+		pr.QueryStats.Record(request)
+	}
+
+	// Send the request to the server, or run it on the shard and relay its response.
+	if shard != nil {
+		var shardClient *Client
+		shardClient, err = pr.sendToShard(conn, shard, outgoing, correlation)
+		span.AddEvent("Sent traffic to shard")
+		stack.PopLastRequest()
+		pr.releaseSlot(conn, err != nil)
+		if err != nil {
+			return err
+		}
+		if shardClient != nil {
+			client = shardClient
+		}
+	} else if readsClient != nil {
+		err = pr.sendToReads(conn, readsClient, outgoing, correlation)
+		span.AddEvent("Sent traffic to the reads")
+		stack.PopLastRequest()
+		pr.releaseSlot(conn, err != nil)
+		if err != nil {
+			return err
+		}
+		client = readsClient
+	} else {
+		if prepared, response := pr.prepareStatements(conn, client, request); response != nil {
+			span.AddEvent("Deallocated a cached prepared statement")
+			stack.PopLastRequest()
+			pr.releaseSlot(conn, false)
+			return pr.sendTrafficToClient(conn.Conn(), response, len(response), correlation)
+		} else if prepared != nil {
+			// The correlation IDs are only injected in the simple queries, which
+			// aren't rewritten.
+			outgoing = prepared
+			span.AddEvent("Prepared the statements on the cached statements")
+		}
+		outgoing = pr.injectFaults(client, Ingress, outgoing, logger)
+		_, err = pr.sendTrafficToServer(client, outgoing, correlation)
+		span.AddEvent("Sent traffic to server")
+		if err != nil {
+			pr.releaseSlot(conn, true)
+		} else {
+			pr.startStatementTimeout(conn, client, request, correlation)
+		}
+	}
 
 	pluginTimeoutCtx, cancel = context.WithTimeout(context.Background(), pr.pluginTimeout)
 	defer cancel()
 
 	// Run the OnTrafficToServer hooks.
-	_, err = pr.pluginRegistry.Run(
+	_, err = pr.pluginRegistry.RunTyped(
 		pluginTimeoutCtx,
 		trafficData(
 			conn.Conn(),
 			client,
-			[]Field{
-				{
-					Name:  "request",
-					Value: request,
-				},
-			},
+			correlation,
+			request,
+			nil,
 			err),
 		v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_SERVER)
 	if err != nil {
-		pr.logger.Error().Err(err).Msg("Error running hook")
+		logger.Error().Err(err).Msg("Error running hook")
 		span.RecordError(err)
 	}
 	span.AddEvent("Ran the OnTrafficToServer hooks")
@@ -481,6 +1116,14 @@ func (pr *Proxy) PassThroughToClient(conn *ConnWrapper, stack *Stack) *gerr.Gate
 	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "PassThrough")
 	defer span.End()
 
+	// The query ID is taken from the request that this response belongs to.
+	correlation := Correlation{ConnectionID: conn.ID()}
+	if lastRequest := stack.GetLastRequest(); lastRequest != nil {
+		correlation.QueryID = lastRequest.QueryID
+	}
+	logger := correlation.Logger(pr.logger)
+	span.SetAttributes(correlation.Attributes()...)
+
 	var client *Client
 	// Check if the proxy has a egress client for the incoming connection.
 	if pr.busyConnections.Get(conn) == nil {
@@ -502,8 +1145,28 @@ func (pr *Proxy) PassThroughToClient(conn *ConnWrapper, stack *Stack) *gerr.Gate
 	}
 
 	// Receive the response from the server.
-	received, response, err := pr.receiveTrafficFromServer(client)
+	received, response, more, err := pr.receiveTrafficFromServer(
+		client, pr.MaxMessageSize, correlation)
 	span.AddEvent("Received traffic from server")
+	pr.releaseSlot(conn, received == 0 || err != nil)
+
+	if err != nil && pr.isServerLost(conn, client) {
+		// Receive the response of a read-only query from another standby.
+		if received == 0 && pr.retryRead(conn, client, stack, correlation) {
+			return nil
+		}
+
+		// Keep the session if its server connection died while it was idle.
+		if pr.recoverSession(conn, client, correlation) {
+			stack.PopLastRequest()
+			return nil
+		}
+
+		// Tell the client why it is being disconnected.
+		response := ErrorResponse(pr.ErrorMessages, config.ErrorBackendDown, "")
+		//nolint:errcheck
+		pr.sendTrafficToClient(conn.Conn(), response, len(response), correlation)
+	}
 
 	// If the response is empty, don't send anything, instead just close the ingress connection.
 	if received == 0 || err != nil {
@@ -514,7 +1177,7 @@ func (pr *Proxy) PassThroughToClient(conn *ConnWrapper, stack *Stack) *gerr.Gate
 		if client.RemoteAddr() != "" {
 			fields["remote_addr"] = client.RemoteAddr()
 		}
-		pr.logger.Debug().Fields(fields).Msg("No data to send to client")
+		logger.Debug().Fields(fields).Msg("No data to send to client")
 		span.AddEvent("No data to send to client")
 		span.RecordError(err)
 
@@ -522,6 +1185,25 @@ func (pr *Proxy) PassThroughToClient(conn *ConnWrapper, stack *Stack) *gerr.Gate
 
 		return err
 	}
+	defer pr.hold(conn, len(response))()
+
+	// Answer the password request of the server with an IAM auth token, instead of
+	// asking the client for its password, and pass the outcome to the client.
+	if client.IAMAuth != nil && !more && IsPostgresCleartextPasswordRequest(response[:received]) {
+		span.AddEvent("Authenticating with an IAM auth token")
+		received, response, more, err = pr.authenticateWithIAM(conn, client, correlation)
+		if err != nil {
+			span.RecordError(err)
+			stack.PopLastRequest()
+			return err
+		}
+	}
+
+	// Answer the Parse messages of the statements prepared on the cached statements.
+	if pr.StatementCache != nil {
+		response = pr.StatementCache.Complete(client, response[:received])
+		received = len(response)
+	}
 
 	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), pr.pluginTimeout)
 	defer cancel()
@@ -531,28 +1213,76 @@ func (pr *Proxy) PassThroughToClient(conn *ConnWrapper, stack *Stack) *gerr.Gate
 	request := make([]byte, 0)
 	if lastRequest != nil {
 		request = lastRequest.Data
+		if !lastRequest.Time.IsZero() {
+			pr.observeQueryLatency(conn, time.Since(lastRequest.Time), correlation)
+		}
+	}
+
+	// The response is larger than the maximum message size.
+	if more {
+		logger.Debug().Fields(
+			map[string]interface{}{
+				"maxMessageSize":   pr.MaxMessageSize,
+				"oversizeBehavior": pr.OversizeBehavior,
+			},
+		).Msg("Response is larger than the maximum message size")
+		metrics.ProxyOversizeMessages.Inc()
+
+		switch pr.OversizeBehavior {
+		case config.Stream:
+			return pr.streamTrafficToClient(
+				conn, client, request, response[:received], correlation, logger)
+		case config.Truncate:
+			// Keep the complete messages within the maximum size, and discard the rest
+			// of the response, but for the transaction status of its ReadyForQuery.
+			kept := response[:PostgresMessagesLength(response[:received])]
+			tail := append([]byte(nil), response[max(received-6, 0):received]...)
+			for more && err == nil {
+				var discarded []byte
+				received, discarded, more, err = pr.receiveTrafficFromServer(
+					client, pr.MaxMessageSize, correlation)
+				if err == nil {
+					tail = append(tail, discarded[:received]...)
+					tail = tail[max(len(tail)-6, 0):]
+					if pr.StatementCache != nil {
+						pr.StatementCache.Complete(client, discarded[:received])
+					}
+				}
+			}
+			if err != nil {
+				return err
+			}
+			// The client gets an error instead of the rest of the response, so that it
+			// can still parse it.
+			ready := PostgreSQLReadyForQuery()
+			if status := PostgresTransactionStatus(tail); status != 0 {
+				ready[5] = status
+			}
+			response = append(kept[:len(kept):len(kept)],
+				ErrorResponse(pr.ErrorMessages, config.ErrorResponseTruncated, "")...)
+			response = append(response, ready...)
+			received = len(response)
+			span.AddEvent("Truncated the response")
+		default:
+			span.RecordError(gerr.ErrMessageTooLarge)
+			pr.rejectOversizeMessage(conn, correlation)
+			return gerr.ErrMessageTooLarge
+		}
 	}
 
 	// Run the OnTrafficFromServer hooks.
-	result, err := pr.pluginRegistry.Run(
+	result, err := pr.pluginRegistry.RunTyped(
 		pluginTimeoutCtx,
 		trafficData(
 			conn.Conn(),
 			client,
-			[]Field{
-				{
-					Name:  "request",
-					Value: request,
-				},
-				{
-					Name:  "response",
-					Value: response[:received],
-				},
-			},
+			correlation,
+			request,
+			response[:received],
 			err),
 		v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_SERVER)
 	if err != nil {
-		pr.logger.Error().Err(err).Msg("Error running hook")
+		logger.Error().Err(err).Msg("Error running hook")
 		span.RecordError(err)
 	}
 	span.AddEvent("Ran the OnTrafficFromServer hooks")
@@ -564,37 +1294,55 @@ func (pr *Proxy) PassThroughToClient(conn *ConnWrapper, stack *Stack) *gerr.Gate
 		span.AddEvent("Plugin(s) modified the response")
 	}
 
+	pr.throttle(conn, Egress, received, logger)
+	pr.recordUsage(conn, Egress, response[:received])
+	pr.trackTransaction(conn, response[:received], correlation)
+	response = pr.limitResult(conn, client, response[:received], correlation)
+	received = len(response)
+
+	// Announce the compression negotiated with the startup message along with its
+	// response, which is the last data sent to the client uncompressed.
+	outgoing, outgoingLength := response, received
+	compression := conn.PendingCompression()
+	if compression != "" {
+		outgoing = append(
+			PostgreSQLParameterStatus(CompressionParameter, string(compression)),
+			response[:received]...)
+		outgoingLength = len(outgoing)
+	}
+
 	// Send the response to the client.
-	errVerdict := pr.sendTrafficToClient(conn.Conn(), response, received)
+	outgoing = pr.injectFaults(client, Egress, outgoing[:outgoingLength], logger)
+	outgoingLength = len(outgoing)
+	errVerdict := pr.sendTrafficToClient(conn.Conn(), outgoing, outgoingLength, correlation)
 	span.AddEvent("Sent traffic to client")
+	if compression != "" {
+		conn.CompressWrites()
+		span.AddEvent("Compressing the traffic to the client")
+	}
 
 	// Run the OnTrafficToClient hooks.
 	pluginTimeoutCtx, cancel = context.WithTimeout(context.Background(), pr.pluginTimeout)
 	defer cancel()
 
-	_, err = pr.pluginRegistry.Run(
+	_, err = pr.pluginRegistry.RunTyped(
 		pluginTimeoutCtx,
 		trafficData(
 			conn.Conn(),
 			client,
-			[]Field{
-				{
-					Name:  "request",
-					Value: request,
-				},
-				{
-					Name:  "response",
-					Value: response[:received],
-				},
-			},
+			correlation,
+			request,
+			response[:received],
 			nil,
 		),
 		v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_CLIENT)
 	if err != nil {
-		pr.logger.Error().Err(err).Msg("Error running hook")
+		logger.Error().Err(err).Msg("Error running hook")
 		span.RecordError(err)
 	}
 
+	pr.flushOnTransactionEnd(conn, response[:received])
+
 	if errVerdict != nil {
 		span.RecordError(errVerdict)
 	}
@@ -604,44 +1352,772 @@ func (pr *Proxy) PassThroughToClient(conn *ConnWrapper, stack *Stack) *gerr.Gate
 	return errVerdict
 }
 
-// IsHealthy checks if the pool is exhausted or the client is disconnected.
-func (pr *Proxy) IsHealthy(client *Client) (*Client, *gerr.GatewayDError) {
-	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "IsHealthy")
+// streamTrafficToClient sends a large response to the client in chunks as it is
+// received from the server, so that the memory used is bounded by the maximum
+// message size. The hooks run once the whole response is sent, with the first chunk
+// as the response and a summary of the stream, so they can't modify the response.
+func (pr *Proxy) streamTrafficToClient(
+	conn *ConnWrapper,
+	client *Client,
+	request, chunk []byte,
+	correlation Correlation,
+	logger zerolog.Logger,
+) *gerr.GatewayDError {
+	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "streamTrafficToClient")
 	defer span.End()
+	span.SetAttributes(correlation.Attributes()...)
 
-	if pr.IsExhausted() {
-		pr.logger.Error().Msg("No more available connections")
-		span.RecordError(gerr.ErrPoolExhausted)
-		return client, gerr.ErrPoolExhausted
+	head := chunk
+	size := 0
+	chunks := 0
+	more := true
+
+	var errVerdict *gerr.GatewayDError
+	for {
+		pr.throttle(conn, Egress, len(chunk), logger)
+		pr.recordUsage(conn, Egress, chunk)
+		pr.trackTransaction(conn, chunk, correlation)
+		chunk = pr.limitResult(conn, client, chunk, correlation)
+		if errVerdict = pr.sendTrafficToClient(conn.Conn(), chunk, len(chunk), correlation); errVerdict != nil {
+			break
+		}
+		size += len(chunk)
+		chunks++
+
+		if !more {
+			break
+		}
+
+		var received int
+		received, chunk, more, errVerdict = pr.receiveTrafficFromServer(
+			client, pr.MaxMessageSize, correlation)
+		if errVerdict != nil || received == 0 {
+			break
+		}
+		chunk = chunk[:received]
+		if pr.StatementCache != nil {
+			chunk = pr.StatementCache.Complete(client, chunk)
+		}
 	}
 
-	if !client.IsConnected() {
-		pr.logger.Error().Msg("Client is disconnected")
-		span.RecordError(gerr.ErrClientNotConnected)
+	logger.Debug().Fields(
+		map[string]interface{}{
+			"size":   size,
+			"chunks": chunks,
+		},
+	).Msg("Streamed the response to the client")
+	span.AddEvent("Streamed the response to the client")
+	metrics.ProxyStreamedResponses.Inc()
+
+	summary := &sdk.Stream{Size: size, Chunks: chunks, Complete: errVerdict == nil && !more}
+	for _, hookName := range []v1.HookName{
+		v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_SERVER,
+		v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_CLIENT,
+	} {
+		data := trafficData(
+			conn.Conn(),
+			client,
+			correlation,
+			request,
+			head,
+			errVerdict,
+		)
+		data.Stream = summary
+
+		pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), pr.pluginTimeout)
+		if _, err := pr.pluginRegistry.RunTyped(pluginTimeoutCtx, data, hookName); err != nil {
+			logger.Error().Err(err).Msg("Error running hook")
+			span.RecordError(err)
+		}
+		cancel()
 	}
 
-	return client, nil
+	if errVerdict != nil {
+		span.RecordError(errVerdict)
+	}
+
+	metrics.ProxyPassThroughsToClient.Inc()
+
+	return errVerdict
 }
 
-// IsExhausted checks if the available connection pool is exhausted.
-func (pr *Proxy) IsExhausted() bool {
-	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "IsExhausted")
-	defer span.End()
+// rejectOversizeMessage tells the client that the message is too large, before
+// the connection is closed.
+func (pr *Proxy) rejectOversizeMessage(conn *ConnWrapper, correlation Correlation) {
+	response := ErrorResponse(pr.ErrorMessages, config.ErrorMessageTooLarge, "")
+	//nolint:errcheck
+	pr.sendTrafficToClient(conn.Conn(), response, len(response), correlation)
+}
 
-	if pr.Elastic {
+// ServerConn returns the database connection that the client connection is
+// proxied to, or nil if it is not connected.
+func (pr *Proxy) ServerConn(conn *ConnWrapper) net.Conn {
+	if client, ok := pr.busyConnections.Get(conn).(*Client); ok && client != nil {
+		return client.conn
+	}
+	return nil
+}
+
+// CanSplice checks if the traffic of the connection can take the fast path, which
+// requires that no traffic hooks are registered and that no feature of the proxy
+// needs to decode or account for the traffic, such as TLS termination, correlation
+// ID injection, concurrency limiting, bandwidth throttling, query counting,
+// statement timeouts, idle transaction timeouts, connection memory limits, error
+// counting, compression and IAM authentication.
+func (pr *Proxy) CanSplice(conn *ConnWrapper) bool {
+	if !pr.FastPath || !spliceSupported || conn.IsTLSEnabled() {
 		return false
 	}
 
-	return pr.availableConnections.Size() == 0 && pr.availableConnections.Cap() > 0
+	if pr.InjectCorrelationIDs || pr.Limiter != nil || pr.Throttler != nil ||
+		pr.Usage != nil || pr.QueryStats != nil || pr.Firewall != nil || pr.Sharding != nil ||
+		pr.Faults != nil || pr.StatementCache != nil || pr.Affinity != nil ||
+		pr.ReadWriteSplit != nil || pr.ResultLimits != nil || pr.StatementTimeouts != nil ||
+		pr.IdleTransactions != nil || pr.MaxConnectionMemory > 0 || pr.ErrorStats != nil ||
+		len(pr.CompressionAlgorithms) > 0 ||
+		len(pr.StartupParameters) > 0 {
+		return false
+	}
+
+	// The spliced traffic isn't decoded, so the SSL requests of the clients would reach
+	// the server over its TLS connection, and the password requests of the server would
+	// reach the clients instead of being answered with the IAM auth tokens.
+	if client, ok := pr.busyConnections.Get(conn).(*Client); ok &&
+		(client.TLSConfig != nil || client.IAMAuth != nil) {
+		return false
+	}
+
+	hooks := pr.pluginRegistry.Hooks()
+	for _, hookName := range []v1.HookName{
+		v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT,
+		v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_SERVER,
+		v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_SERVER,
+		v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_CLIENT,
+		sdk.OnTransactionStart,
+		sdk.OnTransactionEnd,
+	} {
+		if len(hooks[hookName]) > 0 {
+			return false
+		}
+	}
+
+	return true
 }
 
-// Shutdown closes all connections and clears the connection pools.
-func (pr *Proxy) Shutdown() {
-	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "Shutdown")
+// Splice passes the traffic between the client and the database in both directions,
+// without copying it to userspace, until either side closes the connection. Since the
+// traffic is not decoded, SSL requests are answered by the database.
+func (pr *Proxy) Splice(conn *ConnWrapper) *gerr.GatewayDError {
+	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "Splice")
 	defer span.End()
 
-	pr.availableConnections.ForEach(func(key, value interface{}) bool {
-		if client, ok := value.(*Client); ok {
+	correlation := Correlation{ConnectionID: conn.ID()}
+	logger := correlation.Logger(pr.logger)
+	span.SetAttributes(correlation.Attributes()...)
+
+	client, ok := pr.busyConnections.Get(conn).(*Client)
+	if !ok || client == nil {
+		span.RecordError(gerr.ErrClientNotFound)
+		return gerr.ErrClientNotFound
+	}
+
+	if !client.IsConnected() {
+		return gerr.ErrClientNotConnected
+	}
+
+	logger.Debug().Msg("Splicing the traffic between the client and the database")
+	if value, ok := pr.sessions.Load(conn); ok {
+		value.(*session).state.Store(Spliced) //nolint:forcetypeassert
+	}
+	metrics.ProxySplicedConnections.Inc()
+
+	type result struct {
+		direction Direction
+		copied    int64
+		err       error
+	}
+	results := make(chan result, 2) //nolint:gomnd
+	go func() {
+		copied, err := spliceConn(client.conn, conn.Conn())
+		results <- result{Ingress, copied, err}
+	}()
+	go func() {
+		copied, err := spliceConn(conn.Conn(), client.conn)
+		results <- result{Egress, copied, err}
+	}()
+
+	// Once either side is done, unblock the other direction.
+	first := <-results
+	now := time.Now()
+	_ = conn.Conn().SetReadDeadline(now)
+	_ = client.conn.SetReadDeadline(now)
+	second := <-results
+	_ = client.conn.SetReadDeadline(time.Time{})
+
+	copied := map[Direction]int64{}
+	for _, res := range []result{first, second} {
+		copied[res.direction] = res.copied
+		metrics.TotalTrafficBytes.Observe(float64(res.copied))
+	}
+	metrics.BytesSentToServer.Observe(float64(copied[Ingress]))
+	metrics.BytesSentToClient.Observe(float64(copied[Egress]))
+
+	logger.Debug().Fields(
+		map[string]interface{}{
+			"ingress": copied[Ingress],
+			"egress":  copied[Egress],
+		},
+	).Msg("Finished splicing the traffic")
+
+	if first.err != nil {
+		span.RecordError(first.err)
+		return gerr.ErrReadFailed.Wrap(first.err)
+	}
+
+	return nil
+}
+
+// popAvailableClient pops the first available client from the pool. It returns nil
+// if the pool is empty.
+func (pr *Proxy) popAvailableClient() *Client {
+	var client *Client
+	pr.availableConnections.ForEach(func(key, _ interface{}) bool {
+		// Another connection might have popped the client in the meantime.
+		if cl, ok := pr.availableConnections.Pop(key).(*Client); ok {
+			client = cl
+			return false // stop the loop.
+		}
+		return true
+	})
+	return client
+}
+
+// newClient creates a new client, e.g. for the elastic pools.
+func (pr *Proxy) newClient() *Client {
+	return NewClient(
+		pr.ctx, pr.clientConfig(), pr.logger,
+		NewClientRetry(pr.ClientConfig, pr.logger),
+	)
+}
+
+// releaseClient recycles the client a session of another proxy borrowed, e.g. for a
+// shard, by reconnecting it, and puts it back in the pool.
+func (pr *Proxy) releaseClient(client *Client) {
+	if pr.Elastic && !pr.ReuseElasticClients {
+		client.Close()
+		return
+	}
+	if err := pr.reconnectClient(client, ReconnectReleased); err != nil {
+		pr.logger.Error().Err(err).Msg("Failed to reconnect to the client")
+	}
+	if err := pr.availableConnections.Put(client.ID, client); err != nil {
+		pr.logger.Error().Err(err).Msg("Failed to put the client back in the pool")
+		client.Close()
+	} else {
+		pr.notifyReleased()
+	}
+}
+
+// The reasons of the reconnections of the clients of the pool to the server.
+const (
+	ReconnectReleased        = "released"
+	ReconnectHealthCheck     = "healthCheck"
+	ReconnectBackendMoved    = "backendMoved"
+	ReconnectSessionRecovery = "sessionRecovery"
+)
+
+// reconnectClient reconnects the client to the server, with the backoff and jitter of
+// its retries, and notifies the OnBackendReconnect hooks of the reason and outcome.
+func (pr *Proxy) reconnectClient(client *Client, reason string) error {
+	// The statements cached on the server connection die with it.
+	if pr.StatementCache != nil {
+		pr.StatementCache.Forget(client)
+	}
+	start := time.Now()
+	err := client.Reconnect()
+	if err != nil {
+		metrics.ProxyBackendFailures.Inc()
+	}
+	pr.backendReconnected(client.Network, client.Address, reason, time.Since(start), err)
+	return err
+}
+
+// backendReconnected counts the reconnection of a client of the pool to the server,
+// and runs the OnBackendReconnect hooks.
+func (pr *Proxy) backendReconnected(
+	network, address, reason string, duration time.Duration, err error,
+) {
+	metrics.ProxyBackendReconnects.WithLabelValues(
+		reason, config.If[string](err == nil, "success", "failure")).Inc()
+	if pr.pluginRegistry == nil {
+		return
+	}
+
+	args := map[string]interface{}{
+		"network":     network,
+		"address":     address,
+		"reason":      reason,
+		"duration":    duration.String(),
+		"reconnected": err == nil,
+	}
+	if err != nil {
+		args["error"] = err.Error()
+	}
+	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), pr.pluginTimeout)
+	defer cancel()
+	if _, err := pr.pluginRegistry.Run(pluginTimeoutCtx, args, sdk.OnBackendReconnect); err != nil {
+		pr.logger.Error().Err(err).Msg("Failed to run the OnBackendReconnect hooks")
+	}
+}
+
+// waitForClient queues the incoming connection until a client is released back to
+// the pool. It fails if the queue is full or if no client is released in time.
+func (pr *Proxy) waitForClient(logger zerolog.Logger) (*Client, *gerr.GatewayDError) {
+	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "waitForClient")
+	defer span.End()
+
+	if pr.queued.Add(1) > int32(pr.QueueSize) {
+		pr.queued.Add(-1)
+		metrics.ProxyQueueRejections.Inc()
+		logger.Error().Int("queueSize", pr.QueueSize).Msg(
+			"Pool is exhausted and the connection queue is full")
+		span.RecordError(gerr.ErrPoolQueueFull)
+		return nil, gerr.ErrPoolQueueFull
+	}
+	metrics.ProxyQueueDepth.Inc()
+	defer func() {
+		pr.queued.Add(-1)
+		metrics.ProxyQueueDepth.Dec()
+	}()
+
+	logger.Debug().Msg("Pool is exhausted, waiting for an available connection")
+	start := time.Now()
+	timer := time.NewTimer(config.If[time.Duration](
+		pr.QueueTimeout > 0, pr.QueueTimeout, config.DefaultQueueTimeout))
+	defer timer.Stop()
+
+	for {
+		if client := pr.popAvailableClient(); client != nil {
+			metrics.ProxyQueueWaitDuration.Observe(time.Since(start).Seconds())
+			span.AddEvent("Got an available connection")
+			return client, nil
+		}
+
+		select {
+		case <-pr.released:
+		case <-timer.C:
+			metrics.ProxyQueueRejections.Inc()
+			logger.Error().Str("queueTimeout", pr.QueueTimeout.String()).Msg(
+				"Timed out waiting for an available connection")
+			span.RecordError(gerr.ErrPoolQueueTimeout)
+			return nil, gerr.ErrPoolQueueTimeout
+		}
+	}
+}
+
+// notifyReleased wakes up a queued connection, if any, after a client
+// is put back in the pool.
+func (pr *Proxy) notifyReleased() {
+	select {
+	case pr.released <- struct{}{}:
+	default:
+	}
+}
+
+// acquireSlot takes a limiter slot of the priority class for the connection, unless
+// it already holds one, e.g. for a query sent in several messages.
+func (pr *Proxy) acquireSlot(conn *ConnWrapper, class int) *gerr.GatewayDError {
+	if _, ok := pr.inFlight.Load(conn); ok {
+		return nil
+	}
+
+	if err := pr.Limiter.AcquireClass(class, config.If[time.Duration](
+		pr.LimiterQueueTimeout > 0, pr.LimiterQueueTimeout, config.DefaultLimiterQueueTimeout),
+	); err != nil {
+		return err
+	}
+	pr.inFlight.Store(conn, time.Now())
+
+	return nil
+}
+
+// releaseSlot releases the limiter slot held by the connection, if any, and
+// reports the latency of the query to the limiter.
+func (pr *Proxy) releaseSlot(conn *ConnWrapper, dropped bool) {
+	if pr.Limiter == nil {
+		return
+	}
+
+	if acquired, ok := pr.inFlight.LoadAndDelete(conn); ok {
+		if start, ok := acquired.(time.Time); ok {
+			latency := time.Since(start)
+			metrics.ProxyBackendLatency.Observe(latency.Seconds())
+			pr.Limiter.Release(latency, dropped)
+		}
+	}
+}
+
+// negotiateCompression compresses the traffic of the connection with the first of the
+// algorithms requested in its startup message that is enabled. It returns the startup
+// message without the request, since the database doesn't know about it.
+func (pr *Proxy) negotiateCompression(
+	conn *ConnWrapper, request []byte, parameters map[string]string, logger zerolog.Logger,
+) []byte {
+	requested, ok := parameters[CompressionParameter]
+	if !ok {
+		return request
+	}
+	request = RemovePostgresStartupParameter(request, CompressionParameter)
+
+	algorithm := NegotiateCompression(requested, pr.CompressionAlgorithms)
+	if algorithm == "" {
+		logger.Debug().Str("requested", requested).Msg(
+			"None of the requested compression algorithms are enabled")
+		return request
+	}
+
+	if err := conn.EnableCompression(algorithm, pr.CompressionLevel); err != nil {
+		logger.Error().Err(err).Msg("Failed to enable compression")
+		return request
+	}
+	metrics.ProxyCompressedConnections.Inc()
+	logger.Debug().Str("algorithm", string(algorithm)).Msg("Negotiated compression")
+
+	return request
+}
+
+// injectStartupParameters sets the configured parameters in the startup message of
+// the connection. The values are expanded with the ${connectionId} of the connection,
+// the ${value} the client set, if any, and the other parameters of the client, e.g.
+// ${user}. The parameters of the connection keep the values the client set.
+func (pr *Proxy) injectStartupParameters(
+	conn *ConnWrapper, request []byte, parameters map[string]string,
+) []byte {
+	for _, parameter := range pr.StartupParameters {
+		current, ok := parameters[parameter.Name]
+		if ok && !parameter.Override {
+			continue
+		}
+
+		value := os.Expand(parameter.Value, func(name string) string {
+			switch name {
+			case "connectionId":
+				return conn.ID()
+			case "value":
+				return current
+			default:
+				return parameters[name]
+			}
+		})
+		request = SetPostgresStartupParameter(request, parameter.Name, value)
+	}
+
+	return request
+}
+
+// restoreSettings replays the SET statements of the session on its server connection,
+// e.g. after it was replaced, or forgets them if the reset policy discards them. The
+// statements that fail are skipped.
+func (pr *Proxy) restoreSettings(
+	conn *ConnWrapper, client *Client, correlation Correlation,
+) *gerr.GatewayDError {
+	value, ok := pr.sessions.Load(conn)
+	if !ok {
+		return nil
+	}
+	settings := &value.(*session).settings //nolint:forcetypeassert
+
+	if pr.ResetPolicy == config.Discard {
+		settings.mu.Lock()
+		settings.clear()
+		settings.mu.Unlock()
+		return nil
+	}
+
+	logger := correlation.Logger(pr.logger)
+	for _, statement := range settings.Statements() {
+		if _, err := pr.sendTrafficToServer(client, PostgreSQLQuery(statement), correlation); err != nil {
+			return err
+		}
+
+		// Read the whole response, up to the ReadyForQuery message.
+		var response []byte
+		for PostgresTransactionStatus(response) == 0 {
+			_, received, _, err := pr.receiveTrafficFromServer(client, 0, correlation)
+			if err != nil {
+				return err
+			}
+			response = append(response, received...)
+		}
+
+		if message := PostgresErrorMessage(response); message != "" {
+			logger.Warn().Str("statement", statement).Str("error", message).Msg(
+				"Failed to replay the SET statement")
+		}
+	}
+
+	return nil
+}
+
+// authenticateWithIAM sends an IAM auth token for the user of the connection as its
+// password, and returns the response of the server to it.
+func (pr *Proxy) authenticateWithIAM(
+	conn *ConnWrapper, client *Client, correlation Correlation,
+) (int, []byte, bool, *gerr.GatewayDError) {
+	user := pr.startupParameters(conn)["user"]
+	password, err := client.IAMAuth.Password(context.Background(), user)
+	if err != nil {
+		logger := correlation.Logger(pr.logger)
+		logger.Error().Err(err).Str("user", user).Msg(
+			"Failed to get the IAM auth token")
+		return 0, nil, false, err
+	}
+
+	if _, err := pr.sendTrafficToServer(
+		client, PostgreSQLPasswordMessage(password), correlation); err != nil {
+		return 0, nil, false, err
+	}
+	return pr.receiveTrafficFromServer(client, pr.MaxMessageSize, correlation)
+}
+
+// startupParameters returns the startup parameters of the connection, or nil if the
+// client hasn't sent its startup message yet.
+func (pr *Proxy) startupParameters(conn *ConnWrapper) map[string]string {
+	if value, ok := pr.parameters.Load(conn); ok {
+		if parameters, ok := value.(map[string]string); ok {
+			return parameters
+		}
+	}
+	return nil
+}
+
+// metricLabels returns the values of the connection labels of the metrics, from the
+// startup parameters of the connection.
+func (pr *Proxy) metricLabels(parameters map[string]string) map[string]string {
+	return map[string]string{
+		metrics.LabelServer:      pr.Name,
+		metrics.LabelProxy:       pr.Name,
+		metrics.LabelUser:        parameters["user"],
+		metrics.LabelDatabase:    parameters["database"],
+		metrics.LabelApplication: parameters["application_name"],
+	}
+}
+
+// observeQueryLatency records the latency of the query, with its correlation IDs as
+// the exemplar, and sends it to the onMetric hooks with the user, database and
+// application name of the connection, so plugins can enrich it.
+func (pr *Proxy) observeQueryLatency(
+	conn *ConnWrapper, latency time.Duration, correlation Correlation,
+) {
+	metrics.ObserveWithExemplar(metrics.ProxyQueryLatency, latency.Seconds(), correlation.Labels())
+
+	parameters := pr.startupParameters(conn)
+	pr.pluginRegistry.EmitMetric(plugin.MetricSample{
+		Name:  "proxy_query_latency_seconds",
+		Type:  plugin.Histogram,
+		Value: latency.Seconds(),
+		Labels: map[string]string{
+			"user":             parameters["user"],
+			"database":         parameters["database"],
+			"application_name": parameters["application_name"],
+		},
+	})
+}
+
+// recordUsage accounts the traffic of the connection, and of its user and database if
+// usage accounting is enabled.
+func (pr *Proxy) recordUsage(conn *ConnWrapper, direction Direction, data []byte) {
+	if value, ok := pr.sessions.Load(conn); ok {
+		value.(*session).record(direction, data) //nolint:forcetypeassert
+	}
+	if direction == Egress {
+		pr.recordErrors(conn, data)
+	}
+	if pr.Usage != nil {
+		pr.Usage.Record(conn, direction, data)
+	}
+}
+
+// throttle waits until the traffic of the connection's user is allowed through.
+func (pr *Proxy) throttle(conn *ConnWrapper, direction Direction, length int, logger zerolog.Logger) {
+	if pr.Throttler == nil || !pr.isThrottled(conn) {
+		return
+	}
+
+	user := pr.startupParameters(conn)["user"]
+
+	if waited := pr.Throttler.Wait(pr.ctx, user, direction, length); waited > 0 {
+		logger.Trace().Fields(
+			map[string]interface{}{
+				"direction": direction,
+				"user":      user,
+				"waited":    waited.String(),
+			},
+		).Msg("Throttled the traffic")
+
+		if direction == Ingress {
+			metrics.ProxyIngressThrottled.Add(waited.Seconds())
+		} else {
+			metrics.ProxyEgressThrottled.Add(waited.Seconds())
+		}
+	}
+}
+
+// isThrottled returns whether the throttle policy matches the connection. The
+// decision is cached once the startup parameters of the connection are known.
+func (pr *Proxy) isThrottled(conn *ConnWrapper) bool {
+	if pr.ThrottlePolicy == nil {
+		return true
+	}
+	if throttled, ok := pr.throttled.Load(conn); ok {
+		return throttled.(bool) //nolint:forcetypeassert
+	}
+
+	throttled, err := pr.ThrottlePolicy.Matches(pr.policyVars(conn, ""))
+	if err != nil {
+		pr.logger.Debug().Err(err).Msg(
+			"Failed to evaluate the throttle policy, so the connection isn't throttled")
+	}
+	if pr.startupParameters(conn) != nil {
+		pr.throttled.Store(conn, throttled)
+	}
+	return throttled
+}
+
+// isLimited returns whether the limit policy matches a query of the request. A query
+// whose policy fails to evaluate, e.g. on a missing field, isn't limited.
+func (pr *Proxy) isLimited(conn *ConnWrapper, request []byte) bool {
+	if pr.LimitPolicy == nil {
+		return true
+	}
+	for _, query := range PostgresQueries(request) {
+		limited, err := pr.LimitPolicy.Matches(pr.policyVars(conn, query))
+		if err != nil {
+			pr.logger.Debug().Err(err).Msg(
+				"Failed to evaluate the limit policy, so the query isn't limited")
+		}
+		if limited {
+			return true
+		}
+	}
+	return false
+}
+
+// priorityClass returns the index of the priority class of the request: the class the
+// OnTrafficFromClient hooks put it in, or the first class whose policy matches a query
+// of the request, or the default class after the others.
+func (pr *Proxy) priorityClass(conn *ConnWrapper, request []byte, result *v1.Struct) int {
+	if name := result.GetFields()[PriorityField].GetStringValue(); name != "" {
+		for index, class := range pr.PriorityClasses {
+			if class.Name == name {
+				return index
+			}
+		}
+	}
+
+	for index, class := range pr.PriorityClasses {
+		if class.Policy == nil {
+			continue
+		}
+		for _, query := range PostgresQueries(request) {
+			matches, err := class.Policy.Matches(pr.policyVars(conn, query))
+			if err != nil {
+				pr.logger.Debug().Err(err).Str("class", class.Name).Msg(
+					"Failed to evaluate the policy of the priority class, so it doesn't match")
+			}
+			if matches {
+				return index
+			}
+		}
+	}
+	return len(pr.PriorityClasses)
+}
+
+// checkFirewall returns the firewall rule that denies a query of the request, or nil
+// if they are all allowed.
+func (pr *Proxy) checkFirewall(conn *ConnWrapper, request []byte) *FirewallRule {
+	if pr.Firewall == nil {
+		return nil
+	}
+	for _, query := range PostgresQueries(request) {
+		if rule := pr.Firewall.Check(pr.policyVars(conn, query)); rule != nil {
+			return rule
+		}
+	}
+	return nil
+}
+
+// policyVars returns the variables of the policy expressions for the connection and
+// the query, if any.
+func (pr *Proxy) policyVars(conn *ConnWrapper, query string) map[string]any {
+	parameters := pr.startupParameters(conn)
+	return map[string]any{
+		"request": map[string]any{
+			"user":        parameters["user"],
+			"db":          parameters["database"],
+			"application": parameters["application_name"],
+			"client":      RemoteAddr(conn.Conn()),
+			"query":       query,
+		},
+	}
+}
+
+// flushOnTransactionEnd sends the pending batches of the batched hooks once the
+// response ends a transaction, so that the plugins see the whole transaction.
+func (pr *Proxy) flushOnTransactionEnd(conn *ConnWrapper, response []byte) {
+	if !pr.pluginRegistry.Batching() {
+		return
+	}
+
+	status := PostgresTransactionStatus(response)
+	if status == 0 {
+		return
+	}
+
+	previous, loaded := pr.transactions.Swap(conn, status)
+	if status == 'I' && loaded && previous != byte('I') {
+		pr.pluginRegistry.Flush()
+	}
+}
+
+// IsHealthy checks if the pool is exhausted or the client is disconnected.
+func (pr *Proxy) IsHealthy(client *Client) (*Client, *gerr.GatewayDError) {
+	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "IsHealthy")
+	defer span.End()
+
+	if pr.IsExhausted() {
+		pr.logger.Error().Msg("No more available connections")
+		span.RecordError(gerr.ErrPoolExhausted)
+		return client, gerr.ErrPoolExhausted
+	}
+
+	if !client.IsConnected() {
+		pr.logger.Error().Msg("Client is disconnected")
+		span.RecordError(gerr.ErrClientNotConnected)
+	}
+
+	return client, nil
+}
+
+// IsExhausted checks if the available connection pool is exhausted.
+func (pr *Proxy) IsExhausted() bool {
+	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "IsExhausted")
+	defer span.End()
+
+	if pr.Elastic {
+		return false
+	}
+
+	return pr.availableConnections.Size() == 0 && pr.availableConnections.Cap() > 0
+}
+
+// Shutdown closes all connections and clears the connection pools.
+func (pr *Proxy) Shutdown() {
+	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "Shutdown")
+	defer span.End()
+
+	pr.availableConnections.ForEach(func(key, value interface{}) bool {
+		if client, ok := value.(*Client); ok {
 			if client.IsConnected() {
 				client.Close()
 			}
@@ -706,19 +2182,228 @@ func (pr *Proxy) BusyConnections() []string {
 	return connections
 }
 
+// Queries returns the number of the requests that started a query since the proxy was
+// created, which excludes the spliced traffic.
+func (pr *Proxy) Queries() uint64 {
+	return pr.queries.Load()
+}
+
+// Connections returns the client connections, with their user, database, state and
+// traffic.
+func (pr *Proxy) Connections() []ConnectionInfo {
+	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "Connections")
+	defer span.End()
+
+	connections := make([]ConnectionInfo, 0)
+	pr.sessions.Range(func(key, value interface{}) bool {
+		conn, connOk := key.(*ConnWrapper)
+		session, sessionOk := value.(*session)
+		if connOk && sessionOk {
+			connections = append(connections, session.info(conn, pr.startupParameters(conn)))
+		}
+		return true
+	})
+	return connections
+}
+
+// Kill closes the client connection with the ID, which disconnects it from the
+// server. It returns false if there is no such connection.
+func (pr *Proxy) Kill(id string) bool {
+	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "Kill")
+	defer span.End()
+
+	killed := false
+	pr.sessions.Range(func(key, _ interface{}) bool {
+		conn, ok := key.(*ConnWrapper)
+		if !ok || conn.ID() != id {
+			return true
+		}
+		correlation := Correlation{ConnectionID: id}
+		logger := correlation.Logger(pr.logger)
+		if err := conn.Close(); err != nil {
+			logger.Debug().Err(err).Msg("Failed to close the killed connection")
+		}
+		logger.Info().Msg("Killed the client connection")
+		killed = true
+		return false
+	})
+	return killed
+}
+
+// WatchBackend re-resolves the hostname of the address of the clients on the resolve
+// interval of the client config, if any. The hostname can also be re-resolved with
+// ResolveBackend.
+func (pr *Proxy) WatchBackend() {
+	if pr.ClientConfig == nil || pr.ClientConfig.SSHTunnel.Enabled ||
+		pr.ClientConfig.UpstreamProxy.URL != "" || pr.Cluster != nil {
+		return
+	}
+
+	pr.Backend = NewBackendResolver(
+		pr.ClientConfig.Network, pr.ClientConfig.Address, pr.ClientConfig.ResolveInterval)
+	if pr.Backend == nil {
+		return
+	}
+	if _, err := pr.ResolveBackend(); err != nil {
+		pr.logger.Error().Err(err).Str("address", pr.Backend.Address).Msg(
+			"Failed to resolve the backend")
+	}
+
+	if pr.Backend.Interval <= 0 {
+		return
+	}
+	if _, err := pr.scheduler.Every(pr.Backend.Interval).SingletonMode().StartAt(
+		time.Now().Add(pr.Backend.Interval)).Do(func() {
+		if _, err := pr.ResolveBackend(); err != nil {
+			pr.logger.Error().Err(err).Str("address", pr.Backend.Address).Msg(
+				"Failed to re-resolve the backend")
+		}
+	}); err != nil {
+		pr.logger.Error().Err(err).Msg("Failed to schedule the backend re-resolution")
+		sentry.CaptureException(err)
+	}
+	pr.logger.Info().Str("address", pr.Backend.Address).Str(
+		"resolveInterval", pr.Backend.Interval.String()).Msg("Re-resolving the backend periodically")
+}
+
+// ResolveBackend re-resolves the hostname of the address of the clients, and returns
+// true if its IP addresses changed. If so, it runs the OnBackendChanged hooks and
+// reconnects the available clients to the new address. The busy clients are
+// reconnected to it when they are released.
+func (pr *Proxy) ResolveBackend() (bool, *gerr.GatewayDError) {
+	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "ResolveBackend")
+	defer span.End()
+
+	if pr.Backend == nil {
+		return false, nil
+	}
+
+	previous, current, changed, err := pr.Backend.Resolve(pr.ctx)
+	if err != nil {
+		metrics.BackendResolveFailures.Inc()
+		span.RecordError(err)
+		return false, err
+	}
+	if !changed {
+		return false, nil
+	}
+
+	metrics.BackendAddressChanges.Inc()
+	pr.logger.Info().Str("address", pr.Backend.Address).Strs("previous", previous).Strs(
+		"current", current).Msg("The IP addresses of the backend changed")
+	events.Publish(events.FailoverHappened, "The IP addresses of the backend changed",
+		map[string]interface{}{
+			"address":  pr.Backend.Address,
+			"previous": previous,
+			"current":  current,
+		})
+
+	if pr.pluginRegistry != nil {
+		pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), pr.pluginTimeout)
+		defer cancel()
+		_, err := pr.pluginRegistry.Run(
+			pluginTimeoutCtx,
+			map[string]interface{}{
+				"network":  pr.Backend.Network,
+				"address":  pr.Backend.Address,
+				"previous": previous,
+				"current":  current,
+			},
+			sdk.OnBackendChanged,
+		)
+		if err != nil {
+			pr.logger.Error().Err(err).Msg("Failed to run the OnBackendChanged hooks")
+			span.RecordError(err)
+		}
+	}
+
+	// Reconnect the available clients that are connected to the old addresses.
+	pr.moveAvailableClients(pr.Backend.IsCurrent, pr.Backend.Current)
+
+	return true, nil
+}
+
+// PrimaryChanged reconnects the available clients to the member of the cluster of
+// their role after its primary changed. The busy clients are reconnected to it when
+// they are released.
+func (pr *Proxy) PrimaryChanged(primary string) {
+	if pr.Cluster == nil {
+		return
+	}
+	pr.logger.Info().Str("primary", primary).Str("role", string(pr.ClusterRole)).Msg(
+		"Moving the clients to the new members of the cluster")
+	pr.moveAvailableClients(
+		func(address string) bool { return pr.Cluster.IsCurrent(pr.ClusterRole, address) },
+		func() string { return pr.Cluster.Target(pr.ClusterRole) },
+	)
+}
+
+// moveAvailableClients reconnects the available clients whose address isn't current
+// to the target address.
+func (pr *Proxy) moveAvailableClients(isCurrent func(string) bool, target func() string) {
+	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "moveAvailableClients")
+	defer span.End()
+
+	pr.availableConnections.ForEach(func(key, value interface{}) bool {
+		if client, ok := value.(*Client); !ok || isCurrent(client.Address) {
+			return true
+		}
+		// Another connection might have popped the client in the meantime.
+		client, ok := pr.availableConnections.Pop(key).(*Client)
+		if !ok {
+			return true
+		}
+		if address := target(); address != "" {
+			client.Address = address
+		}
+		if err := pr.reconnectClient(client, ReconnectBackendMoved); err != nil {
+			pr.logger.Error().Err(err).Msg("Failed to reconnect to the new address of the backend")
+			span.RecordError(err)
+		}
+		if err := pr.availableConnections.Put(client.ID, client); err != nil {
+			pr.logger.Error().Err(err).Msg("Failed to put the client back in the pool")
+			span.RecordError(err)
+		} else {
+			pr.notifyReleased()
+		}
+		return true
+	})
+}
+
+// clientConfig returns the config of the new clients, which connect to the member of
+// the cluster of their role instead of the address, if it is known.
+func (pr *Proxy) clientConfig() *config.Client {
+	if pr.Cluster == nil || pr.ClientConfig == nil {
+		return pr.ClientConfig
+	}
+	target := pr.Cluster.Target(pr.ClusterRole)
+	if target == "" {
+		return pr.ClientConfig
+	}
+	clientConfig := *pr.ClientConfig
+	clientConfig.Address = target
+	return &clientConfig
+}
+
 // receiveTrafficFromClient is a function that waits to receive data from the client.
-func (pr *Proxy) receiveTrafficFromClient(conn net.Conn) ([]byte, *gerr.GatewayDError) {
+func (pr *Proxy) receiveTrafficFromClient(
+	conn net.Conn, correlation Correlation,
+) ([]byte, *gerr.GatewayDError) {
 	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "receiveTrafficFromClient")
 	defer span.End()
 
+	logger := correlation.Logger(pr.logger)
+	span.SetAttributes(correlation.Attributes()...)
+
 	// request contains the data from the client.
 	received := 0
+	truncated := false
 	buffer := bytes.NewBuffer(nil)
 	for {
-		chunk := make([]byte, pr.ClientConfig.ReceiveChunkSize)
+		chunk := make([]byte, pr.ReceiveBufferSize)
 		read, err := conn.Read(chunk)
 		if read == 0 || err != nil {
-			pr.logger.Debug().Err(err).Msg("Error reading from client")
+			logger.Debug().Err(err).Msg("Error reading from client")
 			span.RecordError(err)
 
 			metrics.BytesReceivedFromClient.Observe(float64(read))
@@ -728,9 +2413,22 @@ func (pr *Proxy) receiveTrafficFromClient(conn net.Conn) ([]byte, *gerr.GatewayD
 		}
 
 		received += read
-		buffer.Write(chunk[:read])
+		if pr.MaxMessageSize > 0 && received > pr.MaxMessageSize {
+			if pr.OversizeBehavior != config.Truncate {
+				logger.Debug().Int("maxMessageSize", pr.MaxMessageSize).Msg(
+					"Request is larger than the maximum message size")
+				span.RecordError(gerr.ErrMessageTooLarge)
+				metrics.ProxyOversizeMessages.Inc()
+				return buffer.Bytes(), gerr.ErrMessageTooLarge
+			}
+			// Keep reading the rest of the request, but only buffer up to the maximum size.
+			buffer.Write(chunk[:max(pr.MaxMessageSize-buffer.Len(), 0)])
+			truncated = true
+		} else {
+			buffer.Write(chunk[:read])
+		}
 
-		if received == 0 || received < pr.ClientConfig.ReceiveChunkSize {
+		if received == 0 || read < pr.ReceiveBufferSize {
 			break
 		}
 
@@ -739,8 +2437,15 @@ func (pr *Proxy) receiveTrafficFromClient(conn net.Conn) ([]byte, *gerr.GatewayD
 		}
 	}
 
+	if truncated {
+		logger.Debug().Int("maxMessageSize", pr.MaxMessageSize).Msg(
+			"Truncated the request to the maximum message size")
+		span.AddEvent("Truncated the request")
+		metrics.ProxyOversizeMessages.Inc()
+	}
+
 	length := len(buffer.Bytes())
-	pr.logger.Debug().Fields(
+	logging.Sampled(logger, logging.TrafficCategory).Debug().Fields(
 		map[string]interface{}{
 			"length": length,
 			"local":  LocalAddr(conn),
@@ -757,22 +2462,28 @@ func (pr *Proxy) receiveTrafficFromClient(conn net.Conn) ([]byte, *gerr.GatewayD
 }
 
 // sendTrafficToServer is a function that sends data to the server.
-func (pr *Proxy) sendTrafficToServer(client *Client, request []byte) (int, *gerr.GatewayDError) {
+func (pr *Proxy) sendTrafficToServer(
+	client *Client, request []byte, correlation Correlation,
+) (int, *gerr.GatewayDError) {
 	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "sendTrafficToServer")
 	defer span.End()
 
+	logger := correlation.Logger(pr.logger)
+	span.SetAttributes(correlation.Attributes()...)
+
 	if len(request) == 0 {
-		pr.logger.Trace().Msg("Empty request")
+		logger.Trace().Msg("Empty request")
 		return 0, nil
 	}
 
 	// Send the request to the server.
 	sent, err := client.Send(request)
 	if err != nil {
-		pr.logger.Error().Err(err).Msg("Error sending request to database")
+		logger.Error().Err(err).Msg("Error sending request to database")
 		span.RecordError(err)
+		metrics.ProxyBackendFailures.Inc()
 	}
-	pr.logger.Debug().Fields(
+	logging.Sampled(logger, logging.TrafficCategory).Debug().Fields(
 		map[string]interface{}{
 			"function": "proxy.passthrough",
 			"length":   sent,
@@ -790,12 +2501,23 @@ func (pr *Proxy) sendTrafficToServer(client *Client, request []byte) (int, *gerr
 }
 
 // receiveTrafficFromServer is a function that receives data from the server.
-func (pr *Proxy) receiveTrafficFromServer(client *Client) (int, []byte, *gerr.GatewayDError) {
+// If limit is positive, it stops once limit bytes are received and reports whether
+// there is more data to receive.
+func (pr *Proxy) receiveTrafficFromServer(
+	client *Client, limit int, correlation Correlation,
+) (int, []byte, bool, *gerr.GatewayDError) {
 	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "receiveTrafficFromServer")
 	defer span.End()
 
+	logger := correlation.Logger(pr.logger)
+	span.SetAttributes(correlation.Attributes()...)
+
 	// Receive the response from the server.
-	received, response, err := client.Receive()
+	received, response, more, err := client.ReceiveUpTo(limit)
+	if err != nil {
+		span.RecordError(err)
+		metrics.ProxyBackendFailures.Inc()
+	}
 
 	fields := map[string]interface{}{
 		"function": "proxy.passthrough",
@@ -808,23 +2530,28 @@ func (pr *Proxy) receiveTrafficFromServer(client *Client) (int, []byte, *gerr.Ga
 		fields["remote"] = client.RemoteAddr()
 	}
 
-	pr.logger.Debug().Fields(fields).Msg("Received data from database")
+	logging.Sampled(logger, logging.TrafficCategory).Debug().Fields(fields).Msg("Received data from database")
 
 	span.AddEvent("Received data from database")
 
 	metrics.BytesReceivedFromServer.Observe(float64(received))
 	metrics.TotalTrafficBytes.Observe(float64(received))
 
-	return received, response, err
+	return received, response, more, err
 }
 
 // sendTrafficToClient is a function that sends data to the client.
 func (pr *Proxy) sendTrafficToClient(
-	conn net.Conn, response []byte, received int,
+	conn net.Conn, response []byte, received int, correlation Correlation,
 ) *gerr.GatewayDError {
 	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "sendTrafficToClient")
 	defer span.End()
 
+	logger := correlation.Logger(pr.logger)
+	span.SetAttributes(correlation.Attributes()...)
+
+	bufferSize := config.If[int](pr.SendBufferSize > 0, pr.SendBufferSize, received)
+
 	// Send the response to the client async.
 	sent := 0
 	for {
@@ -832,9 +2559,9 @@ func (pr *Proxy) sendTrafficToClient(
 			break
 		}
 
-		written, origErr := conn.Write(response[:received])
+		written, origErr := conn.Write(response[sent:min(sent+bufferSize, received)])
 		if origErr != nil {
-			pr.logger.Error().Err(origErr).Msg("Error writing to client")
+			logger.Error().Err(origErr).Msg("Error writing to client")
 			span.RecordError(origErr)
 			return gerr.ErrServerSendFailed.Wrap(origErr)
 		}
@@ -842,7 +2569,7 @@ func (pr *Proxy) sendTrafficToClient(
 		sent += written
 	}
 
-	pr.logger.Debug().Fields(
+	logging.Sampled(logger, logging.TrafficCategory).Debug().Fields(
 		map[string]interface{}{
 			"function": "proxy.passthrough",
 			"length":   sent,
@@ -861,16 +2588,14 @@ func (pr *Proxy) sendTrafficToClient(
 
 // shouldTerminate is a function that retrieves the terminate field from the hook result.
 // Only the OnTrafficFromClient hook will terminate the connection.
-func (pr *Proxy) shouldTerminate(result map[string]interface{}) bool {
+func (pr *Proxy) shouldTerminate(result *v1.Struct) bool {
 	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "shouldTerminate")
 	defer span.End()
 
 	// If the hook wants to terminate the connection, do it.
-	if result != nil {
-		if terminate, ok := result["terminate"].(bool); ok && terminate {
-			pr.logger.Debug().Str("function", "proxy.passthrough").Msg("Terminating connection")
-			return true
-		}
+	if result.GetFields()[sdk.FieldTerminate].GetBoolValue() {
+		pr.logger.Debug().Str("function", "proxy.passthrough").Msg("Terminating connection")
+		return true
 	}
 
 	return false
@@ -878,14 +2603,14 @@ func (pr *Proxy) shouldTerminate(result map[string]interface{}) bool {
 
 // getPluginModifiedRequest is a function that retrieves the modified request
 // from the hook result.
-func (pr *Proxy) getPluginModifiedRequest(result map[string]interface{}) []byte {
+func (pr *Proxy) getPluginModifiedRequest(result *v1.Struct) []byte {
 	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "getPluginModifiedRequest")
 	defer span.End()
 
 	// If the hook modified the request, use the modified request.
-	if modRequest, errMsg := extractFieldValue(result, "request"); errMsg != "" {
+	if errMsg := sdk.Error(result); errMsg != "" {
 		pr.logger.Error().Str("error", errMsg).Msg("Error in hook")
-	} else if modRequest != nil {
+	} else if modRequest := sdk.Request(result); modRequest != nil {
 		return modRequest
 	}
 
@@ -894,14 +2619,14 @@ func (pr *Proxy) getPluginModifiedRequest(result map[string]interface{}) []byte
 
 // getPluginModifiedResponse is a function that retrieves the modified response
 // from the hook result.
-func (pr *Proxy) getPluginModifiedResponse(result map[string]interface{}) ([]byte, int) {
+func (pr *Proxy) getPluginModifiedResponse(result *v1.Struct) ([]byte, int) {
 	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "getPluginModifiedResponse")
 	defer span.End()
 
 	// If the hook returns a response, use it instead of the original response.
-	if modResponse, errMsg := extractFieldValue(result, "response"); errMsg != "" {
+	if errMsg := sdk.Error(result); errMsg != "" {
 		pr.logger.Error().Str("error", errMsg).Msg("Error in hook")
-	} else if modResponse != nil {
+	} else if modResponse := sdk.Response(result); modResponse != nil {
 		return modResponse, len(modResponse)
 	}
 
@@ -2,15 +2,19 @@ package network
 
 import (
 	"context"
+	"io"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
 	"github.com/gatewayd-io/gatewayd/logging"
 	"github.com/gatewayd-io/gatewayd/plugin"
 	"github.com/gatewayd-io/gatewayd/pool"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestNewProxy tests the creation of a new proxy with a fixed connection pool.
@@ -436,3 +440,492 @@ func BenchmarkProxyAvailableAndBusyConnections(b *testing.B) {
 		proxy.BusyConnections()
 	}
 }
+
+// TestProxyQueue tests that incoming connections wait for a client to be released
+// back to the pool when it is exhausted, and are rejected when the queue is full
+// or the wait times out.
+func TestProxyQueue(t *testing.T) {
+	logger := logging.NewLogger(context.Background(), logging.LoggerConfig{
+		Output:            []config.LogOutput{config.Console},
+		TimeFormat:        zerolog.TimeFormatUnix,
+		ConsoleTimeFormat: time.RFC3339,
+		Level:             zerolog.WarnLevel,
+		NoColor:           true,
+	})
+
+	newPool := pool.NewPool(context.Background(), 1)
+	proxy := NewProxy(
+		context.Background(),
+		newPool,
+		plugin.NewRegistry(
+			context.Background(),
+			config.Loose,
+			config.PassDown,
+			config.Accept,
+			config.Stop,
+			logger,
+			false,
+		),
+		false,
+		false,
+		config.DefaultHealthCheckPeriod,
+		nil,
+		logger,
+		config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+	proxy.QueueSize = 1
+	proxy.QueueTimeout = time.Second
+
+	// The first connection waits until a client is released.
+	type result struct {
+		client *Client
+		err    error
+	}
+	waiting := make(chan result)
+	go func() {
+		client, err := proxy.waitForClient(logger)
+		if err != nil {
+			waiting <- result{nil, err}
+			return
+		}
+		waiting <- result{client, nil}
+	}()
+	assert.Eventually(t, func() bool { return proxy.queued.Load() == 1 }, time.Second, time.Millisecond)
+
+	// The queue is full, so the second connection is rejected right away.
+	_, err := proxy.waitForClient(logger)
+	assert.ErrorIs(t, err, gerr.ErrPoolQueueFull)
+
+	released := &Client{ID: "released"}
+	assert.Nil(t, newPool.Put(released.ID, released))
+	proxy.notifyReleased()
+
+	got := <-waiting
+	assert.NoError(t, got.err)
+	assert.Equal(t, released, got.client)
+	assert.Equal(t, int32(0), proxy.queued.Load())
+
+	// Nothing is released, so the wait times out.
+	proxy.QueueTimeout = 10 * time.Millisecond
+	_, err = proxy.waitForClient(logger)
+	assert.ErrorIs(t, err, gerr.ErrPoolQueueTimeout)
+}
+
+// TestProxyLimitPolicy tests that only the queries matched by the limit policy are
+// limited.
+func TestProxyLimitPolicy(t *testing.T) {
+	ctx := context.Background()
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, 1),
+		plugin.NewRegistry(
+			ctx, config.Loose, config.PassDown, config.Accept, config.Stop, zerolog.Nop(), false),
+		false, false, config.DefaultHealthCheckPeriod, nil, zerolog.Nop(), config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+
+	assert.NotNil(t, proxy.Configure(&config.Proxy{
+		AdaptiveLimit: config.AdaptiveLimit{Enabled: true, When: "request.query =="},
+	}))
+	assert.Nil(t, proxy.Configure(&config.Proxy{
+		AdaptiveLimit: config.AdaptiveLimit{
+			Enabled: true,
+			When:    `request.query.lowerAscii().startsWith("select")`,
+		},
+	}))
+	require.NotNil(t, proxy.LimitPolicy)
+
+	client, server := net.Pipe()
+	defer client.Close()
+	conn := NewConnWrapper(server, nil, config.DefaultHandshakeTimeout)
+	defer conn.Close()
+
+	assert.True(t, proxy.isLimited(conn, CreatePostgreSQLPacket('Q', []byte("SELECT 1\x00"))))
+	assert.False(t, proxy.isLimited(conn, CreatePostgreSQLPacket('Q', []byte("INSERT 1\x00"))))
+
+	// All the queries are limited without a policy.
+	assert.Nil(t, proxy.Configure(&config.Proxy{AdaptiveLimit: config.AdaptiveLimit{Enabled: true}}))
+	assert.True(t, proxy.isLimited(conn, CreatePostgreSQLPacket('Q', []byte("INSERT 1\x00"))))
+}
+
+// TestProxyStartupParameters tests that the configured parameters are set in the
+// startup messages, and that the parameters of the clients are kept unless overridden.
+func TestProxyStartupParameters(t *testing.T) {
+	ctx := context.Background()
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, 1),
+		plugin.NewRegistry(
+			ctx, config.Loose, config.PassDown, config.Accept, config.Stop, zerolog.Nop(), false),
+		false, false, config.DefaultHealthCheckPeriod, nil, zerolog.Nop(), config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+
+	assert.Nil(t, proxy.Configure(&config.Proxy{
+		StartupParameters: []config.StartupParameter{
+			{Name: "Application_Name", Value: "${value} (gatewayd ${connectionId})", Override: true},
+			{Name: "search_path", Value: "${user}"},
+			{Name: "database", Value: "other"},
+		},
+	}))
+
+	client, server := net.Pipe()
+	defer client.Close()
+	conn := NewConnWrapper(server, nil, config.DefaultHandshakeTimeout)
+	defer conn.Close()
+
+	request := SetPostgresStartupParameter(CreatePgStartupPacket(), "application_name", "psql")
+	parameters := PostgresStartupParameters(request)
+	injected := PostgresStartupParameters(proxy.injectStartupParameters(conn, request, parameters))
+	assert.Equal(t, "psql (gatewayd "+conn.ID()+")", injected["application_name"])
+	assert.Equal(t, "postgres", injected["search_path"])
+	assert.Equal(t, parameters["database"], injected["database"])
+	assert.False(t, proxy.CanSplice(conn))
+}
+
+// TestProxyRestoreSettings tests that the SET statements of a session are replayed on
+// its server connection, and forgotten if the reset policy discards them.
+func TestProxyRestoreSettings(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, 1),
+		plugin.NewRegistry(ctx, config.Loose, config.PassDown, config.Accept, config.Stop, logger, false),
+		false, false, config.DefaultHealthCheckPeriod, nil, logger, config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+	assert.Nil(t, proxy.Configure(&config.Proxy{}))
+	assert.Equal(t, config.Replay, proxy.ResetPolicy)
+
+	incoming, outgoing := net.Pipe()
+	defer outgoing.Close()
+	conn := NewConnWrapper(incoming, nil, config.DefaultHandshakeTimeout)
+	defer conn.Close()
+	proxy.sessions.Store(conn, newSession())
+	proxy.recordUsage(conn, Ingress, PostgreSQLQuery("SET search_path TO app; SET work_mem = 1"))
+
+	// The database side of the connection.
+	database, server := net.Pipe()
+	defer database.Close()
+	client := &Client{
+		conn:             server,
+		ctx:              ctx,
+		logger:           logger,
+		ReceiveChunkSize: config.DefaultChunkSize,
+	}
+	client.connected.Store(true)
+
+	replayed := make(chan string, 2)
+	go func() {
+		for _, response := range [][]byte{
+			[]byte("C\x00\x00\x00\x08SET\x00"),
+			PostgreSQLErrorResponse("ERROR", "42501", "permission denied"),
+		} {
+			buffer := make([]byte, config.DefaultChunkSize)
+			read, _ := database.Read(buffer)
+			replayed <- PostgresQueries(buffer[:read])[0]
+			_, _ = database.Write(append(response, PostgreSQLReadyForQuery()...))
+		}
+	}()
+
+	assert.Nil(t, proxy.restoreSettings(conn, client, Correlation{}))
+	assert.Equal(t, "SET search_path TO app", <-replayed)
+	assert.Equal(t, "SET work_mem = 1", <-replayed)
+
+	proxy.ResetPolicy = config.Discard
+	assert.Nil(t, proxy.restoreSettings(conn, client, Correlation{}))
+	assert.Empty(t, proxy.Connections()[0].Settings)
+}
+
+// TestStreamTrafficToClient tests that a large response is sent to the client in chunks.
+func TestStreamTrafficToClient(t *testing.T) {
+	logger := zerolog.Nop()
+
+	proxy := NewProxy(
+		context.Background(),
+		pool.NewPool(context.Background(), config.EmptyPoolCapacity),
+		plugin.NewRegistry(
+			context.Background(),
+			config.Loose,
+			config.PassDown,
+			config.Accept,
+			config.Stop,
+			logger,
+			false,
+		),
+		false,
+		false,
+		config.DefaultHealthCheckPeriod,
+		nil,
+		logger,
+		config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+	proxy.MaxMessageSize = 8
+
+	// The database side of the connection.
+	database, server := net.Pipe()
+	defer database.Close()
+	defer server.Close()
+	client := &Client{
+		conn:             server,
+		ctx:              context.Background(),
+		logger:           logger,
+		ReceiveChunkSize: 4,
+	}
+	client.connected.Store(true)
+
+	// The client side of the connection.
+	incoming, outgoing := net.Pipe()
+	defer incoming.Close()
+	defer outgoing.Close()
+	conn := NewConnWrapper(incoming, nil, config.DefaultHandshakeTimeout)
+
+	go func() {
+		_, _ = database.Write([]byte("89abcd"))
+	}()
+
+	response := make(chan []byte)
+	go func() {
+		buffer := make([]byte, 14)
+		_, _ = io.ReadFull(outgoing, buffer)
+		response <- buffer
+	}()
+
+	err := proxy.streamTrafficToClient(
+		conn, client, []byte("request"), []byte("01234567"), Correlation{}, logger)
+	assert.Nil(t, err)
+	assert.Equal(t, "0123456789abcd", string(<-response))
+}
+
+// TestReceiveOversizeTrafficFromClient tests that the requests larger than the maximum
+// message size are rejected or truncated.
+func TestReceiveOversizeTrafficFromClient(t *testing.T) {
+	logger := zerolog.Nop()
+
+	proxy := NewProxy(
+		context.Background(),
+		pool.NewPool(context.Background(), config.EmptyPoolCapacity),
+		plugin.NewRegistry(
+			context.Background(),
+			config.Loose,
+			config.PassDown,
+			config.Accept,
+			config.Stop,
+			logger,
+			false,
+		),
+		false,
+		false,
+		config.DefaultHealthCheckPeriod,
+		nil,
+		logger,
+		config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+	proxy.ReceiveBufferSize = 4
+	proxy.MaxMessageSize = 6
+
+	receive := func() ([]byte, *gerr.GatewayDError) {
+		incoming, outgoing := net.Pipe()
+		defer incoming.Close()
+		defer outgoing.Close()
+
+		go func() {
+			_, _ = outgoing.Write([]byte("0123456789"))
+		}()
+		return proxy.receiveTrafficFromClient(incoming, Correlation{})
+	}
+
+	proxy.OversizeBehavior = config.Close
+	_, err := receive()
+	assert.ErrorIs(t, err, gerr.ErrMessageTooLarge)
+
+	// Requests can't be streamed, so they are rejected too.
+	proxy.OversizeBehavior = config.Stream
+	_, err = receive()
+	assert.ErrorIs(t, err, gerr.ErrMessageTooLarge)
+
+	proxy.OversizeBehavior = config.Truncate
+	request, err := receive()
+	assert.Nil(t, err)
+	assert.Equal(t, "012345", string(request))
+}
+
+// TestSplice tests that the traffic takes the fast path only when nothing needs
+// to see it, and that it is passed through in both directions.
+func TestSplice(t *testing.T) {
+	logger := zerolog.Nop()
+
+	proxy := NewProxy(
+		context.Background(),
+		pool.NewPool(context.Background(), config.EmptyPoolCapacity),
+		plugin.NewRegistry(
+			context.Background(),
+			config.Loose,
+			config.PassDown,
+			config.Accept,
+			config.Stop,
+			logger,
+			false,
+		),
+		false,
+		false,
+		config.DefaultHealthCheckPeriod,
+		nil,
+		logger,
+		config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+
+	// An echo server in place of the database.
+	database, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer database.Close()
+	go func() {
+		conn, err := database.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(conn, conn)
+	}()
+
+	server, err := net.Dial("tcp", database.Addr().String())
+	assert.Nil(t, err)
+	client := &Client{ID: "database", conn: server, ctx: context.Background(), logger: logger}
+	client.connected.Store(true)
+
+	// A client connected to the gateway.
+	gateway, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer gateway.Close()
+	incoming, err := net.Dial("tcp", gateway.Addr().String())
+	assert.Nil(t, err)
+	accepted, err := gateway.Accept()
+	assert.Nil(t, err)
+	conn := NewConnWrapper(accepted, nil, config.DefaultHandshakeTimeout)
+	defer conn.Close()
+
+	assert.False(t, proxy.CanSplice(conn))
+	proxy.FastPath = true
+	assert.Equal(t, spliceSupported, proxy.CanSplice(conn))
+	proxy.Throttler = NewThrottler(1024, 1024, 0, false)
+	assert.False(t, proxy.CanSplice(conn))
+	proxy.Throttler = nil
+	proxy.QueryStats = NewQueryStats(1)
+	assert.False(t, proxy.CanSplice(conn))
+	proxy.QueryStats = nil
+	proxy.StatementTimeouts = &StatementTimeouts{}
+	assert.False(t, proxy.CanSplice(conn))
+	proxy.StatementTimeouts = nil
+	proxy.IdleTransactions = &IdleTransactions{}
+	assert.False(t, proxy.CanSplice(conn))
+	proxy.IdleTransactions = nil
+	proxy.MaxConnectionMemory = 1024
+	assert.False(t, proxy.CanSplice(conn))
+	proxy.MaxConnectionMemory = 0
+	proxy.ErrorStats = NewErrorStats(1)
+	assert.False(t, proxy.CanSplice(conn))
+	proxy.ErrorStats = nil
+
+	assert.Nil(t, proxy.busyConnections.Put(conn, client))
+	// The password requests of the server must be answered with the IAM auth tokens.
+	client.IAMAuth = &IAMAuth{}
+	assert.False(t, proxy.CanSplice(conn))
+	client.IAMAuth = nil
+	done := make(chan *gerr.GatewayDError)
+	go func() {
+		done <- proxy.Splice(conn)
+	}()
+
+	_, err = incoming.Write([]byte("hello"))
+	assert.Nil(t, err)
+	echo := make([]byte, 5)
+	_, err = io.ReadFull(incoming, echo)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(echo))
+
+	// Closing the client connection ends the splice.
+	assert.Nil(t, incoming.Close())
+	assert.Nil(t, <-done)
+}
+
+// TestProxyDisconnectElastic tests that the state of a connection is released when its
+// elastic server connection isn't reused.
+func TestProxyDisconnectElastic(t *testing.T) {
+	ctx := context.Background()
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, 1),
+		plugin.NewRegistry(
+			ctx, config.Loose, config.PassDown, config.Accept, config.Stop, zerolog.Nop(), false),
+		true, false, config.DefaultHealthCheckPeriod, nil, zerolog.Nop(), config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+	assert.Nil(t, proxy.Configure(&config.Proxy{AdaptiveLimit: config.AdaptiveLimit{Enabled: true}}))
+	require.NotNil(t, proxy.Limiter)
+
+	incoming, outgoing := net.Pipe()
+	defer outgoing.Close()
+	conn := NewConnWrapper(incoming, nil, config.DefaultHandshakeTimeout)
+	defer conn.Close()
+	require.Nil(t, proxy.busyConnections.Put(conn, &Client{ID: "elastic"}))
+	proxy.sessions.Store(conn, newSession())
+	proxy.parameters.Store(conn, map[string]string{"user": "app", "database": "shop"})
+
+	// The client disconnects in the middle of a query.
+	require.Nil(t, proxy.acquireSlot(conn, 0))
+	assert.Equal(t, 1, proxy.Limiter.InFlight())
+
+	assert.ErrorIs(t, proxy.Disconnect(conn), gerr.ErrClientNotConnected)
+	assert.Equal(t, 0, proxy.Limiter.InFlight())
+	_, ok := proxy.inFlight.Load(conn)
+	assert.False(t, ok)
+	_, ok = proxy.sessions.Load(conn)
+	assert.False(t, ok)
+	_, ok = proxy.parameters.Load(conn)
+	assert.False(t, ok)
+}
+
+// TestPassThroughTruncatedResponse tests that a response larger than the maximum message
+// size is truncated to its complete messages and ends with an error.
+func TestPassThroughTruncatedResponse(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, config.EmptyPoolCapacity),
+		plugin.NewRegistry(
+			ctx, config.Loose, config.PassDown, config.Accept, config.Stop, logger, false),
+		false, false, config.DefaultHealthCheckPeriod, nil, logger, config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+	proxy.MaxMessageSize = 16
+	proxy.OversizeBehavior = config.Truncate
+
+	// The database side of the connection.
+	database, server := net.Pipe()
+	defer database.Close()
+	defer server.Close()
+	client := &Client{ID: "truncated", conn: server, ctx: ctx, logger: logger, ReceiveChunkSize: 4}
+	client.connected.Store(true)
+
+	// The client side of the connection.
+	incoming, outgoing := net.Pipe()
+	defer outgoing.Close()
+	require.NoError(t, outgoing.SetReadDeadline(time.Now().Add(5*time.Second)))
+	conn := NewConnWrapper(incoming, nil, config.DefaultHandshakeTimeout)
+	defer conn.Close()
+	require.Nil(t, proxy.busyConnections.Put(conn, client))
+
+	// The first message fits in the maximum size, and the second one is cut off.
+	row := []byte("D\x00\x00\x00\x0c\x00\x01\x00\x00\x00\x0212")
+	response := append(append(append([]byte{}, row...), row...), row...)
+	response = append(response, "C\x00\x00\x00\x0dSELECT 3\x00"...)
+	response = append(response, 'Z', 0, 0, 0, 5, 'T')
+	go func() {
+		_, _ = database.Write(response)
+	}()
+
+	expected := append([]byte{}, row...)
+	expected = append(expected, ErrorResponse(nil, config.ErrorResponseTruncated, "")...)
+	expected = append(expected, 'Z', 0, 0, 0, 5, 'T')
+	received := make(chan []byte)
+	go func() {
+		buffer := make([]byte, len(expected))
+		_, _ = io.ReadFull(outgoing, buffer)
+		received <- buffer
+	}()
+
+	assert.Nil(t, proxy.PassThroughToClient(conn, NewStack()))
+	assert.Equal(t, expected, <-received)
+}
@@ -0,0 +1,91 @@
+package network
+
+import (
+	"context"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// QueryResult is the result of a read-only query, whose values are decoded to their JSON
+// values.
+type QueryResult struct {
+	Columns   []string
+	Rows      [][]interface{}
+	Truncated bool
+}
+
+// Querier runs the read-only queries of the internal tools through the listeners of the
+// servers, like their clients do, so that the firewall, the plugins and the pool of
+// their proxies apply to them.
+type Querier struct {
+	MaxRows int
+	Timeout time.Duration
+
+	connect func(ctx context.Context, address string) (*pgconn.PgConn, error)
+}
+
+// NewQuerier creates the querier of the config, or returns nil if it is disabled.
+func NewQuerier(cfg config.APIQuery) *Querier {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	database := config.If[string](cfg.Database != "", cfg.Database, config.DefaultAPIQueryDatabase)
+	timeout := config.If[time.Duration](cfg.Timeout > 0, cfg.Timeout, config.DefaultAPIQueryTimeout)
+	return &Querier{
+		MaxRows: config.If[int](cfg.MaxRows > 0, cfg.MaxRows, config.DefaultAPIQueryMaxRows),
+		Timeout: timeout,
+		connect: func(ctx context.Context, address string) (*pgconn.PgConn, error) {
+			return ConnectPostgres(ctx, address, cfg.User, cfg.Password, database,
+				config.DefaultAPIQueryApplicationName, timeout)
+		},
+	}
+}
+
+// Query runs the query with its arguments in the text format through the server that
+// listens at the address, in a read-only transaction. Each query has its connection,
+// so that the settings it changes don't outlive it. The rows beyond maxRows, or the
+// maximum of the querier if it is lower or zero, are discarded.
+func (q *Querier) Query(
+	ctx context.Context, address, sql string, args [][]byte, maxRows int,
+) (*QueryResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, q.Timeout)
+	defer cancel()
+
+	conn, err := q.connect(ctx, serverAddress(address))
+	if err != nil {
+		return nil, err
+	}
+	// Closing the connection rolls the transaction back.
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "BEGIN READ ONLY").ReadAll(); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	limit := config.If[int](maxRows > 0 && maxRows < q.MaxRows, maxRows, q.MaxRows)
+	reader := conn.ExecParams(ctx, sql, args, nil, nil, nil)
+	fields := reader.FieldDescriptions()
+	result := &QueryResult{Columns: make([]string, 0, len(fields)), Rows: [][]interface{}{}}
+	for _, field := range fields {
+		result.Columns = append(result.Columns, field.Name)
+	}
+	for reader.NextRow() {
+		if len(result.Rows) >= limit {
+			result.Truncated = true
+			continue
+		}
+		values := reader.Values()
+		row := make([]interface{}, len(values))
+		for i, value := range values {
+			row[i] = decodeDataValue(fields[i].DataTypeOID, value)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if _, err := reader.Close(); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package network
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// queryLiterals are the string and numeric literals replaced by placeholders, so
+	// that the queries that differ only in their values are counted together.
+	queryLiterals = regexp.MustCompile(`'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b`)
+	// queryWhitespace are the runs of whitespace collapsed into a space.
+	queryWhitespace = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeQuery replaces the literals of the query with placeholders and collapses
+// its whitespace.
+func NormalizeQuery(query string) string {
+	query = queryLiterals.ReplaceAllString(query, "?")
+	return strings.TrimSpace(queryWhitespace.ReplaceAllString(query, " "))
+}
+
+// QueryStat is the number of times a normalized query was sent.
+type QueryStat struct {
+	Query    string    `json:"query"`
+	Count    uint64    `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// QueryStats counts the normalized queries sent by the clients of the proxies, up to
+// a number of distinct queries, after which the least sent query is evicted for a new
+// one.
+type QueryStats struct {
+	mu      sync.Mutex
+	limit   int
+	queries map[string]*QueryStat
+}
+
+// NewQueryStats creates a counter of up to limit distinct queries.
+func NewQueryStats(limit int) *QueryStats {
+	return &QueryStats{limit: limit, queries: map[string]*QueryStat{}}
+}
+
+// Record counts the queries of the request.
+func (q *QueryStats) Record(request []byte) {
+	queries := PostgresQueries(request)
+	if len(queries) == 0 {
+		return
+	}
+
+	now := time.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, query := range queries {
+		query = NormalizeQuery(query)
+		stat, ok := q.queries[query]
+		if !ok {
+			if len(q.queries) >= q.limit {
+				q.evict()
+			}
+			stat = &QueryStat{Query: query}
+			q.queries[query] = stat
+		}
+		stat.Count++
+		stat.LastSeen = now
+	}
+}
+
+// evict removes the least sent query, and the least recently sent one of those.
+func (q *QueryStats) evict() {
+	var least *QueryStat
+	for _, stat := range q.queries {
+		if least == nil || stat.Count < least.Count ||
+			(stat.Count == least.Count && stat.LastSeen.Before(least.LastSeen)) {
+			least = stat
+		}
+	}
+	if least != nil {
+		delete(q.queries, least.Query)
+	}
+}
+
+// Top returns up to n of the most sent queries.
+func (q *QueryStats) Top(n int) []QueryStat {
+	q.mu.Lock()
+	top := make([]QueryStat, 0, len(q.queries))
+	for _, stat := range q.queries {
+		top = append(top, *stat)
+	}
+	q.mu.Unlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Query < top[j].Query
+	})
+	if n >= 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
@@ -0,0 +1,45 @@
+package network
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNormalizeQuery tests that the queries that differ in their literals are
+// normalized to the same query.
+func TestNormalizeQuery(t *testing.T) {
+	assert.Equal(t, "SELECT * FROM users WHERE id = ? AND name = ?",
+		NormalizeQuery("SELECT *\n  FROM users WHERE id = 42 AND name = 'O''Brien'"))
+	assert.Equal(t, "SELECT price * ? FROM items2", NormalizeQuery("SELECT price * 1.5 FROM items2"))
+}
+
+// TestQueryStats tests that the most sent queries are counted, and that the least
+// sent query is evicted for a new one.
+func TestQueryStats(t *testing.T) {
+	stats := NewQueryStats(2)
+	stats.Record(PostgreSQLQuery("SELECT 1"))
+	stats.Record(PostgreSQLQuery("SELECT 2"))
+	stats.Record(bytes.Join([][]byte{
+		PostgreSQLQuery("SELECT * FROM users"),
+		PostgreSQLQuery("SELECT 3"),
+	}, nil))
+	// The requests without queries are not counted.
+	stats.Record(PostgreSQLSSLRequest())
+
+	top := stats.Top(10)
+	assert.Len(t, top, 2)
+	assert.Equal(t, "SELECT ?", top[0].Query)
+	assert.Equal(t, uint64(3), top[0].Count)
+	assert.Equal(t, "SELECT * FROM users", top[1].Query)
+	assert.Equal(t, uint64(1), top[1].Count)
+
+	// The query sent the least is evicted for the new one.
+	stats.Record(PostgreSQLQuery("SELECT * FROM orders"))
+	top = stats.Top(-1)
+	assert.Len(t, top, 2)
+	assert.Equal(t, "SELECT ?", top[0].Query)
+	assert.Equal(t, "SELECT * FROM orders", top[1].Query)
+	assert.Len(t, stats.Top(1), 1)
+}
@@ -0,0 +1,132 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"go.opentelemetry.io/otel"
+)
+
+// ReconnectReadRetry is the reason of the reconnections of the clients to another
+// standby, to retry a read-only query.
+const ReconnectReadRetry = "readRetry"
+
+// retryRead moves the session of the connection to another standby of the cluster
+// after its server connection died while waiting for the response of the last request,
+// and sends the request again, so that the client gets the response from the other
+// standby instead of an error. The request is only retried once, if no response was
+// received for it, the session wasn't in a transaction and the request is provably
+// read-only. The session is authenticated and restored on the other standby like the
+// recovered sessions.
+func (pr *Proxy) retryRead(
+	conn *ConnWrapper, client *Client, stack *Stack, correlation Correlation,
+) bool {
+	if !pr.RetryReads || pr.Cluster == nil || pr.ClusterRole != config.Standby {
+		return false
+	}
+	value, ok := pr.sessions.Load(conn)
+	if !ok {
+		return false
+	}
+	session := value.(*session) //nolint:forcetypeassert
+	startup, password := session.authentication()
+	lastRequest := stack.GetLastRequest()
+	if startup == nil || session.status.Load() != 'I' || lastRequest == nil ||
+		lastRequest.Retried || !readOnlyRequest(lastRequest.Data) {
+		return false
+	}
+	address := pr.Cluster.Alternative(client.Address)
+	if address == "" {
+		return false
+	}
+
+	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "retryRead")
+	defer span.End()
+
+	logger := correlation.Logger(pr.logger)
+	lastRequest.Retried = true
+	failed := client.Address
+
+	client.Address = address
+	if err := pr.reconnectClient(client, ReconnectReadRetry); err != nil {
+		logger.Error().Err(err).Str("address", address).Msg(
+			"Failed to connect to another standby to retry the query")
+		span.RecordError(err)
+		metrics.ProxyReadRetries.WithLabelValues("failure").Inc()
+		return false
+	}
+	if err := pr.authenticateSession(conn, client, startup, password, correlation); err != nil {
+		logger.Error().Err(err).Str("address", address).Msg(
+			"Failed to restore the session on another standby to retry the query")
+		span.RecordError(err)
+		metrics.ProxyReadRetries.WithLabelValues("failure").Inc()
+		return false
+	}
+
+	outgoing := lastRequest.Data
+	if pr.InjectCorrelationIDs {
+		outgoing = InjectSQLComment(outgoing, correlation.SQLComment())
+	}
+	if prepared, _ := pr.prepareStatements(conn, client, lastRequest.Data); prepared != nil {
+		outgoing = prepared
+	}
+	if _, err := pr.sendTrafficToServer(client, outgoing, correlation); err != nil {
+		span.RecordError(err)
+		metrics.ProxyReadRetries.WithLabelValues("failure").Inc()
+		return false
+	}
+
+	logger.Warn().Str("failed", failed).Str("address", address).Msg(
+		"Retried the read-only query on another standby")
+	metrics.ProxyReadRetries.WithLabelValues("success").Inc()
+	return true
+}
+
+// readOnlyRequest returns true if the request is a single simple query, or a batch of
+// the extended query protocol on the unnamed statement, whose queries are all
+// read-only, so that it can be sent again to another server without side effects.
+//
+//nolint:gomnd
+func readOnlyRequest(request []byte) bool {
+	queries := PostgresQueries(request)
+	if len(queries) == 0 {
+		return false
+	}
+	for _, query := range queries {
+		if !plugin.ReadOnlyQuery(query) {
+			return false
+		}
+	}
+
+	for offset := 0; offset+5 <= len(request); {
+		length := int(binary.BigEndian.Uint32(request[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(request) {
+			return false
+		}
+		body := request[offset+5 : offset+1+length]
+		switch request[offset] {
+		case 'Q':
+			if len(queries) > 1 {
+				return false
+			}
+		case 'P':
+			// The named statements are restored on the new server connection.
+			if len(body) == 0 || body[0] != 0 {
+				return false
+			}
+		case 'B':
+			// The portal and the statement must be unnamed.
+			if !bytes.HasPrefix(body, []byte{0, 0}) {
+				return false
+			}
+		case 'D', 'E', 'S', 'H':
+		default:
+			return false
+		}
+		offset += 1 + length
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProxyRetryRead tests that a read-only query is retried on another standby when
+// its own dies, and only once.
+func TestProxyRetryRead(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, 1),
+		plugin.NewRegistry(ctx, config.Loose, config.PassDown, config.Accept, config.Stop, logger, false),
+		false, false, config.DefaultHealthCheckPeriod, nil, logger, config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+	require.Nil(t, proxy.Configure(&config.Proxy{}))
+
+	startup := CreatePgStartupPacket()
+	query := PostgreSQLQuery("SELECT * FROM users")
+	authenticationOk := []byte{'R', 0, 0, 0, 8, 0, 0, 0, 0}
+	rows := append([]byte("C\x00\x00\x00\x0dSELECT 0\x00"), PostgreSQLReadyForQuery()...)
+
+	// The first standby dies with the query in flight, and the second one answers it
+	// after the session is authenticated on it.
+	first, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer first.Close()
+	second, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer second.Close()
+	go func() {
+		conn, err := first.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+	received := make(chan []byte, 2)
+	go func() {
+		conn, err := second.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, response := range [][]byte{append(authenticationOk, PostgreSQLReadyForQuery()...), rows} {
+			buffer := make([]byte, config.DefaultChunkSize)
+			read, err := conn.Read(buffer)
+			if err != nil {
+				return
+			}
+			received <- buffer[:read]
+			_, _ = conn.Write(response)
+		}
+	}()
+
+	cluster := &fakeCluster{}
+	cluster.set(map[string]bool{
+		"primary:5432": false, first.Addr().String(): true, second.Addr().String(): true,
+	})
+	monitor := NewClusterMonitor(config.PrimaryDiscovery{
+		Members: []string{"primary:5432", first.Addr().String(), second.Addr().String()},
+	}, time.Second, logger)
+	monitor.check = cluster.check
+	_, _, _, checkErr := monitor.Check(ctx)
+	require.Nil(t, checkErr)
+	proxy.Cluster = monitor
+	proxy.ClusterRole = config.Standby
+	proxy.RetryReads = true
+
+	client := NewClient(ctx, &config.Client{
+		Network:          "tcp",
+		Address:          first.Addr().String(),
+		ReceiveChunkSize: config.DefaultChunkSize,
+		DialTimeout:      config.DefaultDialTimeout,
+	}, logger, NewRetry(0, config.DefaultBackoff, config.DefaultBackoffMultiplier, false, logger))
+	require.NotNil(t, client)
+	defer client.Close()
+
+	incoming, outgoing := net.Pipe()
+	defer outgoing.Close()
+	conn := NewConnWrapper(incoming, nil, config.DefaultHandshakeTimeout)
+	defer conn.Close()
+	require.Nil(t, proxy.busyConnections.Put(conn, client))
+	proxy.sessions.Store(conn, newSession())
+	proxy.recordUsage(conn, Ingress, startup)
+	proxy.recordUsage(conn, Egress, PostgreSQLReadyForQuery())
+	proxy.recordUsage(conn, Ingress, query)
+
+	stack := NewStack()
+	stack.Push(&Request{Data: query})
+	assert.Nil(t, proxy.PassThroughToClient(conn, stack))
+	assert.Equal(t, second.Addr().String(), client.Address)
+	assert.Equal(t, startup, <-received)
+	assert.Equal(t, query, <-received)
+	assert.True(t, stack.GetLastRequest().Retried)
+
+	// The response of the other standby is sent to the client.
+	response := make(chan []byte)
+	go func() {
+		buffer := make([]byte, config.DefaultChunkSize)
+		read, _ := outgoing.Read(buffer)
+		response <- buffer[:read]
+	}()
+	assert.Nil(t, proxy.PassThroughToClient(conn, stack))
+	assert.Equal(t, rows, <-response)
+}
+
+func TestReadOnlyRequest(t *testing.T) {
+	parse := CreatePostgreSQLPacket('P', []byte("\x00SELECT 1\x00\x00\x00"))
+	bind := CreatePostgreSQLPacket('B', []byte("\x00\x00\x00\x00\x00\x00\x00\x00"))
+	execute := CreatePostgreSQLPacket('E', []byte("\x00\x00\x00\x00\x00"))
+	sync := []byte{'S', 0, 0, 0, 4}
+
+	assert.True(t, readOnlyRequest(PostgreSQLQuery("SELECT * FROM users")))
+	assert.True(t, readOnlyRequest(append(append(append(parse, bind...), execute...), sync...)))
+	assert.False(t, readOnlyRequest(PostgreSQLQuery("SELECT nextval('ids')")))
+	assert.False(t, readOnlyRequest(append(PostgreSQLQuery("SELECT 1"), PostgreSQLQuery("SELECT 2")...)))
+	assert.False(t, readOnlyRequest(
+		CreatePostgreSQLPacket('P', []byte("statement\x00SELECT 1\x00\x00\x00"))))
+	assert.False(t, readOnlyRequest(append(bind, execute...)))
+}
@@ -0,0 +1,400 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/getsentry/sentry-go"
+)
+
+// replicationQuery returns the WAL position of the primary, and the one the slowest
+// standby replayed, which is NULL if no standby is connected.
+const replicationQuery = "SELECT pg_current_wal_lsn(), " +
+	"(SELECT min(replay_lsn) FROM pg_stat_replication)"
+
+// tempTableStatement matches the statements that create a temporary table, which only
+// exists on the server connection of the session.
+var tempTableStatement = regexp.MustCompile(
+	`(?is)\bcreate\s+(?:(?:global|local)\s+)?temp(?:orary)?\s+table\b`)
+
+// ReplicationStatus is the last poll of the replication of the backend.
+type ReplicationStatus struct {
+	Address     string    `json:"address"`
+	CurrentLSN  uint64    `json:"currentLSN"`  //nolint:tagliatelle
+	ReplayedLSN uint64    `json:"replayedLSN"` //nolint:tagliatelle
+	Error       string    `json:"error,omitempty"`
+	CheckedAt   time.Time `json:"checkedAt"`
+}
+
+// splitSession is the server connection a session borrowed from the pool of the reads,
+// and its last write.
+type splitSession struct {
+	mu     sync.Mutex
+	client *Client
+	// wroteAt is when the session last wrote, readAt is when it first read after the
+	// write, and lsn is the WAL position of the primary at the first poll after readAt,
+	// which the standbys must replay for the session to read its write.
+	wroteAt time.Time
+	readAt  time.Time
+	lsn     uint64
+	// pinned keeps all the queries of the session on the proxy, e.g. after it created
+	// a temporary table.
+	pinned bool
+}
+
+// ReadWriteSplitter sends the read-only queries of the sessions of the home config
+// group to the pool of the reads config group, except in the read-your-writes window
+// after a write of the session: until the window ends, or until the standbys replayed
+// the write, as polled from pg_stat_replication of the primary on the replication
+// interval.
+type ReadWriteSplitter struct {
+	Home                string
+	Reads               string
+	ReadsProxy          *Proxy
+	Window              time.Duration
+	ReplicationInterval time.Duration
+
+	// check returns the WAL position of the primary at the address, and the one the
+	// slowest standby replayed.
+	check func(ctx context.Context, address string) (uint64, uint64, error)
+
+	mu          sync.RWMutex
+	replication ReplicationStatus
+	sessions    sync.Map
+}
+
+// NewReadWriteSplitter creates the read/write split of the proxy of the home config
+// group, whose reads are served by the proxy of the reads config group.
+func NewReadWriteSplitter(
+	home string, cfg config.ReadWriteSplit, proxies map[string]*Proxy, dialTimeout time.Duration,
+) (*ReadWriteSplitter, *gerr.GatewayDError) {
+	if cfg.Reads == home {
+		return nil, gerr.ErrInvalidReadWriteSplit.Wrap(
+			fmt.Errorf("the reads of %s can't be served by itself", home))
+	}
+	reads, ok := proxies[cfg.Reads]
+	if !ok {
+		return nil, gerr.ErrInvalidReadWriteSplit.Wrap(
+			fmt.Errorf("the reads config group %q of %s doesn't exist", cfg.Reads, home))
+	}
+
+	database := config.If[string](cfg.Database != "", cfg.Database, config.DefaultReplicationDatabase)
+	return &ReadWriteSplitter{
+		Home:       home,
+		Reads:      cfg.Reads,
+		ReadsProxy: reads,
+		Window: config.If[time.Duration](
+			cfg.Window > 0, cfg.Window, config.DefaultReadYourWritesWindow),
+		ReplicationInterval: cfg.ReplicationInterval,
+		check: func(ctx context.Context, address string) (uint64, uint64, error) {
+			conn, err := ConnectPostgres(
+				ctx, address, cfg.User, cfg.Password, database, config.Name, dialTimeout)
+			if err != nil {
+				return 0, 0, err
+			}
+			defer conn.Close(ctx)
+
+			results, err := conn.Exec(ctx, replicationQuery).ReadAll()
+			if err != nil {
+				return 0, 0, err //nolint:wrapcheck
+			}
+			if len(results) != 1 || len(results[0].Rows) != 1 || len(results[0].Rows[0]) != 2 {
+				return 0, 0, errors.New("unexpected result of pg_stat_replication")
+			}
+			row := results[0].Rows[0]
+			current, err := ParseLSN(string(row[0]))
+			if err != nil {
+				return 0, 0, err
+			}
+			if row[1] == nil {
+				return current, 0, nil
+			}
+			replayed, err := ParseLSN(string(row[1]))
+			return current, replayed, err
+		},
+	}, nil
+}
+
+// ParseLSN parses a WAL position of PostgreSQL, e.g. 16/B374D848.
+func ParseLSN(lsn string) (uint64, error) {
+	high, low, ok := strings.Cut(lsn, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid WAL position %q", lsn)
+	}
+	highValue, err := strconv.ParseUint(high, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid WAL position %q: %w", lsn, err)
+	}
+	lowValue, err := strconv.ParseUint(low, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid WAL position %q: %w", lsn, err)
+	}
+	return highValue<<32 | lowValue, nil
+}
+
+// CheckReplication polls the replication of the primary at the address.
+func (s *ReadWriteSplitter) CheckReplication(ctx context.Context, address string) ReplicationStatus {
+	status := ReplicationStatus{Address: address, CheckedAt: time.Now()}
+	ctx, cancel := context.WithTimeout(ctx, s.ReplicationInterval)
+	defer cancel()
+	current, replayed, err := s.check(ctx, address)
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.CurrentLSN, status.ReplayedLSN = current, replayed
+		metrics.ProxyReplicationLagBytes.Set(float64(current - min(replayed, current)))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The failed polls keep the last positions, which only end the windows later.
+	if err != nil {
+		s.replication.Error = status.Error
+		return status
+	}
+	s.replication = status
+	return status
+}
+
+// Replication returns the last poll of the replication of the primary.
+func (s *ReadWriteSplitter) Replication() ReplicationStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.replication
+}
+
+// session returns the split session of the connection.
+func (s *ReadWriteSplitter) session(conn *ConnWrapper) *splitSession {
+	value, _ := s.sessions.LoadOrStore(conn, &splitSession{})
+	return value.(*splitSession) //nolint:forcetypeassert
+}
+
+// release puts the server connection the session of the connection borrowed back in
+// the pool of the reads.
+func (s *ReadWriteSplitter) release(conn *ConnWrapper) {
+	value, ok := s.sessions.LoadAndDelete(conn)
+	if !ok {
+		return
+	}
+	session := value.(*splitSession) //nolint:forcetypeassert
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.client != nil {
+		s.ReadsProxy.releaseClient(session.client)
+		session.client = nil
+	}
+}
+
+// inWindow returns true if the session is in the read-your-writes window of its last
+// write. It must be called with the lock of the session held.
+func (s *ReadWriteSplitter) inWindow(session *splitSession) bool {
+	if session.wroteAt.IsZero() {
+		return false
+	}
+	if time.Since(session.wroteAt) >= s.Window || s.replayed(session) {
+		session.wroteAt, session.readAt, session.lsn = time.Time{}, time.Time{}, 0
+		return false
+	}
+	return true
+}
+
+// replayed returns true if the polls of the replication tell that the standbys
+// replayed the last write of the session. It must be called with the lock of the
+// session held.
+func (s *ReadWriteSplitter) replayed(session *splitSession) bool {
+	if s.ReplicationInterval <= 0 {
+		return false
+	}
+	if session.readAt.IsZero() {
+		session.readAt = time.Now()
+	}
+	// The polls that start after the first read see the write, which the client waited
+	// for before reading.
+	replication := s.Replication()
+	if session.lsn == 0 && !replication.CheckedAt.Before(session.readAt) {
+		session.lsn = replication.CurrentLSN
+	}
+	return session.lsn != 0 && replication.ReplayedLSN >= session.lsn
+}
+
+// splitRead returns true if the request of the connection is sent to the reads: a
+// provably read-only request outside of a transaction, sent along with its Sync
+// message, of a session that isn't in the read-your-writes window. The other queries,
+// except the SET statements, start the window.
+func (pr *Proxy) splitRead(conn *ConnWrapper, request []byte) bool {
+	splitter := pr.ReadWriteSplit
+	if !IsPostgresQuery(request) {
+		return false
+	}
+	value, ok := pr.sessions.Load(conn)
+	if !ok {
+		return false
+	}
+	status := value.(*session).status.Load() //nolint:forcetypeassert
+
+	state := splitter.session(conn)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.pinned {
+		return false
+	}
+
+	if readOnlyRequest(request) {
+		if last := lastMessageType(request); status != 'I' || (last != 'Q' && last != 'S') {
+			return false
+		}
+		if splitter.inWindow(state) {
+			metrics.ProxyReadYourWrites.Inc()
+			return false
+		}
+		return true
+	}
+
+	settings := false
+	for _, query := range PostgresQueries(request) {
+		for _, statement := range strings.Split(query, ";") {
+			statement = strings.TrimSpace(statement)
+			switch {
+			case tempTableStatement.MatchString(statement):
+				state.pinned = true
+			case setStatement.MatchString(statement) || resetStatement.MatchString(statement) ||
+				discardStatement.MatchString(statement):
+				settings = true
+			}
+		}
+	}
+	// The server connection of the reads is authenticated again with the settings.
+	if (settings || state.pinned) && state.client != nil {
+		splitter.ReadsProxy.releaseClient(state.client)
+		state.client = nil
+	}
+	if !settings {
+		state.wroteAt, state.readAt, state.lsn = time.Now(), time.Time{}, 0
+	}
+	return false
+}
+
+// readsClient returns the server connection of the session of the connection to the
+// reads, borrowing one from the pool of the reads and authenticating the session on it
+// if needed.
+func (pr *Proxy) readsClient(
+	conn *ConnWrapper, correlation Correlation,
+) (*Client, *gerr.GatewayDError) {
+	splitter := pr.ReadWriteSplit
+	state := splitter.session(conn)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.client != nil {
+		return state.client, nil
+	}
+
+	client := splitter.ReadsProxy.popAvailableClient()
+	if client == nil && splitter.ReadsProxy.Elastic {
+		client = splitter.ReadsProxy.newClient()
+	}
+	if client == nil || client.ID == "" {
+		return nil, gerr.ErrReadsUnavailable.Wrap(
+			fmt.Errorf("the pool of %s is exhausted", splitter.Reads))
+	}
+
+	var startup, password []byte
+	if value, ok := pr.sessions.Load(conn); ok {
+		startup, password = value.(*session).authentication() //nolint:forcetypeassert
+	}
+	if startup == nil {
+		splitter.ReadsProxy.releaseClient(client)
+		return nil, gerr.ErrReadsUnavailable.Wrap(
+			fmt.Errorf("the session isn't authenticated on %s", splitter.Home))
+	}
+	if err := pr.authenticateSession(conn, client, startup, password, correlation); err != nil {
+		splitter.ReadsProxy.releaseClient(client)
+		return nil, gerr.ErrReadsUnavailable.Wrap(err)
+	}
+
+	state.client = client
+	return client, nil
+}
+
+// dropReadsClient puts the server connection of the session to the reads back in the
+// pool of the reads, e.g. after it failed, so that the next read borrows another.
+func (pr *Proxy) dropReadsClient(conn *ConnWrapper) {
+	state := pr.ReadWriteSplit.session(conn)
+	state.mu.Lock()
+	client := state.client
+	state.client = nil
+	state.mu.Unlock()
+	if client != nil {
+		pr.ReadWriteSplit.ReadsProxy.releaseClient(client)
+	}
+}
+
+// sendToReads runs the read-only request on the server connection of the session to
+// the reads, and relays the response to the client as it is received, up to its
+// ReadyForQuery message.
+func (pr *Proxy) sendToReads(
+	conn *ConnWrapper, client *Client, request []byte, correlation Correlation,
+) *gerr.GatewayDError {
+	start := time.Now()
+	if _, err := pr.sendTrafficToServer(client, request, correlation); err != nil {
+		pr.dropReadsClient(conn)
+		return err
+	}
+	pr.startStatementTimeout(conn, client, request, correlation)
+	metrics.ProxySplitReads.Inc()
+
+	if err := pr.relayResponse(conn, client, correlation, func() {
+		pr.dropReadsClient(conn)
+	}); err != nil {
+		return err
+	}
+
+	pr.observeQueryLatency(conn, time.Since(start), correlation)
+	return nil
+}
+
+// WatchReplication polls the replication of the primary on the replication interval,
+// if the read/write split tracks it. The backends reached through an SSH tunnel or an
+// upstream proxy can't be polled, as the poll connects to them directly.
+func (pr *Proxy) WatchReplication() {
+	splitter := pr.ReadWriteSplit
+	if splitter == nil || splitter.ReplicationInterval <= 0 || pr.ClientConfig == nil {
+		return
+	}
+	if !strings.HasPrefix(pr.ClientConfig.Network, "tcp") || pr.ClientConfig.SSHTunnel.Enabled ||
+		pr.ClientConfig.UpstreamProxy.URL != "" {
+		pr.logger.Warn().Str("network", pr.ClientConfig.Network).Msg(
+			"The replication can only be polled on the TCP backends that are reached directly")
+		return
+	}
+
+	if _, err := pr.scheduler.Every(splitter.ReplicationInterval).SingletonMode().Do(
+		pr.CheckReplication); err != nil {
+		pr.logger.Error().Err(err).Msg("Failed to schedule the poll of the replication")
+		sentry.CaptureException(err)
+		return
+	}
+	pr.logger.Info().Str("reads", splitter.Reads).Str(
+		"replicationInterval", splitter.ReplicationInterval.String()).Msg(
+		"Polling the replication of the backend for the read/write split")
+}
+
+// CheckReplication polls the replication of the backend the new clients connect to.
+func (pr *Proxy) CheckReplication() {
+	if pr.ReadWriteSplit == nil || pr.ClientConfig == nil {
+		return
+	}
+	address := pr.clientConfig().Address
+	if status := pr.ReadWriteSplit.CheckReplication(pr.ctx, address); status.Error != "" {
+		pr.logger.Debug().Str("address", address).Str("error", status.Error).Msg(
+			"Failed to poll the replication of the backend")
+	}
+}
@@ -0,0 +1,165 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLSN(t *testing.T) {
+	lsn, err := ParseLSN("16/B374D848")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x16B374D848), lsn)
+
+	_, err = ParseLSN("16B374D848")
+	assert.Error(t, err)
+	_, err = ParseLSN("16/G374D848")
+	assert.Error(t, err)
+}
+
+// TestReadWriteSplit tests that the reads of a session go to the reads, except in the
+// read-your-writes window of its writes, which ends once the standbys replayed them.
+func TestReadWriteSplit(t *testing.T) {
+	splitter, err := NewReadWriteSplitter("writes", config.ReadWriteSplit{
+		Reads: "reads", Window: time.Minute, ReplicationInterval: time.Second,
+	}, map[string]*Proxy{"reads": {}}, config.DefaultDialTimeout)
+	require.Nil(t, err)
+	var current, replayed uint64
+	splitter.check = func(context.Context, string) (uint64, uint64, error) {
+		return current, replayed, nil
+	}
+	proxy := &Proxy{ReadWriteSplit: splitter}
+	conn := &ConnWrapper{}
+	state := newSession()
+	state.status.Store('I')
+	proxy.sessions.Store(conn, state)
+
+	read := PostgreSQLQuery("SELECT * FROM users")
+	assert.True(t, proxy.splitRead(conn, read))
+	assert.False(t, proxy.splitRead(conn, PostgreSQLQuery("SELECT * FROM users FOR UPDATE")))
+
+	// The reads stay on the backend until the standbys replayed the write.
+	assert.False(t, proxy.splitRead(conn, PostgreSQLQuery("INSERT INTO users VALUES (1)")))
+	assert.False(t, proxy.splitRead(conn, read))
+	current, replayed = 200, 100
+	splitter.CheckReplication(context.Background(), "primary:5432")
+	assert.False(t, proxy.splitRead(conn, read))
+	current, replayed = 300, 200
+	splitter.CheckReplication(context.Background(), "primary:5432")
+	assert.True(t, proxy.splitRead(conn, read))
+
+	// The SET statements don't start the window, and the reads of a transaction stay on
+	// the backend.
+	assert.False(t, proxy.splitRead(conn, PostgreSQLQuery("SET search_path TO app")))
+	assert.True(t, proxy.splitRead(conn, read))
+	state.status.Store('T')
+	assert.False(t, proxy.splitRead(conn, read))
+	state.status.Store('I')
+
+	// The window ends without the polls of the replication.
+	splitter.ReplicationInterval = 0
+	splitter.Window = time.Millisecond
+	assert.False(t, proxy.splitRead(conn, PostgreSQLQuery("DELETE FROM users")))
+	time.Sleep(2 * time.Millisecond)
+	assert.True(t, proxy.splitRead(conn, read))
+
+	// The temporary tables only exist on the backend.
+	assert.False(t, proxy.splitRead(conn, PostgreSQLQuery("CREATE TEMP TABLE ids (id int)")))
+	time.Sleep(2 * time.Millisecond)
+	assert.False(t, proxy.splitRead(conn, read))
+
+	_, err = NewReadWriteSplitter("writes", config.ReadWriteSplit{Reads: "replicas"},
+		map[string]*Proxy{}, config.DefaultDialTimeout)
+	assert.Equal(t, gerr.ErrCodeInvalidReadWriteSplit, err.Code)
+}
+
+// TestProxySendToReads tests that the session is authenticated on a server connection
+// of the reads, and that the response of the read is relayed to the client.
+func TestProxySendToReads(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+	newProxy := func(size int) *Proxy {
+		return NewProxy(
+			ctx, pool.NewPool(ctx, size),
+			plugin.NewRegistry(ctx, config.Loose, config.PassDown, config.Accept, config.Stop, logger, false),
+			false, false, config.DefaultHealthCheckPeriod, nil, logger, config.DefaultPluginTimeout)
+	}
+	proxy, reads := newProxy(1), newProxy(1)
+	defer proxy.Shutdown()
+	defer reads.Shutdown()
+
+	startup := CreatePgStartupPacket()
+	query := PostgreSQLQuery("SELECT * FROM users")
+	authenticationOk := []byte{'R', 0, 0, 0, 8, 0, 0, 0, 0}
+	rows := append([]byte("C\x00\x00\x00\x0dSELECT 0\x00"), PostgreSQLReadyForQuery()...)
+
+	database, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer database.Close()
+	received := make(chan []byte, 2)
+	go func() {
+		conn, err := database.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, response := range [][]byte{append(authenticationOk, PostgreSQLReadyForQuery()...), rows} {
+			buffer := make([]byte, config.DefaultChunkSize)
+			read, err := conn.Read(buffer)
+			if err != nil {
+				return
+			}
+			received <- buffer[:read]
+			_, _ = conn.Write(response)
+		}
+	}()
+
+	client := NewClient(ctx, &config.Client{
+		Network:          "tcp",
+		Address:          database.Addr().String(),
+		ReceiveChunkSize: config.DefaultChunkSize,
+		DialTimeout:      config.DefaultDialTimeout,
+	}, logger, NewRetry(0, config.DefaultBackoff, config.DefaultBackoffMultiplier, false, logger))
+	require.NotNil(t, client)
+	defer client.Close()
+	require.Nil(t, reads.availableConnections.Put(client.ID, client))
+
+	splitter, splitErr := NewReadWriteSplitter("writes", config.ReadWriteSplit{Reads: "reads"},
+		map[string]*Proxy{"reads": reads}, config.DefaultDialTimeout)
+	require.Nil(t, splitErr)
+	proxy.ReadWriteSplit = splitter
+
+	incoming, outgoing := net.Pipe()
+	defer outgoing.Close()
+	conn := NewConnWrapper(incoming, nil, config.DefaultHandshakeTimeout)
+	defer conn.Close()
+	proxy.sessions.Store(conn, newSession())
+	proxy.recordUsage(conn, Ingress, startup)
+	proxy.recordUsage(conn, Egress, PostgreSQLReadyForQuery())
+
+	correlation := Correlation{ConnectionID: conn.ID()}
+	readsClient, readsErr := proxy.readsClient(conn, correlation)
+	require.Nil(t, readsErr)
+	assert.Equal(t, client, readsClient)
+	assert.Equal(t, startup, <-received)
+	assert.Equal(t, 0, reads.availableConnections.Size())
+
+	response := make(chan []byte)
+	go func() {
+		buffer := make([]byte, config.DefaultChunkSize)
+		read, _ := outgoing.Read(buffer)
+		response <- buffer[:read]
+	}()
+	assert.Nil(t, proxy.sendToReads(conn, readsClient, query, correlation))
+	assert.Equal(t, query, <-received)
+	assert.Equal(t, rows, <-response)
+}
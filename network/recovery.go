@@ -0,0 +1,261 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"go.opentelemetry.io/otel"
+)
+
+var deallocateStatement = regexp.MustCompile(`(?is)^deallocate\s+(?:prepare\s+)?("?[\w$]+"?)`)
+
+// preparedStatements holds the Parse messages of the named prepared statements of a
+// session, so that they can be prepared again on a new server connection.
+type preparedStatements struct {
+	mu         sync.Mutex
+	names      []string
+	statements map[string][]byte
+}
+
+// track records the Parse and Close messages of the request, and the DEALLOCATE and
+// DISCARD ALL statements of its queries.
+//
+//nolint:gomnd
+func (p *preparedStatements) track(request []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for offset := 0; offset+5 <= len(request); {
+		length := int(binary.BigEndian.Uint32(request[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(request) {
+			break
+		}
+		message := request[offset : offset+1+length]
+		body := message[5:]
+		switch message[0] {
+		case 'P':
+			// The unnamed statement only lasts until the next Parse message.
+			if name, _, ok := bytes.Cut(body, []byte{0}); ok && len(name) > 0 {
+				p.set(string(name), message)
+			}
+		case 'C':
+			if len(body) > 1 && body[0] == 'S' {
+				p.remove(string(bytes.TrimRight(body[1:], "\x00")))
+			}
+		case 'Q':
+			for _, statement := range strings.Split(string(bytes.TrimRight(body, "\x00")), ";") {
+				statement = strings.TrimSpace(statement)
+				if discardStatement.MatchString(statement) {
+					p.names = nil
+					p.statements = nil
+				} else if match := deallocateStatement.FindStringSubmatch(statement); match != nil {
+					if name := strings.Trim(match[1], `"`); strings.EqualFold(name, "all") {
+						p.names = nil
+						p.statements = nil
+					} else {
+						p.remove(name)
+					}
+				}
+			}
+		}
+		offset += 1 + length
+	}
+}
+
+// set must be called with the lock held.
+func (p *preparedStatements) set(name string, message []byte) {
+	if p.statements == nil {
+		p.statements = make(map[string][]byte)
+	}
+	if _, ok := p.statements[name]; !ok {
+		p.names = append(p.names, name)
+	}
+	p.statements[name] = bytes.Clone(message)
+}
+
+// remove must be called with the lock held.
+func (p *preparedStatements) remove(name string) {
+	if _, ok := p.statements[name]; !ok {
+		return
+	}
+	delete(p.statements, name)
+	for index, other := range p.names {
+		if other == name {
+			p.names = append(p.names[:index], p.names[index+1:]...)
+			break
+		}
+	}
+}
+
+// Size returns the approximate memory held by the prepared statements, in bytes.
+func (p *preparedStatements) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	size := 0
+	for name, message := range p.statements {
+		size += len(name) + len(message)
+	}
+	return size
+}
+
+// Messages returns the Parse messages of the prepared statements, in the order they
+// were prepared.
+func (p *preparedStatements) Messages() [][]byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	messages := make([][]byte, 0, len(p.names))
+	for _, name := range p.names {
+		messages = append(messages, p.statements[name])
+	}
+	return messages
+}
+
+// isServerLost returns true if the server connection of the connection died, as
+// opposed to being closed after the client disconnected or terminated the session.
+func (pr *Proxy) isServerLost(conn *ConnWrapper, client *Client) bool {
+	value, ok := pr.sessions.Load(conn)
+	if !ok || pr.busyConnections.Get(conn) != client {
+		// The client disconnected, and its server connection was recycled.
+		return false
+	}
+	return !value.(*session).terminated.Load() //nolint:forcetypeassert
+}
+
+// recoverSession moves the session of the connection to a new server connection after
+// its own died, authenticating it with the startup and password messages of the
+// session and restoring its settings and prepared statements, so that the client can
+// keep using it. Only the idle sessions are recovered, since the transactions and the
+// queries in flight die with the server connection, and only the sessions that
+// authenticated without a challenge, i.e. with trust, a cleartext password or an IAM
+// auth token. The cancel requests of the client still target the old server process.
+func (pr *Proxy) recoverSession(conn *ConnWrapper, client *Client, correlation Correlation) bool {
+	_, span := otel.Tracer(config.TracerName).Start(pr.ctx, "recoverSession")
+	defer span.End()
+
+	logger := correlation.Logger(pr.logger)
+
+	value, ok := pr.sessions.Load(conn)
+	if !ok {
+		return false
+	}
+	session := value.(*session) //nolint:forcetypeassert
+	startup, password := session.authentication()
+	if !pr.ReconnectSessions || startup == nil || session.state.Load() != Idle {
+		metrics.ProxySessionDrops.Inc()
+		return false
+	}
+
+	if err := pr.reconnectSession(conn, client, startup, password, correlation); err != nil {
+		logger.Error().Err(err).Msg("Failed to recover the session on a new server connection")
+		span.RecordError(err)
+		metrics.ProxySessionDrops.Inc()
+		return false
+	}
+
+	logger.Info().Str("address", client.Address).Msg(
+		"Recovered the session on a new server connection")
+	metrics.ProxySessionRecoveries.Inc()
+	return true
+}
+
+// reconnectSession reconnects the client and authenticates and restores the session
+// of the connection on it.
+func (pr *Proxy) reconnectSession(
+	conn *ConnWrapper, client *Client, startup, password []byte, correlation Correlation,
+) *gerr.GatewayDError {
+	if err := pr.reconnectClient(client, ReconnectSessionRecovery); err != nil {
+		return gerr.ErrRecoverSessionFailed.Wrap(err)
+	}
+	return pr.authenticateSession(conn, client, startup, password, correlation)
+}
+
+// authenticateSession authenticates the session of the connection on the new server
+// connection with its startup and password messages, and restores its settings and
+// prepared statements on it.
+func (pr *Proxy) authenticateSession(
+	conn *ConnWrapper, client *Client, startup, password []byte, correlation Correlation,
+) *gerr.GatewayDError {
+	if _, err := pr.sendTrafficToServer(client, startup, correlation); err != nil {
+		return err
+	}
+	var response []byte
+	for PostgresTransactionStatus(response) == 0 {
+		_, received, _, err := pr.receiveTrafficFromServer(client, 0, correlation)
+		if err != nil {
+			return err
+		}
+
+		if IsPostgresCleartextPasswordRequest(received) {
+			switch {
+			case client.IAMAuth != nil:
+				_, received, _, err = pr.authenticateWithIAM(conn, client, correlation)
+			case password != nil:
+				if _, err = pr.sendTrafficToServer(client, password, correlation); err == nil {
+					_, received, _, err = pr.receiveTrafficFromServer(client, 0, correlation)
+				}
+			default:
+				err = gerr.ErrRecoverSessionFailed.Wrap(errors.New("no password to send"))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if PostgresAuthenticationRequest(received) > 0 {
+			// The other authentication methods send a challenge only the client can answer.
+			return gerr.ErrRecoverSessionFailed.Wrap(errors.New("unsupported authentication method"))
+		}
+		response = append(response, received...)
+	}
+	if message := PostgresErrorMessage(response); message != "" {
+		return gerr.ErrRecoverSessionFailed.Wrap(errors.New(message))
+	}
+
+	if err := pr.restoreSettings(conn, client, correlation); err != nil {
+		return err
+	}
+	return pr.restoreStatements(conn, client, correlation)
+}
+
+// restoreStatements prepares the prepared statements of the session of the connection
+// again on its server connection. The statements that fail are skipped.
+func (pr *Proxy) restoreStatements(
+	conn *ConnWrapper, client *Client, correlation Correlation,
+) *gerr.GatewayDError {
+	value, ok := pr.sessions.Load(conn)
+	if !ok {
+		return nil
+	}
+
+	logger := correlation.Logger(pr.logger)
+	for _, message := range value.(*session).statements.Messages() { //nolint:forcetypeassert
+		// The Sync message asks for a ReadyForQuery message once it is parsed, and
+		// ends the implicit transaction, so that a failure doesn't skip the others.
+		message = append(bytes.Clone(message), 'S', 0, 0, 0, 4)
+		if _, err := pr.sendTrafficToServer(client, message, correlation); err != nil {
+			return err
+		}
+
+		var response []byte
+		for PostgresTransactionStatus(response) == 0 {
+			_, received, _, err := pr.receiveTrafficFromServer(client, 0, correlation)
+			if err != nil {
+				return err
+			}
+			response = append(response, received...)
+		}
+		if message := PostgresErrorMessage(response); message != "" {
+			logger.Warn().Str("error", message).Msg("Failed to restore a prepared statement")
+		}
+	}
+
+	return nil
+}
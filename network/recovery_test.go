@@ -0,0 +1,128 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPreparedStatements tests that the named prepared statements of a session are
+// tracked until they are closed or deallocated.
+func TestPreparedStatements(t *testing.T) {
+	var statements preparedStatements
+	first := CreatePostgreSQLPacket('P', []byte("first\x00SELECT 1\x00\x00\x00"))
+	second := CreatePostgreSQLPacket('P', []byte("second\x00SELECT 2\x00\x00\x00"))
+	unnamed := CreatePostgreSQLPacket('P', []byte("\x00SELECT 3\x00\x00\x00"))
+
+	statements.track(append(append(first, second...), unnamed...))
+	assert.Equal(t, [][]byte{first, second}, statements.Messages())
+
+	statements.track(CreatePostgreSQLPacket('C', []byte("Sfirst\x00")))
+	assert.Equal(t, [][]byte{second}, statements.Messages())
+
+	statements.track(PostgreSQLQuery("DEALLOCATE second"))
+	assert.Empty(t, statements.Messages())
+
+	statements.track(first)
+	statements.track(PostgreSQLQuery("SELECT 1; DISCARD ALL"))
+	assert.Empty(t, statements.Messages())
+}
+
+// TestProxyRecoverSession tests that an idle session is moved to a new server
+// connection when its own dies, and that the other sessions are disconnected.
+func TestProxyRecoverSession(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, 1),
+		plugin.NewRegistry(ctx, config.Loose, config.PassDown, config.Accept, config.Stop, logger, false),
+		false, false, config.DefaultHealthCheckPeriod, nil, logger, config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+	require.Nil(t, proxy.Configure(&config.Proxy{ReconnectSessions: true}))
+
+	startup := CreatePgStartupPacket()
+	password := PostgreSQLPasswordMessage("secret")
+	set := PostgreSQLQuery("SET search_path TO app")
+	parse := CreatePostgreSQLPacket('P', []byte("statement\x00SELECT 1\x00\x00\x00"))
+	sync := []byte{'S', 0, 0, 0, 4}
+	passwordRequest := []byte{'R', 0, 0, 0, 8, 0, 0, 0, 3}
+	authenticationOk := []byte{'R', 0, 0, 0, 8, 0, 0, 0, 0}
+
+	// The database closes the first connection, and expects the session to be
+	// authenticated and restored on the second one.
+	database, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer database.Close()
+	received := make(chan []byte, 4)
+	go func() {
+		first, err := database.Accept()
+		if err != nil {
+			return
+		}
+		first.Close()
+
+		second, err := database.Accept()
+		if err != nil {
+			return
+		}
+		defer second.Close()
+		for _, response := range [][]byte{
+			passwordRequest,
+			append(authenticationOk, PostgreSQLReadyForQuery()...),
+			append([]byte("C\x00\x00\x00\x08SET\x00"), PostgreSQLReadyForQuery()...),
+			append([]byte{'1', 0, 0, 0, 4}, PostgreSQLReadyForQuery()...),
+		} {
+			buffer := make([]byte, config.DefaultChunkSize)
+			read, err := second.Read(buffer)
+			if err != nil {
+				return
+			}
+			received <- buffer[:read]
+			_, _ = second.Write(response)
+		}
+	}()
+
+	client := NewClient(ctx, &config.Client{
+		Network:          "tcp",
+		Address:          database.Addr().String(),
+		ReceiveChunkSize: config.DefaultChunkSize,
+		DialTimeout:      config.DefaultDialTimeout,
+	}, logger, NewRetry(0, config.DefaultBackoff, config.DefaultBackoffMultiplier, false, logger))
+	require.NotNil(t, client)
+	defer client.Close()
+
+	incoming, outgoing := net.Pipe()
+	defer outgoing.Close()
+	conn := NewConnWrapper(incoming, nil, config.DefaultHandshakeTimeout)
+	defer conn.Close()
+	require.Nil(t, proxy.busyConnections.Put(conn, client))
+	proxy.sessions.Store(conn, newSession())
+	for _, request := range [][]byte{startup, password, set, append(parse, sync...)} {
+		proxy.recordUsage(conn, Ingress, request)
+	}
+	proxy.recordUsage(conn, Egress, PostgreSQLReadyForQuery())
+
+	assert.Nil(t, proxy.PassThroughToClient(conn, NewStack()))
+	assert.Equal(t, startup, <-received)
+	assert.Equal(t, password, <-received)
+	assert.Equal(t, set, <-received)
+	assert.Equal(t, append(parse, sync...), <-received)
+
+	// The sessions with a query in flight are disconnected, with an error.
+	response := make(chan []byte)
+	go func() {
+		buffer := make([]byte, config.DefaultChunkSize)
+		read, _ := outgoing.Read(buffer)
+		response <- buffer[:read]
+	}()
+	proxy.recordUsage(conn, Ingress, PostgreSQLQuery("SELECT 1"))
+	assert.NotNil(t, proxy.PassThroughToClient(conn, NewStack()))
+	assert.Equal(t, "the connection to the database was lost", PostgresErrorMessage(<-response))
+}
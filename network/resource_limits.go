@@ -0,0 +1,86 @@
+package network
+
+import (
+	"encoding/binary"
+	"strings"
+
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/recorder"
+)
+
+// hold accounts the data the proxy buffers for the connection, until the returned
+// function releases it.
+func (pr *Proxy) hold(conn *ConnWrapper, size int) func() {
+	if value, ok := pr.sessions.Load(conn); ok {
+		return value.(*session).hold(size) //nolint:forcetypeassert
+	}
+	return func() {}
+}
+
+// exceedsConnectionMemory returns true if the request would make its connection hold
+// more memory than the limit. The requests that free memory, e.g. DEALLOCATE or
+// DISCARD ALL, are always accepted, so that the clients can get back under it.
+func (pr *Proxy) exceedsConnectionMemory(conn *ConnWrapper, request []byte) bool {
+	if pr.MaxConnectionMemory <= 0 || releasesMemory(request) {
+		return false
+	}
+	value, ok := pr.sessions.Load(conn)
+	if !ok {
+		return false
+	}
+	// The request is already held while it is checked.
+	return value.(*session).memory() > int64(pr.MaxConnectionMemory) //nolint:forcetypeassert
+}
+
+// releasesMemory returns true if the request closes prepared statements, or resets the
+// settings of the session.
+//
+//nolint:gomnd
+func releasesMemory(request []byte) bool {
+	for offset := 0; offset+5 <= len(request); {
+		length := int(binary.BigEndian.Uint32(request[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(request) {
+			break
+		}
+		switch request[offset] {
+		case 'C', 'X':
+			return true
+		}
+		offset += 1 + length
+	}
+
+	for _, query := range PostgresQueries(request) {
+		for _, statement := range strings.Split(query, ";") {
+			statement = strings.TrimSpace(statement)
+			if deallocateStatement.MatchString(statement) || discardStatement.MatchString(statement) ||
+				resetStatement.MatchString(statement) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// spawn runs the function of a connection in a goroutine of the server, which is
+// accounted until it returns. The flight recorder is dumped if it panics.
+func (s *Server) spawn(function func()) {
+	metrics.ServerGoroutines.WithLabelValues(s.Address).Set(float64(s.goroutines.Add(1)))
+	go func() {
+		defer recorder.DumpOnPanic()
+		defer func() {
+			metrics.ServerGoroutines.WithLabelValues(s.Address).Set(float64(s.goroutines.Add(-1)))
+		}()
+		function()
+	}()
+}
+
+// Goroutines returns the number of goroutines the server runs for its connections.
+func (s *Server) Goroutines() int64 {
+	return s.goroutines.Load()
+}
+
+// exceedsGoroutines returns true if the server runs more goroutines for its
+// connections than the limit, counting the one opening the new connection.
+func (s *Server) exceedsGoroutines() bool {
+	return s.MaxGoroutines > 0 && s.goroutines.Load() > int64(s.MaxGoroutines)
+}
@@ -0,0 +1,67 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConnectionMemory tests that the memory of the connections is accounted, and that
+// their requests are rejected beyond the limit unless they free memory.
+func TestConnectionMemory(t *testing.T) {
+	proxy := &Proxy{MaxConnectionMemory: 100}
+	conn := NewConnWrapper(nil, nil, 0)
+	state := newSession()
+	proxy.sessions.Store(conn, state)
+
+	request := PostgreSQLQuery("SELECT 1")
+	release := proxy.hold(conn, len(request))
+	assert.Equal(t, int64(len(request)), state.memory())
+	assert.False(t, proxy.exceedsConnectionMemory(conn, request))
+	release()
+	assert.Zero(t, state.memory())
+
+	// The prepared statements are held until they are deallocated.
+	parse := CreatePostgreSQLPacket('P', append([]byte("statement\x00"), make([]byte, 100)...))
+	state.record(Ingress, parse)
+	assert.Greater(t, state.memory(), int64(100))
+	assert.True(t, proxy.exceedsConnectionMemory(conn, request))
+
+	deallocate := PostgreSQLQuery("DEALLOCATE statement")
+	assert.False(t, proxy.exceedsConnectionMemory(conn, deallocate))
+	state.record(Ingress, deallocate)
+	assert.False(t, proxy.exceedsConnectionMemory(conn, request))
+
+	assert.True(t, releasesMemory(PostgreSQLQuery("SELECT 1; DISCARD ALL")))
+	assert.True(t, releasesMemory(CreatePostgreSQLPacket('C', []byte("Sstatement\x00"))))
+	assert.False(t, releasesMemory(request))
+	assert.False(t, (&Proxy{}).exceedsConnectionMemory(conn, request))
+}
+
+// TestServerGoroutines tests that the goroutines of the server are accounted until they
+// return, and that the connections are rejected beyond the limit.
+func TestServerGoroutines(t *testing.T) {
+	server := &Server{MaxGoroutines: 1}
+	assert.False(t, server.exceedsGoroutines())
+
+	done := make(chan struct{})
+	started := make(chan struct{}, 2)
+	for range 2 {
+		server.spawn(func() {
+			started <- struct{}{}
+			<-done
+		})
+	}
+	<-started
+	<-started
+	assert.Equal(t, int64(2), server.Goroutines())
+	assert.True(t, server.exceedsGoroutines())
+
+	close(done)
+	require.Eventually(t, func() bool { return server.Goroutines() == 0 },
+		time.Second, time.Millisecond)
+	assert.False(t, server.exceedsGoroutines())
+	assert.False(t, (&Server{}).exceedsGoroutines())
+}
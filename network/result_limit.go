@@ -0,0 +1,297 @@
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/policy"
+)
+
+// ResultLimitRule is the maximum number of rows and bytes of the result sets of the
+// queries its policy matches. A limit of zero disables it.
+type ResultLimitRule struct {
+	Name     string
+	Policy   *policy.Program
+	MaxRows  int
+	MaxBytes int
+}
+
+// exceeded returns true if a result set of the rows and bytes exceeds the limits.
+func (r *ResultLimitRule) exceeded(rows, bytes int) bool {
+	return (r.MaxRows > 0 && rows > r.MaxRows) || (r.MaxBytes > 0 && bytes > r.MaxBytes)
+}
+
+// message returns the message of the error of a result set of the rows that exceeds
+// the limits.
+func (r *ResultLimitRule) message(rows int) string {
+	if r.MaxRows > 0 && rows > r.MaxRows {
+		return fmt.Sprintf("the result exceeds the limit of %d rows", r.MaxRows)
+	}
+	return fmt.Sprintf("the result exceeds the limit of %d bytes", r.MaxBytes)
+}
+
+// ResultLimits are the limits of the result sets the proxy stops sending to the
+// clients, whatever the databases return.
+type ResultLimits struct {
+	Default ResultLimitRule
+	Rules   []ResultLimitRule
+}
+
+// NewResultLimits compiles the policies of the rules of the result limit.
+func NewResultLimits(cfg config.ResultLimit) (*ResultLimits, *gerr.GatewayDError) {
+	if cfg.MaxRows < 0 || cfg.MaxBytes < 0 {
+		return nil, gerr.ErrInvalidPolicy.Wrap(
+			fmt.Errorf("invalid result limit of %d rows and %d bytes", cfg.MaxRows, cfg.MaxBytes))
+	}
+
+	limits := &ResultLimits{
+		Default: ResultLimitRule{
+			Name: config.DefaultResultLimitRule, MaxRows: cfg.MaxRows, MaxBytes: cfg.MaxBytes,
+		},
+		Rules: make([]ResultLimitRule, 0, len(cfg.Rules)),
+	}
+	for _, rule := range cfg.Rules {
+		if rule.MaxRows < 0 || rule.MaxBytes < 0 {
+			return nil, gerr.ErrInvalidPolicy.Wrap(fmt.Errorf(
+				"invalid limit of %d rows and %d bytes of the result limit rule %q",
+				rule.MaxRows, rule.MaxBytes, rule.Name))
+		}
+		program, err := policy.Compile(rule.When)
+		if err != nil {
+			return nil, err
+		}
+		limits.Rules = append(limits.Rules, ResultLimitRule{
+			Name:     rule.Name,
+			Policy:   program,
+			MaxRows:  rule.MaxRows,
+			MaxBytes: rule.MaxBytes,
+		})
+	}
+	return limits, nil
+}
+
+// resultLimit counts the rows and bytes of the result sets of a session against the
+// limits of the rule of its last query, across the reads of the responses.
+type resultLimit struct {
+	mu   sync.Mutex
+	rule *ResultLimitRule
+	// rows and bytes are the size of the result set being sent, which continues over
+	// the executions of a suspended portal.
+	rows  int
+	bytes int
+	// exceeded is true from when a result set exceeds the limits until the next
+	// ReadyForQuery message, and the messages in between are dropped.
+	exceeded bool
+	// header is the start of a message split across the reads, which is sent with the
+	// rest of it, and remaining is the number of bytes of the last message in the next
+	// reads, which are dropped if dropping is true.
+	header    []byte
+	remaining int
+	dropping  bool
+}
+
+// start sets the rule of the result sets of the next query. The rule is nil if the
+// query has no limit.
+func (r *resultLimit) start(rule *ResultLimitRule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The messages are only followed while there is a rule.
+	if r.rule == nil {
+		r.rows, r.bytes, r.exceeded = 0, 0, false
+		r.header, r.remaining, r.dropping = nil, 0, false
+	}
+	r.rule = rule
+}
+
+// limit returns the response without the messages of the result set that exceeded its
+// limits, which are replaced with the error response, up to the next ReadyForQuery
+// message. It also returns the rule of the result set that exceeded its limits in the
+// response, if any, and whether the rest of that result set is still to come.
+//
+//nolint:gomnd
+func (r *resultLimit) limit(
+	response []byte, errorResponse func(rule *ResultLimitRule, rows int) []byte,
+) ([]byte, *ResultLimitRule, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.rule == nil {
+		return response, nil, false
+	}
+
+	data := response
+	if len(r.header) > 0 {
+		data = append(r.header, response...)
+		r.header = nil
+	}
+
+	// The output is the data itself until a message is dropped, and a copy of the
+	// kept messages after that.
+	var output []byte
+	copied := false
+	drop := func(offset int) {
+		if !copied {
+			output = append(make([]byte, 0, len(data)), data[:offset]...)
+			copied = true
+		}
+	}
+	keep := func(from, to int) {
+		if copied {
+			output = append(output, data[from:to]...)
+		}
+	}
+
+	offset := 0
+	if r.remaining > 0 {
+		offset = min(r.remaining, len(data))
+		r.remaining -= offset
+		if r.dropping {
+			drop(0)
+		}
+	}
+
+	var exceeded *ResultLimitRule
+	for offset < len(data) {
+		if len(data)-offset < 5 {
+			r.header = append([]byte(nil), data[offset:]...)
+			drop(offset)
+			break
+		}
+		length := int(binary.BigEndian.Uint32(data[offset+1 : offset+5]))
+		if length < 4 {
+			keep(offset, len(data))
+			break
+		}
+
+		size := 1 + length
+		switch data[offset] {
+		case 'D':
+			if !r.exceeded {
+				r.rows++
+				r.bytes += size
+				if r.rule.exceeded(r.rows, r.bytes) {
+					r.exceeded = true
+					exceeded = r.rule
+					drop(offset)
+					output = append(output, errorResponse(r.rule, r.rows)...)
+				}
+			}
+		case 'T', 'C', 'I', 'E':
+			// A new result set starts, or the last one ended.
+			r.rows, r.bytes = 0, 0
+		}
+
+		dropped := r.exceeded && data[offset] != 'Z'
+		if data[offset] == 'Z' {
+			r.exceeded = false
+		}
+		end := min(offset+size, len(data))
+		if dropped {
+			drop(offset)
+		} else {
+			keep(offset, end)
+		}
+		r.remaining = offset + size - end
+		r.dropping = dropped
+		offset = end
+	}
+
+	if !copied {
+		output = data
+	}
+	return output, exceeded, r.exceeded
+}
+
+// resultLimit returns the result limit rule of the request: the first rule whose policy
+// matches a query of the request, or the default one, or nil if it has no limits. The
+// requests without the SQL of their queries are matched by the user and the database of
+// the connection alone.
+func (pr *Proxy) resultLimit(conn *ConnWrapper, request []byte) *ResultLimitRule {
+	queries := PostgresQueries(request)
+	if len(queries) == 0 {
+		queries = []string{""}
+	}
+
+	rule := &pr.ResultLimits.Default
+	for index := range pr.ResultLimits.Rules {
+		if pr.resultLimitMatches(conn, &pr.ResultLimits.Rules[index], queries) {
+			rule = &pr.ResultLimits.Rules[index]
+			break
+		}
+	}
+	if rule.MaxRows == 0 && rule.MaxBytes == 0 {
+		return nil
+	}
+	return rule
+}
+
+func (pr *Proxy) resultLimitMatches(conn *ConnWrapper, rule *ResultLimitRule, queries []string) bool {
+	for _, query := range queries {
+		matches, err := rule.Policy.Matches(pr.policyVars(conn, query))
+		if err != nil {
+			pr.logger.Debug().Err(err).Str("rule", rule.Name).Msg(
+				"Failed to evaluate the result limit rule, so it doesn't match")
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// startResultLimit sets the result limit rule of the request on the session of the
+// connection.
+func (pr *Proxy) startResultLimit(conn *ConnWrapper, request []byte) {
+	if pr.ResultLimits == nil || !IsPostgresQuery(request) {
+		return
+	}
+	if value, ok := pr.sessions.Load(conn); ok {
+		value.(*session).result.start(pr.resultLimit(conn, request)) //nolint:forcetypeassert
+	}
+}
+
+// limitResult stops sending the result set of the response to the connection once it
+// exceeds its limits, sends the client an error instead, and cancels the query on the
+// server connection if it is still sending the result set.
+func (pr *Proxy) limitResult(
+	conn *ConnWrapper, client *Client, response []byte, correlation Correlation,
+) []byte {
+	if pr.ResultLimits == nil {
+		return response
+	}
+	value, ok := pr.sessions.Load(conn)
+	if !ok {
+		return response
+	}
+
+	//nolint:forcetypeassert
+	response, rule, pending := value.(*session).result.limit(
+		response, func(rule *ResultLimitRule, rows int) []byte {
+			return ErrorResponse(pr.ErrorMessages, config.ErrorResultTooLarge, rule.message(rows))
+		})
+	if rule == nil {
+		return response
+	}
+
+	metrics.ProxyResultLimitsExceeded.WithLabelValues(rule.Name).Inc()
+	logger := correlation.Logger(pr.logger)
+	if pending {
+		if err := client.Cancel(); err != nil {
+			logger.Error().Err(err).Str("rule", rule.Name).Msg(
+				"Failed to cancel the query whose result exceeded its limit")
+		}
+	}
+	logger.Warn().Fields(
+		map[string]interface{}{
+			"rule":     rule.Name,
+			"maxRows":  rule.MaxRows,
+			"maxBytes": rule.MaxBytes,
+		},
+	).Msg("Stopped the result that exceeded its limit")
+	return response
+}
@@ -0,0 +1,188 @@
+package network
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resultMessage creates a message of the type with the body.
+func resultMessage(typ byte, body string) []byte {
+	message := append([]byte{typ, 0, 0, 0, 0}, body...)
+	binary.BigEndian.PutUint32(message[1:5], uint32(len(message)-1))
+	return message
+}
+
+func resultError(rule *ResultLimitRule, rows int) []byte {
+	return PostgreSQLErrorResponse("ERROR", SQLStateProgramLimitExceeded, rule.message(rows))
+}
+
+// TestResultLimit tests that the rows of the result sets past their limit are replaced
+// with an error, whichever way the response is split across the reads.
+func TestResultLimit(t *testing.T) {
+	rule := &ResultLimitRule{Name: "export", MaxRows: 2}
+	description := resultMessage('T', "\x00\x01id\x00")
+	row := resultMessage('D', "\x00\x01\x00\x00\x00\x011")
+	var response []byte
+	response = append(response, description...)
+	for range 5 {
+		response = append(response, row...)
+	}
+	response = append(response, resultMessage('C', "SELECT 5\x00")...)
+	response = append(response, PostgreSQLReadyForQuery()...)
+
+	var expected []byte
+	expected = append(expected, description...)
+	expected = append(expected, row...)
+	expected = append(expected, row...)
+	expected = append(expected, resultError(rule, 3)...)
+	expected = append(expected, PostgreSQLReadyForQuery()...)
+
+	for split := range len(response) {
+		var limit resultLimit
+		limit.start(rule)
+		first, exceeded, pending := limit.limit(response[:split], resultError)
+		second, exceededLater, pendingLater := limit.limit(response[split:], resultError)
+		assert.Equal(t, expected, append(append([]byte{}, first...), second...), "split at %d", split)
+		assert.False(t, pendingLater)
+		if exceeded == nil {
+			assert.Equal(t, rule, exceededLater)
+		} else {
+			assert.Nil(t, exceededLater)
+		}
+		// The result set is pending until the header of the ReadyForQuery message.
+		assert.Equal(t, exceeded != nil && split < len(response)-1, pending, "split at %d", split)
+	}
+
+	// The next result set has its own limit.
+	var limit resultLimit
+	limit.start(rule)
+	_, exceeded, _ := limit.limit(response, resultError)
+	assert.Equal(t, rule, exceeded)
+	var small []byte
+	small = append(small, description...)
+	small = append(small, row...)
+	small = append(small, resultMessage('C', "SELECT 1\x00")...)
+	small = append(small, PostgreSQLReadyForQuery()...)
+	output, exceeded, _ := limit.limit(small, resultError)
+	assert.Equal(t, small, output)
+	assert.Nil(t, exceeded)
+
+	// The rows of a suspended portal count until its result set completes.
+	suspended := append(append(append([]byte{}, row...), row...), resultMessage('s', "")...)
+	output, exceeded, _ = limit.limit(suspended, resultError)
+	assert.Equal(t, suspended, output)
+	assert.Nil(t, exceeded)
+	_, exceeded, pending := limit.limit(row, resultError)
+	assert.Equal(t, rule, exceeded)
+	assert.True(t, pending)
+	output, _, pending = limit.limit(PostgreSQLReadyForQuery(), resultError)
+	assert.Equal(t, PostgreSQLReadyForQuery(), output)
+	assert.False(t, pending)
+
+	// The bytes of the rows are limited too, and the queries without a rule aren't.
+	limit.start(&ResultLimitRule{Name: "bytes", MaxBytes: 2 * len(row)})
+	_, exceeded, _ = limit.limit(response, resultError)
+	assert.Equal(t, "bytes", exceeded.Name)
+	limit.start(nil)
+	output, exceeded, _ = limit.limit(response, resultError)
+	assert.Equal(t, response, output)
+	assert.Nil(t, exceeded)
+}
+
+// TestResultLimitRules tests that the queries get the limit of the first rule that
+// matches them, or the default limit.
+func TestResultLimitRules(t *testing.T) {
+	limits, err := NewResultLimits(config.ResultLimit{
+		MaxRows: 1000,
+		Rules: []config.ResultLimitRule{
+			{Name: "reporting", When: `request.user == "reporting"`, MaxBytes: 1 << 30},
+			{Name: "unlimited", When: `request.db == "analytics"`},
+		},
+	})
+	require.Nil(t, err)
+	proxy := &Proxy{ResultLimits: limits}
+	conn := NewConnWrapper(nil, nil, 0)
+	proxy.parameters.Store(conn, map[string]string{"user": "app", "database": "shop"})
+
+	rule := proxy.resultLimit(conn, PostgreSQLQuery("SELECT * FROM orders"))
+	require.NotNil(t, rule)
+	assert.Equal(t, config.DefaultResultLimitRule, rule.Name)
+	assert.Equal(t, 1000, rule.MaxRows)
+
+	proxy.parameters.Store(conn, map[string]string{"user": "reporting", "database": "shop"})
+	rule = proxy.resultLimit(conn, []byte{'E', 0, 0, 0, 9, 0, 0, 0, 0, 0})
+	require.NotNil(t, rule)
+	assert.Equal(t, "reporting", rule.Name)
+
+	proxy.parameters.Store(conn, map[string]string{"user": "app", "database": "analytics"})
+	assert.Nil(t, proxy.resultLimit(conn, PostgreSQLQuery("SELECT * FROM events")))
+
+	for _, invalid := range []config.ResultLimit{
+		{MaxRows: -1},
+		{Rules: []config.ResultLimitRule{{Name: "negative", When: "true", MaxBytes: -1}}},
+		{Rules: []config.ResultLimitRule{{Name: "invalid", When: "request.user =="}}},
+	} {
+		_, err := NewResultLimits(invalid)
+		assert.NotNil(t, err)
+	}
+}
+
+// TestProxyLimitResult tests that the query whose result set exceeds its limit is
+// cancelled, unless its response already ended.
+func TestProxyLimitResult(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	cancelRequests := make(chan []byte, 1)
+	go func() {
+		for {
+			server, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			message := make([]byte, 16)
+			if _, err := io.ReadFull(server, message); err == nil {
+				cancelRequests <- message
+			}
+			server.Close()
+		}
+	}()
+
+	limits, gErr := NewResultLimits(config.ResultLimit{MaxRows: 1})
+	require.Nil(t, gErr)
+	proxy := &Proxy{ResultLimits: limits, logger: zerolog.Nop()}
+	conn := NewConnWrapper(nil, nil, 0)
+	proxy.sessions.Store(conn, newSession())
+	client := &Client{Network: "tcp", Address: listener.Addr().String(), DialTimeout: time.Second}
+	client.backendKey.Store(&[2]uint32{42, 7})
+
+	row := resultMessage('D', "\x00\x01\x00\x00\x00\x011")
+	proxy.startResultLimit(conn, PostgreSQLQuery("SELECT * FROM orders"))
+	output := proxy.limitResult(conn, client, append(append([]byte{}, row...), row...), Correlation{})
+	assert.Equal(t, append(append([]byte{}, row...), resultError(&limits.Default, 2)...), output)
+	assert.Equal(t, PostgreSQLCancelRequest(42, 7), <-cancelRequests)
+
+	// The rest of the result set is dropped, up to the ReadyForQuery message.
+	canceled := append(PostgreSQLErrorResponse("ERROR", "57014", "canceling statement"),
+		PostgreSQLReadyForQuery()...)
+	assert.Equal(t, PostgreSQLReadyForQuery(),
+		proxy.limitResult(conn, client, append(append([]byte{}, row...), canceled...), Correlation{}))
+
+	// The query isn't cancelled if its response ended.
+	proxy.startResultLimit(conn, PostgreSQLQuery("SELECT * FROM orders"))
+	proxy.limitResult(conn, client,
+		append(append(append([]byte{}, row...), row...), PostgreSQLReadyForQuery()...), Correlation{})
+	select {
+	case <-cancelRequests:
+		t.Fatal("the query was cancelled after its response ended")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
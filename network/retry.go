@@ -3,8 +3,10 @@ package network
 import (
 	"errors"
 	"math"
+	"math/rand"
 	"time"
 
+	"github.com/gatewayd-io/gatewayd/config"
 	"github.com/rs/zerolog"
 )
 
@@ -25,6 +27,12 @@ type Retry struct {
 	Backoff            time.Duration
 	BackoffMultiplier  float64
 	DisableBackoffCaps bool
+	// MaxBackoff caps the backoff duration, unless the caps are disabled. Zero is
+	// BackoffDurationCap.
+	MaxBackoff time.Duration
+	// Jitter spreads each backoff duration randomly by up to its fraction of it, so
+	// that the clients that failed together don't retry together.
+	Jitter float64
 }
 
 var _ IRetry = (*Retry)(nil)
@@ -56,7 +64,8 @@ func (r *Retry) Retry(callback RetryCallback) (any, error) {
 		// is capped at 1 minute and the backoff multiplier is capped at 10, so the
 		// backoff duration will be 1 minute after 6 retries. The backoff multiplier
 		// is capped at 10 to prevent the backoff duration from growing too quickly,
-		// unless the backoff caps are disabled.
+		// unless the backoff caps are disabled. The cap of the backoff duration is
+		// the max backoff, if set. The jitter is applied after the cap.
 		// Example: 1 second * 2 ^ 1 = 2 seconds
 		//  		1 second * 2 ^ 2 = 4 seconds
 		//  		1 second * 2 ^ 3 = 8 seconds
@@ -71,8 +80,14 @@ func (r *Retry) Retry(callback RetryCallback) (any, error) {
 			math.Pow(r.BackoffMultiplier, float64(retry)),
 		)
 
-		if !r.DisableBackoffCaps && backoffDuration > BackoffDurationCap {
-			backoffDuration = BackoffDurationCap
+		backoffCap := config.If[time.Duration](r.MaxBackoff > 0, r.MaxBackoff, BackoffDurationCap)
+		if !r.DisableBackoffCaps && backoffDuration > backoffCap {
+			backoffDuration = backoffCap
+		}
+		if r.Jitter > 0 {
+			//nolint:gosec
+			backoffDuration = time.Duration(
+				float64(backoffDuration) * (1 + r.Jitter*(2*rand.Float64()-1)))
 		}
 
 		if retry > 0 {
@@ -125,3 +140,22 @@ func NewRetry(
 
 	return &retry
 }
+
+// NewClientRetry creates the retry of the connections of the client config to the
+// server, with the default backoff if it has none.
+func NewClientRetry(clientConfig *config.Client, logger zerolog.Logger) *Retry {
+	retry := NewRetry(
+		clientConfig.Retries,
+		config.If[time.Duration](
+			clientConfig.Backoff > 0,
+			clientConfig.Backoff,
+			config.DefaultBackoff,
+		),
+		clientConfig.BackoffMultiplier,
+		clientConfig.DisableBackoffCaps,
+		logger,
+	)
+	retry.MaxBackoff = clientConfig.MaxBackoff
+	retry.Jitter = min(max(clientConfig.BackoffJitter, 0), 1)
+	return retry
+}
@@ -2,6 +2,7 @@ package network
 
 import (
 	"context"
+	"errors"
 	"net"
 	"testing"
 	"time"
@@ -75,3 +76,31 @@ func TestRetry(t *testing.T) {
 		})
 	})
 }
+
+// TestRetryBackoff tests that the backoff durations are capped at the max backoff, and
+// spread by the jitter.
+func TestRetryBackoff(t *testing.T) {
+	retry := NewClientRetry(&config.Client{
+		Retries:           2,
+		Backoff:           10 * time.Millisecond,
+		BackoffMultiplier: 10,
+		MaxBackoff:        20 * time.Millisecond,
+		BackoffJitter:     1.5,
+	}, zerolog.Nop())
+	assert.Equal(t, 20*time.Millisecond, retry.MaxBackoff)
+	assert.Equal(t, 1.0, retry.Jitter)
+	retry.Jitter = 0.5
+
+	attempts := 0
+	start := time.Now()
+	_, err := retry.Retry(func() (any, error) {
+		attempts++
+		return nil, errors.New("connection refused")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 3, attempts)
+	// The backoff durations are 10ms, 20ms and 20ms, capped, give or take the jitter,
+	// instead of 10ms, 100ms and 1s.
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(start), 25*time.Millisecond)
+}
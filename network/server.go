@@ -7,15 +7,19 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"runtime"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
 	"github.com/gatewayd-io/gatewayd/config"
 	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/events"
 	"github.com/gatewayd-io/gatewayd/metrics"
 	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/sdk"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -33,6 +37,9 @@ const (
 	Shutdown
 )
 
+// drainPollInterval is how often Drain checks whether the connections are closed.
+const drainPollInterval = 100 * time.Millisecond
+
 type IServer interface {
 	OnBoot(engine Engine) Action
 	OnOpen(conn *ConnWrapper) ([]byte, Action)
@@ -60,11 +67,51 @@ type Server struct {
 	Status       config.Status
 	TickInterval time.Duration
 
+	// EngineMode is how the connections are served: with a goroutine per connection
+	// and direction, or with a bounded set of event loop workers.
+	EngineMode       config.EngineMode
+	EventLoopWorkers int
+	eventLoop        *EventLoop
+
 	// TLS config
 	EnableTLS        bool
 	CertFile         string
 	KeyFile          string
 	HandshakeTimeout time.Duration
+	// WatchCertFiles reloads the cert and key files when they change.
+	WatchCertFiles bool
+	// ACME gets the certificate from an ACME certificate authority instead of the files.
+	ACME config.ACME
+	// SPIFFE gets the certificate from the SPIFFE Workload API instead of the files,
+	// and requires the clients to present their SVIDs (mTLS).
+	SPIFFE config.ServerSPIFFE
+
+	// Maintenance rejects or queues the new connections while it is enabled.
+	// It is disabled if nil.
+	Maintenance *Maintenance
+
+	// DualStack listens on IPv6 and IPv4 when the address is the IPv4 wildcard.
+	DualStack bool
+	// Listeners are the additional networks and addresses the server listens on,
+	// sharing the proxy, pool and hooks of the server.
+	Listeners []config.Listener
+
+	// DrainTimeout is how long Drain waits for the open connections to close.
+	DrainTimeout time.Duration
+	draining     atomic.Bool
+
+	// Canary measures the latency of the full path through the server with a client
+	// connection on the interval. It is disabled if nil.
+	Canary *Canary
+
+	// MaxGoroutines is the maximum number of goroutines the server runs for its
+	// connections. The new connections are rejected beyond it. Zero disables the limit.
+	MaxGoroutines int
+	goroutines    atomic.Int64
+
+	// Sniffer detects the protocol of the connections, and forwards the ones that aren't
+	// PostgreSQL. It is disabled if nil.
+	Sniffer *Sniffer
 }
 
 var _ IServer = (*Server)(nil)
@@ -81,9 +128,9 @@ func (s *Server) OnBoot(engine Engine) Action {
 	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), s.pluginTimeout)
 	defer cancel()
 	// Run the OnBooting hooks.
-	_, err := s.pluginRegistry.Run(
+	_, err := s.pluginRegistry.RunTyped(
 		pluginTimeoutCtx,
-		map[string]interface{}{"status": fmt.Sprint(s.Status)},
+		&sdk.StatusMessage{Status: fmt.Sprint(s.Status)},
 		v1.HookName_HOOK_NAME_ON_BOOTING)
 	if err != nil {
 		s.logger.Error().Err(err).Msg("Failed to run OnBooting hook")
@@ -102,9 +149,9 @@ func (s *Server) OnBoot(engine Engine) Action {
 	pluginTimeoutCtx, cancel = context.WithTimeout(context.Background(), s.pluginTimeout)
 	defer cancel()
 
-	_, err = s.pluginRegistry.Run(
+	_, err = s.pluginRegistry.RunTyped(
 		pluginTimeoutCtx,
-		map[string]interface{}{"status": fmt.Sprint(s.Status)},
+		&sdk.StatusMessage{Status: fmt.Sprint(s.Status)},
 		v1.HookName_HOOK_NAME_ON_BOOTED)
 	if err != nil {
 		s.logger.Error().Err(err).Msg("Failed to run OnBooted hook")
@@ -113,6 +160,10 @@ func (s *Server) OnBoot(engine Engine) Action {
 	span.AddEvent("Ran the OnBooted hooks")
 
 	s.logger.Debug().Msg("GatewayD booted")
+	events.Publish(events.ServerStarted, "GatewayD server started", map[string]interface{}{
+		"network": s.Network,
+		"address": s.Address,
+	})
 
 	return None
 }
@@ -123,22 +174,50 @@ func (s *Server) OnOpen(conn *ConnWrapper) ([]byte, Action) {
 	_, span := otel.Tracer("gatewayd").Start(s.ctx, "OnOpen")
 	defer span.End()
 
-	s.logger.Debug().Str("from", RemoteAddr(conn.Conn())).Msg(
+	correlation := Correlation{ConnectionID: conn.ID()}
+	logger := correlation.Logger(s.logger)
+	span.SetAttributes(correlation.Attributes()...)
+
+	logger.Debug().Str("from", RemoteAddr(conn.Conn())).Msg(
 		"GatewayD is opening a connection")
 
+	// While draining, the new connections are rejected, so that the clients retry
+	// on another instance.
+	if s.draining.Load() {
+		span.AddEvent("Rejected the connection while draining")
+		return s.errorResponse(config.ErrorShuttingDown, ""), Close
+	}
+
+	// Reject the new connections while the server runs too many goroutines, rather than
+	// running out of memory.
+	if s.exceedsGoroutines() {
+		metrics.ServerGoroutineRejections.Inc()
+		logger.Warn().Int("maxGoroutines", s.MaxGoroutines).Msg(
+			"Rejected the connection, as the server runs too many goroutines")
+		span.RecordError(gerr.ErrGoroutineLimitExceeded)
+		return s.errorResponse(config.ErrorGoroutineLimit, ""), Close
+	}
+
+	// During maintenance, the new connections wait for it to end or are rejected.
+	if s.Maintenance != nil {
+		if admitted, message := s.Maintenance.Admit(); !admitted {
+			logger.Debug().Msg("Rejected the connection during maintenance")
+			span.AddEvent("Rejected the connection during maintenance")
+			return s.errorResponse(config.ErrorMaintenance, message), Close
+		}
+	}
+
 	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), s.pluginTimeout)
 	defer cancel()
 	// Run the OnOpening hooks.
-	onOpeningData := map[string]interface{}{
-		"client": map[string]interface{}{
-			"local":  LocalAddr(conn.Conn()),
-			"remote": RemoteAddr(conn.Conn()),
-		},
+	onOpeningData := &sdk.ConnectionMessage{
+		Client:       sdk.Address{Local: LocalAddr(conn.Conn()), Remote: RemoteAddr(conn.Conn())},
+		ConnectionID: conn.ID(),
 	}
-	_, err := s.pluginRegistry.Run(
+	_, err := s.pluginRegistry.RunTyped(
 		pluginTimeoutCtx, onOpeningData, v1.HookName_HOOK_NAME_ON_OPENING)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("Failed to run OnOpening hook")
+		logger.Error().Err(err).Msg("Failed to run OnOpening hook")
 		span.RecordError(err)
 	}
 	span.AddEvent("Ran the OnOpening hooks")
@@ -147,14 +226,29 @@ func (s *Server) OnOpen(conn *ConnWrapper) ([]byte, Action) {
 	// This effectively get a connection from the pool and puts both the incoming and the server
 	// connections in the pool of the busy connections.
 	if err := s.proxy.Connect(conn); err != nil {
-		if errors.Is(err, gerr.ErrPoolExhausted) {
+		// The connection didn't get a server connection, either right away or after
+		// waiting in the queue, so tell the client why it is being disconnected.
+		switch {
+		case errors.Is(err, gerr.ErrPoolExhausted):
+			span.RecordError(err)
+			return s.errorResponse(config.ErrorPoolExhausted, ""), Close
+		case errors.Is(err, gerr.ErrPoolQueueFull):
 			span.RecordError(err)
-			return nil, Close
+			return s.errorResponse(config.ErrorQueueFull, ""), Close
+		case errors.Is(err, gerr.ErrPoolQueueTimeout):
+			span.RecordError(err)
+			return s.errorResponse(config.ErrorQueueTimeout, ""), Close
+		case errors.Is(err, gerr.ErrConnectionQuotaExceeded):
+			span.RecordError(err)
+			return s.errorResponse(config.ErrorConnectionQuota, ""), Close
+		case errors.Is(err, gerr.ErrBackendDegraded):
+			span.RecordError(err)
+			return s.errorResponse(config.ErrorBackendDown, ""), Close
 		}
 
 		// This should never happen.
 		// TODO: Send error to client or retry connection
-		s.logger.Error().Err(err).Msg("Failed to connect to proxy")
+		logger.Error().Err(err).Msg("Failed to connect to proxy")
 		span.RecordError(err)
 		return nil, None
 	}
@@ -163,21 +257,20 @@ func (s *Server) OnOpen(conn *ConnWrapper) ([]byte, Action) {
 	pluginTimeoutCtx, cancel = context.WithTimeout(context.Background(), s.pluginTimeout)
 	defer cancel()
 
-	onOpenedData := map[string]interface{}{
-		"client": map[string]interface{}{
-			"local":  LocalAddr(conn.Conn()),
-			"remote": RemoteAddr(conn.Conn()),
-		},
+	onOpenedData := &sdk.ConnectionMessage{
+		Client:       sdk.Address{Local: LocalAddr(conn.Conn()), Remote: RemoteAddr(conn.Conn())},
+		ConnectionID: conn.ID(),
 	}
-	_, err = s.pluginRegistry.Run(
+	_, err = s.pluginRegistry.RunTyped(
 		pluginTimeoutCtx, onOpenedData, v1.HookName_HOOK_NAME_ON_OPENED)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("Failed to run OnOpened hook")
+		logger.Error().Err(err).Msg("Failed to run OnOpened hook")
 		span.RecordError(err)
 	}
 	span.AddEvent("Ran the OnOpened hooks")
 
 	metrics.ClientConnections.Inc()
+	metrics.ListenerConnections.WithLabelValues(conn.Listener()).Inc()
 
 	return nil, None
 }
@@ -189,27 +282,28 @@ func (s *Server) OnClose(conn *ConnWrapper, err error) Action {
 	_, span := otel.Tracer("gatewayd").Start(s.ctx, "OnClose")
 	defer span.End()
 
-	s.logger.Debug().Str("from", RemoteAddr(conn.Conn())).Msg(
+	correlation := Correlation{ConnectionID: conn.ID()}
+	logger := correlation.Logger(s.logger)
+	span.SetAttributes(correlation.Attributes()...)
+
+	logger.Debug().Str("from", RemoteAddr(conn.Conn())).Msg(
 		"GatewayD is closing a connection")
 
 	// Run the OnClosing hooks.
 	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), s.pluginTimeout)
 	defer cancel()
 
-	data := map[string]interface{}{
-		"client": map[string]interface{}{
-			"local":  LocalAddr(conn.Conn()),
-			"remote": RemoteAddr(conn.Conn()),
-		},
-		"error": "",
+	message := &sdk.ConnectionMessage{
+		Client:       sdk.Address{Local: LocalAddr(conn.Conn()), Remote: RemoteAddr(conn.Conn())},
+		ConnectionID: conn.ID(),
 	}
 	if err != nil {
-		data["error"] = err.Error()
+		message.Error = err.Error()
 	}
-	_, gatewaydErr := s.pluginRegistry.Run(
-		pluginTimeoutCtx, data, v1.HookName_HOOK_NAME_ON_CLOSING)
+	_, gatewaydErr := s.pluginRegistry.RunTyped(
+		pluginTimeoutCtx, message, v1.HookName_HOOK_NAME_ON_CLOSING)
 	if gatewaydErr != nil {
-		s.logger.Error().Err(gatewaydErr).Msg("Failed to run OnClosing hook")
+		logger.Error().Err(gatewaydErr).Msg("Failed to run OnClosing hook")
 		span.RecordError(gatewaydErr)
 	}
 	span.AddEvent("Ran the OnClosing hooks")
@@ -228,7 +322,7 @@ func (s *Server) OnClose(conn *ConnWrapper, err error) Action {
 	// the incoming and the server connections in the pool of the busy connections and either
 	// recycles or disconnects the connections.
 	if err := s.proxy.Disconnect(conn); err != nil {
-		s.logger.Error().Err(err).Msg("Failed to disconnect the server connection")
+		logger.Error().Err(err).Msg("Failed to disconnect the server connection")
 		span.RecordError(err)
 		return Close
 	}
@@ -239,7 +333,7 @@ func (s *Server) OnClose(conn *ConnWrapper, err error) Action {
 
 	// Close the incoming connection.
 	if err := conn.Close(); err != nil {
-		s.logger.Error().Err(err).Msg("Failed to close the incoming connection")
+		logger.Error().Err(err).Msg("Failed to close the incoming connection")
 		span.RecordError(err)
 		return Close
 	}
@@ -248,25 +342,23 @@ func (s *Server) OnClose(conn *ConnWrapper, err error) Action {
 	pluginTimeoutCtx, cancel = context.WithTimeout(context.Background(), s.pluginTimeout)
 	defer cancel()
 
-	data = map[string]interface{}{
-		"client": map[string]interface{}{
-			"local":  LocalAddr(conn.Conn()),
-			"remote": RemoteAddr(conn.Conn()),
-		},
-		"error": "",
+	message = &sdk.ConnectionMessage{
+		Client:       sdk.Address{Local: LocalAddr(conn.Conn()), Remote: RemoteAddr(conn.Conn())},
+		ConnectionID: conn.ID(),
 	}
 	if err != nil {
-		data["error"] = err.Error()
+		message.Error = err.Error()
 	}
-	_, gatewaydErr = s.pluginRegistry.Run(
-		pluginTimeoutCtx, data, v1.HookName_HOOK_NAME_ON_CLOSED)
+	_, gatewaydErr = s.pluginRegistry.RunTyped(
+		pluginTimeoutCtx, message, v1.HookName_HOOK_NAME_ON_CLOSED)
 	if gatewaydErr != nil {
-		s.logger.Error().Err(gatewaydErr).Msg("Failed to run OnClosed hook")
+		logger.Error().Err(gatewaydErr).Msg("Failed to run OnClosed hook")
 		span.RecordError(gatewaydErr)
 	}
 	span.AddEvent("Ran the OnClosed hooks")
 
 	metrics.ClientConnections.Dec()
+	metrics.ListenerConnections.WithLabelValues(conn.Listener()).Dec()
 
 	return Close
 }
@@ -277,60 +369,105 @@ func (s *Server) OnTraffic(conn *ConnWrapper, stopConnection chan struct{}) Acti
 	_, span := otel.Tracer("gatewayd").Start(s.ctx, "OnTraffic")
 	defer span.End()
 
-	// Run the OnTraffic hooks.
-	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), s.pluginTimeout)
-	defer cancel()
+	correlation := Correlation{ConnectionID: conn.ID()}
+	logger := correlation.Logger(s.logger)
+	span.SetAttributes(correlation.Attributes()...)
 
-	onTrafficData := map[string]interface{}{
-		"client": map[string]interface{}{
-			"local":  LocalAddr(conn.Conn()),
-			"remote": RemoteAddr(conn.Conn()),
-		},
-	}
-	_, err := s.pluginRegistry.Run(
-		pluginTimeoutCtx, onTrafficData, v1.HookName_HOOK_NAME_ON_TRAFFIC)
-	if err != nil {
-		s.logger.Error().Err(err).Msg("Failed to run OnTraffic hook")
-		span.RecordError(err)
-	}
+	s.runOnTrafficHooks(conn)
 	span.AddEvent("Ran the OnTraffic hooks")
 
+	// Take the fast path if nothing needs to see the traffic.
+	if s.proxy.CanSplice(conn) {
+		if err := recoverPanic(logger, func() *gerr.GatewayDError {
+			return s.proxy.Splice(conn)
+		}); err != nil {
+			logger.Trace().Err(err).Msg("Failed to splice traffic")
+			span.RecordError(err)
+		}
+		return Close
+	}
+
 	stack := NewStack()
+	// stopped is closed once the connection is stopped, so that the direction that
+	// fails last doesn't wait forever to stop it.
+	stopped := make(chan struct{})
 
 	// Pass the traffic from the client to server.
 	// If there is an error, log it and close the connection.
-	go func(server *Server, conn *ConnWrapper, stopConnection chan struct{}, stack *Stack) {
+	s.spawn(func() {
 		for {
-			server.logger.Trace().Msg("Passing through traffic from client to server")
-			if err := server.proxy.PassThroughToServer(conn, stack); err != nil {
-				server.logger.Trace().Err(err).Msg("Failed to pass through traffic")
+			s.logger.Trace().Msg("Passing through traffic from client to server")
+			if err := recoverPanic(logger, func() *gerr.GatewayDError {
+				return s.proxy.PassThroughToServer(conn, stack)
+			}); err != nil {
+				s.logger.Trace().Err(err).Msg("Failed to pass through traffic")
 				span.RecordError(err)
-				stopConnection <- struct{}{}
+				select {
+				case stopConnection <- struct{}{}:
+				case <-stopped:
+				}
 				break
 			}
 		}
-	}(s, conn, stopConnection, stack)
+	})
 
 	// Pass the traffic from the server to client.
 	// If there is an error, log it and close the connection.
-	go func(server *Server, conn *ConnWrapper, stopConnection chan struct{}, stack *Stack) {
+	s.spawn(func() {
 		for {
-			server.logger.Trace().Msg("Passing through traffic from server to client")
-			if err := server.proxy.PassThroughToClient(conn, stack); err != nil {
-				server.logger.Trace().Err(err).Msg("Failed to pass through traffic")
+			s.logger.Trace().Msg("Passing through traffic from server to client")
+			if err := recoverPanic(logger, func() *gerr.GatewayDError {
+				return s.proxy.PassThroughToClient(conn, stack)
+			}); err != nil {
+				s.logger.Trace().Err(err).Msg("Failed to pass through traffic")
 				span.RecordError(err)
-				stopConnection <- struct{}{}
+				select {
+				case stopConnection <- struct{}{}:
+				case <-stopped:
+				}
 				break
 			}
 		}
-	}(s, conn, stopConnection, stack)
+	})
 
 	<-stopConnection
+	close(stopped)
 	stack.Clear()
 
 	return Close
 }
 
+// errorResponse returns the ErrorResponse message of the error, with the code and
+// message configured in the proxy, if any.
+func (s *Server) errorResponse(kind config.ErrorKind, message string) []byte {
+	var messages map[config.ErrorKind]config.ErrorMessage
+	if proxy, ok := s.proxy.(*Proxy); ok {
+		messages = proxy.ErrorMessages
+	}
+	return ErrorResponse(messages, kind, message)
+}
+
+// runOnTrafficHooks runs the OnTraffic hooks once the connection starts passing traffic.
+func (s *Server) runOnTrafficHooks(conn *ConnWrapper) {
+	_, span := otel.Tracer("gatewayd").Start(s.ctx, "OnTraffic hooks")
+	defer span.End()
+
+	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), s.pluginTimeout)
+	defer cancel()
+
+	onTrafficData := &sdk.TrafficMessage{
+		Client:       sdk.Address{Local: LocalAddr(conn.Conn()), Remote: RemoteAddr(conn.Conn())},
+		ConnectionID: conn.ID(),
+	}
+	_, err := s.pluginRegistry.RunTyped(
+		pluginTimeoutCtx, onTrafficData, v1.HookName_HOOK_NAME_ON_TRAFFIC)
+	if err != nil {
+		logger := Correlation{ConnectionID: conn.ID()}.Logger(s.logger)
+		logger.Error().Err(err).Msg("Failed to run OnTraffic hook")
+		span.RecordError(err)
+	}
+}
+
 // OnShutdown is called when the server is shutting down. It calls the OnShutdown hooks.
 func (s *Server) OnShutdown() {
 	_, span := otel.Tracer("gatewayd").Start(s.ctx, "OnShutdown")
@@ -341,9 +478,9 @@ func (s *Server) OnShutdown() {
 	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), s.pluginTimeout)
 	defer cancel()
 	// Run the OnShutdown hooks.
-	_, err := s.pluginRegistry.Run(
+	_, err := s.pluginRegistry.RunTyped(
 		pluginTimeoutCtx,
-		map[string]interface{}{"connections": s.engine.CountConnections()},
+		&sdk.ConnectionsMessage{Connections: s.engine.CountConnections()},
 		v1.HookName_HOOK_NAME_ON_SHUTDOWN)
 	if err != nil {
 		s.logger.Error().Err(err).Msg("Failed to run OnShutdown hook")
@@ -372,9 +509,9 @@ func (s *Server) OnTick() (time.Duration, Action) {
 	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), s.pluginTimeout)
 	defer cancel()
 	// Run the OnTick hooks.
-	_, err := s.pluginRegistry.Run(
+	_, err := s.pluginRegistry.RunTyped(
 		pluginTimeoutCtx,
-		map[string]interface{}{"connections": s.engine.CountConnections()},
+		&sdk.ConnectionsMessage{Connections: s.engine.CountConnections()},
 		v1.HookName_HOOK_NAME_ON_TICK)
 	if err != nil {
 		s.logger.Error().Err(err).Msg("Failed to run OnTick hook")
@@ -409,12 +546,12 @@ func (s *Server) Run() *gerr.GatewayDError {
 	defer cancel()
 	// Run the OnRun hooks.
 	// Since Run is blocking, we need to run OnRun before it.
-	onRunData := map[string]interface{}{"address": addr}
+	onRunMessage := &sdk.RunMessage{Address: addr}
 	if err != nil && err.Unwrap() != nil {
-		onRunData["error"] = err.OriginalError.Error()
+		onRunMessage.Error = err.OriginalError.Error()
 	}
-	result, err := s.pluginRegistry.Run(
-		pluginTimeoutCtx, onRunData, v1.HookName_HOOK_NAME_ON_RUN)
+	result, err := s.pluginRegistry.RunTyped(
+		pluginTimeoutCtx, onRunMessage, v1.HookName_HOOK_NAME_ON_RUN)
 	if err != nil {
 		s.logger.Error().Err(err).Msg("Failed to run the hook")
 		span.RecordError(err)
@@ -422,12 +559,12 @@ func (s *Server) Run() *gerr.GatewayDError {
 	span.AddEvent("Ran the OnRun hooks")
 
 	if result != nil {
-		if errMsg, ok := result["error"].(string); ok && errMsg != "" {
+		if errMsg := sdk.Error(result); errMsg != "" {
 			s.logger.Error().Str("error", errMsg).Msg("Error in hook")
 		}
 
-		if address, ok := result["address"].(string); ok {
-			addr = address
+		if address, ok := result.GetFields()[sdk.FieldAddress]; ok {
+			addr = address.GetStringValue()
 		}
 	}
 
@@ -435,23 +572,22 @@ func (s *Server) Run() *gerr.GatewayDError {
 		return nil
 	}
 
-	listener, origErr := net.Listen(s.Network, addr)
-	if origErr != nil {
-		s.logger.Error().Err(origErr).Msg("Server failed to start listening")
-		return gerr.ErrServerListenFailed.Wrap(origErr)
+	listeners, listenErr := s.listen(addr)
+	if listenErr != nil {
+		return listenErr
 	}
 	s.mu.Lock()
-	s.engine.listener = listener
+	s.engine.listeners = listeners
 	s.mu.Unlock()
-	defer s.engine.listener.Close()
-
-	if s.engine.listener == nil {
-		s.logger.Error().Msg("Server is not properly initialized")
-		return nil
-	}
+	defer func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}()
 
 	var port string
-	s.engine.host, port, origErr = net.SplitHostPort(s.engine.listener.Addr().String())
+	var origErr error
+	s.engine.host, port, origErr = net.SplitHostPort(listeners[0].Addr().String())
 	if origErr != nil {
 		s.logger.Error().Err(origErr).Msg("Failed to split host and port")
 		return gerr.ErrSplitHostPortFailed.Wrap(origErr)
@@ -492,26 +628,133 @@ func (s *Server) Run() *gerr.GatewayDError {
 	}(s)
 
 	s.engine.running.Store(true)
+	close(s.engine.listening)
+
+	if s.Canary != nil {
+		if s.Canary.Address == "" && listeners[0].Addr().Network() != "tcp" {
+			s.logger.Warn().Msg("The canary needs an address, as the server doesn't listen on TCP")
+		} else {
+			go s.Canary.Start(s.ctx, listeners[0].Addr(), s.engine.stopServer)
+		}
+	}
 
 	var tlsConfig *tls.Config
 	if s.EnableTLS {
-		tlsConfig, origErr = CreateTLSConfig(s.CertFile, s.KeyFile)
-		if origErr != nil {
-			s.logger.Error().Err(origErr).Msg("Failed to create TLS config")
-			return gerr.ErrGetTLSConfigFailed.Wrap(origErr)
+		switch {
+		case s.SPIFFE.Enabled:
+			tlsConfig = GetX509Source(s.SPIFFE.SocketPath, s.logger).ServerTLSConfig(
+				s.SPIFFE.AuthorizedIDs)
+			s.logger.Info().Msg("Getting the TLS certificates from the SPIFFE Workload API")
+		case s.ACME.Enabled:
+			certificates, err := NewACMECertificates(s.ACME, s.logger)
+			if err != nil {
+				s.logger.Error().Err(err).Msg("Failed to set up the ACME certificates")
+				return gerr.ErrGetTLSConfigFailed.Wrap(err)
+			}
+			defer certificates.Close()
+			tlsConfig = CreateTLSConfigWithCertificates(certificates.GetCertificate)
+			s.logger.Info().Strs("domains", s.ACME.Domains).Msg(
+				"Getting the TLS certificates with ACME")
+		case s.WatchCertFiles:
+			reloader, err := NewCertificateReloader(s.CertFile, s.KeyFile, s.logger)
+			if err != nil {
+				s.logger.Error().Err(err).Msg("Failed to create TLS config")
+				return gerr.ErrGetTLSConfigFailed.Wrap(err)
+			}
+			defer reloader.Close()
+			tlsConfig = CreateTLSConfigWithCertificates(reloader.GetCertificate)
+			s.logger.Info().Msg("Watching the TLS certificate files for changes")
+		default:
+			tlsConfig, origErr = CreateTLSConfig(s.CertFile, s.KeyFile)
+			if origErr != nil {
+				s.logger.Error().Err(origErr).Msg("Failed to create TLS config")
+				return gerr.ErrGetTLSConfigFailed.Wrap(origErr)
+			}
 		}
 		s.logger.Info().Msg("TLS is enabled")
 	} else {
 		s.logger.Debug().Msg("TLS is disabled")
 	}
 
+	if s.EngineMode == config.EventLoop {
+		eventLoop, err := NewEventLoop(s, config.If[int](
+			s.EventLoopWorkers > 0, s.EventLoopWorkers, runtime.NumCPU()))
+		if err != nil {
+			s.logger.Warn().Err(err).Msg(
+				"Failed to start the event loop, serving connections with goroutines instead")
+		} else {
+			s.eventLoop = eventLoop
+			s.eventLoop.Start()
+			defer s.eventLoop.Stop()
+		}
+	}
+
+	// Every listener has its own accept loop, and the first one that stops
+	// stops the server.
+	errs := make(chan *gerr.GatewayDError, len(listeners))
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			errs <- s.acceptConnections(listener, tlsConfig)
+		}(listener)
+	}
+	return <-errs
+}
+
+// listen listens on the address of the server and on the additional listeners.
+// If any of them fails, the ones that are already listening are closed.
+func (s *Server) listen(address string) ([]net.Listener, *gerr.GatewayDError) {
+	addresses := append(
+		[]config.Listener{{Network: s.Network, Address: address}}, s.Listeners...)
+
+	listeners := make([]net.Listener, 0, len(addresses))
+	for _, addr := range addresses {
+		listener, err := s.listenOn(addr.Network, addr.Address)
+		if err != nil {
+			s.logger.Error().Err(err).Str("network", addr.Network).Str(
+				"address", addr.Address).Msg("Server failed to start listening")
+			for _, listener := range listeners {
+				listener.Close()
+			}
+			return nil, gerr.ErrServerListenFailed.Wrap(err)
+		}
+		if len(listeners) > 0 {
+			s.logger.Info().Str("network", addr.Network).Str(
+				"address", listener.Addr().String()).Msg("GatewayD is listening")
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// listenOn listens on the address. If dual-stack is enabled and the address is the
+// IPv4 wildcard, it listens on the IPv6 wildcard instead, which accepts both IPv6
+// and IPv4 connections, and falls back to IPv4 if IPv6 isn't available.
+func (s *Server) listenOn(network, address string) (net.Listener, error) {
+	host, port, err := net.SplitHostPort(address)
+	if !s.DualStack || network != "tcp" || err != nil || host != "0.0.0.0" {
+		return net.Listen(network, address) //nolint:wrapcheck
+	}
+
+	listener, err := net.Listen(network, net.JoinHostPort("::", port))
+	if err == nil {
+		return listener, nil
+	}
+	s.logger.Warn().Err(err).Str("address", address).Msg(
+		"Failed to listen on IPv6, listening on IPv4 only")
+	return net.Listen(network, address) //nolint:wrapcheck
+}
+
+// acceptConnections accepts the connections of the listener and serves them
+// until the server is stopped.
+func (s *Server) acceptConnections(listener net.Listener, tlsConfig *tls.Config) *gerr.GatewayDError {
 	for {
 		select {
 		case <-s.engine.stopServer:
 			s.logger.Info().Msg("Server stopped")
 			return nil
 		default:
-			netConn, err := s.engine.listener.Accept()
+			netConn, err := listener.Accept()
 			if err != nil {
 				if !s.engine.running.Load() {
 					return nil
@@ -520,45 +763,70 @@ func (s *Server) Run() *gerr.GatewayDError {
 				return gerr.ErrAcceptFailed.Wrap(err)
 			}
 
-			conn := NewConnWrapper(netConn, tlsConfig, s.HandshakeTimeout)
-
-			if out, action := s.OnOpen(conn); action != None {
-				if _, err := conn.Write(out); err != nil {
-					s.logger.Error().Err(err).Msg("Failed to write to connection")
-				}
-				conn.Close()
-				if action == Shutdown {
-					s.OnShutdown()
-					return nil
+			metrics.ClientConnectionsAccepted.WithLabelValues(
+				AddressFamily(netConn.RemoteAddr())).Inc()
+			metrics.ListenerConnectionsAccepted.WithLabelValues(listener.Addr().String()).Inc()
+
+			// Detecting the protocol and opening the connection might wait, e.g. for an
+			// available connection in the pool, so it shouldn't block accepting new connections.
+			server := s
+			s.spawn(func() {
+				// Only the PostgreSQL connections are served, and the others are forwarded.
+				if server.Sniffer != nil {
+					if netConn = server.sniff(netConn); netConn == nil {
+						return
+					}
 				}
-			}
-			s.engine.mu.Lock()
-			s.engine.connections++
-			s.engine.mu.Unlock()
-
-			// For every new connection, a new unbuffered channel is created to help
-			// stop the proxy, recycle the server connection and close stale connections.
-			stopConnection := make(chan struct{})
-			go func(server *Server, conn *ConnWrapper, stopConnection chan struct{}) {
-				if action := server.OnTraffic(conn, stopConnection); action == Close {
-					stopConnection <- struct{}{}
+
+				conn := NewConnWrapper(netConn, tlsConfig, server.HandshakeTimeout)
+				conn.listener = listener.Addr().String()
+				if out, action := server.OnOpen(conn); action != None {
+					if len(out) > 0 {
+						if _, err := conn.Write(out); err != nil {
+							server.logger.Error().Err(err).Msg("Failed to write to connection")
+						}
+					}
+					conn.Close()
+					return
 				}
-			}(s, conn, stopConnection)
-
-			go func(server *Server, conn *ConnWrapper, stopConnection chan struct{}) {
-				for {
-					select {
-					case <-stopConnection:
-						server.engine.mu.Lock()
-						server.engine.connections--
-						server.engine.mu.Unlock()
-						server.OnClose(conn, err)
-						return
-					case <-server.engine.stopServer:
+				server.engine.mu.Lock()
+				server.engine.connections++
+				server.engine.mu.Unlock()
+
+				// Idle connections are served by the event loop workers, if enabled.
+				if server.eventLoop != nil {
+					err := server.eventLoop.Register(conn)
+					if err == nil {
 						return
 					}
+					server.logger.Debug().Err(err).Msg(
+						"Serving the connection with goroutines instead of the event loop")
 				}
-			}(s, conn, stopConnection)
+
+				// For every new connection, a new unbuffered channel is created to help
+				// stop the proxy, recycle the server connection and close stale connections.
+				stopConnection := make(chan struct{})
+				server.spawn(func() {
+					if action := server.OnTraffic(conn, stopConnection); action == Close {
+						stopConnection <- struct{}{}
+					}
+				})
+
+				server.spawn(func() {
+					for {
+						select {
+						case <-stopConnection:
+							server.engine.mu.Lock()
+							server.engine.connections--
+							server.engine.mu.Unlock()
+							server.OnClose(conn, nil)
+							return
+						case <-server.engine.stopServer:
+							return
+						}
+					}
+				})
+			})
 		}
 	}
 }
@@ -583,6 +851,51 @@ func (s *Server) Shutdown() {
 	}
 }
 
+// Drain stops accepting new connections and waits for the open ones to close, for
+// up to the drain timeout. It returns the number of connections still open.
+func (s *Server) Drain() int {
+	_, span := otel.Tracer("gatewayd").Start(s.ctx, "Drain")
+	defer span.End()
+
+	s.draining.Store(true)
+
+	deadline := time.Now().Add(s.DrainTimeout)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for s.engine.CountConnections() > 0 && time.Now().Before(deadline) {
+		<-ticker.C
+	}
+
+	remaining := s.engine.CountConnections()
+	span.SetAttributes(attribute.Int("remaining", remaining))
+	return remaining
+}
+
+// IsDraining returns true if the server is draining, so it isn't ready for new
+// connections.
+func (s *Server) IsDraining() bool {
+	return s.draining.Load()
+}
+
+// Listening returns a channel that is closed once the server listens on all its
+// listeners.
+func (s *Server) Listening() <-chan struct{} {
+	return s.engine.listening
+}
+
+// ListenerAddresses returns the addresses the server is bound to, the first one being
+// the address of the server. It is empty if the server isn't listening yet.
+func (s *Server) ListenerAddresses() []net.Addr {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	addresses := make([]net.Addr, 0, len(s.engine.listeners))
+	for _, listener := range s.engine.listeners {
+		addresses = append(addresses, listener.Addr())
+	}
+	return addresses
+}
+
 // IsRunning returns true if the server is running.
 func (s *Server) IsRunning() bool {
 	_, span := otel.Tracer("gatewayd").Start(s.ctx, "IsRunning")
@@ -650,3 +963,34 @@ func NewServer(
 
 	return &server
 }
+
+// Configure applies the settings of the server config that NewServer doesn't take,
+// filling the zero values with the default ones.
+func (s *Server) Configure(cfg *config.Server) {
+	s.EngineMode = config.If[config.EngineMode](
+		cfg.EngineMode != "",
+		config.EngineMode(cfg.EngineMode),
+		config.DefaultEngineMode,
+	)
+	s.EventLoopWorkers = cfg.EventLoopWorkers
+	s.WatchCertFiles = cfg.WatchCertFiles
+	s.ACME = cfg.ACME
+	s.SPIFFE = cfg.SPIFFE
+	s.DrainTimeout = cfg.DrainTimeout
+	s.Listeners = cfg.Listeners
+	s.DualStack = cfg.DualStack
+	s.Canary = NewCanary(cfg.Canary, s.logger)
+	s.MaxGoroutines = cfg.MaxGoroutines
+	s.Sniffer = NewSniffer(cfg.Sniffing)
+
+	// The compressed data is buffered, which the event loop can't see.
+	if proxy, ok := s.proxy.(*Proxy); ok && s.EngineMode == config.EventLoop &&
+		len(proxy.CompressionAlgorithms) > 0 {
+		s.logger.Warn().Msg("Compression is not supported by the event loop engine, so it is disabled")
+		proxy.CompressionAlgorithms = nil
+	}
+	// The event loop can't poll the connections whose first bytes were already read.
+	if s.EngineMode == config.EventLoop && s.Sniffer != nil {
+		s.logger.Warn().Msg("The connections of the server are served with goroutines, as sniffing is enabled")
+	}
+}
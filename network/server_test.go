@@ -5,23 +5,150 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
 	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
 	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
 	"github.com/gatewayd-io/gatewayd/logging"
+	"github.com/gatewayd-io/gatewayd/metrics"
 	"github.com/gatewayd-io/gatewayd/plugin"
 	"github.com/gatewayd-io/gatewayd/pool"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 )
 
+// TestServerDrain tests that the new connections are rejected while draining, and
+// that the drain waits for the open connections for up to the drain timeout.
+func TestServerDrain(t *testing.T) {
+	server := &Server{
+		ctx:          context.Background(),
+		logger:       zerolog.Nop(),
+		mu:           &sync.RWMutex{},
+		engine:       NewEngine(zerolog.Nop()),
+		Status:       config.Running,
+		DrainTimeout: 50 * time.Millisecond,
+	}
+	assert.False(t, server.IsDraining())
+	assert.Zero(t, server.Drain())
+	assert.True(t, server.IsDraining())
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	response, action := server.OnOpen(NewConnWrapper(serverConn, nil, 0))
+	assert.Equal(t, Close, action)
+	assert.Equal(t, byte('E'), response[0])
+	assert.Contains(t, string(response), SQLStateCannotConnectNow)
+
+	// The connections still open after the drain timeout are left to Shutdown.
+	server.engine.connections = 1
+	start := time.Now()
+	assert.Equal(t, 1, server.Drain())
+	assert.GreaterOrEqual(t, time.Since(start), server.DrainTimeout)
+}
+
+// runRejectingServer runs a server that rejects the connections right after they are
+// accepted, and returns its listeners once it is listening on all of them.
+func runRejectingServer(t *testing.T, address string, cfg *config.Server, listeners int) []net.Listener {
+	t.Helper()
+
+	ctx := context.Background()
+	pluginRegistry := plugin.NewRegistry(
+		ctx, config.Loose, config.PassDown, config.Accept, config.Stop, zerolog.Nop(), false)
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, 1), pluginRegistry, false, false,
+		config.DefaultHealthCheckPeriod, &config.Client{}, zerolog.Nop(), config.DefaultPluginTimeout)
+	server := NewServer(
+		ctx, "tcp", address, config.DefaultTickInterval, Option{}, proxy,
+		zerolog.Nop(), pluginRegistry, config.DefaultPluginTimeout, false, "", "", 0)
+	server.Configure(cfg)
+	server.draining.Store(true)
+
+	stopped := make(chan *gerr.GatewayDError, 1)
+	go func() {
+		stopped <- server.Run()
+	}()
+	t.Cleanup(func() {
+		require.NoError(t, server.engine.Stop(ctx))
+		select {
+		case err := <-stopped:
+			assert.Nil(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("Server didn't stop")
+		}
+	})
+
+	var serverListeners []net.Listener
+	require.Eventually(t, func() bool {
+		server.mu.RLock()
+		defer server.mu.RUnlock()
+		serverListeners = server.engine.listeners
+		return len(serverListeners) == listeners
+	}, time.Second, 10*time.Millisecond)
+	return serverListeners
+}
+
+// dialRejectingServer connects to the server and checks that the connection is rejected.
+func dialRejectingServer(t *testing.T, network, address string) {
+	t.Helper()
+
+	conn, err := net.Dial(network, address)
+	require.NoError(t, err)
+	defer conn.Close()
+	response, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Contains(t, string(response), SQLStateCannotConnectNow)
+}
+
+// TestServerListeners tests that the server accepts the connections on all of its
+// listeners, and that the connections are counted per listener.
+func TestServerListeners(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "gatewayd.sock")
+	listeners := runRejectingServer(t, "127.0.0.1:0", &config.Server{
+		Listeners: []config.Listener{{Network: "unix", Address: socket}},
+	}, 2)
+	assert.Equal(t, socket, listeners[1].Addr().String())
+
+	for _, listener := range listeners {
+		accepted := metrics.ListenerConnectionsAccepted.WithLabelValues(listener.Addr().String())
+		before := testutil.ToFloat64(accepted)
+		dialRejectingServer(t, listener.Addr().Network(), listener.Addr().String())
+		assert.Equal(t, before+1, testutil.ToFloat64(accepted))
+	}
+}
+
+// TestServerDualStack tests that the server listens on IPv6 and IPv4 when the address
+// is the IPv4 wildcard, and that the connections are counted per address family.
+func TestServerDualStack(t *testing.T) {
+	if listener, err := net.Listen("tcp", "[::1]:0"); err != nil {
+		t.Skipf("IPv6 is not available: %v", err)
+	} else {
+		listener.Close()
+	}
+
+	listeners := runRejectingServer(t, "0.0.0.0:0", &config.Server{DualStack: true}, 1)
+	host, port, err := net.SplitHostPort(listeners[0].Addr().String())
+	require.NoError(t, err)
+	assert.Equal(t, "::", host)
+
+	for family, host := range map[string]string{"ipv4": "127.0.0.1", "ipv6": "::1"} {
+		accepted := metrics.ClientConnectionsAccepted.WithLabelValues(family)
+		before := testutil.ToFloat64(accepted)
+		dialRejectingServer(t, "tcp", net.JoinHostPort(host, port))
+		assert.Equal(t, before+1, testutil.ToFloat64(accepted), family)
+	}
+}
+
 // TestRunServer tests an entire server run with a single client connection and hooks.
 func TestRunServer(t *testing.T) {
 	// Reset prometheus metrics.
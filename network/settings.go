@@ -0,0 +1,120 @@
+package network
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	setStatement = regexp.MustCompile(
+		`(?is)^set\s+(?:(session|local)\s+)?(time\s+zone|session\s+authorization|` +
+			`session\s+characteristics|transaction|[a-z_][\w.$]*)\b`)
+	resetStatement = regexp.MustCompile(
+		`(?is)^reset\s+(all|time\s+zone|session\s+authorization|[a-z_][\w.$]*)\b`)
+	discardStatement = regexp.MustCompile(`(?is)^discard\s+all\b`)
+	whitespace       = regexp.MustCompile(`\s+`)
+)
+
+// sessionSettings holds the SET statements that changed the settings of a session,
+// so that they can be replayed on a new server connection. The statements are
+// tracked when they are sent, so the ones that fail or are rolled back are kept.
+type sessionSettings struct {
+	mu         sync.Mutex
+	names      []string
+	statements map[string]string
+}
+
+// track records the SET, RESET and DISCARD ALL statements of the query. The SET
+// LOCAL and SET TRANSACTION statements only last until the end of the transaction,
+// so they are ignored.
+func (s *sessionSettings) track(query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The semicolons in the string literals split the statements in the wrong place,
+	// but the parts don't start with SET, RESET or DISCARD anyway.
+	for _, statement := range strings.Split(query, ";") {
+		statement = strings.TrimSpace(statement)
+		if match := setStatement.FindStringSubmatch(statement); match != nil {
+			name := settingName(match[2])
+			if strings.EqualFold(match[1], "local") || name == "transaction" {
+				continue
+			}
+			s.set(name, statement)
+		} else if match := resetStatement.FindStringSubmatch(statement); match != nil {
+			if name := settingName(match[1]); name == "all" {
+				s.clear()
+			} else {
+				s.reset(name)
+			}
+		} else if discardStatement.MatchString(statement) {
+			s.clear()
+		}
+	}
+}
+
+// set must be called with the lock held.
+func (s *sessionSettings) set(name, statement string) {
+	if s.statements == nil {
+		s.statements = make(map[string]string)
+	}
+	if _, ok := s.statements[name]; !ok {
+		s.names = append(s.names, name)
+	}
+	s.statements[name] = statement
+}
+
+// reset must be called with the lock held.
+func (s *sessionSettings) reset(name string) {
+	if _, ok := s.statements[name]; !ok {
+		return
+	}
+	delete(s.statements, name)
+	for index, other := range s.names {
+		if other == name {
+			s.names = append(s.names[:index], s.names[index+1:]...)
+			break
+		}
+	}
+}
+
+// clear must be called with the lock held.
+func (s *sessionSettings) clear() {
+	s.names = nil
+	s.statements = nil
+}
+
+// Statements returns the last SET statement of each setting, in the order the
+// settings were first changed.
+func (s *sessionSettings) Statements() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.names) == 0 {
+		return nil
+	}
+
+	statements := make([]string, 0, len(s.names))
+	for _, name := range s.names {
+		statements = append(statements, s.statements[name])
+	}
+	return statements
+}
+
+// Size returns the approximate memory held by the settings, in bytes.
+func (s *sessionSettings) Size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := 0
+	for name, statement := range s.statements {
+		size += len(name) + len(statement)
+	}
+	return size
+}
+
+// settingName normalizes the name of a setting, which is case-insensitive.
+func settingName(name string) string {
+	return whitespace.ReplaceAllString(strings.ToLower(name), " ")
+}
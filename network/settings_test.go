@@ -0,0 +1,38 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSessionSettings tests that the SET statements of a session are tracked until
+// they are reset or discarded.
+func TestSessionSettings(t *testing.T) {
+	var settings sessionSettings
+	assert.Nil(t, settings.Statements())
+
+	settings.track("SET search_path TO public")
+	settings.track("set Statement_Timeout = 1000; SELECT 1;")
+	settings.track("SET LOCAL lock_timeout = 100")
+	settings.track("SET TRANSACTION ISOLATION LEVEL SERIALIZABLE")
+	settings.track("SET TIME ZONE 'UTC'")
+	settings.track("SET SESSION search_path = app")
+	assert.Equal(t, []string{
+		"SET SESSION search_path = app",
+		"set Statement_Timeout = 1000",
+		"SET TIME ZONE 'UTC'",
+	}, settings.Statements())
+
+	settings.track("RESET statement_timeout")
+	settings.track("reset time  zone")
+	assert.Equal(t, []string{"SET SESSION search_path = app"}, settings.Statements())
+
+	settings.track("SET work_mem = '64MB'")
+	settings.track("RESET ALL")
+	assert.Nil(t, settings.Statements())
+
+	settings.track("SET work_mem = '64MB'")
+	settings.track("DISCARD ALL")
+	assert.Nil(t, settings.Statements())
+}
@@ -0,0 +1,611 @@
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/policy"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"github.com/rs/zerolog"
+)
+
+// ShardKeyField is the field of the result of the OnTrafficFromClient hooks that holds
+// the shard key of the request.
+const ShardKeyField = "shardKey"
+
+// sqlLiteral matches a string or numeric literal of a query.
+const sqlLiteral = `'(?:[^']|'')*'|-?\d+(?:\.\d+)?`
+
+var (
+	// insertStatement matches the columns and the values of an INSERT statement.
+	insertStatement = regexp.MustCompile(`(?is)^\s*insert\s+into\s+[^(]+\(([^)]*)\)\s*values\s*(.*)$`)
+	// literalValue matches a value that is a literal.
+	literalValue = regexp.MustCompile(`^(?:` + sqlLiteral + `)$`)
+)
+
+// Shard is a config group whose pool serves the statements of the shard keys its policy
+// matches, or of a share of the hashes of the keys if it has no policy.
+type Shard struct {
+	Name   string
+	Policy *policy.Program
+	// Proxy lends the server connections to the shard to the sessions of the other
+	// proxies.
+	Proxy *Proxy
+}
+
+// shardSession is the shard of the last statement of a session, and the server
+// connections it borrowed from the other shards.
+type shardSession struct {
+	mu      sync.Mutex
+	current string
+	clients map[string]*Client
+}
+
+// ShardRouter finds the shard keys of the statements, and the shards that serve them.
+type ShardRouter struct {
+	// Home is the config group of the proxy, whose server connections the sessions
+	// start on.
+	Home   string
+	Key    config.ShardKeySource
+	Shards []*Shard
+
+	// hashed are the shards without a policy, which the keys are hashed over.
+	hashed   []*Shard
+	columnEq *regexp.Regexp
+	columnIn *regexp.Regexp
+	column   string
+	hint     *regexp.Regexp
+	sessions sync.Map
+	logger   zerolog.Logger
+}
+
+// NewShardRouter creates the router of the proxy of the home config group, whose shards
+// are served by the proxies of their config groups.
+func NewShardRouter(
+	home string, sharding config.Sharding, proxies map[string]*Proxy, logger zerolog.Logger,
+) (*ShardRouter, *gerr.GatewayDError) {
+	router := &ShardRouter{
+		Home:   home,
+		Key:    config.ShardKeySource(sharding.Key),
+		column: strings.ToLower(sharding.Column),
+		logger: logger,
+	}
+	if router.Key == "" {
+		router.Key = config.DefaultShardKeySource
+	}
+
+	switch router.Key {
+	case config.ShardKeyColumn:
+		if sharding.Column == "" {
+			return nil, gerr.ErrInvalidSharding.Wrap(
+				fmt.Errorf("the shard key column of %s is empty", home))
+		}
+		column := `(?:^|[^\w$"])"?` + regexp.QuoteMeta(sharding.Column) + `"?`
+		router.columnEq = regexp.MustCompile(`(?i)` + column + `\s*=\s*(` + sqlLiteral + `)`)
+		router.columnIn = regexp.MustCompile(
+			`(?i)` + column + `\s+in\s*\(((?:\s*(?:` + sqlLiteral + `)\s*,?)+)\)`)
+	case config.ShardKeyComment:
+		hint := config.If[string](sharding.Hint != "", sharding.Hint, config.DefaultShardKeyHint)
+		router.hint = regexp.MustCompile(
+			`/\*[^*]*?\b` + regexp.QuoteMeta(hint) + `\s*[=:]\s*'?([^\s'*,]+)'?`)
+	case config.ShardKeyHook:
+	default:
+		return nil, gerr.ErrInvalidSharding.Wrap(
+			fmt.Errorf("invalid shard key source %q of %s", sharding.Key, home))
+	}
+
+	if len(sharding.Shards) == 0 {
+		return nil, gerr.ErrInvalidSharding.Wrap(fmt.Errorf("%s has no shards", home))
+	}
+	for _, shard := range sharding.Shards {
+		proxy, ok := proxies[shard.Name]
+		if !ok {
+			return nil, gerr.ErrInvalidSharding.Wrap(
+				fmt.Errorf("the shard %s of %s is not a config group", shard.Name, home))
+		}
+		compiled := &Shard{Name: shard.Name, Proxy: proxy}
+		if shard.When != "" {
+			program, err := policy.Compile(shard.When)
+			if err != nil {
+				return nil, gerr.ErrInvalidSharding.Wrap(err)
+			}
+			compiled.Policy = program
+		} else {
+			router.hashed = append(router.hashed, compiled)
+		}
+		router.Shards = append(router.Shards, compiled)
+	}
+
+	return router, nil
+}
+
+// Shard returns the shard of the name, or nil if it isn't a shard.
+func (r *ShardRouter) Shard(name string) *Shard {
+	for _, shard := range r.Shards {
+		if shard.Name == name {
+			return shard
+		}
+	}
+	return nil
+}
+
+// Keys returns the distinct shard keys of the request, from the queries or from the
+// result of the OnTrafficFromClient hooks.
+func (r *ShardRouter) Keys(request []byte, result *v1.Struct) []string {
+	var keys []string
+	add := func(key string) {
+		for _, other := range keys {
+			if other == key {
+				return
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	if r.Key == config.ShardKeyHook {
+		switch key := result.GetFields()[ShardKeyField].GetKind().(type) {
+		case *v1.Value_StringValue:
+			if key.StringValue != "" {
+				add(key.StringValue)
+			}
+		case *v1.Value_NumberValue:
+			add(strconv.FormatFloat(key.NumberValue, 'f', -1, 64))
+		}
+		return keys
+	}
+
+	for _, query := range PostgresQueries(request) {
+		if r.Key == config.ShardKeyComment {
+			for _, match := range r.hint.FindAllStringSubmatch(query, -1) {
+				add(match[1])
+			}
+			continue
+		}
+
+		for _, match := range r.columnEq.FindAllStringSubmatch(query, -1) {
+			add(unquoteLiteral(match[1]))
+		}
+		for _, match := range r.columnIn.FindAllStringSubmatch(query, -1) {
+			for _, value := range splitList(match[1]) {
+				add(unquoteLiteral(value))
+			}
+		}
+		for _, value := range r.insertedKeys(query) {
+			add(value)
+		}
+	}
+	return keys
+}
+
+// insertedKeys returns the literal values of the column in the rows of an INSERT
+// statement.
+func (r *ShardRouter) insertedKeys(query string) []string {
+	match := insertStatement.FindStringSubmatch(query)
+	if match == nil {
+		return nil
+	}
+	index := -1
+	for i, column := range splitList(match[1]) {
+		if strings.ToLower(strings.Trim(column, `"`)) == r.column {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+
+	var keys []string
+	for _, row := range splitRows(match[2]) {
+		values := splitList(row)
+		if index < len(values) && literalValue.MatchString(values[index]) {
+			keys = append(keys, unquoteLiteral(values[index]))
+		}
+	}
+	return keys
+}
+
+// ShardOf returns the first shard whose policy matches the key, with the variables of
+// the query and the request.shardKey field, or the shard of the hash of the key among
+// the shards without a policy. It returns nil if no shard serves the key.
+func (r *ShardRouter) ShardOf(key string, vars map[string]any) *Shard {
+	fields := map[string]any{ShardKeyField: key}
+	if request, ok := vars["request"].(map[string]any); ok {
+		for name, value := range request {
+			fields[name] = value
+		}
+	}
+	shardVars := map[string]any{"request": fields}
+
+	for _, shard := range r.Shards {
+		if shard.Policy == nil {
+			continue
+		}
+		matches, err := shard.Policy.Matches(shardVars)
+		if err != nil {
+			r.logger.Debug().Err(err).Str("shard", shard.Name).Msg(
+				"Failed to evaluate the policy of the shard, so it doesn't match")
+			continue
+		}
+		if matches {
+			return shard
+		}
+	}
+
+	if len(r.hashed) == 0 {
+		return nil
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return r.hashed[hash.Sum32()%uint32(len(r.hashed))]
+}
+
+// session returns the shard session of the connection.
+func (r *ShardRouter) session(conn *ConnWrapper) *shardSession {
+	value, _ := r.sessions.LoadOrStore(conn, &shardSession{current: r.Home, clients: map[string]*Client{}})
+	return value.(*shardSession) //nolint:forcetypeassert
+}
+
+// release puts the server connections the session of the connection borrowed back in
+// the pools of their shards.
+func (r *ShardRouter) release(conn *ConnWrapper) {
+	value, ok := r.sessions.LoadAndDelete(conn)
+	if !ok {
+		return
+	}
+	session := value.(*shardSession) //nolint:forcetypeassert
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	for name, client := range session.clients {
+		if shard := r.Shard(name); shard != nil {
+			shard.Proxy.releaseClient(client)
+		}
+	}
+	session.clients = map[string]*Client{}
+}
+
+// routeStatement returns the shard of the request, or the response to send to the
+// client instead if the request can't be routed to a single shard. The shard is nil if
+// the request stays on the server connection of the session.
+func (pr *Proxy) routeStatement(
+	conn *ConnWrapper, request []byte, result *v1.Struct, correlation Correlation,
+) (*Shard, []byte) {
+	router := pr.Sharding
+	if !IsPostgresQuery(request) {
+		return nil, nil
+	}
+
+	state := router.session(conn)
+	state.mu.Lock()
+	current := state.current
+	state.mu.Unlock()
+
+	target := router.Shard(current)
+	if keys := router.Keys(request, result); len(keys) > 0 {
+		var query string
+		if queries := PostgresQueries(request); len(queries) > 0 {
+			query = queries[0]
+		}
+		vars := pr.policyVars(conn, query)
+
+		var shards []*Shard
+		for _, key := range keys {
+			shard := router.ShardOf(key, vars)
+			if shard == nil {
+				return nil, pr.shardErrorResponse(config.ErrorShardUnavailable,
+					"no shard serves the shard key "+key)
+			}
+			if !containsShard(shards, shard) {
+				shards = append(shards, shard)
+			}
+		}
+		if len(shards) > 1 {
+			return nil, pr.crossShard(conn, request, keys, shards, correlation)
+		}
+
+		// A transaction can't move to another shard.
+		target = shards[0]
+		if target.Name != current && pr.inTransaction(conn) {
+			shards = append(shards, router.Shard(current))
+			return nil, pr.crossShard(conn, request, keys, shards, correlation)
+		}
+		state.mu.Lock()
+		state.current = target.Name
+		state.mu.Unlock()
+		metrics.ProxyShardedQueries.WithLabelValues(target.Name).Inc()
+	}
+
+	if target == nil || target.Name == router.Home {
+		return nil, nil
+	}
+	// The response to the other shards is read up to the ReadyForQuery message.
+	if last := lastMessageType(request); last != 'Q' && last != 'S' {
+		return nil, pr.shardErrorResponse(config.ErrorCrossShard,
+			"the statements routed to a shard must be sent along with their Sync message")
+	}
+	return target, nil
+}
+
+// crossShard passes the statement that spans the shards to the OnCrossShard hooks, and
+// returns their response, or the error response if none of them handled it.
+func (pr *Proxy) crossShard(
+	conn *ConnWrapper, request []byte, keys []string, shards []*Shard, correlation Correlation,
+) []byte {
+	logger := correlation.Logger(pr.logger)
+	names := make([]string, 0, len(shards))
+	shardArgs := make([]interface{}, 0, len(shards))
+	for _, shard := range shards {
+		if shard == nil {
+			// The shard of the transaction is the config group itself.
+			shard = &Shard{Name: pr.Sharding.Home, Proxy: pr}
+		}
+		names = append(names, shard.Name)
+		shardArgs = append(shardArgs, map[string]interface{}{
+			"name":    shard.Name,
+			"address": shard.Proxy.clientConfig().Address,
+		})
+	}
+	keyArgs := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		keyArgs = append(keyArgs, key)
+	}
+
+	if pr.pluginRegistry != nil {
+		pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), pr.pluginTimeout)
+		defer cancel()
+		result, err := pr.pluginRegistry.Run(
+			pluginTimeoutCtx,
+			map[string]interface{}{
+				"connectionId": conn.ID(),
+				"request":      request,
+				"keys":         keyArgs,
+				"shards":       shardArgs,
+			},
+			sdk.OnCrossShard,
+		)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to run the OnCrossShard hooks")
+		}
+		if response, errMsg := extractFieldValue(result, "response"); errMsg != "" {
+			logger.Error().Str("error", errMsg).Msg("Error in hook")
+		} else if response != nil {
+			return response
+		}
+	}
+
+	metrics.ProxyCrossShardRejections.Inc()
+	logger.Debug().Strs("shards", names).Strs("keys", keys).Msg(
+		"Rejected a statement that spans the shards")
+	return pr.shardErrorResponse(config.ErrorCrossShard,
+		"the statement spans the shards "+strings.Join(names, ", "))
+}
+
+// shardErrorResponse returns the error response of the kind, with the message unless
+// one is configured, followed by a ReadyForQuery message.
+func (pr *Proxy) shardErrorResponse(kind config.ErrorKind, message string) []byte {
+	if configured, ok := pr.ErrorMessages[kind]; ok && configured.Message != "" {
+		message = ""
+	}
+	return append(ErrorResponse(pr.ErrorMessages, kind, message), PostgreSQLReadyForQuery()...)
+}
+
+// inTransaction returns true if the session of the connection is in a transaction block.
+func (pr *Proxy) inTransaction(conn *ConnWrapper) bool {
+	if value, ok := pr.sessions.Load(conn); ok {
+		return value.(*session).state.Load() == InTransaction //nolint:forcetypeassert
+	}
+	return false
+}
+
+// shardClient returns the server connection of the session of the connection to the
+// shard, borrowing one from the pool of the shard and authenticating the session on it
+// if needed.
+func (pr *Proxy) shardClient(
+	conn *ConnWrapper, shard *Shard, correlation Correlation,
+) (*Client, *gerr.GatewayDError) {
+	state := pr.Sharding.session(conn)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if client, ok := state.clients[shard.Name]; ok {
+		return client, nil
+	}
+
+	client := shard.Proxy.popAvailableClient()
+	if client == nil && shard.Proxy.Elastic {
+		client = shard.Proxy.newClient()
+	}
+	if client == nil || client.ID == "" {
+		return nil, gerr.ErrShardUnavailable.Wrap(
+			fmt.Errorf("the pool of the shard %s is exhausted", shard.Name))
+	}
+
+	var startup, password []byte
+	if value, ok := pr.sessions.Load(conn); ok {
+		startup, password = value.(*session).authentication() //nolint:forcetypeassert
+	}
+	if startup == nil {
+		shard.Proxy.releaseClient(client)
+		return nil, gerr.ErrShardUnavailable.Wrap(
+			fmt.Errorf("the session isn't authenticated on %s", pr.Sharding.Home))
+	}
+	if err := pr.authenticateSession(conn, client, startup, password, correlation); err != nil {
+		shard.Proxy.releaseClient(client)
+		return nil, gerr.ErrShardUnavailable.Wrap(err)
+	}
+
+	state.clients[shard.Name] = client
+	return client, nil
+}
+
+// dropShardClient puts the server connection of the session to the shard back in the
+// pool of the shard, e.g. after it failed, so that the next statement borrows another.
+func (pr *Proxy) dropShardClient(conn *ConnWrapper, shard *Shard) {
+	state := pr.Sharding.session(conn)
+	state.mu.Lock()
+	client, ok := state.clients[shard.Name]
+	delete(state.clients, shard.Name)
+	state.mu.Unlock()
+	if ok {
+		shard.Proxy.releaseClient(client)
+	}
+}
+
+// sendToShard runs the request on the server connection of the session to the shard,
+// and relays the response to the client as it is received, up to its ReadyForQuery
+// message. It returns the server connection, and an error if the session is broken.
+func (pr *Proxy) sendToShard(
+	conn *ConnWrapper, shard *Shard, request []byte, correlation Correlation,
+) (*Client, *gerr.GatewayDError) {
+	logger := correlation.Logger(pr.logger)
+	client, err := pr.shardClient(conn, shard, correlation)
+	if err != nil {
+		logger.Error().Err(err).Str("shard", shard.Name).Msg("Failed to connect to the shard")
+		response := pr.shardErrorResponse(config.ErrorShardUnavailable, "")
+		return nil, pr.sendTrafficToClient(conn.Conn(), response, len(response), correlation)
+	}
+
+	start := time.Now()
+	if _, err := pr.sendTrafficToServer(client, request, correlation); err != nil {
+		logger.Error().Err(err).Str("shard", shard.Name).Msg("Failed to send to the shard")
+		pr.dropShardClient(conn, shard)
+		response := pr.shardErrorResponse(config.ErrorShardUnavailable, "")
+		return client, pr.sendTrafficToClient(conn.Conn(), response, len(response), correlation)
+	}
+	pr.startStatementTimeout(conn, client, request, correlation)
+
+	if err := pr.relayResponse(conn, client, correlation, func() {
+		pr.dropShardClient(conn, shard)
+	}); err != nil {
+		return client, err
+	}
+
+	pr.observeQueryLatency(conn, time.Since(start), correlation)
+	return client, nil
+}
+
+// relayResponse relays the response of the server connection to the client as it is
+// received, up to its ReadyForQuery message. The drop function is called if the server
+// connection fails.
+func (pr *Proxy) relayResponse(
+	conn *ConnWrapper, client *Client, correlation Correlation, drop func(),
+) *gerr.GatewayDError {
+	logger := correlation.Logger(pr.logger)
+	var tail []byte
+	for {
+		_, received, _, err := pr.receiveTrafficFromServer(client, 0, correlation)
+		if err != nil {
+			// Part of the response might have been sent, so the session is broken.
+			drop()
+			return err
+		}
+		pr.throttle(conn, Egress, len(received), logger)
+		pr.recordUsage(conn, Egress, received)
+		pr.trackTransaction(conn, received, correlation)
+		limited := pr.limitResult(conn, client, received, correlation)
+		if err := pr.sendTrafficToClient(conn.Conn(), limited, len(limited), correlation); err != nil {
+			return err
+		}
+
+		// The ReadyForQuery message is the last 6 bytes of the response.
+		tail = append(tail, received...)
+		tail = tail[max(len(tail)-6, 0):] //nolint:gomnd
+		if PostgresTransactionStatus(tail) != 0 {
+			return nil
+		}
+	}
+}
+
+// lastMessageType returns the type of the last message of the request, or zero if it
+// isn't a sequence of messages.
+//
+//nolint:gomnd
+func lastMessageType(request []byte) byte {
+	var last byte
+	for offset := 0; offset+5 <= len(request); {
+		length := int(binary.BigEndian.Uint32(request[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(request) {
+			return 0
+		}
+		last = request[offset]
+		offset += 1 + length
+	}
+	return last
+}
+
+func containsShard(shards []*Shard, shard *Shard) bool {
+	for _, other := range shards {
+		if other == shard {
+			return true
+		}
+	}
+	return false
+}
+
+// unquoteLiteral returns the value of a string literal, or the literal as is.
+func unquoteLiteral(literal string) string {
+	literal = strings.TrimSpace(literal)
+	if len(literal) >= 2 && literal[0] == '\'' && literal[len(literal)-1] == '\'' {
+		return strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	}
+	return literal
+}
+
+// splitList splits the comma-separated list, except within the string literals and the
+// parentheses.
+func splitList(list string) []string {
+	var items []string
+	depth, quoted, start := 0, false, 0
+	for i, char := range list {
+		switch {
+		case char == '\'':
+			quoted = !quoted
+		case quoted:
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case char == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(list[start:i]))
+			start = i + 1
+		}
+	}
+	return append(items, strings.TrimSpace(list[start:]))
+}
+
+// splitRows returns the contents of the parenthesized rows of the VALUES list, up to
+// the end of the list, e.g. ON CONFLICT or RETURNING.
+func splitRows(values string) []string {
+	var rows []string
+	depth, quoted, start := 0, false, 0
+	for i, char := range values {
+		switch {
+		case char == '\'':
+			quoted = !quoted
+		case quoted:
+		case char == '(':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+		case char == ')':
+			depth--
+			if depth == 0 {
+				rows = append(rows, values[start:i])
+			}
+		case depth == 0 && char != ',' && char != ' ' && char != '\n' && char != '\t':
+			return rows
+		}
+	}
+	return rows
+}
@@ -0,0 +1,147 @@
+package network
+
+import (
+	"testing"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shardProxies returns the proxies of the config groups of the shards.
+func shardProxies(names ...string) map[string]*Proxy {
+	proxies := map[string]*Proxy{}
+	for _, name := range names {
+		proxies[name] = &Proxy{ClientConfig: &config.Client{Address: name + ":5432"}}
+	}
+	return proxies
+}
+
+// TestShardRouterKeys tests that the shard keys are found in the queries, in their
+// comments, or in the result of the hooks.
+func TestShardRouterKeys(t *testing.T) {
+	proxies := shardProxies("default", "shard-1")
+	column, err := NewShardRouter("default", config.Sharding{
+		Key:    string(config.ShardKeyColumn),
+		Column: "tenant_id",
+		Shards: []config.Shard{{Name: "default"}, {Name: "shard-1"}},
+	}, proxies, zerolog.Nop())
+	require.Nil(t, err)
+
+	keys := func(router *ShardRouter, query string) []string {
+		return router.Keys(PostgreSQLQuery(query), nil)
+	}
+	assert.Equal(t, []string{"42"}, keys(column, "SELECT * FROM users WHERE tenant_id = 42"))
+	assert.Equal(t, []string{"o'neil"},
+		keys(column, `SELECT * FROM users WHERE "tenant_id"='o''neil'`))
+	assert.Equal(t, []string{"1", "2"},
+		keys(column, "DELETE FROM users WHERE tenant_id IN (1, 2, 1)"))
+	assert.Equal(t, []string{"7", "8"}, keys(column,
+		"INSERT INTO users (id, tenant_id, name) VALUES (1, 7, 'a'), (2, 8, now())"))
+	assert.Empty(t, keys(column, "SELECT * FROM users WHERE other_tenant_id = 42"))
+	assert.Empty(t, keys(column, "INSERT INTO users (id, tenant_id) VALUES (1, $1)"))
+
+	comment, err := NewShardRouter("default", config.Sharding{
+		Key:    string(config.ShardKeyComment),
+		Shards: []config.Shard{{Name: "default"}},
+	}, proxies, zerolog.Nop())
+	require.Nil(t, err)
+	assert.Equal(t, []string{"acme"}, keys(comment, "/* shard_key='acme' */ SELECT 1"))
+	assert.Empty(t, keys(comment, "SELECT 1"))
+
+	hook, err := NewShardRouter("default", config.Sharding{
+		Key:    string(config.ShardKeyHook),
+		Shards: []config.Shard{{Name: "default"}},
+	}, proxies, zerolog.Nop())
+	require.Nil(t, err)
+	assert.Equal(t, []string{"3"}, hook.Keys(nil, &v1.Struct{
+		Fields: map[string]*v1.Value{ShardKeyField: v1.NewNumberValue(3)},
+	}))
+	assert.Empty(t, hook.Keys(nil, &v1.Struct{}))
+}
+
+// TestShardRouterShardOf tests that the keys go to the first shard whose policy
+// matches, or are hashed over the shards without a policy.
+func TestShardRouterShardOf(t *testing.T) {
+	router, err := NewShardRouter("default", config.Sharding{
+		Column: "tenant_id",
+		Shards: []config.Shard{
+			{Name: "eu", When: `request.shardKey.startsWith("eu-")`},
+			{Name: "default"},
+			{Name: "shard-1"},
+		},
+	}, shardProxies("default", "shard-1", "eu"), zerolog.Nop())
+	require.Nil(t, err)
+	assert.Equal(t, config.ShardKeyColumn, router.Key)
+
+	assert.Equal(t, "eu", router.ShardOf("eu-42", nil).Name)
+	hashed := router.ShardOf("us-42", nil)
+	require.NotNil(t, hashed)
+	assert.NotEqual(t, "eu", hashed.Name)
+	// The hash of a key is stable.
+	assert.Equal(t, hashed, router.ShardOf("us-42", nil))
+}
+
+func TestNewShardRouterInvalid(t *testing.T) {
+	proxies := shardProxies("default")
+	for _, sharding := range []config.Sharding{
+		{Column: "", Shards: []config.Shard{{Name: "default"}}},
+		{Key: "header", Shards: []config.Shard{{Name: "default"}}},
+		{Column: "tenant_id"},
+		{Column: "tenant_id", Shards: []config.Shard{{Name: "missing"}}},
+		{Column: "tenant_id", Shards: []config.Shard{{Name: "default", When: "request.shardKey =="}}},
+	} {
+		_, err := NewShardRouter("default", sharding, proxies, zerolog.Nop())
+		assert.NotNil(t, err)
+	}
+}
+
+// TestRouteStatement tests that the statements stay on the home shard, move to the
+// shard of their key, or are rejected if they span the shards.
+func TestRouteStatement(t *testing.T) {
+	proxies := shardProxies("default", "eu")
+	router, err := NewShardRouter("default", config.Sharding{
+		Column: "tenant_id",
+		Shards: []config.Shard{
+			{Name: "eu", When: `request.shardKey.startsWith("eu-")`},
+			{Name: "default", When: "true"},
+		},
+	}, proxies, zerolog.Nop())
+	require.Nil(t, err)
+	proxy := proxies["default"]
+	proxy.Sharding = router
+	conn := NewConnWrapper(nil, nil, 0)
+	defer router.release(conn)
+
+	route := func(query string) (*Shard, []byte) {
+		return proxy.routeStatement(conn, PostgreSQLQuery(query), nil, Correlation{})
+	}
+
+	shard, response := route("SELECT * FROM users WHERE tenant_id = 'us-1'")
+	assert.Nil(t, shard)
+	assert.Nil(t, response)
+
+	shard, response = route("SELECT * FROM users WHERE tenant_id = 'eu-1'")
+	require.NotNil(t, shard)
+	assert.Equal(t, "eu", shard.Name)
+	assert.Nil(t, response)
+
+	// The statements without a key stay on the shard of the session.
+	shard, _ = route("SELECT 1")
+	require.NotNil(t, shard)
+	assert.Equal(t, "eu", shard.Name)
+
+	shard, response = route("SELECT * FROM users WHERE tenant_id IN ('eu-1', 'us-1')")
+	assert.Nil(t, shard)
+	assert.Contains(t, string(response), "the statement spans the shards")
+	assert.Equal(t, byte('Z'), lastMessageType(response))
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"1", "'a, b'", "f(1, 2)"}, splitList("1, 'a, b', f(1, 2)"))
+	assert.Equal(t, []string{"1, 'a'", "2, 'b)'"}, splitRows("(1, 'a'), (2, 'b)')"))
+	assert.Equal(t, byte('S'), lastMessageType(append(PostgreSQLQuery("SELECT 1"),
+		'S', 0, 0, 0, 4)))
+}
@@ -0,0 +1,394 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// SharedStore keeps the usage of the limits shared by the GatewayD instances.
+type SharedStore interface {
+	// IncrBy adds the delta to the counter of the key, which expires after the ttl, and
+	// returns the value of the counter.
+	IncrBy(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+	// Report sets the value of the instance for the key, and returns the sum of the
+	// values the instances reported within the ttl.
+	Report(ctx context.Context, key, instance string, value int64, ttl time.Duration) (int64, error)
+	Close() error
+}
+
+// RedisStore is the SharedStore of a Redis.
+type RedisStore struct {
+	client *redis.Client
+}
+
+var _ SharedStore = (*RedisStore)(nil)
+
+// NewRedisStore creates a store of the Redis of the shared limits config.
+func NewRedisStore(cfg config.SharedLimits) *RedisStore {
+	return &RedisStore{client: redis.NewClient(&redis.Options{
+		Addr:     cfg.Address,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})}
+}
+
+func (r *RedisStore) IncrBy(
+	ctx context.Context, key string, delta int64, ttl time.Duration,
+) (int64, error) {
+	var value *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		value = pipe.IncrBy(ctx, key, delta)
+		pipe.PExpire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+	return value.Val(), nil
+}
+
+// Report keeps the values of the instances in a hash of the key, along with the time
+// they were reported at, and removes the values of the instances that stopped
+// reporting.
+func (r *RedisStore) Report(
+	ctx context.Context, key, instance string, value int64, ttl time.Duration,
+) (int64, error) {
+	now := time.Now()
+	var values *redis.MapStringStringCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, instance, fmt.Sprintf("%d:%d", value, now.UnixMilli()))
+		pipe.PExpire(ctx, key, ttl)
+		values = pipe.HGetAll(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	var total int64
+	var stale []string
+	for field, reported := range values.Val() {
+		count, at, ok := strings.Cut(reported, ":")
+		reportedAt, err := strconv.ParseInt(at, 10, 64)
+		if !ok || err != nil || now.Sub(time.UnixMilli(reportedAt)) > ttl {
+			stale = append(stale, field)
+			continue
+		}
+		if count, err := strconv.ParseInt(count, 10, 64); err == nil {
+			total += count
+		}
+	}
+	if len(stale) > 0 {
+		// The stale values are removed again on the next report if this fails.
+		r.client.HDel(ctx, key, stale...)
+	}
+	return total, nil
+}
+
+func (r *RedisStore) Close() error {
+	return r.client.Close() //nolint:wrapcheck
+}
+
+// sharedWindow is the usage of a limit in a fixed window of time.
+type sharedWindow struct {
+	index  int64
+	length time.Duration
+	// local is the usage of the instance, and pending is the part of it that wasn't
+	// added to the store yet.
+	local   int64
+	pending int64
+	// shared is the usage of all the instances at the last sync.
+	shared int64
+}
+
+// sharedGauge is the number of the resources of a quota in use, e.g. connections.
+type sharedGauge struct {
+	local int64
+	// others is the number in use by the other instances at the last sync.
+	others int64
+}
+
+// SharedState syncs the usage of the limits of the instance with the SharedStore, so
+// that the limits are enforced across the GatewayD instances. The usage is counted
+// locally and synced on the interval, so that the store isn't on the path of the
+// traffic. While the store is unavailable, the limits apply to the usage of the
+// instance alone if the failure mode is open, or are exceeded if it is closed.
+type SharedState struct {
+	store       SharedStore
+	instance    string
+	prefix      string
+	interval    time.Duration
+	failureMode config.FailureMode
+	logger      zerolog.Logger
+
+	mu        sync.Mutex
+	windows   map[string]*sharedWindow
+	gauges    map[string]*sharedGauge
+	available atomic.Bool
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewSharedState creates the shared state of the limits of the instance, which is
+// synced with the store once started.
+func NewSharedState(
+	store SharedStore, cfg config.SharedLimits, logger zerolog.Logger,
+) *SharedState {
+	state := &SharedState{
+		store:    store,
+		instance: NewCorrelationID(),
+		prefix:   config.If[string](cfg.KeyPrefix != "", cfg.KeyPrefix, config.DefaultSharedLimitsKeyPrefix),
+		interval: config.If[time.Duration](
+			cfg.SyncInterval > 0, cfg.SyncInterval, config.DefaultSharedLimitsSyncInterval),
+		failureMode: config.If[config.FailureMode](
+			cfg.FailureMode != "",
+			config.FailureMode(cfg.FailureMode),
+			config.DefaultSharedLimitsFailureMode,
+		),
+		logger:  logger,
+		windows: map[string]*sharedWindow{},
+		gauges:  map[string]*sharedGauge{},
+		stop:    make(chan struct{}),
+	}
+	// The usage of the instance alone is known until the first sync.
+	state.available.Store(true)
+	return state
+}
+
+// Limits returns the limits of the config group, whose keys are scoped to its name.
+func (s *SharedState) Limits(group string) *SharedLimits {
+	return &SharedLimits{state: s, scope: group}
+}
+
+// Available returns true if the last sync with the store succeeded.
+func (s *SharedState) Available() bool {
+	return s.available.Load()
+}
+
+// gaugeTTL is how long the number reported by an instance counts, so that the
+// resources of the instances that stopped are released.
+func (s *SharedState) gaugeTTL() time.Duration {
+	return max(3*s.interval, time.Second) //nolint:gomnd
+}
+
+// Sync adds the usage of the instance since the last sync to the store, and gets the
+// usage of all the instances.
+func (s *SharedState) Sync(ctx context.Context) *gerr.GatewayDError {
+	type windowSync struct {
+		key     string
+		window  *sharedWindow
+		pending int64
+	}
+	type gaugeSync struct {
+		key   string
+		gauge *sharedGauge
+		local int64
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	windows := make([]windowSync, 0, len(s.windows))
+	for key, window := range s.windows {
+		if now.UnixNano()/int64(window.length) != window.index {
+			// The usage of the past windows doesn't count anymore.
+			delete(s.windows, key)
+			continue
+		}
+		windows = append(windows, windowSync{key, window, window.pending})
+		window.pending = 0
+	}
+	gauges := make([]gaugeSync, 0, len(s.gauges))
+	for key, gauge := range s.gauges {
+		gauges = append(gauges, gaugeSync{key, gauge, gauge.local})
+	}
+	s.mu.Unlock()
+
+	var syncErr error
+	for _, item := range windows {
+		key := s.prefix + item.key + ":" + strconv.FormatInt(item.window.index, 10)
+		shared, err := s.store.IncrBy(ctx, key, item.pending, 2*item.window.length)
+		s.mu.Lock()
+		if err != nil {
+			item.window.pending += item.pending
+			syncErr = err
+		} else {
+			item.window.shared = shared
+		}
+		s.mu.Unlock()
+	}
+	for _, item := range gauges {
+		total, err := s.store.Report(ctx, s.prefix+item.key, s.instance, item.local, s.gaugeTTL())
+		s.mu.Lock()
+		switch {
+		case err != nil:
+			syncErr = err
+		case item.local == 0 && item.gauge.local == 0:
+			// The instance reported that it doesn't use the resources anymore.
+			delete(s.gauges, item.key)
+		default:
+			item.gauge.others = max(total-item.local, 0)
+		}
+		s.mu.Unlock()
+	}
+
+	s.setAvailable(syncErr == nil, syncErr)
+	if syncErr != nil {
+		metrics.SharedLimitsSyncFailures.Inc()
+		return gerr.ErrSharedLimitsFailed.Wrap(syncErr)
+	}
+	return nil
+}
+
+func (s *SharedState) setAvailable(available bool, err error) {
+	if available {
+		metrics.SharedLimitsAvailable.Set(1)
+	} else {
+		metrics.SharedLimitsAvailable.Set(0)
+	}
+	if s.available.Swap(available) == available {
+		return
+	}
+	if available {
+		s.logger.Info().Msg("The shared state of the limits is available again")
+	} else {
+		s.logger.Warn().Err(err).Str("failureMode", string(s.failureMode)).Msg(
+			"The shared state of the limits is unavailable, so the limits fall back")
+	}
+}
+
+// Start syncs the usage with the store on the interval, until the state is stopped.
+func (s *SharedState) Start(ctx context.Context) {
+	syncState := func() {
+		syncCtx, cancel := context.WithTimeout(ctx, max(s.interval, time.Second))
+		defer cancel()
+		if err := s.Sync(syncCtx); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to sync the shared state of the limits")
+		}
+	}
+	syncState()
+	s.logger.Info().Str("interval", s.interval.String()).Str(
+		"failureMode", string(s.failureMode)).Msg("Syncing the shared state of the limits")
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				syncState()
+			case <-s.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops syncing, releases the resources of the quotas the instance used, and
+// closes the store.
+func (s *SharedState) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+
+		s.mu.Lock()
+		for _, gauge := range s.gauges {
+			gauge.local = 0
+		}
+		s.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), max(s.interval, time.Second))
+		defer cancel()
+		if err := s.Sync(ctx); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to release the shared quotas of the instance")
+		}
+		if err := s.store.Close(); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to close the store of the shared limits")
+		}
+	})
+}
+
+// SharedLimits are the limits of a config group in the shared state.
+type SharedLimits struct {
+	state *SharedState
+	scope string
+}
+
+// Consume adds the usage to the limit of the key in the current window, and returns
+// the usage of the window by all the instances, as of the last sync. It returns false
+// if the usage is unknown and the limit fails closed.
+func (l *SharedLimits) Consume(key string, usage int64, window time.Duration) (int64, bool) {
+	state := l.state
+	key = l.scope + "/" + key
+	index := time.Now().UnixNano() / int64(window)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	current, ok := state.windows[key]
+	if !ok || current.index != index {
+		current = &sharedWindow{index: index, length: window}
+		state.windows[key] = current
+	}
+	current.local += usage
+	current.pending += usage
+
+	switch {
+	case state.available.Load():
+		return current.shared + current.pending, true
+	case state.failureMode == config.FailClosed:
+		return 0, false
+	default:
+		return current.local, true
+	}
+}
+
+// Acquire takes one of the resources of the quota of the key, unless all the instances
+// use the limit already.
+func (l *SharedLimits) Acquire(key string, limit int64) bool {
+	state := l.state
+	key = l.scope + "/" + key
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	gauge, ok := state.gauges[key]
+	if !ok {
+		gauge = &sharedGauge{}
+		state.gauges[key] = gauge
+	}
+
+	inUse := gauge.local
+	switch {
+	case state.available.Load():
+		inUse += gauge.others
+	case state.failureMode == config.FailClosed:
+		return false
+	}
+	if inUse >= limit {
+		return false
+	}
+	gauge.local++
+	return true
+}
+
+// Release gives back a resource of the quota of the key taken by Acquire.
+func (l *SharedLimits) Release(key string) {
+	state := l.state
+	key = l.scope + "/" + key
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if gauge, ok := state.gauges[key]; ok && gauge.local > 0 {
+		gauge.local--
+	}
+}
@@ -0,0 +1,116 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSharedStates returns the shared states of two instances that share a Redis.
+func newSharedStates(t *testing.T, failureMode config.FailureMode) (*miniredis.Miniredis, *SharedState, *SharedState) {
+	t.Helper()
+	redis := miniredis.RunT(t)
+	cfg := config.SharedLimits{Address: redis.Addr(), FailureMode: string(failureMode)}
+	first := NewSharedState(NewRedisStore(cfg), cfg, zerolog.Nop())
+	second := NewSharedState(NewRedisStore(cfg), cfg, zerolog.Nop())
+	t.Cleanup(func() {
+		first.Stop()
+		second.Stop()
+	})
+	return redis, first, second
+}
+
+// TestSharedLimitsQuota tests that the connection quota is shared by the instances.
+func TestSharedLimitsQuota(t *testing.T) {
+	_, first, second := newSharedStates(t, config.FailOpen)
+	ctx := context.Background()
+
+	assert.True(t, first.Limits("default").Acquire("connections", 2))
+	require.Nil(t, first.Sync(ctx))
+	require.Nil(t, second.Sync(ctx))
+	assert.True(t, second.Limits("default").Acquire("connections", 2))
+	require.Nil(t, second.Sync(ctx))
+	require.Nil(t, first.Sync(ctx))
+
+	assert.False(t, first.Limits("default").Acquire("connections", 2))
+	assert.False(t, second.Limits("default").Acquire("connections", 2))
+	// The quotas of the config groups are separate.
+	assert.True(t, first.Limits("other").Acquire("connections", 2))
+
+	second.Limits("default").Release("connections")
+	require.Nil(t, second.Sync(ctx))
+	require.Nil(t, first.Sync(ctx))
+	assert.True(t, first.Limits("default").Acquire("connections", 2))
+}
+
+// TestSharedLimitsConsume tests that the usage of the windows is added up across the
+// instances.
+func TestSharedLimitsConsume(t *testing.T) {
+	_, first, second := newSharedStates(t, config.FailOpen)
+	ctx := context.Background()
+	window := time.Hour
+
+	used, ok := first.Limits("default").Consume("bandwidth", 100, window)
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), used)
+	used, _ = second.Limits("default").Consume("bandwidth", 50, window)
+	assert.Equal(t, int64(50), used)
+
+	require.Nil(t, first.Sync(ctx))
+	require.Nil(t, second.Sync(ctx))
+	require.Nil(t, first.Sync(ctx))
+	used, _ = first.Limits("default").Consume("bandwidth", 10, window)
+	assert.Equal(t, int64(160), used)
+}
+
+// TestSharedLimitsFailureMode tests that the limits fall back to the usage of the
+// instance, or fail closed, while Redis is unreachable.
+func TestSharedLimitsFailureMode(t *testing.T) {
+	ctx := context.Background()
+
+	redis, open, _ := newSharedStates(t, config.FailOpen)
+	assert.True(t, open.Limits("default").Acquire("connections", 1))
+	redis.Close()
+	assert.NotNil(t, open.Sync(ctx))
+	assert.False(t, open.Available())
+	assert.False(t, open.Limits("default").Acquire("connections", 1))
+	open.Limits("default").Release("connections")
+	assert.True(t, open.Limits("default").Acquire("connections", 1))
+	used, ok := open.Limits("default").Consume("bandwidth", 10, time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, int64(10), used)
+
+	redis, closed, _ := newSharedStates(t, config.FailClosed)
+	assert.True(t, closed.Limits("default").Acquire("connections", 2))
+	redis.Close()
+	assert.NotNil(t, closed.Sync(ctx))
+	assert.False(t, closed.Limits("default").Acquire("connections", 2))
+	_, ok = closed.Limits("default").Consume("bandwidth", 10, time.Hour)
+	assert.False(t, ok)
+}
+
+func TestSharedThrottler(t *testing.T) {
+	_, state, _ := newSharedStates(t, config.FailOpen)
+	throttler := NewThrottler(100, 0, 0, false)
+	throttler.Shared = state.Limits("default")
+
+	assert.Zero(t, throttler.sharedDelay("ingress/", 100, 100))
+	delay := throttler.sharedDelay("ingress/", 100, 50)
+	assert.InDelta(t, 500*time.Millisecond, delay, float64(10*time.Millisecond))
+}
+
+func TestProxyConnectionQuota(t *testing.T) {
+	proxy := &Proxy{MaxConnections: 1}
+	assert.True(t, proxy.acquireConnection())
+	assert.False(t, proxy.acquireConnection())
+	proxy.releaseConnection()
+	assert.True(t, proxy.acquireConnection())
+
+	assert.True(t, (&Proxy{}).acquireConnection())
+}
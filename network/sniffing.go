@@ -0,0 +1,166 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+)
+
+type Protocol string
+
+const (
+	PostgreSQL      Protocol = "postgresql"
+	MySQL           Protocol = "mysql"
+	HTTP            Protocol = "http"
+	UnknownProtocol Protocol = "unknown"
+)
+
+// sniffLength is the length of the first bytes the protocols are detected from, which is
+// the length of the startup, SSL, GSSENC and cancel requests of PostgreSQL.
+const sniffLength = 8
+
+// httpPrefixes are the beginnings of the HTTP/1 requests and of the HTTP/2 preface.
+var httpPrefixes = [][]byte{
+	[]byte("GET "), []byte("HEAD "), []byte("POST "), []byte("PUT "), []byte("DELETE "),
+	[]byte("OPTIONS "), []byte("PATCH "), []byte("CONNECT "), []byte("TRACE "), []byte("PRI * "),
+}
+
+// Sniffer detects the protocol of the connections of a server from their first bytes,
+// and forwards the ones that aren't PostgreSQL to the address of their protocol.
+type Sniffer struct {
+	Timeout   time.Duration
+	Addresses map[Protocol]string
+}
+
+// NewSniffer returns the sniffer of the config, or nil if it is disabled.
+func NewSniffer(cfg config.Sniffing) *Sniffer {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &Sniffer{
+		Timeout: config.If[time.Duration](cfg.Timeout > 0, cfg.Timeout, config.DefaultSniffingTimeout),
+		Addresses: map[Protocol]string{
+			MySQL: cfg.MySQL,
+			HTTP:  cfg.HTTP,
+		},
+	}
+}
+
+// DetectProtocol returns the protocol of a connection from its first bytes. The MySQL
+// clients send nothing until the server greets them.
+//
+//nolint:gomnd
+func DetectProtocol(prefix []byte) Protocol {
+	if len(prefix) == 0 {
+		return MySQL
+	}
+	for _, httpPrefix := range httpPrefixes {
+		if bytes.HasPrefix(prefix, httpPrefix) {
+			return HTTP
+		}
+	}
+	if len(prefix) >= sniffLength {
+		switch binary.BigEndian.Uint32(prefix[4:8]) {
+		case 196608, 80877102, 80877103, 80877104:
+			return PostgreSQL
+		}
+	}
+	return UnknownProtocol
+}
+
+// sniffedConn is a connection whose first bytes were already read, which are read again
+// before the rest.
+type sniffedConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c *sniffedConn) Read(data []byte) (int, error) {
+	return c.reader.Read(data) //nolint:wrapcheck
+}
+
+// sniff reads the first bytes of the connection to detect its protocol, and returns the
+// connection if it is PostgreSQL. The others are forwarded to the address of their
+// protocol, or closed, and nil is returned.
+func (s *Server) sniff(netConn net.Conn) net.Conn {
+	prefix := make([]byte, sniffLength)
+	read := 0
+	if err := netConn.SetReadDeadline(time.Now().Add(s.Sniffer.Timeout)); err == nil {
+		// The first bytes can arrive in pieces, until the timeout.
+		for read < sniffLength {
+			count, err := netConn.Read(prefix[read:])
+			read += count
+			if err != nil {
+				if !errors.Is(err, os.ErrDeadlineExceeded) {
+					s.logger.Debug().Err(err).Msg("Failed to read the first bytes of the connection")
+					netConn.Close()
+					return nil
+				}
+				break
+			}
+		}
+		//nolint:errcheck
+		netConn.SetReadDeadline(time.Time{})
+	}
+	prefix = prefix[:read]
+
+	protocol := DetectProtocol(prefix)
+	metrics.ServerSniffedConnections.WithLabelValues(string(protocol)).Inc()
+	if protocol == PostgreSQL {
+		return &sniffedConn{Conn: netConn, reader: io.MultiReader(bytes.NewReader(prefix), netConn)}
+	}
+
+	logger := s.logger.With().Str("protocol", string(protocol)).Str(
+		"remote", RemoteAddr(netConn)).Logger()
+	address := s.Sniffer.Addresses[protocol]
+	if address == "" {
+		logger.Debug().Msg("Closing the connection, as its protocol isn't served")
+		netConn.Close()
+		return nil
+	}
+
+	target, err := net.DialTimeout("tcp", address, config.DefaultDialTimeout)
+	if err != nil {
+		logger.Error().Err(err).Str("address", address).Msg("Failed to forward the connection")
+		netConn.Close()
+		return nil
+	}
+	logger.Debug().Str("address", address).Msg("Forwarding the connection")
+	s.forward(netConn, target, prefix)
+	return nil
+}
+
+// forward relays the connection to the target, starting with its first bytes, until
+// either side closes its connection.
+func (s *Server) forward(netConn, target net.Conn, prefix []byte) {
+	var once sync.Once
+	closeBoth := func() {
+		once.Do(func() {
+			netConn.Close()
+			target.Close()
+		})
+	}
+	defer closeBoth()
+
+	if _, err := target.Write(prefix); err != nil {
+		return
+	}
+
+	done := make(chan struct{})
+	s.spawn(func() {
+		defer close(done)
+		io.Copy(netConn, target) //nolint:errcheck
+		closeBoth()
+	})
+	io.Copy(target, netConn) //nolint:errcheck
+	closeBoth()
+	<-done
+}
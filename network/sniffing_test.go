@@ -0,0 +1,85 @@
+package network
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectProtocol(t *testing.T) {
+	sslRequest := make([]byte, 8)
+	binary.BigEndian.PutUint32(sslRequest[0:4], 8)
+	binary.BigEndian.PutUint32(sslRequest[4:8], 80877103)
+
+	assert.Equal(t, PostgreSQL, DetectProtocol(sslRequest))
+	assert.Equal(t, MySQL, DetectProtocol(nil))
+	assert.Equal(t, HTTP, DetectProtocol([]byte("GET / HT")))
+	assert.Equal(t, HTTP, DetectProtocol([]byte("PRI * HT")))
+	assert.Equal(t, UnknownProtocol, DetectProtocol([]byte{0x16, 0x03, 0x01}))
+	assert.Nil(t, NewSniffer(config.Sniffing{}))
+}
+
+// TestSniff tests that the PostgreSQL connections are served with their first bytes,
+// that the HTTP ones are forwarded to their address, and that the connections whose
+// protocol has no address are closed.
+func TestSniff(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer target.Close()
+	go func() {
+		conn, err := target.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn) //nolint:errcheck
+	}()
+
+	server := &Server{
+		logger: zerolog.Nop(),
+		Sniffer: NewSniffer(config.Sniffing{
+			Enabled: true, Timeout: 50 * time.Millisecond, HTTP: target.Addr().String(),
+		}),
+	}
+
+	// PostgreSQL
+	client, netConn := net.Pipe()
+	request := PostgreSQLQuery("SELECT 1")
+	startup := make([]byte, 8)
+	binary.BigEndian.PutUint32(startup[0:4], 8)
+	binary.BigEndian.PutUint32(startup[4:8], 196608)
+	go client.Write(append(startup, request...)) //nolint:errcheck
+	sniffed := server.sniff(netConn)
+	require.NotNil(t, sniffed)
+	received := make([]byte, len(startup)+len(request))
+	_, err = io.ReadFull(sniffed, received)
+	require.NoError(t, err)
+	assert.Equal(t, append(startup, request...), received)
+	client.Close()
+
+	// HTTP
+	client, netConn = net.Pipe()
+	forwarded := make(chan net.Conn)
+	go func() { forwarded <- server.sniff(netConn) }()
+	_, err = client.Write([]byte("GET / HTTP/1.1\r\n\r\n"))
+	require.NoError(t, err)
+	echoed := make([]byte, len("GET / HTTP/1.1\r\n\r\n"))
+	_, err = io.ReadFull(client, echoed)
+	require.NoError(t, err)
+	assert.Equal(t, "GET / HTTP/1.1\r\n\r\n", string(echoed))
+	client.Close()
+	assert.Nil(t, <-forwarded)
+
+	// MySQL
+	client, netConn = net.Pipe()
+	assert.Nil(t, server.sniff(netConn))
+	_, err = client.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
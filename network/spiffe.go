@@ -0,0 +1,389 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// SPIFFEEndpointSocketEnv is the environment variable of the Workload API socket.
+	SPIFFEEndpointSocketEnv = "SPIFFE_ENDPOINT_SOCKET"
+	// fetchX509SVIDMethod is the Workload API method that streams the X.509 SVIDs.
+	fetchX509SVIDMethod = "/SpiffeWorkloadAPI/FetchX509SVID"
+	// spiffeRetryBackoff is how long to wait before watching the SVIDs again, and
+	// spiffeMaxRetryBackoff is the maximum it grows to.
+	spiffeRetryBackoff    = time.Second
+	spiffeMaxRetryBackoff = 30 * time.Second
+)
+
+// x509Sources holds the X.509 sources by their socket, so that the servers and clients
+// share one stream of the Workload API.
+var x509Sources sync.Map
+
+// X509SVID is the X.509 SPIFFE verifiable identity document of the workload, along
+// with the bundle of the trust domain to verify its peers with.
+type X509SVID struct {
+	ID          string
+	Certificate tls.Certificate
+	Bundle      *x509.CertPool
+}
+
+// X509Source gets the X.509 SVIDs of the workload from the SPIFFE Workload API, e.g.
+// of the SPIRE agent, which pushes the new ones before the current ones expire. The
+// TLS configs of the source always use the latest SVID, so the certificates are rotated
+// without a restart.
+type X509Source struct {
+	socketPath string
+	logger     zerolog.Logger
+	svid       atomic.Pointer[X509SVID]
+	ready      chan struct{}
+	readyOnce  sync.Once
+	startOnce  sync.Once
+}
+
+// GetX509Source returns the X.509 source of the Workload API socket, which is created
+// once and then shared. The socket defaults to the SPIFFE_ENDPOINT_SOCKET environment
+// variable, and then to the default socket of the SPIRE agent.
+func GetX509Source(socketPath string, logger zerolog.Logger) *X509Source {
+	if socketPath == "" {
+		socketPath = os.Getenv(SPIFFEEndpointSocketEnv)
+	}
+	if socketPath == "" {
+		socketPath = config.DefaultSPIFFEEndpointSocket
+	}
+
+	source, _ := x509Sources.LoadOrStore(socketPath, &X509Source{
+		socketPath: socketPath,
+		logger:     logger,
+		ready:      make(chan struct{}),
+	})
+	x509Source, _ := source.(*X509Source)
+	x509Source.startOnce.Do(func() {
+		go x509Source.run()
+	})
+	return x509Source
+}
+
+// SVID returns the current SVID, and waits for the first one until the context is done.
+func (s *X509Source) SVID(ctx context.Context) (*X509SVID, *gerr.GatewayDError) {
+	select {
+	case <-s.ready:
+		return s.svid.Load(), nil
+	case <-ctx.Done():
+		return nil, gerr.ErrSPIFFEFailed.Wrap(
+			fmt.Errorf("no SVID from the Workload API at %s: %w", s.socketPath, ctx.Err()))
+	}
+}
+
+// ServerTLSConfig returns the TLS config of the listeners, which requires the clients
+// to present an SVID of the trust domain, with one of the authorized IDs if any.
+func (s *X509Source) ServerTLSConfig(authorizedIDs []string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			svid, err := s.SVID(hello.Context())
+			if err != nil {
+				return nil, err
+			}
+			return &svid.Certificate, nil
+		},
+		ClientAuth: tls.RequireAnyClientCert,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return s.verifyPeer(rawCerts, authorizedIDs)
+		},
+	}
+}
+
+// ClientTLSConfig returns the TLS config of the connections to the servers, which
+// presents the SVID of the workload and requires the server to present an SVID of
+// the trust domain, with the given ID if any.
+func (s *X509Source) ClientTLSConfig(serverID string) *tls.Config {
+	var authorizedIDs []string
+	if serverID != "" {
+		authorizedIDs = []string{serverID}
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			svid, err := s.SVID(info.Context())
+			if err != nil {
+				return nil, err
+			}
+			return &svid.Certificate, nil
+		},
+		// The server is verified by its SVID instead of its host name.
+		InsecureSkipVerify: true, //nolint:gosec
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return s.verifyPeer(rawCerts, authorizedIDs)
+		},
+	}
+}
+
+// verifyPeer verifies that the certificates of the peer are an SVID signed by the
+// bundle of the trust domain, with one of the authorized IDs if any.
+func (s *X509Source) verifyPeer(rawCerts [][]byte, authorizedIDs []string) error {
+	svid := s.svid.Load()
+	if svid == nil {
+		return errors.New("no SVID bundle to verify the peer with")
+	}
+	if len(rawCerts) == 0 {
+		return errors.New("the peer presented no certificate")
+	}
+
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		certs = append(certs, cert)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         svid.Bundle,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	id, err := spiffeID(certs[0])
+	if err != nil {
+		return err
+	}
+	if len(authorizedIDs) == 0 {
+		return nil
+	}
+	for _, authorizedID := range authorizedIDs {
+		if id == authorizedID {
+			return nil
+		}
+	}
+	return fmt.Errorf("SPIFFE ID %q is not authorized", id)
+}
+
+// spiffeID returns the SPIFFE ID of the SVID, which is its only URI SAN.
+func spiffeID(cert *x509.Certificate) (string, error) {
+	if len(cert.URIs) != 1 || cert.URIs[0].Scheme != "spiffe" {
+		return "", errors.New("the certificate is not an SVID")
+	}
+	return cert.URIs[0].String(), nil
+}
+
+// run watches the SVIDs of the Workload API, and watches them again if the stream
+// breaks, e.g. because the agent restarted.
+func (s *X509Source) run() {
+	backoff := spiffeRetryBackoff
+	for {
+		received, err := s.watch()
+		if received {
+			backoff = spiffeRetryBackoff
+		}
+		s.logger.Warn().Err(err).Str("socket", s.socketPath).Msg(
+			"Failed to watch the SVIDs of the Workload API, retrying")
+		time.Sleep(backoff)
+		backoff = min(backoff*2, spiffeMaxRetryBackoff) //nolint:gomnd
+	}
+}
+
+// watch receives the SVIDs from the Workload API until the stream breaks, and
+// reports whether it received any.
+func (s *X509Source) watch() (bool, error) {
+	var received bool
+	// gRPC dials the unix:// targets as is, and the others by their address.
+	target := strings.TrimPrefix(s.socketPath, "tcp://")
+
+	conn, err := grpc.Dial(
+		target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(rawCodec{})),
+	)
+	if err != nil {
+		return received, err //nolint:wrapcheck
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The Workload API requires this header, so that it isn't called by accident.
+	ctx = metadata.AppendToOutgoingContext(ctx, "workload.spiffe.io", "true")
+
+	stream, err := conn.NewStream(
+		ctx, &grpc.StreamDesc{ServerStreams: true}, fetchX509SVIDMethod)
+	if err != nil {
+		return received, err //nolint:wrapcheck
+	}
+	// The request is empty.
+	if err := stream.SendMsg(&[]byte{}); err != nil {
+		return received, err //nolint:wrapcheck
+	}
+	if err := stream.CloseSend(); err != nil {
+		return received, err //nolint:wrapcheck
+	}
+
+	for {
+		var response []byte
+		if err := stream.RecvMsg(&response); err != nil {
+			return received, err //nolint:wrapcheck
+		}
+
+		svid, err := ParseX509SVIDResponse(response)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to parse the SVID of the Workload API")
+			continue
+		}
+		s.svid.Store(svid)
+		received = true
+		s.readyOnce.Do(func() { close(s.ready) })
+		metrics.SPIFFESVIDUpdates.Inc()
+		s.logger.Info().Str("id", svid.ID).Time(
+			"expiry", svid.Certificate.Leaf.NotAfter).Msg("Received the SVID of the workload")
+	}
+}
+
+// ParseX509SVIDResponse parses the first, i.e. default, SVID of an X509SVIDResponse
+// message of the Workload API:
+// https://github.com/spiffe/spiffe/blob/main/standards/SPIFFE_Workload_API.md
+func ParseX509SVIDResponse(response []byte) (*X509SVID, error) {
+	// X509SVIDResponse: repeated X509SVID svids = 1.
+	var svidMessage []byte
+	if err := parseProtoFields(response, func(number protowire.Number, value []byte) {
+		if number == 1 && svidMessage == nil {
+			svidMessage = value
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if svidMessage == nil {
+		return nil, errors.New("the response has no SVID")
+	}
+
+	// X509SVID: spiffe_id = 1, x509_svid = 2, x509_svid_key = 3 and bundle = 4.
+	var id string
+	var chain, key, bundle []byte
+	if err := parseProtoFields(svidMessage, func(number protowire.Number, value []byte) {
+		switch number {
+		case 1:
+			id = string(value)
+		case 2:
+			chain = value
+		case 3:
+			key = value
+		case 4:
+			bundle = value
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	certs, err := x509.ParseCertificates(chain)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("the SVID has no certificate")
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	roots, err := x509.ParseCertificates(bundle)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if certID, err := spiffeID(certs[0]); err != nil || certID != id {
+		return nil, fmt.Errorf("the certificate doesn't match the SPIFFE ID %q", id)
+	}
+
+	svid := &X509SVID{
+		ID: id,
+		Certificate: tls.Certificate{
+			PrivateKey: privateKey,
+			Leaf:       certs[0],
+		},
+		Bundle: x509.NewCertPool(),
+	}
+	for _, cert := range certs {
+		svid.Certificate.Certificate = append(svid.Certificate.Certificate, cert.Raw)
+	}
+	for _, root := range roots {
+		svid.Bundle.AddCert(root)
+	}
+	return svid, nil
+}
+
+// parseProtoFields calls the function with the length-delimited fields of the
+// protobuf message, i.e. the strings, bytes and messages, and skips the others.
+func parseProtoFields(message []byte, field func(protowire.Number, []byte)) error {
+	for len(message) > 0 {
+		number, typ, length := protowire.ConsumeTag(message)
+		if length < 0 {
+			return protowire.ParseError(length)
+		}
+		message = message[length:]
+
+		if typ == protowire.BytesType {
+			value, length := protowire.ConsumeBytes(message)
+			if length < 0 {
+				return protowire.ParseError(length)
+			}
+			field(number, value)
+			message = message[length:]
+			continue
+		}
+
+		length = protowire.ConsumeFieldValue(number, typ, message)
+		if length < 0 {
+			return protowire.ParseError(length)
+		}
+		message = message[length:]
+	}
+	return nil
+}
+
+// rawCodec passes the protobuf messages as is, since only a few fields of them are
+// needed, which are parsed with protowire.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	data, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type: %T", v)
+	}
+	return *data, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	message, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type: %T", v)
+	}
+	*message = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,287 @@
+package network
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// spiffeCA issues the SVIDs of a trust domain, for testing.
+type spiffeCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newSPIFFECA(t *testing.T) *spiffeCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		URIs:                  []*url.URL{{Scheme: "spiffe", Host: "example.org"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &spiffeCA{cert: cert, key: key}
+}
+
+// response returns an X509SVIDResponse with an SVID of the ID.
+func (ca *spiffeCA) response(t *testing.T, id string, serial int64) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	uri, err := url.Parse(id)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		URIs:         []*url.URL{uri},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	var svid []byte
+	svid = protowire.AppendTag(svid, 1, protowire.BytesType)
+	svid = protowire.AppendString(svid, id)
+	svid = protowire.AppendTag(svid, 2, protowire.BytesType)
+	svid = protowire.AppendBytes(svid, der)
+	svid = protowire.AppendTag(svid, 3, protowire.BytesType)
+	svid = protowire.AppendBytes(svid, keyDER)
+	svid = protowire.AppendTag(svid, 4, protowire.BytesType)
+	svid = protowire.AppendBytes(svid, ca.cert.Raw)
+
+	var response []byte
+	response = protowire.AppendTag(response, 1, protowire.BytesType)
+	return protowire.AppendBytes(response, svid)
+}
+
+// startWorkloadAPI starts a Workload API on a unix socket, which streams the
+// responses sent to the channel.
+func startWorkloadAPI(t *testing.T, responses <-chan []byte) string {
+	t.Helper()
+
+	// The unix socket paths are limited in length, so the test name isn't used.
+	directory, err := os.MkdirTemp("", "spiffe")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(directory) })
+	socketPath := filepath.Join(directory, "api.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	server := grpc.NewServer(
+		grpc.ForceServerCodec(rawCodec{}),
+		grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			requestMetadata, _ := metadata.FromIncomingContext(stream.Context())
+			if method != fetchX509SVIDMethod ||
+				len(requestMetadata.Get("workload.spiffe.io")) == 0 {
+				return io.EOF
+			}
+			var request []byte
+			if err := stream.RecvMsg(&request); err != nil {
+				return err //nolint:wrapcheck
+			}
+			for {
+				select {
+				case response := <-responses:
+					if err := stream.SendMsg(&response); err != nil {
+						return err //nolint:wrapcheck
+					}
+				case <-stream.Context().Done():
+					return nil
+				}
+			}
+		}),
+	)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return "unix://" + socketPath
+}
+
+// handshake performs a TLS handshake between the server and the client configs.
+func handshake(serverConfig, clientConfig *tls.Config) (error, error) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		tlsConn := tls.Server(serverConn, serverConfig)
+		err := tlsConn.Handshake()
+		if err == nil {
+			// The client verifies the server after sending its certificate, so the
+			// handshake is only over for the client once it reads the server's data.
+			_, err = tlsConn.Write([]byte{0})
+		}
+		serverConn.Close()
+		serverErr <- err
+	}()
+	tlsConn := tls.Client(clientConn, clientConfig)
+	clientErr := tlsConn.Handshake()
+	if clientErr == nil {
+		_, clientErr = tlsConn.Read(make([]byte, 1))
+	}
+	clientConn.Close()
+	return <-serverErr, clientErr
+}
+
+// TestParseX509SVIDResponse tests that the SVID and the bundle are parsed from the
+// response, and that an SVID that doesn't match its ID is rejected.
+func TestParseX509SVIDResponse(t *testing.T) {
+	ca := newSPIFFECA(t)
+
+	svid, err := ParseX509SVIDResponse(ca.response(t, "spiffe://example.org/gatewayd", 2))
+	require.NoError(t, err)
+	assert.Equal(t, "spiffe://example.org/gatewayd", svid.ID)
+	assert.Equal(t, int64(2), svid.Certificate.Leaf.SerialNumber.Int64())
+	_, err = svid.Certificate.Leaf.Verify(x509.VerifyOptions{
+		Roots:     svid.Bundle,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	assert.NoError(t, err)
+
+	_, err = ParseX509SVIDResponse(nil)
+	assert.Error(t, err)
+
+	response := ca.response(t, "spiffe://example.org/gatewayd", 2)
+	svidMessage, _ := protowire.ConsumeBytes(response[1:])
+	// Replace the ID of the SVID, which is its first field.
+	_, _, length := protowire.ConsumeField(svidMessage)
+	forged := protowire.AppendTag(nil, 1, protowire.BytesType)
+	forged = protowire.AppendString(forged, "spiffe://example.org/admin")
+	forged = append(forged, svidMessage[length:]...)
+	_, err = ParseX509SVIDResponse(protowire.AppendBytes(
+		protowire.AppendTag(nil, 1, protowire.BytesType), forged))
+	assert.Error(t, err)
+}
+
+// TestX509Source tests that the SVIDs are received from the Workload API and rotated,
+// and that the TLS configs only accept the authorized SVIDs.
+func TestX509Source(t *testing.T) {
+	ca := newSPIFFECA(t)
+	responses := make(chan []byte, 1)
+	socketPath := startWorkloadAPI(t, responses)
+
+	source := GetX509Source(socketPath, zerolog.Nop())
+	assert.Same(t, source, GetX509Source(socketPath, zerolog.Nop()))
+
+	responses <- ca.response(t, "spiffe://example.org/gatewayd", 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	svid, gErr := source.SVID(ctx)
+	require.Nil(t, gErr)
+	assert.Equal(t, "spiffe://example.org/gatewayd", svid.ID)
+
+	// The workload talks to itself, so its own ID is the one authorized.
+	serverErr, clientErr := handshake(
+		source.ServerTLSConfig([]string{"spiffe://example.org/gatewayd"}),
+		source.ClientTLSConfig("spiffe://example.org/gatewayd"))
+	assert.NoError(t, serverErr)
+	assert.NoError(t, clientErr)
+
+	serverErr, _ = handshake(
+		source.ServerTLSConfig([]string{"spiffe://example.org/other"}),
+		source.ClientTLSConfig(""))
+	assert.Error(t, serverErr)
+
+	_, clientErr = handshake(
+		source.ServerTLSConfig(nil),
+		source.ClientTLSConfig("spiffe://example.org/postgres"))
+	assert.Error(t, clientErr)
+
+	// The rotated SVID is used right away.
+	responses <- ca.response(t, "spiffe://example.org/gatewayd", 3)
+	assert.Eventually(t, func() bool {
+		svid, _ := source.SVID(ctx)
+		return svid.Certificate.Leaf.SerialNumber.Int64() == 3
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// TestX509Source_Unavailable tests that there is no SVID without a Workload API.
+func TestX509Source_Unavailable(t *testing.T) {
+	source := GetX509Source("unix:///nonexistent/api.sock", zerolog.Nop())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := source.SVID(ctx)
+	assert.NotNil(t, err)
+}
+
+// TestClient_UpgradeToTLS tests that the connection to the server is upgraded to
+// mTLS with an SSLRequest before it is used.
+func TestClient_UpgradeToTLS(t *testing.T) {
+	ca := newSPIFFECA(t)
+	responses := make(chan []byte, 1)
+	responses <- ca.response(t, "spiffe://example.org/gatewayd", 2)
+	source := GetX509Source(startWorkloadAPI(t, responses), zerolog.Nop())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		request := make([]byte, 8)
+		if _, err := io.ReadFull(conn, request); err != nil || !IsPostgresSSLRequest(request) {
+			return
+		}
+		_, _ = conn.Write([]byte{'S'})
+		tlsConn := tls.Server(conn, source.ServerTLSConfig(nil))
+		_, _ = io.Copy(tlsConn, tlsConn)
+	}()
+
+	client := &Client{
+		Network:     "tcp",
+		Address:     listener.Addr().String(),
+		DialTimeout: 5 * time.Second,
+		TLSConfig:   source.ClientTLSConfig("spiffe://example.org/gatewayd"),
+	}
+	conn, err := client.dial()
+	require.NoError(t, err)
+	defer conn.Close()
+	_, ok := conn.(*tls.Conn)
+	assert.True(t, ok)
+
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	received := make([]byte, 4)
+	_, err = io.ReadFull(conn, received)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(received))
+}
@@ -0,0 +1,23 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"io"
+	"net"
+)
+
+// spliceSupported is true if the kernel can move the bytes between
+// the sockets without copying them to userspace.
+const spliceSupported = true
+
+// spliceConn copies the data from src to dst until EOF. The Go runtime uses
+// splice(2) on Linux when dst is a TCP socket and src is a TCP or Unix socket,
+// so the bytes never reach userspace.
+func spliceConn(dst, src net.Conn) (int64, error) {
+	if tcpConn, ok := dst.(*net.TCPConn); ok {
+		return tcpConn.ReadFrom(src) //nolint:wrapcheck
+	}
+	return io.Copy(dst, src) //nolint:wrapcheck
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package network
+
+import (
+	"io"
+	"net"
+)
+
+// spliceSupported is false, because splice(2) is only available on Linux.
+const spliceSupported = false
+
+// spliceConn copies the data from src to dst until EOF.
+func spliceConn(dst, src net.Conn) (int64, error) {
+	return io.Copy(dst, src) //nolint:wrapcheck
+}
@@ -0,0 +1,214 @@
+package network
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/rs/zerolog"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshTunnels holds the SSH tunnels by their config, so that the clients with the same
+// bastion share one SSH connection.
+var sshTunnels sync.Map
+
+// SSHTunnel tunnels the connections to the database through an SSH bastion. The SSH
+// connection is made on the first dial, shared by all the tunneled connections, and
+// made again once it breaks, which the keepalives detect.
+type SSHTunnel struct {
+	config      config.SSHTunnel
+	dialTimeout time.Duration
+	logger      zerolog.Logger
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// GetSSHTunnel returns the SSH tunnel for the config, which is created once and then
+// shared by all the clients with the same config.
+func GetSSHTunnel(
+	tunnelConfig config.SSHTunnel, dialTimeout time.Duration, logger zerolog.Logger,
+) *SSHTunnel {
+	tunnel, _ := sshTunnels.LoadOrStore(tunnelConfig, &SSHTunnel{
+		config:      tunnelConfig,
+		dialTimeout: dialTimeout,
+		logger:      logger,
+	})
+	sshTunnel, _ := tunnel.(*SSHTunnel)
+	return sshTunnel
+}
+
+// Dial connects to the address through the bastion. If the SSH connection is broken,
+// it is made again once.
+func (t *SSHTunnel) Dial(network, address string) (net.Conn, error) {
+	client, gErr := t.connect()
+	if gErr != nil {
+		return nil, gErr
+	}
+
+	conn, err := client.Dial(network, address)
+	if err != nil {
+		// The SSH connection might be broken, but not closed yet.
+		t.disconnect(client)
+		if client, gErr = t.connect(); gErr != nil {
+			return nil, gErr
+		}
+		if conn, err = client.Dial(network, address); err != nil {
+			return nil, gerr.ErrSSHTunnelFailed.Wrap(err)
+		}
+	}
+
+	return &sshTunnelConn{Conn: conn}, nil
+}
+
+// Close closes the SSH connection, which closes the tunneled connections too.
+func (t *SSHTunnel) Close() {
+	t.mu.Lock()
+	client := t.client
+	t.mu.Unlock()
+
+	if client != nil {
+		t.disconnect(client)
+	}
+}
+
+// connect returns the SSH connection to the bastion, and makes it if there is none.
+func (t *SSHTunnel) connect() (*ssh.Client, *gerr.GatewayDError) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	clientConfig, err := t.clientConfig()
+	if err != nil {
+		return nil, gerr.ErrSSHTunnelFailed.Wrap(err)
+	}
+
+	client, err := ssh.Dial("tcp", t.config.Address, clientConfig)
+	if err != nil {
+		t.logger.Error().Err(err).Str("bastion", t.config.Address).Msg(
+			"Failed to connect to the SSH bastion")
+		return nil, gerr.ErrSSHTunnelFailed.Wrap(err)
+	}
+	t.client = client
+	metrics.SSHTunnelConnects.Inc()
+	t.logger.Debug().Str("bastion", t.config.Address).Msg("Connected to the SSH bastion")
+
+	// Forget the connection once it is closed, so that the next dial makes it again.
+	go func() {
+		_ = client.Wait()
+		t.mu.Lock()
+		if t.client == client {
+			t.client = nil
+		}
+		t.mu.Unlock()
+		t.logger.Debug().Str("bastion", t.config.Address).Msg(
+			"Disconnected from the SSH bastion")
+	}()
+
+	if t.config.KeepAliveInterval > 0 {
+		go t.keepAlive(client)
+	}
+
+	return client, nil
+}
+
+// disconnect closes the SSH connection, if it is still the current one.
+func (t *SSHTunnel) disconnect(client *ssh.Client) {
+	t.mu.Lock()
+	if t.client == client {
+		t.client = nil
+	}
+	t.mu.Unlock()
+
+	_ = client.Close()
+}
+
+// keepAlive sends keepalive requests to the bastion, and closes the SSH connection
+// if they fail, e.g. because the network is down.
+func (t *SSHTunnel) keepAlive(client *ssh.Client) {
+	ticker := time.NewTicker(t.config.KeepAliveInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		t.mu.Lock()
+		current := t.client == client
+		t.mu.Unlock()
+		if !current {
+			return
+		}
+
+		if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+			t.logger.Warn().Err(err).Str("bastion", t.config.Address).Msg(
+				"SSH keepalive failed, reconnecting on the next dial")
+			t.disconnect(client)
+			return
+		}
+	}
+}
+
+// clientConfig returns the config of the SSH connection, which authenticates with the
+// key file and checks the host key of the bastion against the known hosts file.
+func (t *SSHTunnel) clientConfig() (*ssh.ClientConfig, error) {
+	if t.config.Address == "" || t.config.User == "" || t.config.KeyFile == "" {
+		return nil, errors.New("the address, user and key file of the SSH bastion are required")
+	}
+
+	key, err := os.ReadFile(expandHome(t.config.KeyFile))
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	knownHostsFile := t.config.KnownHostsFile
+	if knownHostsFile == "" {
+		knownHostsFile = config.DefaultSSHKnownHostsFile
+	}
+	hostKeyCallback, err := knownhosts.New(expandHome(knownHostsFile))
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return &ssh.ClientConfig{
+		User:            t.config.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         t.dialTimeout,
+	}, nil
+}
+
+// expandHome replaces the leading ~ of the path with the home directory of the user.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// sshTunnelConn is a connection tunneled through SSH. The SSH channels don't support
+// deadlines, so they are ignored, instead of failing the callers that set them.
+type sshTunnelConn struct {
+	net.Conn
+}
+
+func (c *sshTunnelConn) SetDeadline(time.Time) error      { return nil }
+func (c *sshTunnelConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *sshTunnelConn) SetWriteDeadline(time.Time) error { return nil }
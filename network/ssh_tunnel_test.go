@@ -0,0 +1,217 @@
+package network
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshBastion is an SSH server that forwards the direct-tcpip channels, for testing.
+type sshBastion struct {
+	listener net.Listener
+	mu       sync.Mutex
+	conns    []net.Conn
+}
+
+// newSSHBastion starts an SSH bastion that accepts the given client key, and returns
+// the config of a tunnel through it, with the key and known hosts files written to
+// a temporary directory.
+func newSSHBastion(t *testing.T) (*sshBastion, config.SSHTunnel) {
+	t.Helper()
+
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+	clientPublicKey, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	authorizedKey, err := ssh.NewPublicKey(clientPublicKey)
+	require.NoError(t, err)
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(authorizedKey.Marshal()) {
+				return &ssh.Permissions{}, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	bastion := &sshBastion{listener: listener}
+	go bastion.serve(serverConfig)
+	t.Cleanup(func() { listener.Close() })
+
+	directory := t.TempDir()
+	block, err := ssh.MarshalPrivateKey(clientKey, "")
+	require.NoError(t, err)
+	keyFile := filepath.Join(directory, "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600))
+	knownHostsFile := filepath.Join(directory, "known_hosts")
+	require.NoError(t, os.WriteFile(knownHostsFile, []byte(
+		knownhosts.Line([]string{listener.Addr().String()}, hostSigner.PublicKey())+"\n"), 0o600))
+
+	return bastion, config.SSHTunnel{
+		Enabled:        true,
+		Address:        listener.Addr().String(),
+		User:           "gatewayd",
+		KeyFile:        keyFile,
+		KnownHostsFile: knownHostsFile,
+	}
+}
+
+func (b *sshBastion) serve(serverConfig *ssh.ServerConfig) {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		b.mu.Lock()
+		b.conns = append(b.conns, conn)
+		b.mu.Unlock()
+
+		go func() {
+			_, channels, requests, err := ssh.NewServerConn(conn, serverConfig)
+			if err != nil {
+				return
+			}
+			go ssh.DiscardRequests(requests)
+			for newChannel := range channels {
+				var target struct {
+					Host     string
+					Port     uint32
+					OrigHost string
+					OrigPort uint32
+				}
+				if err := ssh.Unmarshal(newChannel.ExtraData(), &target); err != nil {
+					_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+					continue
+				}
+				targetConn, err := net.Dial(
+					"tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+				if err != nil {
+					_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+					continue
+				}
+				channel, channelRequests, err := newChannel.Accept()
+				if err != nil {
+					targetConn.Close()
+					continue
+				}
+				go ssh.DiscardRequests(channelRequests)
+				go func() {
+					_, _ = io.Copy(channel, targetConn)
+					channel.Close()
+				}()
+				go func() {
+					_, _ = io.Copy(targetConn, channel)
+					targetConn.Close()
+				}()
+			}
+		}()
+	}
+}
+
+// disconnect closes the SSH connections of the bastion.
+func (b *sshBastion) disconnect() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, conn := range b.conns {
+		conn.Close()
+	}
+	b.conns = nil
+}
+
+// startEchoServer starts a TCP server that echoes the data it receives.
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// TestSSHTunnel tests that the connections are tunneled through the bastion, share
+// one SSH connection, and that it is made again once it breaks.
+func TestSSHTunnel(t *testing.T) {
+	bastion, tunnelConfig := newSSHBastion(t)
+	echoAddress := startEchoServer(t)
+
+	tunnel := GetSSHTunnel(tunnelConfig, time.Second, zerolog.Nop())
+	defer tunnel.Close()
+	assert.Same(t, tunnel, GetSSHTunnel(tunnelConfig, time.Second, zerolog.Nop()))
+
+	connects := testutil.ToFloat64(metrics.SSHTunnelConnects)
+	echo := func() {
+		conn, err := tunnel.Dial("tcp", echoAddress)
+		require.NoError(t, err)
+		defer conn.Close()
+		// Deadlines are ignored on the tunneled connections.
+		require.NoError(t, conn.SetDeadline(time.Now()))
+
+		_, err = conn.Write([]byte("ping"))
+		require.NoError(t, err)
+		received := make([]byte, 4)
+		_, err = io.ReadFull(conn, received)
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(received))
+	}
+
+	echo()
+	echo()
+	assert.Equal(t, connects+1, testutil.ToFloat64(metrics.SSHTunnelConnects))
+
+	// The SSH connection is made again once it breaks.
+	bastion.disconnect()
+	echo()
+	assert.Equal(t, connects+2, testutil.ToFloat64(metrics.SSHTunnelConnects))
+}
+
+// TestSSHTunnel_InvalidConfig tests that the tunnel fails without the required
+// config, or if the host key of the bastion is unknown.
+func TestSSHTunnel_InvalidConfig(t *testing.T) {
+	_, tunnelConfig := newSSHBastion(t)
+
+	missingUser := tunnelConfig
+	missingUser.User = ""
+	_, err := GetSSHTunnel(missingUser, time.Second, zerolog.Nop()).Dial("tcp", "127.0.0.1:5432")
+	assert.Error(t, err)
+
+	unknownHost := tunnelConfig
+	unknownHost.KnownHostsFile = filepath.Join(t.TempDir(), "known_hosts")
+	require.NoError(t, os.WriteFile(unknownHost.KnownHostsFile, nil, 0o600))
+	_, err = GetSSHTunnel(unknownHost, time.Second, zerolog.Nop()).Dial("tcp", "127.0.0.1:5432")
+	assert.Error(t, err)
+}
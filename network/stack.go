@@ -1,9 +1,18 @@
 package network
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 type Request struct {
-	Data []byte
+	Data    []byte
+	QueryID string
+	// Time is when the request was received from the client.
+	Time time.Time
+	// Retried is true once the request was sent again to another server, after the
+	// one it was sent to died.
+	Retried bool
 }
 
 type Stack struct {
@@ -0,0 +1,455 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+)
+
+// StatementCache caches the named prepared statements on the server connections, and
+// prepares the identical statements a session prepares under different names once, on
+// a statement of the server connection named by the cache, which saves the server from
+// parsing them again, e.g. for the ORMs that prepare a statement per query. The
+// statements live as long as their server connection, which is reconnected once its
+// client disconnects.
+//
+// The gateway answers the Parse messages of the statements already prepared, so it
+// only does so for the Parse messages that start a request ending with a Sync message,
+// sent once the responses of the previous requests are received, whose ParseComplete
+// messages start the response. The Bind, Describe and Close messages of the statements
+// use their cached names in all the requests.
+type StatementCache struct {
+	// MaxStatements is the maximum number of statements cached on each server
+	// connection. The statements beyond it are prepared under the names of the client.
+	MaxStatements int
+
+	// servers holds the statements of each server connection, by client.
+	servers sync.Map
+}
+
+// NewStatementCache returns the statement cache of the config, or nil if it is
+// disabled.
+func NewStatementCache(cfg config.StatementCache) *StatementCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &StatementCache{
+		MaxStatements: config.If[int](
+			cfg.MaxStatements > 0, cfg.MaxStatements, config.DefaultMaxCachedStatements),
+	}
+}
+
+// prepareStatements rewrites the request of the connection to prepare its statements
+// on the statements cached on the server connection of the client. It returns the
+// request to send to the server, or nil if it is unchanged, or the response to send to
+// the client instead.
+func (pr *Proxy) prepareStatements(conn *ConnWrapper, client *Client, request []byte) ([]byte, []byte) {
+	if pr.StatementCache == nil || IsPostgresSSLRequest(request) ||
+		PostgresStartupParameters(request) != nil {
+		return nil, nil
+	}
+
+	status := byte('I')
+	if value, ok := pr.sessions.Load(conn); ok {
+		if last := value.(*session).status.Load(); last != 0 { //nolint:forcetypeassert
+			status = byte(last)
+		}
+	}
+	return pr.StatementCache.Rewrite(client, request, status)
+}
+
+// cachedStatement is a statement prepared on a server connection.
+type cachedStatement struct {
+	// key is the query and the parameter types of the statement.
+	key  string
+	name string
+	// prepared is true once the server parsed the statement.
+	prepared bool
+}
+
+// pendingParse is a Parse message of a request whose ParseComplete message is expected.
+type pendingParse struct {
+	// answered is true if the gateway answers the Parse message, as its statement is
+	// already prepared.
+	answered bool
+	// name is the name the client prepares the statement under.
+	name string
+	// statement is the cached statement, or nil if the Parse message is sent as it is.
+	statement *cachedStatement
+}
+
+// serverStatements are the statements cached on a server connection, and the
+// messages of its traffic in flight.
+type serverStatements struct {
+	mu sync.Mutex
+	// statements holds the cached statements by their key, and names holds them by the
+	// names the client prepared them under.
+	statements map[string]*cachedStatement
+	names      map[string]*cachedStatement
+	// next is the number of the name of the next cached statement.
+	next int
+
+	// pending are the Parse messages of the request in flight.
+	pending []pendingParse
+	// inFlight is the number of ReadyForQuery messages expected from the server.
+	inFlight int
+	// split is true once a request didn't end with a whole message, so that the
+	// ReadyForQuery messages expected can't be counted anymore.
+	split bool
+	// header holds the start of the header of the message the last response ended
+	// in, and skip is the length of the rest of its body.
+	header []byte
+	skip   int
+}
+
+// server returns the statements of the server connection of the client.
+func (c *StatementCache) server(client *Client) *serverStatements {
+	value, _ := c.servers.LoadOrStore(client, &serverStatements{
+		statements: map[string]*cachedStatement{},
+		names:      map[string]*cachedStatement{},
+	})
+	return value.(*serverStatements) //nolint:forcetypeassert
+}
+
+// Forget forgets the statements of the server connection of the client, once it is
+// reconnected or closed.
+func (c *StatementCache) Forget(client *Client) {
+	if value, ok := c.servers.LoadAndDelete(client); ok {
+		server := value.(*serverStatements) //nolint:forcetypeassert
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		metrics.ProxyCachedStatements.Sub(float64(len(server.statements)))
+	}
+}
+
+// Rewrite prepares the statements of the request on the cached statements of the
+// server connection of the client. It returns the request to send to the server, or
+// nil if it is unchanged. If the request deallocates a cached statement, it returns
+// the response to send to the client instead, with the transaction status, as the
+// statement stays prepared for the other names and the next sessions.
+//
+//nolint:gomnd
+func (c *StatementCache) Rewrite(client *Client, request []byte, status byte) ([]byte, []byte) {
+	server := c.server(client)
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	// The Parse messages are only cached once the responses of the previous requests
+	// are received, and if the request ends with a Sync message, so that the
+	// ParseComplete messages start its response.
+	messages, partial := postgresMessages(request)
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	if len(partial) > 0 {
+		server.split = true
+	}
+	cacheable := !server.split && server.inFlight == 0 && len(server.pending) == 0 &&
+		messages[len(messages)-1][0] == 'S'
+	if len(messages) == 1 && messages[0][0] == 'Q' && status != 'E' {
+		if response := server.deallocate(messages[0]); response != nil {
+			return nil, append(response, 'Z', 0, 0, 0, 5, status)
+		}
+	}
+
+	var rewritten []byte
+	changed := false
+	leading := true
+	for _, message := range messages {
+		body := message[5:]
+		var replacement []byte
+		switch message[0] {
+		case 'P':
+			name, rest, _ := bytes.Cut(body, []byte{0})
+			if !leading || !cacheable {
+				// The client prepares the statement under its own name.
+				delete(server.names, string(name))
+				break
+			}
+			var answered bool
+			answered, replacement = c.parse(server, string(name), rest)
+			if answered {
+				changed = true
+				continue
+			}
+		case 'B':
+			// The portal comes before the statement.
+			portal, rest, _ := bytes.Cut(body, []byte{0})
+			name, rest, _ := bytes.Cut(rest, []byte{0})
+			if statement, ok := server.names[string(name)]; ok {
+				replacement = postgresMessage('B', portal, []byte{0}, []byte(statement.name), []byte{0}, rest)
+			}
+		case 'D':
+			if len(body) > 1 && body[0] == 'S' {
+				name := bytes.TrimSuffix(body[1:], []byte{0})
+				if statement, ok := server.names[string(name)]; ok {
+					replacement = postgresMessage('D', []byte{'S'}, []byte(statement.name), []byte{0})
+				}
+			}
+		case 'C':
+			// Closing the name of the client is a no-op on the server, which keeps the
+			// statement cached.
+			if len(body) > 1 && body[0] == 'S' {
+				delete(server.names, string(bytes.TrimSuffix(body[1:], []byte{0})))
+			}
+		case 'Q':
+			for _, query := range strings.Split(string(bytes.TrimSuffix(body, []byte{0})), ";") {
+				if deallocatesAll(strings.TrimSpace(query)) {
+					server.reset()
+				}
+			}
+		}
+		if message[0] != 'P' {
+			leading = false
+		}
+		// The Sync, Query and FunctionCall messages are answered with a ReadyForQuery
+		// message.
+		if message[0] == 'S' || message[0] == 'Q' || message[0] == 'F' {
+			server.inFlight++
+		}
+
+		if replacement != nil {
+			changed = true
+			message = replacement
+		}
+		rewritten = append(rewritten, message...)
+	}
+
+	if !changed {
+		return nil, nil
+	}
+	return append(rewritten, partial...), nil
+}
+
+// parse caches the statement of the Parse message of the name, with its query and
+// parameter types. It returns true if the statement is already prepared, or the Parse
+// message to send, if any, otherwise.
+func (c *StatementCache) parse(server *serverStatements, name string, rest []byte) (bool, []byte) {
+	// The unnamed statement only lasts until the next Parse message, and the client
+	// can't prepare a statement under a name it didn't close.
+	_, exists := server.names[name]
+	if name == "" || exists {
+		delete(server.names, name)
+		server.pending = append(server.pending, pendingParse{})
+		return false, nil
+	}
+
+	key := string(rest)
+	if statement, ok := server.statements[key]; ok {
+		server.names[name] = statement
+		server.pending = append(server.pending, pendingParse{
+			answered: true, name: name, statement: statement,
+		})
+		metrics.ProxyStatementCacheHits.Inc()
+		return true, nil
+	}
+	if len(server.statements) >= c.MaxStatements {
+		server.pending = append(server.pending, pendingParse{})
+		return false, nil
+	}
+
+	server.next++
+	statement := &cachedStatement{key: key, name: "gatewayd_" + strconv.Itoa(server.next)}
+	server.statements[key] = statement
+	server.names[name] = statement
+	server.pending = append(server.pending, pendingParse{name: name, statement: statement})
+	metrics.ProxyCachedStatements.Inc()
+	metrics.ProxyStatementCacheMisses.Inc()
+	return false, postgresMessage('P', []byte(statement.name), []byte{0}, rest)
+}
+
+// deallocate returns the CommandComplete message of the query, if it deallocates a
+// statement of the client that is cached, which is forgotten.
+func (s *serverStatements) deallocate(message []byte) []byte {
+	query := strings.TrimSpace(string(bytes.TrimSuffix(message[5:], []byte{0})))
+	query = strings.TrimSpace(strings.TrimSuffix(query, ";"))
+	match := deallocateStatement.FindStringSubmatch(query)
+	if match == nil || match[0] != query {
+		return nil
+	}
+	name := match[1]
+	if unquoted := strings.Trim(name, `"`); unquoted != name {
+		name = unquoted
+	} else {
+		// The unquoted names are case-insensitive.
+		name = strings.ToLower(name)
+	}
+	if _, ok := s.names[name]; !ok {
+		return nil
+	}
+	delete(s.names, name)
+	return postgresMessage('C', []byte("DEALLOCATE"), []byte{0})
+}
+
+// reset forgets the statements, once the server deallocated them.
+func (s *serverStatements) reset() {
+	metrics.ProxyCachedStatements.Sub(float64(len(s.statements)))
+	s.statements = map[string]*cachedStatement{}
+	s.names = map[string]*cachedStatement{}
+}
+
+// forget forgets the statement, once the server failed to parse it.
+func (s *serverStatements) forget(statement *cachedStatement) {
+	if s.statements[statement.key] != statement {
+		return
+	}
+	delete(s.statements, statement.key)
+	metrics.ProxyCachedStatements.Dec()
+	for name, other := range s.names {
+		if other == statement {
+			delete(s.names, name)
+		}
+	}
+}
+
+// Complete adds the ParseComplete messages of the Parse messages the gateway answered
+// to the response of the server connection of the client, and forgets the statements
+// the server failed to parse. It returns the response to send to the client.
+//
+//nolint:gomnd
+func (c *StatementCache) Complete(client *Client, response []byte) []byte {
+	value, ok := c.servers.Load(client)
+	if !ok {
+		return response
+	}
+	server := value.(*serverStatements) //nolint:forcetypeassert
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	var completed []byte
+	copied := 0
+	for offset := 0; offset < len(response); {
+		// Skip the rest of the message the last response ended in.
+		if server.skip > 0 {
+			skipped := min(server.skip, len(response)-offset)
+			server.skip -= skipped
+			offset += skipped
+			continue
+		}
+
+		if len(server.header) == 0 && len(server.pending) > 0 {
+			// A message starts here, so the ParseComplete messages of the Parse
+			// messages the gateway answered go before it.
+			if parseCompletes := server.resolve(response[offset]); parseCompletes > 0 {
+				completed = append(completed, response[copied:offset]...)
+				for range parseCompletes {
+					completed = append(completed, '1', 0, 0, 0, 4)
+				}
+				copied = offset
+			}
+		}
+
+		read := min(5-len(server.header), len(response)-offset)
+		server.header = append(server.header, response[offset:offset+read]...)
+		offset += read
+		if len(server.header) < 5 {
+			break
+		}
+		if server.header[0] == 'Z' && server.inFlight > 0 {
+			server.inFlight--
+		}
+		server.skip = max(int(binary.BigEndian.Uint32(server.header[1:5]))-4, 0)
+		server.header = server.header[:0]
+	}
+
+	// The ParseComplete messages of the Parse messages answered last end the response
+	// if the server has nothing else to send yet.
+	if server.skip > 0 || len(server.header) > 0 {
+		// The response ends in the middle of a message.
+	} else if parseCompletes := server.resolve(0); parseCompletes > 0 {
+		completed = append(completed, response[copied:]...)
+		for range parseCompletes {
+			completed = append(completed, '1', 0, 0, 0, 4)
+		}
+		return completed
+	}
+	if completed == nil {
+		return response
+	}
+	return append(completed, response[copied:]...)
+}
+
+// resolve matches the Parse messages in flight with the message of the type the server
+// sends next, or with no message if the type is 0. It returns the number of
+// ParseComplete messages to send before the message.
+func (s *serverStatements) resolve(messageType byte) int {
+	parseCompletes := 0
+	for len(s.pending) > 0 {
+		parse := s.pending[0]
+		if parse.answered {
+			parseCompletes++
+			s.pending = s.pending[1:]
+			continue
+		}
+
+		switch messageType {
+		case 0, 'N', 'S', 'A':
+			// The asynchronous messages can come before the ParseComplete message.
+			return parseCompletes
+		case '1':
+			if parse.statement != nil {
+				parse.statement.prepared = true
+			}
+			s.pending = s.pending[1:]
+			return parseCompletes
+		default:
+			// The server failed to parse the statement, and skips the rest of the
+			// request until its Sync message.
+			for _, parse := range s.pending {
+				if parse.statement != nil && !parse.statement.prepared {
+					s.forget(parse.statement)
+				}
+				if parse.name != "" && s.names[parse.name] == parse.statement {
+					delete(s.names, parse.name)
+				}
+			}
+			s.pending = nil
+			return parseCompletes
+		}
+	}
+	return parseCompletes
+}
+
+// deallocatesAll returns true if the statement deallocates all the prepared statements.
+func deallocatesAll(statement string) bool {
+	if discardStatement.MatchString(statement) {
+		return true
+	}
+	match := deallocateStatement.FindStringSubmatch(statement)
+	return match != nil && strings.EqualFold(strings.Trim(match[1], `"`), "all")
+}
+
+// postgresMessages splits the request into its messages, and returns the rest of the
+// request from the first incomplete message.
+//
+//nolint:gomnd
+func postgresMessages(request []byte) ([][]byte, []byte) {
+	var messages [][]byte
+	offset := 0
+	for offset+5 <= len(request) {
+		length := int(binary.BigEndian.Uint32(request[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(request) {
+			break
+		}
+		messages = append(messages, request[offset:offset+1+length])
+		offset += 1 + length
+	}
+	return messages, request[offset:]
+}
+
+// postgresMessage returns the message of the type with the parts of its body.
+//
+//nolint:gomnd
+func postgresMessage(messageType byte, parts ...[]byte) []byte {
+	message := []byte{messageType, 0, 0, 0, 0}
+	for _, part := range parts {
+		message = append(message, part...)
+	}
+	binary.BigEndian.PutUint32(message[1:5], uint32(len(message)-1))
+	return message
+}
@@ -0,0 +1,112 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStatementCache tests that the identical statements of a session are prepared once
+// on the server connection, and that the gateway answers the Parse messages of the
+// statements already prepared.
+func TestStatementCache(t *testing.T) {
+	cache := NewStatementCache(config.StatementCache{Enabled: true})
+	require.NotNil(t, cache)
+	client := &Client{}
+
+	parse := func(name, query string) []byte {
+		return CreatePostgreSQLPacket('P', []byte(name+"\x00"+query+"\x00\x00\x00"))
+	}
+	bind := func(name string) []byte {
+		return CreatePostgreSQLPacket('B', []byte("\x00"+name+"\x00\x00\x00\x00\x00\x00\x00"))
+	}
+	describe := func(name string) []byte {
+		return CreatePostgreSQLPacket('D', []byte("S"+name+"\x00"))
+	}
+	join := func(messages ...[]byte) []byte {
+		var joined []byte
+		for _, message := range messages {
+			joined = append(joined, message...)
+		}
+		return joined
+	}
+	sync := []byte{'S', 0, 0, 0, 4}
+	parseComplete := []byte{'1', 0, 0, 0, 4}
+	bindComplete := []byte{'2', 0, 0, 0, 4}
+	noData := []byte{'n', 0, 0, 0, 4}
+	readyForQuery := PostgreSQLReadyForQuery()
+
+	// The first statement is prepared under the name of the cache.
+	request, response := cache.Rewrite(
+		client, join(parse("a1", "SELECT 1"), describe("a1"), sync), 'I')
+	assert.Nil(t, response)
+	assert.Equal(t, join(parse("gatewayd_1", "SELECT 1"), describe("gatewayd_1"), sync), request)
+	assert.Equal(t, join(parseComplete, noData, readyForQuery),
+		cache.Complete(client, join(parseComplete, noData, readyForQuery)))
+
+	// The same statement under another name is answered by the gateway, with the
+	// ParseComplete message split from the rest of the response.
+	request, _ = cache.Rewrite(client, join(parse("a2", "SELECT 1"), bind("a2"), sync), 'I')
+	assert.Equal(t, join(bind("gatewayd_1"), sync), request)
+	assert.Equal(t, join(parseComplete, bindComplete[:2]),
+		cache.Complete(client, bindComplete[:2]))
+	assert.Equal(t, join(bindComplete[2:], readyForQuery),
+		cache.Complete(client, join(bindComplete[2:], readyForQuery)))
+
+	// The statements that fail to parse aren't cached.
+	request, _ = cache.Rewrite(client, join(parse("a3", "SELEC 2"), sync), 'I')
+	assert.Equal(t, join(parse("gatewayd_2", "SELEC 2"), sync), request)
+	failure := CreatePostgreSQLPacket('E', []byte("SERROR\x00C42601\x00\x00"))
+	cache.Complete(client, join(failure, readyForQuery))
+	request, _ = cache.Rewrite(client, join(parse("a4", "SELEC 2"), sync), 'I')
+	assert.Equal(t, join(parse("gatewayd_3", "SELEC 2"), sync), request)
+	// The Parse messages of a request sent before the response of the previous one
+	// aren't answered by the gateway.
+	request, _ = cache.Rewrite(client, join(parse("a5", "SELECT 1"), sync), 'I')
+	assert.Nil(t, request)
+	cache.Complete(client, join(failure, readyForQuery, parseComplete, readyForQuery))
+
+	// The gateway answers the deallocation of a cached statement, which stays cached.
+	request, response = cache.Rewrite(client, PostgreSQLQuery("DEALLOCATE a1"), 'T')
+	assert.Nil(t, request)
+	assert.Equal(t, join(
+		CreatePostgreSQLPacket('C', []byte("DEALLOCATE\x00")), []byte{'Z', 0, 0, 0, 5, 'T'},
+	), response)
+	request, _ = cache.Rewrite(client, join(bind("a1"), sync), 'I')
+	assert.Nil(t, request)
+	cache.Complete(client, join(bindComplete, readyForQuery))
+	request, _ = cache.Rewrite(client, join(parse("a1", "SELECT 1"), sync), 'I')
+	assert.Equal(t, sync, request)
+	assert.Equal(t, join(parseComplete, readyForQuery), cache.Complete(client, readyForQuery))
+
+	// DISCARD ALL deallocates the statements of the server connection.
+	_, response = cache.Rewrite(client, PostgreSQLQuery("DISCARD ALL"), 'I')
+	assert.Nil(t, response)
+	cache.Complete(client, join(CreatePostgreSQLPacket('C', []byte("DISCARD ALL\x00")), readyForQuery))
+	request, _ = cache.Rewrite(client, join(parse("a6", "SELECT 1"), sync), 'I')
+	assert.Equal(t, join(parse("gatewayd_4", "SELECT 1"), sync), request)
+}
+
+// TestStatementCacheMaxStatements tests that the statements beyond the max statements
+// are prepared under the names of the client.
+func TestStatementCacheMaxStatements(t *testing.T) {
+	cache := NewStatementCache(config.StatementCache{Enabled: true, MaxStatements: 1})
+	client := &Client{}
+	sync := []byte{'S', 0, 0, 0, 4}
+	first := CreatePostgreSQLPacket('P', []byte("a1\x00SELECT 1\x00\x00\x00"))
+	second := CreatePostgreSQLPacket('P', []byte("a2\x00SELECT 2\x00\x00\x00"))
+
+	request, _ := cache.Rewrite(client, append(append(first, second...), sync...), 'I')
+	assert.Equal(t, append(append(
+		CreatePostgreSQLPacket('P', []byte("gatewayd_1\x00SELECT 1\x00\x00\x00")), second...), sync...),
+		request)
+
+	cache.Forget(client)
+	request, _ = cache.Rewrite(client, append(second, sync...), 'I')
+	assert.Equal(t, append(
+		CreatePostgreSQLPacket('P', []byte("gatewayd_1\x00SELECT 2\x00\x00\x00")), sync...), request)
+
+	assert.Nil(t, NewStatementCache(config.StatementCache{}))
+}
@@ -0,0 +1,147 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/policy"
+	"github.com/gatewayd-io/gatewayd/sdk"
+)
+
+// StatementTimeoutRule is the statement timeout of the queries its policy matches.
+type StatementTimeoutRule struct {
+	Name    string
+	Policy  *policy.Program
+	Timeout time.Duration
+}
+
+// StatementTimeouts are the timeouts the proxy cancels the queries after, whatever the
+// statement_timeout of the server is.
+type StatementTimeouts struct {
+	Default StatementTimeoutRule
+	Rules   []StatementTimeoutRule
+}
+
+// NewStatementTimeouts compiles the policies of the rules of the statement timeout.
+func NewStatementTimeouts(cfg config.StatementTimeout) (*StatementTimeouts, *gerr.GatewayDError) {
+	if cfg.Timeout < 0 {
+		return nil, gerr.ErrInvalidPolicy.Wrap(
+			fmt.Errorf("invalid statement timeout %s", cfg.Timeout))
+	}
+
+	timeouts := &StatementTimeouts{
+		Default: StatementTimeoutRule{Name: config.DefaultStatementTimeoutRule, Timeout: cfg.Timeout},
+		Rules:   make([]StatementTimeoutRule, 0, len(cfg.Rules)),
+	}
+	for _, rule := range cfg.Rules {
+		if rule.Timeout < 0 {
+			return nil, gerr.ErrInvalidPolicy.Wrap(
+				fmt.Errorf("invalid timeout %s of the statement timeout rule %q", rule.Timeout, rule.Name))
+		}
+		program, err := policy.Compile(rule.When)
+		if err != nil {
+			return nil, err
+		}
+		timeouts.Rules = append(timeouts.Rules, StatementTimeoutRule{
+			Name:    rule.Name,
+			Policy:  program,
+			Timeout: rule.Timeout,
+		})
+	}
+	return timeouts, nil
+}
+
+// statementTimeout returns the statement timeout rule of the request: the first rule
+// whose policy matches a query of the request, or the default one. The requests
+// without the SQL of their queries, e.g. the executions of the prepared statements,
+// are matched by the user and the database of the connection alone.
+func (pr *Proxy) statementTimeout(conn *ConnWrapper, request []byte) *StatementTimeoutRule {
+	queries := PostgresQueries(request)
+	if len(queries) == 0 {
+		queries = []string{""}
+	}
+
+	for index := range pr.StatementTimeouts.Rules {
+		rule := &pr.StatementTimeouts.Rules[index]
+		for _, query := range queries {
+			matches, err := rule.Policy.Matches(pr.policyVars(conn, query))
+			if err != nil {
+				pr.logger.Debug().Err(err).Str("rule", rule.Name).Msg(
+					"Failed to evaluate the statement timeout rule, so it doesn't match")
+			}
+			if matches {
+				return rule
+			}
+		}
+	}
+	return &pr.StatementTimeouts.Default
+}
+
+// startStatementTimeout cancels the query of the request on the server connection if
+// it doesn't end within its statement timeout.
+func (pr *Proxy) startStatementTimeout(
+	conn *ConnWrapper, client *Client, request []byte, correlation Correlation,
+) {
+	if pr.StatementTimeouts == nil || !IsPostgresQuery(request) {
+		return
+	}
+	value, ok := pr.sessions.Load(conn)
+	if !ok {
+		return
+	}
+
+	rule := pr.statementTimeout(conn, request)
+	if rule.Timeout <= 0 {
+		return
+	}
+	value.(*session).startTimeout(rule.Timeout, func() { //nolint:forcetypeassert
+		pr.cancelStatement(conn, client, request, rule, correlation)
+	})
+}
+
+// cancelStatement cancels the query of the request that exceeded the timeout of the
+// rule, and notifies the OnQueryTimeout hooks.
+func (pr *Proxy) cancelStatement(
+	conn *ConnWrapper, client *Client, request []byte, rule *StatementTimeoutRule,
+	correlation Correlation,
+) {
+	logger := correlation.Logger(pr.logger)
+	metrics.ProxyStatementTimeouts.WithLabelValues(rule.Name).Inc()
+
+	cancelled := true
+	if err := client.Cancel(); err != nil {
+		cancelled = false
+		logger.Error().Err(err).Str("rule", rule.Name).Msg(
+			"Failed to cancel the query that exceeded its statement timeout")
+	} else {
+		logger.Warn().Str("rule", rule.Name).Str("timeout", rule.Timeout.String()).Msg(
+			"Cancelled the query that exceeded its statement timeout")
+	}
+
+	if pr.pluginRegistry == nil {
+		return
+	}
+	parameters := pr.startupParameters(conn)
+	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), pr.pluginTimeout)
+	defer cancel()
+	if _, err := pr.pluginRegistry.Run(
+		pluginTimeoutCtx,
+		map[string]interface{}{
+			"connectionId": conn.ID(),
+			"queryId":      correlation.QueryID,
+			"user":         parameters["user"],
+			"database":     parameters["database"],
+			"request":      request,
+			"rule":         rule.Name,
+			"timeout":      rule.Timeout.String(),
+			"cancelled":    cancelled,
+		},
+		sdk.OnQueryTimeout,
+	); err != nil {
+		logger.Error().Err(err).Msg("Failed to run the OnQueryTimeout hooks")
+	}
+}
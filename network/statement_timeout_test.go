@@ -0,0 +1,98 @@
+package network
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStatementTimeoutRules tests that the queries get the timeout of the first rule
+// that matches them, or the default timeout.
+func TestStatementTimeoutRules(t *testing.T) {
+	timeouts, err := NewStatementTimeouts(config.StatementTimeout{
+		Timeout: time.Second,
+		Rules: []config.StatementTimeoutRule{
+			{Name: "reporting", When: `request.user == "reporting"`, Timeout: time.Minute},
+			{Name: "sleep", When: `request.query.contains("pg_sleep")`, Timeout: 0},
+		},
+	})
+	require.Nil(t, err)
+	proxy := &Proxy{StatementTimeouts: timeouts}
+	conn := NewConnWrapper(nil, nil, 0)
+	proxy.parameters.Store(conn, map[string]string{"user": "app"})
+
+	rule := proxy.statementTimeout(conn, PostgreSQLQuery("SELECT 1"))
+	assert.Equal(t, config.DefaultStatementTimeoutRule, rule.Name)
+	assert.Equal(t, time.Second, rule.Timeout)
+	assert.Equal(t, "sleep", proxy.statementTimeout(conn, PostgreSQLQuery("SELECT pg_sleep(5)")).Name)
+
+	proxy.parameters.Store(conn, map[string]string{"user": "reporting"})
+	// The executions of the prepared statements are matched by the connection alone.
+	rule = proxy.statementTimeout(conn, []byte{'E', 0, 0, 0, 9, 0, 0, 0, 0, 0})
+	assert.Equal(t, "reporting", rule.Name)
+	assert.Equal(t, time.Minute, rule.Timeout)
+
+	for _, invalid := range []config.StatementTimeout{
+		{Timeout: -time.Second},
+		{Rules: []config.StatementTimeoutRule{{Name: "negative", When: "true", Timeout: -1}}},
+		{Rules: []config.StatementTimeoutRule{{Name: "invalid", When: "request.user =="}}},
+	} {
+		_, err := NewStatementTimeouts(invalid)
+		assert.NotNil(t, err)
+	}
+}
+
+// TestStatementTimeoutCancel tests that the queries that exceed their statement timeout
+// are cancelled with a cancel request, unless their response ends in time.
+func TestStatementTimeoutCancel(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	cancelRequests := make(chan []byte, 1)
+	go func() {
+		for {
+			server, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			message := make([]byte, 16)
+			if _, err := io.ReadFull(server, message); err == nil {
+				cancelRequests <- message
+			}
+			server.Close()
+		}
+	}()
+
+	timeouts, gErr := NewStatementTimeouts(config.StatementTimeout{Timeout: 50 * time.Millisecond})
+	require.Nil(t, gErr)
+	proxy := &Proxy{StatementTimeouts: timeouts}
+	conn := NewConnWrapper(nil, nil, 0)
+	proxy.sessions.Store(conn, newSession())
+	client := &Client{Network: "tcp", Address: listener.Addr().String(), DialTimeout: time.Second}
+	client.backendKey.Store(&[2]uint32{42, 7})
+
+	// The query ends within its timeout.
+	proxy.startStatementTimeout(conn, client, PostgreSQLQuery("SELECT 1"), Correlation{})
+	proxy.recordUsage(conn, Egress, PostgreSQLReadyForQuery())
+
+	proxy.startStatementTimeout(conn, client, PostgreSQLQuery("SELECT pg_sleep(5)"), Correlation{})
+	select {
+	case message := <-cancelRequests:
+		assert.Equal(t, PostgreSQLCancelRequest(42, 7), message)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the query wasn't cancelled")
+	}
+	select {
+	case <-cancelRequests:
+		t.Fatal("the query that ended in time was cancelled")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The queries aren't cancelled without the backend key of the server connection.
+	assert.NotNil(t, (&Client{}).Cancel())
+}
@@ -0,0 +1,160 @@
+package network
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Direction string
+
+const (
+	// Ingress is the traffic from the clients to the database.
+	Ingress Direction = "ingress"
+	// Egress is the traffic from the database to the clients.
+	Egress Direction = "egress"
+)
+
+// TokenBucket limits the rate of bytes. Requests larger than the available
+// tokens go into debt, which is paid back by waiting, so that a single large
+// message is never rejected, only delayed.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a new token bucket that refills at rate bytes per second
+// and holds up to burst bytes. The bucket starts full.
+func NewTokenBucket(rate, burst int) *TokenBucket {
+	return &TokenBucket{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes n tokens from the bucket and returns how long the caller has
+// to wait before the tokens are available.
+func (b *TokenBucket) reserve(n int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// Wait blocks until n bytes are allowed through, or the context is done.
+// It returns the time spent waiting.
+func (b *TokenBucket) Wait(ctx context.Context, n int) time.Duration {
+	return sleep(ctx, b.reserve(n))
+}
+
+// sleep waits for the delay, or until the context is done, and returns the time spent
+// waiting.
+func sleep(ctx context.Context, delay time.Duration) time.Duration {
+	if delay <= 0 {
+		return 0
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	start := time.Now()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return time.Since(start)
+}
+
+// Throttler limits the bandwidth of the traffic flowing through the proxy, either
+// for all the clients together or for each authenticated user separately, so that
+// a few clients streaming large result sets can't starve the others.
+type Throttler struct {
+	IngressRate int
+	EgressRate  int
+	Burst       int
+	PerUser     bool
+
+	// Shared counts the traffic of all the GatewayD instances against the rates, in
+	// windows of a second, instead of the token buckets of the instance. It is
+	// disabled if nil.
+	Shared *SharedLimits
+
+	// buckets holds a token bucket per direction and user.
+	buckets sync.Map
+}
+
+// NewThrottler creates a new throttler. The rates are in bytes per second, and
+// zero disables throttling in that direction. If the burst is zero, it defaults
+// to one second worth of traffic.
+func NewThrottler(ingressRate, egressRate, burst int, perUser bool) *Throttler {
+	return &Throttler{
+		IngressRate: ingressRate,
+		EgressRate:  egressRate,
+		Burst:       burst,
+		PerUser:     perUser,
+	}
+}
+
+// Wait blocks until n bytes of the user's traffic are allowed through in the given
+// direction. It returns the time spent waiting.
+func (t *Throttler) Wait(ctx context.Context, user string, direction Direction, n int) time.Duration {
+	rate := t.IngressRate
+	if direction == Egress {
+		rate = t.EgressRate
+	}
+	if rate <= 0 || n <= 0 {
+		return 0
+	}
+
+	if !t.PerUser {
+		user = ""
+	}
+
+	key := string(direction) + "/" + user
+	if t.Shared != nil {
+		return sleep(ctx, t.sharedDelay(key, rate, n))
+	}
+
+	bucket, ok := t.buckets.Load(key)
+	if !ok {
+		burst := t.Burst
+		if burst <= 0 {
+			burst = rate
+		}
+		bucket, _ = t.buckets.LoadOrStore(key, NewTokenBucket(rate, burst))
+	}
+
+	if tokenBucket, ok := bucket.(*TokenBucket); ok {
+		return tokenBucket.Wait(ctx, n)
+	}
+	return 0
+}
+
+// sharedDelay counts the traffic in the shared window, and returns how long to wait
+// until the traffic of all the instances is within the rate. The traffic beyond the
+// rate and the burst is paid back by waiting, as with the token buckets. The traffic is
+// held until the next window if the shared state is unavailable and fails closed.
+func (t *Throttler) sharedDelay(key string, rate, n int) time.Duration {
+	used, ok := t.Shared.Consume("bandwidth/"+key, int64(n), time.Second)
+	if !ok {
+		return time.Until(time.Now().Truncate(time.Second).Add(time.Second))
+	}
+	allowed := int64(max(rate, t.Burst))
+	if used <= allowed {
+		return 0
+	}
+	return time.Duration(float64(used-allowed) / float64(rate) * float64(time.Second))
+}
@@ -0,0 +1,44 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTokenBucket tests that the traffic beyond the burst is delayed by the rate.
+func TestTokenBucket(t *testing.T) {
+	bucket := NewTokenBucket(1000, 100)
+
+	// The bucket starts full.
+	assert.Equal(t, time.Duration(0), bucket.Wait(context.Background(), 100))
+
+	// 50 bytes over the burst take 50ms at 1000 bytes per second.
+	waited := bucket.Wait(context.Background(), 50)
+	assert.GreaterOrEqual(t, waited, 40*time.Millisecond)
+
+	// The wait is cut short when the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Less(t, bucket.Wait(ctx, 10000), time.Second)
+}
+
+// TestThrottler tests that the buckets are shared by all the users, unless
+// the bandwidth is limited per user.
+func TestThrottler(t *testing.T) {
+	throttler := NewThrottler(1000, 0, 100, false)
+
+	// Egress is unlimited.
+	assert.Equal(t, time.Duration(0), throttler.Wait(context.Background(), "alice", Egress, 1000))
+
+	assert.Equal(t, time.Duration(0), throttler.Wait(context.Background(), "alice", Ingress, 100))
+	// Bob shares the bucket with Alice, which is empty now.
+	assert.Greater(t, throttler.Wait(context.Background(), "bob", Ingress, 10), time.Duration(0))
+
+	perUser := NewThrottler(1000, 0, 100, true)
+	assert.Equal(t, time.Duration(0), perUser.Wait(context.Background(), "alice", Ingress, 100))
+	// Bob gets a separate bucket.
+	assert.Equal(t, time.Duration(0), perUser.Wait(context.Background(), "bob", Ingress, 100))
+}
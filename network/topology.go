@@ -0,0 +1,393 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+)
+
+const (
+	// DefaultPostgresPort is the port of the members whose connection URL has none.
+	DefaultPostgresPort = "5432"
+	// ConsulIndexHeader is the header of the index of the blocking queries of Consul.
+	ConsulIndexHeader = "X-Consul-Index"
+)
+
+// patroniPrefix returns the prefix of the keys Patroni maintains for the cluster in its
+// DCS, e.g. /service/demo/.
+func patroniPrefix(discovery config.PrimaryDiscovery) string {
+	namespace := config.If[string](discovery.Namespace != "",
+		discovery.Namespace, config.DefaultPrimaryDiscoveryNamespace)
+	return path.Join("/", namespace, discovery.Scope) + "/"
+}
+
+// patroniMember is the state of a member, as Patroni reports it in its REST API or keeps
+// it in the key of the member in its DCS.
+type patroniMember struct {
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	State   string `json:"state"`
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+	ConnURL string `json:"conn_url"` //nolint:tagliatelle
+}
+
+// healthy returns true if PostgreSQL is running on the member. The replicas of the
+// recent versions of Patroni are streaming.
+func (p patroniMember) healthy() bool {
+	return p.State == "running" || p.State == "streaming"
+}
+
+// leader returns true if the member holds the leader lock of the cluster. The standby
+// leader of a standby cluster is in recovery, so it isn't the primary.
+func (p patroniMember) leader() bool {
+	return p.Role == "leader" || p.Role == "master" || p.Role == "primary"
+}
+
+// address returns the address of PostgreSQL on the member.
+func (p patroniMember) address() (string, error) {
+	if p.Host != "" {
+		return net.JoinHostPort(p.Host, strconv.Itoa(p.Port)), nil
+	}
+	connURL, err := url.Parse(p.ConnURL)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	if connURL.Port() == "" {
+		return net.JoinHostPort(connURL.Hostname(), DefaultPostgresPort), nil
+	}
+	return connURL.Host, nil
+}
+
+// clusterMember returns the member as a member of the cluster, which is the primary if
+// it is running as the leader.
+func (p patroniMember) clusterMember(leader bool) ClusterMember {
+	member := ClusterMember{
+		Primary:   leader && p.healthy(),
+		Healthy:   p.healthy(),
+		CheckedAt: time.Now(),
+	}
+	address, err := p.address()
+	if err != nil {
+		member.Address = p.Name
+		member.Healthy, member.Primary = false, false
+		member.Error = err.Error()
+		return member
+	}
+	member.Address = address
+	if !member.Healthy {
+		member.Error = "state: " + p.State
+	}
+	return member
+}
+
+// dcsTopology returns the members of the cluster from the keys of Patroni in its DCS: the
+// leader key holds the name of the leader, and the members/<name> keys the state of the
+// members.
+func dcsTopology(prefix string, keys map[string][]byte) []ClusterMember {
+	leader := string(keys[prefix+"leader"])
+	names := []string{}
+	for key := range keys {
+		if name, ok := strings.CutPrefix(key, prefix+"members/"); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	members := make([]ClusterMember, 0, len(names))
+	for _, name := range names {
+		var member patroniMember
+		if err := json.Unmarshal(keys[prefix+"members/"+name], &member); err != nil {
+			members = append(members, ClusterMember{
+				Address: name, Error: err.Error(), CheckedAt: time.Now(),
+			})
+			continue
+		}
+		member.Name = name
+		members = append(members, member.clusterMember(name == leader))
+	}
+	return members
+}
+
+// eachEndpoint calls the function with the endpoints in turn, until it succeeds.
+func eachEndpoint(endpoints []string, call func(endpoint string) error) error {
+	if len(endpoints) == 0 {
+		return errors.New("no endpoints are configured")
+	}
+	errs := make([]error, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		err := call(strings.TrimSuffix(endpoint, "/"))
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint, err))
+	}
+	return errors.Join(errs...)
+}
+
+// doJSON sends the request, and decodes the JSON response into the value.
+func doJSON(request *http.Request, value interface{}) (*http.Response, error) {
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return response, fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+	if err := json.NewDecoder(response.Body).Decode(value); err != nil {
+		return response, err //nolint:wrapcheck
+	}
+	return response, nil
+}
+
+// withTimeout returns the context with the timeout, unless it is zero.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// patroniSource learns the topology of the cluster from the REST API of Patroni, which
+// is polled on the interval.
+type patroniSource struct {
+	endpoints []string
+	timeout   time.Duration
+}
+
+// Topology returns the members of the cluster from the /cluster endpoint of Patroni.
+func (s *patroniSource) Topology(ctx context.Context) ([]ClusterMember, error) {
+	var members []ClusterMember
+	err := eachEndpoint(s.endpoints, func(endpoint string) error {
+		ctx, cancel := withTimeout(ctx, s.timeout)
+		defer cancel()
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/cluster", nil)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		var cluster struct {
+			Members []patroniMember `json:"members"`
+		}
+		if _, err := doJSON(request, &cluster); err != nil {
+			return err
+		}
+
+		members = make([]ClusterMember, 0, len(cluster.Members))
+		for _, member := range cluster.Members {
+			members = append(members, member.clusterMember(member.leader()))
+		}
+		return nil
+	})
+	return members, err
+}
+
+// etcdSource learns the topology of the cluster from the keys of Patroni in etcd, using
+// the JSON gateway of the v3 API, and watches them for changes.
+type etcdSource struct {
+	endpoints []string
+	prefix    string
+	token     string
+	timeout   time.Duration
+	// revision is the revision of etcd of the last topology.
+	revision atomic.Int64
+}
+
+// keyRange returns the range of the keys of the cluster, base64-encoded by the JSON
+// encoding of the byte slices.
+func (s *etcdSource) keyRange() map[string][]byte {
+	rangeEnd := []byte(s.prefix)
+	rangeEnd[len(rangeEnd)-1]++
+	return map[string][]byte{"key": []byte(s.prefix), "range_end": rangeEnd}
+}
+
+func (s *etcdSource) post(ctx context.Context, endpoint, path string, body interface{}) (*http.Request, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	request, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		request.Header.Set("Authorization", s.token)
+	}
+	return request, nil
+}
+
+// Topology returns the members of the cluster from a range request of the keys.
+func (s *etcdSource) Topology(ctx context.Context) ([]ClusterMember, error) {
+	var members []ClusterMember
+	err := eachEndpoint(s.endpoints, func(endpoint string) error {
+		ctx, cancel := withTimeout(ctx, s.timeout)
+		defer cancel()
+		request, err := s.post(ctx, endpoint, "/v3/kv/range", s.keyRange())
+		if err != nil {
+			return err
+		}
+		var result struct {
+			Header struct {
+				Revision int64 `json:"revision,string"`
+			} `json:"header"`
+			Kvs []struct {
+				Key   []byte `json:"key"`
+				Value []byte `json:"value"`
+			} `json:"kvs"`
+		}
+		if _, err := doJSON(request, &result); err != nil {
+			return err
+		}
+
+		keys := make(map[string][]byte, len(result.Kvs))
+		for _, kv := range result.Kvs {
+			keys[string(kv.Key)] = kv.Value
+		}
+		members = dcsTopology(s.prefix, keys)
+		s.revision.Store(result.Header.Revision)
+		return nil
+	})
+	return members, err
+}
+
+// Watch watches the keys from the revision of the last topology, and returns once one
+// of them changed.
+func (s *etcdSource) Watch(ctx context.Context) error {
+	return eachEndpoint(s.endpoints, func(endpoint string) error {
+		create := map[string]interface{}{
+			"key":            []byte(s.prefix),
+			"range_end":      s.keyRange()["range_end"],
+			"start_revision": s.revision.Load() + 1,
+		}
+		request, err := s.post(
+			ctx, endpoint, "/v3/watch", map[string]interface{}{"create_request": create})
+		if err != nil {
+			return err
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected response status: %s", response.Status)
+		}
+
+		// The watch responses are streamed, starting with the creation of the watch.
+		decoder := json.NewDecoder(response.Body)
+		for {
+			var message struct {
+				Result struct {
+					Canceled     bool              `json:"canceled"`
+					CancelReason string            `json:"cancel_reason"` //nolint:tagliatelle
+					Events       []json.RawMessage `json:"events"`
+				} `json:"result"`
+				Error *struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := decoder.Decode(&message); err != nil {
+				return err //nolint:wrapcheck
+			}
+			switch {
+			case message.Error != nil:
+				return errors.New(message.Error.Message)
+			case message.Result.Canceled:
+				// The revision was compacted, so the next topology starts over.
+				return fmt.Errorf("watch canceled: %s", message.Result.CancelReason)
+			case len(message.Result.Events) > 0:
+				return nil
+			}
+		}
+	})
+}
+
+// consulSource learns the topology of the cluster from the keys of Patroni in the KV
+// store of Consul, and watches them with blocking queries.
+type consulSource struct {
+	endpoints []string
+	prefix    string
+	token     string
+	timeout   time.Duration
+	// index is the index of Consul of the last topology.
+	index atomic.Uint64
+}
+
+// get lists the keys of the cluster, and returns them with the index of Consul. If the
+// index is set, the query blocks until the keys change.
+func (s *consulSource) get(
+	ctx context.Context, endpoint string, index uint64,
+) (map[string][]byte, uint64, error) {
+	query := url.Values{"recurse": {"true"}}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		endpoint+"/v1/kv/"+s.prefix+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, 0, err //nolint:wrapcheck
+	}
+	if s.token != "" {
+		request.Header.Set("X-Consul-Token", s.token)
+	}
+
+	var pairs []struct {
+		Key   string `json:"Key"`   //nolint:tagliatelle
+		Value []byte `json:"Value"` //nolint:tagliatelle
+	}
+	response, err := doJSON(request, &pairs)
+	if response != nil && response.StatusCode == http.StatusNotFound {
+		// None of the keys exists, e.g. as the cluster isn't bootstrapped yet.
+		err = nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	newIndex, _ := strconv.ParseUint(response.Header.Get(ConsulIndexHeader), 10, 64)
+
+	keys := make(map[string][]byte, len(pairs))
+	for _, pair := range pairs {
+		keys[pair.Key] = pair.Value
+	}
+	return keys, newIndex, nil
+}
+
+// Topology returns the members of the cluster from the keys.
+func (s *consulSource) Topology(ctx context.Context) ([]ClusterMember, error) {
+	var members []ClusterMember
+	err := eachEndpoint(s.endpoints, func(endpoint string) error {
+		ctx, cancel := withTimeout(ctx, s.timeout)
+		defer cancel()
+		keys, index, err := s.get(ctx, endpoint, 0)
+		if err != nil {
+			return err
+		}
+		members = dcsTopology(s.prefix, keys)
+		s.index.Store(index)
+		return nil
+	})
+	return members, err
+}
+
+// Watch blocks until the keys changed since the index of the last topology.
+func (s *consulSource) Watch(ctx context.Context) error {
+	return eachEndpoint(s.endpoints, func(endpoint string) error {
+		_, _, err := s.get(ctx, endpoint, max(s.index.Load(), 1))
+		return err
+	})
+}
@@ -0,0 +1,214 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDCS is the keys of Patroni in a DCS, whose revision is bumped on every change.
+type fakeDCS struct {
+	mu       sync.Mutex
+	keys     map[string]string
+	revision int64
+	changed  chan struct{}
+}
+
+func newFakeDCS() *fakeDCS {
+	return &fakeDCS{keys: map[string]string{}, revision: 1, changed: make(chan struct{})}
+}
+
+func (f *fakeDCS) set(keys map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = keys
+	f.revision++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeDCS) snapshot() (map[string]string, int64, chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.keys, f.revision, f.changed
+}
+
+func patroniKeys(prefix, leader string, replicas ...string) map[string]string {
+	keys := map[string]string{}
+	if leader != "" {
+		keys[prefix+"leader"] = leader
+	}
+	for _, name := range append([]string{leader}, replicas...) {
+		if name == "" {
+			continue
+		}
+		keys[prefix+"members/"+name] = `{"conn_url":"postgres://` + name +
+			`:5432/postgres","state":"running","role":"replica"}`
+	}
+	return keys
+}
+
+// newFakeEtcd serves the range and watch requests of the JSON gateway of etcd.
+func newFakeEtcd(t *testing.T, dcs *fakeDCS) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys, revision, changed := dcs.snapshot()
+		switch r.URL.Path {
+		case "/v3/kv/range":
+			kvs := []map[string][]byte{}
+			for key, value := range keys {
+				kvs = append(kvs, map[string][]byte{"key": []byte(key), "value": []byte(value)})
+			}
+			assert.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+				"header": map[string]string{"revision": strconv.FormatInt(revision, 10)},
+				"kvs":    kvs,
+			}))
+		case "/v3/watch":
+			var body struct {
+				CreateRequest struct {
+					StartRevision int64 `json:"start_revision"`
+				} `json:"create_request"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			w.Write([]byte(`{"result":{"created":true}}` + "\n"))
+			w.(http.Flusher).Flush()
+			if body.CreateRequest.StartRevision > revision {
+				select {
+				case <-changed:
+				case <-r.Context().Done():
+					return
+				}
+			}
+			w.Write([]byte(`{"result":{"events":[{"type":"PUT"}]}}` + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// newFakeConsul serves the blocking queries of the KV store of Consul.
+func newFakeConsul(t *testing.T, dcs *fakeDCS) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys, revision, changed := dcs.snapshot()
+		if index, _ := strconv.ParseInt(r.URL.Query().Get("index"), 10, 64); index >= revision {
+			select {
+			case <-changed:
+				keys, revision, _ = dcs.snapshot()
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set(ConsulIndexHeader, strconv.FormatInt(revision, 10))
+		if len(keys) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		pairs := []map[string]interface{}{}
+		for key, value := range keys {
+			pairs = append(pairs, map[string]interface{}{"Key": key, "Value": []byte(value)})
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(pairs))
+	}))
+}
+
+// TestClusterMonitorWatch tests that the primary is followed as soon as Patroni records
+// a failover in etcd or Consul, long before the interval.
+func TestClusterMonitorWatch(t *testing.T) {
+	for _, source := range []config.TopologySource{config.EtcdSource, config.ConsulSource} {
+		t.Run(string(source), func(t *testing.T) {
+			dcs := newFakeDCS()
+			var server *httptest.Server
+			if source == config.EtcdSource {
+				server = newFakeEtcd(t, dcs)
+			} else {
+				server = newFakeConsul(t, dcs)
+			}
+			defer server.Close()
+
+			discovery := config.PrimaryDiscovery{
+				Source:    source,
+				Endpoints: []string{"http://127.0.0.1:1", server.URL},
+				Scope:     "demo",
+				Interval:  time.Minute,
+			}
+			prefix := patroniPrefix(discovery)
+			if source == config.ConsulSource {
+				prefix = prefix[1:]
+			}
+			dcs.set(patroniKeys(prefix, "pg-1", "pg-2"))
+
+			monitor := NewClusterMonitor(discovery, time.Second, zerolog.Nop())
+			changes := make(chan string, 1)
+			monitor.OnPrimaryChanged(func(primary string) { changes <- primary })
+			monitor.Start(context.Background())
+			defer monitor.Stop()
+			assert.Equal(t, "pg-1:5432", monitor.Target(config.Primary))
+			assert.Equal(t, "pg-2:5432", monitor.Target(config.Standby))
+
+			dcs.set(patroniKeys(prefix, "pg-2", "pg-1"))
+			select {
+			case primary := <-changes:
+				assert.Equal(t, "pg-2:5432", primary)
+			case <-time.After(5 * time.Second):
+				t.Fatal("The failover wasn't followed")
+			}
+			assert.Equal(t, "pg-1:5432", monitor.Target(config.Standby))
+
+			// The cluster has no leader while Patroni elects a new one, so the primary is
+			// kept.
+			dcs.set(patroniKeys(prefix, "", "pg-1", "pg-2"))
+			_, current, _, err := monitor.Check(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, "pg-2:5432", current)
+		})
+	}
+}
+
+func TestPatroniSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/cluster", r.URL.Path)
+		w.Write([]byte(`{"scope":"demo","members":[
+			{"name":"pg-1","role":"replica","state":"streaming","host":"10.0.0.1","port":5432},
+			{"name":"pg-2","role":"leader","state":"running","host":"10.0.0.2","port":5433},
+			{"name":"pg-3","role":"replica","state":"stopped","host":"10.0.0.3","port":5432}
+		]}`))
+	}))
+	defer server.Close()
+
+	monitor := NewClusterMonitor(config.PrimaryDiscovery{
+		Source:    config.PatroniSource,
+		Endpoints: []string{server.URL + "/"},
+	}, time.Second, zerolog.Nop())
+	_, current, _, err := monitor.Check(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.2:5433", current)
+	assert.Equal(t, "10.0.0.1:5432", monitor.Target(config.Standby))
+	assert.Equal(t, "10.0.0.1:5432", monitor.Target(config.Standby))
+
+	status := monitor.Status()
+	require.Len(t, status, 3)
+	assert.False(t, status[2].Healthy)
+	assert.Equal(t, "state: stopped", status[2].Error)
+}
+
+func TestClusterKeySource(t *testing.T) {
+	etcd := config.PrimaryDiscovery{
+		Source:    config.EtcdSource,
+		Endpoints: []string{"http://etcd-1:2379"},
+		Scope:     "demo",
+	}
+	assert.Equal(t, "etcd:http://etcd-1:2379/service/demo/", ClusterKey(etcd))
+	etcd.Scope = "other"
+	assert.NotEqual(t, "etcd:http://etcd-1:2379/service/demo/", ClusterKey(etcd))
+}
@@ -0,0 +1,215 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/sdk"
+)
+
+// transaction follows the transaction of a session through the responses of the
+// server: the tags of their CommandComplete messages and the transaction status of
+// their ReadyForQuery messages, as the status doesn't change when the statements of a
+// single Query message start and end the transaction.
+type transaction struct {
+	mu sync.Mutex
+	// requestAt is when the client sent its first request since the last
+	// ReadyForQuery message, which is when the transaction it starts started.
+	requestAt  time.Time
+	open       bool
+	startedAt  time.Time
+	statements int
+	// failed is true if the transaction is in a failed state, and rolledBack is true
+	// if its last transaction control statement was a ROLLBACK.
+	failed     bool
+	rolledBack bool
+}
+
+// transactionEvent is the start or the end of a transaction of a session.
+type transactionEvent struct {
+	end        bool
+	startedAt  time.Time
+	duration   time.Duration
+	statements int
+	outcome    string
+}
+
+// request records when the client sent the request, if it is a query.
+func (t *transaction) request(data []byte) {
+	if !IsPostgresQuery(data) {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.requestAt.IsZero() {
+		t.requestAt = time.Now()
+	}
+}
+
+// track returns the transactions the response started and ended, in order.
+//
+//nolint:gomnd
+func (t *transaction) track(response []byte) []transactionEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var events []transactionEvent
+	for offset := 0; offset+5 <= len(response); {
+		length := int(binary.BigEndian.Uint32(response[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(response) {
+			break
+		}
+		body := response[offset+5 : offset+1+length]
+		switch response[offset] {
+		case 'C':
+			switch tag := string(bytes.TrimRight(body, "\x00")); tag {
+			case "BEGIN", "START TRANSACTION":
+				events = t.start(events)
+			case "COMMIT", "ROLLBACK":
+				// ROLLBACK TO SAVEPOINT has the tag of ROLLBACK too, so the transaction
+				// only ends with the ReadyForQuery message.
+				t.rolledBack = tag == "ROLLBACK"
+			default:
+				if t.open {
+					t.statements++
+				}
+			}
+		case 'E':
+			if t.open {
+				t.statements++
+				t.failed = true
+			}
+		case 'Z':
+			if length != 5 {
+				break
+			}
+			switch status := body[0]; status {
+			case 'T', 'E':
+				events = t.start(events)
+				t.failed = status == 'E'
+			case 'I':
+				if t.open {
+					events = append(events, t.end(t.outcome()))
+				}
+			}
+			t.requestAt = time.Time{}
+		}
+		offset += 1 + length
+	}
+	return events
+}
+
+// abort ends the transaction with the outcome if it is open, e.g. when the gateway
+// rolled it back or the client disconnected in it.
+func (t *transaction) abort(outcome string) []transactionEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.open {
+		return nil
+	}
+	return []transactionEvent{t.end(outcome)}
+}
+
+// start must be called with the lock held.
+func (t *transaction) start(events []transactionEvent) []transactionEvent {
+	if t.open {
+		return events
+	}
+
+	t.open = true
+	t.startedAt = t.requestAt
+	if t.startedAt.IsZero() {
+		t.startedAt = time.Now()
+	}
+	t.statements = 0
+	t.failed = false
+	t.rolledBack = false
+	return append(events, transactionEvent{startedAt: t.startedAt})
+}
+
+// end must be called with the lock held.
+func (t *transaction) end(outcome string) transactionEvent {
+	t.open = false
+	return transactionEvent{
+		end:        true,
+		startedAt:  t.startedAt,
+		duration:   time.Since(t.startedAt),
+		statements: t.statements,
+		outcome:    outcome,
+	}
+}
+
+// outcome must be called with the lock held.
+func (t *transaction) outcome() string {
+	switch {
+	case t.failed:
+		return sdk.TransactionError
+	case t.rolledBack:
+		return sdk.TransactionRollback
+	default:
+		return sdk.TransactionCommit
+	}
+}
+
+// trackTransaction follows the transaction of the session of the connection through
+// the response, and notifies the hooks of the transactions it started and ended.
+func (pr *Proxy) trackTransaction(conn *ConnWrapper, response []byte, correlation Correlation) {
+	if value, ok := pr.sessions.Load(conn); ok {
+		//nolint:forcetypeassert
+		pr.notifyTransactions(conn, value.(*session).transaction.track(response), correlation)
+	}
+}
+
+// notifyTransactions runs the OnTransactionStart and OnTransactionEnd hooks of the
+// transactions of the connection.
+func (pr *Proxy) notifyTransactions(
+	conn *ConnWrapper, events []transactionEvent, correlation Correlation,
+) {
+	for _, event := range events {
+		hookName := sdk.OnTransactionStart
+		if event.end {
+			hookName = sdk.OnTransactionEnd
+			metrics.ProxyTransactions.WithLabelValues(event.outcome).Inc()
+			metrics.ProxyTransactionDuration.Observe(event.duration.Seconds())
+		}
+		if pr.pluginRegistry == nil || len(pr.pluginRegistry.Hooks()[hookName]) == 0 {
+			continue
+		}
+
+		parameters := pr.startupParameters(conn)
+		args := map[string]interface{}{
+			"connectionId": conn.ID(),
+			"queryId":      correlation.QueryID,
+			"user":         parameters["user"],
+			"database":     parameters["database"],
+			"startedAt":    event.startedAt.Format(time.RFC3339Nano),
+		}
+		if event.end {
+			args["duration"] = event.duration.String()
+			args["statements"] = event.statements
+			args["outcome"] = event.outcome
+		}
+		pr.runTransactionHook(args, hookName, correlation)
+	}
+}
+
+// runTransactionHook runs the hooks of the transaction with the plugin timeout.
+func (pr *Proxy) runTransactionHook(
+	args map[string]interface{}, hookName v1.HookName, correlation Correlation,
+) {
+	pluginTimeoutCtx, cancel := context.WithTimeout(context.Background(), pr.pluginTimeout)
+	defer cancel()
+	if _, err := pr.pluginRegistry.Run(pluginTimeoutCtx, args, hookName); err != nil {
+		logger := correlation.Logger(pr.logger)
+		logger.Error().Err(err).Msgf(
+			"Failed to run the %s hooks", plugin.HookNameString(hookName))
+	}
+}
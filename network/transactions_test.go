@@ -0,0 +1,183 @@
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"testing"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// transactionResponse creates a response with the CommandComplete messages of the tags
+// and the ReadyForQuery message of the status.
+func transactionResponse(status byte, tags ...string) []byte {
+	var response []byte
+	for _, tag := range tags {
+		message := append([]byte{'C', 0, 0, 0, 0}, tag...)
+		message = append(message, 0)
+		binary.BigEndian.PutUint32(message[1:5], uint32(len(message)-1))
+		response = append(response, message...)
+	}
+	return append(response, 'Z', 0, 0, 0, 5, status)
+}
+
+// TestTransactionTrack tests that the transactions start and end with the responses,
+// with their statements and outcome.
+func TestTransactionTrack(t *testing.T) {
+	failure := func(status byte) []byte {
+		return append(PostgreSQLErrorResponse("ERROR", "23505", "duplicate key"),
+			transactionResponse(status)...)
+	}
+
+	tests := []struct {
+		name       string
+		responses  [][]byte
+		statements int
+		outcome    string
+	}{
+		{
+			name: "commit",
+			responses: [][]byte{
+				transactionResponse('T', "BEGIN"),
+				transactionResponse('T', "INSERT 0 1"),
+				transactionResponse('T', "UPDATE 2"),
+				transactionResponse('I', "COMMIT"),
+			},
+			statements: 2,
+			outcome:    sdk.TransactionCommit,
+		},
+		{
+			name: "rollback",
+			responses: [][]byte{
+				transactionResponse('T', "BEGIN"),
+				transactionResponse('T', "DELETE 1"),
+				transactionResponse('I', "ROLLBACK"),
+			},
+			statements: 1,
+			outcome:    sdk.TransactionRollback,
+		},
+		{
+			name: "failed statement",
+			responses: [][]byte{
+				transactionResponse('T', "BEGIN"),
+				failure('E'),
+				transactionResponse('I', "ROLLBACK"),
+			},
+			statements: 1,
+			outcome:    sdk.TransactionError,
+		},
+		{
+			name: "rollback to savepoint",
+			responses: [][]byte{
+				transactionResponse('T', "BEGIN"),
+				transactionResponse('T', "SAVEPOINT"),
+				failure('E'),
+				transactionResponse('T', "ROLLBACK"),
+				transactionResponse('I', "COMMIT"),
+			},
+			statements: 2,
+			outcome:    sdk.TransactionCommit,
+		},
+		{
+			name: "failed commit",
+			responses: [][]byte{
+				transactionResponse('T', "BEGIN"),
+				transactionResponse('T', "INSERT 0 1"),
+				failure('I'),
+			},
+			statements: 2,
+			outcome:    sdk.TransactionError,
+		},
+		{
+			name:       "single query",
+			responses:  [][]byte{transactionResponse('I', "BEGIN", "INSERT 0 1", "COMMIT")},
+			statements: 1,
+			outcome:    sdk.TransactionCommit,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var tracked transaction
+			tracked.request(PostgreSQLQuery("BEGIN"))
+			var events []transactionEvent
+			for _, response := range test.responses {
+				events = append(events, tracked.track(response)...)
+			}
+			require.Len(t, events, 2)
+			assert.False(t, events[0].end)
+			assert.True(t, events[1].end)
+			assert.Equal(t, test.statements, events[1].statements)
+			assert.Equal(t, test.outcome, events[1].outcome)
+			assert.Equal(t, events[0].startedAt, events[1].startedAt)
+		})
+	}
+
+	// The statements outside of the transactions don't start one.
+	var tracked transaction
+	assert.Empty(t, tracked.track(transactionResponse('I', "SELECT 1")))
+	assert.Nil(t, tracked.abort(sdk.TransactionError))
+	require.Len(t, tracked.track(transactionResponse('T', "BEGIN")), 1)
+	events := tracked.abort(sdk.TransactionError)
+	require.Len(t, events, 1)
+	assert.Equal(t, sdk.TransactionError, events[0].outcome)
+	assert.Nil(t, tracked.abort(sdk.TransactionError))
+}
+
+// TestProxyTrackTransaction tests that the transaction hooks are notified of the
+// transactions of the sessions.
+func TestProxyTrackTransaction(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := context.Background()
+	pluginRegistry := plugin.NewRegistry(
+		ctx, config.Loose, config.PassDown, config.Accept, config.Stop, logger, false)
+	var mu sync.Mutex
+	var notified []map[string]interface{}
+	for _, hookName := range []v1.HookName{sdk.OnTransactionStart, sdk.OnTransactionEnd} {
+		pluginRegistry.AddHook(hookName, 1, func(
+			_ context.Context, params *v1.Struct, _ ...grpc.CallOption,
+		) (*v1.Struct, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			notified = append(notified, params.AsMap())
+			return params, nil
+		})
+	}
+
+	proxy := NewProxy(
+		ctx, pool.NewPool(ctx, 1), pluginRegistry, false, false,
+		config.DefaultHealthCheckPeriod, nil, logger, config.DefaultPluginTimeout)
+	defer proxy.Shutdown()
+	conn := &ConnWrapper{}
+	proxy.sessions.Store(conn, newSession())
+	proxy.parameters.Store(conn, map[string]string{"user": "app", "database": "shop"})
+	assert.False(t, proxy.CanSplice(conn))
+
+	correlation := Correlation{ConnectionID: conn.ID(), QueryID: "query"}
+	proxy.recordUsage(conn, Ingress, PostgreSQLQuery("BEGIN"))
+	proxy.trackTransaction(conn, transactionResponse('T', "BEGIN"), correlation)
+	proxy.recordUsage(conn, Ingress, PostgreSQLQuery("INSERT INTO orders VALUES (1)"))
+	proxy.trackTransaction(conn, transactionResponse('T', "INSERT 0 1"), correlation)
+	proxy.recordUsage(conn, Ingress, PostgreSQLQuery("COMMIT"))
+	proxy.trackTransaction(conn, transactionResponse('I', "COMMIT"), correlation)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, notified, 2)
+	assert.Equal(t, "app", notified[0]["user"])
+	assert.Equal(t, "shop", notified[0]["database"])
+	assert.Equal(t, "query", notified[0]["queryId"])
+	assert.NotContains(t, notified[0], "outcome")
+	assert.Equal(t, notified[0]["startedAt"], notified[1]["startedAt"])
+	assert.Equal(t, sdk.TransactionCommit, notified[1]["outcome"])
+	assert.InDelta(t, 1, notified[1]["statements"], 0)
+	assert.NotEmpty(t, notified[1]["duration"])
+}
@@ -0,0 +1,150 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"golang.org/x/net/proxy"
+)
+
+// UpstreamProxy connects to the servers through a SOCKS5 or HTTP CONNECT proxy, for
+// the hosts that aren't allowed to connect to them directly.
+type UpstreamProxy struct {
+	url         *url.URL
+	username    string
+	password    string
+	dialTimeout time.Duration
+}
+
+// NewUpstreamProxy creates a new upstream proxy from its config. The credentials in
+// the config take precedence over the ones in the URL.
+func NewUpstreamProxy(
+	proxyConfig config.UpstreamProxy, dialTimeout time.Duration,
+) (*UpstreamProxy, *gerr.GatewayDError) {
+	proxyURL, err := url.Parse(proxyConfig.URL)
+	if err != nil {
+		return nil, gerr.ErrUpstreamProxyFailed.Wrap(err)
+	}
+
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h", "http":
+	default:
+		return nil, gerr.ErrUpstreamProxyFailed.Wrap(
+			fmt.Errorf("unsupported upstream proxy scheme: %q", proxyURL.Scheme))
+	}
+	if proxyURL.Host == "" {
+		return nil, gerr.ErrUpstreamProxyFailed.Wrap(errors.New("upstream proxy has no host"))
+	}
+
+	upstreamProxy := &UpstreamProxy{
+		url:         proxyURL,
+		username:    proxyConfig.Username,
+		password:    proxyConfig.Password,
+		dialTimeout: dialTimeout,
+	}
+	if upstreamProxy.username == "" && proxyURL.User != nil {
+		upstreamProxy.username = proxyURL.User.Username()
+		upstreamProxy.password, _ = proxyURL.User.Password()
+	}
+
+	return upstreamProxy, nil
+}
+
+// Dial connects to the address through the proxy. Only TCP is supported.
+func (p *UpstreamProxy) Dial(network, address string) (net.Conn, error) {
+	if network != "tcp" {
+		return nil, gerr.ErrUpstreamProxyFailed.Wrap(
+			fmt.Errorf("network %q is not supported by the upstream proxy", network))
+	}
+
+	var conn net.Conn
+	var err error
+	if p.url.Scheme == "http" {
+		conn, err = p.dialHTTP(address)
+	} else {
+		conn, err = p.dialSOCKS5(address)
+	}
+	if err != nil {
+		return nil, gerr.ErrUpstreamProxyFailed.Wrap(err)
+	}
+
+	return conn, nil
+}
+
+// dialSOCKS5 connects to the address through the SOCKS5 proxy, which resolves it.
+func (p *UpstreamProxy) dialSOCKS5(address string) (net.Conn, error) {
+	var auth *proxy.Auth
+	if p.username != "" {
+		auth = &proxy.Auth{User: p.username, Password: p.password}
+	}
+
+	dialer, err := proxy.SOCKS5("tcp", p.url.Host, auth, &net.Dialer{Timeout: p.dialTimeout})
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return dialer.Dial("tcp", address) //nolint:wrapcheck
+}
+
+// dialHTTP connects to the address through the HTTP proxy with the CONNECT method.
+func (p *UpstreamProxy) dialHTTP(address string) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", p.url.Host, p.dialTimeout)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if p.username != "" {
+		request.SetBasicAuth(p.username, p.password)
+		request.Header.Set("Proxy-Authorization", request.Header.Get("Authorization"))
+		request.Header.Del("Authorization")
+	}
+
+	if p.dialTimeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(p.dialTimeout))
+	}
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, err //nolint:wrapcheck
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		conn.Close()
+		return nil, err //nolint:wrapcheck
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("upstream proxy refused to connect: %s", response.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	// The proxy might have sent some data of the server along with its response.
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose data was partly read into a buffer.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(data []byte) (int, error) {
+	return c.reader.Read(data) //nolint:wrapcheck
+}
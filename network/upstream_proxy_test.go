@@ -0,0 +1,193 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveProxy accepts the connections of the listener, and connects each one to the
+// address returned by the handshake, unless it fails.
+func serveProxy(listener net.Listener, handshake func(net.Conn, *bufio.Reader) (string, bool)) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			address, ok := handshake(conn, reader)
+			if !ok {
+				return
+			}
+			target, err := net.Dial("tcp", address)
+			if err != nil {
+				return
+			}
+			defer target.Close()
+			go func() {
+				_, _ = io.Copy(target, reader)
+			}()
+			_, _ = io.Copy(conn, target)
+		}()
+	}
+}
+
+// startHTTPProxy starts an HTTP CONNECT proxy that requires the given credentials.
+func startHTTPProxy(t *testing.T, username, password string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go serveProxy(listener, func(conn net.Conn, reader *bufio.Reader) (string, bool) {
+		request, err := http.ReadRequest(reader)
+		if err != nil || request.Method != http.MethodConnect {
+			return "", false
+		}
+		request.Header.Set("Authorization", request.Header.Get("Proxy-Authorization"))
+		if user, pass, _ := request.BasicAuth(); user != username || pass != password {
+			_, _ = conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\n\r\n"))
+			return "", false
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		return request.Host, true
+	})
+	return listener.Addr().String()
+}
+
+// startSOCKS5Proxy starts a SOCKS5 proxy that requires the given credentials.
+//
+//nolint:gomnd
+func startSOCKS5Proxy(t *testing.T, username, password string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go serveProxy(listener, func(conn net.Conn, reader *bufio.Reader) (string, bool) {
+		// Greeting: version, number of methods and the methods.
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return "", false
+		}
+		if _, err := io.ReadFull(reader, make([]byte, header[1])); err != nil {
+			return "", false
+		}
+		// Pick username/password authentication.
+		_, _ = conn.Write([]byte{5, 2})
+		// Authentication: version, username and password.
+		if _, err := io.ReadFull(reader, header[:2]); err != nil {
+			return "", false
+		}
+		user := make([]byte, header[1])
+		_, _ = io.ReadFull(reader, user)
+		passLength, _ := reader.ReadByte()
+		pass := make([]byte, passLength)
+		_, _ = io.ReadFull(reader, pass)
+		if string(user) != username || string(pass) != password {
+			_, _ = conn.Write([]byte{1, 1})
+			return "", false
+		}
+		_, _ = conn.Write([]byte{1, 0})
+		// Request: version, command, reserved, address type, address and port.
+		request := make([]byte, 4)
+		if _, err := io.ReadFull(reader, request); err != nil {
+			return "", false
+		}
+		var host string
+		switch request[3] {
+		case 1:
+			ip := make([]byte, 4)
+			_, _ = io.ReadFull(reader, ip)
+			host = net.IP(ip).String()
+		case 3:
+			length, _ := reader.ReadByte()
+			name := make([]byte, length)
+			_, _ = io.ReadFull(reader, name)
+			host = string(name)
+		default:
+			return "", false
+		}
+		port := make([]byte, 2)
+		_, _ = io.ReadFull(reader, port)
+		_, _ = conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+		return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), true
+	})
+	return listener.Addr().String()
+}
+
+// TestUpstreamProxy tests connecting through the SOCKS5 and HTTP CONNECT proxies,
+// with the credentials in the config or in the URL.
+func TestUpstreamProxy(t *testing.T) {
+	echoAddress := startEchoServer(t)
+	httpProxy := startHTTPProxy(t, "gatewayd", "secret")
+	socks5Proxy := startSOCKS5Proxy(t, "gatewayd", "secret")
+
+	tests := []struct {
+		name   string
+		config config.UpstreamProxy
+		fails  bool
+	}{
+		{"http", config.UpstreamProxy{
+			URL: "http://" + httpProxy, Username: "gatewayd", Password: "secret",
+		}, false},
+		{"http with credentials in the URL", config.UpstreamProxy{
+			URL: "http://gatewayd:secret@" + httpProxy,
+		}, false},
+		{"http with wrong credentials", config.UpstreamProxy{
+			URL: "http://" + httpProxy, Username: "gatewayd", Password: "wrong",
+		}, true},
+		{"socks5", config.UpstreamProxy{
+			URL: "socks5://" + socks5Proxy, Username: "gatewayd", Password: "secret",
+		}, false},
+		{"socks5 with wrong credentials", config.UpstreamProxy{
+			URL: "socks5://gatewayd:wrong@" + socks5Proxy,
+		}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			upstreamProxy, gErr := NewUpstreamProxy(test.config, time.Second)
+			require.Nil(t, gErr)
+
+			conn, err := upstreamProxy.Dial("tcp", echoAddress)
+			if test.fails {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer conn.Close()
+
+			_, err = conn.Write([]byte("ping"))
+			require.NoError(t, err)
+			received := make([]byte, 4)
+			_, err = io.ReadFull(conn, received)
+			require.NoError(t, err)
+			assert.Equal(t, "ping", string(received))
+		})
+	}
+}
+
+// TestUpstreamProxy_InvalidConfig tests that the unsupported proxies and networks
+// are rejected.
+func TestUpstreamProxy_InvalidConfig(t *testing.T) {
+	_, err := NewUpstreamProxy(config.UpstreamProxy{URL: "ftp://proxy:21"}, time.Second)
+	assert.NotNil(t, err)
+	_, err = NewUpstreamProxy(config.UpstreamProxy{URL: "http://"}, time.Second)
+	assert.NotNil(t, err)
+
+	upstreamProxy, err := NewUpstreamProxy(config.UpstreamProxy{URL: "socks5://proxy:1080"}, time.Second)
+	require.Nil(t, err)
+	_, dialErr := upstreamProxy.Dial("unix", "/tmp/.s.PGSQL.5432")
+	assert.Error(t, dialErr)
+}
@@ -0,0 +1,274 @@
+package network
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/rs/zerolog"
+)
+
+// Usage is the traffic of a connection, user or database, in bytes and protocol
+// messages, where in is from the clients and out is to them.
+type Usage struct {
+	BytesIn     uint64 `json:"bytesIn"`
+	BytesOut    uint64 `json:"bytesOut"`
+	MessagesIn  uint64 `json:"messagesIn"`
+	MessagesOut uint64 `json:"messagesOut"`
+}
+
+func (u *Usage) add(direction Direction, bytes, messages int) {
+	if direction == Ingress {
+		u.BytesIn += uint64(bytes)
+		u.MessagesIn += uint64(messages)
+	} else {
+		u.BytesOut += uint64(bytes)
+		u.MessagesOut += uint64(messages)
+	}
+}
+
+// ConnectionUsage is the usage of an open connection.
+type ConnectionUsage struct {
+	Usage
+	ID       string    `json:"id"`
+	Remote   string    `json:"remote"`
+	User     string    `json:"user"`
+	Database string    `json:"database"`
+	Since    time.Time `json:"since"`
+
+	// labels are the values of the connection labels of the usage metrics, by name.
+	labels map[string]string
+}
+
+// UsageReport is the usage of the open connections, and the total usage of each user
+// and database, including the closed connections, since GatewayD started.
+type UsageReport struct {
+	Connections []ConnectionUsage `json:"connections"`
+	Users       map[string]Usage  `json:"users"`
+	Databases   map[string]Usage  `json:"databases"`
+	Total       Usage             `json:"total"`
+}
+
+// UsageLogEntry is a line of the usage log, with the usage of each user and database
+// since the previous flush.
+type UsageLogEntry struct {
+	Time      time.Time        `json:"time"`
+	Since     time.Time        `json:"since"`
+	Users     map[string]Usage `json:"users"`
+	Databases map[string]Usage `json:"databases"`
+}
+
+// UsageTracker accounts the traffic of the connections, users and databases, e.g.
+// for chargeback, and periodically appends the usage since the previous flush to the
+// usage log, if any.
+type UsageTracker struct {
+	logger zerolog.Logger
+
+	mu          sync.Mutex
+	connections map[*ConnWrapper]*ConnectionUsage
+	users       map[string]*Usage
+	databases   map[string]*Usage
+	total       Usage
+
+	// The usage since the previous flush.
+	usageLog       io.Writer
+	flushedAt      time.Time
+	unflushedUsers map[string]*Usage
+	unflushedDBs   map[string]*Usage
+	stop           chan struct{}
+	stopOnce       sync.Once
+}
+
+// NewUsageTracker creates a new usage tracker, which writes the usage log to the
+// writer, if it isn't nil.
+func NewUsageTracker(usageLog io.Writer, logger zerolog.Logger) *UsageTracker {
+	return &UsageTracker{
+		logger:         logger,
+		connections:    map[*ConnWrapper]*ConnectionUsage{},
+		users:          map[string]*Usage{},
+		databases:      map[string]*Usage{},
+		usageLog:       usageLog,
+		flushedAt:      time.Now(),
+		unflushedUsers: map[string]*Usage{},
+		unflushedDBs:   map[string]*Usage{},
+		stop:           make(chan struct{}),
+	}
+}
+
+// Identify sets the user and database of the connection, from its startup message,
+// and the values of the connection labels of its usage metrics.
+func (u *UsageTracker) Identify(
+	conn *ConnWrapper, user, database string, labels map[string]string,
+) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	connection := u.connection(conn)
+	connection.User = user
+	connection.Database = database
+	connection.labels = labels
+}
+
+// Record accounts the traffic of the connection in the direction.
+func (u *UsageTracker) Record(conn *ConnWrapper, direction Direction, data []byte) {
+	messages := CountPostgresMessages(data)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	connection := u.connection(conn)
+	connection.add(direction, len(data), messages)
+	u.total.add(direction, len(data), messages)
+	for _, usages := range []struct {
+		byKey map[string]*Usage
+		key   string
+	}{
+		{u.users, connection.User},
+		{u.databases, connection.Database},
+		{u.unflushedUsers, connection.User},
+		{u.unflushedDBs, connection.Database},
+	} {
+		usage, ok := usages.byKey[usages.key]
+		if !ok {
+			usage = &Usage{}
+			usages.byKey[usages.key] = usage
+		}
+		usage.add(direction, len(data), messages)
+	}
+
+	labels := metrics.LabelValues(config.UsageMetricLabels, connection.labels, string(direction))
+	metrics.ProxyUsageBytes.WithLabelValues(labels...).Add(float64(len(data)))
+	metrics.ProxyUsageMessages.WithLabelValues(labels...).Add(float64(messages))
+}
+
+// Close forgets the connection. Its usage is kept in the totals of its user and
+// database.
+func (u *UsageTracker) Close(conn *ConnWrapper) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	delete(u.connections, conn)
+}
+
+// Report returns the usage of the open connections, users and databases.
+func (u *UsageTracker) Report() UsageReport {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	report := UsageReport{
+		Connections: make([]ConnectionUsage, 0, len(u.connections)),
+		Users:       copyUsages(u.users),
+		Databases:   copyUsages(u.databases),
+		Total:       u.total,
+	}
+	for _, connection := range u.connections {
+		report.Connections = append(report.Connections, *connection)
+	}
+	return report
+}
+
+// Start flushes the usage to the usage log on every interval, until it is stopped.
+func (u *UsageTracker) Start(interval time.Duration) {
+	if u.usageLog == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				u.Flush()
+			case <-u.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops flushing the usage, and flushes it one last time.
+func (u *UsageTracker) Stop() {
+	u.stopOnce.Do(func() {
+		close(u.stop)
+		u.Flush()
+	})
+}
+
+// Flush appends the usage since the previous flush to the usage log, if there is any.
+func (u *UsageTracker) Flush() {
+	if u.usageLog == nil {
+		return
+	}
+
+	u.mu.Lock()
+	now := time.Now()
+	entry := UsageLogEntry{
+		Time:      now,
+		Since:     u.flushedAt,
+		Users:     copyUsages(u.unflushedUsers),
+		Databases: copyUsages(u.unflushedDBs),
+	}
+	u.flushedAt = now
+	u.unflushedUsers = map[string]*Usage{}
+	u.unflushedDBs = map[string]*Usage{}
+	u.mu.Unlock()
+
+	if len(entry.Users) == 0 && len(entry.Databases) == 0 {
+		return
+	}
+	if err := json.NewEncoder(u.usageLog).Encode(entry); err != nil {
+		u.logger.Error().Err(err).Msg("Failed to write the usage log")
+	}
+}
+
+// connection returns the usage of the connection, which is created on first use.
+func (u *UsageTracker) connection(conn *ConnWrapper) *ConnectionUsage {
+	connection, ok := u.connections[conn]
+	if !ok {
+		connection = &ConnectionUsage{ID: conn.ID(), Since: time.Now()}
+		if remote := conn.RemoteAddr(); remote != nil {
+			connection.Remote = remote.String()
+		}
+		u.connections[conn] = connection
+	}
+	return connection
+}
+
+func copyUsages(usages map[string]*Usage) map[string]Usage {
+	copied := make(map[string]Usage, len(usages))
+	for key, usage := range usages {
+		copied[key] = *usage
+	}
+	return copied
+}
+
+// CountPostgresMessages returns the number of PostgreSQL messages in the data. A
+// startup message, SSL request or cancel request counts as one. The messages split
+// across reads are counted in the read they start in.
+//
+//nolint:gomnd
+func CountPostgresMessages(data []byte) int {
+	if len(data) == 0 {
+		return 0
+	}
+	// The messages without a type byte start with their length.
+	if len(data) >= 8 && int(binary.BigEndian.Uint32(data[0:4])) == len(data) {
+		return 1
+	}
+
+	count := 0
+	for offset := 0; offset+5 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[offset+1 : offset+5]))
+		if length < 4 {
+			break
+		}
+		count++
+		offset += 1 + length
+	}
+	return max(count, 1)
+}
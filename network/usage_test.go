@@ -0,0 +1,85 @@
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCountPostgresMessages tests that the messages are counted in the data.
+func TestCountPostgresMessages(t *testing.T) {
+	assert.Equal(t, 0, CountPostgresMessages(nil))
+	assert.Equal(t, 1, CountPostgresMessages(CreatePostgreSQLPacket('Q', []byte("SELECT 1\x00"))))
+	assert.Equal(t, 1, CountPostgresMessages(PostgreSQLSSLRequest()))
+	assert.Equal(t, 3, CountPostgresMessages(bytes.Join([][]byte{
+		CreatePostgreSQLPacket('T', []byte{0, 0}),
+		CreatePostgreSQLPacket('C', []byte("SELECT 1\x00")),
+		CreatePostgreSQLPacket('Z', []byte{'I'}),
+	}, nil)))
+	// The data that isn't a whole message, e.g. the rest of a split one, counts as one.
+	assert.Equal(t, 1, CountPostgresMessages([]byte("ELECT 1")))
+}
+
+// TestUsageTracker tests that the traffic is accounted per connection, user and
+// database, and that the usage since the previous flush is logged.
+func TestUsageTracker(t *testing.T) {
+	var usageLog bytes.Buffer
+	tracker := NewUsageTracker(&usageLog, zerolog.Nop())
+
+	newConn := func() *ConnWrapper {
+		server, client := net.Pipe()
+		t.Cleanup(func() {
+			server.Close()
+			client.Close()
+		})
+		return NewConnWrapper(server, nil, 0)
+	}
+	alice, bob := newConn(), newConn()
+	tracker.Identify(alice, "alice", "sales", map[string]string{"user": "alice", "database": "sales"})
+	tracker.Identify(bob, "bob", "sales", map[string]string{"user": "bob", "database": "sales"})
+
+	query := CreatePostgreSQLPacket('Q', []byte("SELECT 1\x00"))
+	tracker.Record(alice, Ingress, query)
+	tracker.Record(alice, Egress, []byte("response"))
+	tracker.Record(bob, Ingress, query)
+
+	report := tracker.Report()
+	assert.Len(t, report.Connections, 2)
+	assert.Equal(t, Usage{
+		BytesIn: uint64(len(query)), BytesOut: 8, MessagesIn: 1, MessagesOut: 1,
+	}, report.Users["alice"])
+	assert.Equal(t, Usage{BytesIn: uint64(len(query)), MessagesIn: 1}, report.Users["bob"])
+	assert.Equal(t, Usage{
+		BytesIn: 2 * uint64(len(query)), BytesOut: 8, MessagesIn: 2, MessagesOut: 1,
+	}, report.Databases["sales"])
+	assert.Equal(t, report.Databases["sales"], report.Total)
+
+	// The usage of the closed connections is kept in the totals.
+	tracker.Close(alice)
+	report = tracker.Report()
+	require.Len(t, report.Connections, 1)
+	assert.Equal(t, "bob", report.Connections[0].User)
+	assert.Equal(t, bob.ID(), report.Connections[0].ID)
+	assert.Equal(t, uint64(8), report.Users["alice"].BytesOut)
+
+	tracker.Flush()
+	var entry UsageLogEntry
+	require.NoError(t, json.Unmarshal(usageLog.Bytes(), &entry))
+	assert.Equal(t, report.Users, entry.Users)
+	assert.Equal(t, report.Databases, entry.Databases)
+
+	// Only the usage since the previous flush is logged, and nothing without usage.
+	usageLog.Reset()
+	tracker.Flush()
+	assert.Empty(t, usageLog.String())
+	tracker.Record(bob, Egress, []byte("response"))
+	tracker.Stop()
+	entry = UsageLogEntry{}
+	require.NoError(t, json.Unmarshal(usageLog.Bytes(), &entry))
+	assert.Equal(t, map[string]Usage{"bob": {BytesOut: 8, MessagesOut: 1}}, entry.Users)
+}
@@ -10,6 +10,7 @@ import (
 	"net"
 
 	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/sdk"
 	"github.com/rs/zerolog"
 )
 
@@ -50,42 +51,57 @@ func Resolve(network, address string, logger zerolog.Logger) (string, *gerr.Gate
 	}
 }
 
-// trafficData creates the ingress/egress map for the traffic hooks.
+// AddressFamily returns the family of the address: ipv4, ipv6 or its network if it
+// isn't an IP address, e.g. unix.
+func AddressFamily(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.Network()
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return addr.Network()
+	case ip.To4() != nil:
+		return "ipv4"
+	default:
+		return "ipv6"
+	}
+}
+
+// trafficData creates the ingress/egress message for the traffic hooks.
 func trafficData(
 	conn net.Conn,
 	client *Client,
-	fields []Field,
+	correlation Correlation,
+	request, response []byte,
 	err interface{},
-) map[string]interface{} {
-	if conn == nil || client == nil {
-		return nil
+) *sdk.TrafficMessage {
+	data := &sdk.TrafficMessage{
+		Request:      request,
+		Response:     response,
+		ConnectionID: correlation.ConnectionID,
+		QueryID:      correlation.QueryID,
 	}
-
-	data := map[string]interface{}{
-		"client": map[string]interface{}{
-			"local":  LocalAddr(conn),
-			"remote": RemoteAddr(conn),
-		},
-		"server": map[string]interface{}{
-			"local":  client.LocalAddr(),
-			"remote": client.RemoteAddr(),
-		},
-		"error": "",
+	if conn != nil {
+		data.Client = sdk.Address{Local: LocalAddr(conn), Remote: RemoteAddr(conn)}
 	}
-
-	for _, field := range fields {
-		data[field.Name] = field.Value
+	if client != nil {
+		data.Server = sdk.Address{Local: client.LocalAddr(), Remote: client.RemoteAddr()}
 	}
 
 	if err != nil {
 		switch typedErr := err.(type) {
 		case *gerr.GatewayDError:
 		case error:
-			data["error"] = typedErr.Error()
+			data.Error = typedErr.Error()
 		case string:
-			data["error"] = typedErr
+			data.Error = typedErr
 		default:
-			data["error"] = fmt.Sprintf("%v", err)
+			data.Error = fmt.Sprintf("%v", err)
 		}
 	}
 
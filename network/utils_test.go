@@ -45,6 +45,14 @@ func TestResolve(t *testing.T) {
 	assert.Equal(t, "127.0.0.1:53", address)
 }
 
+// TestAddressFamily tests the AddressFamily function.
+func TestAddressFamily(t *testing.T) {
+	assert.Equal(t, "ipv4", AddressFamily(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 5432}))
+	assert.Equal(t, "ipv6", AddressFamily(&net.TCPAddr{IP: net.ParseIP("::1"), Port: 5432}))
+	assert.Equal(t, "unix", AddressFamily(&net.UnixAddr{Name: "/tmp/.s.PGSQL.5432", Net: "unix"}))
+	assert.Equal(t, "", AddressFamily(nil))
+}
+
 // TestIsPostgresSSLRequest tests the IsPostgresSSLRequest function.
 // It checks the entire SSL request including the length.
 func TestIsPostgresSSLRequest(t *testing.T) {
@@ -166,19 +174,11 @@ func BenchmarkTrafficData(b *testing.B) {
 		TCPKeepAlivePeriod: time.Second * 10,
 		ReceiveChunkSize:   1024,
 	}, logger, nil)
-	fields := []Field{
-		{
-			Name:  "test",
-			Value: []byte("test"),
-		},
-		{
-			Name:  "test2",
-			Value: big.NewInt(123456).Bytes(),
-		},
-	}
+	request := []byte("test")
+	response := big.NewInt(123456).Bytes()
 	err := "test error"
 	for i := 0; i < b.N; i++ {
-		trafficData(conn.Conn(), client, fields, err)
+		trafficData(conn.Conn(), client, Correlation{}, request, response, err)
 	}
 }
 
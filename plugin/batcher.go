@@ -0,0 +1,184 @@
+package plugin
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/rs/zerolog"
+)
+
+// batchQueueSize is the number of batches that can wait to be sent to the plugin,
+// before the next ones are dropped.
+const batchQueueSize = 16
+
+// HookBatch is the batched delivery that a plugin opts into for its high-frequency
+// hooks, e.g. onTraffic. The arguments of the hooks are collected and sent to the
+// plugin in a single call, once there are Size of them or Interval milliseconds
+// have passed, whichever comes first.
+type HookBatch struct {
+	Hooks    []v1.HookName
+	Size     int
+	Interval int
+}
+
+// Batcher collects the arguments of a hook and sends them to the plugin in batches,
+// as {"batch": [args, ...]}, to amortize the cost of the calls. The batches are
+// sent in order, one at a time, and the results of the plugin are ignored, since
+// the traffic has already moved on by the time a batch is sent.
+type Batcher struct {
+	hookName   v1.HookName
+	priority   sdkPlugin.Priority
+	hookMethod sdkPlugin.Method
+	size       int
+	interval   time.Duration
+	timeout    time.Duration
+	logger     zerolog.Logger
+
+	mu      sync.Mutex
+	pending []*v1.Value
+	timer   *time.Timer
+	queue   chan []*v1.Value
+	done    chan struct{}
+	stopped bool
+}
+
+// NewBatcher creates a new batcher and starts sending its batches to the hook method.
+// The timeout is applied to each call.
+func NewBatcher(
+	hookName v1.HookName,
+	priority sdkPlugin.Priority,
+	hookMethod sdkPlugin.Method,
+	size int,
+	interval, timeout time.Duration,
+	logger zerolog.Logger,
+) *Batcher {
+	batcher := &Batcher{
+		hookName:   hookName,
+		priority:   priority,
+		hookMethod: hookMethod,
+		size:       max(size, 1),
+		interval:   interval,
+		timeout:    timeout,
+		logger:     logger,
+		queue:      make(chan []*v1.Value, batchQueueSize),
+		done:       make(chan struct{}),
+	}
+	go batcher.send()
+	return batcher
+}
+
+// Add adds the arguments of a hook to the current batch, and flushes the batch
+// if it is full.
+func (b *Batcher) Add(args *v1.Struct) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stopped {
+		return
+	}
+
+	b.pending = append(b.pending, v1.NewStructValue(args))
+	if len(b.pending) >= b.size {
+		b.flush()
+		return
+	}
+
+	if b.timer == nil && b.interval > 0 {
+		b.timer = time.AfterFunc(b.interval, b.Flush)
+	}
+}
+
+// Flush queues the current batch to be sent, even if it isn't full.
+func (b *Batcher) Flush() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.stopped {
+		b.flush()
+	}
+}
+
+// flush queues the current batch, or drops it if the queue is full, so that adding
+// arguments on the traffic path never waits for a slow plugin. The batches are queued
+// while holding the lock, so that they are sent in the same order as the arguments
+// were added.
+func (b *Batcher) flush() {
+	b.stopTimer()
+	if len(b.pending) == 0 {
+		return
+	}
+
+	select {
+	case b.queue <- b.pending:
+	default:
+		metrics.PluginHookBatchesDropped.Inc()
+		b.logger.Warn().Fields(
+			map[string]interface{}{
+				"hookName": b.hookName.String(),
+				"priority": b.priority,
+				"size":     len(b.pending),
+			},
+		).Msg("Dropped the batch, because the plugin is too slow to keep up")
+	}
+	b.pending = nil
+}
+
+func (b *Batcher) stopTimer() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+}
+
+// Stop sends the current batch and waits for the queued batches to be sent. The
+// current batch waits for room in the queue, instead of being dropped.
+func (b *Batcher) Stop() {
+	b.mu.Lock()
+	if b.stopped {
+		b.mu.Unlock()
+		return
+	}
+	b.stopped = true
+	b.stopTimer()
+	last := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	// Nothing else is queued once the batcher is stopped.
+	if len(last) > 0 {
+		b.queue <- last
+	}
+	close(b.queue)
+
+	<-b.done
+}
+
+// send sends the queued batches to the hook method, one at a time.
+func (b *Batcher) send() {
+	defer close(b.done)
+
+	for batch := range b.queue {
+		params := &v1.Struct{
+			Fields: map[string]*v1.Value{
+				"batch": v1.NewListValue(&v1.ListValue{Values: batch}),
+			},
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+		if _, err := b.hookMethod(ctx, params); err != nil {
+			b.logger.Error().Err(err).Fields(
+				map[string]interface{}{
+					"hookName": b.hookName.String(),
+					"priority": b.priority,
+					"size":     len(batch),
+				},
+			).Msg("Hook returned an error for the batch")
+		}
+		cancel()
+		metrics.PluginHookBatchesSent.Inc()
+	}
+}
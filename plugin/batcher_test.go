@@ -0,0 +1,122 @@
+package plugin
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// batchRecorder records the batches sent to a hook.
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]interface{}
+}
+
+func (r *batchRecorder) hook(
+	_ context.Context, args *v1.Struct, _ ...grpc.CallOption,
+) (*v1.Struct, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, args.GetFields()["batch"].GetListValue().AsSlice())
+	return args, nil
+}
+
+func (r *batchRecorder) Batches() [][]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.batches
+}
+
+func newBatchArgs(t *testing.T, index int) *v1.Struct {
+	t.Helper()
+
+	args, err := v1.NewStruct(map[string]interface{}{"index": index})
+	assert.Nil(t, err)
+	return args
+}
+
+// Test_Batcher tests that the arguments are sent in order, in batches of the given size.
+func Test_Batcher(t *testing.T) {
+	recorder := &batchRecorder{}
+	batcher := NewBatcher(
+		v1.HookName_HOOK_NAME_ON_TRAFFIC, 0, recorder.hook, 2, time.Hour, time.Second, zerolog.Nop())
+
+	for index := 0; index < 5; index++ {
+		batcher.Add(newBatchArgs(t, index))
+	}
+	// The last, incomplete batch is sent on stop.
+	batcher.Stop()
+
+	assert.Equal(t, [][]interface{}{
+		{map[string]interface{}{"index": 0.0}, map[string]interface{}{"index": 1.0}},
+		{map[string]interface{}{"index": 2.0}, map[string]interface{}{"index": 3.0}},
+		{map[string]interface{}{"index": 4.0}},
+	}, recorder.Batches())
+
+	// Arguments added after stop are dropped.
+	batcher.Add(newBatchArgs(t, 5))
+	assert.Len(t, recorder.Batches(), 3)
+}
+
+// Test_Batcher_Interval tests that an incomplete batch is sent after the interval.
+func Test_Batcher_Interval(t *testing.T) {
+	recorder := &batchRecorder{}
+	batcher := NewBatcher(
+		v1.HookName_HOOK_NAME_ON_TRAFFIC, 0, recorder.hook, 100, 10*time.Millisecond, time.Second, zerolog.Nop())
+	defer batcher.Stop()
+
+	batcher.Add(newBatchArgs(t, 0))
+	assert.Eventually(t, func() bool {
+		return len(recorder.Batches()) == 1
+	}, time.Second, time.Millisecond)
+}
+
+// Test_Batcher_QueueFull tests that the batches are dropped, instead of blocking the
+// caller, once the queue is full.
+func Test_Batcher_QueueFull(t *testing.T) {
+	recorder := &batchRecorder{}
+	unblock := make(chan struct{})
+	sending := make(chan struct{}, 1)
+	slowHook := func(ctx context.Context, args *v1.Struct, opts ...grpc.CallOption) (*v1.Struct, error) {
+		select {
+		case sending <- struct{}{}:
+		default:
+		}
+		<-unblock
+		return recorder.hook(ctx, args, opts...)
+	}
+	batcher := NewBatcher(
+		v1.HookName_HOOK_NAME_ON_TRAFFIC, 0, slowHook, 1, time.Hour, time.Minute, zerolog.Nop())
+
+	// The first batch is being sent, and the next ones fill the queue.
+	batcher.Add(newBatchArgs(t, 0))
+	<-sending
+	for index := 1; index <= batchQueueSize; index++ {
+		batcher.Add(newBatchArgs(t, index))
+	}
+
+	dropped := testutil.ToFloat64(metrics.PluginHookBatchesDropped)
+	added := make(chan struct{})
+	go func() {
+		batcher.Add(newBatchArgs(t, batchQueueSize+1))
+		close(added)
+	}()
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		t.Fatal("Adding to a full queue blocked")
+	}
+	assert.Equal(t, dropped+1, testutil.ToFloat64(metrics.PluginHookBatchesDropped))
+
+	close(unblock)
+	batcher.Stop()
+	assert.Len(t, recorder.Batches(), batchQueueSize+1)
+}
@@ -0,0 +1,450 @@
+package plugin
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+	"sync"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+)
+
+// The names of the built-in plugins in the registry.
+const (
+	BuiltinCacheName       = "builtin-cache"
+	BuiltinMetricsName     = "builtin-metrics"
+	BuiltinQueryLoggerName = "builtin-query-logger"
+)
+
+// volatileKeywords are the parts of the read-only queries whose results change from one
+// run to the next, or that lock rows, so they aren't cached.
+var volatileKeywords = []string{
+	" for update", " for share", " for no key update", " for key share", " into ",
+	"nextval(", "setval(", "now()", "random()", "clock_timestamp(", "current_", "pg_", "txid_",
+}
+
+// builtin is a plugin compiled into GatewayD, with its hooks.
+type builtin struct {
+	name         string
+	description  string
+	mode         string
+	replacedBy   []string
+	capabilities []Capability
+	hooks        map[v1.HookName]sdkPlugin.Method
+}
+
+// LoadBuiltins adds the built-in plugins to the registry, with priorities before the
+// ones of the plugins, unless they are disabled or, in the auto mode, replaced by one of
+// the loaded plugins. It is called once the plugins are loaded.
+func (reg *Registry) LoadBuiltins(cfg config.BuiltinPlugins) {
+	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "LoadBuiltins")
+	defer span.End()
+
+	loaded := map[string]bool{}
+	reg.ForEach(func(pluginID sdkPlugin.Identifier, _ *Plugin) {
+		loaded[pluginID.Name] = true
+	})
+
+	cache := newBuiltinCache(cfg.Cache)
+	metrics := &builtinMetrics{labels: cfg.Metrics.Labels}
+	queryLogger := newBuiltinQueryLogger(cfg.QueryLogger, reg.Logger)
+	// The query logger runs first, so that it logs the queries the cache answers.
+	builtins := []builtin{
+		{
+			name:         BuiltinQueryLoggerName,
+			description:  "Logs the queries of the clients",
+			mode:         cfg.QueryLogger.Mode,
+			replacedBy:   cfg.QueryLogger.ReplacedBy,
+			capabilities: []Capability{ReadTraffic},
+			hooks: map[v1.HookName]sdkPlugin.Method{
+				sdk.OnTrafficFromClient: queryLogger.onTrafficFromClient,
+				sdk.OnClosed:            queryLogger.sessions.onClosed,
+			},
+		},
+		{
+			name:        BuiltinMetricsName,
+			description: "Records the metrics by the user, database and application of the connections",
+			mode:        cfg.Metrics.Mode,
+			replacedBy:  cfg.Metrics.ReplacedBy,
+			hooks: map[v1.HookName]sdkPlugin.Method{
+				sdk.OnMetric: metrics.onMetric,
+			},
+		},
+		{
+			name:         BuiltinCacheName,
+			description:  "Serves the results of the read-only queries from memory",
+			mode:         cfg.Cache.Mode,
+			replacedBy:   cfg.Cache.ReplacedBy,
+			capabilities: []Capability{ReadTraffic, TerminateConnections},
+			hooks: map[v1.HookName]sdkPlugin.Method{
+				sdk.OnTrafficFromClient: cache.onTrafficFromClient,
+				sdk.OnTrafficToClient:   cache.onTrafficToClient,
+				sdk.OnClosed:            cache.sessions.onClosed,
+			},
+		},
+	}
+
+	for index, builtin := range builtins {
+		mode, ok := config.BuiltinModes[builtin.mode]
+		if !ok {
+			reg.Logger.Warn().Str("name", builtin.name).Str("mode", builtin.mode).Msg(
+				"Unknown mode of the built-in plugin, so it is disabled")
+			continue
+		}
+		if mode == config.BuiltinDisabled {
+			continue
+		}
+		if replacement := firstLoaded(builtin.replacedBy, loaded); mode == config.BuiltinAuto &&
+			replacement != "" {
+			reg.Logger.Debug().Str("name", builtin.name).Str("replacedBy", replacement).Msg(
+				"Built-in plugin is replaced by a plugin")
+			continue
+		}
+
+		plugin := &Plugin{
+			ID:          sdkPlugin.Identifier{Name: builtin.name, Version: config.Version},
+			Description: builtin.description,
+			Enabled:     true,
+			Priority:    sdkPlugin.Priority(index),
+		}
+		for hookName, hookMethod := range builtin.hooks {
+			if err := reg.AddHook(hookName, plugin.Priority, hookMethod); err != nil {
+				reg.Logger.Error().Err(err).Str("name", builtin.name).Msg(
+					"Failed to register the hook of the built-in plugin")
+				continue
+			}
+			plugin.Hooks = append(plugin.Hooks, hookName)
+		}
+		reg.Add(plugin)
+		reg.SetCapabilities(plugin.Priority, builtin.capabilities)
+		reg.Logger.Info().Str("name", builtin.name).Msg("Built-in plugin is ready")
+	}
+}
+
+// Builtins returns the names of the built-in plugins in the registry.
+func (reg *Registry) Builtins() []string {
+	var names []string
+	reg.ForEach(func(pluginID sdkPlugin.Identifier, plugin *Plugin) {
+		if plugin.Client == nil && strings.HasPrefix(pluginID.Name, "builtin-") {
+			names = append(names, pluginID.Name)
+		}
+	})
+	return names
+}
+
+// firstLoaded returns the first of the plugins that is loaded, if any.
+func firstLoaded(plugins []string, loaded map[string]bool) string {
+	for _, name := range plugins {
+		if loaded[name] {
+			return name
+		}
+	}
+	return ""
+}
+
+// builtinSession is the user and database of a connection.
+type builtinSession struct {
+	user     string
+	database string
+}
+
+// builtinSessions tracks the user and database of the connections from their startup
+// messages, by connection ID, since the traffic hooks don't receive them.
+type builtinSessions struct {
+	sessions sync.Map
+}
+
+// track records the session of the connection if the request is its startup message,
+// and returns true if it is.
+func (s *builtinSessions) track(args *v1.Struct) bool {
+	parameters := sdk.StartupParameters(args)
+	if parameters == nil {
+		return false
+	}
+	s.sessions.Store(sdk.ConnectionID(args), builtinSession{
+		user:     parameters["user"],
+		database: parameters["database"],
+	})
+	return true
+}
+
+// get returns the session of the connection, if its startup message was tracked.
+func (s *builtinSessions) get(args *v1.Struct) (builtinSession, bool) {
+	session, ok := s.sessions.Load(sdk.ConnectionID(args))
+	if !ok {
+		return builtinSession{}, false
+	}
+	return session.(builtinSession), true //nolint:forcetypeassert
+}
+
+// onClosed forgets the session of the closed connection.
+func (s *builtinSessions) onClosed(
+	_ context.Context, args *v1.Struct, _ ...grpc.CallOption,
+) (*v1.Struct, error) {
+	s.sessions.Delete(sdk.ConnectionID(args))
+	return args, nil
+}
+
+// builtinCacheEntry is a cached response, until it expires.
+type builtinCacheEntry struct {
+	response []byte
+	expires  time.Time
+}
+
+// builtinCache serves the responses of the read-only simple queries from memory, by the
+// user and database of the connection, until their TTL. Any other query evicts the
+// responses of its database, and the responses aren't cached once there are the max
+// entries of unexpired ones.
+type builtinCache struct {
+	sessions   builtinSessions
+	ttl        time.Duration
+	maxEntries int
+
+	mu sync.Mutex
+	// entries holds the responses by database, and then by user and query.
+	entries map[string]map[string]*builtinCacheEntry
+	size    int
+
+	// now returns the current time, which is replaced in the tests.
+	now func() time.Time
+}
+
+func newBuiltinCache(cfg config.BuiltinCache) *builtinCache {
+	return &builtinCache{
+		ttl: config.If[time.Duration](cfg.TTL > 0, cfg.TTL, config.DefaultBuiltinCacheTTL),
+		maxEntries: config.If[int](
+			cfg.MaxEntries > 0, cfg.MaxEntries, config.DefaultBuiltinCacheMaxEntries),
+		entries: map[string]map[string]*builtinCacheEntry{},
+		now:     time.Now,
+	}
+}
+
+// onTrafficFromClient answers the read-only query from the cache, if its response is
+// cached, and evicts the responses of the database on any other query.
+func (c *builtinCache) onTrafficFromClient(
+	_ context.Context, args *v1.Struct, _ ...grpc.CallOption,
+) (*v1.Struct, error) {
+	if c.sessions.track(args) {
+		return args, nil
+	}
+	session, ok := c.sessions.get(args)
+	if !ok {
+		return args, nil
+	}
+	query := sdk.Query(args)
+	if query == "" {
+		return args, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !ReadOnlyQuery(query) {
+		c.size -= len(c.entries[session.database])
+		delete(c.entries, session.database)
+		return args, nil
+	}
+	if !simpleQuery(sdk.Request(args)) {
+		return args, nil
+	}
+	entry, ok := c.entries[session.database][session.user+"\x00"+query]
+	if !ok || !c.now().Before(entry.expires) {
+		return args, nil
+	}
+	return sdk.Terminate(args, entry.response), nil
+}
+
+// onTrafficToClient caches the complete response of the read-only simple query, unless
+// it failed or ran in a transaction.
+func (c *builtinCache) onTrafficToClient(
+	_ context.Context, args *v1.Struct, _ ...grpc.CallOption,
+) (*v1.Struct, error) {
+	// The streamed responses are too large to be cached.
+	if _, streamed := args.GetFields()["stream"]; streamed {
+		return args, nil
+	}
+	session, ok := c.sessions.get(args)
+	if !ok {
+		return args, nil
+	}
+	query := sdk.Query(args)
+	response := sdk.Response(args)
+	if !simpleQuery(sdk.Request(args)) || !ReadOnlyQuery(query) || !completeResponse(response) {
+		return args, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if c.size >= c.maxEntries {
+		for database, entries := range c.entries {
+			for key, entry := range entries {
+				if !now.Before(entry.expires) {
+					delete(entries, key)
+					c.size--
+				}
+			}
+			if len(entries) == 0 {
+				delete(c.entries, database)
+			}
+		}
+		if c.size >= c.maxEntries {
+			return args, nil
+		}
+	}
+	if c.entries[session.database] == nil {
+		c.entries[session.database] = map[string]*builtinCacheEntry{}
+	}
+	key := session.user + "\x00" + query
+	if _, exists := c.entries[session.database][key]; !exists {
+		c.size++
+	}
+	c.entries[session.database][key] = &builtinCacheEntry{
+		response: response,
+		expires:  now.Add(c.ttl),
+	}
+	return args, nil
+}
+
+// ReadOnlyQuery returns true if the query is a single SELECT that doesn't lock rows or
+// call the common volatile functions, so that its result can be cached and it can be
+// retried on another server.
+func ReadOnlyQuery(query string) bool {
+	query = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(query)), ";")
+	if !strings.HasPrefix(query, "select") || strings.Contains(query, ";") {
+		return false
+	}
+	for _, keyword := range volatileKeywords {
+		if strings.Contains(query, keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// simpleQuery returns true if the request is a single Query message.
+//
+//nolint:gomnd
+func simpleQuery(request []byte) bool {
+	return len(request) >= 5 && request[0] == 'Q' &&
+		int(binary.BigEndian.Uint32(request[1:5])) == len(request)-1
+}
+
+// completeResponse returns true if the response holds whole messages, without an
+// ErrorResponse, and ends with a ReadyForQuery outside of a transaction.
+//
+//nolint:gomnd
+func completeResponse(response []byte) bool {
+	var last byte
+	var status byte
+	offset := 0
+	for offset+5 <= len(response) {
+		length := int(binary.BigEndian.Uint32(response[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(response) {
+			return false
+		}
+		last = response[offset]
+		if last == 'E' {
+			return false
+		}
+		if last == 'Z' && length == 5 {
+			status = response[offset+5]
+		}
+		offset += 1 + length
+	}
+	return offset == len(response) && last == 'Z' && status == 'I'
+}
+
+// builtinMetrics records the samples of the metrics by the user, database and
+// application of their connections, with the static labels, as derived metrics.
+type builtinMetrics struct {
+	labels map[string]string
+}
+
+// onMetric derives the metric of the sample, with the labels of its connection and the
+// static labels.
+func (m *builtinMetrics) onMetric(
+	_ context.Context, args *v1.Struct, _ ...grpc.CallOption,
+) (*v1.Struct, error) {
+	sample := args.AsMap()
+	labels, _ := sample["labels"].(map[string]interface{})
+	if labels == nil {
+		labels = map[string]interface{}{}
+	}
+	for name, value := range m.labels {
+		labels[name] = value
+	}
+	name, _ := sample["name"].(string)
+	return v1.NewStruct(map[string]interface{}{ //nolint:wrapcheck
+		"metrics": []interface{}{
+			map[string]interface{}{
+				"name":   name,
+				"type":   sample["type"],
+				"value":  sample["value"],
+				"labels": labels,
+				"help":   name + " by the user, database and application of the connections",
+			},
+		},
+	})
+}
+
+// builtinQueryLogger logs the queries of the clients, with the user and database of
+// their connections.
+type builtinQueryLogger struct {
+	sessions       builtinSessions
+	logger         zerolog.Logger
+	level          zerolog.Level
+	maxQueryLength int
+}
+
+func newBuiltinQueryLogger(cfg config.BuiltinQueryLogger, logger zerolog.Logger) *builtinQueryLogger {
+	level, ok := config.LogLevels[cfg.Level]
+	if !ok {
+		level = config.LogLevels[config.DefaultBuiltinQueryLoggerLevel]
+	}
+	return &builtinQueryLogger{
+		logger: logger,
+		level:  level,
+		maxQueryLength: config.If[int](
+			cfg.MaxQueryLength > 0, cfg.MaxQueryLength, config.DefaultBuiltinMaxQueryLength),
+	}
+}
+
+// onTrafficFromClient logs the query of the request, if any, truncated to the max
+// query length.
+func (l *builtinQueryLogger) onTrafficFromClient(
+	_ context.Context, args *v1.Struct, _ ...grpc.CallOption,
+) (*v1.Struct, error) {
+	if l.sessions.track(args) {
+		return args, nil
+	}
+	event := l.logger.WithLevel(l.level)
+	if !event.Enabled() {
+		return args, nil
+	}
+	query := sdk.Query(args)
+	if query == "" {
+		event.Discard()
+		return args, nil
+	}
+	if len(query) > l.maxQueryLength {
+		query = query[:l.maxQueryLength] + "..."
+	}
+
+	session, _ := l.sessions.get(args)
+	event.Fields(map[string]interface{}{
+		"user":         session.user,
+		"database":     session.database,
+		"client":       sdk.Client(args).Remote,
+		"connectionId": sdk.ConnectionID(args),
+		"queryId":      sdk.QueryID(args),
+		"query":        query,
+	}).Msg("Query")
+	return args, nil
+}
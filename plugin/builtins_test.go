@@ -0,0 +1,145 @@
+package plugin
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// message returns a message of the PostgreSQL protocol with the type and body.
+func message(messageType byte, body string) []byte {
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(body)+4))
+	return append(append([]byte{messageType}, length...), body...)
+}
+
+// trafficArgs returns the arguments of the traffic hooks of the connection.
+func trafficArgs(t *testing.T, request, response []byte) *v1.Struct {
+	t.Helper()
+
+	fields := map[string]interface{}{
+		"connectionId": "connection-1",
+		"request":      request,
+	}
+	if response != nil {
+		fields["response"] = response
+	}
+	args, err := v1.NewStruct(fields)
+	require.NoError(t, err)
+	return args
+}
+
+// startupMessage returns the startup message of the user and database.
+func startupMessage(user, database string) []byte {
+	body := []byte{0, 3, 0, 0}
+	body = append(body, "user\x00"+user+"\x00database\x00"+database+"\x00\x00"...)
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(body)+4))
+	return append(length, body...)
+}
+
+// Test_builtinCache tests that the responses of the read-only queries are served from
+// the cache until their TTL, and that the other queries evict them.
+func Test_builtinCache(t *testing.T) {
+	cache := newBuiltinCache(config.BuiltinCache{TTL: time.Minute, MaxEntries: 10})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := cache.onTrafficFromClient(ctx, trafficArgs(t, startupMessage("postgres", "app"), nil))
+	require.NoError(t, err)
+
+	query := message('Q', "SELECT 1\x00")
+	response := append(message('T', "row"), message('Z', "I")...)
+	result, err := cache.onTrafficFromClient(ctx, trafficArgs(t, query, nil))
+	require.NoError(t, err)
+	assert.False(t, result.GetFields()[sdk.FieldTerminate].GetBoolValue())
+
+	// The responses of the failed queries aren't cached.
+	_, err = cache.onTrafficToClient(ctx, trafficArgs(t, query, message('E', "error")))
+	require.NoError(t, err)
+	assert.Equal(t, 0, cache.size)
+
+	_, err = cache.onTrafficToClient(ctx, trafficArgs(t, query, response))
+	require.NoError(t, err)
+	result, err = cache.onTrafficFromClient(ctx, trafficArgs(t, query, nil))
+	require.NoError(t, err)
+	assert.True(t, result.GetFields()[sdk.FieldTerminate].GetBoolValue())
+	assert.Equal(t, response, sdk.Response(result))
+
+	now = now.Add(time.Minute)
+	result, err = cache.onTrafficFromClient(ctx, trafficArgs(t, query, nil))
+	require.NoError(t, err)
+	assert.False(t, result.GetFields()[sdk.FieldTerminate].GetBoolValue())
+
+	_, err = cache.onTrafficToClient(ctx, trafficArgs(t, query, response))
+	require.NoError(t, err)
+	_, err = cache.onTrafficFromClient(
+		ctx, trafficArgs(t, message('Q', "UPDATE users SET name = 'a'\x00"), nil))
+	require.NoError(t, err)
+	assert.Equal(t, 0, cache.size)
+	result, err = cache.onTrafficFromClient(ctx, trafficArgs(t, query, nil))
+	require.NoError(t, err)
+	assert.False(t, result.GetFields()[sdk.FieldTerminate].GetBoolValue())
+}
+
+// Test_builtinMetrics tests that the samples are derived with the static labels.
+func Test_builtinMetrics(t *testing.T) {
+	metrics := &builtinMetrics{labels: map[string]string{"region": "eu"}}
+	args, err := MetricSample{
+		Name:   "proxy_query_latency_seconds",
+		Type:   Histogram,
+		Value:  0.5,
+		Labels: map[string]string{"user": "postgres"},
+	}.args()
+	require.NoError(t, err)
+
+	result, err := metrics.onMetric(context.Background(), args)
+	require.NoError(t, err)
+	derived := result.AsMap()["metrics"].([]interface{})
+	require.Len(t, derived, 1)
+	metric := derived[0].(map[string]interface{})
+	assert.Equal(t, "proxy_query_latency_seconds", metric["name"])
+	assert.Equal(t, "histogram", metric["type"])
+	assert.InDelta(t, 0.5, metric["value"], 0)
+	assert.Equal(t, map[string]interface{}{"user": "postgres", "region": "eu"}, metric["labels"])
+}
+
+// Test_Registry_LoadBuiltins tests that the built-in plugins are loaded unless they are
+// disabled or, in the auto mode, replaced by a loaded plugin.
+func Test_Registry_LoadBuiltins(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	reg.Add(&Plugin{
+		ID:       sdkPlugin.Identifier{Name: "gatewayd-plugin-metrics"},
+		Priority: config.PluginPriorityStart,
+	})
+
+	builtins := config.DefaultPluginConfig().Builtins
+	builtins.Cache.Mode = string(config.BuiltinEnabled)
+	reg.LoadBuiltins(builtins)
+
+	assert.ElementsMatch(t, []string{BuiltinCacheName, BuiltinQueryLoggerName}, reg.Builtins())
+	queryLogger := reg.Get(sdkPlugin.Identifier{Name: BuiltinQueryLoggerName, Version: config.Version})
+	require.NotNil(t, queryLogger)
+	assert.Equal(t, sdkPlugin.Priority(0), queryLogger.Priority)
+	assert.Nil(t, queryLogger.Ping())
+	assert.Len(t, reg.Hooks()[sdk.OnTrafficFromClient], 2)
+}
+
+func TestReadOnlyQuery(t *testing.T) {
+	assert.True(t, ReadOnlyQuery("SELECT * FROM users WHERE id = 1;"))
+	assert.True(t, ReadOnlyQuery("  select name from users"))
+	assert.False(t, ReadOnlyQuery("SELECT * FROM users FOR UPDATE"))
+	assert.False(t, ReadOnlyQuery("SELECT now()"))
+	assert.False(t, ReadOnlyQuery("SELECT nextval('ids')"))
+	assert.False(t, ReadOnlyQuery("SELECT 1; DELETE FROM users"))
+	assert.False(t, ReadOnlyQuery("INSERT INTO users VALUES (1)"))
+}
@@ -0,0 +1,123 @@
+package plugin
+
+import (
+	"bytes"
+	"strings"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+)
+
+// Capability is what a plugin is allowed to do with the arguments of the hooks.
+// Plugins declare their capabilities in the "capabilities" field of their metadata.
+type Capability string
+
+const (
+	// ReadTraffic lets the plugin see the requests and responses.
+	ReadTraffic Capability = "read_traffic"
+	// ModifyTraffic lets the plugin rewrite the requests and responses.
+	ModifyTraffic Capability = "modify_traffic"
+	// TerminateConnections lets the plugin stop the request from reaching the
+	// database, and answer the client itself.
+	TerminateConnections Capability = "terminate_connections"
+	// AccessSecrets lets the plugin see the secrets in the configuration, e.g. passwords.
+	AccessSecrets Capability = "access_secrets"
+)
+
+// trafficFields are the fields of the arguments that hold the traffic.
+var trafficFields = []string{"request", "response"}
+
+// secretKeywords are the parts of the configuration keys that hold secrets.
+var secretKeywords = []string{"password", "secret", "token"}
+
+// RemoveFields returns a new struct without the given fields of the params.
+func RemoveFields(params *v1.Struct, fields []string) *v1.Struct {
+	removed := &v1.Struct{Fields: make(map[string]*v1.Value, len(params.GetFields()))}
+	for key, value := range params.GetFields() {
+		removed.Fields[key] = value
+	}
+	for _, field := range fields {
+		delete(removed.Fields, field)
+	}
+	return removed
+}
+
+// RemoveSecrets returns a new struct without the fields of the params, including the
+// nested ones, whose keys look like they hold secrets, e.g. "password".
+func RemoveSecrets(params *v1.Struct) *v1.Struct {
+	removed := &v1.Struct{Fields: make(map[string]*v1.Value, len(params.GetFields()))}
+	for key, value := range params.GetFields() {
+		if isSecret(key) {
+			continue
+		}
+		if nested := value.GetStructValue(); nested != nil {
+			value = v1.NewStructValue(RemoveSecrets(nested))
+		}
+		removed.Fields[key] = value
+	}
+	return removed
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, keyword := range secretKeywords {
+		if strings.Contains(key, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictParams removes the fields of the params that the capabilities don't allow
+// the plugin to see. It returns true if any fields were removed.
+func restrictParams(
+	hookName v1.HookName, params *v1.Struct, capabilities map[Capability]bool,
+) (*v1.Struct, bool) {
+	restricted := false
+	if !capabilities[ReadTraffic] {
+		for _, field := range trafficFields {
+			if _, ok := params.GetFields()[field]; ok {
+				params = RemoveFields(params, trafficFields)
+				restricted = true
+				break
+			}
+		}
+	}
+	if !capabilities[AccessSecrets] && hookName == v1.HookName_HOOK_NAME_ON_CONFIG_LOADED {
+		params = RemoveSecrets(params)
+		restricted = true
+	}
+	return params, restricted
+}
+
+// enforceResult reverts the changes of the result that the capabilities don't allow
+// the plugin to make. It returns the enforced result and true if any changes were reverted.
+func enforceResult(
+	params, result *v1.Struct, capabilities map[Capability]bool,
+) (*v1.Struct, bool) {
+	violated := false
+	if !capabilities[ModifyTraffic] {
+		for _, field := range trafficFields {
+			original, existed := params.GetFields()[field]
+			modified, exists := result.GetFields()[field]
+			if existed == exists &&
+				bytes.Equal(original.GetBytesValue(), modified.GetBytesValue()) &&
+				original.GetStringValue() == modified.GetStringValue() {
+				continue
+			}
+			if !violated {
+				result = RemoveFields(result, nil)
+				violated = true
+			}
+			if existed {
+				result.Fields[field] = original
+			} else {
+				delete(result.Fields, field)
+			}
+		}
+	}
+	if !capabilities[TerminateConnections] && result.GetFields()["terminate"].GetBoolValue() {
+		result = RemoveFields(result, []string{"terminate"})
+		violated = true
+	}
+	return result, violated
+}
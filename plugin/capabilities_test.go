@@ -0,0 +1,74 @@
+package plugin
+
+import (
+	"testing"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_RemoveSecrets tests that the secrets are removed from the nested fields.
+func Test_RemoveSecrets(t *testing.T) {
+	params, err := v1.NewStruct(map[string]interface{}{
+		"clients": map[string]interface{}{
+			"default": map[string]interface{}{
+				"address":  "localhost:5432",
+				"password": "postgres",
+			},
+		},
+		"apiToken": "token",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"clients": map[string]interface{}{
+			"default": map[string]interface{}{
+				"address": "localhost:5432",
+			},
+		},
+	}, RemoveSecrets(params).AsMap())
+	// The params are left as is.
+	assert.Equal(t, "token", params.GetFields()["apiToken"].GetStringValue())
+}
+
+// Test_Capabilities tests that the traffic is hidden from and its changes are
+// reverted for the plugins without the capabilities.
+func Test_Capabilities(t *testing.T) {
+	params, err := v1.NewStruct(map[string]interface{}{
+		"request": []byte("request"),
+		"error":   "",
+	})
+	require.NoError(t, err)
+
+	restricted, ok := restrictParams(v1.HookName_HOOK_NAME_ON_TRAFFIC, params, map[Capability]bool{})
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"error": ""}, restricted.AsMap())
+
+	restricted, ok = restrictParams(
+		v1.HookName_HOOK_NAME_ON_TRAFFIC, params, map[Capability]bool{ReadTraffic: true})
+	assert.False(t, ok)
+	assert.Equal(t, params, restricted)
+
+	result, err := v1.NewStruct(map[string]interface{}{
+		"request":   []byte("modified"),
+		"response":  []byte("response"),
+		"terminate": true,
+		"error":     "",
+	})
+	require.NoError(t, err)
+
+	enforced, violated := enforceResult(params, result, map[Capability]bool{ReadTraffic: true})
+	assert.True(t, violated)
+	assert.Equal(t, map[string]interface{}{
+		"request": []byte("request"),
+		"error":   "",
+	}, enforced.AsMap())
+
+	enforced, violated = enforceResult(params, result, map[Capability]bool{
+		ModifyTraffic:        true,
+		TerminateConnections: true,
+	})
+	assert.False(t, violated)
+	assert.Equal(t, result, enforced)
+}
@@ -0,0 +1,135 @@
+package plugin
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"sync"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/klauspost/compress/zstd"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip" // Registers the gzip compressor.
+	"google.golang.org/protobuf/proto"
+)
+
+func init() {
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+// HookCompression compresses the arguments of the hooks sent to the plugins, and their
+// results, once the arguments reach the threshold. The compressor of each plugin is
+// negotiated when it is loaded, from the compressors it declares in its metadata.
+type HookCompression struct {
+	compressors []string
+	threshold   int
+}
+
+// NewHookCompression creates the hook compression of the config. The unknown
+// compressors are skipped, and it is nil if it is disabled or there are none.
+func NewHookCompression(cfg config.PluginCompression, logger zerolog.Logger) *HookCompression {
+	if !cfg.Enabled {
+		return nil
+	}
+	compression := &HookCompression{threshold: max(cfg.Threshold, 0)}
+	for _, name := range cfg.Algorithms {
+		if encoding.GetCompressor(name) == nil {
+			logger.Warn().Str("algorithm", name).Msg(
+				"Unknown algorithm in the plugin compression, skipping")
+			continue
+		}
+		compression.compressors = append(compression.compressors, name)
+	}
+	if len(compression.compressors) == 0 {
+		return nil
+	}
+	return compression
+}
+
+// Negotiate returns the first of the configured compressors the plugin supports, or an
+// empty string if it supports none of them.
+func (c *HookCompression) Negotiate(supported []string) string {
+	if c == nil {
+		return ""
+	}
+	for _, compressor := range c.compressors {
+		if slices.Contains(supported, compressor) {
+			return compressor
+		}
+	}
+	return ""
+}
+
+// Compress returns true if the arguments are large enough to be compressed.
+func (c *HookCompression) Compress(args *v1.Struct) bool {
+	return c != nil && proto.Size(args) >= c.threshold
+}
+
+// zstdCompressor is the zstd compressor of the gRPC calls, which reuses the encoders
+// and decoders, as creating them is expensive.
+type zstdCompressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+var _ encoding.Compressor = (*zstdCompressor)(nil)
+
+// zstdWriter returns its encoder to the pool once closed.
+type zstdWriter struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+func (w *zstdWriter) Close() error {
+	defer w.pool.Put(w)
+	return w.Encoder.Close() //nolint:wrapcheck
+}
+
+// zstdReader returns its decoder to the pool once it is read.
+type zstdReader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	n, err := r.Decoder.Read(p)
+	if errors.Is(err, io.EOF) {
+		r.pool.Put(r)
+	}
+	return n, err //nolint:wrapcheck
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if writer, ok := c.encoders.Get().(*zstdWriter); ok {
+		writer.Reset(w)
+		return writer, nil
+	}
+	// The fastest level, as the point is to cut the CPU and the latency.
+	encoder, err := zstd.NewWriter(w,
+		zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return &zstdWriter{Encoder: encoder, pool: &c.encoders}, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	if reader, ok := c.decoders.Get().(*zstdReader); ok {
+		if err := reader.Reset(r); err != nil {
+			c.decoders.Put(reader)
+			return nil, err //nolint:wrapcheck
+		}
+		return reader, nil
+	}
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return &zstdReader{Decoder: decoder, pool: &c.decoders}, nil
+}
+
+func (c *zstdCompressor) Name() string {
+	return config.ZstdPluginCompressor
+}
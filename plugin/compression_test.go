@@ -0,0 +1,106 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+func TestNewHookCompression(t *testing.T) {
+	assert.Nil(t, NewHookCompression(config.PluginCompression{
+		Algorithms: []string{config.ZstdPluginCompressor},
+	}, zerolog.Nop()))
+	assert.Nil(t, NewHookCompression(config.PluginCompression{
+		Enabled:    true,
+		Algorithms: []string{"lz4"},
+	}, zerolog.Nop()))
+
+	compression := NewHookCompression(config.PluginCompression{
+		Enabled:    true,
+		Algorithms: []string{"lz4", config.ZstdPluginCompressor, config.GzipPluginCompressor},
+		Threshold:  64,
+	}, zerolog.Nop())
+	require.NotNil(t, compression)
+	assert.Equal(t, "zstd", compression.Negotiate([]string{"gzip", "zstd"}))
+	assert.Equal(t, "gzip", compression.Negotiate([]string{"gzip"}))
+	assert.Equal(t, "", compression.Negotiate([]string{"lz4"}))
+	assert.Equal(t, "", compression.Negotiate(nil))
+
+	small, err := v1.NewStruct(map[string]interface{}{"query": "SELECT 1"})
+	require.NoError(t, err)
+	assert.False(t, compression.Compress(small))
+	large, err := v1.NewStruct(map[string]interface{}{"query": strings.Repeat("SELECT 1;", 16)})
+	require.NoError(t, err)
+	assert.True(t, compression.Compress(large))
+}
+
+// TestZstdCompressor tests that the zstd compressor is registered, and that the data it
+// compresses is decompressed, with the encoders and decoders reused.
+func TestZstdCompressor(t *testing.T) {
+	compressor := encoding.GetCompressor(config.ZstdPluginCompressor)
+	require.NotNil(t, compressor)
+
+	data := []byte(strings.Repeat("SELECT * FROM users;", 100))
+	for range 3 {
+		var compressed bytes.Buffer
+		writer, err := compressor.Compress(&compressed)
+		require.NoError(t, err)
+		_, err = writer.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		assert.Less(t, compressed.Len(), len(data))
+
+		reader, err := compressor.Decompress(&compressed)
+		require.NoError(t, err)
+		decompressed, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, data, decompressed)
+	}
+}
+
+// Test_HookRegistry_Run_Compression tests that the large arguments of the hooks of the
+// plugins that negotiated a compressor are compressed.
+func Test_HookRegistry_Run_Compression(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	var compressors []string
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, 0, func(
+		ctx context.Context, args *v1.Struct, opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		compressor := ""
+		for _, opt := range opts {
+			if option, ok := opt.(grpc.CompressorCallOption); ok {
+				compressor = option.CompressorType
+			}
+		}
+		compressors = append(compressors, compressor)
+		return args, nil
+	})
+	reg.SetHookCompression(NewHookCompression(config.PluginCompression{
+		Enabled:    true,
+		Algorithms: []string{config.ZstdPluginCompressor},
+		Threshold:  64,
+	}, zerolog.Nop()))
+
+	small := map[string]interface{}{"request": "data"}
+	large := map[string]interface{}{"request": strings.Repeat("data", 64)}
+	_, err := reg.Run(context.Background(), large, v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
+	require.Nil(t, err)
+
+	reg.SetCompressor(0, config.ZstdPluginCompressor)
+	_, err = reg.Run(context.Background(), small, v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
+	require.Nil(t, err)
+	_, err = reg.Run(context.Background(), large, v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{"", "", "zstd"}, compressors)
+}
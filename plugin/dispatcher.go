@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/rs/zerolog"
+)
+
+type invocation struct {
+	hookName   v1.HookName
+	priority   sdkPlugin.Priority
+	hookMethod sdkPlugin.Method
+	args       *v1.Struct
+}
+
+// Dispatcher runs the async hooks in the background with a fixed number of workers,
+// so that the observe-only plugins, e.g. audit or metrics, add no latency to the
+// queries. The invocations are queued in a bounded queue, and dropped if it is full.
+type Dispatcher struct {
+	queue   chan invocation
+	timeout time.Duration
+	logger  zerolog.Logger
+
+	mu      sync.RWMutex
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewDispatcher creates a new dispatcher and starts its workers. The timeout is
+// applied to each invocation.
+func NewDispatcher(workers, queueSize int, timeout time.Duration, logger zerolog.Logger) *Dispatcher {
+	dispatcher := &Dispatcher{
+		queue:   make(chan invocation, max(queueSize, 1)),
+		timeout: timeout,
+		logger:  logger,
+	}
+	for i := 0; i < max(workers, 1); i++ {
+		dispatcher.wg.Add(1)
+		go dispatcher.work()
+	}
+	return dispatcher
+}
+
+// Dispatch queues the invocation of the hook method without waiting for it to run.
+// It returns false if the invocation is dropped, because the queue is full.
+func (d *Dispatcher) Dispatch(
+	hookName v1.HookName, priority sdkPlugin.Priority, hookMethod sdkPlugin.Method, args *v1.Struct,
+) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.stopped {
+		return false
+	}
+
+	select {
+	case d.queue <- invocation{hookName, priority, hookMethod, args}:
+		metrics.PluginAsyncHooksDispatched.Inc()
+		return true
+	default:
+		metrics.PluginAsyncHooksDropped.Inc()
+		return false
+	}
+}
+
+// Stop waits for the queued invocations to run and stops the workers.
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.stopped = true
+	close(d.queue)
+	d.mu.Unlock()
+
+	d.wg.Wait()
+}
+
+// work runs the queued invocations until the dispatcher is stopped.
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+
+	for call := range d.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+		if _, err := call.hookMethod(ctx, call.args); err != nil {
+			d.logger.Error().Err(err).Fields(
+				map[string]interface{}{
+					"hookName": call.hookName.String(),
+					"priority": call.priority,
+				},
+			).Msg("Async hook returned an error")
+		}
+		cancel()
+	}
+}
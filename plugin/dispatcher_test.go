@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// Test_Dispatcher tests that the invocations run in the background, and are
+// dropped once the queue is full.
+func Test_Dispatcher(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	hookMethod := func(
+		_ context.Context, args *v1.Struct, _ ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		<-release
+		calls.Add(1)
+		return args, nil
+	}
+
+	dispatcher := NewDispatcher(1, 1, time.Second, zerolog.Nop())
+	args := &v1.Struct{}
+	assert.True(t, dispatcher.Dispatch(v1.HookName_HOOK_NAME_ON_TRAFFIC, 0, hookMethod, args))
+	// Wait for the worker to take the first invocation, so the second one is queued.
+	assert.Eventually(t, func() bool {
+		return len(dispatcher.queue) == 0
+	}, time.Second, time.Millisecond)
+	assert.True(t, dispatcher.Dispatch(v1.HookName_HOOK_NAME_ON_TRAFFIC, 0, hookMethod, args))
+	// The queue is full, so this one is dropped.
+	assert.False(t, dispatcher.Dispatch(v1.HookName_HOOK_NAME_ON_TRAFFIC, 0, hookMethod, args))
+
+	close(release)
+	dispatcher.Stop()
+	assert.Equal(t, int32(2), calls.Load())
+
+	// Invocations after stop are dropped.
+	assert.False(t, dispatcher.Dispatch(v1.HookName_HOOK_NAME_ON_TRAFFIC, 0, hookMethod, args))
+}
@@ -0,0 +1,161 @@
+package plugin
+
+import (
+	"sync"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/events"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/rs/zerolog"
+)
+
+// HookBudget is the latency budget of the hook chains of a hook.
+type HookBudget struct {
+	Budget     time.Duration
+	Violations int
+	Action     config.HookBudgetAction
+}
+
+// HookBudgets enforce the latency budgets of the hook chains. When the chain of a hook
+// exceeds its budget the number of violations in a row, the hook of its slowest plugin
+// is demoted by the action of the budget, until GatewayD restarts.
+type HookBudgets struct {
+	mu       sync.Mutex
+	budgets  map[v1.HookName]HookBudget
+	exceeded map[v1.HookName]int
+	demoted  map[v1.HookName]map[sdkPlugin.Priority]config.HookBudgetAction
+}
+
+// NewHookBudgets creates the hook budgets of the config, by hook name. The budgets of
+// the unknown hooks are skipped, and it is nil if there are none.
+func NewHookBudgets(budgets map[string]config.HookBudget, logger zerolog.Logger) *HookBudgets {
+	hookBudgets := &HookBudgets{
+		budgets:  map[v1.HookName]HookBudget{},
+		exceeded: map[v1.HookName]int{},
+		demoted:  map[v1.HookName]map[sdkPlugin.Priority]config.HookBudgetAction{},
+	}
+	for name, budget := range budgets {
+		hookName, ok := ParseHookName(name)
+		if !ok {
+			logger.Warn().Str("hook", name).Msg("Unknown hook in the hook budgets, skipping")
+			continue
+		}
+		if budget.Budget <= 0 {
+			continue
+		}
+		action := config.If[config.HookBudgetAction](
+			budget.Action != "", config.HookBudgetAction(budget.Action), config.DefaultHookBudgetAction)
+		if action != config.BudgetAsync && action != config.BudgetDisable && action != config.BudgetLog {
+			logger.Warn().Str("hook", name).Str("action", budget.Action).Msg(
+				"Unknown action in the hook budgets, skipping")
+			continue
+		}
+		hookBudgets.budgets[hookName] = HookBudget{
+			Budget: budget.Budget,
+			Violations: config.If[int](
+				budget.Violations > 0, budget.Violations, config.DefaultHookBudgetViolations),
+			Action: action,
+		}
+	}
+	if len(hookBudgets.budgets) == 0 {
+		return nil
+	}
+	return hookBudgets
+}
+
+// Demoted returns the action the hook of the priority was demoted by, if it was.
+func (b *HookBudgets) Demoted(hookName v1.HookName, priority sdkPlugin.Priority) (config.HookBudgetAction, bool) {
+	if b == nil {
+		return "", false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	action, ok := b.demoted[hookName][priority]
+	return action, ok
+}
+
+// Async returns true if any of the budgets demotes the hooks to async.
+func (b *HookBudgets) Async() bool {
+	if b == nil {
+		return false
+	}
+
+	for _, budget := range b.budgets {
+		if budget.Action == config.BudgetAsync {
+			return true
+		}
+	}
+	return false
+}
+
+// Observe accounts the duration of a chain of the hook, whose slowest plugin hook has
+// the priority, if any. It returns the budget of the hook if the chain exceeded it too
+// many times in a row, in which case the slowest plugin hook is demoted by its action.
+func (b *HookBudgets) Observe(
+	hookName v1.HookName, duration time.Duration, slowest sdkPlugin.Priority, hasSlowest bool,
+) (HookBudget, bool) {
+	if b == nil {
+		return HookBudget{}, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	budget, ok := b.budgets[hookName]
+	if !ok {
+		return HookBudget{}, false
+	}
+	if duration <= budget.Budget {
+		b.exceeded[hookName] = 0
+		return HookBudget{}, false
+	}
+
+	metrics.PluginHookBudgetViolations.WithLabelValues(hookName.String()).Inc()
+	b.exceeded[hookName]++
+	if b.exceeded[hookName] < budget.Violations || !hasSlowest {
+		return HookBudget{}, false
+	}
+
+	b.exceeded[hookName] = 0
+	if budget.Action != config.BudgetLog {
+		if b.demoted[hookName] == nil {
+			b.demoted[hookName] = map[sdkPlugin.Priority]config.HookBudgetAction{}
+		}
+		b.demoted[hookName][slowest] = budget.Action
+	}
+	return budget, true
+}
+
+// observeBudget accounts the duration of the hook chain in its latency budget, and
+// demotes its slowest plugin hook if the chain exceeded it too many times in a row.
+func (reg *Registry) observeBudget(
+	hookName v1.HookName, duration time.Duration, slowest sdkPlugin.Priority, hasSlowest bool,
+) {
+	budget, exceeded := reg.budgets.Observe(hookName, duration, slowest, hasSlowest)
+	if !exceeded {
+		return
+	}
+
+	pluginName := reg.pluginName(reg.pluginPriority(hookName, slowest))
+	metrics.PluginHookBudgetDemotions.WithLabelValues(
+		pluginName, hookName.String(), string(budget.Action)).Inc()
+	fields := map[string]interface{}{
+		"plugin":     pluginName,
+		"hookName":   hookName.String(),
+		"budget":     budget.Budget.String(),
+		"duration":   duration.String(),
+		"violations": budget.Violations,
+		"action":     budget.Action,
+	}
+	message := config.If[string](
+		budget.Action == config.BudgetLog,
+		"The hook chain exceeded its latency budget",
+		"The hook chain exceeded its latency budget, demoted its slowest plugin")
+	reg.Logger.Warn().Fields(fields).Msg(message)
+	events.Publish(events.PluginDemoted, message, fields)
+}
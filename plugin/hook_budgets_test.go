@@ -0,0 +1,78 @@
+package plugin
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestNewHookBudgets(t *testing.T) {
+	assert.Nil(t, NewHookBudgets(nil, zerolog.Nop()))
+	assert.Nil(t, NewHookBudgets(map[string]config.HookBudget{
+		"onUnknown":           {Budget: time.Millisecond},
+		"onTrafficFromClient": {Budget: time.Millisecond, Action: "kill"},
+	}, zerolog.Nop()))
+
+	budgets := NewHookBudgets(map[string]config.HookBudget{
+		"onTrafficFromClient": {Budget: time.Millisecond},
+	}, zerolog.Nop())
+	require.NotNil(t, budgets)
+	assert.Equal(t, HookBudget{
+		Budget:     time.Millisecond,
+		Violations: config.DefaultHookBudgetViolations,
+		Action:     config.DefaultHookBudgetAction,
+	}, budgets.budgets[v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT])
+	assert.True(t, budgets.Async())
+}
+
+// Test_HookRegistry_Run_Budget tests that the slowest plugin hook of a chain is
+// disabled once the chain exceeded its latency budget the number of violations in a
+// row, and that the rest of the chain still runs.
+func Test_HookRegistry_Run_Budget(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	hookName := v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT
+	var slow, fast atomic.Int32
+	reg.AddHook(hookName, 0, func(
+		ctx context.Context, args *v1.Struct, opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		fast.Add(1)
+		return args, nil
+	})
+	reg.AddHook(hookName, 1, func(
+		ctx context.Context, args *v1.Struct, opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		slow.Add(1)
+		time.Sleep(5 * time.Millisecond)
+		return args, nil
+	})
+	reg.SetHookBudgets(NewHookBudgets(map[string]config.HookBudget{
+		"onTrafficFromClient": {Budget: time.Millisecond, Violations: 2, Action: "disable"},
+	}, zerolog.Nop()))
+	demotions := testutil.ToFloat64(metrics.PluginHookBudgetDemotions.WithLabelValues(
+		"1", hookName.String(), string(config.BudgetDisable)))
+
+	for range 3 {
+		result, err := reg.Run(context.Background(), map[string]interface{}{"test": "test"}, hookName)
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{"test": "test"}, result)
+	}
+
+	assert.Equal(t, int32(3), fast.Load())
+	assert.Equal(t, int32(2), slow.Load())
+	action, demoted := reg.budgets.Demoted(hookName, 1)
+	assert.True(t, demoted)
+	assert.Equal(t, config.BudgetDisable, action)
+	assert.InDelta(t, demotions+1, testutil.ToFloat64(metrics.PluginHookBudgetDemotions.WithLabelValues(
+		"1", hookName.String(), string(config.BudgetDisable))), 0)
+	reg.Shutdown()
+}
@@ -0,0 +1,217 @@
+package plugin
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"google.golang.org/protobuf/proto"
+)
+
+// hookCacheKey is a hook of a plugin, by the priority of the hook.
+type hookCacheKey struct {
+	hookName v1.HookName
+	priority sdkPlugin.Priority
+}
+
+// hookCacheEntry is a cached result, as its changes to the arguments of the hook.
+type hookCacheEntry struct {
+	changes map[string]*v1.Value
+	removed []string
+	expires time.Time
+}
+
+// HookCache keeps the results the plugins mark as cacheable, by the values of their key
+// fields in the arguments, so that the repeated calls of the hooks skip the plugins.
+type HookCache struct {
+	mu sync.Mutex
+	// keys holds the key fields of the cacheable hooks.
+	keys       map[hookCacheKey][]string
+	entries    map[string]*hookCacheEntry
+	maxEntries int
+	maxTTL     time.Duration
+
+	// now returns the current time, which is replaced in the tests.
+	now func() time.Time
+}
+
+// NewHookCache creates the hook cache of the config. It is nil if it is disabled.
+func NewHookCache(cfg config.HookCache) *HookCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &HookCache{
+		keys:    map[hookCacheKey][]string{},
+		entries: map[string]*hookCacheEntry{},
+		maxEntries: config.If[int](
+			cfg.MaxEntries > 0, cfg.MaxEntries, config.DefaultHookCacheMaxEntries),
+		maxTTL: config.If[time.Duration](
+			cfg.MaxTTL > 0, cfg.MaxTTL, config.DefaultHookCacheMaxTTL),
+		now: time.Now,
+	}
+}
+
+// Get returns the cached result of the hook of the plugin for the arguments, or nil if
+// there is none. It also returns whether the hook is cacheable, to count the misses.
+func (c *HookCache) Get(
+	hookName v1.HookName, priority sdkPlugin.Priority, args *v1.Struct,
+) (*v1.Struct, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	fields, cacheable := c.keys[hookCacheKey{hookName, priority}]
+	c.mu.Unlock()
+	if !cacheable {
+		return nil, false
+	}
+
+	key, ok := cacheKey(hookName, priority, args, fields)
+	if !ok {
+		return nil, true
+	}
+	c.mu.Lock()
+	entry, exists := c.entries[key]
+	if exists && !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		exists = false
+	}
+	c.mu.Unlock()
+	if !exists {
+		return nil, true
+	}
+
+	result := &v1.Struct{Fields: make(map[string]*v1.Value, len(args.GetFields()))}
+	for field, value := range args.GetFields() {
+		result.Fields[field] = value
+	}
+	for field, value := range entry.changes {
+		result.Fields[field] = value
+	}
+	for _, field := range entry.removed {
+		delete(result.Fields, field)
+	}
+	return result, true
+}
+
+// Put caches the result of the hook of the plugin for the arguments, if the plugin
+// marked it as cacheable, and returns it without the mark.
+func (c *HookCache) Put(
+	hookName v1.HookName, priority sdkPlugin.Priority, args, result *v1.Struct,
+) *v1.Struct {
+	mark, ok := result.GetFields()[sdk.FieldCache]
+	if !ok {
+		return result
+	}
+	result = RemoveFields(result, []string{sdk.FieldCache})
+	if c == nil {
+		return result
+	}
+
+	ttl := cacheTTL(mark.GetStructValue().GetFields()[sdk.FieldCacheTTL])
+	if ttl <= 0 {
+		return result
+	}
+	var fields []string
+	for _, field := range mark.GetStructValue().GetFields()[sdk.FieldCacheKey].GetListValue().GetValues() {
+		fields = append(fields, field.GetStringValue())
+	}
+	key, ok := cacheKey(hookName, priority, args, fields)
+	if !ok {
+		return result
+	}
+
+	entry := &hookCacheEntry{
+		changes: map[string]*v1.Value{},
+		expires: c.now().Add(min(ttl, c.maxTTL)),
+	}
+	for field, value := range result.GetFields() {
+		if original, exists := args.GetFields()[field]; !exists || !proto.Equal(original, value) {
+			entry.changes[field] = value
+		}
+	}
+	for field := range args.GetFields() {
+		if _, exists := result.GetFields()[field]; !exists {
+			entry.removed = append(entry.removed, field)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys[hookCacheKey{hookName, priority}] = fields
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+	c.entries[key] = entry
+	return result
+}
+
+// evict removes the expired entries, or the one closest to expiring if none are.
+func (c *HookCache) evict() {
+	now := c.now()
+	var closest string
+	var closestExpires time.Time
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if closest == "" || entry.expires.Before(closestExpires) {
+			closest, closestExpires = key, entry.expires
+		}
+	}
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, closest)
+	}
+}
+
+// Clear removes all the cached results, e.g. once a plugin is removed, as the
+// priorities of its hooks may be reused.
+func (c *HookCache) Clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = map[hookCacheKey][]string{}
+	c.entries = map[string]*hookCacheEntry{}
+}
+
+// Len returns the number of cached results.
+func (c *HookCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// cacheKey returns the key of the result of the hook of the plugin, from the values of
+// the key fields in the arguments.
+func cacheKey(
+	hookName v1.HookName, priority sdkPlugin.Priority, args *v1.Struct, fields []string,
+) (string, bool) {
+	values, err := proto.MarshalOptions{Deterministic: true}.Marshal(FilterFields(args, fields))
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%d/%d/%s", hookName, priority, values), true
+}
+
+// cacheTTL returns the TTL of the cacheable result, which is either a duration, e.g.
+// "30s", or a number of seconds.
+func cacheTTL(value *v1.Value) time.Duration {
+	if ttl, ok := value.GetKind().(*v1.Value_NumberValue); ok {
+		return time.Duration(ttl.NumberValue * float64(time.Second))
+	}
+	ttl, err := time.ParseDuration(value.GetStringValue())
+	if err != nil {
+		return 0
+	}
+	return ttl
+}
@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func newCacheArgs(t *testing.T, user, request string) *v1.Struct {
+	t.Helper()
+	args, err := v1.NewStruct(map[string]interface{}{
+		"client":  map[string]interface{}{"user": user},
+		"request": request,
+	})
+	require.NoError(t, err)
+	return args
+}
+
+func TestNewHookCache(t *testing.T) {
+	assert.Nil(t, NewHookCache(config.HookCache{}))
+
+	cache := NewHookCache(config.HookCache{Enabled: true})
+	require.NotNil(t, cache)
+	assert.Equal(t, config.DefaultHookCacheMaxEntries, cache.maxEntries)
+	assert.Equal(t, config.DefaultHookCacheMaxTTL, cache.maxTTL)
+}
+
+// TestHookCache tests that the changes of the cacheable results are reused for the
+// arguments with the same key fields, until they expire.
+func TestHookCache(t *testing.T) {
+	cache := NewHookCache(config.HookCache{Enabled: true, MaxEntries: 2, MaxTTL: time.Minute})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	hookName := v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT
+
+	args := newCacheArgs(t, "alice", "first")
+	result := cache.Put(hookName, 0, args, sdk.WithError(args, assert.AnError))
+	assert.Equal(t, assert.AnError.Error(), sdk.Error(result))
+	assert.Equal(t, 0, cache.Len())
+	cached, cacheable := cache.Get(hookName, 0, args)
+	assert.Nil(t, cached)
+	assert.False(t, cacheable)
+
+	result = cache.Put(hookName, 0, args, sdk.Cacheable(
+		sdk.Terminate(args, []byte("denied")), []string{"client.user"}, time.Hour))
+	assert.Nil(t, result.GetFields()[sdk.FieldCache])
+	assert.Equal(t, 1, cache.Len())
+
+	// The decision is reused for the same user, with the other arguments as is.
+	cached, cacheable = cache.Get(hookName, 0, newCacheArgs(t, "alice", "second"))
+	require.NotNil(t, cached)
+	assert.True(t, cacheable)
+	assert.True(t, cached.GetFields()[sdk.FieldTerminate].GetBoolValue())
+	assert.Equal(t, []byte("denied"), sdk.Response(cached))
+	assert.Equal(t, "second", cached.GetFields()["request"].GetStringValue())
+
+	cached, cacheable = cache.Get(hookName, 0, newCacheArgs(t, "bob", "first"))
+	assert.Nil(t, cached)
+	assert.True(t, cacheable)
+	cached, _ = cache.Get(hookName, 1, args)
+	assert.Nil(t, cached)
+
+	// The TTL is capped at the max TTL.
+	now = now.Add(time.Minute)
+	cached, _ = cache.Get(hookName, 0, args)
+	assert.Nil(t, cached)
+	assert.Equal(t, 0, cache.Len())
+
+	// The entry closest to expiring is evicted once the cache is full.
+	for _, user := range []string{"alice", "bob", "carol"} {
+		args := newCacheArgs(t, user, "first")
+		cache.Put(hookName, 0, args, sdk.Cacheable(args, []string{"client.user"}, time.Second))
+		now = now.Add(time.Millisecond)
+	}
+	assert.Equal(t, 2, cache.Len())
+	cached, _ = cache.Get(hookName, 0, newCacheArgs(t, "alice", "first"))
+	assert.Nil(t, cached)
+	cached, _ = cache.Get(hookName, 0, newCacheArgs(t, "carol", "first"))
+	assert.NotNil(t, cached)
+
+	cache.Clear()
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCacheTTL(t *testing.T) {
+	assert.Equal(t, 30*time.Second, cacheTTL(v1.NewStringValue("30s")))
+	assert.Equal(t, 1500*time.Millisecond, cacheTTL(v1.NewNumberValue(1.5)))
+	assert.Equal(t, time.Duration(0), cacheTTL(v1.NewStringValue("soon")))
+	assert.Equal(t, time.Duration(0), cacheTTL(nil))
+}
+
+// Test_HookRegistry_Run_Cache tests that the repeated calls of a cacheable hook skip
+// the plugin.
+func Test_HookRegistry_Run_Cache(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	reg.SetHookCache(NewHookCache(config.HookCache{Enabled: true}))
+	var calls atomic.Int32
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, 0, func(
+		ctx context.Context, args *v1.Struct, opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		calls.Add(1)
+		return sdk.Cacheable(
+			sdk.Terminate(args, []byte("denied")), []string{"client.user"}, time.Minute), nil
+	})
+
+	for _, request := range []string{"first", "second"} {
+		result, err := reg.Run(context.Background(), map[string]interface{}{
+			"client":  map[string]interface{}{"user": "alice"},
+			"request": request,
+		}, v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
+		require.Nil(t, err)
+		assert.Equal(t, true, result["terminate"])
+		assert.Equal(t, request, result["request"])
+		assert.NotContains(t, result, sdk.FieldCache)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -0,0 +1,50 @@
+package plugin
+
+import (
+	"math/rand/v2"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/rs/zerolog"
+)
+
+// HookSampler keeps a fraction of the runs of the hooks, by hook name. The runs that
+// aren't sampled skip the plugins, as if none had registered the hook.
+type HookSampler struct {
+	rates map[v1.HookName]float64
+
+	// random returns a number in [0, 1), which is replaced in the tests.
+	random func() float64
+}
+
+// NewHookSampler creates the hook sampler of the sample rates of the config, by hook
+// name. The rates of the unknown hooks and the invalid rates are skipped, and it is
+// nil if there are none.
+func NewHookSampler(rates map[string]float64, logger zerolog.Logger) *HookSampler {
+	sampler := &HookSampler{rates: map[v1.HookName]float64{}, random: rand.Float64}
+	for name, rate := range rates {
+		hookName, ok := ParseHookName(name)
+		if !ok {
+			logger.Warn().Str("hook", name).Msg("Unknown hook in the hook sampling, skipping")
+			continue
+		}
+		if rate < 0 || rate > 1 {
+			logger.Warn().Str("hook", name).Float64("rate", rate).Msg(
+				"Invalid rate in the hook sampling, it must be between 0 and 1, skipping")
+			continue
+		}
+		sampler.rates[hookName] = rate
+	}
+	if len(sampler.rates) == 0 {
+		return nil
+	}
+	return sampler
+}
+
+// Sample returns true if the run of the hook is kept.
+func (s *HookSampler) Sample(hookName v1.HookName) bool {
+	if s == nil {
+		return true
+	}
+	rate, ok := s.rates[hookName]
+	return !ok || s.random() < rate
+}
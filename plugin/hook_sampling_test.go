@@ -0,0 +1,63 @@
+package plugin
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestNewHookSampler(t *testing.T) {
+	assert.Nil(t, NewHookSampler(nil, zerolog.Nop()))
+	assert.Nil(t, NewHookSampler(map[string]float64{
+		"onUnknown":           0.5,
+		"onTrafficFromClient": 2,
+	}, zerolog.Nop()))
+
+	sampler := NewHookSampler(map[string]float64{"onTrafficFromClient": 0.25}, zerolog.Nop())
+	require.NotNil(t, sampler)
+	assert.Equal(t, map[v1.HookName]float64{v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT: 0.25},
+		sampler.rates)
+}
+
+// Test_HookRegistry_Run_Sampling tests that the runs of the hook that aren't sampled
+// skip its plugins, and that the other hooks always run.
+func Test_HookRegistry_Run_Sampling(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	var runs atomic.Int32
+	hook := func(
+		ctx context.Context, args *v1.Struct, opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		runs.Add(1)
+		return args, nil
+	}
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, 0, hook)
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_CLIENT, 0, hook)
+
+	sampler := NewHookSampler(map[string]float64{"onTrafficFromClient": 0.25}, zerolog.Nop())
+	random := 0.5
+	sampler.random = func() float64 { return random }
+	reg.SetHookSampler(sampler)
+
+	args := map[string]interface{}{"request": "data"}
+	result, err := reg.Run(context.Background(), args, v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
+	require.Nil(t, err)
+	assert.Empty(t, result)
+	assert.Equal(t, int32(0), runs.Load())
+
+	random = 0.1
+	result, err = reg.Run(context.Background(), args, v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
+	require.Nil(t, err)
+	assert.Equal(t, args, result)
+	assert.Equal(t, int32(1), runs.Load())
+
+	random = 0.9
+	_, err = reg.Run(context.Background(), args, v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_CLIENT)
+	require.Nil(t, err)
+	assert.Equal(t, int32(2), runs.Load())
+}
@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+)
+
+// HookStep is the run of a single plugin hook in a chain.
+type HookStep struct {
+	Plugin   string                 `json:"plugin"`
+	Priority sdkPlugin.Priority     `json:"priority"`
+	Mode     string                 `json:"mode,omitempty"`
+	Input    map[string]interface{} `json:"input"`
+	Output   map[string]interface{} `json:"output,omitempty"`
+	Verified bool                   `json:"verified"`
+	Error    string                 `json:"error,omitempty"`
+	Duration time.Duration          `json:"duration"`
+}
+
+// HookChain is the run of all the plugin hooks registered for a hook, in order.
+type HookChain struct {
+	Time  time.Time  `json:"time"`
+	Hook  string     `json:"hook"`
+	Steps []HookStep `json:"steps"`
+}
+
+// HookTracer records the inputs and outputs of each plugin in a sampled fraction of
+// the hook chains to a ring buffer, to find out which plugin mangled a request.
+type HookTracer struct {
+	mu         sync.Mutex
+	enabled    bool
+	sampleRate float64
+	chains     []HookChain
+	next       int
+	full       bool
+}
+
+// NewHookTracer creates a new, disabled hook tracer that keeps the last size chains.
+func NewHookTracer(size int) *HookTracer {
+	return &HookTracer{
+		sampleRate: 1,
+		chains:     make([]HookChain, max(size, 1)),
+	}
+}
+
+// Configure enables or disables the tracer, and sets the fraction of the hook
+// chains to record, between 0 and 1.
+func (t *HookTracer) Configure(enabled bool, sampleRate float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.enabled = enabled
+	t.sampleRate = min(max(sampleRate, 0), 1)
+}
+
+// Status returns whether the tracer is enabled and its sample rate.
+func (t *HookTracer) Status() (bool, float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.enabled, t.sampleRate
+}
+
+// Sample returns true if the next hook chain should be recorded.
+func (t *HookTracer) Sample() bool {
+	if t == nil {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	//nolint:gosec
+	return t.enabled && t.sampleRate > 0 && rand.Float64() < t.sampleRate
+}
+
+// Record adds the hook chain to the ring buffer, replacing the oldest one if it is full.
+func (t *HookTracer) Record(chain HookChain) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.chains[t.next] = chain
+	t.next = (t.next + 1) % len(t.chains)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// Dump returns the recorded hook chains, from the oldest to the newest.
+func (t *HookTracer) Dump() []HookChain {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.full {
+		return append([]HookChain{}, t.chains[:t.next]...)
+	}
+	return append(append([]HookChain{}, t.chains[t.next:]...), t.chains[:t.next]...)
+}
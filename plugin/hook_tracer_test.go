@@ -0,0 +1,32 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_HookTracer tests that the hook tracer keeps the last hook chains in order.
+func Test_HookTracer(t *testing.T) {
+	tracer := NewHookTracer(2)
+	assert.False(t, tracer.Sample())
+
+	tracer.Configure(true, 1)
+	assert.True(t, tracer.Sample())
+	enabled, sampleRate := tracer.Status()
+	assert.True(t, enabled)
+	assert.Equal(t, 1.0, sampleRate)
+
+	assert.Empty(t, tracer.Dump())
+	tracer.Record(HookChain{Hook: "first"})
+	assert.Equal(t, []HookChain{{Hook: "first"}}, tracer.Dump())
+	tracer.Record(HookChain{Hook: "second"})
+	tracer.Record(HookChain{Hook: "third"})
+	assert.Equal(t, []HookChain{{Hook: "second"}, {Hook: "third"}}, tracer.Dump())
+
+	tracer.Configure(true, 0)
+	assert.False(t, tracer.Sample())
+
+	var nilTracer *HookTracer
+	assert.False(t, nilTracer.Sample())
+}
@@ -0,0 +1,354 @@
+package plugin
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// MetricType is the type of a metric sample, and of the metrics recorded from it.
+type MetricType string
+
+const (
+	Counter   MetricType = "counter"
+	Gauge     MetricType = "gauge"
+	Histogram MetricType = "histogram"
+)
+
+const (
+	// EnrichedMetricPrefix prefixes the metrics of GatewayD with the labels added by
+	// the onMetric hooks, so they don't collide with the original metrics.
+	EnrichedMetricPrefix = "enriched_"
+	// DerivedMetricPrefix prefixes the metrics derived by the onMetric hooks.
+	DerivedMetricPrefix = "plugin_"
+)
+
+// MetricSample is a sample of a metric of GatewayD, with the labels of the connection
+// it was observed on, e.g. the user, database and application name.
+type MetricSample struct {
+	Name   string
+	Type   MetricType
+	Value  float64
+	Labels map[string]string
+}
+
+// args returns the arguments of the onMetric hooks for the sample.
+func (s MetricSample) args() (*v1.Struct, error) {
+	labels := make(map[string]interface{}, len(s.Labels))
+	for name, value := range s.Labels {
+		labels[name] = value
+	}
+	return v1.NewStruct(map[string]interface{}{ //nolint:wrapcheck
+		"name":   s.Name,
+		"type":   string(s.Type),
+		"value":  s.Value,
+		"labels": labels,
+	})
+}
+
+// series is a time series of a metric family, i.e. its values for a set of label values.
+type series struct {
+	labelValues []string
+	value       float64
+	count       uint64
+	buckets     []uint64
+}
+
+// family is a metric recorded from the results of the onMetric hooks. The label
+// names are fixed by the first sample, since Prometheus requires all the series of a
+// metric to have the same labels.
+type family struct {
+	desc       *prometheus.Desc
+	metricType MetricType
+	labelNames []string
+	series     map[string]*series
+}
+
+// MetricEnricher sends the samples of the metrics of GatewayD to the onMetric hooks of
+// the plugins, which can add labels to them, e.g. the application name from the startup
+// packet, or derive new metrics from them. It records the results, and exports them as
+// a Prometheus collector. The samples are sent in the background, so the hooks add no
+// latency to the traffic, and dropped if the queue is full.
+type MetricEnricher struct {
+	queueSize int
+	timeout   time.Duration
+	hooks     func() []sdkPlugin.Method
+	logger    zerolog.Logger
+
+	queue   chan MetricSample
+	start   sync.Once
+	stopped bool
+	wg      sync.WaitGroup
+
+	mu       sync.RWMutex
+	families map[string]*family
+}
+
+var _ prometheus.Collector = (*MetricEnricher)(nil)
+
+// NewMetricEnricher creates a new metric enricher, which sends the samples to the
+// hooks returned by the given function, in order. The timeout is applied to each
+// invocation of the hooks.
+func NewMetricEnricher(
+	queueSize int, timeout time.Duration, hooks func() []sdkPlugin.Method, logger zerolog.Logger,
+) *MetricEnricher {
+	return &MetricEnricher{
+		queueSize: max(queueSize, 1),
+		timeout:   timeout,
+		hooks:     hooks,
+		logger:    logger,
+		families:  map[string]*family{},
+	}
+}
+
+// Emit queues the sample to be sent to the hooks. It returns false if the sample is
+// dropped, because the queue is full or the enricher is stopped.
+func (e *MetricEnricher) Emit(sample MetricSample) bool {
+	e.start.Do(func() {
+		e.queue = make(chan MetricSample, e.queueSize)
+		e.wg.Add(1)
+		go e.work()
+	})
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.stopped {
+		return false
+	}
+
+	select {
+	case e.queue <- sample:
+		return true
+	default:
+		metrics.PluginMetricSamplesDropped.Inc()
+		return false
+	}
+}
+
+// Stop waits for the queued samples to be sent and stops the enricher.
+func (e *MetricEnricher) Stop() {
+	e.start.Do(func() {})
+
+	e.mu.Lock()
+	if e.stopped {
+		e.mu.Unlock()
+		return
+	}
+	e.stopped = true
+	if e.queue != nil {
+		close(e.queue)
+	}
+	e.mu.Unlock()
+
+	e.wg.Wait()
+}
+
+// work sends the queued samples to the hooks until the enricher is stopped.
+func (e *MetricEnricher) work() {
+	defer e.wg.Done()
+
+	for sample := range e.queue {
+		e.enrich(sample)
+	}
+}
+
+// enrich sends the sample to the hooks and records their results. Each hook sees the
+// labels added by the previous ones.
+func (e *MetricEnricher) enrich(sample MetricSample) {
+	labels := make(map[string]string, len(sample.Labels))
+	for name, value := range sample.Labels {
+		labels[name] = value
+	}
+	sample.Labels = labels
+	enriched := false
+
+	for _, hookMethod := range e.hooks() {
+		args, err := sample.args()
+		if err != nil {
+			e.logger.Debug().Err(err).Str("metric", sample.Name).Msg("Failed to cast the metric sample")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+		result, err := hookMethod(ctx, args)
+		cancel()
+		if err != nil {
+			e.logger.Error().Err(err).Str("metric", sample.Name).Msg("onMetric hook returned an error")
+			continue
+		}
+
+		fields := result.AsMap()
+		if added, ok := fields["labels"].(map[string]interface{}); ok {
+			for name, value := range added {
+				if value, ok := value.(string); ok && sample.Labels[name] != value {
+					sample.Labels[name] = value
+					enriched = true
+				}
+			}
+		}
+
+		derived, _ := fields["metrics"].([]interface{})
+		for _, metric := range derived {
+			if metric, ok := metric.(map[string]interface{}); ok {
+				e.recordDerived(metric)
+			}
+		}
+	}
+
+	if enriched {
+		e.Record(EnrichedMetricPrefix+sample.Name, "Enriched "+sample.Name, sample)
+	}
+}
+
+// recordDerived records a metric derived by a hook, given as a map of its name, help,
+// type, value and labels.
+func (e *MetricEnricher) recordDerived(metric map[string]interface{}) {
+	name, _ := metric["name"].(string)
+	value, ok := metric["value"].(float64)
+	if name == "" || !ok {
+		e.logger.Debug().Interface("metric", metric).Msg("Derived metric has no name or value")
+		return
+	}
+
+	sample := MetricSample{Name: name, Type: Gauge, Value: value, Labels: map[string]string{}}
+	if metricType, ok := metric["type"].(string); ok {
+		sample.Type = MetricType(metricType)
+	}
+	if labels, ok := metric["labels"].(map[string]interface{}); ok {
+		for name, value := range labels {
+			if value, ok := value.(string); ok {
+				sample.Labels[name] = value
+			}
+		}
+	}
+	help, _ := metric["help"].(string)
+	if help == "" {
+		help = "Derived by a plugin"
+	}
+
+	e.Record(DerivedMetricPrefix+name, help, sample)
+}
+
+// Record records the sample as the metric with the given name and help. Counters add
+// the value, gauges set it and histograms observe it. The sample is dropped if its
+// type or labels don't match the previous samples of the metric.
+func (e *MetricEnricher) Record(name, help string, sample MetricSample) {
+	labelNames := make([]string, 0, len(sample.Labels))
+	for labelName := range sample.Labels {
+		labelNames = append(labelNames, labelName)
+	}
+	sort.Strings(labelNames)
+	labelValues := make([]string, 0, len(labelNames))
+	for _, labelName := range labelNames {
+		labelValues = append(labelValues, sample.Labels[labelName])
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	metricFamily, ok := e.families[name]
+	if !ok {
+		switch sample.Type {
+		case Counter, Gauge, Histogram:
+		default:
+			e.logger.Debug().Fields(
+				map[string]interface{}{
+					"metric": name,
+					"type":   sample.Type,
+				},
+			).Msg("Metric type is not supported, dropping the sample")
+			return
+		}
+		metricFamily = &family{
+			desc: prometheus.NewDesc(
+				prometheus.BuildFQName(metrics.Namespace, "", name), help, labelNames, nil),
+			metricType: sample.Type,
+			labelNames: labelNames,
+			series:     map[string]*series{},
+		}
+		e.families[name] = metricFamily
+	}
+
+	if metricFamily.metricType != sample.Type ||
+		strings.Join(metricFamily.labelNames, ",") != strings.Join(labelNames, ",") {
+		e.logger.Debug().Fields(
+			map[string]interface{}{
+				"metric": name,
+				"type":   sample.Type,
+				"labels": labelNames,
+			},
+		).Msg("Metric type or labels don't match the previous samples, dropping the sample")
+		return
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	timeSeries, ok := metricFamily.series[key]
+	if !ok {
+		timeSeries = &series{labelValues: labelValues}
+		if sample.Type == Histogram {
+			timeSeries.buckets = make([]uint64, len(prometheus.DefBuckets))
+		}
+		metricFamily.series[key] = timeSeries
+	}
+
+	switch sample.Type {
+	case Counter:
+		timeSeries.value += sample.Value
+	case Gauge:
+		timeSeries.value = sample.Value
+	case Histogram:
+		timeSeries.value += sample.Value
+		timeSeries.count++
+		for idx, upperBound := range prometheus.DefBuckets {
+			if sample.Value <= upperBound {
+				timeSeries.buckets[idx]++
+			}
+		}
+	}
+}
+
+// Describe sends nothing, since the metrics are only known once the hooks return them,
+// which makes the enricher an unchecked collector.
+func (e *MetricEnricher) Describe(chan<- *prometheus.Desc) {}
+
+// Collect sends the recorded metrics.
+func (e *MetricEnricher) Collect(metricsChan chan<- prometheus.Metric) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for _, metricFamily := range e.families {
+		for _, timeSeries := range metricFamily.series {
+			var metric prometheus.Metric
+			var err error
+			switch metricFamily.metricType {
+			case Counter:
+				metric, err = prometheus.NewConstMetric(
+					metricFamily.desc, prometheus.CounterValue, timeSeries.value, timeSeries.labelValues...)
+			case Gauge:
+				metric, err = prometheus.NewConstMetric(
+					metricFamily.desc, prometheus.GaugeValue, timeSeries.value, timeSeries.labelValues...)
+			case Histogram:
+				buckets := make(map[float64]uint64, len(prometheus.DefBuckets))
+				for idx, upperBound := range prometheus.DefBuckets {
+					buckets[upperBound] = timeSeries.buckets[idx]
+				}
+				metric, err = prometheus.NewConstHistogram(
+					metricFamily.desc, timeSeries.count, timeSeries.value, buckets, timeSeries.labelValues...)
+			}
+			if err != nil {
+				e.logger.Debug().Err(err).Msg("Failed to collect the metric")
+				continue
+			}
+			metricsChan <- metric
+		}
+	}
+}
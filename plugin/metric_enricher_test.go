@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// Test_MetricEnricher_Record tests recording the samples of each metric type, and that
+// the samples with different labels than the previous ones are dropped.
+func Test_MetricEnricher_Record(t *testing.T) {
+	enricher := NewMetricEnricher(1, time.Second, nil, zerolog.Nop())
+	labels := map[string]string{"app": "test"}
+	enricher.Record("test_counter", "Test counter", MetricSample{Type: Counter, Value: 1, Labels: labels})
+	enricher.Record("test_counter", "Test counter", MetricSample{Type: Counter, Value: 2, Labels: labels})
+	enricher.Record("test_gauge", "Test gauge", MetricSample{Type: Gauge, Value: 1, Labels: labels})
+	enricher.Record("test_gauge", "Test gauge", MetricSample{Type: Gauge, Value: 5, Labels: labels})
+	enricher.Record("test_histogram", "Test histogram", MetricSample{Type: Histogram, Value: 0.2, Labels: labels})
+	// Dropped, since the labels and the types don't match the previous samples.
+	enricher.Record("test_counter", "Test counter", MetricSample{
+		Type: Counter, Value: 1, Labels: map[string]string{"other": "test"},
+	})
+	enricher.Record("test_gauge", "Test gauge", MetricSample{Type: Counter, Value: 1, Labels: labels})
+	// Dropped, since the type isn't supported.
+	enricher.Record("test_summary", "Test summary", MetricSample{Type: "summary", Value: 1})
+
+	expected := `
+# HELP gatewayd_test_counter Test counter
+# TYPE gatewayd_test_counter counter
+gatewayd_test_counter{app="test"} 3
+# HELP gatewayd_test_gauge Test gauge
+# TYPE gatewayd_test_gauge gauge
+gatewayd_test_gauge{app="test"} 5
+`
+	require.NoError(t, testutil.CollectAndCompare(
+		enricher, strings.NewReader(expected), "gatewayd_test_counter", "gatewayd_test_gauge"))
+	assert.Equal(t, 3, testutil.CollectAndCount(enricher))
+}
+
+// Test_Registry_EmitMetric tests that the samples are sent to the onMetric hooks, and
+// that the labels and metrics they return are recorded.
+func Test_Registry_EmitMetric(t *testing.T) {
+	reg := NewRegistry(
+		context.TODO(),
+		config.Loose,
+		config.PassDown,
+		config.Accept,
+		config.Stop,
+		zerolog.Nop(),
+		false,
+	)
+	defer reg.MetricEnricher.Stop()
+
+	// No samples are sent without onMetric hooks.
+	reg.EmitMetric(MetricSample{Name: "query_latency_seconds", Type: Histogram, Value: 0.2})
+
+	addLabels := func(
+		_ context.Context, args *v1.Struct, _ ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		assert.Equal(t, "query_latency_seconds", args.Fields["name"].GetStringValue())
+		return v1.NewStruct(map[string]interface{}{
+			"labels": map[string]interface{}{"application": "billing"},
+		})
+	}
+	deriveMetric := func(
+		_ context.Context, args *v1.Struct, _ ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		// The labels added by the previous hook are passed down.
+		assert.Equal(t, "billing",
+			args.Fields["labels"].GetStructValue().Fields["application"].GetStringValue())
+		return v1.NewStruct(map[string]interface{}{
+			"metrics": []interface{}{
+				map[string]interface{}{
+					"name":   "slow_queries_total",
+					"type":   "counter",
+					"value":  1,
+					"labels": map[string]interface{}{"application": "billing"},
+				},
+			},
+		})
+	}
+	reg.AddHook(sdk.OnMetric, 0, addLabels)
+	reg.AddHook(sdk.OnMetric, 1, deriveMetric)
+	assert.Len(t, reg.MetricHooks(), 2)
+
+	assert.True(t, reg.MetricEnricher.Emit(MetricSample{
+		Name:   "query_latency_seconds",
+		Type:   Histogram,
+		Value:  0.2,
+		Labels: map[string]string{"user": "postgres"},
+	}))
+	reg.MetricEnricher.Stop()
+
+	expected := `
+# HELP gatewayd_plugin_slow_queries_total Derived by a plugin
+# TYPE gatewayd_plugin_slow_queries_total counter
+gatewayd_plugin_slow_queries_total{application="billing"} 1
+`
+	require.NoError(t, testutil.CollectAndCompare(
+		reg.MetricEnricher, strings.NewReader(expected), "gatewayd_plugin_slow_queries_total"))
+	assert.Equal(t, 2, testutil.CollectAndCount(reg.MetricEnricher))
+	assert.Equal(t, 1, testutil.CollectAndCount(
+		reg.MetricEnricher, "gatewayd_enriched_query_latency_seconds"))
+
+	// No samples are queued once the enricher is stopped.
+	assert.False(t, reg.MetricEnricher.Emit(MetricSample{Name: "query_latency_seconds"}))
+}
+
+// Test_MetricEnricher_Stop tests stopping an enricher that never received a sample.
+func Test_MetricEnricher_Stop(t *testing.T) {
+	enricher := NewMetricEnricher(1, time.Second, func() []sdkPlugin.Method { return nil }, zerolog.Nop())
+	enricher.Stop()
+	assert.False(t, enricher.Emit(MetricSample{Name: "test"}))
+}
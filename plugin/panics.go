@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc"
+)
+
+// recoverHook wraps the hook so that a panic, e.g. in the RPC client of a crashed
+// plugin, is returned as an error instead of crashing GatewayD. The hook then has no
+// result, so the verification policy of the registry applies to it.
+func (reg *Registry) recoverHook(
+	hookName v1.HookName, priority sdkPlugin.Priority, hookMethod sdkPlugin.Method,
+) sdkPlugin.Method {
+	return func(
+		ctx context.Context, args *v1.Struct, opts ...grpc.CallOption,
+	) (result *v1.Struct, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				pluginName := reg.pluginName(reg.pluginPriority(hookName, priority))
+				metrics.PluginHookPanics.WithLabelValues(pluginName, hookName.String()).Inc()
+				sentry.CurrentHub().Recover(recovered)
+				reg.Logger.Error().Fields(
+					map[string]interface{}{
+						"plugin":   pluginName,
+						"hookName": hookName.String(),
+						"priority": priority,
+						"panic":    fmt.Sprint(recovered),
+						"stack":    string(debug.Stack()),
+					},
+				).Msg("Recovered from a panic in the hook")
+				result = nil
+				err = gerr.ErrHookPanicked.Wrap(fmt.Errorf("%v", recovered))
+			}
+		}()
+
+		return hookMethod(ctx, args, opts...)
+	}
+}
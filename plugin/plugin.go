@@ -19,8 +19,12 @@ type IPlugin interface {
 
 var _ IPlugin = (*Plugin)(nil)
 
-// Start starts the plugin.
+// Start starts the plugin. The built-in plugins have no client, so they're always
+// started.
 func (p *Plugin) Start() (net.Addr, error) {
+	if p.Client == nil {
+		return nil, nil
+	}
 	var addr net.Addr
 	var err error
 	if addr, err = p.Client.Start(); err != nil {
@@ -31,11 +35,17 @@ func (p *Plugin) Start() (net.Addr, error) {
 
 // Stop kills the plugin.
 func (p *Plugin) Stop() {
+	if p.Client == nil {
+		return
+	}
 	p.Client.Kill()
 }
 
 // Dispense returns the plugin client.
 func (p *Plugin) Dispense() (v1.GatewayDPluginServiceClient, *gerr.GatewayDError) {
+	if p.Client == nil {
+		return nil, gerr.ErrPluginNotReady
+	}
 	rpcClient, err := p.Client.Client()
 	if err != nil {
 		return nil, gerr.ErrFailedToGetRPCClient.Wrap(err)
@@ -55,6 +65,9 @@ func (p *Plugin) Dispense() (v1.GatewayDPluginServiceClient, *gerr.GatewayDError
 
 // Ping pings the plugin.
 func (p *Plugin) Ping() *gerr.GatewayDError {
+	if p.Client == nil {
+		return nil
+	}
 	rpcClient, err := p.Client.Client()
 	if err != nil {
 		return gerr.ErrFailedToGetRPCClient.Wrap(err)
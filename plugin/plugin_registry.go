@@ -4,7 +4,11 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"slices"
 	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -15,6 +19,8 @@ import (
 	"github.com/gatewayd-io/gatewayd/logging"
 	"github.com/gatewayd-io/gatewayd/metrics"
 	"github.com/gatewayd-io/gatewayd/pool"
+	"github.com/gatewayd-io/gatewayd/recorder"
+	"github.com/gatewayd-io/gatewayd/sdk"
 	goplugin "github.com/hashicorp/go-plugin"
 	"github.com/mitchellh/mapstructure"
 	"github.com/rs/zerolog"
@@ -24,7 +30,7 @@ import (
 )
 
 type IHook interface {
-	AddHook(hookName v1.HookName, priority sdkPlugin.Priority, hookMethod sdkPlugin.Method)
+	AddHook(hookName v1.HookName, priority sdkPlugin.Priority, hookMethod sdkPlugin.Method) *gerr.GatewayDError
 	Hooks() map[v1.HookName]map[sdkPlugin.Priority]sdkPlugin.Method
 	Run(
 		ctx context.Context,
@@ -32,6 +38,12 @@ type IHook interface {
 		hookName v1.HookName,
 		opts ...grpc.CallOption,
 	) (map[string]interface{}, *gerr.GatewayDError)
+	RunTyped(
+		ctx context.Context,
+		message sdk.Message,
+		hookName v1.HookName,
+		opts ...grpc.CallOption,
+	) (*v1.Struct, *gerr.GatewayDError)
 }
 
 //nolint:interfacebloat
@@ -57,6 +69,40 @@ type Registry struct {
 	hooks   map[v1.HookName]map[sdkPlugin.Priority]sdkPlugin.Method
 	ctx     context.Context //nolint:containedctx
 	devMode bool
+	// fields holds the fields of the hook arguments each plugin needs, by priority.
+	fields map[sdkPlugin.Priority][]string
+	// batchers holds the batchers of the hooks that are delivered in batches.
+	batchers map[v1.HookName]map[sdkPlugin.Priority]*Batcher
+	// async holds the hooks that run in the background, without waiting for them.
+	async      map[v1.HookName]map[sdkPlugin.Priority]bool
+	dispatcher *Dispatcher
+	// capabilities holds the capabilities each plugin declared, by priority.
+	capabilities map[sdkPlugin.Priority]map[Capability]bool
+	// names holds the names of the plugins by priority, for the metrics.
+	names sync.Map
+	// priorities holds the priorities of the hooks set in the config, by the priority
+	// of their plugin.
+	priorities map[sdkPlugin.Priority]map[v1.HookName]sdkPlugin.Priority
+	// owners holds the priority of the plugin of the hooks registered with a priority
+	// set in the config.
+	owners map[v1.HookName]map[sdkPlugin.Priority]sdkPlugin.Priority
+	// budgets demote the slowest plugin hooks of the chains that exceed their latency
+	// budget. They are disabled if nil.
+	budgets *HookBudgets
+	// sampler skips the runs of the hooks that aren't sampled. All of them run if it
+	// is nil.
+	sampler *HookSampler
+	// compression compresses the large hook arguments of the plugins that negotiated a
+	// compressor. It is disabled if nil.
+	compression *HookCompression
+	// compressors holds the compressors negotiated with the plugins, by priority.
+	compressors map[sdkPlugin.Priority]string
+	// configSchemas holds the JSON schemas of the settings the plugins published, by
+	// priority.
+	configSchemas map[sdkPlugin.Priority][]byte
+	// cache serves the repeated calls of the hooks from the results the plugins marked
+	// as cacheable. It is disabled if nil.
+	cache *HookCache
 
 	Logger        zerolog.Logger
 	Compatibility config.CompatibilityPolicy
@@ -64,6 +110,24 @@ type Registry struct {
 	Acceptance    config.AcceptancePolicy
 	Termination   config.TerminationPolicy
 	StartTimeout  time.Duration
+	Timeout       time.Duration
+	// ShutdownTimeout is how long the plugins have to acknowledge the
+	// OnShutdownComplete hooks before they are stopped.
+	ShutdownTimeout    time.Duration
+	AsyncHookWorkers   int
+	AsyncHookQueueSize int
+	// RequireCapabilities restricts the plugins that don't declare their capabilities
+	// to the ones that don't touch the traffic or secrets, instead of allowing everything.
+	RequireCapabilities bool
+	// Tracer records the hook chains for debugging.
+	Tracer *HookTracer
+	// Scheduler runs the scheduled jobs of the plugins.
+	Scheduler *JobScheduler
+	// MetricEnricher sends the samples of the metrics to the onMetric hooks.
+	MetricEnricher *MetricEnricher
+	// AllowOverride lets a hook replace the one registered with the same priority,
+	// instead of failing to register.
+	AllowOverride bool
 }
 
 var _ IRegistry = (*Registry)(nil)
@@ -81,9 +145,17 @@ func NewRegistry(
 	regCtx, span := otel.Tracer(config.TracerName).Start(ctx, "Create new registry")
 	defer span.End()
 
-	return &Registry{
+	reg := &Registry{
 		plugins:       pool.NewPool(regCtx, config.EmptyPoolCapacity),
 		hooks:         map[v1.HookName]map[sdkPlugin.Priority]sdkPlugin.Method{},
+		fields:        map[sdkPlugin.Priority][]string{},
+		batchers:      map[v1.HookName]map[sdkPlugin.Priority]*Batcher{},
+		async:         map[v1.HookName]map[sdkPlugin.Priority]bool{},
+		capabilities:  map[sdkPlugin.Priority]map[Capability]bool{},
+		compressors:   map[sdkPlugin.Priority]string{},
+		configSchemas: map[sdkPlugin.Priority][]byte{},
+		priorities:    map[sdkPlugin.Priority]map[v1.HookName]sdkPlugin.Priority{},
+		owners:        map[v1.HookName]map[sdkPlugin.Priority]sdkPlugin.Priority{},
 		ctx:           regCtx,
 		devMode:       devMode,
 		Logger:        logger,
@@ -91,7 +163,17 @@ func NewRegistry(
 		Verification:  verification,
 		Acceptance:    acceptance,
 		Termination:   termination,
+		Timeout:       config.DefaultPluginTimeout,
+
+		ShutdownTimeout:    config.DefaultPluginShutdownTimeout,
+		AsyncHookWorkers:   config.DefaultAsyncHookWorkers,
+		AsyncHookQueueSize: config.DefaultAsyncHookQueueSize,
+		Tracer:             NewHookTracer(config.DefaultHookTraceBufferSize),
+		Scheduler:          NewJobScheduler(config.DefaultPluginTimeout, logger),
 	}
+	reg.MetricEnricher = NewMetricEnricher(
+		config.DefaultAsyncHookQueueSize, config.DefaultPluginTimeout, reg.MetricHooks, logger)
+	return reg
 }
 
 // Add adds a plugin to the registry.
@@ -105,6 +187,7 @@ func (reg *Registry) Add(plugin *Plugin) bool {
 		span.RecordError(err)
 		return false
 	}
+	reg.names.Store(plugin.Priority, plugin.ID.Name)
 	return loaded
 }
 
@@ -201,9 +284,32 @@ func (reg *Registry) Remove(pluginID sdkPlugin.Identifier) {
 	defer span.End()
 
 	plugin := reg.Get(pluginID)
-	for _, hooks := range reg.hooks {
-		delete(hooks, plugin.Priority)
+	for hookName, hooks := range reg.hooks {
+		// Keep the hook if another plugin replaced it.
+		priority := reg.hookPriority(plugin.Priority, hookName)
+		if reg.pluginPriority(hookName, priority) == plugin.Priority {
+			delete(hooks, priority)
+			delete(reg.owners[hookName], priority)
+		}
 	}
+	delete(reg.fields, plugin.Priority)
+	for hookName, batchers := range reg.batchers {
+		priority := reg.hookPriority(plugin.Priority, hookName)
+		if batcher, ok := batchers[priority]; ok {
+			batcher.Stop()
+			delete(batchers, priority)
+		}
+	}
+	for hookName, async := range reg.async {
+		delete(async, reg.hookPriority(plugin.Priority, hookName))
+	}
+	delete(reg.capabilities, plugin.Priority)
+	delete(reg.compressors, plugin.Priority)
+	delete(reg.configSchemas, plugin.Priority)
+	reg.cache.Clear()
+	delete(reg.priorities, plugin.Priority)
+	reg.names.Delete(plugin.Priority)
+	reg.Scheduler.Remove(plugin.ID.Name)
 	reg.plugins.Remove(pluginID)
 }
 
@@ -212,6 +318,38 @@ func (reg *Registry) Shutdown() {
 	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "Shutdown")
 	defer span.End()
 
+	// Stop the scheduled jobs, and send the pending batches and async hooks
+	// before the plugins are stopped.
+	reg.Scheduler.Stop()
+	reg.MetricEnricher.Stop()
+	for _, batchers := range reg.batchers {
+		for _, batcher := range batchers {
+			batcher.Stop()
+		}
+	}
+	if reg.dispatcher != nil {
+		reg.dispatcher.Stop()
+	}
+
+	// The plugins acknowledge that they are done by returning from the
+	// OnShutdownComplete hooks.
+	deadline := time.Now().Add(reg.ShutdownTimeout)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	//nolint:contextcheck
+	if _, err := reg.Run(
+		ctx,
+		map[string]interface{}{"deadline": deadline.UTC().Format(time.RFC3339)},
+		sdk.OnShutdownComplete,
+	); err != nil {
+		reg.Logger.Error().Err(err).Msg("Failed to run the OnShutdownComplete hooks")
+		span.RecordError(err)
+	}
+	if ctx.Err() != nil {
+		reg.Logger.Warn().Str("timeout", reg.ShutdownTimeout.String()).Msg(
+			"The plugins didn't acknowledge the shutdown in time, stopping them anyway")
+	}
+
 	reg.plugins.ForEach(func(key, value interface{}) bool {
 		if id, ok := key.(sdkPlugin.Identifier); ok {
 			if plugin, ok := value.(*Plugin); ok {
@@ -232,23 +370,321 @@ func (reg *Registry) Hooks() map[v1.HookName]map[sdkPlugin.Priority]sdkPlugin.Me
 	return reg.hooks
 }
 
-// Add adds a hook with a priority to the hooks map.
-func (reg *Registry) AddHook(hookName v1.HookName, priority sdkPlugin.Priority, hookMethod sdkPlugin.Method) {
+// AddHook adds a hook with a priority to the hooks map. If a hook is already registered
+// with the same priority, it is only replaced if AllowOverride is set, otherwise
+// ErrHookExists is returned. The panics of the hook are returned as errors.
+func (reg *Registry) AddHook(
+	hookName v1.HookName, priority sdkPlugin.Priority, hookMethod sdkPlugin.Method,
+) *gerr.GatewayDError {
 	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "AddHook")
 	defer span.End()
 
+	hookMethod = reg.recoverHook(hookName, priority, hookMethod)
 	if len(reg.hooks[hookName]) == 0 {
 		reg.hooks[hookName] = map[sdkPlugin.Priority]sdkPlugin.Method{priority: hookMethod}
-	} else {
-		if _, ok := reg.hooks[hookName][priority]; ok {
-			reg.Logger.Warn().Fields(
+		return nil
+	}
+
+	if _, ok := reg.hooks[hookName][priority]; ok {
+		fields := map[string]interface{}{
+			"hookName": hookName.String(),
+			"priority": priority,
+		}
+		if !reg.AllowOverride {
+			reg.Logger.Error().Fields(fields).Msg(
+				"Hook is already registered with the same priority")
+			span.RecordError(gerr.ErrHookExists)
+			return gerr.ErrHookExists
+		}
+		reg.Logger.Warn().Fields(fields).Msg("Hook is replaced")
+	}
+	reg.hooks[hookName][priority] = hookMethod
+	return nil
+}
+
+// SetHookPriorities sets the priorities of the hooks of the plugin with the given
+// priority, which are used instead of the priority of the plugin when its hooks
+// are registered.
+func (reg *Registry) SetHookPriorities(
+	priority sdkPlugin.Priority, priorities map[v1.HookName]sdkPlugin.Priority,
+) {
+	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "SetHookPriorities")
+	defer span.End()
+
+	if len(priorities) == 0 {
+		delete(reg.priorities, priority)
+		return
+	}
+	reg.priorities[priority] = priorities
+}
+
+// hookPriority returns the priority of the hook of the plugin with the given priority.
+func (reg *Registry) hookPriority(priority sdkPlugin.Priority, hookName v1.HookName) sdkPlugin.Priority {
+	if hookPriority, ok := reg.priorities[priority][hookName]; ok {
+		return hookPriority
+	}
+	return priority
+}
+
+// pluginPriority returns the priority of the plugin that registered the hook with the
+// given priority, to find its name, fields and capabilities.
+func (reg *Registry) pluginPriority(hookName v1.HookName, priority sdkPlugin.Priority) sdkPlugin.Priority {
+	if pluginPriority, ok := reg.owners[hookName][priority]; ok {
+		return pluginPriority
+	}
+	return priority
+}
+
+// registerHook adds the hook of the plugin with the given priority, with the priority
+// set in the config, if any.
+func (reg *Registry) registerHook(
+	hookName v1.HookName, pluginPriority sdkPlugin.Priority, hookMethod sdkPlugin.Method,
+) *gerr.GatewayDError {
+	priority := reg.hookPriority(pluginPriority, hookName)
+	if err := reg.AddHook(hookName, priority, hookMethod); err != nil {
+		return err
+	}
+
+	if priority == pluginPriority {
+		delete(reg.owners[hookName], priority)
+		return nil
+	}
+	if len(reg.owners[hookName]) == 0 {
+		reg.owners[hookName] = map[sdkPlugin.Priority]sdkPlugin.Priority{}
+	}
+	reg.owners[hookName][priority] = pluginPriority
+	return nil
+}
+
+// SetHookFields sets the fields of the hook arguments that the hooks with the given
+// priority need. Only these fields are passed to the hooks, which reduces the cost
+// of serializing the arguments, and their result is merged with the rest of the
+// arguments. If no fields are set, the hooks receive all the arguments.
+func (reg *Registry) SetHookFields(priority sdkPlugin.Priority, fields []string) {
+	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "SetHookFields")
+	defer span.End()
+
+	if len(fields) == 0 {
+		delete(reg.fields, priority)
+		return
+	}
+	reg.fields[priority] = fields
+}
+
+// SetHookBatch delivers the arguments of the given hooks to the hooks with the given
+// priority in batches. The hooks must be registered beforehand. The results of
+// batched hooks are ignored, so they can only observe the arguments.
+func (reg *Registry) SetHookBatch(priority sdkPlugin.Priority, batch HookBatch) {
+	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "SetHookBatch")
+	defer span.End()
+
+	for _, hookName := range batch.Hooks {
+		hookPriority := reg.hookPriority(priority, hookName)
+		hookMethod, ok := reg.hooks[hookName][hookPriority]
+		if !ok {
+			reg.Logger.Debug().Fields(
 				map[string]interface{}{
 					"hookName": hookName.String(),
-					"priority": priority,
+					"priority": hookPriority,
+				},
+			).Msg("Hook is not registered, so it can't be batched")
+			continue
+		}
+
+		if batcher, ok := reg.batchers[hookName][hookPriority]; ok {
+			batcher.Stop()
+		}
+		if len(reg.batchers[hookName]) == 0 {
+			reg.batchers[hookName] = map[sdkPlugin.Priority]*Batcher{}
+		}
+		reg.batchers[hookName][hookPriority] = NewBatcher(
+			hookName,
+			hookPriority,
+			hookMethod,
+			batch.Size,
+			time.Duration(batch.Interval)*time.Millisecond,
+			reg.Timeout,
+			reg.Logger,
+		)
+	}
+}
+
+// SetHookAsync runs the given hooks with the given priority in the background, without
+// waiting for them to return, so they add no latency to the traffic. The results of
+// async hooks are ignored, so they can only observe the arguments.
+func (reg *Registry) SetHookAsync(priority sdkPlugin.Priority, hooks []v1.HookName) {
+	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "SetHookAsync")
+	defer span.End()
+
+	if reg.dispatcher == nil && len(hooks) > 0 {
+		reg.dispatcher = NewDispatcher(
+			reg.AsyncHookWorkers, reg.AsyncHookQueueSize, reg.Timeout, reg.Logger)
+	}
+
+	for _, hookName := range hooks {
+		if len(reg.async[hookName]) == 0 {
+			reg.async[hookName] = map[sdkPlugin.Priority]bool{}
+		}
+		reg.async[hookName][reg.hookPriority(priority, hookName)] = true
+	}
+}
+
+// SetHookBudgets enforces the latency budgets of the hook chains.
+func (reg *Registry) SetHookBudgets(budgets *HookBudgets) {
+	if reg.dispatcher == nil && budgets.Async() {
+		reg.dispatcher = NewDispatcher(
+			reg.AsyncHookWorkers, reg.AsyncHookQueueSize, reg.Timeout, reg.Logger)
+	}
+	reg.budgets = budgets
+}
+
+// SetHookSampler samples the runs of the hooks.
+func (reg *Registry) SetHookSampler(sampler *HookSampler) {
+	reg.sampler = sampler
+}
+
+// SetHookCompression compresses the large hook arguments of the plugins. It must be
+// set before the plugins are loaded, as their compressors are negotiated then.
+func (reg *Registry) SetHookCompression(compression *HookCompression) {
+	reg.compression = compression
+}
+
+// SetHookCache caches the results of the hooks the plugins mark as cacheable.
+func (reg *Registry) SetHookCache(cache *HookCache) {
+	reg.cache = cache
+}
+
+// SetConfigSchema sets the JSON schema of the settings of the plugin with the given
+// priority.
+func (reg *Registry) SetConfigSchema(priority sdkPlugin.Priority, schema []byte) {
+	reg.configSchemas[priority] = schema
+}
+
+// ConfigSchema returns the JSON schema of the settings the plugin with the given
+// priority published in its metadata, or nil if it didn't.
+func (reg *Registry) ConfigSchema(priority sdkPlugin.Priority) []byte {
+	return reg.configSchemas[priority]
+}
+
+// SetCompressor sets the compressor of the hooks of the plugin with the given priority.
+func (reg *Registry) SetCompressor(priority sdkPlugin.Priority, compressor string) {
+	reg.compressors[priority] = compressor
+}
+
+// SetCapabilities sets the capabilities of the plugin with the given priority, which
+// are enforced every time its hooks run.
+func (reg *Registry) SetCapabilities(priority sdkPlugin.Priority, capabilities []Capability) {
+	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "SetCapabilities")
+	defer span.End()
+
+	reg.capabilities[priority] = make(map[Capability]bool, len(capabilities))
+	for _, capability := range capabilities {
+		reg.capabilities[priority][capability] = true
+	}
+}
+
+// pluginCapabilities returns the capabilities of the plugin with the given priority.
+// Plugins that don't declare them have all the capabilities, unless they are required.
+func (reg *Registry) pluginCapabilities(priority sdkPlugin.Priority) map[Capability]bool {
+	if capabilities, ok := reg.capabilities[priority]; ok {
+		return capabilities
+	}
+	if reg.RequireCapabilities {
+		return map[Capability]bool{}
+	}
+	return nil
+}
+
+// ScheduleJobs schedules the jobs of the plugin with the given priority, which run its
+// onScheduled hook. The plugin must register the hook beforehand.
+func (reg *Registry) ScheduleJobs(pluginID sdkPlugin.Identifier, priority sdkPlugin.Priority, jobs []ScheduledJob) {
+	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "ScheduleJobs")
+	defer span.End()
+
+	hookMethod, ok := reg.hooks[sdk.OnScheduled][reg.hookPriority(priority, sdk.OnScheduled)]
+	if !ok {
+		reg.Logger.Warn().Str("name", pluginID.Name).Msg(
+			"Plugin has scheduled jobs, but doesn't register the onScheduled hook, so they won't run")
+		return
+	}
+
+	for _, job := range jobs {
+		jobName := job.Name
+		// Prefix the job name with the plugin name, so the metrics of the jobs
+		// of different plugins don't collide.
+		job.Name = pluginID.Name + "/" + jobName
+		run := func(ctx context.Context) error {
+			args, err := v1.NewStruct(map[string]interface{}{
+				"job":         jobName,
+				"scheduledAt": time.Now().UTC().Format(time.RFC3339),
+			})
+			if err != nil {
+				return gerr.ErrCastFailed.Wrap(err)
+			}
+			if _, err := hookMethod(ctx, args); err != nil {
+				return err //nolint:wrapcheck
+			}
+			return nil
+		}
+		if err := reg.Scheduler.Add(pluginID.Name, job, run); err != nil {
+			reg.Logger.Error().Err(err).Fields(
+				map[string]interface{}{
+					"name": pluginID.Name,
+					"job":  jobName,
+					"cron": job.Cron,
 				},
-			).Msg("Hook is replaced")
+			).Msg("Failed to schedule plugin job")
+			span.RecordError(err)
+		}
+	}
+}
+
+// MetricHooks returns the onMetric hooks, sorted by priority.
+func (reg *Registry) MetricHooks() []sdkPlugin.Method {
+	priorities := make([]sdkPlugin.Priority, 0, len(reg.hooks[sdk.OnMetric]))
+	for priority := range reg.hooks[sdk.OnMetric] {
+		priorities = append(priorities, priority)
+	}
+	sort.SliceStable(priorities, func(i, j int) bool {
+		return priorities[i] < priorities[j]
+	})
+
+	hooks := make([]sdkPlugin.Method, 0, len(priorities))
+	for _, priority := range priorities {
+		hooks = append(hooks, reg.hooks[sdk.OnMetric][priority])
+	}
+	return hooks
+}
+
+// EmitMetric sends the sample to the onMetric hooks in the background, if any plugin
+// registered them.
+func (reg *Registry) EmitMetric(sample MetricSample) {
+	if len(reg.hooks[sdk.OnMetric]) == 0 {
+		return
+	}
+	reg.MetricEnricher.Emit(sample)
+}
+
+// Batching returns true if any of the hooks are delivered in batches.
+func (reg *Registry) Batching() bool {
+	for _, batchers := range reg.batchers {
+		if len(batchers) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush sends the pending batches of all the batched hooks, e.g. on transaction
+// boundaries, so that the plugins see the traffic of a transaction once it ends.
+func (reg *Registry) Flush() {
+	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "Flush")
+	defer span.End()
+
+	for _, batchers := range reg.batchers {
+		for _, batcher := range batchers {
+			batcher.Flush()
 		}
-		reg.hooks[hookName][priority] = hookMethod
 	}
 }
 
@@ -270,19 +706,6 @@ func (reg *Registry) Run(
 	hookName v1.HookName,
 	opts ...grpc.CallOption,
 ) (map[string]interface{}, *gerr.GatewayDError) {
-	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "Run")
-	defer span.End()
-
-	metrics.PluginHooksExecuted.Inc()
-
-	if ctx == nil {
-		return nil, gerr.ErrNilContext
-	}
-
-	// Inherit context.
-	inheritedCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
 	// Cast custom fields to their primitive types, like time.Duration to float64.
 	args = CastToPrimitiveTypes(args)
 
@@ -293,10 +716,58 @@ func (reg *Registry) Run(
 	} else if casted, err := v1.NewStruct(args); err == nil {
 		params = casted
 	} else {
-		span.RecordError(err)
 		return nil, gerr.ErrCastFailed.Wrap(err)
 	}
 
+	result, err := reg.run(ctx, params, hookName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return result.AsMap(), nil
+}
+
+// RunTyped runs the hooks like Run, with the typed message of the hook as their
+// arguments. The message is converted to the v1.Struct of the hooks once, without
+// going through a map, and the result is returned as is, for the caller to read it
+// with the typed message, e.g. sdk.NewTrafficMessage.
+func (reg *Registry) RunTyped(
+	ctx context.Context,
+	message sdk.Message,
+	hookName v1.HookName,
+	opts ...grpc.CallOption,
+) (*v1.Struct, *gerr.GatewayDError) {
+	return reg.run(ctx, message.Struct(nil), hookName, opts...)
+}
+
+// run runs the hooks with the arguments, passing the result of each hook to the next.
+func (reg *Registry) run(
+	ctx context.Context,
+	params *v1.Struct,
+	hookName v1.HookName,
+	opts ...grpc.CallOption,
+) (*v1.Struct, *gerr.GatewayDError) {
+	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "Run")
+	defer span.End()
+
+	metrics.PluginHooksExecuted.Inc()
+	defer func(start time.Time) {
+		metrics.PluginHookDuration.Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	if ctx == nil {
+		return nil, gerr.ErrNilContext
+	}
+
+	// Skip the runs of the hook that aren't sampled, as if no plugin registered it.
+	if !reg.sampler.Sample(hookName) {
+		metrics.PluginHookRunsSampledOut.WithLabelValues(hookName.String()).Inc()
+		return &v1.Struct{}, nil
+	}
+
+	// Inherit context.
+	inheritedCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Sort hooks by priority.
 	priorities := make([]sdkPlugin.Priority, 0, len(reg.hooks[hookName]))
 	for priority := range reg.hooks[hookName] {
@@ -306,20 +777,130 @@ func (reg *Registry) Run(
 		return priorities[i] < priorities[j]
 	})
 
+	// Record the hook chain, if it is sampled.
+	var chain *HookChain
+	if reg.Tracer.Sample() {
+		chain = &HookChain{Time: time.Now(), Hook: hookName.String()}
+		defer func() {
+			reg.Tracer.Record(*chain)
+		}()
+	}
+
+	// Account the duration of the chain in its latency budget, with its slowest hook.
+	var slowest sdkPlugin.Priority
+	var slowestDuration time.Duration
+	if reg.budgets != nil {
+		defer func(start time.Time) {
+			reg.observeBudget(hookName, time.Since(start), slowest, slowestDuration > 0)
+		}(time.Now())
+	}
+
 	// Run hooks, passing the result of the previous hook to the next one.
 	returnVal := &v1.Struct{}
 	var removeList []sdkPlugin.Priority
 	// The signature of parameters and args MUST be the same for this to work.
 	for idx, priority := range priorities {
-		var result *v1.Struct
-		var err error
+		input := returnVal
 		if idx == 0 {
-			result, err = reg.hooks[hookName][priority](inheritedCtx, params, opts...)
+			input = params
+		}
+
+		// The fields, capabilities and name are the ones of the plugin of the hook.
+		pluginPriority := reg.pluginPriority(hookName, priority)
+
+		// Only pass the fields the plugin needs, if it has declared them.
+		hookParams := input
+		fields, filtered := reg.fields[pluginPriority]
+		if filtered {
+			hookParams = FilterFields(input, fields)
+		}
+
+		// Hide the fields the plugin isn't allowed to see.
+		capabilities := reg.pluginCapabilities(pluginPriority)
+		if capabilities != nil {
+			var restricted bool
+			hookParams, restricted = restrictParams(hookName, hookParams, capabilities)
+			filtered = filtered || restricted
+		}
+
+		pluginName := reg.pluginName(pluginPriority)
+		var step *HookStep
+		if chain != nil {
+			step = &HookStep{Plugin: pluginName, Priority: priority, Input: hookParams.AsMap()}
+		}
+
+		// Batched hooks only observe the arguments, so the chain continues as is.
+		if batcher, ok := reg.batchers[hookName][priority]; ok {
+			batcher.Add(hookParams)
+			returnVal = input
+			if step != nil {
+				step.Mode = "batched"
+				chain.Steps = append(chain.Steps, *step)
+			}
+			continue
+		}
+
+		// So do async hooks, which run in the background.
+		if reg.async[hookName][priority] {
+			reg.dispatcher.Dispatch(hookName, priority, reg.hooks[hookName][priority], hookParams)
+			returnVal = input
+			if step != nil {
+				step.Mode = "async"
+				chain.Steps = append(chain.Steps, *step)
+			}
+			continue
+		}
+
+		// So do the hooks demoted for exceeding the latency budget of the chain, unless
+		// they are disabled.
+		if action, demoted := reg.budgets.Demoted(hookName, priority); demoted {
+			if action == config.BudgetAsync {
+				reg.dispatcher.Dispatch(hookName, priority, reg.hooks[hookName][priority], hookParams)
+			}
+			returnVal = input
+			if step != nil {
+				step.Mode = string(action)
+				chain.Steps = append(chain.Steps, *step)
+			}
+			continue
+		}
+
+		// Serve the repeated calls of the cacheable hooks from the cache.
+		start := time.Now()
+		result, cacheable := reg.cache.Get(hookName, priority, hookParams)
+		var err error
+		if result != nil {
+			metrics.PluginHookCacheHits.WithLabelValues(pluginName, hookName.String()).Inc()
+			if step != nil {
+				step.Mode = "cached"
+			}
 		} else {
-			result, err = reg.hooks[hookName][priority](inheritedCtx, returnVal, opts...)
+			if cacheable {
+				metrics.PluginHookCacheMisses.WithLabelValues(pluginName, hookName.String()).Inc()
+			}
+
+			// Compress the large arguments, if the plugin supports it.
+			callOpts := opts
+			if compressor := reg.compressors[pluginPriority]; compressor != "" &&
+				reg.compression.Compress(hookParams) {
+				callOpts = append(slices.Clip(opts), grpc.UseCompressor(compressor))
+				metrics.PluginHookCompressedCalls.WithLabelValues(pluginName, compressor).Inc()
+			}
+
+			result, err = reg.hooks[hookName][priority](inheritedCtx, hookParams, callOpts...)
+			if duration := time.Since(start); duration > slowestDuration {
+				slowest, slowestDuration = priority, duration
+			}
+			metrics.PluginHookInvocations.WithLabelValues(pluginName, hookName.String()).Inc()
+			metrics.PluginHookLatency.WithLabelValues(pluginName, hookName.String()).Observe(
+				time.Since(start).Seconds())
+			if err == nil {
+				result = reg.cache.Put(hookName, priority, hookParams, result)
+			}
 		}
 
 		if err != nil {
+			metrics.PluginHookErrors.WithLabelValues(pluginName, hookName.String()).Inc()
 			reg.Logger.Error().Err(err).Fields(
 				map[string]interface{}{
 					"hookName": hookName.String(),
@@ -333,9 +914,32 @@ func (reg *Registry) Run(
 		// and that the hook does not return any unexpected values.
 		// If the verification mode is non-strict (permissive), let the plugin pass
 		// extra keys/values to the next plugin in chain.
-		if Verify(params, result) || reg.Verification == config.PassDown {
-			// Update the last return value with the current result
-			returnVal = result
+		verified := Verify(hookParams, result)
+		reg.recordHook(hookName, pluginName, time.Since(start), verified, err)
+		if step != nil {
+			step.Output = result.AsMap()
+			step.Verified = verified
+			step.Duration = time.Since(start)
+			if err != nil {
+				step.Error = err.Error()
+			}
+			chain.Steps = append(chain.Steps, *step)
+		}
+		if !verified && err == nil {
+			metrics.PluginHookModifications.WithLabelValues(pluginName, hookName.String()).Inc()
+		}
+		if result.GetFields()["terminate"].GetBoolValue() {
+			metrics.PluginHookTerminations.WithLabelValues(pluginName, hookName.String()).Inc()
+		}
+
+		if verified || reg.Verification == config.PassDown {
+			// Update the last return value with the current result, keeping
+			// the fields that weren't passed to the plugin.
+			if filtered {
+				result = MergeFields(input, result)
+			}
+			returnVal = reg.enforceCapabilities(hookName, priority, input, result, capabilities)
+			result = returnVal
 
 			// If the termination policy is set to Stop, check if the terminate flag
 			// is set to true. If it is, abort the execution of the rest of the registered hooks.
@@ -361,9 +965,9 @@ func (reg *Registry) Run(
 		// Abort execution of the plugins, log the error and return the result of the last
 		case config.Abort:
 			if idx == 0 {
-				return args, nil
+				return params, nil
 			}
-			return returnVal.AsMap(), nil
+			return returnVal, nil
 		// Remove the hook from the registry, log the error and execute the next
 		case config.Remove:
 			removeList = append(removeList, priority)
@@ -372,7 +976,10 @@ func (reg *Registry) Run(
 			}
 		case config.PassDown: // fallthrough
 		default:
-			returnVal = result
+			if filtered {
+				result = MergeFields(input, result)
+			}
+			returnVal = reg.enforceCapabilities(hookName, priority, input, result, capabilities)
 		}
 	}
 
@@ -381,7 +988,61 @@ func (reg *Registry) Run(
 		delete(reg.hooks[hookName], priority)
 	}
 
-	return returnVal.AsMap(), nil
+	return returnVal, nil
+}
+
+// recordHook keeps the outcome of the hook in the flight recorder.
+func (reg *Registry) recordHook(
+	hookName v1.HookName, pluginName string, duration time.Duration, verified bool, err error,
+) {
+	fields := map[string]interface{}{
+		"plugin":   pluginName,
+		"hookName": hookName.String(),
+		"duration": duration.String(),
+		"verified": verified,
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		recorder.Add(recorder.Hook, "Hook returned an error", fields)
+		return
+	}
+	recorder.Add(recorder.Hook, "Hook ran", fields)
+}
+
+// pluginName returns the name of the plugin with the given priority, or the priority
+// itself if the hook was added without a plugin.
+func (reg *Registry) pluginName(priority sdkPlugin.Priority) string {
+	if name, ok := reg.names.Load(priority); ok {
+		if name, ok := name.(string); ok {
+			return name
+		}
+	}
+	return strconv.FormatUint(uint64(priority), 10)
+}
+
+// enforceCapabilities reverts the changes of the result of a hook that the plugin
+// isn't allowed to make, e.g. rewriting the request without the modify_traffic capability.
+func (reg *Registry) enforceCapabilities(
+	hookName v1.HookName,
+	priority sdkPlugin.Priority,
+	input, result *v1.Struct,
+	capabilities map[Capability]bool,
+) *v1.Struct {
+	if capabilities == nil {
+		return result
+	}
+
+	enforced, violated := enforceResult(input, result, capabilities)
+	if violated {
+		reg.Logger.Warn().Fields(
+			map[string]interface{}{
+				"hookName": hookName.String(),
+				"priority": priority,
+			},
+		).Msg("Plugin made changes its capabilities don't allow, so they are reverted")
+		metrics.PluginCapabilityViolations.Inc()
+	}
+	return enforced
 }
 
 // LoadPlugins loads plugins from the config file.
@@ -537,6 +1198,62 @@ func (reg *Registry) LoadPlugins(
 				"Plugin doesn't have any requirements")
 		}
 
+		// Retrieve the capabilities of the plugin.
+		if capabilities := metadata.GetFields()["capabilities"]; capabilities != nil &&
+			capabilities.GetListValue() != nil {
+			var pluginCapabilities []Capability
+			if err := mapstructure.Decode(
+				capabilities.GetListValue().AsSlice(), &pluginCapabilities); err != nil {
+				reg.Logger.Debug().Err(err).Msg("Failed to decode plugin capabilities")
+			}
+			reg.SetCapabilities(plugin.Priority, pluginCapabilities)
+		} else if reg.RequireCapabilities {
+			reg.Logger.Warn().Str("name", plugin.ID.Name).Msg(
+				"Plugin doesn't declare its capabilities, so it can't read or modify the traffic")
+		} else {
+			reg.Logger.Debug().Str("name", plugin.ID.Name).Msg(
+				"Plugin doesn't declare its capabilities, so it has all of them")
+		}
+
+		// Negotiate the compressor of the hooks with the plugin.
+		if compression := metadata.GetFields()["compression"]; reg.compression != nil &&
+			compression != nil && compression.GetListValue() != nil {
+			var compressors []string
+			if err := mapstructure.Decode(
+				compression.GetListValue().AsSlice(), &compressors); err != nil {
+				reg.Logger.Debug().Err(err).Msg("Failed to decode plugin compressors")
+			}
+			if compressor := reg.compression.Negotiate(compressors); compressor != "" {
+				reg.SetCompressor(plugin.Priority, compressor)
+				reg.Logger.Debug().Str("name", plugin.ID.Name).Str("compressor", compressor).Msg(
+					"Plugin hooks are compressed")
+			}
+		}
+
+		// Retrieve the JSON schema of the settings of the plugin, either as JSON or as
+		// a struct, to lint them.
+		if schema := metadata.GetFields()["configSchema"]; schema.GetStructValue() != nil {
+			if data, err := json.Marshal(schema.GetStructValue().AsMap()); err == nil {
+				reg.SetConfigSchema(plugin.Priority, data)
+			} else {
+				reg.Logger.Debug().Err(err).Msg("Failed to decode plugin config schema")
+			}
+		} else if schema.GetStringValue() != "" {
+			reg.SetConfigSchema(plugin.Priority, []byte(schema.GetStringValue()))
+		}
+
+		// Retrieve the fields of the hook arguments the plugin needs.
+		if fields, ok := metadata.GetFields()["fields"]; ok && fields != nil && fields.GetListValue() != nil {
+			var hookFields []string
+			if err := mapstructure.Decode(fields.GetListValue().AsSlice(), &hookFields); err != nil {
+				reg.Logger.Debug().Err(err).Msg("Failed to decode plugin hook fields")
+			}
+			reg.SetHookFields(plugin.Priority, hookFields)
+		} else {
+			reg.Logger.Debug().Str("name", plugin.ID.Name).Msg(
+				"Plugin doesn't declare any fields, so it receives all of them")
+		}
+
 		// Too many requirements or not enough plugins loaded.
 		// Note: Plugin requirements won't cause the required plugins to be loaded.
 		if len(plugin.Requires) > reg.plugins.Size() {
@@ -624,11 +1341,67 @@ func (reg *Registry) LoadPlugins(
 
 		span.AddEvent("Plugin metadata loaded")
 
+		// The priorities of the hooks set in the config are on the same scale as the
+		// order of the plugins, so they can run before or after the hooks of the others.
+		hookPriorities := make(map[v1.HookName]sdkPlugin.Priority, len(pCfg.Priorities))
+		for name, hookPriority := range pCfg.Priorities {
+			hookName, ok := ParseHookName(name)
+			if !ok {
+				reg.Logger.Warn().Fields(map[string]interface{}{
+					"name": plugin.ID.Name,
+					"hook": name,
+				}).Msg("Unknown hook in the priorities of the plugin, skipping")
+				continue
+			}
+			hookPriorities[hookName] = sdkPlugin.Priority(config.PluginPriorityStart + hookPriority)
+		}
+		reg.SetHookPriorities(plugin.Priority, hookPriorities)
+
 		reg.RegisterHooks(pluginCtx, plugin.ID)
 		reg.Logger.Debug().Str("name", plugin.ID.Name).Msg("Plugin hooks registered")
 
 		span.AddEvent("Registered plugin hooks")
 
+		// Run the hooks the plugin marked as async in the background.
+		if async := metadata.GetFields()["async"]; async != nil && async.GetListValue() != nil {
+			var asyncHooks []v1.HookName
+			if err := mapstructure.Decode(async.GetListValue().AsSlice(), &asyncHooks); err != nil {
+				reg.Logger.Debug().Err(err).Msg("Failed to decode plugin async hooks")
+			} else {
+				reg.SetHookAsync(plugin.Priority, asyncHooks)
+				span.AddEvent("Marked plugin hooks as async")
+			}
+		}
+
+		// Schedule the jobs of the plugin.
+		if schedules := metadata.GetFields()["schedules"]; schedules != nil && schedules.GetListValue() != nil {
+			var jobs []ScheduledJob
+			decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+				DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+				Result:     &jobs,
+			})
+			if err == nil {
+				err = decoder.Decode(schedules.GetListValue().AsSlice())
+			}
+			if err != nil {
+				reg.Logger.Debug().Err(err).Msg("Failed to decode plugin schedules")
+			} else {
+				reg.ScheduleJobs(plugin.ID, plugin.Priority, jobs)
+				span.AddEvent("Scheduled plugin jobs")
+			}
+		}
+
+		// Deliver the hooks the plugin opted into in batches.
+		if batch := metadata.GetFields()["batch"]; batch != nil && batch.GetStructValue() != nil {
+			var hookBatch HookBatch
+			if err := mapstructure.Decode(batch.GetStructValue().AsMap(), &hookBatch); err != nil {
+				reg.Logger.Debug().Err(err).Msg("Failed to decode plugin hook batch")
+			} else {
+				reg.SetHookBatch(plugin.Priority, hookBatch)
+				span.AddEvent("Batched plugin hooks")
+			}
+		}
+
 		metrics.PluginsLoaded.Inc()
 		reg.Logger.Info().Str("name", plugin.ID.Name).Msg("Plugin is ready")
 	}
@@ -727,8 +1500,11 @@ func (reg *Registry) RegisterHooks(ctx context.Context, pluginID sdkPlugin.Ident
 					"priority": pluginImpl.Priority,
 					"name":     pluginImpl.ID.Name,
 				}).Msg("Registering a custom hook")
+				if err := reg.registerHook(hookName, pluginImpl.Priority, pluginV1.OnHook); err != nil {
+					span.RecordError(err)
+					continue
+				}
 				metrics.PluginHooksRegistered.Inc()
-				reg.AddHook(hookName, pluginImpl.Priority, pluginV1.OnHook)
 			}
 			continue
 		}
@@ -738,7 +1514,10 @@ func (reg *Registry) RegisterHooks(ctx context.Context, pluginID sdkPlugin.Ident
 			"priority": pluginImpl.Priority,
 			"name":     pluginImpl.ID.Name,
 		}).Msg("Registering hook")
+		if err := reg.registerHook(hookName, pluginImpl.Priority, hookMethod); err != nil {
+			span.RecordError(err)
+			continue
+		}
 		metrics.PluginHooksRegistered.Inc()
-		reg.AddHook(hookName, pluginImpl.Priority, hookMethod)
 	}
 }
@@ -2,13 +2,19 @@ package plugin
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
 	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
 	"github.com/gatewayd-io/gatewayd/config"
+	gerr "github.com/gatewayd-io/gatewayd/errors"
 	"github.com/gatewayd-io/gatewayd/logging"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
@@ -102,6 +108,90 @@ func Test_PluginRegistry_AddHook_Multiple(t *testing.T) {
 	assert.NotNil(t, reg.Hooks()[v1.HookName_HOOK_NAME_ON_NEW_LOGGER][1])
 }
 
+// Test_PluginRegistry_AddHook_Exists tests that a hook with the same priority is only
+// replaced if the override is allowed.
+func Test_PluginRegistry_AddHook_Exists(t *testing.T) {
+	hook := func(value string) sdkPlugin.Method {
+		return func(
+			ctx context.Context,
+			args *v1.Struct,
+			opts ...grpc.CallOption,
+		) (*v1.Struct, error) {
+			args.Fields["test"] = v1.NewStringValue(value)
+			return args, nil
+		}
+	}
+
+	reg := NewPluginRegistry(t)
+	assert.Nil(t, reg.AddHook(v1.HookName_HOOK_NAME_ON_NEW_LOGGER, 0, hook("first")))
+	assert.Equal(t, gerr.ErrHookExists, reg.AddHook(v1.HookName_HOOK_NAME_ON_NEW_LOGGER, 0, hook("second")))
+	result, err := reg.Run(
+		context.Background(), map[string]interface{}{"test": "test"}, v1.HookName_HOOK_NAME_ON_NEW_LOGGER)
+	assert.Nil(t, err)
+	assert.Equal(t, "first", result["test"])
+
+	reg.AllowOverride = true
+	assert.Nil(t, reg.AddHook(v1.HookName_HOOK_NAME_ON_NEW_LOGGER, 0, hook("second")))
+	result, err = reg.Run(
+		context.Background(), map[string]interface{}{"test": "test"}, v1.HookName_HOOK_NAME_ON_NEW_LOGGER)
+	assert.Nil(t, err)
+	assert.Equal(t, "second", result["test"])
+}
+
+// Test_PluginRegistry_HookPriorities tests that the hooks run in the order of the
+// priorities set in the config, with the fields of their plugin.
+func Test_PluginRegistry_HookPriorities(t *testing.T) {
+	var order []string
+	hook := func(name string) sdkPlugin.Method {
+		return func(
+			ctx context.Context,
+			args *v1.Struct,
+			opts ...grpc.CallOption,
+		) (*v1.Struct, error) {
+			order = append(order, name+":"+strings.Join(sortedKeys(args.AsMap()), ","))
+			return args, nil
+		}
+	}
+
+	reg := NewPluginRegistry(t)
+	first := &Plugin{ID: sdkPlugin.Identifier{Name: "first"}, Priority: 1000}
+	second := &Plugin{ID: sdkPlugin.Identifier{Name: "second"}, Priority: 1001}
+	reg.Add(first)
+	reg.Add(second)
+
+	// The hook of the second plugin runs first, and only receives its fields.
+	reg.SetHookPriorities(second.Priority, map[v1.HookName]sdkPlugin.Priority{
+		v1.HookName_HOOK_NAME_ON_TRAFFIC: 999,
+	})
+	reg.SetHookFields(second.Priority, []string{"query"})
+	assert.Nil(t, reg.registerHook(v1.HookName_HOOK_NAME_ON_TRAFFIC, first.Priority, hook("first")))
+	assert.Nil(t, reg.registerHook(v1.HookName_HOOK_NAME_ON_TRAFFIC, second.Priority, hook("second")))
+	assert.Contains(t, reg.Hooks()[v1.HookName_HOOK_NAME_ON_TRAFFIC], sdkPlugin.Priority(999))
+	assert.Equal(t, "second", reg.pluginName(reg.pluginPriority(v1.HookName_HOOK_NAME_ON_TRAFFIC, 999)))
+
+	_, err := reg.Run(
+		context.Background(),
+		map[string]interface{}{"query": "SELECT 1;", "client": "127.0.0.1"},
+		v1.HookName_HOOK_NAME_ON_TRAFFIC)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"second:query", "first:client,query"}, order)
+
+	reg.Remove(second.ID)
+	assert.Len(t, reg.Hooks()[v1.HookName_HOOK_NAME_ON_TRAFFIC], 1)
+	assert.Empty(t, reg.owners[v1.HookName_HOOK_NAME_ON_TRAFFIC])
+	assert.Empty(t, reg.priorities)
+}
+
+// sortedKeys returns the sorted keys of the map.
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Test_HookRegistry_Run tests the Run function.
 func Test_PluginRegistry_Run(t *testing.T) {
 	reg := NewPluginRegistry(t)
@@ -118,6 +208,37 @@ func Test_PluginRegistry_Run(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+// Test_PluginRegistry_RunTyped tests that the hooks get the typed message as their
+// arguments, and that their result is returned as is.
+func Test_PluginRegistry_RunTyped(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, 0, func(
+		ctx context.Context,
+		args *v1.Struct,
+		opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		msg := sdk.NewTrafficMessage(args)
+		assert.Equal(t, "localhost:45320", msg.Client.Remote)
+		assert.Equal(t, "SELECT 1;", msg.Query())
+		msg.Response = []byte("cached")
+		msg.Terminate = true
+		return msg.Struct(args), nil
+	})
+
+	result, err := reg.RunTyped(
+		context.Background(),
+		&sdk.TrafficMessage{
+			Client:       sdk.Address{Local: "localhost:15432", Remote: "localhost:45320"},
+			Request:      []byte("Q\x00\x00\x00\x0dSELECT 1;\x00"),
+			ConnectionID: "connection",
+		},
+		v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("cached"), sdk.Response(result))
+	assert.True(t, result.GetFields()[sdk.FieldTerminate].GetBoolValue())
+	assert.Equal(t, "connection", sdk.ConnectionID(result))
+}
+
 // Test_HookRegistry_Run_PassDown tests the Run function with the PassDown option.
 func Test_PluginRegistry_Run_PassDown(t *testing.T) {
 	reg := NewPluginRegistry(t)
@@ -294,6 +415,260 @@ func Test_HookRegistry_Run_Remove(t *testing.T) {
 	assert.Len(t, reg.Hooks()[v1.HookName_HOOK_NAME_ON_NEW_LOGGER], 1)
 }
 
+// Test_HookRegistry_Run_Fields tests the Run function with hooks that only
+// receive the fields they need.
+func Test_HookRegistry_Run_Fields(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	reg.Verification = config.Ignore
+	reg.SetHookFields(0, []string{"query"})
+	// This only receives the query, and its result is merged with the rest of the fields.
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_NEW_LOGGER, 0, func(
+		ctx context.Context,
+		args *v1.Struct,
+		opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		assert.Equal(t, map[string]interface{}{"query": "SELECT 1"}, args.AsMap())
+		args.Fields["query"] = v1.NewStringValue("SELECT 2")
+		return args, nil
+	})
+	// This receives all the fields.
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_NEW_LOGGER, 1, func(
+		ctx context.Context,
+		args *v1.Struct,
+		opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		assert.Equal(t, map[string]interface{}{
+			"query":   "SELECT 2",
+			"request": "payload",
+		}, args.AsMap())
+		return args, nil
+	})
+
+	result, err := reg.Run(
+		context.Background(),
+		map[string]interface{}{"query": "SELECT 1", "request": "payload"},
+		v1.HookName_HOOK_NAME_ON_NEW_LOGGER)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"query":   "SELECT 2",
+		"request": "payload",
+	}, result)
+}
+
+// Test_HookRegistry_Run_Batch tests the Run function with a hook that is
+// delivered in batches.
+func Test_HookRegistry_Run_Batch(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	recorder := &batchRecorder{}
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_TRAFFIC, 0, recorder.hook)
+	reg.SetHookBatch(0, HookBatch{
+		Hooks:    []v1.HookName{v1.HookName_HOOK_NAME_ON_TRAFFIC},
+		Size:     10,
+		Interval: 60000,
+	})
+	assert.True(t, reg.Batching())
+
+	for index := 0; index < 3; index++ {
+		result, err := reg.Run(
+			context.Background(),
+			map[string]interface{}{"index": index},
+			v1.HookName_HOOK_NAME_ON_TRAFFIC)
+		assert.Nil(t, err)
+		// The arguments are passed down as is.
+		assert.Equal(t, map[string]interface{}{"index": float64(index)}, result)
+	}
+	assert.Empty(t, recorder.Batches())
+
+	reg.Flush()
+	assert.Eventually(t, func() bool {
+		return len(recorder.Batches()) == 1
+	}, time.Second, time.Millisecond)
+	assert.Len(t, recorder.Batches()[0], 3)
+}
+
+// Test_HookRegistry_Run_Async tests the Run function with an async hook, which
+// doesn't block the rest of the hooks.
+func Test_HookRegistry_Run_Async(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	called := make(chan *v1.Struct, 1)
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_TRAFFIC, 0, func(
+		ctx context.Context,
+		args *v1.Struct,
+		opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		called <- args
+		// The result of an async hook is ignored.
+		return &v1.Struct{}, nil
+	})
+	reg.SetHookAsync(0, []v1.HookName{v1.HookName_HOOK_NAME_ON_TRAFFIC})
+
+	result, err := reg.Run(
+		context.Background(),
+		map[string]interface{}{"test": "test"},
+		v1.HookName_HOOK_NAME_ON_TRAFFIC)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"test": "test"}, result)
+
+	select {
+	case args := <-called:
+		assert.Equal(t, map[string]interface{}{"test": "test"}, args.AsMap())
+	case <-time.After(time.Second):
+		t.Fatal("async hook was not called")
+	}
+	reg.Shutdown()
+}
+
+// Test_PluginRegistry_Shutdown tests that the registry waits for the plugins to
+// acknowledge the OnShutdownComplete hooks before stopping them, until the timeout.
+func Test_PluginRegistry_Shutdown(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	reg.ShutdownTimeout = 50 * time.Millisecond
+	acknowledged := make(chan *v1.Struct, 1)
+	reg.AddHook(sdk.OnShutdownComplete, 0, func(
+		ctx context.Context,
+		args *v1.Struct,
+		opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		acknowledged <- args
+		// The plugin doesn't return before the deadline.
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	start := time.Now()
+	reg.Shutdown()
+	assert.Less(t, time.Since(start), time.Second)
+	select {
+	case args := <-acknowledged:
+		assert.NotEmpty(t, args.AsMap()["deadline"])
+	default:
+		t.Fatal("the OnShutdownComplete hook was not called")
+	}
+}
+
+// Test_HookRegistry_Run_Capabilities tests that the Run function doesn't apply the
+// changes of a plugin without the capabilities to make them.
+func Test_HookRegistry_Run_Capabilities(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	reg.RequireCapabilities = true
+	reg.SetCapabilities(0, []Capability{ReadTraffic})
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, 0, func(
+		ctx context.Context,
+		args *v1.Struct,
+		opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		assert.Equal(t, []byte("SELECT 1"), args.GetFields()["request"].GetBytesValue())
+		return v1.NewStruct(map[string]interface{}{
+			"request":   []byte("DROP TABLE users"),
+			"terminate": true,
+		})
+	})
+	// This plugin doesn't declare its capabilities, so it has none of them.
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, 1, func(
+		ctx context.Context,
+		args *v1.Struct,
+		opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		assert.Nil(t, args.GetFields()["request"])
+		return args, nil
+	})
+
+	result, err := reg.Run(
+		context.Background(),
+		map[string]interface{}{"request": []byte("SELECT 1")},
+		v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"request": []byte("SELECT 1")}, result)
+}
+
+// Test_HookRegistry_Run_Metrics tests that the Run function records the metrics
+// of each plugin hook.
+func Test_HookRegistry_Run_Metrics(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	reg.Add(&Plugin{ID: sdkPlugin.Identifier{Name: "metrics-plugin"}, Priority: 42})
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_TICK, 42, func(
+		ctx context.Context,
+		args *v1.Struct,
+		opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		return v1.NewStruct(map[string]interface{}{"terminate": true})
+	})
+
+	_, err := reg.Run(context.Background(), map[string]interface{}{}, v1.HookName_HOOK_NAME_ON_TICK)
+	assert.Nil(t, err)
+
+	hook := v1.HookName_HOOK_NAME_ON_TICK.String()
+	assert.Equal(t, 1.0, testutil.ToFloat64(
+		metrics.PluginHookInvocations.WithLabelValues("metrics-plugin", hook)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(
+		metrics.PluginHookModifications.WithLabelValues("metrics-plugin", hook)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(
+		metrics.PluginHookTerminations.WithLabelValues("metrics-plugin", hook)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(
+		metrics.PluginHookErrors.WithLabelValues("metrics-plugin", hook)))
+}
+
+// Test_HookRegistry_Run_Panic tests that a panicking hook is handled like a hook that
+// returned an error, and that the next hooks still run.
+func Test_HookRegistry_Run_Panic(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	reg.Verification = config.Ignore
+	reg.Add(&Plugin{ID: sdkPlugin.Identifier{Name: "panicking-plugin"}, Priority: 0})
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_NEW_LOGGER, 0, func(
+		ctx context.Context,
+		args *v1.Struct,
+		opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		panic("plugin crashed")
+	})
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_NEW_LOGGER, 1, func(
+		ctx context.Context,
+		args *v1.Struct,
+		opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		args.Fields["test"] = v1.NewStringValue("test")
+		return args, nil
+	})
+
+	result, err := reg.Run(
+		context.Background(), map[string]interface{}{"test": ""}, v1.HookName_HOOK_NAME_ON_NEW_LOGGER)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"test": "test"}, result)
+
+	hook := v1.HookName_HOOK_NAME_ON_NEW_LOGGER.String()
+	assert.Equal(t, 1.0, testutil.ToFloat64(
+		metrics.PluginHookPanics.WithLabelValues("panicking-plugin", hook)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(
+		metrics.PluginHookErrors.WithLabelValues("panicking-plugin", hook)))
+}
+
+// Test_HookRegistry_Run_Trace tests that the Run function records the inputs and
+// outputs of each plugin hook.
+func Test_HookRegistry_Run_Trace(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	reg.Tracer.Configure(true, 1)
+	reg.AddHook(v1.HookName_HOOK_NAME_ON_TICK, 0, func(
+		ctx context.Context,
+		args *v1.Struct,
+		opts ...grpc.CallOption,
+	) (*v1.Struct, error) {
+		return v1.NewStruct(map[string]interface{}{"test": "mangled"})
+	})
+
+	_, err := reg.Run(
+		context.Background(), map[string]interface{}{"test": "test"}, v1.HookName_HOOK_NAME_ON_TICK)
+	assert.Nil(t, err)
+
+	chains := reg.Tracer.Dump()
+	assert.Len(t, chains, 1)
+	assert.Equal(t, v1.HookName_HOOK_NAME_ON_TICK.String(), chains[0].Hook)
+	assert.Len(t, chains[0].Steps, 1)
+	assert.Equal(t, "0", chains[0].Steps[0].Plugin)
+	assert.Equal(t, map[string]interface{}{"test": "test"}, chains[0].Steps[0].Input)
+	assert.Equal(t, map[string]interface{}{"test": "mangled"}, chains[0].Steps[0].Output)
+	assert.False(t, chains[0].Steps[0].Verified)
+}
+
 func BenchmarkHookRun(b *testing.B) {
 	cfg := logging.LoggerConfig{
 		Output:            []config.LogOutput{config.Console},
@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"go.opentelemetry.io/otel"
+)
+
+// PreflightFailure is a plugin that failed its preflight checks, with the errors of its
+// config and its unavailable dependencies.
+type PreflightFailure struct {
+	Plugin sdkPlugin.Identifier
+	Errors []string
+}
+
+// Preflight runs the onValidate hooks of the plugins, each within the timeout, and
+// returns the plugins that failed, sorted by name. The plugins that don't register the
+// hook pass.
+func (reg *Registry) Preflight(ctx context.Context, timeout time.Duration) []PreflightFailure {
+	_, span := otel.Tracer(config.TracerName).Start(reg.ctx, "Preflight")
+	defer span.End()
+
+	var failures []PreflightFailure
+	reg.ForEach(func(pluginID sdkPlugin.Identifier, plugin *Plugin) {
+		priority := reg.hookPriority(plugin.Priority, sdk.OnValidate)
+		hookMethod, ok := reg.hooks[sdk.OnValidate][priority]
+		if !ok || reg.pluginPriority(sdk.OnValidate, priority) != plugin.Priority {
+			return
+		}
+		if errors := reg.validate(ctx, plugin, hookMethod, timeout); len(errors) > 0 {
+			failures = append(failures, PreflightFailure{Plugin: pluginID, Errors: errors})
+			metrics.PluginPreflightFailures.WithLabelValues(pluginID.Name).Inc()
+		}
+	})
+
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].Plugin.Name < failures[j].Plugin.Name
+	})
+	return failures
+}
+
+// validate runs the onValidate hook of the plugin with its config, and returns the
+// errors of its config and its unavailable dependencies.
+func (reg *Registry) validate(
+	ctx context.Context, plugin *Plugin, hookMethod sdkPlugin.Method, timeout time.Duration,
+) []string {
+	pluginConfig := make(map[string]interface{}, len(plugin.Config))
+	for key, value := range plugin.Config {
+		pluginConfig[key] = value
+	}
+	args, err := v1.NewStruct(map[string]interface{}{sdk.FieldConfig: pluginConfig})
+	if err != nil {
+		return []string{fmt.Sprintf("failed to cast the config of the plugin: %s", err)}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := hookMethod(ctx, args)
+	if err != nil {
+		return []string{fmt.Sprintf("the onValidate hook failed: %s", err)}
+	}
+
+	var errors []string
+	for _, value := range result.GetFields()[sdk.FieldErrors].GetListValue().GetValues() {
+		errors = append(errors, value.GetStringValue())
+	}
+	for _, dependency := range sdk.Dependencies(result) {
+		if dependency.OK {
+			reg.Logger.Debug().Str("name", plugin.ID.Name).Str(
+				"dependency", dependency.Name).Msg("Plugin dependency is available")
+			continue
+		}
+		errors = append(errors, fmt.Sprintf(
+			"the dependency %q isn't available: %s", dependency.Name, dependency.Error))
+	}
+	return errors
+}
@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// Test_HookRegistry_Preflight tests that the plugins whose onValidate hooks report
+// errors, unavailable dependencies or fail are returned, and that the plugins that
+// don't register the hook pass.
+func Test_HookRegistry_Preflight(t *testing.T) {
+	reg := NewPluginRegistry(t)
+	plugins := map[string]func(args *v1.Struct) (*v1.Struct, error){
+		"valid": func(args *v1.Struct) (*v1.Struct, error) {
+			return sdk.Validation(nil, []sdk.Dependency{{Name: "redis", OK: true}}), nil
+		},
+		"invalid": func(args *v1.Struct) (*v1.Struct, error) {
+			config := args.GetFields()[sdk.FieldConfig].GetStructValue().GetFields()
+			return sdk.Validation(
+				[]string{"invalid ttl " + config["ttl"].GetStringValue()},
+				[]sdk.Dependency{{Name: "redis", Error: "connection refused"}},
+			), nil
+		},
+		"failing": func(args *v1.Struct) (*v1.Struct, error) {
+			return nil, errors.New("license expired")
+		},
+	}
+	priority := sdkPlugin.Priority(0)
+	for name, validate := range plugins {
+		reg.Add(&Plugin{
+			ID:       sdkPlugin.Identifier{Name: name},
+			Priority: priority,
+			Config:   map[string]string{"ttl": "forever"},
+		})
+		require.Nil(t, reg.AddHook(sdk.OnValidate, priority, func(
+			ctx context.Context, args *v1.Struct, opts ...grpc.CallOption,
+		) (*v1.Struct, error) {
+			return validate(args)
+		}))
+		priority++
+	}
+	reg.Add(&Plugin{ID: sdkPlugin.Identifier{Name: "unvalidated"}, Priority: priority})
+
+	assert.Equal(t, []PreflightFailure{
+		{
+			Plugin: sdkPlugin.Identifier{Name: "failing"},
+			Errors: []string{"the onValidate hook failed: license expired"},
+		},
+		{
+			Plugin: sdkPlugin.Identifier{Name: "invalid"},
+			Errors: []string{
+				"invalid ttl forever",
+				"the dependency \"redis\" isn't available: connection refused",
+			},
+		},
+	}, reg.Preflight(context.Background(), time.Second))
+}
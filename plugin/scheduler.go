@@ -0,0 +1,140 @@
+package plugin
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/go-co-op/gocron"
+	"github.com/rs/zerolog"
+)
+
+// MissedRunPolicy controls what happens when a job is due while its previous run
+// is still running.
+type MissedRunPolicy string
+
+const (
+	// SkipMissedRuns skips the runs that are due while the job is running.
+	SkipMissedRuns MissedRunPolicy = "skip"
+	// RunOnceMissedRuns runs the job once more after the current run, no matter
+	// how many runs were missed in the meantime.
+	RunOnceMissedRuns MissedRunPolicy = "runOnce"
+)
+
+// ScheduledJob is a cron-style job run by GatewayD, either for a plugin or built-in.
+type ScheduledJob struct {
+	Name string
+	// Cron is the standard five-field cron expression of the job.
+	Cron string
+	// Jitter delays each run by a random duration up to it, so that jobs with
+	// the same schedule don't all run at once.
+	Jitter          time.Duration
+	MissedRunPolicy MissedRunPolicy
+}
+
+// JobScheduler runs the scheduled jobs.
+type JobScheduler struct {
+	scheduler *gocron.Scheduler
+	timeout   time.Duration
+	logger    zerolog.Logger
+}
+
+// NewJobScheduler creates a new job scheduler. The timeout is applied to each run.
+func NewJobScheduler(timeout time.Duration, logger zerolog.Logger) *JobScheduler {
+	return &JobScheduler{
+		scheduler: gocron.NewScheduler(time.UTC),
+		timeout:   timeout,
+		logger:    logger,
+	}
+}
+
+// Add schedules the job to run the given function. The tag groups the jobs, e.g.
+// by plugin, so they can be removed together.
+func (s *JobScheduler) Add(tag string, job ScheduledJob, run func(ctx context.Context) error) error {
+	state := &jobState{job: job, run: run, scheduler: s}
+	if _, err := s.scheduler.Cron(job.Cron).Tag(tag).Do(state.trigger); err != nil {
+		return err //nolint:wrapcheck
+	}
+	s.logger.Debug().Fields(
+		map[string]interface{}{
+			"job":  job.Name,
+			"cron": job.Cron,
+			"tag":  tag,
+		},
+	).Msg("Scheduled job")
+	return nil
+}
+
+// Remove removes the jobs with the given tag.
+func (s *JobScheduler) Remove(tag string) {
+	_ = s.scheduler.RemoveByTag(tag)
+}
+
+// Start starts running the jobs in the background.
+func (s *JobScheduler) Start() {
+	s.scheduler.StartAsync()
+}
+
+// Stop stops running the jobs.
+func (s *JobScheduler) Stop() {
+	s.scheduler.Stop()
+}
+
+// jobState tracks the runs of a job, to apply its missed run policy.
+type jobState struct {
+	job       ScheduledJob
+	run       func(ctx context.Context) error
+	scheduler *JobScheduler
+
+	mu      sync.Mutex
+	running bool
+	pending bool
+}
+
+// trigger is called by the scheduler every time the job is due.
+func (j *jobState) trigger() {
+	j.mu.Lock()
+	if j.running {
+		metrics.ScheduledJobMissed.WithLabelValues(j.job.Name).Inc()
+		j.pending = j.job.MissedRunPolicy == RunOnceMissedRuns
+		j.mu.Unlock()
+		return
+	}
+	j.running = true
+	j.mu.Unlock()
+
+	for {
+		j.execute()
+
+		j.mu.Lock()
+		if !j.pending {
+			j.running = false
+			j.mu.Unlock()
+			return
+		}
+		j.pending = false
+		j.mu.Unlock()
+	}
+}
+
+// execute runs the job once, after the jitter.
+func (j *jobState) execute() {
+	if j.job.Jitter > 0 {
+		//nolint:gosec
+		time.Sleep(time.Duration(rand.Int63n(int64(j.job.Jitter))))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), j.scheduler.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := j.run(ctx)
+	metrics.ScheduledJobRuns.WithLabelValues(j.job.Name).Inc()
+	metrics.ScheduledJobDuration.WithLabelValues(j.job.Name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.ScheduledJobErrors.WithLabelValues(j.job.Name).Inc()
+		j.scheduler.logger.Error().Err(err).Str("job", j.job.Name).Msg("Scheduled job failed")
+	}
+}
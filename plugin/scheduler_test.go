@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_JobScheduler tests that the scheduled jobs run and their metrics are recorded.
+func Test_JobScheduler(t *testing.T) {
+	scheduler := NewJobScheduler(time.Second, zerolog.Nop())
+	defer scheduler.Stop()
+
+	var runs atomic.Int32
+	job := ScheduledJob{Name: "test/run", Cron: "0 0 * * *"}
+	require.NoError(t, scheduler.Add("test", job, func(context.Context) error {
+		runs.Add(1)
+		return errors.New("failed")
+	}))
+	scheduler.Start()
+
+	require.NoError(t, scheduler.scheduler.RunByTag("test"))
+	assert.Eventually(t, func() bool {
+		return runs.Load() == 1
+	}, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.ScheduledJobErrors.WithLabelValues(job.Name)) == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.ScheduledJobRuns.WithLabelValues(job.Name)))
+
+	scheduler.Remove("test")
+	assert.Empty(t, scheduler.scheduler.Jobs())
+}
+
+// Test_JobScheduler_InvalidCron tests that a job with an invalid cron expression
+// isn't scheduled.
+func Test_JobScheduler_InvalidCron(t *testing.T) {
+	scheduler := NewJobScheduler(time.Second, zerolog.Nop())
+	err := scheduler.Add("test", ScheduledJob{Name: "test/invalid", Cron: "invalid"},
+		func(context.Context) error { return nil })
+	assert.Error(t, err)
+	assert.Empty(t, scheduler.scheduler.Jobs())
+}
+
+// Test_JobScheduler_MissedRunPolicy tests the runs that are due while the job is
+// still running.
+func Test_JobScheduler_MissedRunPolicy(t *testing.T) {
+	tests := []struct {
+		policy MissedRunPolicy
+		runs   int32
+	}{
+		{SkipMissedRuns, 1},
+		{RunOnceMissedRuns, 2},
+	}
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			scheduler := NewJobScheduler(time.Second, zerolog.Nop())
+			started := make(chan struct{}, 1)
+			release := make(chan struct{})
+			var runs atomic.Int32
+			state := &jobState{
+				job: ScheduledJob{
+					Name:            "test/" + string(test.policy),
+					MissedRunPolicy: test.policy,
+				},
+				run: func(context.Context) error {
+					if runs.Add(1) == 1 {
+						started <- struct{}{}
+						<-release
+					}
+					return nil
+				},
+				scheduler: scheduler,
+			}
+
+			done := make(chan struct{})
+			go func() {
+				state.trigger()
+				close(done)
+			}()
+			<-started
+			// Both runs are missed, since the first one is still running.
+			state.trigger()
+			state.trigger()
+			close(release)
+			<-done
+
+			assert.Equal(t, test.runs, runs.Load())
+			assert.Equal(t, float64(2), testutil.ToFloat64(
+				metrics.ScheduledJobMissed.WithLabelValues(state.job.Name)))
+		})
+	}
+}
@@ -2,21 +2,68 @@ package plugin
 
 import (
 	"os/exec"
+	"strings"
 	"time"
 
 	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
-	"github.com/google/go-cmp/cmp"
-	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/gatewayd-io/gatewayd/sdk"
 )
 
 // Verify compares two structs and returns true if they are equal.
 func Verify(params, returnVal *v1.Struct) bool {
-	return cmp.Equal(params.AsMap(), returnVal.AsMap(), cmp.Options{
-		cmpopts.SortMaps(func(a, b string) bool {
-			return a < b
-		}),
-		cmpopts.EquateEmpty(),
-	})
+	return sdk.Verify(params, returnVal)
+}
+
+// customHookNames holds the names of the custom hooks, which aren't in the enum.
+var customHookNames = map[v1.HookName]string{
+	sdk.OnScheduled:        "onScheduled",
+	sdk.OnMetric:           "onMetric",
+	sdk.OnBackendChanged:   "onBackendChanged",
+	sdk.OnCrossShard:       "onCrossShard",
+	sdk.OnQueryTimeout:     "onQueryTimeout",
+	sdk.OnBackendReconnect: "onBackendReconnect",
+	sdk.OnShuttingDown:     "onShuttingDown",
+	sdk.OnShutdownComplete: "onShutdownComplete",
+	sdk.OnValidate:         "onValidate",
+	sdk.OnTransactionStart: "onTransactionStart",
+	sdk.OnTransactionEnd:   "onTransactionEnd",
+}
+
+// hookNames holds the hooks by their normalized names.
+var hookNames = func() map[string]v1.HookName {
+	names := make(map[string]v1.HookName, len(customHookNames)+len(v1.HookName_value))
+	for hookName, name := range customHookNames {
+		names[normalizeHookName(name)] = hookName
+	}
+	for name, value := range v1.HookName_value {
+		if v1.HookName(value) != v1.HookName_HOOK_NAME_UNSPECIFIED {
+			names[normalizeHookName(name)] = v1.HookName(value)
+		}
+	}
+	return names
+}()
+
+// normalizeHookName lowercases the name of the hook and removes its prefix and
+// separators, so "HOOK_NAME_ON_TRAFFIC" and "onTraffic" are the same.
+func normalizeHookName(name string) string {
+	name = strings.TrimPrefix(strings.ToLower(name), "hook_name_")
+	return strings.NewReplacer("_", "", "-", "").Replace(name)
+}
+
+// ParseHookName returns the hook with the given name, either the name of the enum,
+// e.g. "HOOK_NAME_ON_TRAFFIC_FROM_CLIENT", or its camel case, e.g. "onTrafficFromClient".
+func ParseHookName(name string) (v1.HookName, bool) {
+	hookName, ok := hookNames[normalizeHookName(name)]
+	return hookName, ok
+}
+
+// HookNameString returns the name of the hook, i.e. the name of the enum, e.g.
+// "HOOK_NAME_ON_TRAFFIC_FROM_CLIENT", or the name of the custom hook, e.g. "onScheduled".
+func HookNameString(hookName v1.HookName) string {
+	if name, ok := customHookNames[hookName]; ok {
+		return name
+	}
+	return hookName.String()
 }
 
 // NewCommand returns a command with the given arguments and environment variables.
@@ -52,6 +99,13 @@ func CastToPrimitiveTypes(args map[string]interface{}) map[string]interface{} {
 				}
 			}
 			args[key] = array
+		case []string:
+			// Cast []string to []interface{}.
+			array := make([]interface{}, len(value))
+			for idx, v := range value {
+				array[idx] = v
+			}
+			args[key] = array
 		// TODO: Add more types here as needed.
 		default:
 			args[key] = value
@@ -59,3 +113,55 @@ func CastToPrimitiveTypes(args map[string]interface{}) map[string]interface{} {
 	}
 	return args
 }
+
+// FilterFields returns a new struct with only the given fields of the params,
+// so that plugins only receive the fields they need. Nested fields are separated
+// by dots, e.g. "client.remote". The values are shared with the params.
+func FilterFields(params *v1.Struct, fields []string) *v1.Struct {
+	filtered := &v1.Struct{Fields: map[string]*v1.Value{}}
+	for _, field := range fields {
+		source, target := params, filtered
+		keys := strings.Split(field, ".")
+		for idx, key := range keys {
+			value, ok := source.GetFields()[key]
+			if !ok {
+				break
+			}
+			if idx == len(keys)-1 {
+				target.Fields[key] = value
+				break
+			}
+			if value.GetStructValue() == nil {
+				break
+			}
+			// Create the nested struct, unless it's already been created for another field.
+			nested := target.GetFields()[key].GetStructValue()
+			if nested == nil {
+				nested = &v1.Struct{Fields: map[string]*v1.Value{}}
+				target.Fields[key] = v1.NewStructValue(nested)
+			}
+			source, target = value.GetStructValue(), nested
+		}
+	}
+	return filtered
+}
+
+// MergeFields returns a new struct with the fields of the params, overridden by
+// the fields of the result. Nested structs are merged recursively, so that the
+// result of a plugin that received filtered fields doesn't drop the rest of them.
+func MergeFields(params, result *v1.Struct) *v1.Struct {
+	merged := &v1.Struct{Fields: make(map[string]*v1.Value, len(params.GetFields()))}
+	for key, value := range params.GetFields() {
+		merged.Fields[key] = value
+	}
+	for key, value := range result.GetFields() {
+		if original, ok := merged.Fields[key]; ok &&
+			original.GetStructValue() != nil && value.GetStructValue() != nil {
+			merged.Fields[key] = v1.NewStructValue(
+				MergeFields(original.GetStructValue(), value.GetStructValue()))
+			continue
+		}
+		merged.Fields[key] = value
+	}
+	return merged
+}
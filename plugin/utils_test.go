@@ -5,6 +5,7 @@ import (
 	"time"
 
 	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/sdk"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -92,6 +93,7 @@ func Test_CastToPrimitiveTypes(t *testing.T) {
 		"bool":     true,
 		"map":      map[string]interface{}{"test": "test"},
 		"duration": time.Duration(123),
+		"strings":  []string{"a", "b"},
 		"array": []interface{}{
 			"test",
 			123,
@@ -108,6 +110,7 @@ func Test_CastToPrimitiveTypes(t *testing.T) {
 		"bool":     true,
 		"map":      map[string]interface{}{"test": "test"},
 		"duration": "123ns", // time.Duration is casted to string.
+		"strings":  []interface{}{"a", "b"},
 		"array": []interface{}{
 			"test",
 			123,
@@ -122,3 +125,110 @@ func Test_CastToPrimitiveTypes(t *testing.T) {
 	casted := CastToPrimitiveTypes(actual)
 	assert.Equal(t, expected, casted)
 }
+
+// Test_FilterFields tests the FilterFields function.
+func Test_FilterFields(t *testing.T) {
+	params, err := v1.NewStruct(
+		map[string]interface{}{
+			"query":   "SELECT 1",
+			"request": []byte("payload"),
+			"client": map[string]interface{}{
+				"local":  "localhost:15432",
+				"remote": "localhost:45320",
+			},
+		},
+	)
+	assert.Nil(t, err)
+
+	filtered := FilterFields(params, []string{"query", "client.remote", "missing", "query.text"})
+	assert.Equal(t, map[string]interface{}{
+		"query": "SELECT 1",
+		"client": map[string]interface{}{
+			"remote": "localhost:45320",
+		},
+	}, filtered.AsMap())
+}
+
+// Test_MergeFields tests the MergeFields function.
+func Test_MergeFields(t *testing.T) {
+	params, err := v1.NewStruct(
+		map[string]interface{}{
+			"query": "SELECT 1",
+			"client": map[string]interface{}{
+				"local":  "localhost:15432",
+				"remote": "localhost:45320",
+			},
+		},
+	)
+	assert.Nil(t, err)
+
+	result, err := v1.NewStruct(
+		map[string]interface{}{
+			"query": "SELECT 2",
+			"client": map[string]interface{}{
+				"remote": "localhost:45321",
+			},
+		},
+	)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"query": "SELECT 2",
+		"client": map[string]interface{}{
+			"local":  "localhost:15432",
+			"remote": "localhost:45321",
+		},
+	}, MergeFields(params, result).AsMap())
+}
+
+// Test_ParseHookName tests that the hooks are parsed from the names of the enum and
+// their camel case.
+func Test_ParseHookName(t *testing.T) {
+	hookName, ok := ParseHookName("HOOK_NAME_ON_TRAFFIC_FROM_CLIENT")
+	assert.True(t, ok)
+	assert.Equal(t, v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, hookName)
+
+	hookName, ok = ParseHookName("onTrafficFromClient")
+	assert.True(t, ok)
+	assert.Equal(t, v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, hookName)
+
+	hookName, ok = ParseHookName("onScheduled")
+	assert.True(t, ok)
+	assert.Equal(t, sdk.OnScheduled, hookName)
+
+	hookName, ok = ParseHookName("onBackendChanged")
+	assert.True(t, ok)
+	assert.Equal(t, sdk.OnBackendChanged, hookName)
+
+	hookName, ok = ParseHookName("onCrossShard")
+	assert.True(t, ok)
+	assert.Equal(t, sdk.OnCrossShard, hookName)
+
+	hookName, ok = ParseHookName("onQueryTimeout")
+	assert.True(t, ok)
+	assert.Equal(t, sdk.OnQueryTimeout, hookName)
+
+	hookName, ok = ParseHookName("onBackendReconnect")
+	assert.True(t, ok)
+	assert.Equal(t, sdk.OnBackendReconnect, hookName)
+
+	hookName, ok = ParseHookName("onShuttingDown")
+	assert.True(t, ok)
+	assert.Equal(t, sdk.OnShuttingDown, hookName)
+
+	hookName, ok = ParseHookName("onShutdownComplete")
+	assert.True(t, ok)
+	assert.Equal(t, sdk.OnShutdownComplete, hookName)
+
+	_, ok = ParseHookName("HOOK_NAME_UNSPECIFIED")
+	assert.False(t, ok)
+	_, ok = ParseHookName("onSomething")
+	assert.False(t, ok)
+}
+
+func Test_HookNameString(t *testing.T) {
+	assert.Equal(t, "HOOK_NAME_ON_TRAFFIC_FROM_CLIENT", HookNameString(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT))
+	assert.Equal(t, "onScheduled", HookNameString(sdk.OnScheduled))
+	assert.Equal(t, "onValidate", HookNameString(sdk.OnValidate))
+	assert.Equal(t, "onTransactionEnd", HookNameString(sdk.OnTransactionEnd))
+}
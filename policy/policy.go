@@ -0,0 +1,89 @@
+// Package policy evaluates the Common Expression Language (CEL) expressions of the
+// declarative policies, e.g. `request.user == "admin" && request.db != "prod"`,
+// in-process.
+//
+// The expressions have the request variable, a map of the fields of the connection
+// and the query, and the standard CEL functions and macros, along with the string
+// extensions, e.g. lowerAscii, upperAscii and trim.
+package policy
+
+import (
+	"errors"
+
+	gerr "github.com/gatewayd-io/gatewayd/errors"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+)
+
+// Request is the name of the variable that holds the fields of the request.
+const Request = "request"
+
+var errNotBool = errors.New("the policy must evaluate to a bool")
+
+// env is the CEL environment the expressions are compiled in.
+var env, envErr = cel.NewEnv(
+	cel.Variable(Request, cel.MapType(cel.StringType, cel.DynType)),
+	ext.Strings(),
+)
+
+// Program is a compiled expression, which is safe for concurrent use.
+type Program struct {
+	expression string
+	program    cel.Program
+}
+
+// Compile parses and checks the expression.
+func Compile(expression string) (*Program, *gerr.GatewayDError) {
+	if envErr != nil {
+		return nil, gerr.ErrInvalidPolicy.Wrap(envErr)
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, gerr.ErrInvalidPolicy.Wrap(issues.Err())
+	}
+	// The constant regular expressions are compiled and checked once, here.
+	program, err := env.Program(ast, cel.EvalOptions(cel.OptOptimize))
+	if err != nil {
+		return nil, gerr.ErrInvalidPolicy.Wrap(err)
+	}
+	return &Program{expression: expression, program: program}, nil
+}
+
+// MustCompile parses the expression, and panics if it is invalid.
+func MustCompile(expression string) *Program {
+	program, err := Compile(expression)
+	if err != nil {
+		panic(err)
+	}
+	return program
+}
+
+// String returns the expression of the program.
+func (p *Program) String() string {
+	return p.expression
+}
+
+// Eval evaluates the expression with the variables, e.g. {"request": {...}}, and
+// returns its result as a Go value, e.g. an int64 for CEL ints.
+func (p *Program) Eval(vars map[string]any) (any, *gerr.GatewayDError) {
+	value, _, err := p.program.Eval(vars)
+	if err != nil {
+		return nil, gerr.ErrPolicyEvalFailed.Wrap(err)
+	}
+	return value.Value(), nil
+}
+
+// Matches evaluates the expression with the variables, which must result in a bool.
+func (p *Program) Matches(vars map[string]any) (bool, *gerr.GatewayDError) {
+	value, _, err := p.program.Eval(vars)
+	if err != nil {
+		return false, gerr.ErrPolicyEvalFailed.Wrap(err)
+	}
+	matches, ok := value.(types.Bool)
+	if !ok {
+		return false, gerr.ErrPolicyEvalFailed.Wrap(errNotBool)
+	}
+	return bool(matches), nil
+}
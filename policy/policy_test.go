@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgram_Eval(t *testing.T) {
+	vars := map[string]any{
+		"request": map[string]any{
+			"user":   "admin",
+			"db":     "staging",
+			"query":  "SELECT * FROM users WHERE id = 1",
+			"roles":  []string{"reader", "writer"},
+			"params": map[string]string{"application_name": "psql"},
+			"size":   42,
+		},
+	}
+
+	tests := []struct {
+		expression string
+		expected   any
+	}{
+		{`request.user == "admin" && request.db != "prod"`, true},
+		{`request.user == 'admin' && request.db == "prod"`, false},
+		{`request.user in ["admin", "root"]`, true},
+		{`"writer" in request.roles`, true},
+		{`"application_name" in request.params`, true},
+		{`request.params["application_name"] == "psql"`, true},
+		{`request.roles[1]`, "writer"},
+		{`request.query.startsWith("SELECT") && !request.query.contains("DELETE")`, true},
+		{`request.query.matches("(?i)^select\\s")`, true},
+		{`matches(request.query, "^DELETE")`, false},
+		{`request.query.lowerAscii().endsWith("= 1")`, true},
+		{`size(request.roles) + request.roles.size()`, int64(4)},
+		{`request.size * 2 > 80 ? "large" : "small"`, "large"},
+		{`double(request.size) / 4.0`, 10.5},
+		{`request.size % 5 == 2 && -request.size < 0`, true},
+		{`has(request.user) && !has(request.password)`, true},
+		{`request.password == "secret" || request.user == "admin"`, true},
+		{`int("7") + int(2.9)`, int64(9)},
+		{`string(request.size) + "b"`, "42b"},
+		{`request.size == 42.0 && [1, 2] == [1, 2] && null == null`, true},
+		{`(1 + 2) * 3 <= 9 && "a" < "b"`, true},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			program, err := Compile(test.expression)
+			require.Nil(t, err)
+			value, err := program.Eval(vars)
+			require.Nil(t, err)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+}
+
+func TestProgram_Errors(t *testing.T) {
+	for _, expression := range []string{
+		`request.user ==`,
+		`request.user == "admin`,
+		`(request.user == "admin"`,
+		`request.user # 1`,
+		`unknown(request.user)`,
+		`request.query.matches("(")`,
+		`size(1, 2)`,
+		`has(request)`,
+		`requests.user == "admin"`,
+		`request.user.size() > "a"`,
+	} {
+		_, err := Compile(expression)
+		assert.NotNil(t, err, expression)
+	}
+
+	vars := map[string]any{"request": map[string]any{"user": "admin", "size": 1}}
+	for _, expression := range []string{
+		`request.password == "secret"`,
+		`request.user + 1`,
+		`request.size / 0`,
+	} {
+		program, err := Compile(expression)
+		require.Nil(t, err, expression)
+		_, err = program.Eval(vars)
+		assert.NotNil(t, err, expression)
+	}
+
+	// The result of a policy must be a bool.
+	_, err := MustCompile(`request.user`).Matches(vars)
+	assert.NotNil(t, err)
+	matches, err := MustCompile(`request.user == "admin"`).Matches(vars)
+	assert.Nil(t, err)
+	assert.True(t, matches)
+}
@@ -0,0 +1,38 @@
+package recorder
+
+import (
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+)
+
+// LogWriter keeps the logs of the error level and above in the default recorder, so
+// that the errors are dumped with the other records. It writes nothing else.
+type LogWriter struct{}
+
+var _ zerolog.LevelWriter = LogWriter{}
+
+// Write ignores the logs without a level.
+func (LogWriter) Write(line []byte) (int, error) {
+	return len(line), nil
+}
+
+// WriteLevel keeps the log as an error record, with its fields but the level and time.
+func (LogWriter) WriteLevel(level zerolog.Level, line []byte) (int, error) {
+	if level < zerolog.ErrorLevel || level >= zerolog.NoLevel {
+		return len(line), nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(line, &fields); err != nil {
+		// The line isn't JSON, e.g. it is written by a console writer.
+		Add(Error, string(line), nil)
+		return len(line), nil
+	}
+	message, _ := fields[zerolog.MessageFieldName].(string)
+	delete(fields, zerolog.MessageFieldName)
+	delete(fields, zerolog.LevelFieldName)
+	delete(fields, zerolog.TimestampFieldName)
+	Add(Error, message, fields)
+	return len(line), nil
+}
@@ -0,0 +1,173 @@
+package recorder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/gatewayd-io/gatewayd/config"
+)
+
+type Kind string
+
+const (
+	Event Kind = "event"
+	Hook  Kind = "hook"
+	Error Kind = "error"
+	Panic Kind = "panic"
+)
+
+// Record is something that happened in GatewayD, kept by the flight recorder.
+type Record struct {
+	Time    time.Time              `json:"time"`
+	Kind    Kind                   `json:"kind"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Recorder is a flight recorder, which keeps the last records in memory, so that they
+// can be dumped to a file with the goroutines when GatewayD crashes or stalls.
+type Recorder struct {
+	mu      sync.Mutex
+	records []Record
+	next    int
+	full    bool
+	dumpDir string
+}
+
+// New creates a flight recorder of up to size records, which dumps them to the
+// directory, or to the temporary directory of the system if it is empty.
+func New(size int, dumpDir string) *Recorder {
+	return &Recorder{
+		records: make([]Record, config.If[int](size > 0, size, config.DefaultFlightRecorderSize)),
+		dumpDir: dumpDir,
+	}
+}
+
+// Record keeps the record, replacing the oldest one if the recorder is full.
+func (r *Recorder) Record(kind Kind, message string, fields map[string]interface{}) {
+	record := Record{Time: time.Now(), Kind: kind, Message: message, Fields: fields}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records[r.next] = record
+	r.next++
+	if r.next == len(r.records) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// Records returns the kept records, from the oldest to the newest.
+func (r *Recorder) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.ordered()
+}
+
+func (r *Recorder) ordered() []Record {
+	records := make([]Record, 0, len(r.records))
+	if r.full {
+		records = append(records, r.records[r.next:]...)
+	}
+	return append(records, r.records[:r.next]...)
+}
+
+// Configure resizes the recorder, keeping the most recent records that fit, and sets
+// the directory it dumps to.
+func (r *Recorder) Configure(size int, dumpDir string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	size = config.If[int](size > 0, size, config.DefaultFlightRecorderSize)
+	records := r.ordered()
+	if len(records) > size {
+		records = records[len(records)-size:]
+	}
+
+	r.records = make([]Record, size)
+	r.next = copy(r.records, records)
+	r.full = r.next == size
+	if r.full {
+		r.next = 0
+	}
+	r.dumpDir = dumpDir
+}
+
+// Dump writes the reason, the records as JSON lines and the stacks of the goroutines
+// to a new file in the dump directory, and returns its name.
+func (r *Recorder) Dump(reason string) (string, error) {
+	r.mu.Lock()
+	records := r.ordered()
+	dumpDir := config.If[string](r.dumpDir != "", r.dumpDir, os.TempDir())
+	r.mu.Unlock()
+
+	fileName := filepath.Join(dumpDir, fmt.Sprintf(
+		"gatewayd-%d-%s.flight", os.Getpid(), time.Now().UTC().Format("20060102T150405.000Z")))
+	//nolint:gomnd
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	defer file.Close()
+
+	fmt.Fprintf(file, "Reason: %s\n\nRecords:\n", reason)
+	encoder := json.NewEncoder(file)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return "", err //nolint:wrapcheck
+		}
+	}
+
+	fmt.Fprintln(file, "\nGoroutines:")
+	//nolint:gomnd
+	if err := pprof.Lookup("goroutine").WriteTo(file, 2); err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return fileName, nil
+}
+
+// defaultRecorder is always on, so that the records from before the config is loaded
+// are kept too.
+var defaultRecorder = New(config.DefaultFlightRecorderSize, "")
+
+// Default returns the recorder used by the package-level functions.
+func Default() *Recorder {
+	return defaultRecorder
+}
+
+// Configure resizes the default recorder and sets the directory it dumps to.
+func Configure(size int, dumpDir string) {
+	defaultRecorder.Configure(size, dumpDir)
+}
+
+// Add keeps the record in the default recorder.
+func Add(kind Kind, message string, fields map[string]interface{}) {
+	defaultRecorder.Record(kind, message, fields)
+}
+
+// Dump dumps the default recorder to a new file, and returns its name.
+func Dump(reason string) (string, error) {
+	return defaultRecorder.Dump(reason)
+}
+
+// DumpOnPanic dumps the default recorder if the goroutine panics, and panics again, so
+// that GatewayD still crashes. It must be deferred.
+func DumpOnPanic() {
+	if recovered := recover(); recovered != nil {
+		Add(Panic, fmt.Sprint(recovered), map[string]interface{}{"stack": string(debug.Stack())})
+		if fileName, err := Dump("panic"); err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to dump the flight recorder:", err)
+		} else {
+			fmt.Fprintln(os.Stderr, "Dumped the flight recorder to", fileName)
+		}
+		panic(recovered)
+	}
+}
@@ -0,0 +1,74 @@
+package recorder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecorder tests that the recorder keeps the last records, from the oldest to the
+// newest, and that resizing it keeps the most recent ones.
+func TestRecorder(t *testing.T) {
+	recorder := New(2, "")
+	assert.Empty(t, recorder.Records())
+
+	recorder.Record(Event, "first", nil)
+	recorder.Record(Hook, "second", nil)
+	recorder.Record(Error, "third", map[string]interface{}{"error": "failed"})
+	records := recorder.Records()
+	require.Len(t, records, 2)
+	assert.Equal(t, "second", records[0].Message)
+	assert.Equal(t, Error, records[1].Kind)
+	assert.Equal(t, "failed", records[1].Fields["error"])
+
+	recorder.Configure(1, "")
+	records = recorder.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, "third", records[0].Message)
+
+	recorder.Configure(3, "")
+	recorder.Record(Event, "fourth", nil)
+	records = recorder.Records()
+	require.Len(t, records, 2)
+	assert.Equal(t, "third", records[0].Message)
+	assert.Equal(t, "fourth", records[1].Message)
+}
+
+// TestDump tests that the reason, the records and the goroutines are dumped to a file.
+func TestDump(t *testing.T) {
+	dumpDir := t.TempDir()
+	recorder := New(0, dumpDir)
+	recorder.Record(Event, "Server started", map[string]interface{}{"type": "server.started"})
+
+	fileName, err := recorder.Dump("SIGQUIT")
+	require.NoError(t, err)
+	assert.Equal(t, dumpDir, filepath.Dir(fileName))
+	data, err := os.ReadFile(fileName)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "Reason: SIGQUIT\n\nRecords:\n"))
+	assert.Contains(t, string(data), `"message":"Server started"`)
+	assert.Contains(t, string(data), "Goroutines:")
+	assert.Contains(t, string(data), "TestDump")
+}
+
+// TestLogWriter tests that only the logs of the error level and above are recorded.
+func TestLogWriter(t *testing.T) {
+	previous := defaultRecorder
+	defaultRecorder = New(10, "")
+	defer func() { defaultRecorder = previous }()
+
+	logger := zerolog.New(zerolog.MultiLevelWriter(LogWriter{})).With().Timestamp().Logger()
+	logger.Info().Msg("Started")
+	logger.Error().Str("name", "default").Msg("Failed to connect")
+
+	records := Default().Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, Error, records[0].Kind)
+	assert.Equal(t, "Failed to connect", records[0].Message)
+	assert.Equal(t, map[string]interface{}{"name": "default"}, records[0].Fields)
+}
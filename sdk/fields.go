@@ -0,0 +1,147 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+)
+
+// The fields of the arguments of the traffic hooks.
+const (
+	FieldClient       = "client"
+	FieldServer       = "server"
+	FieldRequest      = "request"
+	FieldResponse     = "response"
+	FieldError        = "error"
+	FieldTerminate    = "terminate"
+	FieldConnectionID = "connectionId"
+	FieldQueryID      = "queryId"
+	FieldStream       = "stream"
+)
+
+// The fields of the arguments of the other hooks.
+const (
+	FieldName        = "name"
+	FieldSize        = "size"
+	FieldSignal      = "signal"
+	FieldAddress     = "address"
+	FieldStatus      = "status"
+	FieldConnections = "connections"
+	FieldConfig      = "config"
+)
+
+// The fields of the results of the hooks.
+const (
+	// FieldCache marks the result as cacheable, with the key fields and the TTL.
+	FieldCache    = "cache"
+	FieldCacheKey = "key"
+	FieldCacheTTL = "ttl"
+
+	// FieldErrors and FieldDependencies are the results of the onValidate hook.
+	FieldErrors       = "errors"
+	FieldDependencies = "dependencies"
+)
+
+// Address is the local and remote address of a connection.
+type Address struct {
+	Local  string
+	Remote string
+}
+
+// Client returns the address of the client connection to GatewayD.
+func Client(args *v1.Struct) Address {
+	return address(args, FieldClient)
+}
+
+// Server returns the address of the connection from GatewayD to the database.
+func Server(args *v1.Struct) Address {
+	return address(args, FieldServer)
+}
+
+func address(args *v1.Struct, field string) Address {
+	fields := args.GetFields()[field].GetStructValue().GetFields()
+	return Address{
+		Local:  fields["local"].GetStringValue(),
+		Remote: fields["remote"].GetStringValue(),
+	}
+}
+
+// Request returns the raw request sent by the client.
+func Request(args *v1.Struct) []byte {
+	return args.GetFields()[FieldRequest].GetBytesValue()
+}
+
+// Response returns the raw response sent by the database.
+func Response(args *v1.Struct) []byte {
+	return args.GetFields()[FieldResponse].GetBytesValue()
+}
+
+// Error returns the error that occurred while passing the traffic, if any.
+func Error(args *v1.Struct) string {
+	return args.GetFields()[FieldError].GetStringValue()
+}
+
+// ConnectionID returns the ID of the client connection.
+func ConnectionID(args *v1.Struct) string {
+	return args.GetFields()[FieldConnectionID].GetStringValue()
+}
+
+// QueryID returns the ID of the query the traffic belongs to.
+func QueryID(args *v1.Struct) string {
+	return args.GetFields()[FieldQueryID].GetStringValue()
+}
+
+// Query returns the text of the first query in the request, sent either with the
+// simple query protocol (Query) or the extended query protocol (Parse), or an empty
+// string if the request has no query.
+//
+//nolint:gomnd
+func Query(args *v1.Struct) string {
+	request := Request(args)
+	for offset := 0; offset+5 <= len(request); {
+		length := int(binary.BigEndian.Uint32(request[offset+1 : offset+5]))
+		if length < 4 || offset+1+length > len(request) {
+			break
+		}
+		body := request[offset+5 : offset+1+length]
+
+		switch request[offset] {
+		case 'Q':
+			return string(bytes.TrimSuffix(body, []byte{0}))
+		case 'P':
+			// The statement name comes before the query.
+			if parts := bytes.SplitN(body, []byte{0}, 3); len(parts) == 3 {
+				return string(parts[1])
+			}
+		}
+		offset += 1 + length
+	}
+	return ""
+}
+
+// StartupParameters returns the parameters of the startup message in the request, e.g.
+// the user and the database, or nil if the request isn't a startup message. It is the
+// first request of each connection.
+//
+//nolint:gomnd
+func StartupParameters(args *v1.Struct) map[string]string {
+	request := Request(args)
+	if len(request) < 8 || int(binary.BigEndian.Uint32(request[0:4])) != len(request) {
+		return nil
+	}
+	// Protocol version 3.0.
+	if binary.BigEndian.Uint32(request[4:8]) != 196608 {
+		return nil
+	}
+
+	parameters := map[string]string{}
+	fields := bytes.Split(request[8:], []byte{0})
+	for index := 0; index+1 < len(fields); index += 2 {
+		if len(fields[index]) == 0 {
+			break
+		}
+		parameters[string(fields[index])] = string(fields[index+1])
+	}
+	return parameters
+}
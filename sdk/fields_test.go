@@ -0,0 +1,69 @@
+package sdk
+
+import (
+	"testing"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTrafficArgs(t *testing.T, request []byte) *v1.Struct {
+	t.Helper()
+
+	args, err := v1.NewStruct(map[string]interface{}{
+		"client": map[string]interface{}{
+			"local":  "localhost:15432",
+			"remote": "localhost:45320",
+		},
+		"server": map[string]interface{}{
+			"local":  "localhost:54321",
+			"remote": "localhost:5432",
+		},
+		"request":      request,
+		"error":        "",
+		"connectionId": "connection",
+		"queryId":      "query",
+	})
+	require.NoError(t, err)
+	return args
+}
+
+// TestFields tests the accessors of the fields of the traffic hooks.
+func TestFields(t *testing.T) {
+	request := []byte("Q\x00\x00\x00\x0dSELECT 1;\x00")
+	args := newTrafficArgs(t, request)
+
+	assert.Equal(t, Address{Local: "localhost:15432", Remote: "localhost:45320"}, Client(args))
+	assert.Equal(t, Address{Local: "localhost:54321", Remote: "localhost:5432"}, Server(args))
+	assert.Equal(t, request, Request(args))
+	assert.Nil(t, Response(args))
+	assert.Empty(t, Error(args))
+	assert.Equal(t, "connection", ConnectionID(args))
+	assert.Equal(t, "query", QueryID(args))
+	assert.Equal(t, "SELECT 1;", Query(args))
+
+	// Missing fields are empty.
+	assert.Equal(t, Address{}, Client(&v1.Struct{}))
+	assert.Empty(t, Query(&v1.Struct{}))
+}
+
+// TestQuery_Parse tests that the query is extracted from the extended query protocol.
+func TestQuery_Parse(t *testing.T) {
+	parse := []byte("P\x00\x00\x00\x16stmt\x00SELECT $1;\x00\x00\x00")
+	sync := []byte{'S', 0, 0, 0, 4}
+	args := newTrafficArgs(t, append(append([]byte{}, sync...), parse...))
+	assert.Equal(t, "SELECT $1;", Query(args))
+
+	// Incomplete messages are ignored.
+	assert.Empty(t, Query(newTrafficArgs(t, parse[:10])))
+}
+
+func TestStartupParameters(t *testing.T) {
+	startup := []byte("\x00\x00\x00\x00\x00\x03\x00\x00user\x00postgres\x00database\x00shop\x00\x00")
+	startup[3] = byte(len(startup))
+	assert.Equal(t, map[string]string{"user": "postgres", "database": "shop"},
+		StartupParameters(newTrafficArgs(t, startup)))
+
+	assert.Nil(t, StartupParameters(newTrafficArgs(t, []byte("Q\x00\x00\x00\x0dSELECT 1;\x00"))))
+}
@@ -0,0 +1,126 @@
+// Package sdk helps plugin authors read the arguments of the hooks and return results
+// that GatewayD accepts. It only depends on the plugin SDK, so plugins can import it
+// without pulling in the rest of GatewayD.
+package sdk
+
+import (
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+)
+
+// The hooks that plugins can register for, in the order they run in the lifecycle
+// of GatewayD and its connections.
+const (
+	OnConfigLoaded      = v1.HookName_HOOK_NAME_ON_CONFIG_LOADED
+	OnNewLogger         = v1.HookName_HOOK_NAME_ON_NEW_LOGGER
+	OnNewPool           = v1.HookName_HOOK_NAME_ON_NEW_POOL
+	OnNewClient         = v1.HookName_HOOK_NAME_ON_NEW_CLIENT
+	OnNewProxy          = v1.HookName_HOOK_NAME_ON_NEW_PROXY
+	OnNewServer         = v1.HookName_HOOK_NAME_ON_NEW_SERVER
+	OnSignal            = v1.HookName_HOOK_NAME_ON_SIGNAL
+	OnRun               = v1.HookName_HOOK_NAME_ON_RUN
+	OnBooting           = v1.HookName_HOOK_NAME_ON_BOOTING
+	OnBooted            = v1.HookName_HOOK_NAME_ON_BOOTED
+	OnOpening           = v1.HookName_HOOK_NAME_ON_OPENING
+	OnOpened            = v1.HookName_HOOK_NAME_ON_OPENED
+	OnClosing           = v1.HookName_HOOK_NAME_ON_CLOSING
+	OnClosed            = v1.HookName_HOOK_NAME_ON_CLOSED
+	OnTraffic           = v1.HookName_HOOK_NAME_ON_TRAFFIC
+	OnTrafficFromClient = v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT
+	OnTrafficToServer   = v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_SERVER
+	OnTrafficFromServer = v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_SERVER
+	OnTrafficToClient   = v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_CLIENT
+	OnShutdown          = v1.HookName_HOOK_NAME_ON_SHUTDOWN
+	OnTick              = v1.HookName_HOOK_NAME_ON_TICK
+	OnHook              = v1.HookName_HOOK_NAME_ON_HOOK
+)
+
+// OnScheduled is the custom hook that runs the scheduled jobs a plugin declares in the
+// "schedules" field of its metadata. It is delivered to the OnHook method of the plugin,
+// with the name of the job in the "job" field of the arguments.
+const OnScheduled v1.HookName = 1000
+
+// OnMetric is the custom hook that receives the samples of the metrics of GatewayD, with
+// the "name", "type", "value" and "labels" of the sample in the arguments. The plugin can
+// return extra "labels" to record the sample with, and derived "metrics", each with a
+// "name", "type", "value", "labels" and "help". It is delivered to the OnHook method of
+// the plugin in the background, so it adds no latency to the traffic.
+const OnMetric v1.HookName = 1001
+
+// OnBackendChanged is the custom hook that is notified when the IP addresses of the
+// hostname of a backend change, with the "network", "address", "previous" and "current"
+// addresses in the arguments. It is delivered to the OnHook method of the plugin, and
+// its result is ignored.
+const OnBackendChanged v1.HookName = 1002
+
+// OnCrossShard is the custom hook that handles the statements the sharding router can't
+// route to a single shard, with the "request", the "keys" and the "shards", each with
+// a "name" and an "address", in the arguments. A plugin that runs the statement on the
+// shards and merges their results, i.e. scatter-gather, returns the "response" to send
+// to the client; otherwise the statement is rejected. It is delivered to the OnHook
+// method of the plugin.
+const OnCrossShard v1.HookName = 1003
+
+// OnQueryTimeout is the custom hook that is notified when a query exceeds the statement
+// timeout of the proxy and is cancelled, with the "connectionId", "queryId", "user",
+// "database", "request", the "rule" and its "timeout", and whether the cancel request
+// was sent, "cancelled", in the arguments. It is delivered to the OnHook method of the
+// plugin, and its result is ignored.
+const OnQueryTimeout v1.HookName = 1004
+
+// OnBackendReconnect is the custom hook that is notified when a connection of the pool
+// is reconnected to the database, e.g. when it is recycled, with the "network",
+// "address", "reason", "duration", whether it "reconnected" and the "error" if not, in
+// the arguments. It is delivered to the OnHook method of the plugin, and its result is
+// ignored.
+const OnBackendReconnect v1.HookName = 1005
+
+// OnShuttingDown is the custom hook that is notified as soon as GatewayD starts shutting
+// down, before the connections are drained, so that the plugins can flush their caches
+// or deregister from service discovery, with the "signal" and the "deadline" of the hook
+// (RFC 3339) in the arguments. It is delivered to the OnHook method of the plugin, and
+// its result is ignored. The OnShutdown hooks run after the connections are drained.
+const OnShuttingDown v1.HookName = 1006
+
+// OnShutdownComplete is the custom hook that is notified once the servers are stopped,
+// right before the plugin processes are stopped, with the "deadline" of the hook
+// (RFC 3339) in the arguments. The plugins acknowledge that they are done by returning,
+// and the ones that don't return before the deadline are stopped anyway. It is delivered
+// to the OnHook method of the plugin, and its result is ignored.
+const OnShutdownComplete v1.HookName = 1007
+
+// OnValidate is the custom hook that validates the config of the plugin before GatewayD
+// listens, with the "config" of the plugin in the arguments. The plugin returns the
+// "errors" of its config, and the "dependencies" it needs, each with a "name", whether
+// it is "ok", and the "error" if not, e.g. that Redis is reachable or that its license
+// is valid. It is delivered to the OnHook method of the plugin, and the preflight
+// policy decides what to do with the plugins that fail.
+const OnValidate v1.HookName = 1008
+
+// OnTransactionStart is the custom hook that is notified when a session starts a
+// transaction, with the "connectionId", "queryId", "user", "database" and when it
+// started, "startedAt" (RFC 3339), in the arguments. It is delivered to the OnHook
+// method of the plugin, and its result is ignored.
+const OnTransactionStart v1.HookName = 1009
+
+// OnTransactionEnd is the custom hook that is notified when the transaction of a
+// session ends, with the "connectionId", "queryId", "user", "database", "startedAt",
+// the "duration" of the transaction, the number of its "statements" and its "outcome",
+// either "commit", "rollback" or "error" if it failed, in the arguments. It is
+// delivered to the OnHook method of the plugin, and its result is ignored.
+const OnTransactionEnd v1.HookName = 1010
+
+// The outcomes of the transactions in the arguments of the OnTransactionEnd hooks.
+const (
+	TransactionCommit   = "commit"
+	TransactionRollback = "rollback"
+	TransactionError    = "error"
+)
+
+// TrafficHooks are the hooks that run on every query and its response.
+var TrafficHooks = []v1.HookName{
+	OnTraffic,
+	OnTrafficFromClient,
+	OnTrafficToServer,
+	OnTrafficFromServer,
+	OnTrafficToClient,
+}
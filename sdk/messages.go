@@ -0,0 +1,404 @@
+package sdk
+
+import (
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+)
+
+// Message is the typed form of the arguments and results of a hook. The hooks are
+// still called with a v1.Struct, since the plugin service is defined in the plugin SDK,
+// so the messages are converted from and to it at the edges of the plugin, e.g.:
+//
+//	func (p *Plugin) OnTrafficFromClient(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+//		msg := sdk.NewTrafficMessage(req)
+//		...
+//		return msg.Struct(req), nil
+//	}
+//
+// GatewayD builds the arguments of the hooks from the same messages, with Struct(nil),
+// so that they are converted to the v1.Struct of the hooks only once.
+type Message interface {
+	// Map returns the fields of the message as the arguments of the hook.
+	Map() map[string]interface{}
+	// Struct converts the message back to the v1.Struct that the hooks return. The
+	// fields of the message are set on a copy of the arguments, so that the fields the
+	// message doesn't know about are passed down as is.
+	Struct(args *v1.Struct) *v1.Struct
+}
+
+var (
+	_ Message = (*TrafficMessage)(nil)
+	_ Message = (*ConfigMessage)(nil)
+	_ Message = (*PoolMessage)(nil)
+	_ Message = (*SignalMessage)(nil)
+	_ Message = (*RunMessage)(nil)
+	_ Message = (*StatusMessage)(nil)
+	_ Message = (*ConnectionMessage)(nil)
+	_ Message = (*ConnectionsMessage)(nil)
+)
+
+// NewMessage reads the typed message of the hook from its arguments. It returns nil
+// for the hooks that have no typed message, such as the custom hooks.
+func NewMessage(hookName v1.HookName, args *v1.Struct) Message {
+	switch hookName {
+	case OnConfigLoaded, OnNewLogger, OnNewClient, OnNewProxy, OnNewServer:
+		return NewConfigMessage(args)
+	case OnNewPool:
+		return NewPoolMessage(args)
+	case OnSignal:
+		return NewSignalMessage(args)
+	case OnRun:
+		return NewRunMessage(args)
+	case OnBooting, OnBooted:
+		return NewStatusMessage(args)
+	case OnOpening, OnOpened, OnClosing, OnClosed:
+		return NewConnectionMessage(args)
+	case OnShutdown, OnTick:
+		return NewConnectionsMessage(args)
+	case OnTraffic, OnTrafficFromClient, OnTrafficToServer, OnTrafficFromServer, OnTrafficToClient:
+		return NewTrafficMessage(args)
+	default:
+		return nil
+	}
+}
+
+// TrafficMessage is the message of the traffic hooks. The stream is only set for the
+// responses streamed to the client, whose head is the response.
+type TrafficMessage struct {
+	Client       Address
+	Server       Address
+	Request      []byte
+	Response     []byte
+	Error        string
+	ConnectionID string
+	QueryID      string
+	Stream       *Stream
+	Terminate    bool
+}
+
+// Stream is the summary of a response streamed to the client.
+type Stream struct {
+	Size     int
+	Chunks   int
+	Complete bool
+}
+
+// NewTrafficMessage reads the typed message from the arguments of a traffic hook.
+func NewTrafficMessage(args *v1.Struct) *TrafficMessage {
+	msg := &TrafficMessage{
+		Client:       Client(args),
+		Server:       Server(args),
+		Request:      Request(args),
+		Response:     Response(args),
+		Error:        Error(args),
+		ConnectionID: ConnectionID(args),
+		QueryID:      QueryID(args),
+		Terminate:    args.GetFields()[FieldTerminate].GetBoolValue(),
+	}
+	if stream := args.GetFields()[FieldStream].GetStructValue(); stream != nil {
+		msg.Stream = &Stream{
+			Size:     int(stream.GetFields()["size"].GetNumberValue()),
+			Chunks:   int(stream.GetFields()["chunks"].GetNumberValue()),
+			Complete: stream.GetFields()["complete"].GetBoolValue(),
+		}
+	}
+	return msg
+}
+
+// Query returns the text of the first query in the request.
+func (m *TrafficMessage) Query() string {
+	return Query(&v1.Struct{
+		Fields: map[string]*v1.Value{FieldRequest: v1.NewBytesValue(m.Request)},
+	})
+}
+
+// Map returns the fields of the message as the arguments of the traffic hooks. Empty
+// fields are left out, except for the addresses and the error, which the traffic hooks
+// always get.
+func (m *TrafficMessage) Map() map[string]interface{} {
+	fields := map[string]interface{}{
+		FieldClient: addressMap(m.Client),
+		FieldServer: addressMap(m.Server),
+		FieldError:  m.Error,
+	}
+	if m.Request != nil {
+		fields[FieldRequest] = m.Request
+	}
+	if m.Response != nil {
+		fields[FieldResponse] = m.Response
+	}
+	if m.ConnectionID != "" {
+		fields[FieldConnectionID] = m.ConnectionID
+	}
+	if m.QueryID != "" {
+		fields[FieldQueryID] = m.QueryID
+	}
+	if m.Stream != nil {
+		fields[FieldStream] = map[string]interface{}{
+			"size":     m.Stream.Size,
+			"chunks":   m.Stream.Chunks,
+			"complete": m.Stream.Complete,
+		}
+	}
+	if m.Terminate {
+		fields[FieldTerminate] = true
+	}
+	return fields
+}
+
+// Struct converts the message back to the v1.Struct that the hooks return. An
+// unmodified message passes verification. Empty fields that aren't in the arguments
+// are left out, except for the addresses and the error, like in Map. GatewayD builds the arguments of
+// the traffic hooks with Struct(nil), without going through a map.
+func (m *TrafficMessage) Struct(args *v1.Struct) *v1.Struct {
+	result := copyStruct(args)
+
+	set := func(field string, value *v1.Value, empty bool) {
+		if _, ok := result.Fields[field]; ok || !empty {
+			result.Fields[field] = value
+		}
+	}
+	set(FieldClient, addressValue(m.Client), false)
+	set(FieldServer, addressValue(m.Server), false)
+	set(FieldRequest, v1.NewBytesValue(m.Request), m.Request == nil)
+	set(FieldResponse, v1.NewBytesValue(m.Response), m.Response == nil)
+	set(FieldError, v1.NewStringValue(m.Error), false)
+	set(FieldConnectionID, v1.NewStringValue(m.ConnectionID), m.ConnectionID == "")
+	set(FieldQueryID, v1.NewStringValue(m.QueryID), m.QueryID == "")
+	if m.Stream != nil {
+		set(FieldStream, v1.NewStructValue(&v1.Struct{
+			Fields: map[string]*v1.Value{
+				"size":     v1.NewNumberValue(float64(m.Stream.Size)),
+				"chunks":   v1.NewNumberValue(float64(m.Stream.Chunks)),
+				"complete": v1.NewBoolValue(m.Stream.Complete),
+			},
+		}), false)
+	}
+	set(FieldTerminate, v1.NewBoolValue(m.Terminate), !m.Terminate)
+
+	return result
+}
+
+// ConfigMessage is the message of the OnConfigLoaded, OnNewLogger, OnNewClient,
+// OnNewProxy and OnNewServer hooks, whose arguments are the config of the object.
+type ConfigMessage struct {
+	Config map[string]interface{}
+}
+
+// NewConfigMessage reads the typed message from the arguments of a config hook.
+func NewConfigMessage(args *v1.Struct) *ConfigMessage {
+	return &ConfigMessage{Config: args.AsMap()}
+}
+
+// Map returns the config as the arguments of the hook.
+func (m *ConfigMessage) Map() map[string]interface{} {
+	return m.Config
+}
+
+// Struct converts the message back to the v1.Struct that the hooks return.
+func (m *ConfigMessage) Struct(args *v1.Struct) *v1.Struct {
+	return mergeStruct(args, m.Map())
+}
+
+// PoolMessage is the message of the OnNewPool hook.
+type PoolMessage struct {
+	Name string
+	Size int
+}
+
+// NewPoolMessage reads the typed message from the arguments of the OnNewPool hook.
+func NewPoolMessage(args *v1.Struct) *PoolMessage {
+	return &PoolMessage{
+		Name: args.GetFields()[FieldName].GetStringValue(),
+		Size: int(args.GetFields()[FieldSize].GetNumberValue()),
+	}
+}
+
+// Map returns the fields of the message as the arguments of the hook.
+func (m *PoolMessage) Map() map[string]interface{} {
+	return map[string]interface{}{FieldName: m.Name, FieldSize: m.Size}
+}
+
+// Struct converts the message back to the v1.Struct that the hooks return.
+func (m *PoolMessage) Struct(args *v1.Struct) *v1.Struct {
+	return setStruct(args, map[string]*v1.Value{
+		FieldName: v1.NewStringValue(m.Name),
+		FieldSize: v1.NewNumberValue(float64(m.Size)),
+	})
+}
+
+// SignalMessage is the message of the OnSignal hook.
+type SignalMessage struct {
+	Signal string
+}
+
+// NewSignalMessage reads the typed message from the arguments of the OnSignal hook.
+func NewSignalMessage(args *v1.Struct) *SignalMessage {
+	return &SignalMessage{Signal: args.GetFields()[FieldSignal].GetStringValue()}
+}
+
+// Map returns the fields of the message as the arguments of the hook.
+func (m *SignalMessage) Map() map[string]interface{} {
+	return map[string]interface{}{FieldSignal: m.Signal}
+}
+
+// Struct converts the message back to the v1.Struct that the hooks return.
+func (m *SignalMessage) Struct(args *v1.Struct) *v1.Struct {
+	return setStruct(args, map[string]*v1.Value{FieldSignal: v1.NewStringValue(m.Signal)})
+}
+
+// RunMessage is the message of the OnRun hook. The hooks can change the address
+// the server listens on.
+type RunMessage struct {
+	Address string
+	Error   string
+}
+
+// NewRunMessage reads the typed message from the arguments of the OnRun hook.
+func NewRunMessage(args *v1.Struct) *RunMessage {
+	return &RunMessage{
+		Address: args.GetFields()[FieldAddress].GetStringValue(),
+		Error:   Error(args),
+	}
+}
+
+// Map returns the fields of the message as the arguments of the hook. The error is
+// left out if it is empty.
+func (m *RunMessage) Map() map[string]interface{} {
+	fields := map[string]interface{}{FieldAddress: m.Address}
+	if m.Error != "" {
+		fields[FieldError] = m.Error
+	}
+	return fields
+}
+
+// Struct converts the message back to the v1.Struct that the hooks return.
+func (m *RunMessage) Struct(args *v1.Struct) *v1.Struct {
+	fields := map[string]*v1.Value{FieldAddress: v1.NewStringValue(m.Address)}
+	if m.Error != "" {
+		fields[FieldError] = v1.NewStringValue(m.Error)
+	}
+	return setStruct(args, fields)
+}
+
+// StatusMessage is the message of the OnBooting and OnBooted hooks.
+type StatusMessage struct {
+	Status string
+}
+
+// NewStatusMessage reads the typed message from the arguments of a boot hook.
+func NewStatusMessage(args *v1.Struct) *StatusMessage {
+	return &StatusMessage{Status: args.GetFields()[FieldStatus].GetStringValue()}
+}
+
+// Map returns the fields of the message as the arguments of the hook.
+func (m *StatusMessage) Map() map[string]interface{} {
+	return map[string]interface{}{FieldStatus: m.Status}
+}
+
+// Struct converts the message back to the v1.Struct that the hooks return.
+func (m *StatusMessage) Struct(args *v1.Struct) *v1.Struct {
+	return setStruct(args, map[string]*v1.Value{FieldStatus: v1.NewStringValue(m.Status)})
+}
+
+// ConnectionMessage is the message of the OnOpening, OnOpened, OnClosing and OnClosed
+// hooks. The error is only set when closing the connection.
+type ConnectionMessage struct {
+	Client       Address
+	ConnectionID string
+	Error        string
+}
+
+// NewConnectionMessage reads the typed message from the arguments of a connection hook.
+func NewConnectionMessage(args *v1.Struct) *ConnectionMessage {
+	return &ConnectionMessage{
+		Client:       Client(args),
+		ConnectionID: ConnectionID(args),
+		Error:        Error(args),
+	}
+}
+
+// Map returns the fields of the message as the arguments of the hook.
+func (m *ConnectionMessage) Map() map[string]interface{} {
+	return map[string]interface{}{
+		FieldClient:       addressMap(m.Client),
+		FieldConnectionID: m.ConnectionID,
+		FieldError:        m.Error,
+	}
+}
+
+// Struct converts the message back to the v1.Struct that the hooks return.
+func (m *ConnectionMessage) Struct(args *v1.Struct) *v1.Struct {
+	return setStruct(args, map[string]*v1.Value{
+		FieldClient:       addressValue(m.Client),
+		FieldConnectionID: v1.NewStringValue(m.ConnectionID),
+		FieldError:        v1.NewStringValue(m.Error),
+	})
+}
+
+// ConnectionsMessage is the message of the OnTick and OnShutdown hooks.
+type ConnectionsMessage struct {
+	Connections int
+}
+
+// NewConnectionsMessage reads the typed message from the arguments of the OnTick and
+// OnShutdown hooks.
+func NewConnectionsMessage(args *v1.Struct) *ConnectionsMessage {
+	return &ConnectionsMessage{
+		Connections: int(args.GetFields()[FieldConnections].GetNumberValue()),
+	}
+}
+
+// Map returns the fields of the message as the arguments of the hook.
+func (m *ConnectionsMessage) Map() map[string]interface{} {
+	return map[string]interface{}{FieldConnections: m.Connections}
+}
+
+// Struct converts the message back to the v1.Struct that the hooks return.
+func (m *ConnectionsMessage) Struct(args *v1.Struct) *v1.Struct {
+	return setStruct(args, map[string]*v1.Value{
+		FieldConnections: v1.NewNumberValue(float64(m.Connections)),
+	})
+}
+
+// copyStruct returns a shallow copy of the arguments.
+func copyStruct(args *v1.Struct) *v1.Struct {
+	result := &v1.Struct{Fields: make(map[string]*v1.Value, len(args.GetFields()))}
+	for key, value := range args.GetFields() {
+		result.Fields[key] = value
+	}
+	return result
+}
+
+// setStruct sets the values on a copy of the arguments.
+func setStruct(args *v1.Struct, values map[string]*v1.Value) *v1.Struct {
+	result := copyStruct(args)
+	for key, value := range values {
+		result.Fields[key] = value
+	}
+	return result
+}
+
+// mergeStruct sets the fields on a copy of the arguments. The fields that can't be
+// converted, e.g. invalid UTF-8 strings, are left as they are in the arguments.
+func mergeStruct(args *v1.Struct, fields map[string]interface{}) *v1.Struct {
+	result := copyStruct(args)
+	for key, field := range fields {
+		if value, err := v1.NewValue(field); err == nil {
+			result.Fields[key] = value
+		}
+	}
+	return result
+}
+
+func addressMap(address Address) map[string]interface{} {
+	return map[string]interface{}{"local": address.Local, "remote": address.Remote}
+}
+
+func addressValue(address Address) *v1.Value {
+	return v1.NewStructValue(&v1.Struct{
+		Fields: map[string]*v1.Value{
+			"local":  v1.NewStringValue(address.Local),
+			"remote": v1.NewStringValue(address.Remote),
+		},
+	})
+}
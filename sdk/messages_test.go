@@ -0,0 +1,72 @@
+package sdk
+
+import (
+	"testing"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTrafficMessage tests that the typed message is converted from and to the
+// arguments of the traffic hooks.
+func TestTrafficMessage(t *testing.T) {
+	args := newTrafficArgs(t, []byte("Q\x00\x00\x00\x0dSELECT 1;\x00"))
+	args.Fields["custom"] = v1.NewStringValue("custom")
+
+	msg := NewTrafficMessage(args)
+	assert.Equal(t, "localhost:45320", msg.Client.Remote)
+	assert.Equal(t, "connection", msg.ConnectionID)
+	assert.Equal(t, "SELECT 1;", msg.Query())
+
+	// An unmodified message passes verification, and keeps the unknown fields.
+	assert.True(t, Verify(args, msg.Struct(args)))
+
+	msg.Response = []byte("cached")
+	msg.Terminate = true
+	result := msg.Struct(args)
+	assert.False(t, Verify(args, result))
+	assert.Equal(t, []byte("cached"), Response(result))
+	assert.True(t, result.GetFields()[FieldTerminate].GetBoolValue())
+	assert.Equal(t, "custom", result.GetFields()["custom"].GetStringValue())
+}
+
+// TestNewMessage tests that the arguments GatewayD builds from the typed messages
+// are read back as the same messages.
+func TestNewMessage(t *testing.T) {
+	for hookName, msg := range map[v1.HookName]Message{
+		OnNewPool:   &PoolMessage{Name: "default", Size: 10},
+		OnSignal:    &SignalMessage{Signal: "interrupt"},
+		OnRun:       &RunMessage{Address: "0.0.0.0:15432"},
+		OnBooted:    &StatusMessage{Status: "0"},
+		OnShutdown:  &ConnectionsMessage{Connections: 3},
+		OnNewServer: &ConfigMessage{Config: map[string]interface{}{"address": "0.0.0.0:15432"}},
+		OnClosed: &ConnectionMessage{
+			Client:       Address{Local: "localhost:15432", Remote: "localhost:45320"},
+			ConnectionID: "connection",
+			Error:        "EOF",
+		},
+		OnTrafficFromClient: &TrafficMessage{Request: []byte("request"), QueryID: "query"},
+		OnTrafficToClient: &TrafficMessage{
+			Response: []byte("response"),
+			Stream:   &Stream{Size: 1 << 20, Chunks: 16, Complete: true},
+		},
+	} {
+		args, err := v1.NewStruct(msg.Map())
+		require.NoError(t, err)
+		assert.Equal(t, msg, NewMessage(hookName, args), hookName.String())
+		assert.True(t, Verify(args, msg.Struct(args)), hookName.String())
+		// GatewayD builds the same arguments without the map.
+		assert.True(t, Verify(args, msg.Struct(nil)), hookName.String())
+	}
+
+	// The custom hooks have no typed message.
+	assert.Nil(t, NewMessage(OnMetric, &v1.Struct{}))
+
+	// The unknown fields are kept.
+	args, err := v1.NewStruct(map[string]interface{}{"signal": "interrupt", "custom": "custom"})
+	require.NoError(t, err)
+	result := (&SignalMessage{Signal: "hangup"}).Struct(args)
+	assert.Equal(t, "hangup", result.GetFields()[FieldSignal].GetStringValue())
+	assert.Equal(t, "custom", result.GetFields()["custom"].GetStringValue())
+}
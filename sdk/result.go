@@ -0,0 +1,129 @@
+package sdk
+
+import (
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// Verify returns true if the result of a hook has the same fields and values as
+// its arguments. Unless the verification policy is passdown, GatewayD ignores the
+// results that don't pass this check, so plugins can use it to test their results.
+func Verify(params, result *v1.Struct) bool {
+	return cmp.Equal(params.AsMap(), result.AsMap(), cmp.Options{
+		cmpopts.SortMaps(func(a, b string) bool {
+			return a < b
+		}),
+		cmpopts.EquateEmpty(),
+	})
+}
+
+// Passthrough returns the arguments as the result, which leaves the traffic as is
+// and always passes verification.
+func Passthrough(params *v1.Struct) *v1.Struct {
+	return params
+}
+
+// With returns a copy of the arguments with the given field set to the value,
+// leaving the arguments as is. Results that change the arguments only pass
+// verification with the passdown policy.
+func With(params *v1.Struct, field string, value *v1.Value) *v1.Struct {
+	result := &v1.Struct{Fields: make(map[string]*v1.Value, len(params.GetFields())+1)}
+	for key, val := range params.GetFields() {
+		result.Fields[key] = val
+	}
+	result.Fields[field] = value
+	return result
+}
+
+// WithRequest returns a copy of the arguments with the request replaced, which
+// GatewayD sends to the database instead of the original request.
+func WithRequest(params *v1.Struct, request []byte) *v1.Struct {
+	return With(params, FieldRequest, v1.NewBytesValue(request))
+}
+
+// WithResponse returns a copy of the arguments with the response replaced, which
+// GatewayD sends to the client instead of the original response.
+func WithResponse(params *v1.Struct, response []byte) *v1.Struct {
+	return With(params, FieldResponse, v1.NewBytesValue(response))
+}
+
+// WithError returns a copy of the arguments with the error set, which GatewayD logs
+// instead of using the modified request or response.
+func WithError(params *v1.Struct, err error) *v1.Struct {
+	return With(params, FieldError, v1.NewStringValue(err.Error()))
+}
+
+// Terminate returns a copy of the arguments that stops GatewayD from sending the
+// request to the database, and sends the response to the client instead, e.g. a
+// cached result or an error message.
+func Terminate(params *v1.Struct, response []byte) *v1.Struct {
+	result := WithResponse(params, response)
+	result.Fields[FieldTerminate] = v1.NewBoolValue(true)
+	return result
+}
+
+// Cacheable returns a copy of the result that GatewayD caches for the TTL, and reuses
+// instead of calling the plugin for the arguments with the same values of the key
+// fields, e.g. "client.remote". Nested fields are separated by dots. Only the changes
+// of the result to the arguments are reused, so it suits the decisions that only
+// depend on the key fields, e.g. authentication or routing per user and database.
+func Cacheable(result *v1.Struct, key []string, ttl time.Duration) *v1.Struct {
+	fields := make([]*v1.Value, 0, len(key))
+	for _, field := range key {
+		fields = append(fields, v1.NewStringValue(field))
+	}
+	return With(result, FieldCache, v1.NewStructValue(&v1.Struct{Fields: map[string]*v1.Value{
+		FieldCacheKey: v1.NewListValue(&v1.ListValue{Values: fields}),
+		FieldCacheTTL: v1.NewStringValue(ttl.String()),
+	}}))
+}
+
+// Dependency is an external dependency of a plugin, e.g. a Redis server or a license,
+// which the plugin checks in its onValidate hook.
+type Dependency struct {
+	Name  string
+	OK    bool
+	Error string
+}
+
+// Validation returns the result of the onValidate hook, with the errors of the config
+// of the plugin and the status of its dependencies. The plugin fails the preflight
+// checks if there are errors or unavailable dependencies.
+func Validation(errors []string, dependencies []Dependency) *v1.Struct {
+	errorValues := make([]*v1.Value, 0, len(errors))
+	for _, err := range errors {
+		errorValues = append(errorValues, v1.NewStringValue(err))
+	}
+	dependencyValues := make([]*v1.Value, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		dependencyValues = append(dependencyValues, v1.NewStructValue(&v1.Struct{
+			Fields: map[string]*v1.Value{
+				"name":  v1.NewStringValue(dependency.Name),
+				"ok":    v1.NewBoolValue(dependency.OK),
+				"error": v1.NewStringValue(dependency.Error),
+			},
+		}))
+	}
+	return &v1.Struct{Fields: map[string]*v1.Value{
+		FieldErrors:       v1.NewListValue(&v1.ListValue{Values: errorValues}),
+		FieldDependencies: v1.NewListValue(&v1.ListValue{Values: dependencyValues}),
+	}}
+}
+
+// Dependencies returns the dependencies in the result of the onValidate hook.
+func Dependencies(result *v1.Struct) []Dependency {
+	values := result.GetFields()[FieldDependencies].GetListValue().GetValues()
+	dependencies := make([]Dependency, 0, len(values))
+	for _, value := range values {
+		fields := value.GetStructValue().GetFields()
+		dependencies = append(dependencies, Dependency{
+			Name:  fields["name"].GetStringValue(),
+			OK:    fields["ok"].GetBoolValue(),
+			Error: fields["error"].GetStringValue(),
+		})
+	}
+	return dependencies
+}
@@ -0,0 +1,47 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResults tests that the results are built from a copy of the arguments.
+func TestResults(t *testing.T) {
+	args := newTrafficArgs(t, []byte("request"))
+
+	assert.True(t, Verify(args, Passthrough(args)))
+
+	modified := WithRequest(args, []byte("modified"))
+	assert.Equal(t, []byte("modified"), Request(modified))
+	assert.Equal(t, []byte("request"), Request(args))
+	assert.False(t, Verify(args, modified))
+
+	assert.Equal(t, []byte("response"), Response(WithResponse(args, []byte("response"))))
+	assert.Equal(t, "failed", Error(WithError(args, errors.New("failed"))))
+
+	terminated := Terminate(args, []byte("cached"))
+	assert.True(t, terminated.GetFields()[FieldTerminate].GetBoolValue())
+	assert.Equal(t, []byte("cached"), Response(terminated))
+	assert.Nil(t, args.GetFields()[FieldTerminate])
+
+	cacheable := Cacheable(terminated, []string{"client.remote"}, time.Minute)
+	assert.Equal(t, map[string]interface{}{"key": []interface{}{"client.remote"}, "ttl": "1m0s"},
+		cacheable.AsMap()[FieldCache])
+	assert.Nil(t, terminated.GetFields()[FieldCache])
+}
+
+// TestValidation tests that the dependencies of the result of the onValidate hook are
+// read back.
+func TestValidation(t *testing.T) {
+	dependencies := []Dependency{
+		{Name: "redis", OK: true},
+		{Name: "license", Error: "expired"},
+	}
+	result := Validation([]string{"invalid ttl"}, dependencies)
+	assert.Equal(t, []interface{}{"invalid ttl"}, result.AsMap()[FieldErrors])
+	assert.Equal(t, dependencies, Dependencies(result))
+	assert.Empty(t, Dependencies(Validation(nil, nil)))
+}
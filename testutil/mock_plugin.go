@@ -0,0 +1,439 @@
+// Package testutil provides helpers for testing GatewayD and its plugins without
+// building or downloading plugin binaries.
+package testutil
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	sdkPlugin "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin"
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/logging"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/go-plugin/runner"
+	"github.com/rs/zerolog"
+)
+
+var (
+	// ErrMockPluginStarted is returned if the mock plugin is served twice.
+	ErrMockPluginStarted = errors.New("mock plugin is already started")
+	// ErrMockPluginNotStarted is returned if the mock plugin fails to start serving.
+	ErrMockPluginNotStarted = errors.New("mock plugin failed to start")
+)
+
+// HookFunc replaces the default behavior of a hook, which returns its arguments.
+type HookFunc func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error)
+
+// HookBehavior configures how a hook of the mock plugin responds.
+type HookBehavior struct {
+	// Latency delays the response, unless the context of the call is canceled first.
+	Latency time.Duration
+	// Err fails the hook.
+	Err error
+	// Fields are set on the result, e.g. "terminate" or "response".
+	Fields map[string]interface{}
+	// Func returns the result, instead of the arguments.
+	Func HookFunc
+}
+
+// HookCall is a recorded call to a hook of the mock plugin.
+type HookCall struct {
+	Hook v1.HookName
+	Args map[string]interface{}
+}
+
+// MockPlugin is an in-process plugin that implements the gRPC plugin contract, with
+// configurable hook behaviors. It is served by go-plugin like a real plugin, so the
+// plugin registry calls it over gRPC:
+//
+//	mock := testutil.NewMockPlugin("mock", v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
+//	mock.SetBehavior(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, testutil.HookBehavior{
+//		Fields: map[string]interface{}{"terminate": true},
+//	})
+//	defer mock.Stop()
+//	if _, err := mock.Register(ctx, pluginRegistry); err != nil {
+//		t.Fatal(err)
+//	}
+type MockPlugin struct {
+	v1.UnimplementedGatewayDPluginServiceServer
+
+	ID           sdkPlugin.Identifier
+	Description  string
+	Hooks        []v1.HookName
+	Capabilities []plugin.Capability
+	Fields       []string
+	// Config is returned with the metadata of the plugin.
+	Config map[string]string
+
+	mu        sync.Mutex
+	behaviors map[v1.HookName]HookBehavior
+	calls     []HookCall
+	cancel    context.CancelFunc
+	closed    chan struct{}
+}
+
+var _ v1.GatewayDPluginServiceServer = (*MockPlugin)(nil)
+
+// NewMockPlugin returns a mock plugin that attaches to the hooks and returns their
+// arguments as is, until their behaviors are set.
+func NewMockPlugin(name string, hooks ...v1.HookName) *MockPlugin {
+	return &MockPlugin{
+		ID: sdkPlugin.Identifier{
+			Name:      name,
+			Version:   "0.0.0",
+			RemoteURL: "github.com/gatewayd-io/gatewayd/testutil",
+		},
+		Description: "Mock plugin for testing",
+		Hooks:       hooks,
+		Config:      map[string]string{},
+		behaviors:   map[v1.HookName]HookBehavior{},
+	}
+}
+
+// SetBehavior sets the behavior of the hook.
+func (m *MockPlugin) SetBehavior(hook v1.HookName, behavior HookBehavior) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.behaviors[hook] = behavior
+}
+
+// Calls returns the recorded calls to the hook.
+func (m *MockPlugin) Calls(hook v1.HookName) []HookCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := make([]HookCall, 0)
+	for _, call := range m.calls {
+		if call.Hook == hook {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset clears the recorded calls.
+func (m *MockPlugin) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+// Serve serves the plugin in the background and returns the config to attach a
+// go-plugin client to it. The plugin stops serving when the client is killed, or
+// when Stop is called.
+func (m *MockPlugin) Serve(logger zerolog.Logger) (*goplugin.ReattachConfig, error) {
+	m.mu.Lock()
+	if m.cancel != nil {
+		m.mu.Unlock()
+		return nil, ErrMockPluginStarted
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	closed := make(chan struct{})
+	m.cancel = cancel
+	m.closed = closed
+	m.mu.Unlock()
+
+	reattachCh := make(chan *goplugin.ReattachConfig, 1)
+	pluginImpl := &v1.Plugin{}
+	pluginImpl.Impl.GatewayDPluginServiceServer = m
+	go goplugin.Serve(&goplugin.ServeConfig{
+		HandshakeConfig: v1.Handshake,
+		Plugins: v1.GetPluginSetMap(map[string]goplugin.Plugin{
+			m.ID.Name: pluginImpl,
+		}),
+		GRPCServer: sdkPlugin.DefaultGRPCServer,
+		Logger:     logging.NewHcLogAdapter(&logger, m.ID.Name),
+		Test: &goplugin.ServeTestConfig{
+			Context:          ctx,
+			ReattachConfigCh: reattachCh,
+			CloseCh:          closed,
+		},
+	})
+
+	select {
+	case reattach := <-reattachCh:
+		// Attach to the plugin like to a process, so killing the client stops it.
+		reattach.Test = false
+		reattach.ReattachFunc = func() (runner.AttachedRunner, error) {
+			return &mockRunner{name: m.ID.Name, cancel: cancel, closed: closed}, nil
+		}
+		return reattach, nil
+	case <-closed:
+		return nil, ErrMockPluginNotStarted
+	}
+}
+
+// Register serves the plugin and adds it to the plugin registry, with the next
+// priority, and registers its hooks, like the registry does for the plugins it loads.
+func (m *MockPlugin) Register(ctx context.Context, reg *plugin.Registry) (*plugin.Plugin, error) {
+	reattach, err := m.Serve(reg.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	pluginImpl := &plugin.Plugin{
+		ID:          m.ID,
+		Description: m.Description,
+		Enabled:     true,
+		Config:      m.Config,
+		Hooks:       m.Hooks,
+		Priority:    sdkPlugin.Priority(config.PluginPriorityStart + uint(reg.Size())),
+		Client: goplugin.NewClient(&goplugin.ClientConfig{
+			HandshakeConfig:  v1.Handshake,
+			Plugins:          v1.GetPluginMap(m.ID.Name),
+			Reattach:         reattach,
+			AllowedProtocols: []goplugin.Protocol{goplugin.ProtocolGRPC},
+			Logger:           logging.NewHcLogAdapter(&reg.Logger, m.ID.Name),
+		}),
+	}
+	if _, err := pluginImpl.Start(); err != nil {
+		m.Stop()
+		return nil, err
+	}
+
+	if m.Capabilities != nil {
+		reg.SetCapabilities(pluginImpl.Priority, m.Capabilities)
+	}
+	if m.Fields != nil {
+		reg.SetHookFields(pluginImpl.Priority, m.Fields)
+	}
+	reg.Add(pluginImpl)
+	reg.RegisterHooks(ctx, pluginImpl.ID)
+
+	return pluginImpl, nil
+}
+
+// Stop stops serving the plugin and waits for it to stop.
+func (m *MockPlugin) Stop() {
+	m.mu.Lock()
+	cancel, closed := m.cancel, m.closed
+	m.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-closed
+	}
+}
+
+// handle records the call to the hook and responds with its behavior.
+func (m *MockPlugin) handle(ctx context.Context, hook v1.HookName, req *v1.Struct) (*v1.Struct, error) {
+	args := req.AsMap()
+
+	m.mu.Lock()
+	m.calls = append(m.calls, HookCall{Hook: hook, Args: args})
+	behavior := m.behaviors[hook]
+	m.mu.Unlock()
+
+	if behavior.Latency > 0 {
+		select {
+		case <-time.After(behavior.Latency):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if behavior.Err != nil {
+		return nil, behavior.Err
+	}
+
+	result := req.AsMap()
+	if behavior.Func != nil {
+		var err error
+		if result, err = behavior.Func(ctx, req.AsMap()); err != nil {
+			return nil, err
+		}
+	}
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+	for key, value := range behavior.Fields {
+		result[key] = value
+	}
+
+	return v1.NewStruct(plugin.CastToPrimitiveTypes(result))
+}
+
+// GetPluginConfig returns the metadata of the plugin.
+func (m *MockPlugin) GetPluginConfig(context.Context, *v1.Struct) (*v1.Struct, error) {
+	hooks := make([]interface{}, 0, len(m.Hooks))
+	for _, hook := range m.Hooks {
+		hooks = append(hooks, int32(hook))
+	}
+	capabilities := make([]interface{}, 0, len(m.Capabilities))
+	for _, capability := range m.Capabilities {
+		capabilities = append(capabilities, string(capability))
+	}
+	pluginConfig := make(map[string]interface{}, len(m.Config))
+	for key, value := range m.Config {
+		pluginConfig[key] = value
+	}
+
+	metadata := map[string]interface{}{
+		"id": map[string]interface{}{
+			"name":      m.ID.Name,
+			"version":   m.ID.Version,
+			"remoteUrl": m.ID.RemoteURL,
+		},
+		"description":  m.Description,
+		"authors":      []interface{}{"GatewayD"},
+		"license":      "AGPL-3.0",
+		"projectUrl":   m.ID.RemoteURL,
+		"config":       pluginConfig,
+		"hooks":        hooks,
+		"capabilities": capabilities,
+		"requires":     []interface{}{},
+	}
+	if m.Fields != nil {
+		fields := make([]interface{}, 0, len(m.Fields))
+		for _, field := range m.Fields {
+			fields = append(fields, field)
+		}
+		metadata["fields"] = fields
+	}
+
+	return v1.NewStruct(metadata)
+}
+
+// OnConfigLoaded is called when the config is loaded.
+func (m *MockPlugin) OnConfigLoaded(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_CONFIG_LOADED, req)
+}
+
+// OnNewLogger is called when a new logger is created.
+func (m *MockPlugin) OnNewLogger(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_NEW_LOGGER, req)
+}
+
+// OnNewPool is called when a new pool is created.
+func (m *MockPlugin) OnNewPool(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_NEW_POOL, req)
+}
+
+// OnNewClient is called when a new client is created.
+func (m *MockPlugin) OnNewClient(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_NEW_CLIENT, req)
+}
+
+// OnNewProxy is called when a new proxy is created.
+func (m *MockPlugin) OnNewProxy(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_NEW_PROXY, req)
+}
+
+// OnNewServer is called when a new server is created.
+func (m *MockPlugin) OnNewServer(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_NEW_SERVER, req)
+}
+
+// OnSignal is called when an OS signal is received.
+func (m *MockPlugin) OnSignal(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_SIGNAL, req)
+}
+
+// OnRun is called when the server is running.
+func (m *MockPlugin) OnRun(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_RUN, req)
+}
+
+// OnBooting is called when the server is booting.
+func (m *MockPlugin) OnBooting(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_BOOTING, req)
+}
+
+// OnBooted is called when the server is booted.
+func (m *MockPlugin) OnBooted(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_BOOTED, req)
+}
+
+// OnOpening is called when a new connection is opening.
+func (m *MockPlugin) OnOpening(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_OPENING, req)
+}
+
+// OnOpened is called when a new connection is opened.
+func (m *MockPlugin) OnOpened(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_OPENED, req)
+}
+
+// OnClosing is called when a connection is closing.
+func (m *MockPlugin) OnClosing(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_CLOSING, req)
+}
+
+// OnClosed is called when a connection is closed.
+func (m *MockPlugin) OnClosed(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_CLOSED, req)
+}
+
+// OnTraffic is called when the server receives traffic from a client.
+func (m *MockPlugin) OnTraffic(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_TRAFFIC, req)
+}
+
+// OnTrafficFromClient is called when the proxy receives traffic from a client.
+func (m *MockPlugin) OnTrafficFromClient(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, req)
+}
+
+// OnTrafficToServer is called when the proxy sends traffic to the database.
+func (m *MockPlugin) OnTrafficToServer(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_SERVER, req)
+}
+
+// OnTrafficFromServer is called when the proxy receives traffic from the database.
+func (m *MockPlugin) OnTrafficFromServer(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_SERVER, req)
+}
+
+// OnTrafficToClient is called when the proxy sends traffic to a client.
+func (m *MockPlugin) OnTrafficToClient(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_CLIENT, req)
+}
+
+// OnShutdown is called when the server is shutting down.
+func (m *MockPlugin) OnShutdown(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_SHUTDOWN, req)
+}
+
+// OnTick is called on every tick of the server.
+func (m *MockPlugin) OnTick(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_TICK, req)
+}
+
+// OnHook is called for the custom hooks.
+func (m *MockPlugin) OnHook(ctx context.Context, req *v1.Struct) (*v1.Struct, error) {
+	return m.handle(ctx, v1.HookName_HOOK_NAME_ON_HOOK, req)
+}
+
+// mockRunner lets go-plugin stop the mock plugin like it kills a plugin process.
+type mockRunner struct {
+	name   string
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+func (r *mockRunner) Wait(context.Context) error {
+	<-r.closed
+	return nil
+}
+
+func (r *mockRunner) Kill(context.Context) error {
+	r.cancel()
+	<-r.closed
+	return nil
+}
+
+func (r *mockRunner) ID() string {
+	return r.name
+}
+
+func (r *mockRunner) PluginToHost(pluginNet, pluginAddr string) (string, string, error) {
+	return pluginNet, pluginAddr, nil
+}
+
+func (r *mockRunner) HostToPlugin(hostNet, hostAddr string) (string, string, error) {
+	return hostNet, hostAddr, nil
+}
@@ -0,0 +1,124 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "github.com/gatewayd-io/gatewayd-plugin-sdk/plugin/v1"
+	"github.com/gatewayd-io/gatewayd/config"
+	"github.com/gatewayd-io/gatewayd/plugin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPluginRegistry() *plugin.Registry {
+	return plugin.NewRegistry(
+		context.Background(),
+		config.Loose,
+		config.PassDown,
+		config.Accept,
+		config.Stop,
+		zerolog.Nop(),
+		false,
+	)
+}
+
+// TestMockPlugin tests that the registry runs the hooks of the mock plugin over gRPC.
+func TestMockPlugin(t *testing.T) {
+	ctx := context.Background()
+	reg := newPluginRegistry()
+
+	mock := NewMockPlugin(
+		"mock",
+		v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT,
+		v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_SERVER,
+	)
+	defer mock.Stop()
+	mock.SetBehavior(v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_SERVER, HookBehavior{
+		Fields: map[string]interface{}{"terminate": true},
+	})
+
+	pluginImpl, err := mock.Register(ctx, reg)
+	require.NoError(t, err)
+	assert.Equal(t, "mock", reg.List()[0].Name)
+	assert.Len(t, reg.Hooks()[v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT], 1)
+	require.Nil(t, pluginImpl.Ping())
+
+	pluginV1, dispenseErr := pluginImpl.Dispense()
+	require.Nil(t, dispenseErr)
+	metadata, err := pluginV1.GetPluginConfig(ctx, &v1.Struct{})
+	require.NoError(t, err)
+	assert.Equal(t, "mock", metadata.AsMap()["id"].(map[string]interface{})["name"])
+
+	args := map[string]interface{}{"query": "SELECT 1;"}
+	result, runErr := reg.Run(ctx, args, v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
+	require.Nil(t, runErr)
+	assert.Equal(t, args, result)
+
+	result, runErr = reg.Run(ctx, args, v1.HookName_HOOK_NAME_ON_TRAFFIC_TO_SERVER)
+	require.Nil(t, runErr)
+	assert.Equal(t, true, result["terminate"])
+
+	calls := mock.Calls(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
+	require.Len(t, calls, 1)
+	assert.Equal(t, args, calls[0].Args)
+	mock.Reset()
+	assert.Empty(t, mock.Calls(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT))
+
+	// Killing the client stops the plugin.
+	reg.Shutdown()
+	select {
+	case <-mock.closed:
+	case <-time.After(time.Second):
+		t.Fatal("Mock plugin didn't stop")
+	}
+}
+
+// TestMockPluginBehaviors tests the latencies, failures and functions of the hooks.
+func TestMockPluginBehaviors(t *testing.T) {
+	ctx := context.Background()
+	reg := newPluginRegistry()
+
+	mock := NewMockPlugin("mock", v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT)
+	defer mock.Stop()
+	pluginImpl, err := mock.Register(ctx, reg)
+	require.NoError(t, err)
+	defer reg.Shutdown()
+
+	pluginV1, dispenseErr := pluginImpl.Dispense()
+	require.Nil(t, dispenseErr)
+	args, err := v1.NewStruct(map[string]interface{}{"query": "SELECT 1;"})
+	require.NoError(t, err)
+
+	mock.SetBehavior(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, HookBehavior{
+		Func: func(_ context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			args["query"] = "SELECT 2;"
+			return args, nil
+		},
+	})
+	result, err := pluginV1.OnTrafficFromClient(ctx, args)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 2;", result.AsMap()["query"])
+
+	mock.SetBehavior(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, HookBehavior{
+		Err: errors.New("hook failed"),
+	})
+	_, err = pluginV1.OnTrafficFromClient(ctx, args)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hook failed")
+
+	mock.SetBehavior(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT, HookBehavior{
+		Latency: time.Second,
+	})
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = pluginV1.OnTrafficFromClient(timeoutCtx, args)
+	require.Error(t, err)
+	assert.Len(t, mock.Calls(v1.HookName_HOOK_NAME_ON_TRAFFIC_FROM_CLIENT), 3)
+
+	_, err = mock.Serve(zerolog.Nop())
+	assert.ErrorIs(t, err, ErrMockPluginStarted)
+}